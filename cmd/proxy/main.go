@@ -2,17 +2,20 @@ package main
 
 import (
 	"context"
-	"crypto/ed25519"
 	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"flag"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"sort"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"modern_reverse_proxy/internal/admin"
@@ -20,8 +23,11 @@ import (
 	"modern_reverse_proxy/internal/breaker"
 	"modern_reverse_proxy/internal/bundle"
 	"modern_reverse_proxy/internal/cache"
+	"modern_reverse_proxy/internal/concurrency"
 	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/handoff"
 	"modern_reverse_proxy/internal/limits"
+	"modern_reverse_proxy/internal/logging"
 	"modern_reverse_proxy/internal/obs"
 	"modern_reverse_proxy/internal/outlier"
 	"modern_reverse_proxy/internal/plugin"
@@ -31,12 +37,21 @@ import (
 	"modern_reverse_proxy/internal/registry"
 	"modern_reverse_proxy/internal/rollout"
 	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/secrets"
 	"modern_reverse_proxy/internal/server"
+	"modern_reverse_proxy/internal/stream"
 	"modern_reverse_proxy/internal/traffic"
 )
 
+var logger = logging.For("main")
+
 func main() {
-	configFile := flag.String("config-file", "", "Path to JSON config")
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		os.Exit(runValidate(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
+	configFile := flag.String("config-file", "", "Path to JSON or YAML config")
+	configDir := flag.String("config-dir", "", "Directory of JSON/YAML config files merged in lexical order")
 	httpAddr := flag.String("http-addr", ":8080", "HTTP listen address")
 	tlsAddr := flag.String("tls-addr", "", "TLS listen address (empty disables TLS)")
 	adminAddr := flag.String("admin-addr", ":9000", "Admin listen address")
@@ -45,33 +60,85 @@ func main() {
 	pullURL := flag.String("pull-url", "", "Pull mode base URL")
 	pullIntervalMS := flag.Int("pull-interval-ms", 5000, "Pull mode interval in ms")
 	publicKeyFile := flag.String("public-key-file", "", "Public key file for signed bundles")
+	keyringFile := flag.String("keyring-file", "", "Keyring file with trusted public keys for signed bundles")
 	adminToken := flag.String("admin-token", "", "Admin API token")
 	logJSON := flag.Bool("log-json", true, "Emit JSON logs")
+	logLevel := flag.String("log-level", "info", "Minimum log level (debug, info, warn, error)")
+	failSafe := flag.Bool("fail-safe", false, "Serve 503s and retry config load instead of exiting on invalid config")
+	readinessPath := flag.String("readiness-path", "", "Data-plane path that reports readiness (empty disables)")
+	printSchema := flag.Bool("print-schema", false, "Print the config JSON Schema and exit")
+	shutdownTimeout := flag.Duration("shutdown-timeout", 30*time.Second, "Maximum time to wait for graceful shutdown before exiting non-zero")
 	flag.Parse()
 
-	configureLogging(*logJSON)
+	if *readinessPath != "" && !strings.HasPrefix(*readinessPath, "/") {
+		log.Fatalf("readiness-path must start with /")
+	}
 
-	cfg, err := loadConfig(*configFile)
+	if *printSchema {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(config.Schema()); err != nil {
+			log.Fatalf("print schema: %v", err)
+		}
+		return
+	}
+
+	secretsManager, err := newSecretsManager()
 	if err != nil {
-		log.Fatalf("load config: %v", err)
+		log.Fatalf("secrets: %v", err)
+	}
+	secrets.SetDefault(secretsManager)
+	if err := configureLogging(*logJSON, *logLevel, secretsManager); err != nil {
+		log.Fatalf("logging: %v", err)
+	}
+
+	configSource, err := newConfigSource(*configFile, *configDir)
+	if err != nil {
+		log.Fatalf("config source: %v", err)
+	}
+	cfg, loadErr := loadConfig(configSource)
+	if loadErr != nil && !*failSafe {
+		log.Fatalf("load config: %v", loadErr)
 	}
 	reg := registry.NewRegistry(0, 0)
 	retryReg := registry.NewRetryRegistry(0, 0)
-	metrics := obs.NewMetrics(obs.MetricsConfig{})
+	metrics := obs.NewMetrics(metricsOptionsFromConfig(cfg))
 	obs.SetDefaultMetrics(metrics)
+	tracer, err := newTracer(secretsManager)
+	if err != nil {
+		log.Fatalf("tracing: %v", err)
+	}
+	obs.SetDefaultTracer(tracer)
+	reg.SetDrainCutoffObserver(metrics.RecordDrainCutoff)
+	reg.SetWarmupObserver(metrics.RecordUpstreamWarmup)
 	breakerReg := breaker.NewRegistry(0, 0)
+	concurrencyReg := concurrency.NewRegistry(0, 0)
+	concurrencyReg.SetLimitObserver(metrics.SetConcurrencyLimit)
 	outlierReg := outlier.NewRegistry(0, 0, metrics.RecordOutlierEjection)
 	trafficReg := traffic.NewRegistry(0, 0)
+	trafficReg.SetRampObserver(metrics.RecordTrafficRamp)
+	trafficReg.SetDrainObserver(metrics.SetDrainedCohorts)
 	pluginReg := plugin.NewRegistry(0)
 
-	publicKey, err := loadPublicKey(*publicKeyFile)
+	keyring, err := loadKeyring(*keyringFile, *publicKeyFile)
 	if err != nil {
-		log.Fatalf("public key: %v", err)
+		log.Fatalf("keyring: %v", err)
 	}
 
-	snap, err := runtime.BuildSnapshot(cfg, reg, breakerReg, outlierReg, trafficReg)
-	if err != nil {
-		log.Fatalf("build snapshot: %v", err)
+	var snap *runtime.Snapshot
+	if loadErr == nil {
+		snap, loadErr = runtime.BuildSnapshot(cfg, reg, breakerReg, outlierReg, trafficReg)
+		if loadErr != nil && !*failSafe {
+			log.Fatalf("build snapshot: %v", loadErr)
+		}
+	}
+	if loadErr != nil {
+		logger.Warn("config_load", "config_load_result", "error", "fail_safe", true, "reason", loadErr)
+		cfg = &config.Config{}
+		snap, err = runtime.FailSafeSnapshot(reg, trafficReg)
+		if err != nil {
+			log.Fatalf("build fail-safe snapshot: %v", err)
+		}
 	}
 
 	store := runtime.NewStore(snap)
@@ -79,14 +146,41 @@ func main() {
 	if err != nil {
 		log.Fatalf("shutdown config: %v", err)
 	}
+	statePersistence, err := runtime.StatePersistenceFromConfig(cfg.StatePersistence)
+	if err != nil {
+		log.Fatalf("state persistence config: %v", err)
+	}
+	statePersister := runtime.NewStatePersister(statePersistence, breakerReg, outlierReg)
+	if restored, err := statePersister.Restore(); err != nil {
+		logger.Warn("state_restore", "state_restore_result", "error", "path", statePersistence.Path, "reason", err)
+	} else if restored.Stale {
+		logger.Warn("state_restore", "state_restore_result", "stale", "path", statePersistence.Path, "max_age_ms", statePersistence.MaxAge.Milliseconds())
+	} else if statePersister != nil {
+		logger.Info("state_restore", "state_restore_result", "success", "path", statePersistence.Path, "breakers", restored.Breakers, "outliers", restored.Outliers)
+	}
+	http3Config, err := runtime.HTTP3FromConfig(cfg.TLS)
+	if err != nil {
+		log.Fatalf("http3 config: %v", err)
+	}
 	inflight := runtime.NewInflightTracker()
-	cacheStore := cache.NewMemoryStore(cache.DefaultMaxObjectBytes)
+	slowRoutes := obs.NewSlowRouteTracker()
+	trafficTap := obs.NewTap()
+	cacheStore, err := cache.NewStore(cache.StoreConfig{
+		Backend:        cfg.Cache.Backend,
+		Dir:            cfg.Cache.Dir,
+		MaxBytes:       cfg.Cache.MaxBytes,
+		MaxObjectBytes: cfg.Cache.MaxObjectBytes,
+		MemoryShards:   cfg.Cache.MemoryShards,
+	})
+	if err != nil {
+		log.Fatalf("cache store: %v", err)
+	}
 	cacheCoalescer := cache.NewCoalescer(cache.DefaultMaxFlights)
 	cacheLayer := cache.NewCache(cacheStore, cacheCoalescer)
 	adminProvider := provider.NewAdminPush()
 	providers := []provider.Provider{adminProvider}
-	if *configFile != "" {
-		providers = append(providers, provider.NewFileProvider(*configFile))
+	if configSource != nil {
+		providers = append(providers, configSource)
 	}
 	applyManager := apply.NewManager(apply.ManagerConfig{
 		Store:           store,
@@ -107,23 +201,28 @@ func main() {
 		ErrorRatePercent: parseFloatEnv(os.Getenv("ROLLOUT_ERROR_PERCENT"), 1),
 	})
 	engine := proxy.NewEngine(reg, retryReg, metrics, breakerReg, outlierReg)
+	adminStore := admin.NewStore()
 	handler := &proxy.Handler{
 		Store:           store,
 		Registry:        reg,
 		RetryRegistry:   retryReg,
 		BreakerRegistry: breakerReg,
+		Concurrency:     concurrencyReg,
 		OutlierRegistry: outlierReg,
 		PluginRegistry:  pluginReg,
 		Engine:          engine,
 		Metrics:         metrics,
 		Cache:           cacheLayer,
 		Inflight:        inflight,
+		SlowRoutes:      slowRoutes,
+		Tap:             trafficTap,
+		Maintenance:     adminStore,
 	}
 
 	metricsEndpoint := resolveMetricsConfig(cfg)
 	metricsHandler := metrics.Handler()
 	if metricsEndpoint.requireToken {
-		metricsToken := os.Getenv(metricsEndpoint.tokenEnv)
+		metricsToken := secrets.LookupEnv(metricsEndpoint.tokenEnv)
 		metricsHandler = server.RequireBearerToken(metricsHandler, true, metricsToken)
 	}
 
@@ -131,6 +230,9 @@ func main() {
 	if metricsEndpoint.enabled {
 		mux.Handle(metricsEndpoint.path, metricsHandler)
 	}
+	if *readinessPath != "" {
+		mux.Handle(*readinessPath, server.ReadinessHandler(store))
+	}
 	mux.Handle("/", handler)
 
 	var tlsBaseConfig *tls.Config
@@ -138,23 +240,91 @@ func main() {
 		tlsBaseConfig = server.BaseTLSConfig(store)
 	}
 
-	stoppers := []server.Stopper{reg, retryReg, breakerReg, outlierReg, trafficReg, pluginReg}
+	stoppers := append([]server.Stopper{startStreamListeners(snap, store, reg, outlierReg, metrics)}, reg, retryReg, breakerReg, concurrencyReg, outlierReg, trafficReg, pluginReg)
+	secretsCtx, secretsCancel := context.WithCancel(context.Background())
+	stoppers = append(stoppers, server.StopFunc(func(ctx context.Context) error {
+		secretsCancel()
+		return nil
+	}))
+	go secretsManager.Run(secretsCtx)
+	if statePersister != nil {
+		stateCtx, stateCancel := context.WithCancel(context.Background())
+		stoppers = append(stoppers, server.StopFunc(func(ctx context.Context) error {
+			stateCancel()
+			return statePersister.Stop(ctx)
+		}))
+		go statePersister.Run(stateCtx)
+	}
+	if cfg != nil && cfg.Metrics != nil && cfg.Metrics.Push != nil {
+		pusher, err := newMetricsPusher(metrics, cfg.Metrics.Push, secretsManager)
+		if err != nil {
+			log.Fatalf("metrics push: %v", err)
+		}
+		pushCtx, pushCancel := context.WithCancel(context.Background())
+		pushDone := make(chan struct{})
+		stoppers = append(stoppers, server.StopFunc(func(ctx context.Context) error {
+			pushCancel()
+			select {
+			case <-pushDone:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}))
+		go func() {
+			defer close(pushDone)
+			pusher.Run(pushCtx)
+		}()
+	}
+	if snap.FailSafe {
+		retryCtx, retryCancel := context.WithCancel(context.Background())
+		stoppers = append(stoppers, server.StopFunc(func(ctx context.Context) error {
+			retryCancel()
+			return nil
+		}))
+		go retryConfigLoad(retryCtx, store, func(ctx context.Context) (*runtime.Snapshot, error) {
+			result, err := applyManager.Reload(ctx, "retry")
+			if err != nil {
+				return nil, err
+			}
+			return result.Snapshot, nil
+		}, parseDurationMS(os.Getenv("CONFIG_RETRY_INITIAL_MS"), 500*time.Millisecond), parseDurationMS(os.Getenv("CONFIG_RETRY_MAX_MS"), 30*time.Second))
+	}
+	var certReloads chan struct{}
+	if snap.TLSEnabled {
+		certCtx, certCancel := context.WithCancel(context.Background())
+		stoppers = append(stoppers, server.StopFunc(func(ctx context.Context) error {
+			certCancel()
+			return nil
+		}))
+		certReloads = make(chan struct{}, 1)
+		go runtime.NewCertWatcher(store, parseDurationMS(os.Getenv("CERT_RELOAD_INTERVAL_MS"), 10*time.Second), metrics.RecordCertReload).Run(certCtx, certReloads)
+	}
+	var puller *pull.Puller
 	if *enablePull {
-		if *pullURL == "" {
-			log.Fatalf("pull-url is required when pull mode is enabled")
+		streamAddr := strings.TrimSpace(os.Getenv("PULL_STREAM_ADDR"))
+		if *pullURL == "" && streamAddr == "" {
+			log.Fatalf("pull-url or PULL_STREAM_ADDR is required when pull mode is enabled")
+		}
+		if keyring.Len() == 0 {
+			log.Fatalf("public-key-file or keyring-file is required for pull mode")
 		}
-		if len(publicKey) == 0 {
-			log.Fatalf("public-key-file is required for pull mode")
+		pullToken, pullTokenFunc, err := resolveToken(secretsManager, os.Getenv("PULL_TOKEN"))
+		if err != nil {
+			log.Fatalf("pull token: %v", err)
 		}
-		puller := pull.NewPuller(pull.Config{
+		puller = pull.NewPuller(pull.Config{
 			Enabled:        true,
 			BaseURL:        *pullURL,
 			Interval:       time.Duration(*pullIntervalMS) * time.Millisecond,
 			Jitter:         parseDurationMS(os.Getenv("PULL_JITTER_MS"), 500*time.Millisecond),
-			PublicKey:      publicKey,
+			Keyring:        keyring,
 			RolloutManager: rolloutManager,
 			Store:          store,
-			Token:          os.Getenv("PULL_TOKEN"),
+			Token:          pullToken,
+			TokenFunc:      pullTokenFunc,
+			StreamAddr:     streamAddr,
+			NodeID:         os.Getenv("PULL_NODE_ID"),
 		})
 		pullCtx, pullCancel := context.WithCancel(context.Background())
 		stoppers = append(stoppers, server.StopFunc(func(ctx context.Context) error {
@@ -163,6 +333,19 @@ func main() {
 		}))
 		go puller.Run(pullCtx)
 	}
+	if diskStore, ok := cacheStore.(*cache.DiskStore); ok {
+		stoppers = append(stoppers, server.StopFunc(func(ctx context.Context) error {
+			return diskStore.Close()
+		}))
+	}
+	stoppers = append(stoppers, server.StopFunc(func(ctx context.Context) error {
+		obs.CloseAccessLogSinks()
+		tracer.Close()
+		return nil
+	}))
+
+	signals := make(chan os.Signal, 4)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP, syscall.SIGUSR2)
 
 	serverHandle, err := server.StartServers(mux, tlsBaseConfig, *httpAddr, *tlsAddr, server.Options{
 		Limits:   snap.Limits,
@@ -172,51 +355,349 @@ func main() {
 		CloseIdle: []func(){
 			engine.CloseIdleConnections,
 		},
+		HTTP3:     http3Config,
+		Listeners: namedListeners(snap),
 	})
 	if err != nil {
 		log.Fatalf("start servers: %v", err)
 	}
 	if serverHandle.HTTPAddr != "" {
-		log.Printf("listening on http://%s", serverHandle.HTTPAddr)
+		logger.Info("listening", "scheme", "http", "addr", serverHandle.HTTPAddr)
 	}
 	if serverHandle.TLSAddr != "" {
-		log.Printf("listening on https://%s", serverHandle.TLSAddr)
+		logger.Info("listening", "scheme", "https", "addr", serverHandle.TLSAddr)
+	}
+	if serverHandle.HTTP3Addr != "" {
+		logger.Info("listening", "scheme", "h3", "addr", serverHandle.HTTP3Addr)
+	}
+	for _, listener := range namedListeners(snap) {
+		scheme := "http"
+		if listener.TLS {
+			scheme = "https"
+		}
+		logger.Info("listening", "scheme", scheme, "addr", serverHandle.ListenerAddrs[listener.Name], "listener", listener.Name)
 	}
 
-	if err := startAdmin(*enableAdmin, *adminAddr, *adminToken, store, applyManager, publicKey, rolloutManager); err != nil {
+	adminServer, err := startAdmin(*enableAdmin, *adminAddr, *adminToken, store, adminStore, reg, outlierReg, breakerReg, applyManager, keyring, rolloutManager, puller, slowRoutes, trafficTap, handler)
+	if err != nil {
 		log.Fatalf("admin: %v", err)
 	}
 
-	select {}
+	if handoff.Inherited() {
+		logger.Info("upgrade_ready", "pid", os.Getpid())
+	}
+	if err := handoff.Ready(); err != nil {
+		logger.Warn("upgrade_ready", "upgrade_ready_result", "error", "reason", err)
+	}
+	notifySystemd("READY=1")
+	go handoff.RunWatchdog(context.Background(), func(err error) {
+		logger.Warn("systemd_watchdog", "systemd_watchdog_result", "error", "reason", err)
+	})
+
+	reason := waitForShutdown(signals, applyManager, certReloads)
+	logger.Info("shutdown_signal", "shutdown_signal", reason, "timeout_ms", shutdownTimeout.Milliseconds())
+	if reason != "upgrade" {
+		notifySystemd("STOPPING=1")
+	}
+	os.Exit(shutdownServers(signals, *shutdownTimeout, serverHandle, adminServer))
+}
+
+func waitForShutdown(signals <-chan os.Signal, applyManager *apply.Manager, certReloads chan<- struct{}) string {
+	for sig := range signals {
+		switch sig {
+		case syscall.SIGHUP:
+			notifySystemd("RELOADING=1")
+			reloadOnHangup(applyManager, certReloads)
+			notifySystemd("READY=1")
+		case syscall.SIGUSR2:
+			pid, err := handoff.Upgrade(handoff.UpgradeConfig{
+				Args:    os.Args[1:],
+				Timeout: parseDurationMS(os.Getenv("UPGRADE_TIMEOUT_MS"), handoff.DefaultTimeout),
+			})
+			if err != nil {
+				logger.Warn("upgrade", "upgrade_result", "error", "reason", err)
+				continue
+			}
+			logger.Info("upgrade", "upgrade_result", "success", "new_pid", pid)
+			notifySystemd(fmt.Sprintf("MAINPID=%d", pid))
+			return "upgrade"
+		default:
+			return sig.String()
+		}
+	}
+	return "closed"
+}
+
+func notifySystemd(state string) {
+	if err := handoff.Notify(state); err != nil {
+		logger.Warn("systemd_notify", "systemd_notify_result", "error", "state", state, "reason", err)
+	}
+}
+
+func reloadOnHangup(applyManager *apply.Manager, certReloads chan<- struct{}) {
+	if certReloads != nil {
+		select {
+		case certReloads <- struct{}{}:
+		default:
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	result, err := applyManager.Reload(ctx, "sighup")
+	if err != nil {
+		logger.Warn("config_reload", "config_reload_result", "error", "trigger", "sighup", "reason", err)
+		return
+	}
+	logger.Info("config_reload", "config_reload_result", "success", "trigger", "sighup", "version", result.Version)
+}
+
+func shutdownServers(signals <-chan os.Signal, timeout time.Duration, servers ...*server.Server) int {
+	done := make(chan error, 1)
+	go func() {
+		var firstErr error
+		for _, srv := range servers {
+			if err := srv.Shutdown(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		done <- firstErr
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				logger.Warn("shutdown", "shutdown_result", "error", "reason", err)
+				return 1
+			}
+			logger.Info("shutdown", "shutdown_result", "success")
+			return 0
+		case <-timer.C:
+			logger.Warn("shutdown", "shutdown_result", "timeout")
+			return 1
+		case sig := <-signals:
+			if sig == syscall.SIGHUP {
+				continue
+			}
+			logger.Warn("shutdown", "shutdown_result", "forced", "signal", sig.String())
+			return 1
+		}
+	}
+}
+
+func namedListeners(snap *runtime.Snapshot) []runtime.Listener {
+	listeners := make([]runtime.Listener, 0, len(snap.Listeners))
+	for _, listener := range snap.Listeners {
+		listeners = append(listeners, listener)
+	}
+	sort.Slice(listeners, func(i, j int) bool {
+		return listeners[i].Name < listeners[j].Name
+	})
+	return listeners
+}
+
+func startStreamListeners(snap *runtime.Snapshot, store *runtime.Store, reg *registry.Registry, outlierReg *outlier.Registry, metrics *obs.Metrics) *stream.Manager {
+	streams := stream.NewManager(stream.ManagerConfig{
+		Store:           store,
+		Registry:        reg,
+		OutlierRegistry: outlierReg,
+		Metrics:         metrics,
+	})
+	if err := streams.Reconcile(snap); err != nil {
+		log.Fatalf("stream listeners: %v", err)
+	}
+	store.OnSwap(func(next *runtime.Snapshot) {
+		_ = streams.Reconcile(next)
+	})
+	return streams
+}
+
+func loadKeyring(keyringPath string, publicKeyPath string) (*bundle.Keyring, error) {
+	if keyringPath == "" {
+		keyringPath = os.Getenv("KEYRING_FILE")
+	}
+	if secrets.IsRef(keyringPath) {
+		data, err := secrets.Default().Resolve(context.Background(), keyringPath)
+		if err != nil {
+			return nil, err
+		}
+		return bundle.ParseKeyring([]byte(data))
+	}
+	if keyringPath != "" {
+		return bundle.LoadKeyring(keyringPath)
+	}
+	if publicKeyPath == "" {
+		publicKeyPath = os.Getenv("PUBLIC_KEY_FILE")
+	}
+	if publicKeyPath == "" {
+		return bundle.NewKeyring(), nil
+	}
+	if secrets.IsRef(publicKeyPath) {
+		data, err := secrets.Default().Resolve(context.Background(), publicKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		publicKey, err := bundle.ParsePublicKey([]byte(data))
+		if err != nil {
+			return nil, err
+		}
+		return bundle.NewKeyring(publicKey), nil
+	}
+	publicKey, err := bundle.LoadPublicKey(publicKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	return bundle.NewKeyring(publicKey), nil
+}
+
+func newSecretsManager() (*secrets.Manager, error) {
+	var source secrets.Source
+	switch kind := strings.ToLower(strings.TrimSpace(os.Getenv("SECRETS_SOURCE"))); kind {
+	case "", "env":
+		source = &secrets.EnvSource{Prefix: os.Getenv("SECRETS_ENV_PREFIX")}
+	case "file":
+		dir := strings.TrimSpace(os.Getenv("SECRETS_DIR"))
+		if dir == "" {
+			return nil, errors.New("SECRETS_DIR is required for file secrets source")
+		}
+		source = &secrets.FileSource{Dir: dir}
+	case "vault":
+		addr := strings.TrimSpace(os.Getenv("VAULT_ADDR"))
+		if addr == "" {
+			return nil, errors.New("VAULT_ADDR is required for vault secrets source")
+		}
+		source = &secrets.VaultSource{Addr: addr, Token: os.Getenv("VAULT_TOKEN"), Mount: os.Getenv("VAULT_KV_MOUNT")}
+	default:
+		return nil, fmt.Errorf("unsupported SECRETS_SOURCE %q", kind)
+	}
+	return secrets.NewManager(secrets.Config{
+		Source:  source,
+		Refresh: parseDurationMS(os.Getenv("SECRETS_REFRESH_MS"), secrets.DefaultRefresh),
+	}), nil
 }
 
-func loadPublicKey(path string) (ed25519.PublicKey, error) {
-	if path == "" {
-		path = os.Getenv("PUBLIC_KEY_FILE")
+func newTracer(manager *secrets.Manager) (*obs.Tracer, error) {
+	endpoint := strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"))
+	if endpoint == "" {
+		endpoint = strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"))
 	}
-	if path == "" {
+	if endpoint == "" {
 		return nil, nil
 	}
-	return bundle.LoadPublicKey(path)
+	sampler, err := obs.ParseSampler(os.Getenv("OTEL_TRACES_SAMPLER"), os.Getenv("OTEL_TRACES_SAMPLER_ARG"))
+	if err != nil {
+		return nil, err
+	}
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"), ",") {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			continue
+		}
+		value, err = manager.Resolve(context.Background(), strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("otlp header %s: %w", strings.TrimSpace(name), err)
+		}
+		headers[strings.TrimSpace(name)] = value
+	}
+	return obs.NewTracer(obs.TracerConfig{
+		Endpoint:    endpoint,
+		Headers:     headers,
+		ServiceName: os.Getenv("OTEL_SERVICE_NAME"),
+		Sampler:     sampler,
+	})
 }
 
-func loadConfig(path string) (*config.Config, error) {
-	if path == "" {
+func newMetricsPusher(metrics *obs.Metrics, push *config.MetricsPushConfig, manager *secrets.Manager) (*obs.Pusher, error) {
+	headers := make(map[string]string, len(push.Headers))
+	for name, value := range push.Headers {
+		resolved, err := manager.Resolve(context.Background(), value)
+		if err != nil {
+			return nil, fmt.Errorf("header %s: %w", name, err)
+		}
+		headers[name] = resolved
+	}
+	return obs.NewPusher(metrics, obs.PushConfig{
+		Type:        push.Type,
+		Address:     push.Address,
+		Endpoint:    push.Endpoint,
+		Headers:     headers,
+		Prefix:      push.Prefix,
+		ServiceName: push.ServiceName,
+		Interval:    time.Duration(push.IntervalMS) * time.Millisecond,
+		Timeout:     time.Duration(push.TimeoutMS) * time.Millisecond,
+	})
+}
+
+func resolveToken(manager *secrets.Manager, value string) (string, func() string, error) {
+	if !secrets.IsRef(value) {
+		return value, nil, nil
+	}
+	token, err := manager.Resolve(context.Background(), value)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, manager.Func(value), nil
+}
+
+func retryConfigLoad(ctx context.Context, store *runtime.Store, reload func(context.Context) (*runtime.Snapshot, error), initial time.Duration, max time.Duration) {
+	delay := initial
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		if current := store.Get(); current != nil && !current.FailSafe {
+			logger.Info("config_retry", "config_retry_result", "superseded", "version", current.Version, "source", current.Source)
+			return
+		}
+		next, err := reload(ctx)
+		if err == nil {
+			logger.Info("config_retry", "config_retry_result", "success", "attempt", attempt, "version", next.Version)
+			return
+		}
+		delay *= 2
+		if delay > max {
+			delay = max
+		}
+		logger.Warn("config_retry", "config_retry_result", "error", "attempt", attempt, "next_retry_ms", delay.Milliseconds(), "reason", err)
+	}
+}
+
+func newConfigSource(file string, dir string) (provider.Provider, error) {
+	switch {
+	case file != "" && dir != "":
+		return nil, errors.New("config-file and config-dir are mutually exclusive")
+	case dir != "":
+		return provider.NewDirectoryProvider(dir), nil
+	case file != "":
+		return provider.NewFileProvider(file), nil
+	}
+	return nil, nil
+}
+
+func loadConfig(source provider.Provider) (*config.Config, error) {
+	if source == nil {
 		return &config.Config{}, nil
 	}
-	data, err := os.ReadFile(path)
+	cfg, err := source.Load(context.Background())
 	if err != nil {
 		return nil, err
 	}
-	return config.ParseJSON(data)
+	if cfg == nil {
+		return &config.Config{}, nil
+	}
+	return cfg, nil
 }
 
-func startAdmin(enabled bool, addr string, token string, store *runtime.Store, applyManager *apply.Manager, publicKey ed25519.PublicKey, rolloutManager *rollout.Manager) error {
+func startAdmin(enabled bool, addr string, token string, store *runtime.Store, adminStore *admin.Store, reg *registry.Registry, outlierReg *outlier.Registry, breakerReg *breaker.Registry, applyManager *apply.Manager, keyring *bundle.Keyring, rolloutManager *rollout.Manager, puller *pull.Puller, slowRoutes *obs.SlowRouteTracker, trafficTap *obs.Tap, proxyHandler http.Handler) (*server.Server, error) {
 	if !enabled {
-		return nil
+		return nil, nil
 	}
 	if addr == "" {
-		return errors.New("admin-addr is required when admin is enabled")
+		return nil, errors.New("admin-addr is required when admin is enabled")
 	}
 	adminToken := token
 	if adminToken == "" {
@@ -227,43 +708,65 @@ func startAdmin(enabled bool, addr string, token string, store *runtime.Store, a
 	adminCA := strings.TrimSpace(os.Getenv("ADMIN_CLIENT_CA_FILE"))
 	allowUnsigned := os.Getenv("ALLOW_UNSIGNED_ADMIN_CONFIG") == "true"
 	if adminToken == "" {
-		return errors.New("ADMIN_TOKEN is required for admin listener")
+		return nil, errors.New("ADMIN_TOKEN is required for admin listener")
 	}
 	if adminCert == "" || adminKey == "" {
-		return errors.New("ADMIN_TLS_CERT_FILE and ADMIN_TLS_KEY_FILE are required for admin listener")
+		return nil, errors.New("ADMIN_TLS_CERT_FILE and ADMIN_TLS_KEY_FILE are required for admin listener")
 	}
 	if adminCA == "" {
-		return errors.New("ADMIN_CLIENT_CA_FILE is required for admin listener")
+		return nil, errors.New("ADMIN_CLIENT_CA_FILE is required for admin listener")
 	}
-	auth, err := admin.NewAuthenticator(admin.AuthConfig{Token: adminToken, ClientCAFile: adminCA})
+	adminToken, adminTokenFunc, err := resolveToken(secrets.Default(), adminToken)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("admin token: %w", err)
+	}
+	auth, err := admin.NewAuthenticator(admin.AuthConfig{Token: adminToken, TokenFunc: adminTokenFunc, ClientCAFile: adminCA})
+	if err != nil {
+		return nil, err
 	}
 	adminTLS, err := admin.TLSConfig(adminCert, adminKey, adminCA)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	auditWriter := io.Writer(os.Stderr)
+	if path := strings.TrimSpace(os.Getenv("ADMIN_AUDIT_LOG_FILE")); path != "" {
+		auditFile, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("open admin audit log: %w", err)
+		}
+		auditWriter = auditFile
+	}
+	auditWriter = secrets.NewRedactingWriter(auditWriter, secrets.Default())
 	adminHandler := admin.NewHandler(admin.HandlerConfig{
 		Store:          store,
 		ApplyManager:   applyManager,
 		Auth:           auth,
 		RateLimiter:    admin.NewRateLimiter(admin.RateLimitConfig{}),
-		AdminStore:     admin.NewStore(),
-		PublicKey:      publicKey,
+		AdminStore:     adminStore,
+		Keyring:        keyring,
 		AllowUnsigned:  allowUnsigned,
 		RolloutManager: rolloutManager,
+		Puller:         puller,
+		Registry:       reg,
+		Outlier:        outlierReg,
+		Breakers:       breakerReg,
+		Audit:          admin.NewAuditLog(auditWriter, 0),
+		SlowRoutes:     slowRoutes,
+		Tap:            trafficTap,
+		Proxy:          proxyHandler,
 	})
 	adminServer, err := server.StartServers(adminHandler, adminTLS, "", addr, server.Options{
 		Limits:   limits.Default(),
 		Shutdown: runtime.DefaultShutdownConfig(),
+		Name:     "admin",
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if adminServer.TLSAddr != "" {
-		log.Printf("admin listening on https://%s", adminServer.TLSAddr)
+		logger.Info("admin_listening", "scheme", "https", "addr", adminServer.TLSAddr)
 	}
-	return nil
+	return adminServer, nil
 }
 
 func envOrFallback(primary string, fallback string) string {
@@ -274,42 +777,19 @@ func envOrFallback(primary string, fallback string) string {
 	return value
 }
 
-func configureLogging(jsonEnabled bool) {
-	if !jsonEnabled {
-		log.SetFlags(log.LstdFlags)
-		return
-	}
-	log.SetFlags(0)
-	log.SetOutput(&jsonLogWriter{writer: os.Stdout})
-}
-
-type jsonLogWriter struct {
-	writer io.Writer
-}
-
-func (j *jsonLogWriter) Write(p []byte) (int, error) {
-	msg := strings.TrimSpace(string(p))
-	if msg == "" {
-		return len(p), nil
-	}
-	entry := map[string]string{
-		"ts":  time.Now().UTC().Format(time.RFC3339Nano),
-		"msg": msg,
-	}
-	data, err := json.Marshal(entry)
+func configureLogging(jsonEnabled bool, level string, secretsManager *secrets.Manager) error {
+	obs.SetAccessLogRedactor(secretsManager.Redact)
+	parsed, err := logging.ParseLevel(level)
 	if err != nil {
-		_, writeErr := j.writer.Write(p)
-		if writeErr != nil {
-			return len(p), writeErr
-		}
-		return len(p), err
+		return err
 	}
-	data = append(data, '\n')
-	_, writeErr := j.writer.Write(data)
-	if writeErr != nil {
-		return len(p), writeErr
+	logging.SetLevel(parsed)
+	if !jsonEnabled {
+		logging.Configure(secrets.NewRedactingWriter(os.Stderr, secretsManager), false)
+		return nil
 	}
-	return len(p), nil
+	logging.Configure(secrets.NewRedactingWriter(os.Stdout, secretsManager), true)
+	return nil
 }
 
 func parseDurationMS(value string, fallback time.Duration) time.Duration {
@@ -341,6 +821,19 @@ type metricsEndpointConfig struct {
 	tokenEnv     string
 }
 
+func metricsOptionsFromConfig(cfg *config.Config) obs.MetricsConfig {
+	if cfg == nil || cfg.Metrics == nil || cfg.Metrics.Histograms == nil {
+		return obs.MetricsConfig{}
+	}
+	histograms := cfg.Metrics.Histograms
+	return obs.MetricsConfig{
+		DurationBuckets:           histograms.Buckets,
+		NativeHistograms:          histograms.Native,
+		NativeHistogramFactor:     histograms.NativeBucketFactor,
+		NativeHistogramMaxBuckets: uint32(histograms.NativeMaxBuckets),
+	}
+}
+
 func resolveMetricsConfig(cfg *config.Config) metricsEndpointConfig {
 	endpoint := metricsEndpointConfig{
 		enabled:      true,
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+
+	"modern_reverse_proxy/internal/configcheck"
+)
+
+func runValidate(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	configFile := flags.String("config-file", "", "Path to JSON or YAML config")
+	configDir := flags.String("config-dir", "", "Directory of JSON/YAML config files merged in lexical order")
+	bundleFile := flags.String("bundle-file", "", "Path to a signed config bundle")
+	publicKeyFile := flags.String("public-key-file", "", "Public key file for bundle signature verification")
+	keyringFile := flags.String("keyring-file", "", "Keyring file for bundle signature verification")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	log.SetOutput(io.Discard)
+	keyring, err := loadKeyring(*keyringFile, *publicKeyFile)
+	if err != nil {
+		fmt.Fprintf(stderr, "load keyring: %v\n", err)
+		return 2
+	}
+	report := configcheck.Run(context.Background(), configcheck.Input{
+		ConfigFile: *configFile,
+		ConfigDir:  *configDir,
+		BundleFile: *bundleFile,
+		Keyring:    keyring,
+	})
+	report.Print(stdout)
+	if !report.OK() {
+		return 1
+	}
+	return 0
+}
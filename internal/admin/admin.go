@@ -9,6 +9,12 @@ import (
 	"os"
 
 	"modern_reverse_proxy/internal/apply"
+	"modern_reverse_proxy/internal/breaker"
+	"modern_reverse_proxy/internal/bundle"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/outlier"
+	"modern_reverse_proxy/internal/pull"
+	"modern_reverse_proxy/internal/registry"
 	"modern_reverse_proxy/internal/rollout"
 	"modern_reverse_proxy/internal/runtime"
 )
@@ -20,31 +26,101 @@ type HandlerConfig struct {
 	RateLimiter    *RateLimiter
 	AdminStore     *Store
 	PublicKey      ed25519.PublicKey
+	Keyring        *bundle.Keyring
 	AllowUnsigned  bool
 	RolloutManager *rollout.Manager
+	Puller         *pull.Puller
+	Registry       *registry.Registry
+	Outlier        *outlier.Registry
+	Breakers       *breaker.Registry
+	Audit          *AuditLog
+	SlowRoutes     *obs.SlowRouteTracker
+	Tap            *obs.Tap
+	Proxy          http.Handler
 }
 
 func NewHandler(cfg HandlerConfig) http.Handler {
+	keyring := cfg.Keyring
+	if keyring == nil && len(cfg.PublicKey) == ed25519.PublicKeySize {
+		keyring = bundle.NewKeyring(cfg.PublicKey)
+	}
 	h := &handler{
 		store:         cfg.Store,
 		apply:         cfg.ApplyManager,
 		auth:          cfg.Auth,
 		rateLimiter:   cfg.RateLimiter,
 		adminStore:    cfg.AdminStore,
-		publicKey:     cfg.PublicKey,
+		keyring:       keyring,
 		allowUnsigned: cfg.AllowUnsigned,
 		rollout:       cfg.RolloutManager,
+		puller:        cfg.Puller,
+		registry:      cfg.Registry,
+		outlier:       cfg.Outlier,
+		breakers:      cfg.Breakers,
+		audit:         cfg.Audit,
+		slowRoutes:    cfg.SlowRoutes,
+		tap:           cfg.Tap,
+		proxy:         cfg.Proxy,
 	}
 	mux := http.NewServeMux()
-	mux.HandleFunc("/admin/validate", h.handleValidate)
-	mux.HandleFunc("/admin/config", h.handleApply)
-	mux.HandleFunc("/admin/bundle", h.handleBundle)
-	mux.HandleFunc("/admin/rollback", h.handleRollback)
-	mux.HandleFunc("/admin/snapshot", h.handleSnapshot)
+	for _, route := range h.routes() {
+		mux.HandleFunc(route.pattern, route.handler)
+	}
 	h.mux = mux
 	return h
 }
 
+type adminRoute struct {
+	pattern string
+	handler http.HandlerFunc
+}
+
+func (h *handler) routes() []adminRoute {
+	return []adminRoute{
+		{"/admin/validate", h.handleValidate},
+		{"/admin/config", h.handleApply},
+		{"/admin/transaction", h.handleTransaction},
+		{"/admin/bundle", h.handleBundle},
+		{"/admin/bundles", h.handleBundles},
+		{"/admin/bundles/{version}", h.handleBundleExport},
+		{"/admin/keyring", h.handleKeyring},
+		{"/admin/rollback", h.handleRollback},
+		{"/admin/snapshot", h.handleSnapshot},
+		{"/admin/pull/status", h.handlePullStatus},
+		{"/admin/routes", h.handleRoutes},
+		{"/admin/routes/{id}", h.handleRoute},
+		{"/admin/pools", h.handlePools},
+		{"/admin/pools/{name}", h.handlePool},
+		{"/admin/health", h.handleHealth},
+		{"/admin/certs", h.handleCerts},
+		{"/admin/pools/{name}/endpoints/{addr}/drain", h.handleEndpointAction(endpointDrain)},
+		{"/admin/pools/{name}/endpoints/{addr}/eject", h.handleEndpointAction(endpointEject)},
+		{"/admin/pools/{name}/endpoints/{addr}/restore", h.handleEndpointAction(endpointRestore)},
+		{"/admin/breakers", h.handleBreakers},
+		{"/admin/breakers/{pool}/reset", h.handleBreakerReset},
+		{"/admin/routes/{id}/disable", h.handleRouteDisable},
+		{"/admin/routes/{id}/enable", h.handleRouteEnable},
+		{"/admin/audit", h.handleAudit},
+		{"/admin/logging", h.handleLogging},
+		{"/admin/slow-routes", h.handleSlowRoutes},
+		{"/admin/tap", h.handleTap},
+		{"/admin/test-route", h.handleTestRoute},
+		{"/admin/debug/pprof/", h.handleDebugPprof},
+		{"/admin/debug/config", h.handleDebugConfig},
+		{"/admin/debug/stacks", h.handleDebugStacks},
+		{"/admin/openapi.json", h.handleOpenAPI},
+	}
+}
+
+func RoutePatterns() []string {
+	routes := (&handler{}).routes()
+	patterns := make([]string, 0, len(routes))
+	for _, route := range routes {
+		patterns = append(patterns, route.pattern)
+	}
+	return patterns
+}
+
 func TLSConfig(certFile string, keyFile string, clientCAFile string) (*tls.Config, error) {
 	if certFile == "" || keyFile == "" {
 		return nil, errors.New("admin cert and key are required")
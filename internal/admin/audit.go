@@ -0,0 +1,201 @@
+package admin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"modern_reverse_proxy/internal/proxy"
+)
+
+const (
+	defaultAuditRecent = 200
+	defaultAuditTail   = 50
+)
+
+type AuditEntry struct {
+	Timestamp     string `json:"ts"`
+	RequestID     string `json:"request_id"`
+	Method        string `json:"method"`
+	Path          string `json:"path"`
+	RemoteAddr    string `json:"remote_addr,omitempty"`
+	TokenHash     string `json:"token_hash,omitempty"`
+	ClientSubject string `json:"client_subject,omitempty"`
+	PayloadSHA256 string `json:"payload_sha256,omitempty"`
+	PayloadBytes  int64  `json:"payload_bytes"`
+	Status        int    `json:"status"`
+	Result        string `json:"result"`
+	DurationMS    int64  `json:"duration_ms"`
+}
+
+type AuditLog struct {
+	mu     sync.Mutex
+	writer io.Writer
+	recent []AuditEntry
+	next   int
+	full   bool
+}
+
+func NewAuditLog(writer io.Writer, recent int) *AuditLog {
+	if recent <= 0 {
+		recent = defaultAuditRecent
+	}
+	return &AuditLog{writer: writer, recent: make([]AuditEntry, recent)}
+}
+
+func (a *AuditLog) Record(entry AuditEntry) {
+	if a == nil {
+		return
+	}
+	data, err := json.Marshal(entry)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.recent[a.next] = entry
+	a.next = (a.next + 1) % len(a.recent)
+	if a.next == 0 {
+		a.full = true
+	}
+	if a.writer == nil {
+		return
+	}
+	if err != nil {
+		logger.Error("admin_audit_marshal_error", "request_id", entry.RequestID, "error", err)
+		return
+	}
+	if _, err := a.writer.Write(append(data, '\n')); err != nil {
+		logger.Error("admin_audit_write_error", "request_id", entry.RequestID, "error", err)
+	}
+}
+
+func (a *AuditLog) Recent(limit int) []AuditEntry {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	count := a.next
+	if a.full {
+		count = len(a.recent)
+	}
+	if limit <= 0 || limit > count {
+		limit = count
+	}
+	entries := make([]AuditEntry, 0, limit)
+	for i := count - limit; i < count; i++ {
+		idx := i
+		if a.full {
+			idx = (a.next + i) % len(a.recent)
+		}
+		entries = append(entries, a.recent[idx])
+	}
+	return entries
+}
+
+func (h *handler) serveAudited(w http.ResponseWriter, r *http.Request, requestID string, serve func(http.ResponseWriter, *http.Request)) {
+	start := time.Now()
+	var body *hashingReader
+	if r.Body != nil && r.Body != http.NoBody {
+		body = &hashingReader{reader: r.Body, hash: sha256.New()}
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{body, r.Body}
+	}
+	recorder := &auditRecorder{ResponseWriter: w, status: http.StatusOK}
+	serve(recorder, r)
+
+	entry := AuditEntry{
+		Timestamp:  start.UTC().Format(time.RFC3339Nano),
+		RequestID:  requestID,
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		RemoteAddr: r.RemoteAddr,
+		Status:     recorder.status,
+		Result:     auditResult(recorder.status),
+		DurationMS: time.Since(start).Milliseconds(),
+	}
+	if token, ok := bearerToken(r.Header.Get("Authorization")); ok && token != "" {
+		sum := sha256.Sum256([]byte(token))
+		entry.TokenHash = hex.EncodeToString(sum[:8])
+	}
+	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		entry.ClientSubject = r.TLS.PeerCertificates[0].Subject.String()
+	}
+	if body != nil && body.n > 0 {
+		entry.PayloadSHA256 = hex.EncodeToString(body.hash.Sum(nil))
+		entry.PayloadBytes = body.n
+	}
+	h.audit.Record(entry)
+}
+
+func (h *handler) handleAudit(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get(proxy.RequestIDHeader)
+	if r.Method != http.MethodGet {
+		writeError(w, requestID, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.audit == nil {
+		writeError(w, requestID, http.StatusServiceUnavailable, "audit log unavailable")
+		return
+	}
+	limit := defaultAuditTail
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			writeError(w, requestID, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		limit = parsed
+	}
+	writeJSON(w, requestID, http.StatusOK, map[string]interface{}{"entries": h.audit.Recent(limit)})
+}
+
+func auditResult(status int) string {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden || status == http.StatusTooManyRequests:
+		return "denied"
+	case status >= http.StatusBadRequest:
+		return "error"
+	default:
+		return "success"
+	}
+}
+
+type hashingReader struct {
+	reader io.Reader
+	hash   hash.Hash
+	n      int64
+}
+
+func (r *hashingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.hash.Write(p[:n])
+		r.n += int64(n)
+	}
+	return n, err
+}
+
+type auditRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *auditRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *auditRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
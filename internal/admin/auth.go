@@ -10,11 +10,13 @@ import (
 
 type AuthConfig struct {
 	Token        string
+	TokenFunc    func() string
 	ClientCAFile string
 }
 
 type Authenticator struct {
 	token     string
+	tokenFunc func() string
 	clientCAs *x509.CertPool
 }
 
@@ -32,6 +34,9 @@ func (e *AuthError) Error() string {
 
 func NewAuthenticator(cfg AuthConfig) (*Authenticator, error) {
 	token := strings.TrimSpace(cfg.Token)
+	if token == "" && cfg.TokenFunc != nil {
+		token = strings.TrimSpace(cfg.TokenFunc())
+	}
 	if token == "" {
 		return nil, errors.New("admin token is required")
 	}
@@ -48,7 +53,7 @@ func NewAuthenticator(cfg AuthConfig) (*Authenticator, error) {
 		}
 	}
 
-	return &Authenticator{token: token, clientCAs: pool}, nil
+	return &Authenticator{token: token, tokenFunc: cfg.TokenFunc, clientCAs: pool}, nil
 }
 
 func (a *Authenticator) Authenticate(r *http.Request) error {
@@ -77,12 +82,21 @@ func (a *Authenticator) Authenticate(r *http.Request) error {
 	if !ok || token == "" {
 		return &AuthError{Status: http.StatusUnauthorized, Message: "token required"}
 	}
-	if token != a.token {
+	if token != a.currentToken() {
 		return &AuthError{Status: http.StatusUnauthorized, Message: "token invalid"}
 	}
 	return nil
 }
 
+func (a *Authenticator) currentToken() string {
+	if a.tokenFunc != nil {
+		if token := strings.TrimSpace(a.tokenFunc()); token != "" {
+			return token
+		}
+	}
+	return a.token
+}
+
 func bearerToken(header string) (string, bool) {
 	if header == "" {
 		return "", false
@@ -0,0 +1,73 @@
+package admin
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/proxy"
+)
+
+type breakerView struct {
+	Pool               string     `json:"pool"`
+	State              string     `json:"state"`
+	Requests           int        `json:"requests"`
+	Failures           int        `json:"failures"`
+	FailureRatePercent int        `json:"failure_rate_percent"`
+	OpenUntil          *time.Time `json:"open_until,omitempty"`
+}
+
+func (h *handler) handleBreakers(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get(proxy.RequestIDHeader)
+	if r.Method != http.MethodGet {
+		writeError(w, requestID, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.breakers == nil {
+		writeError(w, requestID, http.StatusServiceUnavailable, "breaker registry unavailable")
+		return
+	}
+	statuses := h.breakers.Statuses()
+	views := make([]breakerView, 0, len(statuses))
+	for key, status := range statuses {
+		view := breakerView{
+			Pool:               key,
+			State:              status.State.String(),
+			Requests:           status.Requests,
+			Failures:           status.Failures,
+			FailureRatePercent: status.FailureRatePercent,
+		}
+		if !status.OpenUntil.IsZero() {
+			openUntil := status.OpenUntil.UTC()
+			view.OpenUntil = &openUntil
+		}
+		views = append(views, view)
+	}
+	sort.Slice(views, func(i, j int) bool { return views[i].Pool < views[j].Pool })
+	writeJSON(w, requestID, http.StatusOK, map[string]interface{}{"breakers": views})
+}
+
+func (h *handler) handleBreakerReset(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get(proxy.RequestIDHeader)
+	if r.Method != http.MethodPost {
+		writeError(w, requestID, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.breakers == nil {
+		writeError(w, requestID, http.StatusServiceUnavailable, "breaker registry unavailable")
+		return
+	}
+	poolKey := r.PathValue("pool")
+	previous, ok := h.breakers.Reset(poolKey)
+	if !ok {
+		writeError(w, requestID, http.StatusNotFound, "breaker not found")
+		return
+	}
+	if metrics := obs.DefaultMetrics(); metrics != nil {
+		metrics.RecordBreakerReset(poolKey)
+		metrics.SetBreakerOpen(poolKey, false)
+	}
+	logger.Info("admin_breaker_reset", "request_id", requestID, "pool", poolKey, "previous_state", previous.String())
+	writeJSON(w, requestID, http.StatusOK, map[string]interface{}{"pool": poolKey, "previous_state": previous.String(), "state": "closed"})
+}
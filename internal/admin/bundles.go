@@ -0,0 +1,64 @@
+package admin
+
+import (
+	"net/http"
+
+	"modern_reverse_proxy/internal/proxy"
+)
+
+type bundleView struct {
+	Version   string `json:"version"`
+	CreatedAt string `json:"created_at"`
+	Source    string `json:"source"`
+	Label     string `json:"label,omitempty"`
+	GitSHA    string `json:"git_sha,omitempty"`
+	Author    string `json:"author,omitempty"`
+	Signed    bool   `json:"signed"`
+	Active    bool   `json:"active"`
+}
+
+func (h *handler) handleBundles(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get(proxy.RequestIDHeader)
+	if r.Method != http.MethodGet {
+		writeError(w, requestID, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.adminStore == nil {
+		writeError(w, requestID, http.StatusServiceUnavailable, "admin store unavailable")
+		return
+	}
+	active := h.adminStore.CurrentVersion()
+	history := h.adminStore.History()
+	views := make([]bundleView, 0, len(history))
+	for _, item := range history {
+		views = append(views, bundleView{
+			Version:   item.Meta.Version,
+			CreatedAt: item.Meta.CreatedAt,
+			Source:    item.Meta.Source,
+			Label:     item.Meta.Label,
+			GitSHA:    item.Meta.GitSHA,
+			Author:    item.Meta.Author,
+			Signed:    item.SignatureB64 != "",
+			Active:    item.Meta.Version == active,
+		})
+	}
+	writeJSON(w, requestID, http.StatusOK, map[string]interface{}{"active": active, "bundles": views})
+}
+
+func (h *handler) handleBundleExport(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get(proxy.RequestIDHeader)
+	if r.Method != http.MethodGet {
+		writeError(w, requestID, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.adminStore == nil {
+		writeError(w, requestID, http.StatusServiceUnavailable, "admin store unavailable")
+		return
+	}
+	item, ok := h.adminStore.Get(r.PathValue("version"))
+	if !ok {
+		writeError(w, requestID, http.StatusNotFound, "bundle not found")
+		return
+	}
+	writeJSON(w, requestID, http.StatusOK, item)
+}
@@ -0,0 +1,48 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"modern_reverse_proxy/internal/proxy"
+)
+
+type certView struct {
+	ServerName    string    `json:"server_name"`
+	DNSNames      []string  `json:"dns_names"`
+	NotBefore     time.Time `json:"not_before"`
+	NotAfter      time.Time `json:"not_after"`
+	DaysRemaining int       `json:"days_remaining"`
+	ExpiringSoon  bool      `json:"expiring_soon"`
+	CertFile      string    `json:"cert_file,omitempty"`
+	Fingerprint   string    `json:"fingerprint_sha256"`
+}
+
+func (h *handler) handleCerts(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get(proxy.RequestIDHeader)
+	snap, ok := h.inspectSnapshot(w, r, requestID)
+	if !ok {
+		return
+	}
+	certs := snap.TLSStore.Certificates()
+	now := time.Now()
+	views := make([]certView, 0, len(certs))
+	for _, cert := range certs {
+		remaining := cert.NotAfter.Sub(now)
+		views = append(views, certView{
+			ServerName:    cert.ServerName,
+			DNSNames:      cert.DNSNames,
+			NotBefore:     cert.NotBefore.UTC(),
+			NotAfter:      cert.NotAfter.UTC(),
+			DaysRemaining: int(remaining.Hours() / 24),
+			ExpiringSoon:  remaining <= snap.TLSExpiryWarning,
+			CertFile:      cert.CertFile,
+			Fingerprint:   cert.Fingerprint,
+		})
+	}
+	writeJSON(w, requestID, http.StatusOK, map[string]interface{}{
+		"version":             snap.Version,
+		"expiry_warning_days": int(snap.TLSExpiryWarning.Hours() / 24),
+		"certificates":        views,
+	})
+}
@@ -0,0 +1,120 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	runtimepprof "runtime/pprof"
+	"strings"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/secrets"
+)
+
+const debugPprofPrefix = "/admin/debug/pprof/"
+
+func (h *handler) handleDebugPprof(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		writeError(w, r.Header.Get(proxy.RequestIDHeader), http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	switch name := strings.TrimPrefix(r.URL.Path, debugPprofPrefix); name {
+	case "":
+		pprof.Index(w, r)
+	case "cmdline":
+		pprof.Cmdline(w, r)
+	case "profile":
+		pprof.Profile(w, r)
+	case "symbol":
+		pprof.Symbol(w, r)
+	case "trace":
+		pprof.Trace(w, r)
+	default:
+		pprof.Handler(name).ServeHTTP(w, r)
+	}
+}
+
+func (h *handler) handleDebugStacks(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get(proxy.RequestIDHeader)
+	if r.Method != http.MethodGet {
+		writeError(w, requestID, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_ = runtimepprof.Lookup("goroutine").WriteTo(w, 2)
+}
+
+func (h *handler) handleDebugConfig(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get(proxy.RequestIDHeader)
+	if r.Method != http.MethodGet {
+		writeError(w, requestID, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.store == nil {
+		writeError(w, requestID, http.StatusServiceUnavailable, "snapshot unavailable")
+		return
+	}
+	snap := h.store.Get()
+	if snap == nil || snap.Config == nil {
+		writeError(w, requestID, http.StatusNotFound, "config missing")
+		return
+	}
+	redacted, err := redactConfig(snap.Config)
+	if err != nil {
+		writeError(w, requestID, http.StatusInternalServerError, "config encode failed")
+		return
+	}
+	writeJSON(w, requestID, http.StatusOK, map[string]interface{}{
+		"version": snap.Version,
+		"source":  snap.Source,
+		"config":  redacted,
+	})
+}
+
+func redactConfig(cfg *config.Config) (json.RawMessage, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var clone config.Config
+	if err := json.Unmarshal(data, &clone); err != nil {
+		return nil, err
+	}
+	if clone.Logging.AccessLog != nil {
+		redactHeaders(clone.Logging.AccessLog.Headers)
+	}
+	if clone.Metrics != nil && clone.Metrics.Push != nil {
+		redactHeaders(clone.Metrics.Push.Headers)
+	}
+	for i := range clone.Routes {
+		policy := &clone.Routes[i].Policy
+		redactKeys(policy.APIKey.Keys)
+		redactKeys(policy.HMAC.Keys)
+		if policy.AccessLog.Sink != nil {
+			redactHeaders(policy.AccessLog.Sink.Headers)
+		}
+	}
+	data, err = json.Marshal(&clone)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(secrets.Default().Redact(string(data))), nil
+}
+
+func redactHeaders(headers map[string]string) {
+	for name, value := range headers {
+		if !secrets.IsRef(value) {
+			headers[name] = secrets.Redacted
+		}
+	}
+}
+
+func redactKeys(keys []config.AuthKeyConfig) {
+	for i := range keys {
+		if keys[i].Key != "" && !secrets.IsRef(keys[i].Key) {
+			keys[i].Key = secrets.Redacted
+		}
+	}
+}
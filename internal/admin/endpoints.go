@@ -0,0 +1,75 @@
+package admin
+
+import (
+	"net/http"
+
+	"modern_reverse_proxy/internal/pool"
+	"modern_reverse_proxy/internal/proxy"
+)
+
+const (
+	endpointDrain   = "drain"
+	endpointEject   = "eject"
+	endpointRestore = "restore"
+)
+
+func (h *handler) handleEndpointAction(action string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(proxy.RequestIDHeader)
+		if r.Method != http.MethodPost {
+			writeError(w, requestID, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		if h.store == nil || h.registry == nil {
+			writeError(w, requestID, http.StatusServiceUnavailable, "registry unavailable")
+			return
+		}
+		snap := h.store.Get()
+		if snap == nil {
+			writeError(w, requestID, http.StatusNotFound, "snapshot missing")
+			return
+		}
+		name := r.PathValue("name")
+		addr := r.PathValue("addr")
+		key, ok := snap.Pools[name]
+		if !ok {
+			writeError(w, requestID, http.StatusNotFound, "pool not found")
+			return
+		}
+
+		var applied bool
+		switch action {
+		case endpointDrain:
+			applied = h.registry.DrainEndpoint(key, addr)
+		case endpointEject:
+			applied = h.registry.EjectEndpoint(key, addr)
+		case endpointRestore:
+			applied = h.registry.RestoreEndpoint(key, addr)
+			if applied {
+				for _, scope := range outlierScopes(snap)[name] {
+					h.outlier.Reset(scope, addr)
+				}
+			}
+		}
+		if !applied {
+			writeError(w, requestID, http.StatusNotFound, "endpoint not found")
+			return
+		}
+		logger.Info("admin_endpoint_"+string(action), "request_id", requestID, "pool", name, "addr", addr)
+		writeJSON(w, requestID, http.StatusOK, map[string]interface{}{
+			"pool":     name,
+			"action":   action,
+			"endpoint": h.endpointView(key, addr),
+		})
+	}
+}
+
+func (h *handler) endpointView(key pool.PoolKey, addr string) endpointView {
+	statuses, _ := h.registry.PoolStatus(key)
+	for _, status := range statuses {
+		if status.Addr == addr {
+			return endpointViewFromStatus(status)
+		}
+	}
+	return endpointView{Addr: addr}
+}
@@ -1,19 +1,21 @@
 package admin
 
 import (
-	"crypto/ed25519"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"io"
-	"log"
 	"net/http"
 	"time"
 
 	"modern_reverse_proxy/internal/apply"
+	"modern_reverse_proxy/internal/breaker"
 	"modern_reverse_proxy/internal/bundle"
 	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/outlier"
 	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/pull"
+	"modern_reverse_proxy/internal/registry"
 	"modern_reverse_proxy/internal/rollout"
 	"modern_reverse_proxy/internal/runtime"
 )
@@ -24,9 +26,17 @@ type handler struct {
 	auth          *Authenticator
 	rateLimiter   *RateLimiter
 	adminStore    *Store
-	publicKey     ed25519.PublicKey
+	keyring       *bundle.Keyring
 	allowUnsigned bool
 	rollout       *rollout.Manager
+	puller        *pull.Puller
+	registry      *registry.Registry
+	outlier       *outlier.Registry
+	breakers      *breaker.Registry
+	audit         *AuditLog
+	slowRoutes    *obs.SlowRouteTracker
+	tap           *obs.Tap
+	proxy         http.Handler
 	mux           *http.ServeMux
 }
 
@@ -40,6 +50,16 @@ func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	w.Header().Set(proxy.RequestIDHeader, requestID)
 
+	if h.audit != nil {
+		h.serveAudited(w, r, requestID, func(w http.ResponseWriter, r *http.Request) {
+			h.serve(w, r, requestID)
+		})
+		return
+	}
+	h.serve(w, r, requestID)
+}
+
+func (h *handler) serve(w http.ResponseWriter, r *http.Request, requestID string) {
 	if h.rateLimiter != nil {
 		if !h.rateLimiter.Allow(r.RemoteAddr) {
 			writeError(w, requestID, http.StatusTooManyRequests, "rate_limited")
@@ -97,6 +117,9 @@ func (h *handler) handleValidate(w http.ResponseWriter, r *http.Request) {
 	if result != nil && len(result.Warnings) > 0 {
 		response["warnings"] = result.Warnings
 	}
+	if result != nil && result.Impact != nil {
+		response["impact"] = result.Impact
+	}
 	writeJSON(w, requestID, http.StatusOK, response)
 }
 
@@ -106,7 +129,7 @@ func (h *handler) handleApply(w http.ResponseWriter, r *http.Request) {
 		writeError(w, requestID, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
-	if len(h.publicKey) == ed25519.PublicKeySize && !h.allowUnsigned {
+	if h.keyring.Len() > 0 && !h.allowUnsigned {
 		writeError(w, requestID, http.StatusForbidden, "unsigned apply disabled")
 		return
 	}
@@ -124,7 +147,7 @@ func (h *handler) handleApply(w http.ResponseWriter, r *http.Request) {
 	result, err := h.apply.Apply(r.Context(), body, "admin", apply.ModeApply)
 	if err != nil {
 		status, message := applyErrorStatus(err)
-		log.Printf("admin_apply request_id=%s version=%s result=error reason=%s", requestID, version, message)
+		logger.Warn("admin_apply", "request_id", requestID, "version", version, "result", "error", "reason", message)
 		writeError(w, requestID, status, message)
 		return
 	}
@@ -141,7 +164,7 @@ func (h *handler) handleApply(w http.ResponseWriter, r *http.Request) {
 			ConfigSHA256:   configHash,
 		})
 	}
-	log.Printf("admin_apply request_id=%s version=%s result=success", requestID, result.Version)
+	logger.Info("admin_apply", "request_id", requestID, "version", result.Version, "result", "success")
 	response := map[string]interface{}{"applied": true, "version": result.Version}
 	if result != nil && len(result.Warnings) > 0 {
 		response["warnings"] = result.Warnings
@@ -155,7 +178,7 @@ func (h *handler) handleBundle(w http.ResponseWriter, r *http.Request) {
 		writeError(w, requestID, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
-	if len(h.publicKey) != ed25519.PublicKeySize {
+	if h.keyring.Len() == 0 {
 		writeError(w, requestID, http.StatusServiceUnavailable, "public key missing")
 		return
 	}
@@ -169,23 +192,20 @@ func (h *handler) handleBundle(w http.ResponseWriter, r *http.Request) {
 		writeError(w, requestID, http.StatusBadRequest, "invalid bundle")
 		return
 	}
-	if err := bundle.VerifyBundle(bundlePayload, h.publicKey); err != nil {
+	if err := h.keyring.Verify(bundlePayload); err != nil {
 		metrics := obs.DefaultMetrics()
-		result := "bad_sig"
-		if errors.Is(err, bundle.ErrBadHash) {
-			result = "bad_hash"
-		}
+		result := bundle.VerifyResult(err)
 		if metrics != nil {
 			metrics.RecordBundleVerify(result)
 		}
-		log.Printf("bundle_version=%s verify_result=%s", bundlePayload.Meta.Version, result)
+		logger.Warn("bundle_verify", "bundle_version", bundlePayload.Meta.Version, "verify_result", result)
 		writeError(w, requestID, http.StatusBadRequest, "invalid signature")
 		return
 	}
 	if metrics := obs.DefaultMetrics(); metrics != nil {
 		metrics.RecordBundleVerify("ok")
 	}
-	log.Printf("bundle_version=%s verify_result=ok", bundlePayload.Meta.Version)
+	logger.Info("bundle_verify", "bundle_version", bundlePayload.Meta.Version, "verify_result", "ok")
 
 	result, err := h.applyBundle(r, bundlePayload, "")
 	if err != nil {
@@ -243,7 +263,7 @@ func (h *handler) handleRollback(w http.ResponseWriter, r *http.Request) {
 	if metrics := obs.DefaultMetrics(); metrics != nil {
 		metrics.RecordRollback("success")
 	}
-	log.Printf("rollback_from_version=%s rollback_to_version=%s", fromVersion, result.Version)
+	logger.Info("rollback", "rollback_from_version", fromVersion, "rollback_to_version", result.Version)
 	if h.adminStore != nil {
 		h.adminStore.Record(rollbackBundle)
 	}
@@ -270,14 +290,98 @@ func (h *handler) handleSnapshot(w http.ResponseWriter, r *http.Request) {
 		poolCount = len(snap.Pools)
 	}
 	writeJSON(w, requestID, http.StatusOK, map[string]interface{}{
-		"version":     snap.Version,
-		"created_at":  snap.CreatedAt,
-		"source":      snap.Source,
-		"route_count": snap.RouteCount,
-		"pool_count":  poolCount,
+		"version":         snap.Version,
+		"created_at":      snap.CreatedAt,
+		"source":          snap.Source,
+		"route_count":     snap.RouteCount,
+		"pool_count":      poolCount,
+		"provenance":      snap.Provenance,
+		"disabled_routes": h.adminStore.DisabledRoutes(),
 	})
 }
 
+func (h *handler) handleRouteDisable(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get(proxy.RequestIDHeader)
+	if r.Method != http.MethodPost {
+		writeError(w, requestID, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.adminStore == nil {
+		writeError(w, requestID, http.StatusServiceUnavailable, "admin store unavailable")
+		return
+	}
+	routeID := r.PathValue("id")
+	if !h.routeExists(routeID) {
+		writeError(w, requestID, http.StatusNotFound, "route not found")
+		return
+	}
+	var payload struct {
+		Status            int    `json:"status"`
+		Message           string `json:"message"`
+		RetryAfterSeconds int    `json:"retry_after_seconds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, requestID, http.StatusBadRequest, "invalid body")
+		return
+	}
+	if payload.Status == 0 {
+		payload.Status = http.StatusServiceUnavailable
+	}
+	if payload.Status < http.StatusBadRequest || payload.Status > 599 || payload.RetryAfterSeconds < 0 {
+		writeError(w, requestID, http.StatusBadRequest, "invalid maintenance response")
+		return
+	}
+	maintenance := proxy.Maintenance{
+		Status:            payload.Status,
+		Message:           payload.Message,
+		RetryAfterSeconds: payload.RetryAfterSeconds,
+		DisabledAt:        time.Now().UTC(),
+	}
+	h.adminStore.DisableRoute(routeID, maintenance)
+	logger.Info("admin_route_disable", "request_id", requestID, "route", routeID, "status", maintenance.Status)
+	writeJSON(w, requestID, http.StatusOK, map[string]interface{}{"disabled": true, "route": routeID, "maintenance": maintenance})
+}
+
+func (h *handler) handleRouteEnable(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get(proxy.RequestIDHeader)
+	if r.Method != http.MethodPost {
+		writeError(w, requestID, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.adminStore == nil {
+		writeError(w, requestID, http.StatusServiceUnavailable, "admin store unavailable")
+		return
+	}
+	routeID := r.PathValue("id")
+	if !h.adminStore.EnableRoute(routeID) {
+		writeError(w, requestID, http.StatusNotFound, "route not disabled")
+		return
+	}
+	logger.Info("admin_route_enable", "request_id", requestID, "route", routeID)
+	writeJSON(w, requestID, http.StatusOK, map[string]interface{}{"disabled": false, "route": routeID})
+}
+
+func (h *handler) routeExists(routeID string) bool {
+	if h.store == nil || routeID == "" {
+		return false
+	}
+	snap := h.store.Get()
+	if snap == nil {
+		return false
+	}
+	_, ok := snap.Router.Route(routeID)
+	return ok
+}
+
+func (h *handler) handlePullStatus(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get(proxy.RequestIDHeader)
+	if r.Method != http.MethodGet {
+		writeError(w, requestID, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, requestID, http.StatusOK, h.puller.Status())
+}
+
 func (h *handler) applyBundle(r *http.Request, bundlePayload bundle.Bundle, sourceOverride string) (*apply.Result, error) {
 	if h.rollout != nil {
 		return h.rollout.ApplyBundle(r.Context(), bundlePayload, sourceOverride)
@@ -293,7 +397,8 @@ func (h *handler) applyBundle(r *http.Request, bundlePayload bundle.Bundle, sour
 	if sourceOverride != "" {
 		source = sourceOverride
 	}
-	return h.apply.ApplyResolvedVersion(r.Context(), configBytes, source, bundlePayload.Meta.Version, apply.ModeApply)
+	provenance := runtime.Provenance{Label: bundlePayload.Meta.Label, GitSHA: bundlePayload.Meta.GitSHA, Author: bundlePayload.Meta.Author}
+	return h.apply.ApplyResolvedProvenance(r.Context(), configBytes, source, bundlePayload.Meta.Version, provenance, apply.ModeApply)
 }
 
 func applyErrorStatus(err error) (int, string) {
@@ -0,0 +1,98 @@
+package admin
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/runtime"
+)
+
+type healthPoolView struct {
+	Name      string               `json:"name"`
+	Healthy   int                  `json:"healthy"`
+	Total     int                  `json:"total"`
+	Endpoints []healthEndpointView `json:"endpoints"`
+}
+
+type healthEndpointView struct {
+	endpointView
+	Outlier []outlierView `json:"outlier,omitempty"`
+}
+
+type outlierView struct {
+	Scope               string     `json:"scope"`
+	Ejected             bool       `json:"ejected"`
+	EjectUntil          *time.Time `json:"eject_until,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	EjectCount          int        `json:"eject_count"`
+}
+
+func (h *handler) handleHealth(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get(proxy.RequestIDHeader)
+	snap, ok := h.inspectSnapshot(w, r, requestID)
+	if !ok {
+		return
+	}
+	if h.registry == nil {
+		writeError(w, requestID, http.StatusServiceUnavailable, "registry unavailable")
+		return
+	}
+	scopes := outlierScopes(snap)
+	names := make([]string, 0, len(snap.Pools))
+	for name := range snap.Pools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	now := time.Now()
+	views := make([]healthPoolView, 0, len(names))
+	for _, name := range names {
+		view := healthPoolView{Name: name, Endpoints: []healthEndpointView{}}
+		statuses, _ := h.registry.PoolStatus(snap.Pools[name])
+		for _, status := range statuses {
+			endpoint := healthEndpointView{endpointView: endpointViewFromStatus(status)}
+			for _, scope := range scopes[name] {
+				outlierStatus, ok := h.outlier.EndpointStatus(scope, status.Addr, now)
+				if !ok {
+					continue
+				}
+				outlier := outlierView{
+					Scope:               scope,
+					Ejected:             outlierStatus.Ejected,
+					ConsecutiveFailures: outlierStatus.ConsecutiveFailures,
+					EjectCount:          outlierStatus.EjectCount,
+				}
+				if outlierStatus.Ejected {
+					ejectUntil := outlierStatus.EjectUntil.UTC()
+					outlier.EjectUntil = &ejectUntil
+				}
+				endpoint.Outlier = append(endpoint.Outlier, outlier)
+			}
+			if status.State == "healthy" && !status.Ejected {
+				view.Healthy++
+			}
+			view.Total++
+			view.Endpoints = append(view.Endpoints, endpoint)
+		}
+		views = append(views, view)
+	}
+	writeJSON(w, requestID, http.StatusOK, map[string]interface{}{"version": snap.Version, "pools": views})
+}
+
+func outlierScopes(snap *runtime.Snapshot) map[string][]string {
+	scopes := make(map[string][]string)
+	for _, route := range snap.Router.Routes() {
+		if route.StablePoolKey != "" {
+			scopes[route.PoolName] = append(scopes[route.PoolName], route.StablePoolKey)
+		}
+		if route.CanaryPoolKey != "" {
+			scopes[route.CanaryPoolName] = append(scopes[route.CanaryPoolName], route.CanaryPoolKey)
+		}
+	}
+	for _, keys := range scopes {
+		sort.Strings(keys)
+	}
+	return scopes
+}
@@ -0,0 +1,289 @@
+package admin
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"modern_reverse_proxy/internal/policy"
+	"modern_reverse_proxy/internal/pool"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/runtime"
+)
+
+type routeView struct {
+	ID          string             `json:"id"`
+	Host        string             `json:"host"`
+	PathPrefix  string             `json:"path_prefix"`
+	Methods     []string           `json:"methods,omitempty"`
+	Pool        string             `json:"pool"`
+	CanaryPool  string             `json:"canary_pool,omitempty"`
+	Labels      map[string]string  `json:"labels,omitempty"`
+	Timeouts    timeoutView        `json:"timeouts"`
+	Retry       retryView          `json:"retry"`
+	Hedge       hedgeView          `json:"hedge"`
+	Cache       cacheView          `json:"cache"`
+	Traffic     *trafficView       `json:"traffic,omitempty"`
+	Maintenance *proxy.Maintenance `json:"maintenance,omitempty"`
+}
+
+type timeoutView struct {
+	RequestMS                int64 `json:"request_ms"`
+	UpstreamDialMS           int64 `json:"upstream_dial_ms"`
+	UpstreamResponseHeaderMS int64 `json:"upstream_response_header_ms"`
+}
+
+type retryView struct {
+	Enabled            bool             `json:"enabled"`
+	MaxAttempts        int              `json:"max_attempts,omitempty"`
+	PerTryTimeoutMS    int64            `json:"per_try_timeout_ms,omitempty"`
+	TotalRetryBudgetMS int64            `json:"total_retry_budget_ms,omitempty"`
+	RetryOnStatus      []int            `json:"retry_on_status,omitempty"`
+	RetryOnErrors      []string         `json:"retry_on_errors,omitempty"`
+	BackoffMS          int64            `json:"backoff_ms,omitempty"`
+	BackoffJitterMS    int64            `json:"backoff_jitter_ms,omitempty"`
+	StatusBackoffMS    map[string]int64 `json:"status_backoff_ms,omitempty"`
+	RespectRetryAfter  bool             `json:"respect_retry_after,omitempty"`
+	RetryAfterCapMS    int64            `json:"retry_after_cap_ms,omitempty"`
+	ExcludeAttempted   bool             `json:"exclude_attempted,omitempty"`
+}
+
+type hedgeView struct {
+	Enabled   bool  `json:"enabled"`
+	DelayMS   int64 `json:"delay_ms,omitempty"`
+	MaxHedges int   `json:"max_hedges,omitempty"`
+}
+
+type cacheView struct {
+	Enabled        bool     `json:"enabled"`
+	Public         bool     `json:"public,omitempty"`
+	TTLMS          int64    `json:"ttl_ms,omitempty"`
+	MaxObjectBytes int64    `json:"max_object_bytes,omitempty"`
+	VaryHeaders    []string `json:"vary_headers,omitempty"`
+	Coalesce       bool     `json:"coalesce,omitempty"`
+}
+
+type trafficView struct {
+	StableWeight    int  `json:"stable_weight"`
+	CanaryWeight    int  `json:"canary_weight"`
+	Ramping         bool `json:"ramping,omitempty"`
+	AutoDrainActive bool `json:"autodrain_active,omitempty"`
+}
+
+type poolView struct {
+	Name                string         `json:"name"`
+	Key                 string         `json:"key"`
+	Breaker             bool           `json:"breaker_enabled"`
+	Outlier             bool           `json:"outlier_enabled"`
+	AdaptiveConcurrency bool           `json:"adaptive_concurrency_enabled"`
+	Endpoints           []endpointView `json:"endpoints"`
+}
+
+type endpointView struct {
+	Addr                       string     `json:"addr"`
+	State                      string     `json:"state"`
+	Ejected                    bool       `json:"ejected"`
+	EjectUntil                 *time.Time `json:"eject_until,omitempty"`
+	Inflight                   int64      `json:"inflight"`
+	ConsecutiveActiveFailures  int        `json:"consecutive_active_failures"`
+	ConsecutivePassiveFailures int        `json:"consecutive_passive_failures"`
+	ConsecutiveStatusFailures  int        `json:"consecutive_status_failures"`
+	Override                   string     `json:"override,omitempty"`
+}
+
+func (h *handler) handleRoutes(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get(proxy.RequestIDHeader)
+	snap, ok := h.inspectSnapshot(w, r, requestID)
+	if !ok {
+		return
+	}
+	routes := snap.Router.Routes()
+	views := make([]routeView, 0, len(routes))
+	for _, route := range routes {
+		views = append(views, h.routeView(route))
+	}
+	sort.Slice(views, func(i, j int) bool { return views[i].ID < views[j].ID })
+	writeJSON(w, requestID, http.StatusOK, map[string]interface{}{"version": snap.Version, "routes": views})
+}
+
+func (h *handler) handleRoute(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get(proxy.RequestIDHeader)
+	snap, ok := h.inspectSnapshot(w, r, requestID)
+	if !ok {
+		return
+	}
+	route, ok := snap.Router.Route(r.PathValue("id"))
+	if !ok {
+		writeError(w, requestID, http.StatusNotFound, "route not found")
+		return
+	}
+	writeJSON(w, requestID, http.StatusOK, h.routeView(route))
+}
+
+func (h *handler) handlePools(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get(proxy.RequestIDHeader)
+	snap, ok := h.inspectSnapshot(w, r, requestID)
+	if !ok {
+		return
+	}
+	names := make([]string, 0, len(snap.Pools))
+	for name := range snap.Pools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	views := make([]poolView, 0, len(names))
+	for _, name := range names {
+		views = append(views, h.poolView(snap, name))
+	}
+	writeJSON(w, requestID, http.StatusOK, map[string]interface{}{"version": snap.Version, "pools": views})
+}
+
+func (h *handler) handlePool(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get(proxy.RequestIDHeader)
+	snap, ok := h.inspectSnapshot(w, r, requestID)
+	if !ok {
+		return
+	}
+	name := r.PathValue("name")
+	if _, ok := snap.Pools[name]; !ok {
+		writeError(w, requestID, http.StatusNotFound, "pool not found")
+		return
+	}
+	writeJSON(w, requestID, http.StatusOK, h.poolView(snap, name))
+}
+
+func (h *handler) inspectSnapshot(w http.ResponseWriter, r *http.Request, requestID string) (*runtime.Snapshot, bool) {
+	if r.Method != http.MethodGet {
+		writeError(w, requestID, http.StatusMethodNotAllowed, "method not allowed")
+		return nil, false
+	}
+	if h.store == nil {
+		writeError(w, requestID, http.StatusServiceUnavailable, "snapshot unavailable")
+		return nil, false
+	}
+	snap := h.store.Get()
+	if snap == nil {
+		writeError(w, requestID, http.StatusNotFound, "snapshot missing")
+		return nil, false
+	}
+	return snap, true
+}
+
+func (h *handler) routeView(route policy.Route) routeView {
+	p := route.Policy
+	view := routeView{
+		ID:         route.ID,
+		Host:       route.Host,
+		PathPrefix: route.PathPrefix,
+		Methods:    sortedKeys(route.Methods),
+		Pool:       route.PoolName,
+		CanaryPool: route.CanaryPoolName,
+		Labels:     route.Labels,
+		Timeouts: timeoutView{
+			RequestMS:                p.RequestTimeout.Milliseconds(),
+			UpstreamDialMS:           p.UpstreamDialTimeout.Milliseconds(),
+			UpstreamResponseHeaderMS: p.UpstreamResponseHeaderTimeout.Milliseconds(),
+		},
+		Retry: retryView{
+			Enabled:            p.Retry.Enabled,
+			MaxAttempts:        p.Retry.MaxAttempts,
+			PerTryTimeoutMS:    p.Retry.PerTryTimeout.Milliseconds(),
+			TotalRetryBudgetMS: p.Retry.TotalRetryBudget.Milliseconds(),
+			RetryOnErrors:      sortedKeys(p.Retry.RetryOnErrors),
+			BackoffMS:          p.Retry.Backoff.Milliseconds(),
+			BackoffJitterMS:    p.Retry.BackoffJitter.Milliseconds(),
+			RespectRetryAfter:  p.Retry.RetryAfter,
+			RetryAfterCapMS:    p.Retry.RetryAfterCap.Milliseconds(),
+			ExcludeAttempted:   p.Retry.ExcludeAttempted,
+		},
+		Hedge: hedgeView{
+			Enabled:   p.Hedge.Enabled,
+			DelayMS:   p.Hedge.Delay.Milliseconds(),
+			MaxHedges: p.Hedge.MaxHedges,
+		},
+		Cache: cacheView{
+			Enabled:        p.Cache.Enabled,
+			Public:         p.Cache.Public,
+			TTLMS:          p.Cache.TTL.Milliseconds(),
+			MaxObjectBytes: p.Cache.MaxObjectBytes,
+			VaryHeaders:    p.Cache.VaryHeaders,
+			Coalesce:       p.Cache.CoalesceEnabled,
+		},
+	}
+	for status, enabled := range p.Retry.RetryOnStatus {
+		if enabled {
+			view.Retry.RetryOnStatus = append(view.Retry.RetryOnStatus, status)
+		}
+	}
+	sort.Ints(view.Retry.RetryOnStatus)
+	if len(p.Retry.StatusBackoff) > 0 {
+		view.Retry.StatusBackoffMS = make(map[string]int64, len(p.Retry.StatusBackoff))
+		for status, delay := range p.Retry.StatusBackoff {
+			view.Retry.StatusBackoffMS[strconv.Itoa(status)] = delay.Milliseconds()
+		}
+	}
+	if route.TrafficPlan != nil {
+		split, drainActive := route.TrafficPlan.EffectiveSplit()
+		view.Traffic = &trafficView{
+			StableWeight:    split.StableWeight,
+			CanaryWeight:    split.CanaryWeight,
+			Ramping:         route.TrafficPlan.Ramp != nil,
+			AutoDrainActive: drainActive,
+		}
+	}
+	if maintenance, ok := h.adminStore.RouteMaintenance(route.ID); ok {
+		view.Maintenance = &maintenance
+	}
+	return view
+}
+
+func (h *handler) poolView(snap *runtime.Snapshot, name string) poolView {
+	key := snap.Pools[name]
+	cfg := snap.PoolConfigs[name]
+	view := poolView{
+		Name:                name,
+		Key:                 string(key),
+		Breaker:             cfg.Breaker.Enabled,
+		Outlier:             cfg.Outlier.Enabled,
+		AdaptiveConcurrency: cfg.Concurrency.Enabled,
+		Endpoints:           []endpointView{},
+	}
+	if h.registry == nil {
+		return view
+	}
+	statuses, _ := h.registry.PoolStatus(key)
+	for _, status := range statuses {
+		view.Endpoints = append(view.Endpoints, endpointViewFromStatus(status))
+	}
+	return view
+}
+
+func endpointViewFromStatus(status pool.EndpointStatus) endpointView {
+	view := endpointView{
+		Addr:                       status.Addr,
+		State:                      status.State,
+		Ejected:                    status.Ejected,
+		Inflight:                   status.Inflight,
+		ConsecutiveActiveFailures:  status.ConsecutiveActiveFailures,
+		ConsecutivePassiveFailures: status.ConsecutivePassiveFailures,
+		ConsecutiveStatusFailures:  status.ConsecutiveStatusFailures,
+		Override:                   status.Override,
+	}
+	if !status.EjectUntil.IsZero() {
+		ejectUntil := status.EjectUntil.UTC()
+		view.EjectUntil = &ejectUntil
+	}
+	return view
+}
+
+func sortedKeys(values map[string]bool) []string {
+	keys := make([]string, 0, len(values))
+	for key, enabled := range values {
+		if enabled {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,49 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"modern_reverse_proxy/internal/bundle"
+	"modern_reverse_proxy/internal/proxy"
+)
+
+func (h *handler) handleKeyring(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get(proxy.RequestIDHeader)
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, requestID, http.StatusOK, keyringView(h.keyring))
+	case http.MethodPost:
+		if h.keyring.Len() == 0 {
+			writeError(w, requestID, http.StatusServiceUnavailable, "keyring not configured")
+			return
+		}
+		var doc bundle.KeyringDocument
+		if err := json.NewDecoder(r.Body).Decode(&doc); err != nil {
+			writeError(w, requestID, http.StatusBadRequest, "invalid keyring")
+			return
+		}
+		if err := h.keyring.Rotate(doc); err != nil {
+			status := http.StatusBadRequest
+			if errors.Is(err, bundle.ErrStaleKeyring) {
+				status = http.StatusConflict
+			}
+			logger.Warn("admin_keyring_rotate", "request_id", requestID, "version", doc.Version, "result", "error", "reason", err)
+			writeError(w, requestID, status, err.Error())
+			return
+		}
+		logger.Info("admin_keyring_rotate", "request_id", requestID, "version", doc.Version, "signer", doc.SignerKeyID, "result", "success")
+		writeJSON(w, requestID, http.StatusOK, keyringView(h.keyring))
+	default:
+		writeError(w, requestID, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func keyringView(keyring *bundle.Keyring) map[string]interface{} {
+	ids := keyring.KeyIDs()
+	if ids == nil {
+		ids = []string{}
+	}
+	return map[string]interface{}{"version": keyring.Version(), "key_ids": ids}
+}
@@ -0,0 +1,73 @@
+package admin
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+
+	"modern_reverse_proxy/internal/logging"
+	"modern_reverse_proxy/internal/proxy"
+)
+
+func (h *handler) handleLogging(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get(proxy.RequestIDHeader)
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, requestID, http.StatusOK, logging.CurrentLevels())
+	case http.MethodPut:
+		var payload struct {
+			Level      string            `json:"level"`
+			Components map[string]string `json:"components"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			writeError(w, requestID, http.StatusBadRequest, "invalid body")
+			return
+		}
+		var base *slog.Level
+		if payload.Level != "" {
+			parsed, err := logging.ParseLevel(payload.Level)
+			if err != nil {
+				writeError(w, requestID, http.StatusBadRequest, err.Error())
+				return
+			}
+			base = &parsed
+		}
+		overrides := make(map[string]*slog.Level, len(payload.Components))
+		for component, value := range payload.Components {
+			if strings.TrimSpace(component) == "" {
+				writeError(w, requestID, http.StatusBadRequest, "component name is required")
+				return
+			}
+			if value == "" {
+				overrides[component] = nil
+				continue
+			}
+			parsed, err := logging.ParseLevel(value)
+			if err != nil {
+				writeError(w, requestID, http.StatusBadRequest, err.Error())
+				return
+			}
+			overrides[component] = &parsed
+		}
+		if base != nil {
+			logging.SetLevel(*base)
+		}
+		components := make([]string, 0, len(overrides))
+		for component, level := range overrides {
+			if level == nil {
+				logging.ResetComponentLevel(component)
+			} else {
+				logging.SetComponentLevel(component, *level)
+			}
+			components = append(components, component)
+		}
+		sort.Strings(components)
+		current := logging.CurrentLevels()
+		logger.Info("admin_logging", "request_id", requestID, "level", current.Level, "components", strings.Join(components, ","))
+		writeJSON(w, requestID, http.StatusOK, current)
+	default:
+		writeError(w, requestID, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
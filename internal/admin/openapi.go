@@ -0,0 +1,242 @@
+package admin
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"modern_reverse_proxy/internal/proxy"
+)
+
+const openAPIVersion = "1.0.0"
+
+type apiOperation struct {
+	method   string
+	path     string
+	id       string
+	summary  string
+	query    []string
+	request  string
+	response string
+	produces string
+}
+
+var adminOperations = []apiOperation{
+	{method: http.MethodPost, path: "/admin/validate", id: "validateConfig", summary: "Validate a config without applying it", request: "Config", response: "ValidateResult"},
+	{method: http.MethodPost, path: "/admin/config", id: "applyConfig", summary: "Apply an unsigned config", request: "Config", response: "ApplyResult"},
+	{method: http.MethodPost, path: "/admin/transaction", id: "applyTransaction", summary: "Apply route and pool changes against a base version", request: "Transaction", response: "ApplyResult"},
+	{method: http.MethodPost, path: "/admin/bundle", id: "applyBundle", summary: "Verify and apply a signed config bundle", request: "Bundle", response: "ApplyResult"},
+	{method: http.MethodGet, path: "/admin/bundles", id: "listBundles", summary: "List applied bundles"},
+	{method: http.MethodGet, path: "/admin/bundles/{version}", id: "exportBundle", summary: "Export an applied bundle", response: "Bundle"},
+	{method: http.MethodGet, path: "/admin/keyring", id: "getKeyring", summary: "Show bundle verification keys"},
+	{method: http.MethodPost, path: "/admin/keyring", id: "rotateKeyring", summary: "Rotate bundle verification keys with a signed keyring document"},
+	{method: http.MethodPost, path: "/admin/rollback", id: "rollback", summary: "Roll back to a previous bundle, or the one before the current", request: "RollbackRequest", response: "RollbackResult"},
+	{method: http.MethodGet, path: "/admin/snapshot", id: "getSnapshot", summary: "Describe the active snapshot", response: "Snapshot"},
+	{method: http.MethodGet, path: "/admin/pull/status", id: "getPullStatus", summary: "Show pull mode status"},
+	{method: http.MethodGet, path: "/admin/routes", id: "listRoutes", summary: "List compiled routes"},
+	{method: http.MethodGet, path: "/admin/routes/{id}", id: "getRoute", summary: "Show a compiled route"},
+	{method: http.MethodPost, path: "/admin/routes/{id}/disable", id: "disableRoute", summary: "Serve a maintenance response for a route"},
+	{method: http.MethodPost, path: "/admin/routes/{id}/enable", id: "enableRoute", summary: "Remove a route's maintenance response"},
+	{method: http.MethodGet, path: "/admin/pools", id: "listPools", summary: "List pools and endpoints"},
+	{method: http.MethodGet, path: "/admin/pools/{name}", id: "getPool", summary: "Show a pool and its endpoints"},
+	{method: http.MethodPost, path: "/admin/pools/{name}/endpoints/{addr}/drain", id: "drainEndpoint", summary: "Drain an endpoint"},
+	{method: http.MethodPost, path: "/admin/pools/{name}/endpoints/{addr}/eject", id: "ejectEndpoint", summary: "Eject an endpoint"},
+	{method: http.MethodPost, path: "/admin/pools/{name}/endpoints/{addr}/restore", id: "restoreEndpoint", summary: "Restore a drained or ejected endpoint"},
+	{method: http.MethodGet, path: "/admin/health", id: "getHealth", summary: "Show endpoint health and outlier state per pool", response: "Health"},
+	{method: http.MethodGet, path: "/admin/certs", id: "listCerts", summary: "List loaded TLS certificates"},
+	{method: http.MethodGet, path: "/admin/breakers", id: "listBreakers", summary: "List circuit breaker states"},
+	{method: http.MethodPost, path: "/admin/breakers/{pool}/reset", id: "resetBreaker", summary: "Close a circuit breaker"},
+	{method: http.MethodGet, path: "/admin/audit", id: "listAudit", summary: "List recent admin audit entries", query: []string{"limit"}},
+	{method: http.MethodGet, path: "/admin/logging", id: "getLogging", summary: "Show log levels"},
+	{method: http.MethodPut, path: "/admin/logging", id: "setLogging", summary: "Set the global and per-component log levels"},
+	{method: http.MethodGet, path: "/admin/slow-routes", id: "listSlowRoutes", summary: "Rank routes by their slowest request", query: []string{"limit", "window"}},
+	{method: http.MethodGet, path: "/admin/tap", id: "tapTraffic", summary: "Stream sampled request metadata", query: []string{"route", "sample", "body_bytes"}, produces: "text/event-stream"},
+	{method: http.MethodPost, path: "/admin/test-route", id: "testRoute", summary: "Resolve, and optionally send, a synthetic request"},
+	{method: http.MethodGet, path: "/admin/debug/pprof/", id: "debugPprof", summary: "Go pprof index and profiles", produces: "application/octet-stream"},
+	{method: http.MethodPost, path: "/admin/debug/pprof/", id: "debugPprofSymbol", summary: "Resolve program counters through pprof/symbol", produces: "text/plain"},
+	{method: http.MethodGet, path: "/admin/debug/config", id: "debugConfig", summary: "Effective config with secrets redacted"},
+	{method: http.MethodGet, path: "/admin/debug/stacks", id: "debugStacks", summary: "Goroutine dump", produces: "text/plain"},
+	{method: http.MethodGet, path: "/admin/openapi.json", id: "getOpenAPI", summary: "This document"},
+}
+
+var adminSchemas = map[string]interface{}{
+	"Error": objectSchema(map[string]interface{}{"error": stringSchema()}, "error"),
+	"Config": map[string]interface{}{
+		"type":        "object",
+		"description": "Proxy configuration as described in docs/CONFIG.md",
+	},
+	"Warnings": map[string]interface{}{"type": "array", "items": stringSchema()},
+	"ValidateResult": objectSchema(map[string]interface{}{
+		"ok":       map[string]interface{}{"type": "boolean"},
+		"warnings": schemaRef("Warnings"),
+		"impact":   map[string]interface{}{"type": "object"},
+	}, "ok"),
+	"ApplyResult": objectSchema(map[string]interface{}{
+		"applied":  map[string]interface{}{"type": "boolean"},
+		"version":  stringSchema(),
+		"warnings": schemaRef("Warnings"),
+	}, "applied", "version"),
+	"Transaction": objectSchema(map[string]interface{}{
+		"base_version": stringSchema(),
+		"operations": map[string]interface{}{"type": "array", "items": objectSchema(map[string]interface{}{
+			"op":    map[string]interface{}{"type": "string", "enum": []string{opUpsertPool, opDeletePool, opUpsertRoute, opDeleteRoute}},
+			"id":    stringSchema(),
+			"name":  stringSchema(),
+			"route": map[string]interface{}{"type": "object"},
+			"pool":  map[string]interface{}{"type": "object"},
+		}, "op")},
+	}, "operations"),
+	"BundleMeta": objectSchema(map[string]interface{}{
+		"version":    stringSchema(),
+		"created_at": stringSchema(),
+		"source":     stringSchema(),
+		"notes":      stringSchema(),
+		"label":      stringSchema(),
+		"git_sha":    stringSchema(),
+		"author":     stringSchema(),
+		"key_id":     stringSchema(),
+	}, "version"),
+	"Bundle": objectSchema(map[string]interface{}{
+		"meta":             schemaRef("BundleMeta"),
+		"config_bytes_b64": stringSchema(),
+		"config_sha256":    stringSchema(),
+		"signature_b64":    stringSchema(),
+	}, "meta", "config_bytes_b64", "config_sha256", "signature_b64"),
+	"RollbackRequest": objectSchema(map[string]interface{}{"version": stringSchema()}),
+	"RollbackResult": objectSchema(map[string]interface{}{
+		"rolled_back": map[string]interface{}{"type": "boolean"},
+		"version":     stringSchema(),
+	}, "rolled_back", "version"),
+	"Snapshot": objectSchema(map[string]interface{}{
+		"version":         stringSchema(),
+		"created_at":      map[string]interface{}{"type": "string", "format": "date-time"},
+		"source":          stringSchema(),
+		"route_count":     map[string]interface{}{"type": "integer"},
+		"pool_count":      map[string]interface{}{"type": "integer"},
+		"provenance":      map[string]interface{}{"type": "object"},
+		"disabled_routes": map[string]interface{}{"type": "object"},
+	}, "version"),
+	"Health": objectSchema(map[string]interface{}{
+		"version": stringSchema(),
+		"pools": map[string]interface{}{"type": "array", "items": objectSchema(map[string]interface{}{
+			"name":    stringSchema(),
+			"healthy": map[string]interface{}{"type": "integer"},
+			"total":   map[string]interface{}{"type": "integer"},
+			"endpoints": map[string]interface{}{"type": "array", "items": map[string]interface{}{
+				"type": "object",
+			}},
+		}, "name", "healthy", "total")},
+	}, "version", "pools"),
+}
+
+var openAPIDocument = struct {
+	once sync.Once
+	doc  map[string]interface{}
+}{}
+
+func (h *handler) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get(proxy.RequestIDHeader)
+	if r.Method != http.MethodGet {
+		writeError(w, requestID, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	openAPIDocument.once.Do(func() {
+		openAPIDocument.doc = buildOpenAPI(adminOperations, adminSchemas)
+	})
+	writeJSON(w, requestID, http.StatusOK, openAPIDocument.doc)
+}
+
+func buildOpenAPI(operations []apiOperation, schemas map[string]interface{}) map[string]interface{} {
+	paths := make(map[string]interface{})
+	for _, op := range operations {
+		item, ok := paths[op.path].(map[string]interface{})
+		if !ok {
+			item = make(map[string]interface{})
+			paths[op.path] = item
+		}
+		item[strings.ToLower(op.method)] = operationSpec(op)
+	}
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "Proxy Admin API",
+			"version": openAPIVersion,
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"bearerToken": map[string]interface{}{"type": "http", "scheme": "bearer"},
+			},
+		},
+		"security": []interface{}{map[string]interface{}{"bearerToken": []string{}}},
+	}
+}
+
+func operationSpec(op apiOperation) map[string]interface{} {
+	spec := map[string]interface{}{
+		"operationId": op.id,
+		"summary":     op.summary,
+	}
+	var parameters []interface{}
+	for _, name := range pathParameters(op.path) {
+		parameters = append(parameters, map[string]interface{}{"name": name, "in": "path", "required": true, "schema": stringSchema()})
+	}
+	for _, name := range op.query {
+		parameters = append(parameters, map[string]interface{}{"name": name, "in": "query", "schema": stringSchema()})
+	}
+	if len(parameters) > 0 {
+		spec["parameters"] = parameters
+	}
+	if op.request != "" {
+		spec["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  map[string]interface{}{"application/json": map[string]interface{}{"schema": schemaRef(op.request)}},
+		}
+	}
+	success := map[string]interface{}{"description": "OK"}
+	switch {
+	case op.produces != "":
+		success["content"] = map[string]interface{}{op.produces: map[string]interface{}{}}
+	case op.response != "":
+		success["content"] = map[string]interface{}{"application/json": map[string]interface{}{"schema": schemaRef(op.response)}}
+	default:
+		success["content"] = map[string]interface{}{"application/json": map[string]interface{}{"schema": map[string]interface{}{"type": "object"}}}
+	}
+	spec["responses"] = map[string]interface{}{
+		"200": success,
+		"default": map[string]interface{}{
+			"description": "Error",
+			"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": schemaRef("Error")}},
+		},
+	}
+	return spec
+}
+
+func pathParameters(path string) []string {
+	var names []string
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			names = append(names, strings.TrimSuffix(strings.TrimPrefix(segment, "{"), "}"))
+		}
+	}
+	return names
+}
+
+func objectSchema(properties map[string]interface{}, required ...string) map[string]interface{} {
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+func stringSchema() map[string]interface{} {
+	return map[string]interface{}{"type": "string"}
+}
+
+func schemaRef(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
@@ -0,0 +1,51 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/proxy"
+)
+
+const (
+	defaultSlowRoutesLimit  = 10
+	maxSlowRoutesLimit      = 100
+	defaultSlowRoutesWindow = 5 * time.Minute
+)
+
+func (h *handler) handleSlowRoutes(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get(proxy.RequestIDHeader)
+	if r.Method != http.MethodGet {
+		writeError(w, requestID, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.slowRoutes == nil {
+		writeError(w, requestID, http.StatusServiceUnavailable, "slow route tracker unavailable")
+		return
+	}
+	limit := defaultSlowRoutesLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxSlowRoutesLimit {
+			writeError(w, requestID, http.StatusBadRequest, "limit must be between 1 and 100")
+			return
+		}
+		limit = parsed
+	}
+	window := defaultSlowRoutesWindow
+	if value := r.URL.Query().Get("window"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < time.Minute || parsed > obs.SlowRouteMaxWindow {
+			writeError(w, requestID, http.StatusBadRequest, "window must be between 1m and 1h")
+			return
+		}
+		window = parsed
+	}
+	routes := h.slowRoutes.Top(limit, window)
+	if routes == nil {
+		routes = []obs.SlowRouteSummary{}
+	}
+	writeJSON(w, requestID, http.StatusOK, map[string]interface{}{"window": window.String(), "routes": routes})
+}
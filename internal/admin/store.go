@@ -4,19 +4,21 @@ import (
 	"sync"
 
 	"modern_reverse_proxy/internal/bundle"
+	"modern_reverse_proxy/internal/proxy"
 )
 
 const bundleHistoryLimit = 20
 
 type Store struct {
-	mu       sync.RWMutex
-	current  string
-	previous []string
-	bundles  map[string]bundle.Bundle
+	mu          sync.RWMutex
+	current     string
+	previous    []string
+	bundles     map[string]bundle.Bundle
+	maintenance map[string]proxy.Maintenance
 }
 
 func NewStore() *Store {
-	return &Store{bundles: make(map[string]bundle.Bundle)}
+	return &Store{bundles: make(map[string]bundle.Bundle), maintenance: make(map[string]proxy.Maintenance)}
 }
 
 func (s *Store) Record(entry bundle.Bundle) {
@@ -36,6 +38,27 @@ func (s *Store) Record(entry bundle.Bundle) {
 	}
 	s.current = entry.Meta.Version
 	s.bundles[entry.Meta.Version] = entry
+	s.pruneLocked()
+}
+
+func (s *Store) History() []bundle.Bundle {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	history := make([]bundle.Bundle, 0, len(s.previous)+1)
+	seen := make(map[string]struct{}, len(s.previous)+1)
+	for _, version := range append([]string{s.current}, s.previous...) {
+		if _, ok := seen[version]; ok {
+			continue
+		}
+		seen[version] = struct{}{}
+		if item, ok := s.bundles[version]; ok {
+			history = append(history, item)
+		}
+	}
+	return history
 }
 
 func (s *Store) Get(version string) (bundle.Bundle, bool) {
@@ -83,3 +106,64 @@ func (s *Store) CurrentVersion() string {
 	defer s.mu.RUnlock()
 	return s.current
 }
+
+func (s *Store) pruneLocked() {
+	keep := make(map[string]struct{}, len(s.previous)+1)
+	keep[s.current] = struct{}{}
+	for _, version := range s.previous {
+		keep[version] = struct{}{}
+	}
+	for version := range s.bundles {
+		if _, ok := keep[version]; !ok {
+			delete(s.bundles, version)
+		}
+	}
+}
+
+func (s *Store) DisableRoute(routeID string, maintenance proxy.Maintenance) {
+	if s == nil || routeID == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.maintenance == nil {
+		s.maintenance = make(map[string]proxy.Maintenance)
+	}
+	s.maintenance[routeID] = maintenance
+}
+
+func (s *Store) EnableRoute(routeID string) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.maintenance[routeID]; !ok {
+		return false
+	}
+	delete(s.maintenance, routeID)
+	return true
+}
+
+func (s *Store) RouteMaintenance(routeID string) (proxy.Maintenance, bool) {
+	if s == nil {
+		return proxy.Maintenance{}, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	maintenance, ok := s.maintenance[routeID]
+	return maintenance, ok
+}
+
+func (s *Store) DisabledRoutes() map[string]proxy.Maintenance {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make(map[string]proxy.Maintenance, len(s.maintenance))
+	for routeID, maintenance := range s.maintenance {
+		result[routeID] = maintenance
+	}
+	return result
+}
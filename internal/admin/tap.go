@@ -0,0 +1,99 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/proxy"
+)
+
+const (
+	defaultTapSampleRate = 0.01
+	tapKeepaliveInterval = 15 * time.Second
+)
+
+func (h *handler) handleTap(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get(proxy.RequestIDHeader)
+	if r.Method != http.MethodGet {
+		writeError(w, requestID, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.tap == nil {
+		writeError(w, requestID, http.StatusServiceUnavailable, "traffic tap unavailable")
+		return
+	}
+	query := r.URL.Query()
+	filter := obs.TapFilter{Route: query.Get("route"), SampleRate: defaultTapSampleRate}
+	if value := query.Get("sample"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed <= 0 || parsed > 1 {
+			writeError(w, requestID, http.StatusBadRequest, "sample must be greater than 0 and at most 1")
+			return
+		}
+		filter.SampleRate = parsed
+	}
+	if value := query.Get("body_bytes"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 || parsed > obs.TapMaxBodyBytes {
+			writeError(w, requestID, http.StatusBadRequest, fmt.Sprintf("body_bytes must be between 0 and %d", obs.TapMaxBodyBytes))
+			return
+		}
+		filter.BodyBytes = parsed
+	}
+	if filter.Route != "" && h.store != nil {
+		if snap := h.store.Get(); snap != nil && snap.Router != nil {
+			if _, ok := snap.Router.Route(filter.Route); !ok {
+				writeError(w, requestID, http.StatusNotFound, "route not found")
+				return
+			}
+		}
+	}
+	controller := http.NewResponseController(w)
+	sub, ok := h.tap.Subscribe(filter)
+	if !ok {
+		writeError(w, requestID, http.StatusTooManyRequests, "too many tap subscribers")
+		return
+	}
+	defer h.tap.Unsubscribe(sub)
+	_ = controller.SetWriteDeadline(time.Time{})
+	logger.Info("admin_tap_start", "request_id", requestID, "route", filter.Route, "sample", filter.SampleRate, "body_bytes", filter.BodyBytes)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if err := controller.Flush(); err != nil {
+		logger.Warn("admin_tap_flush_error", "request_id", requestID, "error", err)
+		return
+	}
+
+	keepalive := time.NewTicker(tapKeepaliveInterval)
+	defer keepalive.Stop()
+	sent := 0
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			logger.Info("admin_tap_stop", "request_id", requestID, "events", sent, "dropped", sub.Dropped())
+			return
+		case event := <-sub.Events():
+			var data []byte
+			if data, err = json.Marshal(event); err == nil {
+				_, err = fmt.Fprintf(w, "event: tap\ndata: %s\n\n", data)
+				sent++
+			}
+		case <-keepalive.C:
+			_, err = fmt.Fprintf(w, ": keepalive dropped=%d\n\n", sub.Dropped())
+		}
+		if err == nil {
+			err = controller.Flush()
+		}
+		if err != nil {
+			logger.Info("admin_tap_stop", "request_id", requestID, "events", sent, "dropped", sub.Dropped(), "reason", err)
+			return
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package admin
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"modern_reverse_proxy/internal/pool"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/router"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+const testRouteMaxBodyBytes = 64 * 1024
+
+type testRouteRequest struct {
+	Method   string            `json:"method"`
+	Host     string            `json:"host"`
+	Path     string            `json:"path"`
+	Headers  map[string]string `json:"headers"`
+	Body     string            `json:"body"`
+	Listener string            `json:"listener"`
+	Execute  bool              `json:"execute"`
+}
+
+type testRouteResult struct {
+	Version  string             `json:"version"`
+	Matched  bool               `json:"matched"`
+	Route    *routeView         `json:"route,omitempty"`
+	Pool     string             `json:"pool,omitempty"`
+	PoolKey  string             `json:"pool_key,omitempty"`
+	Variant  string             `json:"variant,omitempty"`
+	Endpoint *testRouteEndpoint `json:"endpoint,omitempty"`
+	Pools    []poolView         `json:"pools,omitempty"`
+	Response *testRouteResponse `json:"response,omitempty"`
+	Notes    []string           `json:"notes,omitempty"`
+}
+
+type testRouteEndpoint struct {
+	Addr            string `json:"addr"`
+	Healthy         bool   `json:"healthy"`
+	FailOpen        bool   `json:"fail_open,omitempty"`
+	Zone            string `json:"zone,omitempty"`
+	OutlierIgnored  bool   `json:"outlier_ignored,omitempty"`
+	EndpointEjected bool   `json:"endpoint_ejected,omitempty"`
+}
+
+type testRouteResponse struct {
+	Status        int                 `json:"status"`
+	Headers       map[string][]string `json:"headers"`
+	Body          string              `json:"body"`
+	BodyTruncated bool                `json:"body_truncated,omitempty"`
+	DurationMS    int64               `json:"duration_ms"`
+}
+
+func (h *handler) handleTestRoute(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get(proxy.RequestIDHeader)
+	if r.Method != http.MethodPost {
+		writeError(w, requestID, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.store == nil {
+		writeError(w, requestID, http.StatusServiceUnavailable, "snapshot unavailable")
+		return
+	}
+	snap := h.store.Get()
+	if snap == nil {
+		writeError(w, requestID, http.StatusNotFound, "snapshot missing")
+		return
+	}
+	var payload testRouteRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, requestID, http.StatusBadRequest, "invalid body")
+		return
+	}
+	if payload.Method == "" {
+		payload.Method = http.MethodGet
+	}
+	if payload.Path == "" {
+		payload.Path = "/"
+	}
+	if payload.Host == "" || !strings.HasPrefix(payload.Path, "/") {
+		writeError(w, requestID, http.StatusBadRequest, "host and an absolute path are required")
+		return
+	}
+	if payload.Execute && h.proxy == nil {
+		writeError(w, requestID, http.StatusServiceUnavailable, "proxy handler unavailable")
+		return
+	}
+	synthetic, err := http.NewRequestWithContext(router.WithListener(r.Context(), payload.Listener), strings.ToUpper(payload.Method), "http://"+payload.Host+payload.Path, strings.NewReader(payload.Body))
+	if err != nil {
+		writeError(w, requestID, http.StatusBadRequest, "invalid request: "+err.Error())
+		return
+	}
+	for name, value := range payload.Headers {
+		synthetic.Header.Set(name, value)
+	}
+	synthetic.Host = payload.Host
+	synthetic.RemoteAddr = r.RemoteAddr
+
+	result := testRouteResult{Version: snap.Version}
+	route, ok := snap.Router.Match(synthetic)
+	if !ok {
+		writeJSON(w, requestID, http.StatusOK, result)
+		return
+	}
+	view := h.routeView(route)
+	result.Matched = true
+	result.Route = &view
+	result.Pool = route.PoolName
+	result.Variant = string(traffic.VariantStable)
+	stablePoolKey := route.StablePoolKey
+	if stablePoolKey == "" {
+		stablePoolKey = route.ID + "::" + route.PoolName
+	}
+	if route.Policy.Script.Program != nil {
+		result.Notes = append(result.Notes, "route script may select a different pool at request time")
+	}
+	if route.TrafficPlan != nil {
+		variant, _ := route.TrafficPlan.PickVariant(synthetic)
+		result.Variant = string(variant)
+		if variant == traffic.VariantCanary && route.CanaryPoolName != "" {
+			result.Pool = route.CanaryPoolName
+			stablePoolKey = route.CanaryPoolKey
+			if stablePoolKey == "" {
+				stablePoolKey = route.ID + "::" + route.CanaryPoolName
+			}
+		}
+	}
+	poolKey, ok := snap.Pools[result.Pool]
+	if !ok || poolKey == "" {
+		result.Notes = append(result.Notes, "pool not found in snapshot")
+	} else {
+		result.PoolKey = string(poolKey)
+		result.Pools = []poolView{h.poolView(snap, result.Pool)}
+		if h.registry != nil {
+			if pick, ok := h.registry.Pick(poolKey, func(addr string, now time.Time) bool {
+				return h.outlier != nil && h.outlier.IsEjected(stablePoolKey, addr, now)
+			}); ok && pick.Addr != "" {
+				result.Endpoint = testRouteEndpointFromPick(pick)
+			}
+		}
+	}
+
+	if payload.Execute {
+		synthetic.Header.Set(proxy.RequestIDHeader, requestID)
+		recorder := &testRouteRecorder{header: make(http.Header), status: http.StatusOK}
+		start := time.Now()
+		h.proxy.ServeHTTP(recorder, synthetic)
+		result.Response = &testRouteResponse{
+			Status:        recorder.status,
+			Headers:       recorder.header,
+			Body:          recorder.body.String(),
+			BodyTruncated: recorder.truncated,
+			DurationMS:    time.Since(start).Milliseconds(),
+		}
+		logger.Info("admin_test_route", "request_id", requestID, "method", synthetic.Method, "host", payload.Host, "path", payload.Path, "route", route.ID, "status", recorder.status)
+	}
+	writeJSON(w, requestID, http.StatusOK, result)
+}
+
+func testRouteEndpointFromPick(pick pool.PickResult) *testRouteEndpoint {
+	return &testRouteEndpoint{
+		Addr:            pick.Addr,
+		Healthy:         pick.SelectedHealthy,
+		FailOpen:        pick.SelectedFailOpen,
+		Zone:            pick.Zone,
+		OutlierIgnored:  pick.OutlierIgnored,
+		EndpointEjected: pick.EndpointEjected,
+	}
+}
+
+type testRouteRecorder struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+	truncated   bool
+}
+
+func (r *testRouteRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *testRouteRecorder) WriteHeader(status int) {
+	if r.wroteHeader {
+		return
+	}
+	r.status = status
+	r.wroteHeader = true
+}
+
+func (r *testRouteRecorder) Write(data []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	if remaining := testRouteMaxBodyBytes - r.body.Len(); remaining < len(data) {
+		r.body.Write(data[:max(remaining, 0)])
+		r.truncated = true
+		return len(data), nil
+	}
+	return r.body.Write(data)
+}
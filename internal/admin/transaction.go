@@ -0,0 +1,155 @@
+package admin
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"modern_reverse_proxy/internal/bundle"
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/logging"
+	"modern_reverse_proxy/internal/proxy"
+)
+
+var logger = logging.For("admin")
+
+const (
+	opUpsertPool  = "upsert_pool"
+	opDeletePool  = "delete_pool"
+	opUpsertRoute = "upsert_route"
+	opDeleteRoute = "delete_route"
+)
+
+var errStaleBase = errors.New("base version mismatch")
+
+type transactionRequest struct {
+	BaseVersion string                 `json:"base_version"`
+	Operations  []transactionOperation `json:"operations"`
+}
+
+type transactionOperation struct {
+	Op    string        `json:"op"`
+	ID    string        `json:"id"`
+	Name  string        `json:"name"`
+	Route *config.Route `json:"route"`
+	Pool  *config.Pool  `json:"pool"`
+}
+
+func (h *handler) handleTransaction(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get(proxy.RequestIDHeader)
+	if r.Method != http.MethodPost {
+		writeError(w, requestID, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.keyring.Len() > 0 && !h.allowUnsigned {
+		writeError(w, requestID, http.StatusForbidden, "unsigned apply disabled")
+		return
+	}
+	if h.apply == nil {
+		writeError(w, requestID, http.StatusServiceUnavailable, "apply unavailable")
+		return
+	}
+	var payload transactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, requestID, http.StatusBadRequest, "invalid body")
+		return
+	}
+	if len(payload.Operations) == 0 {
+		writeError(w, requestID, http.StatusBadRequest, "operations required")
+		return
+	}
+
+	result, raw, err := h.apply.Transact(r.Context(), "admin", func(cfg *config.Config) error {
+		if payload.BaseVersion != "" && h.currentVersion() != payload.BaseVersion {
+			return errStaleBase
+		}
+		for i, op := range payload.Operations {
+			if err := applyTransactionOp(cfg, op); err != nil {
+				return fmt.Errorf("operation %d: %w", i, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		status, message := applyErrorStatus(err)
+		if errors.Is(err, errStaleBase) {
+			status = http.StatusConflict
+		}
+		logger.Warn("admin_transaction", "request_id", requestID, "operations", len(payload.Operations), "result", "error", "reason", message)
+		writeError(w, requestID, status, message)
+		return
+	}
+	if h.adminStore != nil {
+		configHash, _ := bundle.HashConfig(raw)
+		h.adminStore.Record(bundle.Bundle{
+			Meta: bundle.Meta{
+				Version:   result.Version,
+				CreatedAt: time.Now().UTC().Format(time.RFC3339Nano),
+				Source:    "admin",
+			},
+			ConfigBytesB64: base64.StdEncoding.EncodeToString(raw),
+			ConfigSHA256:   configHash,
+		})
+	}
+	reuse := result.Snapshot.Reuse
+	logger.Info("admin_transaction", "request_id", requestID, "operations", len(payload.Operations), "version", result.Version, "routes_reused", reuse.RoutesReused, "pools_reused", reuse.PoolsReused, "result", "success")
+	response := map[string]interface{}{"applied": true, "version": result.Version, "operations": len(payload.Operations), "reuse": reuse}
+	if len(result.Warnings) > 0 {
+		response["warnings"] = result.Warnings
+	}
+	writeJSON(w, requestID, http.StatusOK, response)
+}
+
+func (h *handler) currentVersion() string {
+	if h.store == nil {
+		return ""
+	}
+	snap := h.store.Get()
+	if snap == nil {
+		return ""
+	}
+	return snap.Version
+}
+
+func applyTransactionOp(cfg *config.Config, op transactionOperation) error {
+	switch op.Op {
+	case opUpsertPool:
+		if op.Name == "" || op.Pool == nil {
+			return errors.New("upsert_pool requires name and pool")
+		}
+		if cfg.Pools == nil {
+			cfg.Pools = make(map[string]config.Pool)
+		}
+		cfg.Pools[op.Name] = *op.Pool
+	case opDeletePool:
+		if _, ok := cfg.Pools[op.Name]; !ok {
+			return fmt.Errorf("pool %q not found", op.Name)
+		}
+		delete(cfg.Pools, op.Name)
+	case opUpsertRoute:
+		if op.Route == nil || op.Route.ID == "" {
+			return errors.New("upsert_route requires route with id")
+		}
+		for i := range cfg.Routes {
+			if cfg.Routes[i].ID == op.Route.ID {
+				cfg.Routes[i] = *op.Route
+				return nil
+			}
+		}
+		cfg.Routes = append(cfg.Routes, *op.Route)
+	case opDeleteRoute:
+		for i := range cfg.Routes {
+			if cfg.Routes[i].ID == op.ID {
+				cfg.Routes = append(cfg.Routes[:i], cfg.Routes[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("route %q not found", op.ID)
+	default:
+		return fmt.Errorf("unknown op %q", op.Op)
+	}
+	return nil
+}
@@ -5,11 +5,12 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
-	"log"
+	"sync"
 	"time"
 
 	"modern_reverse_proxy/internal/breaker"
 	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/logging"
 	"modern_reverse_proxy/internal/obs"
 	"modern_reverse_proxy/internal/outlier"
 	"modern_reverse_proxy/internal/provider"
@@ -18,6 +19,8 @@ import (
 	"modern_reverse_proxy/internal/traffic"
 )
 
+var logger = logging.For("apply")
+
 const DefaultMaxConfigBytes = 10 * 1024 * 1024
 const DefaultCompileTimeout = 2 * time.Second
 
@@ -32,6 +35,7 @@ var (
 	ErrConfigTooLarge = errors.New("config too large")
 	ErrCompileTimeout = errors.New("compile timeout")
 	ErrPressure       = errors.New("config_pressure")
+	ErrNoProviders    = errors.New("no config providers to reload")
 )
 
 type PressureChecker interface {
@@ -62,6 +66,10 @@ type Manager struct {
 	maxConfigBytes  int
 	compileTimeout  time.Duration
 	pressure        PressureChecker
+
+	applyMu   sync.Mutex
+	currentMu sync.RWMutex
+	current   *config.Config
 }
 
 type Result struct {
@@ -69,6 +77,7 @@ type Result struct {
 	Version  string
 	Config   *config.Config
 	Warnings []string
+	Impact   *Impact
 }
 
 func NewManager(cfg ManagerConfig) *Manager {
@@ -90,6 +99,12 @@ func (m *Manager) Apply(ctx context.Context, raw []byte, source string, mode Mod
 	if m == nil {
 		return nil, errors.New("apply manager is nil")
 	}
+	m.applyMu.Lock()
+	defer m.applyMu.Unlock()
+	return m.apply(ctx, raw, source, mode, nil)
+}
+
+func (m *Manager) apply(ctx context.Context, raw []byte, source string, mode Mode, base *runtime.Snapshot) (*Result, error) {
 	start := time.Now()
 	defer func() {
 		metrics := obs.DefaultMetrics()
@@ -138,6 +153,7 @@ func (m *Manager) Apply(ctx context.Context, raw []byte, source string, mode Mod
 	outlierReg := m.outlierRegistry
 	trafficReg := m.trafficRegistry
 	if mode == ModeValidate {
+		base = nil
 		reg = registry.NewRegistry(0, 0)
 		breakerReg = breaker.NewRegistry(0, 0)
 		outlierReg = outlier.NewRegistry(0, 0, nil)
@@ -149,7 +165,7 @@ func (m *Manager) Apply(ctx context.Context, raw []byte, source string, mode Mod
 	}
 
 	providers := m.buildProviders(cfg)
-	compiled, resolvedCfg, warnings, err := m.compile(ctx, providers, reg, breakerReg, outlierReg, trafficReg)
+	compiled, resolvedCfg, warnings, err := m.compile(ctx, providers, base, reg, breakerReg, outlierReg, trafficReg)
 	if err != nil {
 		metrics := obs.DefaultMetrics()
 		if metrics != nil {
@@ -163,6 +179,11 @@ func (m *Manager) Apply(ctx context.Context, raw []byte, source string, mode Mod
 
 	compiled.Version = version
 	compiled.Source = source
+	provenance, err := runtime.ProvenanceFromConfig(cfg.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	compiled.Provenance = compiled.Provenance.Merge(provenance)
 
 	if mode == ModeApply {
 		if m.store != nil {
@@ -170,10 +191,19 @@ func (m *Manager) Apply(ctx context.Context, raw []byte, source string, mode Mod
 				return nil, err
 			}
 		}
+		m.setCurrent(cfg)
 	}
 
 	logValidationWarnings(warnings)
-	return &Result{Snapshot: compiled, Version: version, Config: resolvedCfg, Warnings: warnings}, nil
+	result := &Result{Snapshot: compiled, Version: version, Config: resolvedCfg, Warnings: warnings}
+	if mode == ModeValidate {
+		var current *runtime.Snapshot
+		if m.store != nil {
+			current = m.store.Get()
+		}
+		result.Impact = computeImpact(m.registry, reg, current, compiled)
+	}
+	return result, nil
 }
 
 func (m *Manager) buildProviders(cfg *config.Config) []provider.Provider {
@@ -229,6 +259,6 @@ func logValidationWarnings(warnings []string) {
 		if warning == "" {
 			continue
 		}
-		log.Printf("config_warning=%s", warning)
+		logger.Warn("config_warning", "warning", warning)
 	}
 }
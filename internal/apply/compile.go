@@ -20,7 +20,7 @@ type compileResult struct {
 	err      error
 }
 
-func (m *Manager) compile(ctx context.Context, providers []provider.Provider, reg *registry.Registry, breakerReg *breaker.Registry, outlierReg *outlier.Registry, trafficReg *traffic.Registry) (*runtime.Snapshot, *config.Config, []string, error) {
+func (m *Manager) compile(ctx context.Context, providers []provider.Provider, base *runtime.Snapshot, reg *registry.Registry, breakerReg *breaker.Registry, outlierReg *outlier.Registry, trafficReg *traffic.Registry) (*runtime.Snapshot, *config.Config, []string, error) {
 	timeout := m.compileTimeout
 	if timeout <= 0 {
 		timeout = DefaultCompileTimeout
@@ -40,7 +40,7 @@ func (m *Manager) compile(ctx context.Context, providers []provider.Provider, re
 			resultCh <- compileResult{err: err}
 			return
 		}
-		snapshot, err := runtime.BuildSnapshot(resolvedCfg, reg, breakerReg, outlierReg, trafficReg)
+		snapshot, err := runtime.BuildSnapshotDelta(resolvedCfg, base, reg, breakerReg, outlierReg, trafficReg)
 		resultCh <- compileResult{snapshot: snapshot, cfg: resolvedCfg, warnings: warnings, err: err}
 	}()
 
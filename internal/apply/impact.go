@@ -0,0 +1,127 @@
+package apply
+
+import (
+	"sort"
+
+	"modern_reverse_proxy/internal/pool"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+)
+
+type Impact struct {
+	PoolsAdded        []string            `json:"pools_added"`
+	PoolsRemoved      []string            `json:"pools_removed"`
+	PoolsReconciled   []string            `json:"pools_reconciled"`
+	EndpointsAdded    map[string][]string `json:"endpoints_added"`
+	EndpointsDraining map[string][]string `json:"endpoints_draining"`
+	TransportsRebuilt []string            `json:"transports_rebuilt"`
+	TLS               TLSImpact           `json:"tls"`
+}
+
+type TLSImpact struct {
+	Changed       bool   `json:"changed"`
+	EnabledBefore bool   `json:"enabled_before"`
+	EnabledAfter  bool   `json:"enabled_after"`
+	AddrBefore    string `json:"addr_before,omitempty"`
+	AddrAfter     string `json:"addr_after,omitempty"`
+}
+
+func computeImpact(live *registry.Registry, next *registry.Registry, current *runtime.Snapshot, compiled *runtime.Snapshot) *Impact {
+	impact := &Impact{
+		PoolsAdded:        []string{},
+		PoolsRemoved:      []string{},
+		PoolsReconciled:   []string{},
+		EndpointsAdded:    map[string][]string{},
+		EndpointsDraining: map[string][]string{},
+		TransportsRebuilt: []string{},
+	}
+
+	liveKeys := make(map[pool.PoolKey]struct{})
+	if live != nil {
+		for _, key := range live.PoolKeys() {
+			liveKeys[key] = struct{}{}
+		}
+	}
+	for _, key := range next.PoolKeys() {
+		name := string(key)
+		nextEndpoints, _ := next.Endpoints(key)
+		if _, ok := liveKeys[key]; !ok {
+			impact.PoolsAdded = append(impact.PoolsAdded, name)
+			if len(nextEndpoints) > 0 {
+				impact.EndpointsAdded[name] = sortedCopy(nextEndpoints)
+			}
+			continue
+		}
+		delete(liveKeys, key)
+		impact.PoolsReconciled = append(impact.PoolsReconciled, name)
+
+		liveEndpoints, _ := live.Endpoints(key)
+		added, removed := diffEndpoints(liveEndpoints, nextEndpoints)
+		if len(added) > 0 {
+			impact.EndpointsAdded[name] = added
+		}
+		if len(removed) > 0 {
+			impact.EndpointsDraining[name] = removed
+		}
+
+		liveOpts, liveOK := live.TransportOptions(key)
+		nextOpts, nextOK := next.TransportOptions(key)
+		if liveOK && nextOK && liveOpts != nextOpts {
+			impact.TransportsRebuilt = append(impact.TransportsRebuilt, name)
+		}
+	}
+	for key := range liveKeys {
+		name := string(key)
+		impact.PoolsRemoved = append(impact.PoolsRemoved, name)
+		if endpoints, _ := live.Endpoints(key); len(endpoints) > 0 {
+			impact.EndpointsDraining[name] = sortedCopy(endpoints)
+		}
+	}
+	sort.Strings(impact.PoolsAdded)
+	sort.Strings(impact.PoolsRemoved)
+	sort.Strings(impact.PoolsReconciled)
+	sort.Strings(impact.TransportsRebuilt)
+
+	if current != nil {
+		impact.TLS.EnabledBefore = current.TLSEnabled
+		if current.TLSEnabled {
+			impact.TLS.AddrBefore = current.TLSAddr
+		}
+	}
+	if compiled != nil {
+		impact.TLS.EnabledAfter = compiled.TLSEnabled
+		if compiled.TLSEnabled {
+			impact.TLS.AddrAfter = compiled.TLSAddr
+		}
+	}
+	impact.TLS.Changed = impact.TLS.EnabledBefore != impact.TLS.EnabledAfter || impact.TLS.AddrBefore != impact.TLS.AddrAfter
+	return impact
+}
+
+func diffEndpoints(before []string, after []string) ([]string, []string) {
+	beforeSet := make(map[string]struct{}, len(before))
+	for _, addr := range before {
+		beforeSet[addr] = struct{}{}
+	}
+	var added []string
+	for _, addr := range after {
+		if _, ok := beforeSet[addr]; ok {
+			delete(beforeSet, addr)
+			continue
+		}
+		added = append(added, addr)
+	}
+	removed := make([]string, 0, len(beforeSet))
+	for addr := range beforeSet {
+		removed = append(removed, addr)
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+func sortedCopy(values []string) []string {
+	out := append([]string(nil), values...)
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,71 @@
+package apply
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/runtime"
+)
+
+func (m *Manager) Reload(ctx context.Context, source string) (*Result, error) {
+	if m == nil {
+		return nil, errors.New("apply manager is nil")
+	}
+	m.applyMu.Lock()
+	defer m.applyMu.Unlock()
+
+	start := time.Now()
+	defer func() {
+		metrics := obs.DefaultMetrics()
+		if metrics == nil {
+			return
+		}
+		metrics.RecordConfigApplyDuration(time.Since(start))
+	}()
+	if m.pressure != nil && m.pressure.UnderPressure() {
+		return nil, ErrPressure
+	}
+
+	providers := m.providers
+	if m.adminProvider != nil {
+		providers = m.buildProviders(nil)
+	}
+	if len(providers) == 0 {
+		return nil, ErrNoProviders
+	}
+	var base *runtime.Snapshot
+	if m.store != nil {
+		base = m.store.Get()
+	}
+	compiled, resolvedCfg, warnings, err := m.compile(ctx, providers, base, m.registry, m.breakerRegistry, m.outlierRegistry, m.trafficRegistry)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(resolvedCfg)
+	if err != nil {
+		return nil, err
+	}
+	provenance, err := runtime.ProvenanceFromConfig(resolvedCfg.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	version := configVersion(raw)
+	compiled.Version = version
+	compiled.Source = source
+	compiled.Provenance = compiled.Provenance.Merge(provenance)
+	if m.store != nil {
+		if err := m.store.Swap(compiled); err != nil {
+			return nil, err
+		}
+	}
+
+	if m.adminProvider == nil {
+		m.setCurrent(resolvedCfg)
+	}
+
+	logValidationWarnings(warnings)
+	return &Result{Snapshot: compiled, Version: version, Config: resolvedCfg, Warnings: warnings}, nil
+}
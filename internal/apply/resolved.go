@@ -19,9 +19,16 @@ func (m *Manager) ApplyResolved(ctx context.Context, raw []byte, source string,
 }
 
 func (m *Manager) ApplyResolvedVersion(ctx context.Context, raw []byte, source string, version string, mode Mode) (*Result, error) {
+	return m.ApplyResolvedProvenance(ctx, raw, source, version, runtime.Provenance{}, mode)
+}
+
+func (m *Manager) ApplyResolvedProvenance(ctx context.Context, raw []byte, source string, version string, provenance runtime.Provenance, mode Mode) (*Result, error) {
 	if m == nil {
 		return nil, errors.New("apply manager is nil")
 	}
+	m.applyMu.Lock()
+	defer m.applyMu.Unlock()
+
 	start := time.Now()
 	defer func() {
 		metrics := obs.DefaultMetrics()
@@ -49,6 +56,9 @@ func (m *Manager) ApplyResolvedVersion(ctx context.Context, raw []byte, source s
 	if version == "" {
 		version = configVersion(raw)
 	}
+	if err := runtime.ValidateProvenance(provenance); err != nil {
+		return nil, err
+	}
 
 	reg := m.registry
 	breakerReg := m.breakerRegistry
@@ -72,6 +82,7 @@ func (m *Manager) ApplyResolvedVersion(ctx context.Context, raw []byte, source s
 
 	snapshot.Version = version
 	snapshot.Source = source
+	snapshot.Provenance = snapshot.Provenance.Merge(provenance)
 
 	if mode == ModeApply {
 		if m.store != nil {
@@ -79,6 +90,7 @@ func (m *Manager) ApplyResolvedVersion(ctx context.Context, raw []byte, source s
 				return nil, err
 			}
 		}
+		m.setCurrent(cfg)
 	}
 
 	logValidationWarnings(warnings)
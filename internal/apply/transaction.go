@@ -0,0 +1,65 @@
+package apply
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/runtime"
+)
+
+func (m *Manager) setCurrent(cfg *config.Config) {
+	m.currentMu.Lock()
+	m.current = cfg
+	m.currentMu.Unlock()
+}
+
+func (m *Manager) Current() *config.Config {
+	if m == nil {
+		return nil
+	}
+	m.currentMu.RLock()
+	defer m.currentMu.RUnlock()
+	return m.current
+}
+
+func (m *Manager) Transact(ctx context.Context, source string, mutate func(cfg *config.Config) error) (*Result, []byte, error) {
+	if m == nil {
+		return nil, nil, errors.New("apply manager is nil")
+	}
+	m.applyMu.Lock()
+	defer m.applyMu.Unlock()
+
+	cfg, err := cloneConfig(m.Current())
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := mutate(cfg); err != nil {
+		return nil, nil, err
+	}
+	raw, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	var base *runtime.Snapshot
+	if m.store != nil {
+		base = m.store.Get()
+	}
+	result, err := m.apply(ctx, raw, source, ModeApply, base)
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, raw, nil
+}
+
+func cloneConfig(cfg *config.Config) (*config.Config, error) {
+	if cfg == nil {
+		return &config.Config{}, nil
+	}
+	raw, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return config.ParseJSON(raw)
+}
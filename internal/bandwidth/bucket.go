@@ -0,0 +1,133 @@
+package bandwidth
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+const DefaultMaxClients = 10000
+
+type Bucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func NewBucket(bytesPerSec int64, burstBytes int64) *Bucket {
+	if bytesPerSec <= 0 {
+		return nil
+	}
+	if burstBytes <= 0 {
+		burstBytes = bytesPerSec
+	}
+	return &Bucket{
+		rate:   float64(bytesPerSec),
+		burst:  float64(burstBytes),
+		tokens: float64(burstBytes),
+		last:   time.Now(),
+	}
+}
+
+func (b *Bucket) Burst() int {
+	if b == nil {
+		return 0
+	}
+	return int(b.burst)
+}
+
+func (b *Bucket) Reserve(n int) time.Duration {
+	if b == nil || n <= 0 {
+		return 0
+	}
+	now := time.Now()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	b.tokens -= float64(n)
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+type Limiter struct {
+	mu         sync.Mutex
+	rate       int64
+	burst      int64
+	maxClients int
+	items      map[string]*list.Element
+	order      *list.List
+}
+
+type clientBucket struct {
+	key    string
+	bucket *Bucket
+}
+
+func NewLimiter(bytesPerSec int64, burstBytes int64, maxClients int) *Limiter {
+	if bytesPerSec <= 0 {
+		return nil
+	}
+	if maxClients <= 0 {
+		maxClients = DefaultMaxClients
+	}
+	return &Limiter{
+		rate:       bytesPerSec,
+		burst:      burstBytes,
+		maxClients: maxClients,
+		items:      make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+func (l *Limiter) Bucket(key string) *Bucket {
+	if l == nil || key == "" {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if element, ok := l.items[key]; ok {
+		l.order.MoveToFront(element)
+		return element.Value.(*clientBucket).bucket
+	}
+	state := &clientBucket{key: key, bucket: NewBucket(l.rate, l.burst)}
+	l.items[key] = l.order.PushFront(state)
+	if l.order.Len() > l.maxClients {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.items, oldest.Value.(*clientBucket).key)
+	}
+	return state.bucket
+}
+
+func (b *Bucket) Allow(n int) bool {
+	if b == nil || n <= 0 {
+		return true
+	}
+	now := time.Now()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	if b.tokens < float64(n) {
+		return false
+	}
+	b.tokens -= float64(n)
+	return true
+}
@@ -0,0 +1,91 @@
+package bandwidth
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+const maxChunkBytes = 32 * 1024
+
+type Writer struct {
+	writer  http.ResponseWriter
+	ctx     context.Context
+	buckets []*Bucket
+	chunk   int
+	waited  time.Duration
+}
+
+func NewWriter(ctx context.Context, w http.ResponseWriter, buckets ...*Bucket) *Writer {
+	active := make([]*Bucket, 0, len(buckets))
+	chunk := maxChunkBytes
+	for _, bucket := range buckets {
+		if bucket == nil {
+			continue
+		}
+		active = append(active, bucket)
+		if burst := bucket.Burst(); burst > 0 && burst < chunk {
+			chunk = burst
+		}
+	}
+	if len(active) == 0 {
+		return nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return &Writer{writer: w, ctx: ctx, buckets: active, chunk: chunk}
+}
+
+func (w *Writer) Header() http.Header {
+	return w.writer.Header()
+}
+
+func (w *Writer) WriteHeader(status int) {
+	w.writer.WriteHeader(status)
+}
+
+func (w *Writer) Write(data []byte) (int, error) {
+	written := 0
+	for len(data) > 0 {
+		size := len(data)
+		if size > w.chunk {
+			size = w.chunk
+		}
+		if err := w.wait(size); err != nil {
+			return written, err
+		}
+		n, err := w.writer.Write(data[:size])
+		written += n
+		if err != nil {
+			return written, err
+		}
+		data = data[size:]
+	}
+	return written, nil
+}
+
+func (w *Writer) Waited() time.Duration {
+	return w.waited
+}
+
+func (w *Writer) wait(n int) error {
+	var delay time.Duration
+	for _, bucket := range w.buckets {
+		if d := bucket.Reserve(n); d > delay {
+			delay = d
+		}
+	}
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		w.waited += delay
+		return nil
+	case <-w.ctx.Done():
+		return w.ctx.Err()
+	}
+}
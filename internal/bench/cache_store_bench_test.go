@@ -0,0 +1,40 @@
+package bench
+
+import (
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/cache"
+)
+
+func BenchmarkMemoryStoreParallel(b *testing.B) {
+	for _, shards := range []int{1, cache.DefaultMemoryShards} {
+		b.Run("shards="+strconv.Itoa(shards), func(b *testing.B) {
+			store := cache.NewShardedMemoryStore(0, shards)
+			keys := make([]string, 1024)
+			entry := cache.Entry{Status: 200, Body: []byte("cached"), ExpiresAt: time.Now().Add(time.Hour)}
+			for i := range keys {
+				keys[i] = "m=GET|h=example.local|u=/object/" + strconv.Itoa(i)
+				_ = store.Set(keys[i], entry)
+			}
+			var seed atomic.Uint64
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := seed.Add(7919)
+				for pb.Next() {
+					key := keys[i%uint64(len(keys))]
+					if i%10 == 0 {
+						_ = store.Set(key, entry)
+					} else {
+						store.Get(key)
+					}
+					i++
+				}
+			})
+		})
+	}
+}
@@ -48,6 +48,14 @@ type Breaker struct {
 	config        atomic.Value
 }
 
+type Status struct {
+	State              State
+	Requests           int
+	Failures           int
+	FailureRatePercent int
+	OpenUntil          time.Time
+}
+
 func New(cfg Config) *Breaker {
 	b := &Breaker{}
 	b.state.Store(int32(StateClosed))
@@ -139,6 +147,49 @@ func (b *Breaker) Report(success bool) (State, error) {
 	return State(b.state.Load()), nil
 }
 
+func (b *Breaker) Status() Status {
+	if b == nil {
+		return Status{State: StateClosed}
+	}
+	status := Status{
+		State:    State(b.state.Load()),
+		Requests: int(b.reqCount.Load()),
+		Failures: int(b.failCount.Load()),
+	}
+	if status.Requests > 0 {
+		status.FailureRatePercent = (status.Failures * 100) / status.Requests
+	}
+	if status.State == StateOpen {
+		status.OpenUntil = time.Unix(0, b.openUntil.Load())
+	}
+	return status
+}
+
+func (b *Breaker) Reset() State {
+	if b == nil {
+		return StateClosed
+	}
+	previous := State(b.state.Load())
+	b.openUntil.Store(0)
+	b.close(time.Now())
+	return previous
+}
+
+func (b *Breaker) Restore(state State, openUntil time.Time) {
+	if b == nil {
+		return
+	}
+	switch state {
+	case StateOpen:
+		b.openUntil.Store(openUntil.UnixNano())
+		b.resetProbes()
+		b.state.Store(int32(StateOpen))
+	case StateHalfOpen:
+		b.resetProbes()
+		b.state.Store(int32(StateHalfOpen))
+	}
+}
+
 func (b *Breaker) loadConfig() (Config, bool) {
 	value := b.config.Load()
 	if value == nil {
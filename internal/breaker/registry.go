@@ -97,6 +97,48 @@ func (r *Registry) Has(key string) bool {
 	return ok
 }
 
+func (r *Registry) Statuses() map[string]Status {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	statuses := make(map[string]Status, len(r.breakers))
+	for key, current := range r.breakers {
+		if current.config.Enabled {
+			statuses[key] = current.breaker.Status()
+		}
+	}
+	return statuses
+}
+
+func (r *Registry) Reset(key string) (State, bool) {
+	if r == nil {
+		return StateClosed, false
+	}
+	r.mu.Lock()
+	current, ok := r.breakers[key]
+	r.mu.Unlock()
+	if !ok {
+		return StateClosed, false
+	}
+	return current.breaker.Reset(), true
+}
+
+func (r *Registry) Restore(key string, status Status) {
+	if r == nil || key == "" {
+		return
+	}
+	r.mu.Lock()
+	current := r.breakers[key]
+	if current == nil {
+		current = &entry{breaker: New(Config{}), lastSeen: time.Now()}
+		r.breakers[key] = current
+	}
+	r.mu.Unlock()
+	current.breaker.Restore(status.State, status.OpenUntil)
+}
+
 func (r *Registry) ensure(key string, cfg Config) *entry {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -12,6 +12,10 @@ type Meta struct {
 	CreatedAt string `json:"created_at"`
 	Source    string `json:"source"`
 	Notes     string `json:"notes,omitempty"`
+	Label     string `json:"label,omitempty"`
+	GitSHA    string `json:"git_sha,omitempty"`
+	Author    string `json:"author,omitempty"`
+	KeyID     string `json:"key_id,omitempty"`
 }
 
 type Bundle struct {
@@ -35,6 +39,15 @@ func (b Bundle) ConfigHashBytes() ([]byte, error) {
 	return hex.DecodeString(b.ConfigSHA256)
 }
 
+func (b Bundle) ETag() string {
+	metaBytes, err := MetaBytes(b.Meta)
+	if err != nil {
+		return ""
+	}
+	checksum := sha256.Sum256(append(metaBytes, b.ConfigSHA256...))
+	return "\"" + hex.EncodeToString(checksum[:]) + "\""
+}
+
 func MetaBytes(meta Meta) ([]byte, error) {
 	return json.Marshal(meta)
 }
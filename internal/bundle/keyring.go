@@ -0,0 +1,233 @@
+package bundle
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+)
+
+var (
+	ErrUnknownKey     = errors.New("bundle signing key unknown")
+	ErrStaleKeyring   = errors.New("keyring version not newer than current")
+	ErrKeyringInvalid = errors.New("keyring invalid")
+)
+
+type KeyringEntry struct {
+	ID           string `json:"id"`
+	PublicKeyB64 string `json:"public_key_b64"`
+}
+
+type KeyringDocument struct {
+	Version      int64          `json:"version"`
+	Keys         []KeyringEntry `json:"keys"`
+	SignerKeyID  string         `json:"signer_key_id,omitempty"`
+	SignatureB64 string         `json:"signature_b64,omitempty"`
+}
+
+type Keyring struct {
+	mu      sync.RWMutex
+	version int64
+	keys    map[string]ed25519.PublicKey
+}
+
+func KeyID(publicKey ed25519.PublicKey) string {
+	sum := sha256.Sum256(publicKey)
+	return hex.EncodeToString(sum[:8])
+}
+
+func NewKeyring(keys ...ed25519.PublicKey) *Keyring {
+	keyring := &Keyring{keys: make(map[string]ed25519.PublicKey, len(keys))}
+	for _, key := range keys {
+		if len(key) != ed25519.PublicKeySize {
+			continue
+		}
+		keyring.keys[KeyID(key)] = key
+	}
+	return keyring
+}
+
+func KeyringFromDocument(doc KeyringDocument) (*Keyring, error) {
+	keys, err := doc.publicKeys()
+	if err != nil {
+		return nil, err
+	}
+	return &Keyring{version: doc.Version, keys: keys}, nil
+}
+
+func LoadKeyring(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseKeyring(data)
+}
+
+func ParseKeyring(data []byte) (*Keyring, error) {
+	var doc KeyringDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return KeyringFromDocument(doc)
+}
+
+func NewKeyringDocument(version int64, keys ...ed25519.PublicKey) KeyringDocument {
+	doc := KeyringDocument{Version: version}
+	for _, key := range keys {
+		doc.Keys = append(doc.Keys, KeyringEntry{ID: KeyID(key), PublicKeyB64: base64.StdEncoding.EncodeToString(key)})
+	}
+	return doc
+}
+
+func SignKeyring(doc KeyringDocument, privateKey ed25519.PrivateKey) (KeyringDocument, error) {
+	if len(privateKey) != ed25519.PrivateKeySize {
+		return KeyringDocument{}, errors.New("invalid private key")
+	}
+	input, err := doc.signatureInput()
+	if err != nil {
+		return KeyringDocument{}, err
+	}
+	doc.SignerKeyID = KeyID(privateKey.Public().(ed25519.PublicKey))
+	doc.SignatureB64 = base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, input))
+	return doc, nil
+}
+
+func (k *Keyring) Len() int {
+	if k == nil {
+		return 0
+	}
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return len(k.keys)
+}
+
+func (k *Keyring) Version() int64 {
+	if k == nil {
+		return 0
+	}
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.version
+}
+
+func (k *Keyring) KeyIDs() []string {
+	if k == nil {
+		return nil
+	}
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (k *Keyring) Lookup(id string) (ed25519.PublicKey, bool) {
+	if k == nil {
+		return nil, false
+	}
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	key, ok := k.keys[id]
+	return key, ok
+}
+
+func (k *Keyring) Verify(bundle Bundle) error {
+	if id := bundle.Meta.KeyID; id != "" {
+		key, ok := k.Lookup(id)
+		if !ok {
+			return ErrUnknownKey
+		}
+		return VerifyBundle(bundle, key)
+	}
+	if k == nil {
+		return ErrBadSignature
+	}
+	k.mu.RLock()
+	keys := make([]ed25519.PublicKey, 0, len(k.keys))
+	for _, key := range k.keys {
+		keys = append(keys, key)
+	}
+	k.mu.RUnlock()
+	for _, key := range keys {
+		err := VerifyBundle(bundle, key)
+		if err == nil || errors.Is(err, ErrBadHash) {
+			return err
+		}
+	}
+	return ErrBadSignature
+}
+
+func (k *Keyring) Rotate(doc KeyringDocument) error {
+	if k == nil {
+		return ErrKeyringInvalid
+	}
+	keys, err := doc.publicKeys()
+	if err != nil {
+		return err
+	}
+	signer, ok := k.Lookup(doc.SignerKeyID)
+	if !ok {
+		return ErrUnknownKey
+	}
+	input, err := doc.signatureInput()
+	if err != nil {
+		return err
+	}
+	sig, err := base64.StdEncoding.DecodeString(doc.SignatureB64)
+	if err != nil || !ed25519.Verify(signer, input, sig) {
+		return ErrBadSignature
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if doc.Version <= k.version {
+		return ErrStaleKeyring
+	}
+	k.version = doc.Version
+	k.keys = keys
+	return nil
+}
+
+func (d KeyringDocument) publicKeys() (map[string]ed25519.PublicKey, error) {
+	if len(d.Keys) == 0 {
+		return nil, fmt.Errorf("%w: no keys", ErrKeyringInvalid)
+	}
+	keys := make(map[string]ed25519.PublicKey, len(d.Keys))
+	for _, entry := range d.Keys {
+		raw, err := parseKeyData([]byte(entry.PublicKeyB64), ed25519.PublicKeySize)
+		if err != nil {
+			return nil, fmt.Errorf("%w: key %q: %v", ErrKeyringInvalid, entry.ID, err)
+		}
+		key := ed25519.PublicKey(raw)
+		id := entry.ID
+		if id == "" {
+			id = KeyID(key)
+		}
+		if _, exists := keys[id]; exists {
+			return nil, fmt.Errorf("%w: duplicate key id %q", ErrKeyringInvalid, id)
+		}
+		keys[id] = key
+	}
+	return keys, nil
+}
+
+func (d KeyringDocument) signatureInput() ([]byte, error) {
+	payload, err := json.Marshal(struct {
+		Version int64          `json:"version"`
+		Keys    []KeyringEntry `json:"keys"`
+	}{d.Version, d.Keys})
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(payload)
+	return sum[:], nil
+}
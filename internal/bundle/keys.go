@@ -13,6 +13,10 @@ func LoadPublicKey(path string) (ed25519.PublicKey, error) {
 	if err != nil {
 		return nil, err
 	}
+	return ParsePublicKey(data)
+}
+
+func ParsePublicKey(data []byte) (ed25519.PublicKey, error) {
 	key, err := parseKeyData(bytes.TrimSpace(data), ed25519.PublicKeySize)
 	if err != nil {
 		return nil, err
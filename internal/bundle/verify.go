@@ -37,3 +37,16 @@ func VerifyBundle(bundle Bundle, publicKey ed25519.PublicKey) error {
 	}
 	return nil
 }
+
+func VerifyResult(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, ErrBadHash):
+		return "bad_hash"
+	case errors.Is(err, ErrUnknownKey):
+		return "unknown_key"
+	default:
+		return "bad_sig"
+	}
+}
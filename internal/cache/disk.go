@@ -0,0 +1,401 @@
+package cache
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultDiskMaxBytes    int64 = 1024 * 1024 * 1024
+	diskIndexFile                = "index.json"
+	diskEntrySuffix              = ".entry"
+	diskTempPattern              = ".tmp-*"
+	diskIndexFlushInterval       = time.Second
+)
+
+type DiskStore struct {
+	mu             sync.Mutex
+	dir            string
+	maxBytes       int64
+	maxObjectBytes int64
+	size           int64
+	items          map[string]*list.Element
+	order          *list.List
+	dirty          bool
+	flushMu        sync.Mutex
+	stop           chan struct{}
+	done           chan struct{}
+	closeOnce      sync.Once
+}
+
+type diskItem struct {
+	Key       string    `json:"key"`
+	File      string    `json:"file"`
+	Size      int64     `json:"size"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type diskIndex struct {
+	Entries []diskItem `json:"entries"`
+}
+
+type diskRecord struct {
+	Key   string
+	Entry Entry
+}
+
+func NewDiskStore(dir string, maxBytes int64, maxObjectBytes int64) (*DiskStore, error) {
+	if strings.TrimSpace(dir) == "" {
+		return nil, errors.New("cache dir is required")
+	}
+	if maxBytes <= 0 {
+		maxBytes = DefaultDiskMaxBytes
+	}
+	if maxObjectBytes <= 0 {
+		maxObjectBytes = DefaultMaxObjectBytes
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	store := &DiskStore{
+		dir:            dir,
+		maxBytes:       maxBytes,
+		maxObjectBytes: maxObjectBytes,
+		items:          make(map[string]*list.Element),
+		order:          list.New(),
+		stop:           make(chan struct{}),
+		done:           make(chan struct{}),
+	}
+	if err := store.load(); err != nil {
+		return nil, err
+	}
+	go store.flushLoop()
+	return store, nil
+}
+
+func (d *DiskStore) Close() error {
+	if d == nil {
+		return nil
+	}
+	d.closeOnce.Do(func() {
+		close(d.stop)
+		<-d.done
+	})
+	return d.Flush()
+}
+
+func (d *DiskStore) Flush() error {
+	if d == nil {
+		return nil
+	}
+	d.flushMu.Lock()
+	defer d.flushMu.Unlock()
+	d.mu.Lock()
+	if !d.dirty {
+		d.mu.Unlock()
+		return nil
+	}
+	index := d.indexLocked()
+	d.dirty = false
+	d.mu.Unlock()
+	if err := writeDiskIndex(d.dir, index); err != nil {
+		d.mu.Lock()
+		d.dirty = true
+		d.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (d *DiskStore) flushLoop() {
+	defer close(d.done)
+	ticker := time.NewTicker(diskIndexFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			_ = d.Flush()
+		case <-d.stop:
+			return
+		}
+	}
+}
+
+func (d *DiskStore) Get(key string) (Entry, bool) {
+	if d == nil {
+		return Entry{}, false
+	}
+
+	d.mu.Lock()
+	element, ok := d.items[key]
+	if !ok {
+		d.mu.Unlock()
+		return Entry{}, false
+	}
+	item := element.Value.(*diskItem)
+	if !item.ExpiresAt.IsZero() && time.Now().After(item.ExpiresAt) {
+		d.removeLocked(element)
+		d.mu.Unlock()
+		return Entry{}, false
+	}
+	d.order.MoveToFront(element)
+	file := item.File
+	d.mu.Unlock()
+
+	record, err := readDiskRecord(filepath.Join(d.dir, file))
+	if err != nil || record.Key != key {
+		d.Delete(key)
+		return Entry{}, false
+	}
+	return record.Entry, true
+}
+
+func (d *DiskStore) Set(key string, entry Entry) error {
+	if d == nil {
+		return errors.New("cache store not initialized")
+	}
+	if d.maxObjectBytes > 0 && int64(len(entry.Body)) > d.maxObjectBytes {
+		return errors.New("cache entry exceeds max object bytes")
+	}
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(diskRecord{Key: key, Entry: entry}); err != nil {
+		return err
+	}
+	size := int64(buffer.Len())
+	if size > d.maxBytes {
+		return errors.New("cache entry exceeds max cache bytes")
+	}
+
+	file := diskFileName(key)
+	if err := writeFileAtomic(d.dir, file, buffer.Bytes()); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if element, ok := d.items[key]; ok {
+		d.size -= element.Value.(*diskItem).Size
+		d.order.Remove(element)
+		delete(d.items, key)
+	}
+	item := &diskItem{Key: key, File: file, Size: size, ExpiresAt: entry.ExpiresAt}
+	d.items[key] = d.order.PushFront(item)
+	d.size += size
+	d.dirty = true
+	d.evictLocked()
+	return nil
+}
+
+func (d *DiskStore) Delete(key string) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	element, ok := d.items[key]
+	if !ok {
+		return
+	}
+	d.removeLocked(element)
+}
+
+func (d *DiskStore) Size() int64 {
+	if d == nil {
+		return 0
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.size
+}
+
+func (d *DiskStore) Len() int {
+	if d == nil {
+		return 0
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.order.Len()
+}
+
+func (d *DiskStore) evictLocked() {
+	for d.size > d.maxBytes {
+		oldest := d.order.Back()
+		if oldest == nil {
+			return
+		}
+		d.removeLocked(oldest)
+	}
+}
+
+func (d *DiskStore) removeLocked(element *list.Element) {
+	item := element.Value.(*diskItem)
+	d.order.Remove(element)
+	delete(d.items, item.Key)
+	d.size -= item.Size
+	d.dirty = true
+	_ = os.Remove(filepath.Join(d.dir, item.File))
+}
+
+func (d *DiskStore) indexLocked() diskIndex {
+	index := diskIndex{Entries: make([]diskItem, 0, d.order.Len())}
+	for element := d.order.Front(); element != nil; element = element.Next() {
+		index.Entries = append(index.Entries, *element.Value.(*diskItem))
+	}
+	return index
+}
+
+func writeDiskIndex(dir string, index diskIndex) error {
+	data, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(dir, diskIndexFile, data)
+}
+
+func (d *DiskStore) load() error {
+	names, err := os.ReadDir(d.dir)
+	if err != nil {
+		return err
+	}
+	files := make(map[string]os.FileInfo)
+	for _, name := range names {
+		if name.IsDir() {
+			continue
+		}
+		if strings.HasPrefix(name.Name(), ".tmp-") {
+			_ = os.Remove(filepath.Join(d.dir, name.Name()))
+			continue
+		}
+		if !strings.HasSuffix(name.Name(), diskEntrySuffix) {
+			continue
+		}
+		info, err := name.Info()
+		if err != nil {
+			continue
+		}
+		files[name.Name()] = info
+	}
+
+	items, ok := readDiskIndex(filepath.Join(d.dir, diskIndexFile))
+	if !ok {
+		items = d.rebuildIndex(files)
+	}
+
+	now := time.Now()
+	for _, item := range items {
+		info, exists := files[item.File]
+		if !exists || info.Size() != item.Size || item.File != diskFileName(item.Key) {
+			continue
+		}
+		if _, duplicate := d.items[item.Key]; duplicate {
+			continue
+		}
+		delete(files, item.File)
+		if !item.ExpiresAt.IsZero() && now.After(item.ExpiresAt) {
+			_ = os.Remove(filepath.Join(d.dir, item.File))
+			continue
+		}
+		copied := item
+		d.items[item.Key] = d.order.PushBack(&copied)
+		d.size += item.Size
+	}
+	for _, item := range d.rebuildIndex(files) {
+		delete(files, item.File)
+		if _, duplicate := d.items[item.Key]; duplicate || item.File != diskFileName(item.Key) || (!item.ExpiresAt.IsZero() && now.After(item.ExpiresAt)) {
+			_ = os.Remove(filepath.Join(d.dir, item.File))
+			continue
+		}
+		copied := item
+		d.items[item.Key] = d.order.PushBack(&copied)
+		d.size += item.Size
+	}
+	for name := range files {
+		_ = os.Remove(filepath.Join(d.dir, name))
+	}
+	d.evictLocked()
+	d.dirty = false
+	return writeDiskIndex(d.dir, d.indexLocked())
+}
+
+func (d *DiskStore) rebuildIndex(files map[string]os.FileInfo) []diskItem {
+	items := make([]diskItem, 0, len(files))
+	modTimes := make(map[string]time.Time, len(files))
+	for name, info := range files {
+		record, err := readDiskRecord(filepath.Join(d.dir, name))
+		if err != nil {
+			continue
+		}
+		items = append(items, diskItem{Key: record.Key, File: name, Size: info.Size(), ExpiresAt: record.Entry.ExpiresAt})
+		modTimes[name] = info.ModTime()
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return modTimes[items[i].File].After(modTimes[items[j].File])
+	})
+	return items
+}
+
+func readDiskIndex(path string) ([]diskItem, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var index diskIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, false
+	}
+	return index.Entries, true
+}
+
+func readDiskRecord(path string) (diskRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return diskRecord{}, err
+	}
+	var record diskRecord
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&record); err != nil {
+		return diskRecord{}, err
+	}
+	return record, nil
+}
+
+func diskFileName(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:]) + diskEntrySuffix
+}
+
+func writeFileAtomic(dir string, name string, data []byte) error {
+	tmp, err := os.CreateTemp(dir, diskTempPattern)
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpName)
+		return err
+	}
+	if err := os.Rename(tmpName, filepath.Join(dir, name)); err != nil {
+		_ = os.Remove(tmpName)
+		return err
+	}
+	return nil
+}
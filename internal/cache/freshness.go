@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxDeltaSeconds = 1 << 31
+
+type Directives struct {
+	NoStore    bool
+	NoCache    bool
+	Private    bool
+	MaxAge     time.Duration
+	HasMaxAge  bool
+	SMaxAge    time.Duration
+	HasSMaxAge bool
+}
+
+func ParseCacheControl(header http.Header) Directives {
+	var directives Directives
+	for _, value := range header.Values("Cache-Control") {
+		for _, part := range strings.Split(value, ",") {
+			name, arg, _ := strings.Cut(strings.TrimSpace(part), "=")
+			arg = strings.Trim(strings.TrimSpace(arg), "\"")
+			switch strings.ToLower(strings.TrimSpace(name)) {
+			case "no-store":
+				directives.NoStore = true
+			case "no-cache":
+				directives.NoCache = true
+			case "private":
+				directives.Private = true
+			case "max-age":
+				if seconds, ok := parseSeconds(arg); ok {
+					directives.MaxAge = seconds
+					directives.HasMaxAge = true
+				}
+			case "s-maxage":
+				if seconds, ok := parseSeconds(arg); ok {
+					directives.SMaxAge = seconds
+					directives.HasSMaxAge = true
+				}
+			}
+		}
+	}
+	return directives
+}
+
+func FreshnessLifetime(header http.Header, now time.Time, fallback time.Duration) (time.Duration, bool) {
+	directives := ParseCacheControl(header)
+	if directives.NoStore || directives.Private {
+		return 0, false
+	}
+	lifetime := fallback
+	switch {
+	case directives.NoCache:
+		lifetime = 0
+	case directives.HasSMaxAge:
+		lifetime = directives.SMaxAge
+	case directives.HasMaxAge:
+		lifetime = directives.MaxAge
+	default:
+		if raw := header.Get("Expires"); raw != "" {
+			expires, err := http.ParseTime(raw)
+			if err != nil {
+				lifetime = 0
+				break
+			}
+			date := now
+			if parsed, err := http.ParseTime(header.Get("Date")); err == nil {
+				date = parsed
+			}
+			lifetime = expires.Sub(date)
+		}
+	}
+	lifetime -= InitialAge(header)
+	if lifetime < 0 {
+		lifetime = 0
+	}
+	return lifetime, true
+}
+
+func InitialAge(header http.Header) time.Duration {
+	age, ok := parseSeconds(header.Get("Age"))
+	if !ok {
+		return 0
+	}
+	return age
+}
+
+func HasValidator(header http.Header) bool {
+	return header.Get("ETag") != "" || header.Get("Last-Modified") != ""
+}
+
+func (e Entry) Fresh(now time.Time) bool {
+	if e.FreshUntil.IsZero() {
+		return true
+	}
+	return now.Before(e.FreshUntil)
+}
+
+func (e Entry) Age(now time.Time) time.Duration {
+	age := InitialAge(e.Header)
+	if !e.StoredAt.IsZero() && now.After(e.StoredAt) {
+		age += now.Sub(e.StoredAt)
+	}
+	return age
+}
+
+func parseSeconds(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	if seconds > maxDeltaSeconds {
+		seconds = maxDeltaSeconds
+	}
+	return time.Duration(seconds) * time.Second, true
+}
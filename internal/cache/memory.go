@@ -6,22 +6,50 @@ import (
 	"time"
 )
 
-const DefaultMaxObjectBytes int64 = 50 * 1024 * 1024
+const (
+	DefaultMaxObjectBytes int64 = 50 * 1024 * 1024
+	DefaultMemoryShards         = 32
+	maxMemoryShards             = 1024
+)
 
 type MemoryStore struct {
-	mu             sync.RWMutex
-	entries        map[string]Entry
+	shards         []memoryShard
+	mask           uint64
 	maxObjectBytes int64
 }
 
+type memoryShard struct {
+	mu      sync.RWMutex
+	entries map[string]Entry
+}
+
 func NewMemoryStore(maxObjectBytes int64) *MemoryStore {
+	return NewShardedMemoryStore(maxObjectBytes, DefaultMemoryShards)
+}
+
+func NewShardedMemoryStore(maxObjectBytes int64, shards int) *MemoryStore {
 	if maxObjectBytes <= 0 {
 		maxObjectBytes = DefaultMaxObjectBytes
 	}
-	return &MemoryStore{
-		entries:        make(map[string]Entry),
+	if shards <= 0 {
+		shards = DefaultMemoryShards
+	}
+	if shards > maxMemoryShards {
+		shards = maxMemoryShards
+	}
+	count := 1
+	for count < shards {
+		count <<= 1
+	}
+	store := &MemoryStore{
+		shards:         make([]memoryShard, count),
+		mask:           uint64(count - 1),
 		maxObjectBytes: maxObjectBytes,
 	}
+	for i := range store.shards {
+		store.shards[i].entries = make(map[string]Entry)
+	}
+	return store
 }
 
 func (m *MemoryStore) Get(key string) (Entry, bool) {
@@ -30,9 +58,10 @@ func (m *MemoryStore) Get(key string) (Entry, bool) {
 	}
 
 	now := time.Now()
-	m.mu.RLock()
-	entry, ok := m.entries[key]
-	m.mu.RUnlock()
+	shard := m.shard(key)
+	shard.mu.RLock()
+	entry, ok := shard.entries[key]
+	shard.mu.RUnlock()
 	if !ok {
 		return Entry{}, false
 	}
@@ -50,9 +79,10 @@ func (m *MemoryStore) Set(key string, entry Entry) error {
 	if m.maxObjectBytes > 0 && int64(len(entry.Body)) > m.maxObjectBytes {
 		return errors.New("cache entry exceeds max object bytes")
 	}
-	m.mu.Lock()
-	m.entries[key] = entry
-	m.mu.Unlock()
+	shard := m.shard(key)
+	shard.mu.Lock()
+	shard.entries[key] = entry
+	shard.mu.Unlock()
 	return nil
 }
 
@@ -60,7 +90,35 @@ func (m *MemoryStore) Delete(key string) {
 	if m == nil {
 		return
 	}
-	m.mu.Lock()
-	delete(m.entries, key)
-	m.mu.Unlock()
+	shard := m.shard(key)
+	shard.mu.Lock()
+	delete(shard.entries, key)
+	shard.mu.Unlock()
+}
+
+func (m *MemoryStore) Len() int {
+	if m == nil {
+		return 0
+	}
+	total := 0
+	for i := range m.shards {
+		shard := &m.shards[i]
+		shard.mu.RLock()
+		total += len(shard.entries)
+		shard.mu.RUnlock()
+	}
+	return total
+}
+
+func (m *MemoryStore) shard(key string) *memoryShard {
+	const (
+		offset64 = 14695981039346656037
+		prime64  = 1099511628211
+	)
+	hash := uint64(offset64)
+	for i := 0; i < len(key); i++ {
+		hash ^= uint64(key[i])
+		hash *= prime64
+	}
+	return &m.shards[hash&m.mask]
 }
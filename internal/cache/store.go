@@ -1,16 +1,19 @@
 package cache
 
 import (
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 )
 
 type Entry struct {
-	Status    int
-	Header    http.Header
-	Body      []byte
-	ExpiresAt time.Time
-	StoredAt  time.Time
+	Status     int
+	Header     http.Header
+	Body       []byte
+	ExpiresAt  time.Time
+	StoredAt   time.Time
+	FreshUntil time.Time
 }
 
 type Store interface {
@@ -18,3 +21,22 @@ type Store interface {
 	Set(key string, entry Entry) error
 	Delete(key string)
 }
+
+type StoreConfig struct {
+	Backend        string
+	Dir            string
+	MaxBytes       int64
+	MaxObjectBytes int64
+	MemoryShards   int
+}
+
+func NewStore(cfg StoreConfig) (Store, error) {
+	switch strings.ToLower(strings.TrimSpace(cfg.Backend)) {
+	case "", "memory":
+		return NewShardedMemoryStore(cfg.MaxObjectBytes, cfg.MemoryShards), nil
+	case "disk":
+		return NewDiskStore(cfg.Dir, cfg.MaxBytes, cfg.MaxObjectBytes)
+	default:
+		return nil, fmt.Errorf("unsupported cache backend %q", cfg.Backend)
+	}
+}
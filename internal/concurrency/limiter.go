@@ -0,0 +1,165 @@
+package concurrency
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	AlgorithmGradient = "gradient"
+	AlgorithmAIMD     = "aimd"
+
+	longRTTDecay = 0.05
+)
+
+type Outcome int
+
+const (
+	OutcomeSuccess Outcome = iota + 1
+	OutcomeFailure
+	OutcomeIgnore
+)
+
+type Config struct {
+	Enabled          bool
+	Algorithm        string
+	InitialLimit     int
+	MinLimit         int
+	MaxLimit         int
+	Window           time.Duration
+	Tolerance        float64
+	Smoothing        float64
+	BackoffRatio     float64
+	LatencyThreshold time.Duration
+}
+
+type Limiter struct {
+	mu          sync.Mutex
+	config      Config
+	limit       float64
+	inflight    int
+	maxInflight int
+	windowStart time.Time
+	samples     int
+	failures    int
+	sumRTT      time.Duration
+	longRTT     time.Duration
+}
+
+func New(cfg Config) *Limiter {
+	limiter := &Limiter{config: cfg, windowStart: time.Now()}
+	limiter.limit = limiter.clamp(float64(cfg.InitialLimit))
+	return limiter
+}
+
+func (l *Limiter) UpdateConfig(cfg Config) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.config = cfg
+	l.limit = l.clamp(l.limit)
+}
+
+func (l *Limiter) Limit() int {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+func (l *Limiter) Inflight() int {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.inflight
+}
+
+func (l *Limiter) Acquire() (func(rtt time.Duration, outcome Outcome), bool) {
+	if l == nil {
+		return func(time.Duration, Outcome) {}, true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.inflight >= int(l.limit) {
+		return nil, false
+	}
+	l.inflight++
+	if l.inflight > l.maxInflight {
+		l.maxInflight = l.inflight
+	}
+	var once sync.Once
+	return func(rtt time.Duration, outcome Outcome) {
+		once.Do(func() {
+			l.release(rtt, outcome, time.Now())
+		})
+	}, true
+}
+
+func (l *Limiter) release(rtt time.Duration, outcome Outcome, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inflight--
+	if outcome == OutcomeIgnore {
+		return
+	}
+	l.samples++
+	l.sumRTT += rtt
+	if outcome == OutcomeFailure {
+		l.failures++
+	}
+	if now.Sub(l.windowStart) < l.config.Window {
+		return
+	}
+	l.update()
+	l.windowStart = now
+	l.samples = 0
+	l.failures = 0
+	l.sumRTT = 0
+	l.maxInflight = l.inflight
+}
+
+func (l *Limiter) update() {
+	shortRTT := l.sumRTT / time.Duration(l.samples)
+	appLimited := float64(l.maxInflight)*2 < l.limit
+
+	switch l.config.Algorithm {
+	case AlgorithmAIMD:
+		if l.failures > 0 || (l.config.LatencyThreshold > 0 && shortRTT > l.config.LatencyThreshold) {
+			l.limit = l.clamp(l.limit * l.config.BackoffRatio)
+		} else if !appLimited {
+			l.limit = l.clamp(l.limit + 1)
+		}
+	default:
+		if l.longRTT == 0 {
+			l.longRTT = shortRTT
+		} else {
+			l.longRTT = time.Duration(float64(l.longRTT)*(1-longRTTDecay) + float64(shortRTT)*longRTTDecay)
+		}
+		gradient := 1.0
+		if shortRTT > 0 {
+			gradient = math.Max(0.5, math.Min(1.0, l.config.Tolerance*float64(l.longRTT)/float64(shortRTT)))
+		}
+		next := l.limit*gradient + math.Sqrt(l.limit)
+		if appLimited && next > l.limit {
+			return
+		}
+		l.limit = l.clamp(l.limit*(1-l.config.Smoothing) + next*l.config.Smoothing)
+	}
+}
+
+func (l *Limiter) clamp(limit float64) float64 {
+	if limit < float64(l.config.MinLimit) {
+		return float64(l.config.MinLimit)
+	}
+	if l.config.MaxLimit > 0 && limit > float64(l.config.MaxLimit) {
+		return float64(l.config.MaxLimit)
+	}
+	return limit
+}
@@ -0,0 +1,165 @@
+package concurrency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	defaultReapInterval = 30 * time.Second
+	defaultTTL          = 30 * time.Minute
+)
+
+type LimitObserver func(key string, limit int)
+
+type Registry struct {
+	mu           sync.Mutex
+	limiters     map[string]*entry
+	reapInterval time.Duration
+	ttl          time.Duration
+	observer     LimitObserver
+	stopCh       chan struct{}
+}
+
+type entry struct {
+	limiter   *Limiter
+	config    Config
+	lastSeen  time.Time
+	lastLimit int
+}
+
+func NewRegistry(reapInterval time.Duration, ttl time.Duration) *Registry {
+	if reapInterval <= 0 {
+		reapInterval = defaultReapInterval
+	}
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+
+	registry := &Registry{
+		limiters:     make(map[string]*entry),
+		reapInterval: reapInterval,
+		ttl:          ttl,
+		stopCh:       make(chan struct{}),
+	}
+	go registry.reapLoop()
+	return registry
+}
+
+func (r *Registry) SetLimitObserver(observer LimitObserver) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.observer = observer
+}
+
+func (r *Registry) Acquire(key string, cfg Config) (func(rtt time.Duration, outcome Outcome), bool) {
+	if r == nil || !cfg.Enabled || key == "" {
+		return func(time.Duration, Outcome) {}, true
+	}
+	current := r.ensure(key, cfg)
+	release, ok := current.limiter.Acquire()
+	if !ok {
+		return nil, false
+	}
+	return func(rtt time.Duration, outcome Outcome) {
+		release(rtt, outcome)
+		r.notify(key, current)
+	}, true
+}
+
+func (r *Registry) Limit(key string) (int, bool) {
+	if r == nil {
+		return 0, false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current, ok := r.limiters[key]
+	if !ok {
+		return 0, false
+	}
+	return current.limiter.Limit(), true
+}
+
+func (r *Registry) Close() {
+	if r == nil {
+		return
+	}
+	select {
+	case <-r.stopCh:
+		return
+	default:
+		close(r.stopCh)
+	}
+}
+
+func (r *Registry) Stop(ctx context.Context) error {
+	_ = ctx
+	if r == nil {
+		return nil
+	}
+	r.Close()
+	return nil
+}
+
+func (r *Registry) ensure(key string, cfg Config) *entry {
+	r.mu.Lock()
+	current := r.limiters[key]
+	if current == nil {
+		current = &entry{limiter: New(cfg), config: cfg, lastSeen: time.Now(), lastLimit: -1}
+		r.limiters[key] = current
+	} else {
+		current.lastSeen = time.Now()
+		if current.config != cfg {
+			current.config = cfg
+			current.limiter.UpdateConfig(cfg)
+		}
+	}
+	r.mu.Unlock()
+	r.notify(key, current)
+	return current
+}
+
+func (r *Registry) notify(key string, current *entry) {
+	limit := current.limiter.Limit()
+	r.mu.Lock()
+	observer := r.observer
+	changed := current.lastLimit != limit
+	current.lastLimit = limit
+	r.mu.Unlock()
+	if changed && observer != nil {
+		observer(key, limit)
+	}
+}
+
+func (r *Registry) reapLoop() {
+	ticker := time.NewTicker(r.reapInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.reapOnce()
+		case <-r.stopCh:
+			return
+		}
+	}
+}
+
+func (r *Registry) reapOnce() {
+	if r == nil {
+		return
+	}
+	cutoff := time.Now().Add(-r.ttl)
+
+	r.mu.Lock()
+	for key, entry := range r.limiters {
+		if entry.lastSeen.Before(cutoff) {
+			delete(r.limiters, key)
+		}
+	}
+	r.mu.Unlock()
+}
@@ -1,60 +1,163 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"io"
 )
 
 type Config struct {
-	ListenAddr string          `json:"listen_addr"`
-	TLS        TLSConfig       `json:"tls"`
-	Limits     LimitsConfig    `json:"limits"`
-	Shutdown   ShutdownConfig  `json:"shutdown"`
-	Logging    LoggingConfig   `json:"logging"`
-	Metrics    *MetricsConfig  `json:"metrics"`
-	Routes     []Route         `json:"routes"`
-	Pools      map[string]Pool `json:"pools"`
+	ListenAddr       string                 `json:"listen_addr"`
+	TLS              TLSConfig              `json:"tls"`
+	Limits           LimitsConfig           `json:"limits"`
+	Shutdown         ShutdownConfig         `json:"shutdown"`
+	StatePersistence StatePersistenceConfig `json:"state_persistence"`
+	Logging          LoggingConfig          `json:"logging"`
+	Metrics          *MetricsConfig         `json:"metrics"`
+	Cache            CacheStoreConfig       `json:"cache"`
+	Metadata         MetadataConfig         `json:"metadata"`
+	RequestRules     []RequestRuleConfig    `json:"request_rules"`
+	RequestID        RequestIDConfig        `json:"request_id"`
+	DebugTrace       DebugTraceConfig       `json:"debug_trace"`
+	SlowLog          SlowLogConfig          `json:"slow_log"`
+	Routes           []Route                `json:"routes"`
+	Pools            map[string]Pool        `json:"pools"`
+	Streams          []Stream               `json:"streams"`
+	Listeners        []Listener             `json:"listeners"`
+	Include          []string               `json:"include,omitempty"`
+}
+
+type Listener struct {
+	Name                  string        `json:"name"`
+	Addr                  string        `json:"addr"`
+	Protocol              string        `json:"protocol"`
+	Limits                *LimitsConfig `json:"limits"`
+	MaxConcurrentRequests *int          `json:"max_concurrent_requests"`
+	MaxQueuedRequests     *int          `json:"max_queued_requests"`
+	QueueTimeoutMS        int           `json:"queue_timeout_ms"`
+	MaxConnections        *int          `json:"max_connections"`
+}
+
+type Stream struct {
+	ID               string           `json:"id"`
+	Addr             string           `json:"addr"`
+	Mode             string           `json:"mode"`
+	Pool             string           `json:"pool"`
+	SNIRoutes        []StreamSNIRoute `json:"sni_routes"`
+	ConnectTimeoutMS int              `json:"connect_timeout_ms"`
+	IdleTimeoutMS    int              `json:"idle_timeout_ms"`
+}
+
+type StreamSNIRoute struct {
+	ServerNames []string `json:"server_names"`
+	Pool        string   `json:"pool"`
 }
 
 type Route struct {
-	ID         string      `json:"id"`
-	Host       string      `json:"host"`
-	PathPrefix string      `json:"path_prefix"`
-	Methods    []string    `json:"methods"`
-	Pool       string      `json:"pool"`
-	Policy     RoutePolicy `json:"policy"`
-	Overlay    bool        `json:"overlay"`
+	ID         string            `json:"id"`
+	Host       string            `json:"host"`
+	PathPrefix string            `json:"path_prefix"`
+	Methods    []string          `json:"methods"`
+	Pool       string            `json:"pool"`
+	Policy     RoutePolicy       `json:"policy"`
+	Listeners  []string          `json:"listeners"`
+	Overlay    bool              `json:"overlay"`
+	Labels     map[string]string `json:"labels"`
 }
 
 type Pool struct {
-	Endpoints []string            `json:"endpoints"`
-	Health    HealthConfig        `json:"health"`
-	Breaker   BreakerConfig       `json:"breaker"`
-	Outlier   OutlierConfig       `json:"outlier"`
-	Transport PoolTransportConfig `json:"transport"`
-	Overlay   bool                `json:"overlay"`
+	Endpoints           []string                  `json:"endpoints"`
+	Health              HealthConfig              `json:"health"`
+	Breaker             BreakerConfig             `json:"breaker"`
+	Outlier             OutlierConfig             `json:"outlier"`
+	Transport           PoolTransportConfig       `json:"transport"`
+	Drain               PoolDrainConfig           `json:"drain"`
+	SlowStart           SlowStartConfig           `json:"slow_start"`
+	Locality            LocalityConfig            `json:"locality"`
+	AdaptiveConcurrency AdaptiveConcurrencyConfig `json:"adaptive_concurrency"`
+	Overlay             bool                      `json:"overlay"`
 }
 
 type RoutePolicy struct {
-	RequestTimeoutMS                int                  `json:"request_timeout_ms"`
-	UpstreamDialTimeoutMS           int                  `json:"upstream_dial_timeout_ms"`
-	UpstreamResponseHeaderTimeoutMS int                  `json:"upstream_response_header_timeout_ms"`
-	Retry                           RetryConfig          `json:"retry"`
-	RetryBudget                     RetryBudgetConfig    `json:"retry_budget"`
-	ClientRetryCap                  ClientRetryCapConfig `json:"client_retry_cap"`
-	RequireMTLS                     bool                 `json:"require_mtls"`
-	MTLSClientCA                    string               `json:"mtls_client_ca"`
-	Cache                           CacheConfig          `json:"cache"`
-	Traffic                         TrafficConfig        `json:"traffic"`
-	Plugins                         PluginConfig         `json:"plugins"`
+	RequestTimeoutMS                int                   `json:"request_timeout_ms"`
+	UpstreamDialTimeoutMS           int                   `json:"upstream_dial_timeout_ms"`
+	UpstreamResponseHeaderTimeoutMS int                   `json:"upstream_response_header_timeout_ms"`
+	IdleTimeoutMS                   int                   `json:"idle_timeout_ms"`
+	ResponseStreamTimeoutMS         int                   `json:"response_stream_timeout_ms"`
+	Retry                           RetryConfig           `json:"retry"`
+	RetryBudget                     RetryBudgetConfig     `json:"retry_budget"`
+	ClientRetryCap                  ClientRetryCapConfig  `json:"client_retry_cap"`
+	RequireMTLS                     bool                  `json:"require_mtls"`
+	MTLSClientCA                    string                `json:"mtls_client_ca"`
+	Cache                           CacheConfig           `json:"cache"`
+	Traffic                         TrafficConfig         `json:"traffic"`
+	Plugins                         PluginConfig          `json:"plugins"`
+	Compression                     CompressionConfig     `json:"compression"`
+	RequestDecompression            DecompressionConfig   `json:"request_decompression"`
+	RequestBody                     RequestBodyConfig     `json:"request_body"`
+	SSE                             SSEConfig             `json:"sse"`
+	TLSFingerprint                  FingerprintConfig     `json:"tls_fingerprint"`
+	Bandwidth                       BandwidthConfig       `json:"bandwidth"`
+	DebugUpstream                   DebugUpstreamConfig   `json:"debug_upstream"`
+	SnapshotSwap                    SnapshotSwapConfig    `json:"snapshot_swap"`
+	Script                          ScriptConfig          `json:"script"`
+	Auth                            string                `json:"auth"`
+	OIDC                            OIDCConfig            `json:"oidc"`
+	APIKey                          APIKeyConfig          `json:"api_key"`
+	HMAC                            HMACConfig            `json:"hmac"`
+	Access                          AccessConfig          `json:"access"`
+	MTLS                            MTLSConfig            `json:"mtls"`
+	Mirror                          MirrorConfig          `json:"mirror"`
+	Hedge                           HedgeConfig           `json:"hedge"`
+	Idempotency                     IdempotencyConfig     `json:"idempotency"`
+	ErrorPages                      []ErrorPageConfig     `json:"error_pages"`
+	CORS                            CORSConfig            `json:"cors"`
+	SecurityHeaders                 SecurityHeadersConfig `json:"security_headers"`
+	RequestRules                    []RequestRuleConfig   `json:"request_rules"`
+	ServerTiming                    bool                  `json:"server_timing"`
+	SlowLogThresholdMS              int                   `json:"slow_log_threshold_ms"`
+	AccessLog                       AccessLogConfig       `json:"access_log"`
 }
 
 type TLSConfig struct {
-	Enabled      bool      `json:"enabled"`
-	Addr         string    `json:"addr"`
-	Certs        []TLSCert `json:"certs"`
-	ClientCAFile string    `json:"client_ca_file"`
-	MinVersion   string    `json:"min_version"`
-	CipherSuites []string  `json:"cipher_suites"`
+	Enabled      bool        `json:"enabled"`
+	Addr         string      `json:"addr"`
+	Certs        []TLSCert   `json:"certs"`
+	ClientCAFile string      `json:"client_ca_file"`
+	MinVersion   string      `json:"min_version"`
+	CipherSuites []string    `json:"cipher_suites"`
+	ACME         ACMEConfig  `json:"acme"`
+	OCSPStapling bool        `json:"ocsp_stapling"`
+	HTTP3        HTTP3Config `json:"http3"`
+
+	ExpiryWarningDays int                  `json:"expiry_warning_days"`
+	SessionTickets    SessionTicketsConfig `json:"session_tickets"`
+}
+
+type SessionTicketsConfig struct {
+	Enabled            bool   `json:"enabled"`
+	RotationIntervalMS int    `json:"rotation_interval_ms"`
+	RetainedKeys       int    `json:"retained_keys"`
+	SeedEnv            string `json:"seed_env"`
+}
+
+type HTTP3Config struct {
+	Enabled        bool   `json:"enabled"`
+	Addr           string `json:"addr"`
+	AltSvc         *bool  `json:"alt_svc"`
+	AltSvcMaxAgeMS int    `json:"alt_svc_max_age_ms"`
+}
+
+type ACMEConfig struct {
+	Enabled       bool     `json:"enabled"`
+	Hostnames     []string `json:"hostnames"`
+	Email         string   `json:"email"`
+	DirectoryURL  string   `json:"directory_url"`
+	CacheDir      string   `json:"cache_dir"`
+	HTTP01        *bool    `json:"http_01"`
+	RenewBeforeMS int      `json:"renew_before_ms"`
+	CARootFile    string   `json:"ca_root_file"`
 }
 
 type TLSCert struct {
@@ -64,15 +167,32 @@ type TLSCert struct {
 }
 
 type LimitsConfig struct {
-	MaxHeaderBytes          int    `json:"max_header_bytes"`
-	MaxHeaderCount          int    `json:"max_header_count"`
-	MaxURLBytes             int    `json:"max_url_bytes"`
-	MaxBodyBytes            *int64 `json:"max_body_bytes"`
-	ReadHeaderTimeoutMS     int    `json:"read_header_timeout_ms"`
-	ReadTimeoutMS           int    `json:"read_timeout_ms"`
-	WriteTimeoutMS          int    `json:"write_timeout_ms"`
-	IdleTimeoutMS           int    `json:"idle_timeout_ms"`
-	ResponseStreamTimeoutMS int    `json:"response_stream_timeout_ms"`
+	MaxHeaderBytes          int          `json:"max_header_bytes"`
+	MaxHeaderCount          int          `json:"max_header_count"`
+	MaxURLBytes             int          `json:"max_url_bytes"`
+	MaxBodyBytes            *int64       `json:"max_body_bytes"`
+	ReadHeaderTimeoutMS     int          `json:"read_header_timeout_ms"`
+	ReadTimeoutMS           int          `json:"read_timeout_ms"`
+	WriteTimeoutMS          int          `json:"write_timeout_ms"`
+	IdleTimeoutMS           int          `json:"idle_timeout_ms"`
+	ResponseStreamTimeoutMS int          `json:"response_stream_timeout_ms"`
+	MaxConcurrentRequests   int          `json:"max_concurrent_requests"`
+	MaxQueuedRequests       int          `json:"max_queued_requests"`
+	QueueTimeoutMS          int          `json:"queue_timeout_ms"`
+	MaxConnections          int          `json:"max_connections"`
+	MaxRequestsPerConn      int          `json:"max_requests_per_connection"`
+	KeepAliveTimeoutMS      int          `json:"keepalive_timeout_ms"`
+	MaxDecompressedBytes    int64        `json:"max_decompressed_body_bytes"`
+	MaxDecompressionRatio   int          `json:"max_decompression_ratio"`
+	Socket                  SocketConfig `json:"socket"`
+}
+
+type SocketConfig struct {
+	ReusePort     bool  `json:"reuse_port"`
+	KeepAliveMS   int   `json:"keepalive_ms"`
+	Backlog       int   `json:"backlog"`
+	DeferAcceptMS int   `json:"defer_accept_ms"`
+	NoDelay       *bool `json:"no_delay"`
 }
 
 type ShutdownConfig struct {
@@ -81,26 +201,105 @@ type ShutdownConfig struct {
 	ForceCloseMS      int `json:"force_close_ms"`
 }
 
+type StatePersistenceConfig struct {
+	Path       string `json:"path"`
+	IntervalMS int    `json:"interval_ms"`
+	MaxAgeMS   int    `json:"max_age_ms"`
+}
+
 type LoggingConfig struct {
-	RedactQuery bool `json:"redact_query"`
+	RedactQuery bool                 `json:"redact_query"`
+	AccessLog   *AccessLogSinkConfig `json:"access_log"`
+}
+
+type AccessLogSinkConfig struct {
+	Type       string            `json:"type"`
+	Path       string            `json:"path"`
+	MaxSizeMB  int               `json:"max_size_mb"`
+	MaxBackups int               `json:"max_backups"`
+	Network    string            `json:"network"`
+	Address    string            `json:"address"`
+	Tag        string            `json:"tag"`
+	URL        string            `json:"url"`
+	Topic      string            `json:"topic"`
+	Headers    map[string]string `json:"headers"`
+	BufferSize int               `json:"buffer_size"`
+	BatchSize  int               `json:"batch_size"`
+	FlushMS    int               `json:"flush_ms"`
+	TimeoutMS  int               `json:"timeout_ms"`
+}
+
+type MetadataConfig struct {
+	VersionLabel string `json:"version_label"`
+	GitSHA       string `json:"git_sha"`
+	Author       string `json:"author"`
+}
+
+type CacheStoreConfig struct {
+	Backend        string `json:"backend"`
+	Dir            string `json:"dir"`
+	MaxBytes       int64  `json:"max_bytes"`
+	MaxObjectBytes int64  `json:"max_object_bytes"`
+	MemoryShards   int    `json:"memory_shards"`
 }
 
 type MetricsConfig struct {
-	Enabled      *bool  `json:"enabled"`
-	Path         string `json:"path"`
-	RequireToken bool   `json:"require_token"`
-	TokenEnv     string `json:"token_env"`
+	Enabled      *bool              `json:"enabled"`
+	Path         string             `json:"path"`
+	RequireToken bool               `json:"require_token"`
+	TokenEnv     string             `json:"token_env"`
+	Push         *MetricsPushConfig `json:"push"`
+	Histograms   *HistogramConfig   `json:"histograms"`
+}
+
+type HistogramConfig struct {
+	Buckets            []float64 `json:"buckets"`
+	Native             bool      `json:"native"`
+	NativeBucketFactor float64   `json:"native_bucket_factor"`
+	NativeMaxBuckets   int       `json:"native_max_buckets"`
+}
+
+type MetricsPushConfig struct {
+	Type        string            `json:"type"`
+	Address     string            `json:"address"`
+	Endpoint    string            `json:"endpoint"`
+	Headers     map[string]string `json:"headers"`
+	Prefix      string            `json:"prefix"`
+	ServiceName string            `json:"service_name"`
+	IntervalMS  int               `json:"interval_ms"`
+	TimeoutMS   int               `json:"timeout_ms"`
 }
 
 type RetryConfig struct {
-	Enabled            bool     `json:"enabled"`
-	MaxAttempts        int      `json:"max_attempts"`
-	PerTryTimeoutMS    int      `json:"per_try_timeout_ms"`
-	TotalRetryBudgetMS int      `json:"total_retry_budget_ms"`
-	RetryOnStatus      []int    `json:"retry_on_status"`
-	RetryOnErrors      []string `json:"retry_on_errors"`
-	BackoffMS          int      `json:"backoff_ms"`
-	BackoffJitterMS    int      `json:"backoff_jitter_ms"`
+	Enabled            bool        `json:"enabled"`
+	MaxAttempts        int         `json:"max_attempts"`
+	PerTryTimeoutMS    int         `json:"per_try_timeout_ms"`
+	TotalRetryBudgetMS int         `json:"total_retry_budget_ms"`
+	RetryOnStatus      []int       `json:"retry_on_status"`
+	RetryOnErrors      []string    `json:"retry_on_errors"`
+	BackoffMS          int         `json:"backoff_ms"`
+	BackoffJitterMS    int         `json:"backoff_jitter_ms"`
+	StatusBackoffMS    map[int]int `json:"status_backoff_ms"`
+	RespectRetryAfter  bool        `json:"respect_retry_after"`
+	RetryAfterCapMS    int         `json:"retry_after_cap_ms"`
+	ExcludeAttempted   bool        `json:"exclude_attempted"`
+	BufferBodyBytes    int64       `json:"buffer_body_bytes"`
+	NonIdempotent      bool        `json:"retry_non_idempotent"`
+}
+
+type AccessLogConfig struct {
+	Disabled   bool                 `json:"disabled"`
+	SampleRate *float64             `json:"sample_rate"`
+	ErrorsOnly bool                 `json:"errors_only"`
+	SlowMS     int                  `json:"slow_ms"`
+	Fields     []string             `json:"fields"`
+	Rename     map[string]string    `json:"rename"`
+	Sink       *AccessLogSinkConfig `json:"sink"`
+}
+
+type HedgeConfig struct {
+	DelayMS   int `json:"delay_ms"`
+	MaxHedges int `json:"max_hedges"`
 }
 
 type RetryBudgetConfig struct {
@@ -118,14 +317,215 @@ type ClientRetryCapConfig struct {
 }
 
 type CacheConfig struct {
+	Enabled             bool                `json:"enabled"`
+	Public              bool                `json:"public"`
+	TTLMS               int                 `json:"ttl_ms"`
+	MaxObjectBytes      int                 `json:"max_object_bytes"`
+	VaryHeaders         []string            `json:"vary_headers"`
+	CoalesceEnabled     *bool               `json:"coalesce_enabled"`
+	CoalesceTimeoutMS   int                 `json:"coalesce_timeout_ms"`
+	OnlyIfContentLength *bool               `json:"only_if_content_length"`
+	RespectCacheControl *bool               `json:"respect_cache_control"`
+	RevalidateWindowMS  int                 `json:"revalidate_window_ms"`
+	CacheableStatuses   []CacheStatusConfig `json:"cacheable_statuses"`
+	RangeCollapse       bool                `json:"range_collapse"`
+}
+
+type CacheStatusConfig struct {
+	Status int `json:"status"`
+	TTLMS  int `json:"ttl_ms"`
+}
+
+type CompressionConfig struct {
+	Enabled      bool     `json:"enabled"`
+	Algorithms   []string `json:"algorithms"`
+	MinSizeBytes int      `json:"min_size_bytes"`
+	ContentTypes []string `json:"content_types"`
+}
+
+type DecompressionConfig struct {
+	Enabled  bool `json:"enabled"`
+	MaxRatio int  `json:"max_ratio"`
+}
+
+type RequestBodyConfig struct {
+	Mode         string          `json:"mode"`
+	MaxBodyBytes int64           `json:"max_body_bytes"`
+	Multipart    MultipartConfig `json:"multipart"`
+}
+
+type MultipartConfig struct {
 	Enabled             bool     `json:"enabled"`
-	Public              bool     `json:"public"`
-	TTLMS               int      `json:"ttl_ms"`
-	MaxObjectBytes      int      `json:"max_object_bytes"`
-	VaryHeaders         []string `json:"vary_headers"`
-	CoalesceEnabled     *bool    `json:"coalesce_enabled"`
-	CoalesceTimeoutMS   int      `json:"coalesce_timeout_ms"`
-	OnlyIfContentLength *bool    `json:"only_if_content_length"`
+	MaxPartBytes        int64    `json:"max_part_bytes"`
+	MaxParts            int      `json:"max_parts"`
+	AllowedContentTypes []string `json:"allowed_content_types"`
+	StripDisallowed     bool     `json:"strip_disallowed"`
+}
+
+type SSEConfig struct {
+	Enabled             bool `json:"enabled"`
+	HeartbeatIntervalMS int  `json:"heartbeat_interval_ms"`
+}
+
+type FingerprintConfig struct {
+	Allow          []string `json:"allow"`
+	Deny           []string `json:"deny"`
+	RateLimitRPS   int      `json:"rate_limit_rps"`
+	RateLimitBurst int      `json:"rate_limit_burst"`
+	RateLimitKeys  int      `json:"rate_limit_keys"`
+}
+
+type BandwidthConfig struct {
+	Enabled           bool  `json:"enabled"`
+	RouteBytesPerSec  int64 `json:"route_bytes_per_sec"`
+	RouteBurstBytes   int64 `json:"route_burst_bytes"`
+	ClientBytesPerSec int64 `json:"client_bytes_per_sec"`
+	ClientBurstBytes  int64 `json:"client_burst_bytes"`
+	MaxClients        int   `json:"max_clients"`
+}
+
+type DebugUpstreamConfig struct {
+	Enabled      bool     `json:"enabled"`
+	Header       string   `json:"header"`
+	TrustedCIDRs []string `json:"trusted_cidrs"`
+	TokenEnv     string   `json:"token_env"`
+}
+
+type SnapshotSwapConfig struct {
+	Rematch        bool `json:"rematch"`
+	RematchAfterMS int  `json:"rematch_after_ms"`
+}
+
+type ScriptConfig struct {
+	Enabled     bool   `json:"enabled"`
+	Lua         string `json:"lua"`
+	File        string `json:"file"`
+	TimeoutMS   int    `json:"timeout_ms"`
+	MaxSteps    int    `json:"max_steps"`
+	FailureMode string `json:"failure_mode"`
+}
+
+type OIDCConfig struct {
+	Issuer          string   `json:"issuer"`
+	ClientID        string   `json:"client_id"`
+	ClientSecretEnv string   `json:"client_secret_env"`
+	RedirectURL     string   `json:"redirect_url"`
+	CallbackPath    string   `json:"callback_path"`
+	LogoutPath      string   `json:"logout_path"`
+	Scopes          []string `json:"scopes"`
+	CookieName      string   `json:"cookie_name"`
+	CookieSecretEnv string   `json:"cookie_secret_env"`
+	CookieSecure    bool     `json:"cookie_secure"`
+	SessionTTLMS    int      `json:"session_ttl_ms"`
+}
+
+type AuthKeyConfig struct {
+	ID             string `json:"id"`
+	Key            string `json:"key"`
+	KeyEnv         string `json:"key_env"`
+	RateLimitRPS   int64  `json:"rate_limit_rps"`
+	RateLimitBurst int64  `json:"rate_limit_burst"`
+}
+
+type APIKeyConfig struct {
+	Header   string          `json:"header"`
+	Keys     []AuthKeyConfig `json:"keys"`
+	KeysFile string          `json:"keys_file"`
+}
+
+type HMACConfig struct {
+	Header       string          `json:"header"`
+	Keys         []AuthKeyConfig `json:"keys"`
+	KeysFile     string          `json:"keys_file"`
+	MaxSkewMS    int             `json:"max_skew_ms"`
+	MaxBodyBytes int64           `json:"max_body_bytes"`
+}
+
+type AccessConfig struct {
+	AllowCIDRs     []string `json:"allow_cidrs"`
+	DenyCIDRs      []string `json:"deny_cidrs"`
+	TrustedProxies []string `json:"trusted_proxies"`
+}
+
+type MTLSConfig struct {
+	AllowedSANs      []string         `json:"allowed_sans"`
+	AllowedSPIFFEIDs []string         `json:"allowed_spiffe_ids"`
+	IdentityHeader   string           `json:"identity_header"`
+	Revocation       RevocationConfig `json:"revocation"`
+}
+
+type MirrorConfig struct {
+	Pool           string  `json:"pool"`
+	Percent        float64 `json:"percent"`
+	IgnoreResponse *bool   `json:"ignore_response"`
+	TimeoutMS      int     `json:"timeout_ms"`
+	MaxBodyBytes   int64   `json:"max_body_bytes"`
+	MaxInflight    int     `json:"max_inflight"`
+}
+
+type IdempotencyConfig struct {
+	Enabled      bool   `json:"enabled"`
+	Header       string `json:"header"`
+	ReplayTTLMS  int    `json:"replay_ttl_ms"`
+	MaxEntries   int    `json:"max_entries"`
+	MaxBodyBytes int64  `json:"max_body_bytes"`
+}
+
+type ErrorPageConfig struct {
+	Status []int  `json:"status"`
+	Format string `json:"format"`
+	Body   string `json:"body"`
+	File   string `json:"file"`
+}
+
+type CORSConfig struct {
+	Enabled          bool     `json:"enabled"`
+	AllowedOrigins   []string `json:"allowed_origins"`
+	AllowedMethods   []string `json:"allowed_methods"`
+	AllowedHeaders   []string `json:"allowed_headers"`
+	ExposedHeaders   []string `json:"exposed_headers"`
+	AllowCredentials bool     `json:"allow_credentials"`
+	MaxAgeSeconds    int      `json:"max_age_seconds"`
+}
+
+type SecurityHeadersConfig struct {
+	Profile string            `json:"profile"`
+	Headers map[string]string `json:"headers"`
+}
+
+type RequestIDConfig struct {
+	Mode         string   `json:"mode"`
+	TrustedCIDRs []string `json:"trusted_cidrs"`
+}
+
+type SlowLogConfig struct {
+	Enabled     bool                 `json:"enabled"`
+	ThresholdMS int                  `json:"threshold_ms"`
+	Sink        *AccessLogSinkConfig `json:"sink"`
+}
+
+type DebugTraceConfig struct {
+	Enabled  bool   `json:"enabled"`
+	Header   string `json:"header"`
+	TokenEnv string `json:"token_env"`
+}
+
+type RequestRuleConfig struct {
+	Name      string            `json:"name"`
+	Action    string            `json:"action"`
+	Methods   []string          `json:"methods"`
+	PathRegex string            `json:"path_regex"`
+	Headers   map[string]string `json:"headers"`
+	Status    int               `json:"status"`
+	TarpitMS  int               `json:"tarpit_ms"`
+}
+
+type RevocationConfig struct {
+	OCSP       bool   `json:"ocsp"`
+	CRLFile    string `json:"crl_file"`
+	SoftFail   bool   `json:"soft_fail"`
+	TimeoutMS  int    `json:"timeout_ms"`
+	CacheTTLMS int    `json:"cache_ttl_ms"`
 }
 
 type TrafficConfig struct {
@@ -135,8 +535,10 @@ type TrafficConfig struct {
 	StableWeight int             `json:"stable_weight"`
 	CanaryWeight int             `json:"canary_weight"`
 	Cohort       CohortConfig    `json:"cohort"`
+	Force        ForceConfig     `json:"force"`
 	Overload     OverloadConfig  `json:"overload"`
 	AutoDrain    AutoDrainConfig `json:"autodrain"`
+	Ramp         RampConfig      `json:"ramp"`
 }
 
 type PluginConfig struct {
@@ -146,11 +548,17 @@ type PluginConfig struct {
 
 type PluginFilter struct {
 	Name              string              `json:"name"`
+	Type              string              `json:"type"`
 	Addr              string              `json:"addr"`
+	Module            string              `json:"module"`
+	MemoryPages       uint32              `json:"memory_pages"`
 	RequestTimeoutMS  int                 `json:"request_timeout_ms"`
 	ResponseTimeoutMS int                 `json:"response_timeout_ms"`
 	FailureMode       string              `json:"failure_mode"`
 	Breaker           PluginBreakerConfig `json:"breaker"`
+	InspectBody       bool                `json:"inspect_body"`
+	MaxBodyBytes      int64               `json:"max_body_bytes"`
+	BodyTimeoutMS     int                 `json:"body_timeout_ms"`
 }
 
 type PluginBreakerConfig struct {
@@ -165,6 +573,12 @@ type CohortConfig struct {
 	Key     string `json:"key"`
 }
 
+type ForceConfig struct {
+	Header          string `json:"header"`
+	Cookie          string `json:"cookie"`
+	CookieSecretEnv string `json:"cookie_secret_env"`
+}
+
 type OverloadConfig struct {
 	Enabled        bool `json:"enabled"`
 	MaxInflight    int  `json:"max_inflight"`
@@ -178,22 +592,111 @@ type AutoDrainConfig struct {
 	MinRequests         int     `json:"min_requests"`
 	ErrorRateMultiplier float64 `json:"error_rate_multiplier"`
 	CooloffMS           int     `json:"cooloff_ms"`
+	Mode                string  `json:"mode"`
+	GracePeriodMS       int     `json:"grace_period_ms"`
+	MaxCohorts          int     `json:"max_cohorts"`
+}
+
+type RampConfig struct {
+	Enabled        bool    `json:"enabled"`
+	Steps          []int   `json:"steps"`
+	StepIntervalMS int     `json:"step_interval_ms"`
+	MinRequests    int     `json:"min_requests"`
+	MaxErrorRate   float64 `json:"max_error_rate"`
+	MaxLatencyMS   int     `json:"max_latency_ms"`
+	OnBreach       string  `json:"on_breach"`
 }
 
 type HealthConfig struct {
-	Path                   string `json:"path"`
-	IntervalMS             int    `json:"interval_ms"`
-	TimeoutMS              int    `json:"timeout_ms"`
-	UnhealthyAfterFailures int    `json:"unhealthy_after_failures"`
-	HealthyAfterSuccesses  int    `json:"healthy_after_successes"`
-	BaseEjectMS            int    `json:"base_eject_ms"`
-	MaxEjectMS             int    `json:"max_eject_ms"`
+	Type                         string            `json:"type"`
+	Path                         string            `json:"path"`
+	IntervalMS                   int               `json:"interval_ms"`
+	TimeoutMS                    int               `json:"timeout_ms"`
+	UnhealthyAfterFailures       int               `json:"unhealthy_after_failures"`
+	HealthyAfterSuccesses        int               `json:"healthy_after_successes"`
+	BaseEjectMS                  int               `json:"base_eject_ms"`
+	MaxEjectMS                   int               `json:"max_eject_ms"`
+	Method                       string            `json:"method"`
+	Headers                      map[string]string `json:"headers"`
+	ExpectedStatuses             []string          `json:"expected_statuses"`
+	BodyContains                 string            `json:"body_contains"`
+	TLS                          bool              `json:"tls"`
+	TLSServerName                string            `json:"tls_server_name"`
+	TLSInsecureSkipVerify        bool              `json:"tls_insecure_skip_verify"`
+	Port                         int               `json:"port"`
+	EndpointPorts                map[string]int    `json:"endpoint_ports"`
+	GRPCService                  string            `json:"grpc_service"`
+	PassiveFailureStatuses       []string          `json:"passive_failure_statuses"`
+	UnhealthyAfterStatusFailures int               `json:"unhealthy_after_status_failures"`
 }
 
 type PoolTransportConfig struct {
-	MaxIdlePerHost    int `json:"max_idle_per_host"`
-	MaxConnsPerHost   int `json:"max_conns_per_host"`
-	IdleConnTimeoutMS int `json:"idle_conn_timeout_ms"`
+	MaxIdlePerHost    int                 `json:"max_idle_per_host"`
+	MaxConnsPerHost   int                 `json:"max_conns_per_host"`
+	IdleConnTimeoutMS int                 `json:"idle_conn_timeout_ms"`
+	Warmup            WarmupConfig        `json:"warmup"`
+	DNS               DNSConfig           `json:"dns"`
+	TLS               UpstreamTLSConfig   `json:"tls"`
+	Proxy             OutboundProxyConfig `json:"proxy"`
+}
+
+type OutboundProxyConfig struct {
+	URL         string   `json:"url"`
+	UsernameEnv string   `json:"username_env"`
+	PasswordEnv string   `json:"password_env"`
+	NoProxy     []string `json:"no_proxy"`
+}
+
+type UpstreamTLSConfig struct {
+	Enabled            bool     `json:"enabled"`
+	ServerName         string   `json:"server_name"`
+	ALPN               []string `json:"alpn"`
+	CAFile             string   `json:"ca_file"`
+	InsecureSkipVerify bool     `json:"insecure_skip_verify"`
+}
+
+type DNSConfig struct {
+	Enabled       bool   `json:"enabled"`
+	Resolver      string `json:"resolver"`
+	MinTTLMS      int    `json:"min_ttl_ms"`
+	MaxTTLMS      int    `json:"max_ttl_ms"`
+	NegativeTTLMS int    `json:"negative_ttl_ms"`
+	TimeoutMS     int    `json:"timeout_ms"`
+}
+
+type WarmupConfig struct {
+	Connections int    `json:"connections"`
+	Path        string `json:"path"`
+	TimeoutMS   int    `json:"timeout_ms"`
+}
+
+type SlowStartConfig struct {
+	WindowMS         int `json:"window_ms"`
+	MinWeightPercent int `json:"min_weight_percent"`
+}
+
+type LocalityConfig struct {
+	LocalZone              string            `json:"local_zone"`
+	EndpointZones          map[string]string `json:"endpoint_zones"`
+	MinLocalHealthyPercent int               `json:"min_local_healthy_percent"`
+}
+
+type PoolDrainConfig struct {
+	TimeoutMS   int `json:"timeout_ms"`
+	MaxBudgetMS int `json:"max_budget_ms"`
+}
+
+type AdaptiveConcurrencyConfig struct {
+	Enabled            bool    `json:"enabled"`
+	Algorithm          string  `json:"algorithm"`
+	InitialLimit       int     `json:"initial_limit"`
+	MinLimit           int     `json:"min_limit"`
+	MaxLimit           int     `json:"max_limit"`
+	WindowMS           int     `json:"window_ms"`
+	Tolerance          float64 `json:"tolerance"`
+	Smoothing          float64 `json:"smoothing"`
+	BackoffRatio       float64 `json:"backoff_ratio"`
+	LatencyThresholdMS int     `json:"latency_threshold_ms"`
 }
 
 type BreakerConfig struct {
@@ -220,11 +723,20 @@ type OutlierConfig struct {
 	LatencyMinSamples           int  `json:"latency_min_samples"`
 	LatencyMultiplier           int  `json:"latency_multiplier"`
 	LatencyConsecutiveIntervals int  `json:"latency_consecutive_intervals"`
+	Shared                      bool `json:"shared"`
 }
 
 func ParseJSON(data []byte) (*Config, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
 	var cfg Config
-	if err := json.Unmarshal(data, &cfg); err != nil {
+	if err := decoder.Decode(&cfg); err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(&struct{}{}); err != io.EOF {
+		return nil, errors.New("unexpected data after config document")
+	}
+	if err := ValidateSchema(&cfg); err != nil {
 		return nil, err
 	}
 	return &cfg, nil
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+type LookupEnvFunc func(name string) (string, bool)
+
+type EnvError struct {
+	Missing []string
+}
+
+func (e *EnvError) Error() string {
+	return "missing required environment variables: " + strings.Join(e.Missing, ", ")
+}
+
+func InterpolateEnv(data []byte, lookup LookupEnvFunc, escape func(string) string) ([]byte, error) {
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+	if escape == nil {
+		escape = func(value string) string { return value }
+	}
+
+	src := string(data)
+	var out strings.Builder
+	out.Grow(len(src))
+	missing := make(map[string]string)
+	for i := 0; i < len(src); i++ {
+		if src[i] != '$' || i+1 >= len(src) {
+			out.WriteByte(src[i])
+			continue
+		}
+		if src[i+1] == '$' {
+			out.WriteByte('$')
+			i++
+			continue
+		}
+		if src[i+1] != '{' {
+			out.WriteByte(src[i])
+			continue
+		}
+		end := strings.IndexByte(src[i+2:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated variable reference at offset %d", i)
+		}
+		expr := src[i+2 : i+2+end]
+		value, problem, err := resolveEnvExpr(expr, lookup)
+		if err != nil {
+			return nil, fmt.Errorf("variable reference at offset %d: %w", i, err)
+		}
+		if problem != "" {
+			missing[envExprName(expr)] = problem
+		}
+		out.WriteString(escape(value))
+		i += end + 2
+	}
+
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for i, name := range names {
+			names[i] = missing[name]
+		}
+		return nil, &EnvError{Missing: names}
+	}
+	return []byte(out.String()), nil
+}
+
+func resolveEnvExpr(expr string, lookup LookupEnvFunc) (string, string, error) {
+	name := envExprName(expr)
+	if !validEnvName(name) {
+		return "", "", fmt.Errorf("invalid variable name %q", name)
+	}
+	value, ok := lookup(name)
+	rest := expr[len(name):]
+	switch {
+	case rest == "":
+		if !ok {
+			return "", name, nil
+		}
+		return value, "", nil
+	case strings.HasPrefix(rest, ":-"):
+		if !ok || value == "" {
+			return rest[2:], "", nil
+		}
+		return value, "", nil
+	case strings.HasPrefix(rest, ":?"):
+		if !ok || value == "" {
+			if message := rest[2:]; message != "" {
+				return "", name + " (" + message + ")", nil
+			}
+			return "", name, nil
+		}
+		return value, "", nil
+	}
+	return "", "", fmt.Errorf("unsupported modifier %q for %s", rest, name)
+}
+
+func envExprName(expr string) string {
+	if idx := strings.IndexByte(expr, ':'); idx >= 0 {
+		return expr[:idx]
+	}
+	return expr
+}
+
+func validEnvName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_', r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func jsonStringEscape(value string) string {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	return string(encoded[1 : len(encoded)-1])
+}
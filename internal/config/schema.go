@@ -0,0 +1,233 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const SchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+type SchemaError struct {
+	Path    string
+	Message string
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+type fieldRule struct {
+	enum []string
+	min  *float64
+	max  *float64
+}
+
+func bounded(min float64, max float64) fieldRule {
+	return fieldRule{min: &min, max: &max}
+}
+
+var fieldRules = map[string]fieldRule{
+	"Stream.mode":                                  {enum: []string{"tcp"}},
+	"Listener.protocol":                            {enum: []string{"http", "https"}},
+	"TLSConfig.min_version":                        {enum: []string{"1.2", "1.3"}},
+	"CacheStoreConfig.backend":                     {enum: []string{"memory", "disk"}},
+	"RoutePolicy.auth":                             {enum: []string{"oidc", "api_key", "hmac"}},
+	"ScriptConfig.failure_mode":                    {enum: []string{"fail_open", "fail_closed"}},
+	"PluginFilter.type":                            {enum: []string{"grpc", "wasm"}},
+	"PluginFilter.failure_mode":                    {enum: []string{"fail_open", "fail_closed"}},
+	"AutoDrainConfig.mode":                         {enum: []string{"immediate", "graceful"}},
+	"RampConfig.on_breach":                         {enum: []string{"pause", "revert"}},
+	"HealthConfig.type":                            {enum: []string{"http", "tcp", "grpc"}},
+	"AdaptiveConcurrencyConfig.algorithm":          {enum: []string{"gradient", "aimd"}},
+	"RetryBudgetConfig.percent_of_successes":       bounded(0, 100),
+	"ClientRetryCapConfig.percent_of_successes":    bounded(0, 100),
+	"MirrorConfig.percent":                         bounded(0, 100),
+	"AccessLogConfig.sample_rate":                  bounded(0, 1),
+	"AccessLogSinkConfig.type":                     {enum: []string{"stdout", "file", "syslog", "http", "kafka"}},
+	"AccessLogSinkConfig.network":                  {enum: []string{"udp", "tcp", "unix", "unixgram"}},
+	"MetricsPushConfig.type":                       {enum: []string{"statsd", "otlp"}},
+	"TrafficConfig.stable_weight":                  bounded(0, 100),
+	"TrafficConfig.canary_weight":                  bounded(0, 100),
+	"RampConfig.max_error_rate":                    bounded(0, 1),
+	"BreakerConfig.failure_rate_threshold_percent": bounded(0, 100),
+	"OutlierConfig.error_rate_threshold_percent":   bounded(0, 100),
+	"OutlierConfig.max_eject_percent":              bounded(0, 100),
+}
+
+func Schema() map[string]interface{} {
+	defs := make(map[string]interface{})
+	root := structSchema(reflect.TypeOf(Config{}), defs)
+	root["$schema"] = SchemaDialect
+	root["title"] = "modern_reverse_proxy config"
+	root["$defs"] = defs
+	return root
+}
+
+func ValidateSchema(cfg *Config) error {
+	if cfg == nil {
+		return nil
+	}
+	return validateValue(reflect.ValueOf(*cfg), "")
+}
+
+func structSchema(t reflect.Type, defs map[string]interface{}) map[string]interface{} {
+	properties := make(map[string]interface{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, ok := jsonFieldName(field)
+		if !ok {
+			continue
+		}
+		prop := typeSchema(field.Type, defs)
+		if rule, ok := fieldRules[t.Name()+"."+name]; ok {
+			prop = applyRule(prop, rule)
+		}
+		properties[name] = prop
+	}
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+func typeSchema(t reflect.Type, defs map[string]interface{}) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Pointer:
+		inner := typeSchema(t.Elem(), defs)
+		return map[string]interface{}{"anyOf": []interface{}{inner, map[string]interface{}{"type": "null"}}}
+	case reflect.Struct:
+		if _, ok := defs[t.Name()]; !ok {
+			defs[t.Name()] = nil
+			defs[t.Name()] = structSchema(t, defs)
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + t.Name()}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": []interface{}{"array", "null"}, "items": typeSchema(t.Elem(), defs)}
+	case reflect.Map:
+		return map[string]interface{}{"type": []interface{}{"object", "null"}, "additionalProperties": typeSchema(t.Elem(), defs)}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	}
+	return map[string]interface{}{}
+}
+
+func applyRule(prop map[string]interface{}, rule fieldRule) map[string]interface{} {
+	if len(rule.enum) > 0 {
+		values := make([]interface{}, 0, len(rule.enum)+1)
+		values = append(values, "")
+		for _, value := range rule.enum {
+			values = append(values, value)
+		}
+		prop["enum"] = values
+	}
+	if rule.min != nil {
+		prop["minimum"] = *rule.min
+	}
+	if rule.max != nil {
+		prop["maximum"] = *rule.max
+	}
+	return prop
+}
+
+func validateValue(v reflect.Value, path string) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return validateValue(v.Elem(), path)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := validateValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := validateValue(iter.Value(), fmt.Sprintf("%s[%q]", path, fmt.Sprint(iter.Key().Interface()))); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			name, ok := jsonFieldName(t.Field(i))
+			if !ok {
+				continue
+			}
+			fieldPath := name
+			if path != "" {
+				fieldPath = path + "." + name
+			}
+			if rule, ok := fieldRules[t.Name()+"."+name]; ok {
+				if err := checkRule(v.Field(i), fieldPath, rule); err != nil {
+					return err
+				}
+			}
+			if err := validateValue(v.Field(i), fieldPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func checkRule(v reflect.Value, path string, rule fieldRule) error {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if len(rule.enum) > 0 && v.Kind() == reflect.String {
+		value := strings.ToLower(strings.TrimSpace(v.String()))
+		if value == "" {
+			return nil
+		}
+		for _, allowed := range rule.enum {
+			if value == allowed {
+				return nil
+			}
+		}
+		return &SchemaError{Path: path, Message: fmt.Sprintf("%q must be one of %s", v.String(), strings.Join(rule.enum, ", "))}
+	}
+	var number float64
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number = float64(v.Int())
+	case reflect.Float32, reflect.Float64:
+		number = v.Float()
+	default:
+		return nil
+	}
+	if (rule.min != nil && number < *rule.min) || (rule.max != nil && number > *rule.max) {
+		return &SchemaError{Path: path, Message: fmt.Sprintf("%v must be between %v and %v", number, *rule.min, *rule.max)}
+	}
+	return nil
+}
+
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name, true
+}
@@ -3,9 +3,13 @@ package config
 import (
 	"errors"
 	"fmt"
-	"os"
+	"math"
+	"net"
+	"net/url"
 	"strings"
 	"time"
+
+	"modern_reverse_proxy/internal/secrets"
 )
 
 const defaultMetricsTokenEnv = "METRICS_TOKEN"
@@ -21,6 +25,9 @@ func Validate(cfg *Config) ([]string, error) {
 	if err := validateMetrics(cfg); err != nil {
 		return warnings, err
 	}
+	if err := validateCacheStore(cfg); err != nil {
+		return warnings, err
+	}
 	if err := validateRoutes(cfg, &warnings); err != nil {
 		return warnings, err
 	}
@@ -51,19 +58,97 @@ func validateLimits(cfg *Config, warnings *[]string) error {
 }
 
 func validateMetrics(cfg *Config) error {
-	if cfg == nil || cfg.Metrics == nil || !cfg.Metrics.RequireToken {
+	if cfg == nil || cfg.Metrics == nil {
+		return nil
+	}
+	if err := validateMetricsPush(cfg.Metrics.Push); err != nil {
+		return err
+	}
+	if err := validateHistograms(cfg.Metrics.Histograms); err != nil {
+		return err
+	}
+	if !cfg.Metrics.RequireToken {
 		return nil
 	}
 	env := strings.TrimSpace(cfg.Metrics.TokenEnv)
 	if env == "" {
 		env = defaultMetricsTokenEnv
 	}
-	if strings.TrimSpace(os.Getenv(env)) == "" {
+	if strings.TrimSpace(secrets.LookupEnv(env)) == "" {
 		return fmt.Errorf("metrics token missing in %s", env)
 	}
 	return nil
 }
 
+func validateMetricsPush(push *MetricsPushConfig) error {
+	if push == nil {
+		return nil
+	}
+	if push.IntervalMS < 0 || push.TimeoutMS < 0 {
+		return errors.New("metrics.push interval_ms and timeout_ms must be >= 0")
+	}
+	switch strings.ToLower(strings.TrimSpace(push.Type)) {
+	case "statsd":
+		if _, _, err := net.SplitHostPort(push.Address); err != nil {
+			return fmt.Errorf("metrics.push.address must be host:port: %v", err)
+		}
+	case "otlp":
+		parsed, err := url.Parse(push.Endpoint)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return errors.New("metrics.push.endpoint must be an absolute http(s) url")
+		}
+	default:
+		return errors.New("metrics.push.type must be statsd or otlp")
+	}
+	return nil
+}
+
+func validateHistograms(histograms *HistogramConfig) error {
+	if histograms == nil {
+		return nil
+	}
+	for i, bound := range histograms.Buckets {
+		if bound <= 0 || math.IsInf(bound, 0) || math.IsNaN(bound) {
+			return fmt.Errorf("metrics.histograms.buckets[%d] must be a positive finite number", i)
+		}
+		if i > 0 && bound <= histograms.Buckets[i-1] {
+			return errors.New("metrics.histograms.buckets must be strictly increasing")
+		}
+	}
+	if histograms.NativeBucketFactor != 0 && histograms.NativeBucketFactor <= 1 {
+		return errors.New("metrics.histograms.native_bucket_factor must be > 1")
+	}
+	if histograms.NativeMaxBuckets < 0 {
+		return errors.New("metrics.histograms.native_max_buckets must be >= 0")
+	}
+	return nil
+}
+
+func validateCacheStore(cfg *Config) error {
+	if cfg == nil {
+		return errors.New("config is nil")
+	}
+	switch strings.ToLower(strings.TrimSpace(cfg.Cache.Backend)) {
+	case "", "memory":
+	case "disk":
+		if strings.TrimSpace(cfg.Cache.Dir) == "" {
+			return errors.New("cache.dir is required for disk backend")
+		}
+	default:
+		return fmt.Errorf("cache.backend %q unsupported", cfg.Cache.Backend)
+	}
+	if cfg.Cache.MaxBytes < 0 {
+		return errors.New("cache.max_bytes must be >= 0")
+	}
+	if cfg.Cache.MaxObjectBytes < 0 {
+		return errors.New("cache.max_object_bytes must be >= 0")
+	}
+	if cfg.Cache.MemoryShards < 0 {
+		return errors.New("cache.memory_shards must be >= 0")
+	}
+	return nil
+}
+
 func validateRoutes(cfg *Config, warnings *[]string) error {
 	if cfg == nil {
 		return errors.New("config is nil")
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+func ParseYAML(data []byte) (*Config, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return parseYAMLDocument(doc)
+}
+
+func parseYAMLDocument(doc interface{}) (*Config, error) {
+	if doc == nil {
+		return &Config{}, nil
+	}
+	normalized, err := normalizeYAML(doc)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(normalized)
+	if err != nil {
+		return nil, err
+	}
+	return ParseJSON(raw)
+}
+
+func IsYAMLPath(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+func ParseFile(path string, data []byte) (*Config, error) {
+	if IsYAMLPath(path) {
+		return parseYAMLWithEnv(data, nil)
+	}
+	expanded, err := InterpolateEnv(data, nil, jsonStringEscape)
+	if err != nil {
+		return nil, err
+	}
+	return ParseJSON(expanded)
+}
+
+func parseYAMLWithEnv(data []byte, lookup LookupEnvFunc) (*Config, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	missing := make(map[string]struct{})
+	if err := interpolateYAMLNode(&root, lookup, missing); err != nil {
+		return nil, err
+	}
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, &EnvError{Missing: names}
+	}
+	var doc interface{}
+	if err := root.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return parseYAMLDocument(doc)
+}
+
+func interpolateYAMLNode(node *yaml.Node, lookup LookupEnvFunc, missing map[string]struct{}) error {
+	if node.Kind == yaml.ScalarNode && strings.Contains(node.Value, "$") {
+		expanded, err := InterpolateEnv([]byte(node.Value), lookup, nil)
+		var envErr *EnvError
+		if errors.As(err, &envErr) {
+			for _, name := range envErr.Missing {
+				missing[name] = struct{}{}
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		node.Value = string(expanded)
+		if node.Style == 0 {
+			node.Tag = ""
+		}
+		return nil
+	}
+	for _, child := range node.Content {
+		if err := interpolateYAMLNode(child, lookup, missing); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func normalizeYAML(value interface{}) (interface{}, error) {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, item := range typed {
+			normalized, err := normalizeYAML(item)
+			if err != nil {
+				return nil, err
+			}
+			typed[key] = normalized
+		}
+		return typed, nil
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(typed))
+		for key, item := range typed {
+			normalized, err := normalizeYAML(item)
+			if err != nil {
+				return nil, err
+			}
+			out[fmt.Sprint(key)] = normalized
+		}
+		return out, nil
+	case []interface{}:
+		for i, item := range typed {
+			normalized, err := normalizeYAML(item)
+			if err != nil {
+				return nil, err
+			}
+			typed[i] = normalized
+		}
+		return typed, nil
+	}
+	return value, nil
+}
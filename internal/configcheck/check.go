@@ -0,0 +1,130 @@
+package configcheck
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"modern_reverse_proxy/internal/apply"
+	"modern_reverse_proxy/internal/bundle"
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/provider"
+)
+
+type Input struct {
+	ConfigFile string
+	ConfigDir  string
+	BundleFile string
+	Keyring    *bundle.Keyring
+}
+
+type Report struct {
+	Source   string
+	Version  string
+	Routes   int
+	Pools    int
+	Streams  int
+	Warnings []string
+	Errors   []string
+}
+
+func (r *Report) OK() bool {
+	return r != nil && len(r.Errors) == 0
+}
+
+func (r *Report) Print(w io.Writer) {
+	if r == nil {
+		return
+	}
+	if r.Source != "" {
+		fmt.Fprintf(w, "source: %s\n", r.Source)
+	}
+	for _, warning := range r.Warnings {
+		fmt.Fprintf(w, "warning: %s\n", warning)
+	}
+	for _, problem := range r.Errors {
+		fmt.Fprintf(w, "error: %s\n", problem)
+	}
+	if !r.OK() {
+		fmt.Fprintf(w, "result: invalid (%d errors, %d warnings)\n", len(r.Errors), len(r.Warnings))
+		return
+	}
+	fmt.Fprintf(w, "result: ok (%d routes, %d pools, %d streams, %d warnings) version=%s\n", r.Routes, r.Pools, r.Streams, len(r.Warnings), r.Version)
+}
+
+func Run(ctx context.Context, input Input) *Report {
+	report := &Report{}
+	raw, err := load(ctx, input, report)
+	if err != nil {
+		report.Errors = append(report.Errors, err.Error())
+		return report
+	}
+
+	result, err := apply.NewManager(apply.ManagerConfig{}).Apply(ctx, raw, "validate", apply.ModeValidate)
+	if err != nil {
+		report.Errors = append(report.Errors, err.Error())
+		return report
+	}
+	report.Version = result.Version
+	report.Warnings = append(report.Warnings, result.Warnings...)
+	if result.Config != nil {
+		report.Routes = len(result.Config.Routes)
+		report.Pools = len(result.Config.Pools)
+		report.Streams = len(result.Config.Streams)
+	}
+	return report
+}
+
+func load(ctx context.Context, input Input, report *Report) ([]byte, error) {
+	sources := 0
+	for _, value := range []string{input.ConfigFile, input.ConfigDir, input.BundleFile} {
+		if value != "" {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return nil, errors.New("exactly one of config-file, config-dir or bundle-file is required")
+	}
+
+	if input.BundleFile != "" {
+		report.Source = "bundle:" + input.BundleFile
+		return loadBundle(input.BundleFile, input.Keyring, report)
+	}
+
+	var source provider.Provider
+	if input.ConfigDir != "" {
+		report.Source = "dir:" + input.ConfigDir
+		source = provider.NewDirectoryProvider(input.ConfigDir)
+	} else {
+		report.Source = "file:" + input.ConfigFile
+		source = provider.NewFileProvider(input.ConfigFile)
+	}
+	cfg, err := source.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	return json.Marshal(cfg)
+}
+
+func loadBundle(path string, keyring *bundle.Keyring, report *Report) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var item bundle.Bundle
+	if err := json.Unmarshal(data, &item); err != nil {
+		return nil, fmt.Errorf("decode bundle: %w", err)
+	}
+	if keyring == nil || keyring.Len() == 0 {
+		report.Warnings = append(report.Warnings, "bundle signature not verified: no public key or keyring provided")
+	} else if err := keyring.Verify(item); err != nil {
+		return nil, fmt.Errorf("bundle %s: %s: %w", item.Meta.Version, bundle.VerifyResult(err), err)
+	}
+	return item.ConfigBytes()
+}
@@ -2,6 +2,7 @@ package distributor
 
 import (
 	"net/http"
+	"sync"
 
 	"modern_reverse_proxy/internal/bundle"
 )
@@ -10,6 +11,7 @@ type Config struct {
 	Storage      bundle.Storage
 	Token        string
 	RequireToken bool
+	Keyring      *bundle.KeyringDocument
 }
 
 type Server struct {
@@ -17,6 +19,9 @@ type Server struct {
 	token        string
 	requireToken bool
 	mux          *http.ServeMux
+
+	keyringMu sync.RWMutex
+	keyring   *bundle.KeyringDocument
 }
 
 func NewHandler(cfg Config) *Server {
@@ -25,9 +30,17 @@ func NewHandler(cfg Config) *Server {
 		token:        cfg.Token,
 		requireToken: cfg.RequireToken,
 		mux:          http.NewServeMux(),
+		keyring:      cfg.Keyring,
 	}
 	server.mux.HandleFunc("/bundles/latest", server.handleLatest)
 	server.mux.HandleFunc("/bundles", server.handleList)
 	server.mux.HandleFunc("/bundles/", server.handleGet)
+	server.mux.HandleFunc("/keyring", server.handleKeyring)
 	return server
 }
+
+func (s *Server) SetKeyring(doc bundle.KeyringDocument) {
+	s.keyringMu.Lock()
+	s.keyring = &doc
+	s.keyringMu.Unlock()
+}
@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+
+	"modern_reverse_proxy/internal/bundle"
 )
 
 const tokenHeader = "X-Distributor-Token"
@@ -37,7 +39,7 @@ func (s *Server) handleLatest(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
-	writeJSON(w, bundle)
+	writeBundle(w, r, bundle)
 }
 
 func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
@@ -59,7 +61,7 @@ func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
-	writeJSON(w, bundle)
+	writeBundle(w, r, bundle)
 }
 
 func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
@@ -81,6 +83,46 @@ func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, meta)
 }
 
+func (s *Server) handleKeyring(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	s.keyringMu.RLock()
+	doc := s.keyring
+	s.keyringMu.RUnlock()
+	if doc == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	writeJSON(w, doc)
+}
+
+func writeBundle(w http.ResponseWriter, r *http.Request, payload bundle.Bundle) {
+	etag := payload.ETag()
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+	writeJSON(w, payload)
+}
+
+func etagMatches(header string, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
 func writeJSON(w http.ResponseWriter, payload interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(payload)
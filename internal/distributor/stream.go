@@ -0,0 +1,126 @@
+package distributor
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"modern_reverse_proxy/internal/bundle"
+	"modern_reverse_proxy/internal/distributor/proto"
+	"modern_reverse_proxy/internal/logging"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var logger = logging.For("distributor")
+
+const (
+	streamTokenMetadata  = "x-distributor-token"
+	defaultStreamRefresh = time.Second
+)
+
+type StreamConfig struct {
+	Storage      bundle.Storage
+	Token        string
+	RequireToken bool
+	Refresh      time.Duration
+}
+
+type StreamServer struct {
+	distributorpb.UnimplementedBundleStreamServer
+
+	storage      bundle.Storage
+	token        string
+	requireToken bool
+	refresh      time.Duration
+}
+
+func NewStreamServer(cfg StreamConfig) *StreamServer {
+	refresh := cfg.Refresh
+	if refresh <= 0 {
+		refresh = defaultStreamRefresh
+	}
+	return &StreamServer{
+		storage:      cfg.Storage,
+		token:        cfg.Token,
+		requireToken: cfg.RequireToken,
+		refresh:      refresh,
+	}
+}
+
+func (s *StreamServer) StreamBundles(stream distributorpb.BundleStream_StreamBundlesServer) error {
+	if s.storage == nil {
+		return status.Error(codes.Unavailable, "storage unavailable")
+	}
+	if s.requireToken && s.token != "" {
+		md, _ := metadata.FromIncomingContext(stream.Context())
+		if values := md.Get(streamTokenMetadata); len(values) == 0 || values[0] != s.token {
+			return status.Error(codes.Unauthenticated, "invalid distributor token")
+		}
+	}
+
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	nodeID := first.GetNodeId()
+	sent := first.GetVersionInfo()
+	requests := make(chan *distributorpb.StreamRequest)
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+			select {
+			case requests <- req:
+			case <-stream.Context().Done():
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(s.refresh)
+	defer ticker.Stop()
+	var nonce uint64
+	pending := ""
+	for {
+		if pending == "" {
+			latest, ok := s.storage.Latest()
+			if ok && latest.Meta.Version != sent {
+				payload, err := json.Marshal(latest)
+				if err != nil {
+					return status.Error(codes.Internal, err.Error())
+				}
+				nonce++
+				pending = strconv.FormatUint(nonce, 10)
+				if err := stream.Send(&distributorpb.BundleUpdate{Version: latest.Meta.Version, Nonce: pending, BundleJson: payload}); err != nil {
+					return err
+				}
+				sent = latest.Meta.Version
+			}
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case err := <-recvErr:
+			return err
+		case req := <-requests:
+			if req.GetResponseNonce() != pending {
+				continue
+			}
+			pending = ""
+			if detail := req.GetErrorDetail(); detail != "" {
+				logger.Warn("distributor_stream", "node_id", nodeID, "version", sent, "result", "nack", "reason", detail)
+				continue
+			}
+			logger.Debug("distributor_stream", "node_id", nodeID, "version", req.GetVersionInfo(), "result", "ack")
+		case <-ticker.C:
+		}
+	}
+}
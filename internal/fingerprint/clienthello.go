@@ -0,0 +1,286 @@
+package fingerprint
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	extensionServerName          = 0x0000
+	extensionSupportedGroups     = 0x000a
+	extensionECPointFormats      = 0x000b
+	extensionSignatureAlgorithms = 0x000d
+	extensionALPN                = 0x0010
+	extensionSupportedVersions   = 0x002b
+)
+
+var errMalformedHello = errors.New("malformed client hello")
+
+type Fingerprint struct {
+	JA3     string
+	JA3Hash string
+	JA4     string
+}
+
+type clientHello struct {
+	version             uint16
+	ciphers             []uint16
+	extensions          []uint16
+	curves              []uint16
+	pointFormats        []uint8
+	signatureAlgorithms []uint16
+	supportedVersions   []uint16
+	alpn                []string
+	hasServerName       bool
+	serverName          string
+}
+
+func parseClientHello(message []byte) (clientHello, error) {
+	var hello clientHello
+	if len(message) < 4 || message[0] != 1 {
+		return hello, errMalformedHello
+	}
+	body := message[4:]
+	if len(body) < 34 {
+		return hello, errMalformedHello
+	}
+	hello.version = binary.BigEndian.Uint16(body)
+	body = body[34:]
+
+	sessionID, body, ok := readVector8(body)
+	if !ok || len(sessionID) > 32 {
+		return hello, errMalformedHello
+	}
+	ciphers, body, ok := readVector16(body)
+	if !ok || len(ciphers)%2 != 0 {
+		return hello, errMalformedHello
+	}
+	hello.ciphers = uint16List(ciphers)
+	if _, body, ok = readVector8(body); !ok {
+		return hello, errMalformedHello
+	}
+	if len(body) == 0 {
+		return hello, nil
+	}
+	extensions, _, ok := readVector16(body)
+	if !ok {
+		return hello, errMalformedHello
+	}
+	for len(extensions) > 0 {
+		if len(extensions) < 4 {
+			return hello, errMalformedHello
+		}
+		extType := binary.BigEndian.Uint16(extensions)
+		var data []byte
+		data, extensions, ok = readVector16(extensions[2:])
+		if !ok {
+			return hello, errMalformedHello
+		}
+		hello.extensions = append(hello.extensions, extType)
+		switch extType {
+		case extensionServerName:
+			hello.hasServerName = true
+			hello.serverName = parseServerName(data)
+		case extensionSupportedGroups:
+			if list, _, ok := readVector16(data); ok {
+				hello.curves = uint16List(list)
+			}
+		case extensionECPointFormats:
+			if list, _, ok := readVector8(data); ok {
+				hello.pointFormats = append([]uint8(nil), list...)
+			}
+		case extensionSignatureAlgorithms:
+			if list, _, ok := readVector16(data); ok {
+				hello.signatureAlgorithms = uint16List(list)
+			}
+		case extensionSupportedVersions:
+			if list, _, ok := readVector8(data); ok {
+				hello.supportedVersions = uint16List(list)
+			}
+		case extensionALPN:
+			if list, _, ok := readVector16(data); ok {
+				for len(list) > 0 {
+					var proto []byte
+					proto, list, ok = readVector8(list)
+					if !ok {
+						break
+					}
+					hello.alpn = append(hello.alpn, string(proto))
+				}
+			}
+		}
+	}
+	return hello, nil
+}
+
+func parseServerName(data []byte) string {
+	list, _, ok := readVector16(data)
+	if !ok {
+		return ""
+	}
+	for len(list) > 0 {
+		nameType := list[0]
+		var name []byte
+		name, list, ok = readVector16(list[1:])
+		if !ok {
+			return ""
+		}
+		if nameType == 0 {
+			return strings.ToLower(string(name))
+		}
+	}
+	return ""
+}
+
+func (h clientHello) fingerprint() Fingerprint {
+	ja3 := h.ja3()
+	sum := md5.Sum([]byte(ja3))
+	return Fingerprint{JA3: ja3, JA3Hash: hex.EncodeToString(sum[:]), JA4: h.ja4()}
+}
+
+func (h clientHello) ja3() string {
+	formats := make([]uint16, 0, len(h.pointFormats))
+	for _, format := range h.pointFormats {
+		formats = append(formats, uint16(format))
+	}
+	return strings.Join([]string{
+		strconv.Itoa(int(h.version)),
+		joinDecimal(h.ciphers),
+		joinDecimal(h.extensions),
+		joinDecimal(h.curves),
+		joinDecimal(formats),
+	}, ",")
+}
+
+func (h clientHello) ja4() string {
+	version := h.version
+	if supported := withoutGrease(h.supportedVersions); len(supported) > 0 {
+		version = 0
+		for _, candidate := range supported {
+			if candidate > version {
+				version = candidate
+			}
+		}
+	}
+	sni := "i"
+	if h.hasServerName {
+		sni = "d"
+	}
+	ciphers := withoutGrease(h.ciphers)
+	extensions := withoutGrease(h.extensions)
+	alpn := "00"
+	if len(h.alpn) > 0 && h.alpn[0] != "" {
+		first := h.alpn[0]
+		alpn = string(first[0]) + string(first[len(first)-1])
+	}
+	prefix := fmt.Sprintf("t%s%s%02d%02d%s", tlsVersionLabel(version), sni, min(len(ciphers), 99), min(len(extensions), 99), alpn)
+
+	sortedCiphers := append([]uint16(nil), ciphers...)
+	sort.Slice(sortedCiphers, func(i, j int) bool { return sortedCiphers[i] < sortedCiphers[j] })
+	sortedExtensions := make([]uint16, 0, len(extensions))
+	for _, ext := range extensions {
+		if ext == extensionServerName || ext == extensionALPN {
+			continue
+		}
+		sortedExtensions = append(sortedExtensions, ext)
+	}
+	sort.Slice(sortedExtensions, func(i, j int) bool { return sortedExtensions[i] < sortedExtensions[j] })
+
+	extensionInput := joinHex(sortedExtensions)
+	if signatures := withoutGrease(h.signatureAlgorithms); len(signatures) > 0 {
+		extensionInput += "_" + joinHex(signatures)
+	}
+	return prefix + "_" + truncatedHash(joinHex(sortedCiphers), len(sortedCiphers) == 0) + "_" + truncatedHash(extensionInput, len(sortedExtensions) == 0)
+}
+
+func tlsVersionLabel(version uint16) string {
+	switch version {
+	case 0x0304:
+		return "13"
+	case 0x0303:
+		return "12"
+	case 0x0302:
+		return "11"
+	case 0x0301:
+		return "10"
+	case 0x0300:
+		return "s3"
+	default:
+		return "00"
+	}
+}
+
+func truncatedHash(input string, empty bool) string {
+	if empty {
+		return "000000000000"
+	}
+	sum := sha256.Sum256([]byte(input))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+func isGrease(value uint16) bool {
+	return value&0x0f0f == 0x0a0a && value>>8 == value&0xff
+}
+
+func withoutGrease(values []uint16) []uint16 {
+	result := make([]uint16, 0, len(values))
+	for _, value := range values {
+		if !isGrease(value) {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+func joinDecimal(values []uint16) string {
+	parts := make([]string, 0, len(values))
+	for _, value := range withoutGrease(values) {
+		parts = append(parts, strconv.Itoa(int(value)))
+	}
+	return strings.Join(parts, "-")
+}
+
+func joinHex(values []uint16) string {
+	parts := make([]string, 0, len(values))
+	for _, value := range values {
+		parts = append(parts, fmt.Sprintf("%04x", value))
+	}
+	return strings.Join(parts, ",")
+}
+
+func uint16List(data []byte) []uint16 {
+	values := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		values = append(values, binary.BigEndian.Uint16(data[i:]))
+	}
+	return values
+}
+
+func readVector8(data []byte) ([]byte, []byte, bool) {
+	if len(data) < 1 {
+		return nil, nil, false
+	}
+	length := int(data[0])
+	if len(data) < 1+length {
+		return nil, nil, false
+	}
+	return data[1 : 1+length], data[1+length:], true
+}
+
+func readVector16(data []byte) ([]byte, []byte, bool) {
+	if len(data) < 2 {
+		return nil, nil, false
+	}
+	length := int(binary.BigEndian.Uint16(data))
+	if len(data) < 2+length {
+		return nil, nil, false
+	}
+	return data[2 : 2+length], data[2+length:], true
+}
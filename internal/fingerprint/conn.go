@@ -0,0 +1,132 @@
+package fingerprint
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"net"
+	"sync"
+)
+
+const (
+	maxHelloBytes       = 16 * 1024
+	recordHeaderLen     = 5
+	recordTypeHandshake = 22
+)
+
+type contextKey struct{}
+
+type Listener struct {
+	net.Listener
+}
+
+func NewListener(ln net.Listener) net.Listener {
+	if ln == nil {
+		return nil
+	}
+	return &Listener{Listener: ln}
+}
+
+func (l *Listener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &Conn{Conn: conn}, nil
+}
+
+type Conn struct {
+	net.Conn
+	mu          sync.Mutex
+	records     []byte
+	handshake   []byte
+	done        bool
+	fingerprint Fingerprint
+	ok          bool
+}
+
+func (c *Conn) Read(buffer []byte) (int, error) {
+	n, err := c.Conn.Read(buffer)
+	if n > 0 {
+		c.capture(buffer[:n])
+	}
+	return n, err
+}
+
+func (c *Conn) Fingerprint() (Fingerprint, bool) {
+	if c == nil {
+		return Fingerprint{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.fingerprint, c.ok
+}
+
+func (c *Conn) capture(data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.done {
+		return
+	}
+	c.records = append(c.records, data...)
+	for len(c.records) >= recordHeaderLen {
+		if c.records[0] != recordTypeHandshake {
+			c.finish()
+			return
+		}
+		length := int(binary.BigEndian.Uint16(c.records[3:5]))
+		if len(c.records) < recordHeaderLen+length {
+			break
+		}
+		c.handshake = append(c.handshake, c.records[recordHeaderLen:recordHeaderLen+length]...)
+		c.records = c.records[recordHeaderLen+length:]
+		if len(c.handshake) >= 4 {
+			messageLen := int(c.handshake[1])<<16 | int(c.handshake[2])<<8 | int(c.handshake[3])
+			if len(c.handshake) >= 4+messageLen {
+				if hello, err := parseClientHello(c.handshake[:4+messageLen]); err == nil {
+					c.fingerprint = hello.fingerprint()
+					c.ok = true
+				}
+				c.finish()
+				return
+			}
+		}
+	}
+	if len(c.records)+len(c.handshake) > maxHelloBytes {
+		c.finish()
+	}
+}
+
+func (c *Conn) finish() {
+	c.done = true
+	c.records = nil
+	c.handshake = nil
+}
+
+func ConnContext(ctx context.Context, conn net.Conn) context.Context {
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		conn = tlsConn.NetConn()
+	}
+	if captured, ok := conn.(*Conn); ok {
+		return context.WithValue(ctx, contextKey{}, captured)
+	}
+	return ctx
+}
+
+func FromContext(ctx context.Context) (Fingerprint, bool) {
+	if ctx == nil {
+		return Fingerprint{}, false
+	}
+	captured, ok := ctx.Value(contextKey{}).(*Conn)
+	if !ok {
+		return Fingerprint{}, false
+	}
+	return captured.Fingerprint()
+}
+
+func (f Fingerprint) Matches(values map[string]bool) bool {
+	if len(values) == 0 {
+		return false
+	}
+	return values[f.JA3Hash] || values[f.JA4]
+}
@@ -0,0 +1,78 @@
+package fingerprint
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+const DefaultLimiterSize = 10000
+
+type Limiter struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   float64
+	maxKeys int
+	items   map[string]*list.Element
+	order   *list.List
+}
+
+type bucket struct {
+	key       string
+	remaining float64
+	last      time.Time
+}
+
+func NewLimiter(rps int, burst int, maxKeys int) *Limiter {
+	if rps <= 0 {
+		return nil
+	}
+	if burst <= 0 {
+		burst = rps
+	}
+	if maxKeys <= 0 {
+		maxKeys = DefaultLimiterSize
+	}
+	return &Limiter{
+		rate:    float64(rps),
+		burst:   float64(burst),
+		maxKeys: maxKeys,
+		items:   make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (l *Limiter) Allow(key string) bool {
+	if l == nil || key == "" {
+		return true
+	}
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	element, ok := l.items[key]
+	if !ok {
+		element = l.order.PushFront(&bucket{key: key, remaining: l.burst, last: now})
+		l.items[key] = element
+		if l.order.Len() > l.maxKeys {
+			oldest := l.order.Back()
+			l.order.Remove(oldest)
+			delete(l.items, oldest.Value.(*bucket).key)
+		}
+	} else {
+		l.order.MoveToFront(element)
+	}
+
+	state := element.Value.(*bucket)
+	state.remaining += now.Sub(state.last).Seconds() * l.rate
+	if state.remaining > l.burst {
+		state.remaining = l.burst
+	}
+	state.last = now
+	if state.remaining < 1 {
+		return false
+	}
+	state.remaining--
+	return true
+}
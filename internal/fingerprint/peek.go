@@ -0,0 +1,59 @@
+package fingerprint
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+var ErrNotTLS = errors.New("connection did not start with a tls handshake")
+
+func PeekServerName(r io.Reader) (string, []byte, error) {
+	var buffered []byte
+	fill := func(n int) error {
+		if n > maxHelloBytes+recordHeaderLen {
+			return errMalformedHello
+		}
+		for len(buffered) < n {
+			chunk := make([]byte, n-len(buffered))
+			read, err := r.Read(chunk)
+			buffered = append(buffered, chunk[:read]...)
+			if err != nil && len(buffered) < n {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var handshake []byte
+	offset := 0
+	for {
+		if err := fill(offset + 1); err != nil {
+			return "", buffered, err
+		}
+		if buffered[offset] != recordTypeHandshake {
+			return "", buffered, ErrNotTLS
+		}
+		if err := fill(offset + recordHeaderLen); err != nil {
+			return "", buffered, err
+		}
+		length := int(binary.BigEndian.Uint16(buffered[offset+3 : offset+5]))
+		if err := fill(offset + recordHeaderLen + length); err != nil {
+			return "", buffered, err
+		}
+		handshake = append(handshake, buffered[offset+recordHeaderLen:offset+recordHeaderLen+length]...)
+		offset += recordHeaderLen + length
+		if len(handshake) < 4 {
+			continue
+		}
+		messageLen := int(handshake[1])<<16 | int(handshake[2])<<8 | int(handshake[3])
+		if len(handshake) < 4+messageLen {
+			continue
+		}
+		hello, err := parseClientHello(handshake[:4+messageLen])
+		if err != nil {
+			return "", buffered, err
+		}
+		return hello.serverName, buffered, nil
+	}
+}
@@ -0,0 +1,299 @@
+package handoff
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	ListenFDsEnv = "PROXY_LISTEN_FDS"
+	ReadyFDEnv   = "PROXY_READY_FD"
+
+	DefaultTimeout = 30 * time.Second
+)
+
+var ErrUpgradeInProgress = errors.New("upgrade already in progress")
+
+type state struct {
+	mu        sync.Mutex
+	loaded    bool
+	inherited map[string]*os.File
+	ready     *os.File
+	active    map[string]syscall.Conn
+	upgrading bool
+}
+
+var global = &state{}
+
+func (s *state) load() {
+	if s.loaded {
+		return
+	}
+	s.loaded = true
+	s.inherited = make(map[string]*os.File)
+	s.active = make(map[string]syscall.Conn)
+	for _, entry := range strings.Split(os.Getenv(ListenFDsEnv), ",") {
+		name, rawFD, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || name == "" {
+			continue
+		}
+		fd, err := strconv.Atoi(rawFD)
+		if err != nil || fd < 3 {
+			continue
+		}
+		s.inherited[name] = os.NewFile(uintptr(fd), name)
+	}
+	s.loadSystemd()
+	if fd, err := strconv.Atoi(os.Getenv(ReadyFDEnv)); err == nil && fd >= 3 {
+		s.ready = os.NewFile(uintptr(fd), "ready")
+	}
+	_ = os.Unsetenv(ListenFDsEnv)
+	_ = os.Unsetenv(ReadyFDEnv)
+}
+
+func (s *state) take(name string, network string, addr string) *os.File {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	if file, ok := s.inherited[name]; ok {
+		delete(s.inherited, name)
+		return file
+	}
+	for key, file := range s.inherited {
+		if boundTo(file, network, addr) {
+			delete(s.inherited, key)
+			return file
+		}
+	}
+	return nil
+}
+
+func (s *state) track(name string, value syscall.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	s.active[name] = value
+}
+
+func Listen(name string, addr string) (net.Listener, error) {
+	return ListenWith(name, addr, nil)
+}
+
+func ListenWith(name string, addr string, lc *net.ListenConfig) (net.Listener, error) {
+	var ln net.Listener
+	var err error
+	if file := global.take(name, "tcp", addr); file != nil {
+		ln, err = net.FileListener(file)
+		_ = file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("inherited listener %s: %w", name, err)
+		}
+	} else {
+		if lc == nil {
+			lc = &net.ListenConfig{}
+		}
+		ln, err = lc.Listen(context.Background(), "tcp", addr)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if value, ok := ln.(syscall.Conn); ok {
+		global.track(name, value)
+	}
+	return ln, nil
+}
+
+func ListenPacket(name string, addr string) (net.PacketConn, error) {
+	var conn net.PacketConn
+	var err error
+	if file := global.take(name, "udp", addr); file != nil {
+		conn, err = net.FilePacketConn(file)
+		_ = file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("inherited socket %s: %w", name, err)
+		}
+	} else {
+		conn, err = net.ListenPacket("udp", addr)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if value, ok := conn.(syscall.Conn); ok {
+		global.track(name, value)
+	}
+	return conn, nil
+}
+
+func Inherited() bool {
+	global.mu.Lock()
+	defer global.mu.Unlock()
+	global.load()
+	return global.ready != nil
+}
+
+func Ready() error {
+	global.mu.Lock()
+	defer global.mu.Unlock()
+	global.load()
+	for name, file := range global.inherited {
+		_ = file.Close()
+		delete(global.inherited, name)
+	}
+	if global.ready == nil {
+		return nil
+	}
+	_, err := global.ready.Write([]byte{1})
+	_ = global.ready.Close()
+	global.ready = nil
+	return err
+}
+
+type UpgradeConfig struct {
+	Path    string
+	Args    []string
+	Env     []string
+	Timeout time.Duration
+}
+
+func Upgrade(cfg UpgradeConfig) (int, error) {
+	global.mu.Lock()
+	global.load()
+	if global.upgrading {
+		global.mu.Unlock()
+		return 0, ErrUpgradeInProgress
+	}
+	global.upgrading = true
+	names := make([]string, 0, len(global.active))
+	for name := range global.active {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	files := make([]*os.File, 0, len(names)+1)
+	entries := make([]string, 0, len(names))
+	var fileErr error
+	for _, name := range names {
+		file, err := dupFile(name, global.active[name])
+		if errors.Is(err, net.ErrClosed) {
+			delete(global.active, name)
+			continue
+		}
+		if err != nil {
+			fileErr = fmt.Errorf("listener %s: %w", name, err)
+			break
+		}
+		entries = append(entries, fmt.Sprintf("%s=%d", name, 3+len(files)))
+		files = append(files, file)
+	}
+	global.mu.Unlock()
+	defer func() {
+		for _, file := range files {
+			_ = file.Close()
+		}
+		global.mu.Lock()
+		global.upgrading = false
+		global.mu.Unlock()
+	}()
+	if fileErr != nil {
+		return 0, fileErr
+	}
+
+	readyRead, readyWrite, err := os.Pipe()
+	if err != nil {
+		return 0, err
+	}
+	defer readyRead.Close()
+	files = append(files, readyWrite)
+
+	path := cfg.Path
+	if path == "" {
+		if path, err = os.Executable(); err != nil {
+			return 0, err
+		}
+	}
+	env := cfg.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	cmd := exec.Command(path, cfg.Args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = files
+	cmd.Env = append(withoutHandoffEnv(env), ListenFDsEnv+"="+strings.Join(entries, ","), fmt.Sprintf("%s=%d", ReadyFDEnv, 3+len(files)-1))
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+	_ = readyWrite.Close()
+	files = files[:len(files)-1]
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ready := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 1)
+		if _, err := readyRead.Read(buf); err != nil {
+			ready <- errors.New("new process exited before becoming ready")
+			return
+		}
+		ready <- nil
+	}()
+	select {
+	case err = <-ready:
+	case <-time.After(timeout):
+		err = fmt.Errorf("new process not ready after %s", timeout)
+	}
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return 0, err
+	}
+	go func() {
+		_ = cmd.Wait()
+	}()
+	return cmd.Process.Pid, nil
+}
+
+func dupFile(name string, conn syscall.Conn) (*os.File, error) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+	dup := -1
+	var dupErr error
+	err = raw.Control(func(fd uintptr) {
+		syscall.ForkLock.RLock()
+		defer syscall.ForkLock.RUnlock()
+		if dup, dupErr = syscall.Dup(int(fd)); dupErr == nil {
+			syscall.CloseOnExec(dup)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if dupErr != nil {
+		return nil, dupErr
+	}
+	return os.NewFile(uintptr(dup), name), nil
+}
+
+func withoutHandoffEnv(env []string) []string {
+	out := make([]string, 0, len(env))
+	for _, entry := range env {
+		if strings.HasPrefix(entry, ListenFDsEnv+"=") || strings.HasPrefix(entry, ReadyFDEnv+"=") || strings.HasPrefix(entry, "WATCHDOG_PID=") {
+			continue
+		}
+		out = append(out, entry)
+	}
+	return out
+}
@@ -0,0 +1,134 @@
+package handoff
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const listenFDsStart = 3
+
+func (s *state) loadSystemd() {
+	defer func() {
+		_ = os.Unsetenv("LISTEN_PID")
+		_ = os.Unsetenv("LISTEN_FDS")
+		_ = os.Unsetenv("LISTEN_FDNAMES")
+	}()
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	for i := 0; i < count; i++ {
+		fd := listenFDsStart + i
+		syscall.CloseOnExec(fd)
+		name := ""
+		if i < len(names) {
+			name = names[i]
+		}
+		if _, taken := s.inherited[name]; name == "" || name == "unknown" || taken {
+			name = fmt.Sprintf("fd%d", fd)
+		}
+		s.inherited[name] = os.NewFile(uintptr(fd), name)
+	}
+}
+
+func boundTo(file *os.File, network string, addr string) bool {
+	want, err := net.ResolveTCPAddr("tcp", addr)
+	if err != nil || want.Port == 0 {
+		return false
+	}
+	raw, err := file.SyscallConn()
+	if err != nil {
+		return false
+	}
+	wantType := syscall.SOCK_STREAM
+	if network == "udp" {
+		wantType = syscall.SOCK_DGRAM
+	}
+	match := false
+	_ = raw.Control(func(fd uintptr) {
+		if sockType, err := syscall.GetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_TYPE); err != nil || sockType != wantType {
+			return
+		}
+		sa, err := syscall.Getsockname(int(fd))
+		if err != nil {
+			return
+		}
+		var ip net.IP
+		var port int
+		switch bound := sa.(type) {
+		case *syscall.SockaddrInet4:
+			ip, port = net.IP(bound.Addr[:]), bound.Port
+		case *syscall.SockaddrInet6:
+			ip, port = net.IP(bound.Addr[:]), bound.Port
+		default:
+			return
+		}
+		if port != want.Port {
+			return
+		}
+		if want.IP == nil || want.IP.IsUnspecified() {
+			match = ip.IsUnspecified()
+			return
+		}
+		match = want.IP.Equal(ip)
+	})
+	return match
+}
+
+func Notify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+	if strings.HasPrefix(socket, "@") {
+		socket = "\x00" + socket[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(state))
+	return err
+}
+
+func WatchdogInterval() time.Duration {
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond
+}
+
+func RunWatchdog(ctx context.Context, onError func(error)) {
+	interval := WatchdogInterval()
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+	for {
+		if err := Notify("WATCHDOG=1"); err != nil && onError != nil {
+			onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -1,16 +1,38 @@
 package health
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"strings"
 	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
 )
 
+const maxProbeBodyBytes = 64 * 1024
+
 func ActiveProbeLoop(cfg Config, addr string, stop <-chan struct{}, onSuccess func(), onFailure func()) {
-	client := &http.Client{Timeout: cfg.Timeout}
-	path := cfg.Path
-	if path == "" {
-		path = "/healthz"
+	target := cfg.ProbeAddr(addr)
+	var probe func() error
+	var closeProbe func()
+	switch cfg.Type {
+	case CheckTCP:
+		probe, closeProbe = func() error { return tcpProbe(target, cfg.Timeout) }, func() {}
+	case CheckGRPC:
+		probe, closeProbe = newGRPCProbe(cfg, target)
+	default:
+		probe, closeProbe = newHTTPProbe(cfg, target)
 	}
+	defer closeProbe()
 
 	ticker := time.NewTicker(cfg.Interval)
 	defer ticker.Stop()
@@ -20,34 +42,126 @@ func ActiveProbeLoop(cfg Config, addr string, stop <-chan struct{}, onSuccess fu
 		case <-stop:
 			return
 		case <-ticker.C:
-			safeProbe(client, addr, path, onSuccess, onFailure)
+			safeProbe(probe, onSuccess, onFailure)
 		}
 	}
 }
 
-func safeProbe(client *http.Client, addr string, path string, onSuccess func(), onFailure func()) {
+func safeProbe(probe func() error, onSuccess func(), onFailure func()) {
 	defer func() {
 		if recover() != nil {
 			onFailure()
 		}
 	}()
 
-	req, err := http.NewRequest(http.MethodGet, "http://"+addr+path, nil)
-	if err != nil {
+	if err := probe(); err != nil {
 		onFailure()
 		return
 	}
+	onSuccess()
+}
+
+func newHTTPProbe(cfg Config, target string) (func() error, func()) {
+	transport := &http.Transport{TLSClientConfig: probeTLSConfig(cfg)}
+	client := &http.Client{Timeout: cfg.Timeout, Transport: transport}
+	scheme := "http"
+	if cfg.TLS {
+		scheme = "https"
+	}
+	method := cfg.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	path := cfg.Path
+	if path == "" {
+		path = "/healthz"
+	}
+	url := scheme + "://" + target + path
+
+	probe := func() error {
+		req, err := http.NewRequest(method, url, nil)
+		if err != nil {
+			return err
+		}
+		for name, value := range cfg.Headers {
+			if strings.EqualFold(name, "Host") {
+				req.Host = value
+				continue
+			}
+			req.Header.Set(name, value)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if !cfg.StatusExpected(resp.StatusCode) {
+			return fmt.Errorf("unexpected status %d", resp.StatusCode)
+		}
+		if cfg.BodyContains == "" {
+			return nil
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxProbeBodyBytes))
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(string(body), cfg.BodyContains) {
+			return errors.New("response body does not contain expected text")
+		}
+		return nil
+	}
+	return probe, transport.CloseIdleConnections
+}
 
-	resp, err := client.Do(req)
+func newGRPCProbe(cfg Config, target string) (func() error, func()) {
+	creds := insecure.NewCredentials()
+	if cfg.TLS {
+		creds = credentials.NewTLS(probeTLSConfig(cfg))
+	}
+	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(creds))
 	if err != nil {
-		onFailure()
-		return
+		return func() error { return err }, func() {}
+	}
+	client := healthpb.NewHealthClient(conn)
+	pairs := make([]string, 0, len(cfg.Headers)*2)
+	for name, value := range cfg.Headers {
+		pairs = append(pairs, strings.ToLower(name), value)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 200 && resp.StatusCode < 400 {
-		onSuccess()
-		return
+	probe := func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+		defer cancel()
+		if len(pairs) > 0 {
+			ctx = metadata.AppendToOutgoingContext(ctx, pairs...)
+		}
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: cfg.GRPCService})
+		if err != nil {
+			return err
+		}
+		if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+			return fmt.Errorf("grpc health status %s", resp.GetStatus())
+		}
+		return nil
+	}
+	return probe, func() { _ = conn.Close() }
+}
+
+func probeTLSConfig(cfg Config) *tls.Config {
+	if !cfg.TLS {
+		return nil
+	}
+	return &tls.Config{
+		ServerName:         cfg.TLSServerName,
+		InsecureSkipVerify: cfg.TLSInsecureSkipVerify,
+	}
+}
+
+func tcpProbe(addr string, timeout time.Duration) error {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return err
 	}
-	onFailure()
+	return conn.Close()
 }
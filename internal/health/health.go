@@ -1,13 +1,114 @@
 package health
 
-import "time"
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	CheckHTTP = "http"
+	CheckTCP  = "tcp"
+	CheckGRPC = "grpc"
+)
 
 type Config struct {
-	Path                   string
-	Interval               time.Duration
-	Timeout                time.Duration
-	UnhealthyAfterFailures int
-	HealthyAfterSuccesses  int
-	BaseEjectDuration      time.Duration
-	MaxEjectDuration       time.Duration
+	Type                         string
+	Path                         string
+	Interval                     time.Duration
+	Timeout                      time.Duration
+	UnhealthyAfterFailures       int
+	HealthyAfterSuccesses        int
+	BaseEjectDuration            time.Duration
+	MaxEjectDuration             time.Duration
+	Method                       string
+	Headers                      map[string]string
+	ExpectedStatuses             []StatusRange
+	BodyContains                 string
+	TLS                          bool
+	TLSServerName                string
+	TLSInsecureSkipVerify        bool
+	Port                         int
+	EndpointPorts                map[string]int
+	GRPCService                  string
+	PassiveFailureStatuses       []StatusRange
+	UnhealthyAfterStatusFailures int
+}
+
+type StatusRange struct {
+	Min int
+	Max int
+}
+
+func ParseStatusRanges(values []string) ([]StatusRange, error) {
+	ranges := make([]StatusRange, 0, len(values))
+	for _, value := range values {
+		value = strings.ToLower(strings.TrimSpace(value))
+		var statusRange StatusRange
+		switch {
+		case len(value) == 3 && strings.HasSuffix(value, "xx"):
+			class, err := strconv.Atoi(value[:1])
+			if err != nil || class < 1 || class > 5 {
+				return nil, fmt.Errorf("status class %q is invalid", value)
+			}
+			statusRange = StatusRange{Min: class * 100, Max: class*100 + 99}
+		case strings.Contains(value, "-"):
+			lo, hi, _ := strings.Cut(value, "-")
+			min, errMin := strconv.Atoi(lo)
+			max, errMax := strconv.Atoi(hi)
+			if errMin != nil || errMax != nil || min > max {
+				return nil, fmt.Errorf("status range %q is invalid", value)
+			}
+			statusRange = StatusRange{Min: min, Max: max}
+		default:
+			code, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("status %q is invalid", value)
+			}
+			statusRange = StatusRange{Min: code, Max: code}
+		}
+		if statusRange.Min < 100 || statusRange.Max > 599 {
+			return nil, fmt.Errorf("status %q is out of range", value)
+		}
+		ranges = append(ranges, statusRange)
+	}
+	return ranges, nil
+}
+
+func (c Config) StatusExpected(code int) bool {
+	if len(c.ExpectedStatuses) == 0 {
+		return code >= 200 && code < 400
+	}
+	for _, statusRange := range c.ExpectedStatuses {
+		if code >= statusRange.Min && code <= statusRange.Max {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Config) PassiveStatusFailure(code int) bool {
+	for _, statusRange := range c.PassiveFailureStatuses {
+		if code >= statusRange.Min && code <= statusRange.Max {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Config) ProbeAddr(addr string) string {
+	port := c.Port
+	if override, ok := c.EndpointPorts[addr]; ok {
+		port = override
+	}
+	if port <= 0 {
+		return addr
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return net.JoinHostPort(host, strconv.Itoa(port))
 }
@@ -0,0 +1,130 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestAccessLogRoutePolicy(t *testing.T) {
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow":
+			time.Sleep(60 * time.Millisecond)
+		case "/fail":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer closeUpstream()
+
+	cfg, err := config.ParseJSON([]byte(fmt.Sprintf(`{
+"routes": [
+  {"id": "quiet", "host": "quiet.local", "path_prefix": "/", "pool": "p", "policy": {"access_log": {"disabled": true}}},
+  {"id": "sampled", "host": "sampled.local", "path_prefix": "/", "pool": "p", "policy": {"access_log": {"sample_rate": 0, "slow_ms": 40}}},
+  {"id": "errors", "host": "errors.local", "path_prefix": "/", "pool": "p", "policy": {"access_log": {"errors_only": true}}},
+  {"id": "projected", "host": "projected.local", "path_prefix": "/", "pool": "p", "policy": {"access_log": {"fields": ["route_id", "status", "path"], "rename": {"status": "code"}}}},
+  {"id": "default", "host": "default.local", "path_prefix": "/", "pool": "p"}
+],
+"pools": {"p": {"endpoints": ["%s"]}}
+}`, upstreamAddr)))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	store := runtime.NewStore(snap)
+	proxyServer := httptest.NewServer(&proxy.Handler{Store: store, Registry: reg, Engine: proxy.NewEngine(reg, nil, nil, nil, nil)})
+	defer proxyServer.Close()
+	client := &http.Client{Timeout: 2 * time.Second}
+
+	logs := testutil.CaptureAccessLogs(t)
+	requests := []struct {
+		host string
+		path string
+	}{
+		{"quiet.local", "/fail"},
+		{"sampled.local", "/"},
+		{"sampled.local", "/slow"},
+		{"errors.local", "/"},
+		{"errors.local", "/fail"},
+		{"projected.local", "/"},
+		{"default.local", "/"},
+	}
+	for _, req := range requests {
+		resp, _ := sendProxyRequest(t, client, proxyServer.URL, req.host, http.MethodGet, req.path)
+		resp.Body.Close()
+	}
+
+	var seen []string
+	var projected map[string]interface{}
+	testutil.Eventually(t, time.Second, 10*time.Millisecond, func() error {
+		seen = seen[:0]
+		for _, entry := range logs.Entries(t) {
+			route, _ := entry["route_id"].(string)
+			path, _ := entry["path"].(string)
+			seen = append(seen, route+path)
+			if route == "projected" {
+				projected = entry
+			}
+		}
+		if len(seen) != 4 {
+			return fmt.Errorf("expected 4 access log lines, got %v", seen)
+		}
+		return nil
+	})
+	if got := strings.Join(seen, ","); got != "sampled/slow,errors/fail,projected/,default/" {
+		t.Fatalf("unexpected logged requests: %s", got)
+	}
+	if len(projected) != 3 || projected["code"] != float64(http.StatusOK) {
+		t.Fatalf("expected projected and renamed fields, got %v", projected)
+	}
+}
+
+func TestAccessLogPolicyValidation(t *testing.T) {
+	cases := map[string]string{
+		`{"fields": ["status", "nope"]}`:                              "field \"nope\" is unknown",
+		`{"rename": {"status": "route_id"}}`:                          "collides with field",
+		`{"rename": {"status": "code", "method": "code"}}`:            "to \"code\"",
+		`{"slow_ms": -1}`:                                             "slow_ms must be >= 0",
+		`{"rename": {"status": "route_id", "route_id": "status"}}`:    "",
+		`{"fields": ["request_id"], "rename": {"request_id": "rid"}}`: "",
+	}
+	for accessLog, want := range cases {
+		cfg, err := config.ParseJSON([]byte(`{"routes": [{"id": "r", "host": "r.local", "path_prefix": "/", "pool": "p", "policy": {"access_log": ` + accessLog + `}}], "pools": {"p": {"endpoints": ["127.0.0.1:9"]}}}`))
+		if err != nil {
+			t.Fatalf("parse %s: %v", accessLog, err)
+		}
+		reg := registry.NewRegistry(0, 0)
+		_, err = runtime.BuildSnapshot(cfg, reg, nil, nil, nil)
+		reg.Close()
+		if want == "" {
+			if err != nil {
+				t.Fatalf("expected %s to be valid, got %v", accessLog, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %s to fail with %q, got %v", accessLog, want, err)
+		}
+	}
+
+	if _, err := config.ParseJSON([]byte(`{"routes": [{"id": "r", "policy": {"access_log": {"sample_rate": 1.5}}}]}`)); err == nil {
+		t.Fatalf("expected sample_rate above 1 to be rejected by schema")
+	}
+}
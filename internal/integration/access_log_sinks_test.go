@@ -0,0 +1,237 @@
+package integration
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+type sinkCollector struct {
+	mu       sync.Mutex
+	requests map[string][]string
+}
+
+func (c *sinkCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests[r.URL.Path] = append(c.requests[r.URL.Path], r.Header.Get("Content-Type")+"|"+r.Header.Get("X-Sink-Token")+"|"+string(body))
+}
+
+func (c *sinkCollector) get(path string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.requests[path]...)
+}
+
+func TestAccessLogSinksGlobalAndPerRoute(t *testing.T) {
+	defer obs.CloseAccessLogSinks()
+	collector := &sinkCollector{requests: make(map[string][]string)}
+	sinkServer := httptest.NewServer(collector)
+	defer sinkServer.Close()
+
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer closeUpstream()
+
+	logPath := filepath.Join(t.TempDir(), "access.log")
+	cfg, err := config.ParseJSON([]byte(fmt.Sprintf(`{
+"logging": {"access_log": {"type": "http", "url": "%[1]s/ingest", "headers": {"X-Sink-Token": "abc"}, "flush_ms": 10}},
+"routes": [
+  {"id": "global", "host": "global.local", "path_prefix": "/", "pool": "p"},
+  {"id": "kafka", "host": "kafka.local", "path_prefix": "/", "pool": "p", "policy": {"access_log": {"sink": {"type": "kafka", "url": "%[1]s/rest", "topic": "access", "flush_ms": 10}}}},
+  {"id": "file", "host": "file.local", "path_prefix": "/", "pool": "p", "policy": {"access_log": {"sink": {"type": "file", "path": "%[2]s", "flush_ms": 10}}}}
+],
+"pools": {"p": {"endpoints": ["%[3]s"]}}
+}`, sinkServer.URL, logPath, upstreamAddr)))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	proxyServer := httptest.NewServer(&proxy.Handler{Store: runtime.NewStore(snap), Registry: reg, Engine: proxy.NewEngine(reg, nil, nil, nil, nil)})
+	defer proxyServer.Close()
+	client := &http.Client{Timeout: 2 * time.Second}
+
+	stdout := testutil.CaptureAccessLogs(t)
+	for _, host := range []string{"global.local", "kafka.local", "file.local", "missing.local"} {
+		resp, _ := sendProxyRequest(t, client, proxyServer.URL, host, http.MethodGet, "/")
+		resp.Body.Close()
+	}
+
+	testutil.Eventually(t, 2*time.Second, 10*time.Millisecond, func() error {
+		ingest := strings.Join(collector.get("/ingest"), "\n")
+		if !strings.Contains(ingest, `"route_id":"global"`) || !strings.Contains(ingest, `"route_id":"none"`) {
+			return fmt.Errorf("expected global and unmatched requests on http sink, got %q", ingest)
+		}
+		if !strings.HasPrefix(ingest, "application/x-ndjson|abc|") {
+			return fmt.Errorf("expected ndjson with configured headers, got %q", ingest)
+		}
+		if len(collector.get("/rest/topics/access")) == 0 {
+			return fmt.Errorf("expected kafka rest batch")
+		}
+		data, _ := os.ReadFile(logPath)
+		if !strings.Contains(string(data), `"route_id":"file"`) {
+			return fmt.Errorf("expected file sink entry, got %q", string(data))
+		}
+		return nil
+	})
+
+	kafka := collector.get("/rest/topics/access")[0]
+	contentType, body, _ := strings.Cut(kafka, "|")
+	_, body, _ = strings.Cut(body, "|")
+	if contentType != "application/vnd.kafka.json.v2+json" {
+		t.Fatalf("unexpected kafka content type %q", contentType)
+	}
+	var payload struct {
+		Records []struct {
+			Value map[string]interface{} `json:"value"`
+		} `json:"records"`
+	}
+	if err := json.Unmarshal([]byte(body), &payload); err != nil || len(payload.Records) != 1 || payload.Records[0].Value["route_id"] != "kafka" {
+		t.Fatalf("unexpected kafka payload %q: %v", body, err)
+	}
+	if entries := stdout.Entries(t); len(entries) != 0 {
+		t.Fatalf("expected no stdout access logs when sinks are configured, got %v", entries)
+	}
+}
+
+func TestAccessLogFileSinkRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	sink, err := obs.AccessLogSinkFor(obs.AccessLogSinkSpec{Type: obs.SinkFile, Path: path, MaxSizeBytes: 100, MaxBackups: 2, BatchSize: 1})
+	if err != nil {
+		t.Fatalf("sink: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		_, _ = fmt.Fprintf(sink, "{\"line\":%d,\"padding\":\"xxxxxxxxxxxxxxxxxxxxxxxx\"}\n", i)
+	}
+	sink.Close()
+
+	for _, name := range []string{"access.log", "access.log.1", "access.log.2"} {
+		info, err := os.Stat(filepath.Join(filepath.Dir(path), name))
+		if err != nil {
+			t.Fatalf("expected %s: %v", name, err)
+		}
+		if info.Size() > 100 {
+			t.Fatalf("expected %s to stay under max size, got %d", name, info.Size())
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Fatalf("expected only two backups, got %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), `"line":9`) {
+		t.Fatalf("expected newest line in active file, got %q", string(data))
+	}
+}
+
+func TestAccessLogSyslogSink(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer conn.Close()
+
+	sink, err := obs.AccessLogSinkFor(obs.AccessLogSinkSpec{Type: obs.SinkSyslog, Network: "udp", Address: conn.LocalAddr().String(), Tag: "edge", BatchSize: 1})
+	if err != nil {
+		t.Fatalf("sink: %v", err)
+	}
+	defer sink.Close()
+	_, _ = sink.Write([]byte(`{"route_id":"syslog"}` + "\n"))
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buf := make([]byte, 2048)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("read syslog: %v", err)
+	}
+	message := string(buf[:n])
+	if !strings.HasPrefix(message, "<134>") || !strings.Contains(message, "edge[") || !strings.Contains(message, `{"route_id":"syslog"}`) {
+		t.Fatalf("unexpected syslog message %q", message)
+	}
+}
+
+func TestAccessLogSinkDropsWhenBehind(t *testing.T) {
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+	obs.SetDefaultMetrics(metrics)
+	defer obs.SetDefaultMetrics(nil)
+
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer slow.Close()
+
+	sink, err := obs.AccessLogSinkFor(obs.AccessLogSinkSpec{Type: obs.SinkHTTP, URL: slow.URL, BufferSize: 1, BatchSize: 1})
+	if err != nil {
+		t.Fatalf("sink: %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		_, _ = sink.Write([]byte("{}\n"))
+	}
+	close(release)
+	sink.Close()
+	if sink.Dropped() < 10 {
+		t.Fatalf("expected most lines dropped while sink was blocked, got %d", sink.Dropped())
+	}
+
+	recorder := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	scanner := bufio.NewScanner(recorder.Body)
+	found := false
+	for scanner.Scan() {
+		if strings.HasPrefix(scanner.Text(), `proxy_access_log_dropped_total{reason="queue_full",sink="http"}`) {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected queue_full drop counter")
+	}
+}
+
+func TestAccessLogSinkValidation(t *testing.T) {
+	cases := map[string]string{
+		`{"type": "file"}`:                                     "requires path",
+		`{"type": "kafka", "url": "http://k:8082"}`:            "requires url and topic",
+		`{"type": "http", "url": "ftp://collector"}`:           "absolute http(s) url",
+		`{"type": "file", "path": "/tmp/x", "batch_size": -1}`: "must be >= 0",
+	}
+	for sink, want := range cases {
+		cfg, err := config.ParseJSON([]byte(`{"logging": {"access_log": ` + sink + `}}`))
+		if err != nil {
+			t.Fatalf("parse %s: %v", sink, err)
+		}
+		reg := registry.NewRegistry(0, 0)
+		_, err = runtime.BuildSnapshot(cfg, reg, nil, nil, nil)
+		reg.Close()
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %s to fail with %q, got %v", sink, want, err)
+		}
+	}
+	if _, err := config.ParseJSON([]byte(`{"logging": {"access_log": {"type": "carrier-pigeon"}}}`)); err == nil {
+		t.Fatalf("expected unknown sink type to be rejected by schema")
+	}
+}
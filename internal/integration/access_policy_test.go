@@ -0,0 +1,114 @@
+package integration
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestAccessPolicyTrustedProxies(t *testing.T) {
+	proxyServer, metrics, closeProxy := startAccessProxy(t, map[string]config.AccessConfig{
+		"internal.local": {
+			AllowCIDRs:     []string{"10.0.0.0/8"},
+			DenyCIDRs:      []string{"10.9.9.9"},
+			TrustedProxies: []string{"127.0.0.1/32", "192.168.0.0/16"},
+		},
+		"direct.local": {
+			AllowCIDRs: []string{"10.0.0.0/8"},
+		},
+		"blocked.local": {
+			DenyCIDRs: []string{"127.0.0.0/8"},
+		},
+	})
+	defer closeProxy()
+	client := &http.Client{Timeout: 2 * time.Second}
+
+	cases := []struct {
+		host      string
+		forwarded string
+		allowed   bool
+	}{
+		{host: "internal.local", forwarded: "10.1.2.3", allowed: true},
+		{host: "internal.local", forwarded: "10.1.2.3, 192.168.1.1", allowed: true},
+		{host: "internal.local", forwarded: "10.1.2.3, 203.0.113.5, 192.168.1.1", allowed: false},
+		{host: "internal.local", forwarded: "10.9.9.9", allowed: false},
+		{host: "internal.local", forwarded: "not-an-ip, 192.168.1.1", allowed: false},
+		{host: "internal.local", forwarded: "", allowed: false},
+		{host: "direct.local", forwarded: "10.1.2.3", allowed: false},
+		{host: "blocked.local", forwarded: "10.1.2.3", allowed: false},
+	}
+	for _, tc := range cases {
+		headers := map[string]string{}
+		if tc.forwarded != "" {
+			headers["X-Forwarded-For"] = tc.forwarded
+		}
+		resp, body := sendProxyRequestWithHeaders(t, client, proxyServer.URL, tc.host, http.MethodGet, "/", headers)
+		if tc.allowed {
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("%s xff=%q expected allowed, got %d %s", tc.host, tc.forwarded, resp.StatusCode, body)
+			}
+			continue
+		}
+		if resp.StatusCode != http.StatusForbidden {
+			t.Fatalf("%s xff=%q expected 403, got %d", tc.host, tc.forwarded, resp.StatusCode)
+		}
+		assertProxyError(t, resp, body, "access_denied")
+	}
+
+	metricsServer := httptest.NewServer(metrics.Handler())
+	defer metricsServer.Close()
+	text := fetchMetrics(t, metricsServer)
+	if value, ok := metricValue(text, "proxy_access_denied_total", map[string]string{"reason": "deny_cidr"}); !ok || value < 1 {
+		t.Fatalf("expected deny_cidr rejections recorded")
+	}
+}
+
+func startAccessProxy(t *testing.T, policies map[string]config.AccessConfig) (*httptest.Server, *obs.Metrics, func()) {
+	t.Helper()
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+
+	reg := registry.NewRegistry(0, 0)
+	trafficReg := traffic.NewRegistry(0, 0)
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+	cfg := &config.Config{
+		Pools: map[string]config.Pool{
+			"p1": {Endpoints: []string{upstreamAddr}},
+		},
+	}
+	for host, access := range policies {
+		cfg.Routes = append(cfg.Routes, config.Route{
+			ID:         host,
+			Host:       host,
+			PathPrefix: "/",
+			Pool:       "p1",
+			Policy:     config.RoutePolicy{Access: access},
+		})
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	server := httptest.NewServer(&proxy.Handler{
+		Store:    runtime.NewStore(snap),
+		Registry: reg,
+		Engine:   proxy.NewEngine(reg, nil, metrics, nil, nil),
+		Metrics:  metrics,
+	})
+	return server, metrics, func() {
+		server.Close()
+		reg.Close()
+		closeUpstream()
+	}
+}
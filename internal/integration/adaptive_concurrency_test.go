@@ -0,0 +1,115 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/concurrency"
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestAdaptiveConcurrencyLimit(t *testing.T) {
+	var latencyMS int64 = 200
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Duration(atomic.LoadInt64(&latencyMS)) * time.Millisecond)
+	}))
+	defer closeUpstream()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+	concurrencyReg := concurrency.NewRegistry(0, 0)
+	concurrencyReg.SetLimitObserver(metrics.SetConcurrencyLimit)
+	defer concurrencyReg.Close()
+
+	cfg := &config.Config{
+		Routes: []config.Route{{ID: "r1", Host: "example.local", PathPrefix: "/", Pool: "p1"}},
+		Pools: map[string]config.Pool{
+			"p1": {
+				Endpoints: []string{upstreamAddr},
+				AdaptiveConcurrency: config.AdaptiveConcurrencyConfig{
+					Enabled:            true,
+					Algorithm:          "aimd",
+					InitialLimit:       4,
+					MaxLimit:           10,
+					WindowMS:           50,
+					BackoffRatio:       0.5,
+					LatencyThresholdMS: 100,
+				},
+			},
+		},
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	proxyServer := httptest.NewServer(&proxy.Handler{Store: runtime.NewStore(snap), Registry: reg, Concurrency: concurrencyReg, Engine: proxy.NewEngine(reg, nil, nil, nil, nil), Metrics: metrics})
+	defer proxyServer.Close()
+	metricsServer := httptest.NewServer(metrics.Handler())
+	defer metricsServer.Close()
+	client := &http.Client{Timeout: 2 * time.Second}
+
+	limitIs := func(check func(float64) bool, want string) func() error {
+		return func() error {
+			text := fetchMetrics(t, metricsServer)
+			if value, ok := metricValue(text, "proxy_adaptive_concurrency_limit", map[string]string{"pool": "p1"}); !ok || !check(value) {
+				return fmt.Errorf("expected concurrency limit %s, got %v", want, value)
+			}
+			return nil
+		}
+	}
+	burst := func(n int) int32 {
+		var limited int32
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				resp, body := sendProxyRequest(t, client, proxyServer.URL, "example.local", http.MethodGet, "/")
+				if resp.StatusCode == http.StatusServiceUnavailable {
+					assertProxyError(t, resp, body, "concurrency_limited")
+					atomic.AddInt32(&limited, 1)
+				}
+			}()
+		}
+		wg.Wait()
+		return limited
+	}
+
+	testutil.Eventually(t, 3*time.Second, 10*time.Millisecond, func() error {
+		burst(4)
+		return limitIs(func(v float64) bool { return v == 1 }, "1")()
+	})
+	if limited := burst(3); limited < 2 {
+		t.Fatalf("expected requests over the limit to be rejected, got %d", limited)
+	}
+	text := fetchMetrics(t, metricsServer)
+	if drops, ok := metricValue(text, "proxy_adaptive_concurrency_drops_total", map[string]string{"pool": "p1"}); !ok || drops < 2 {
+		t.Fatalf("expected concurrency drops to be recorded, got %v", drops)
+	}
+
+	atomic.StoreInt64(&latencyMS, 0)
+	testutil.Eventually(t, 3*time.Second, 60*time.Millisecond, func() error {
+		sendProxyRequest(t, client, proxyServer.URL, "example.local", http.MethodGet, "/")
+		return limitIs(func(v float64) bool { return v >= 2 }, ">= 2")()
+	})
+
+	cfg.Pools["p1"] = config.Pool{Endpoints: []string{upstreamAddr}, AdaptiveConcurrency: config.AdaptiveConcurrencyConfig{Enabled: true, Algorithm: "bogus"}}
+	if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg); err == nil || !strings.Contains(err.Error(), "algorithm") {
+		t.Fatalf("expected invalid algorithm error, got %v", err)
+	}
+}
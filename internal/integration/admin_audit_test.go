@@ -0,0 +1,113 @@
+package integration
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"modern_reverse_proxy/internal/admin"
+	"modern_reverse_proxy/internal/apply"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestAdminAuditLogRecordsCalls(t *testing.T) {
+	addr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer closeUpstream()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+	store := runtime.NewStore(nil)
+	applyManager := apply.NewManager(apply.ManagerConfig{Store: store, Registry: reg, TrafficRegistry: trafficReg})
+
+	auditPath := filepath.Join(t.TempDir(), "audit.log")
+	auditFile, err := os.OpenFile(auditPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatalf("open audit log: %v", err)
+	}
+	defer auditFile.Close()
+
+	ca := testutil.WriteCA(t, "admin-ca")
+	serverCert := testutil.WriteServerCert(t, "admin.local", ca)
+	clientCert := testutil.WriteClientCert(t, "client", ca)
+	auth, err := admin.NewAuthenticator(admin.AuthConfig{Token: "secret", ClientCAFile: ca.CertFile})
+	if err != nil {
+		t.Fatalf("auth config: %v", err)
+	}
+	adminServer := startAdminServer(t, admin.NewHandler(admin.HandlerConfig{
+		Store:        store,
+		ApplyManager: applyManager,
+		Auth:         auth,
+		AdminStore:   admin.NewStore(),
+		Audit:        admin.NewAuditLog(auditFile, 10),
+	}), newAdminTLSConfig(t, serverCert.CertFile, serverCert.KeyFile, ca.CertFile))
+	defer adminServer.Close()
+	client := testutil.NewAdminClient(t, testutil.AdminClientConfig{CAFile: ca.CertFile, ClientCert: &clientCert, Token: "secret", ServerName: "admin.local"})
+	badClient := testutil.NewAdminClient(t, testutil.AdminClientConfig{CAFile: ca.CertFile, ClientCert: &clientCert, Token: "wrong", ServerName: "admin.local"})
+
+	payload := []byte(fmt.Sprintf(`{"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1"}], "pools": {"p1": {"endpoints": ["%s"]}}}`, addr))
+	applyReq := mustAdminRequest(t, http.MethodPost, adminServer.URL+"/admin/config", payload)
+	applyReq.Header.Set(proxy.RequestIDHeader, "audit-apply")
+	if resp, err := client.Do(applyReq); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("apply failed: %v", err)
+	} else {
+		resp.Body.Close()
+	}
+	if resp, err := badClient.Do(mustAdminRequest(t, http.MethodGet, adminServer.URL+"/admin/snapshot", nil)); err != nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected unauthorized call, got %v", err)
+	} else {
+		resp.Body.Close()
+	}
+
+	resp, err := client.Do(mustAdminRequest(t, http.MethodGet, adminServer.URL+"/admin/audit?limit=5", nil))
+	if err != nil {
+		t.Fatalf("audit request: %v", err)
+	}
+	defer resp.Body.Close()
+	var tail struct {
+		Entries []admin.AuditEntry `json:"entries"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tail); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected audit tail, got %d %v", resp.StatusCode, err)
+	}
+	if len(tail.Entries) != 2 {
+		t.Fatalf("expected two prior audit entries, got %+v", tail.Entries)
+	}
+
+	applied := tail.Entries[0]
+	sum := sha256.Sum256(payload)
+	tokenSum := sha256.Sum256([]byte("secret"))
+	if applied.RequestID != "audit-apply" || applied.Path != "/admin/config" || applied.Result != "success" || applied.Status != http.StatusOK {
+		t.Fatalf("unexpected apply audit entry %+v", applied)
+	}
+	if applied.PayloadSHA256 != hex.EncodeToString(sum[:]) || applied.TokenHash != hex.EncodeToString(tokenSum[:8]) || applied.ClientSubject == "" {
+		t.Fatalf("expected identity and payload hash in audit entry, got %+v", applied)
+	}
+	if denied := tail.Entries[1]; denied.Result != "denied" || denied.Status != http.StatusUnauthorized || denied.TokenHash == applied.TokenHash {
+		t.Fatalf("unexpected denied audit entry %+v", denied)
+	}
+
+	logFile, err := os.Open(auditPath)
+	if err != nil {
+		t.Fatalf("open audit log: %v", err)
+	}
+	defer logFile.Close()
+	lines := readLines(t, logFile)
+	if len(lines) != 3 {
+		t.Fatalf("expected three audit log lines, got %d", len(lines))
+	}
+	var last admin.AuditEntry
+	if err := json.Unmarshal([]byte(lines[2]), &last); err != nil || last.Path != "/admin/audit" {
+		t.Fatalf("expected audit tail call to be logged, got %q", lines[2])
+	}
+}
@@ -0,0 +1,137 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/admin"
+	"modern_reverse_proxy/internal/breaker"
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestAdminBreakerListAndReset(t *testing.T) {
+	var upstreamCount atomic.Int32
+	addr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamCount.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer closeUpstream()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	breakerReg := breaker.NewRegistry(0, 0)
+	defer breakerReg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+	obs.SetDefaultMetrics(metrics)
+	defer obs.SetDefaultMetrics(nil)
+
+	cfg := &config.Config{
+		Routes: []config.Route{{ID: "r1", Host: "example.local", PathPrefix: "/", Pool: "p1"}},
+		Pools: map[string]config.Pool{
+			"p1": {
+				Endpoints: []string{addr},
+				Breaker: config.BreakerConfig{
+					Enabled:                     true,
+					FailureRateThresholdPercent: 50,
+					MinimumRequests:             2,
+					EvaluationWindowMS:          5000,
+					OpenMS:                      60000,
+				},
+			},
+		},
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, breakerReg, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	store := runtime.NewStore(snap)
+	proxyServer := httptest.NewServer(&proxy.Handler{Store: store, Registry: reg, BreakerRegistry: breakerReg, Engine: proxy.NewEngine(reg, nil, nil, breakerReg, nil)})
+	defer proxyServer.Close()
+	metricsServer := httptest.NewServer(metrics.Handler())
+	defer metricsServer.Close()
+
+	ca := testutil.WriteCA(t, "admin-ca")
+	serverCert := testutil.WriteServerCert(t, "admin.local", ca)
+	clientCert := testutil.WriteClientCert(t, "client", ca)
+	auth, err := admin.NewAuthenticator(admin.AuthConfig{Token: "secret", ClientCAFile: ca.CertFile})
+	if err != nil {
+		t.Fatalf("auth config: %v", err)
+	}
+	adminServer := startAdminServer(t, admin.NewHandler(admin.HandlerConfig{
+		Store:    store,
+		Auth:     auth,
+		Breakers: breakerReg,
+	}), newAdminTLSConfig(t, serverCert.CertFile, serverCert.KeyFile, ca.CertFile))
+	defer adminServer.Close()
+	adminClient := testutil.NewAdminClient(t, testutil.AdminClientConfig{CAFile: ca.CertFile, ClientCert: &clientCert, Token: "secret", ServerName: "admin.local"})
+	client := &http.Client{Timeout: 2 * time.Second}
+
+	adminCall := func(method string, path string, out interface{}) int {
+		t.Helper()
+		resp, err := adminClient.Do(mustAdminRequest(t, method, adminServer.URL+path, nil))
+		if err != nil {
+			t.Fatalf("admin request %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+		if out != nil {
+			_ = json.NewDecoder(resp.Body).Decode(out)
+		}
+		return resp.StatusCode
+	}
+	type breakerList struct {
+		Breakers []struct {
+			Pool               string  `json:"pool"`
+			State              string  `json:"state"`
+			FailureRatePercent int     `json:"failure_rate_percent"`
+			OpenUntil          *string `json:"open_until"`
+		} `json:"breakers"`
+	}
+
+	for i := 0; i < 2; i++ {
+		sendProxyRequest(t, client, proxyServer.URL, "example.local", http.MethodGet, "/")
+	}
+	resp, body := sendProxyRequest(t, client, proxyServer.URL, "example.local", http.MethodGet, "/")
+	assertProxyError(t, resp, body, "circuit_open")
+
+	var list breakerList
+	if status := adminCall(http.MethodGet, "/admin/breakers", &list); status != http.StatusOK || len(list.Breakers) != 1 {
+		t.Fatalf("unexpected breaker list %d %+v", status, list)
+	}
+	if b := list.Breakers[0]; b.Pool != "r1::p1" || b.State != "open" || b.FailureRatePercent != 100 || b.OpenUntil == nil {
+		t.Fatalf("expected open breaker, got %+v", b)
+	}
+
+	if status := adminCall(http.MethodPost, "/admin/breakers/missing/reset", nil); status != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown breaker, got %d", status)
+	}
+	if status := adminCall(http.MethodPost, "/admin/breakers/r1::p1/reset", nil); status != http.StatusOK {
+		t.Fatalf("expected reset to succeed, got %d", status)
+	}
+	list = breakerList{}
+	adminCall(http.MethodGet, "/admin/breakers", &list)
+	if b := list.Breakers[0]; b.State != "closed" || b.OpenUntil != nil {
+		t.Fatalf("expected closed breaker after reset, got %+v", b)
+	}
+
+	before := upstreamCount.Load()
+	if resp, _ := sendProxyRequest(t, client, proxyServer.URL, "example.local", http.MethodGet, "/"); resp.StatusCode != http.StatusInternalServerError || upstreamCount.Load() != before+1 {
+		t.Fatalf("expected reset breaker to let traffic through, got %d", resp.StatusCode)
+	}
+
+	text := fetchMetrics(t, metricsServer)
+	if value, ok := metricValue(text, "proxy_breaker_manual_resets_total", map[string]string{"pool": "r1::p1"}); !ok || value != 1 {
+		t.Fatalf("expected manual reset metric, got %v", value)
+	}
+}
@@ -0,0 +1,120 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/admin"
+	"modern_reverse_proxy/internal/apply"
+	"modern_reverse_proxy/internal/bundle"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestAdminBundleHistoryAndExport(t *testing.T) {
+	addr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer closeUpstream()
+
+	keyPair := testutil.WriteEd25519KeyPair(t, "bundle")
+	signed := func(host string, label string) bundle.Bundle {
+		raw := []byte(fmt.Sprintf(`{
+"routes": [{"id": "r1", "host": "%s", "path_prefix": "/", "pool": "p1"}],
+"pools": {"p1": {"endpoints": ["%s"]}}
+}`, host, addr))
+		meta := bundle.Meta{
+			Version:   apply.ConfigVersion(raw),
+			CreatedAt: time.Now().UTC().Format(time.RFC3339Nano),
+			Source:    "admin",
+			Label:     label,
+		}
+		item, err := bundle.NewSignedBundle(raw, meta, keyPair.PrivateKey)
+		if err != nil {
+			t.Fatalf("sign bundle: %v", err)
+		}
+		return item
+	}
+	bundleA := signed("a.local", "first")
+	bundleB := signed("b.local", "second")
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+	store := runtime.NewStore(nil)
+	applyManager := apply.NewManager(apply.ManagerConfig{Store: store, Registry: reg, TrafficRegistry: trafficReg})
+
+	ca := testutil.WriteCA(t, "admin-ca")
+	serverCert := testutil.WriteServerCert(t, "admin.local", ca)
+	clientCert := testutil.WriteClientCert(t, "client", ca)
+	auth, err := admin.NewAuthenticator(admin.AuthConfig{Token: "secret", ClientCAFile: ca.CertFile})
+	if err != nil {
+		t.Fatalf("auth config: %v", err)
+	}
+	adminServer := startAdminServer(t, admin.NewHandler(admin.HandlerConfig{
+		Store:        store,
+		ApplyManager: applyManager,
+		Auth:         auth,
+		AdminStore:   admin.NewStore(),
+		PublicKey:    keyPair.PublicKey,
+	}), newAdminTLSConfig(t, serverCert.CertFile, serverCert.KeyFile, ca.CertFile))
+	defer adminServer.Close()
+	client := testutil.NewAdminClient(t, testutil.AdminClientConfig{CAFile: ca.CertFile, ClientCert: &clientCert, Token: "secret", ServerName: "admin.local"})
+
+	for _, item := range []bundle.Bundle{bundleA, bundleB} {
+		if resp, _, err := client.PostJSON(adminServer.URL+"/admin/bundle", mustMarshalJSON(t, item)); err != nil || resp.StatusCode != http.StatusOK {
+			t.Fatalf("apply bundle %s failed: %v", item.Meta.Label, err)
+		}
+	}
+
+	adminGet := func(path string, out interface{}) int {
+		t.Helper()
+		resp, err := client.Do(mustAdminRequest(t, http.MethodGet, adminServer.URL+path, nil))
+		if err != nil {
+			t.Fatalf("admin request %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+		if out != nil {
+			_ = json.NewDecoder(resp.Body).Decode(out)
+		}
+		return resp.StatusCode
+	}
+
+	var list struct {
+		Active  string `json:"active"`
+		Bundles []struct {
+			Version string `json:"version"`
+			Label   string `json:"label"`
+			Source  string `json:"source"`
+			Signed  bool   `json:"signed"`
+			Active  bool   `json:"active"`
+		} `json:"bundles"`
+	}
+	if status := adminGet("/admin/bundles", &list); status != http.StatusOK || len(list.Bundles) != 2 {
+		t.Fatalf("unexpected bundle list %d %+v", status, list)
+	}
+	if list.Active != bundleB.Meta.Version || list.Bundles[0].Version != bundleB.Meta.Version || !list.Bundles[0].Active {
+		t.Fatalf("expected latest bundle to be active and first, got %+v", list)
+	}
+	if second := list.Bundles[1]; second.Version != bundleA.Meta.Version || second.Active || !second.Signed || second.Label != "first" {
+		t.Fatalf("unexpected history entry %+v", second)
+	}
+
+	var exported bundle.Bundle
+	if status := adminGet("/admin/bundles/"+bundleA.Meta.Version, &exported); status != http.StatusOK {
+		t.Fatalf("expected bundle export, got %d", status)
+	}
+	if exported.SignatureB64 != bundleA.SignatureB64 || exported.ConfigBytesB64 != bundleA.ConfigBytesB64 {
+		t.Fatalf("exported bundle does not match applied bundle")
+	}
+	if err := bundle.VerifyBundle(exported, keyPair.PublicKey); err != nil {
+		t.Fatalf("exported bundle failed verification: %v", err)
+	}
+	if status := adminGet("/admin/bundles/unknown", nil); status != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown bundle, got %d", status)
+	}
+}
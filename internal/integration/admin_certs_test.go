@@ -0,0 +1,116 @@
+package integration
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"modern_reverse_proxy/internal/admin"
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+func TestAdminCertInventoryAndExpiryWarning(t *testing.T) {
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+	obs.SetDefaultMetrics(metrics)
+	defer obs.SetDefaultMetrics(nil)
+
+	certA := testutil.WriteSelfSignedCert(t, "a.local")
+	certB := testutil.WriteSelfSignedCert(t, "b.local")
+	cfg := &config.Config{
+		TLS: config.TLSConfig{
+			Enabled: true,
+			Certs: []config.TLSCert{
+				{ServerName: "b.local", CertFile: certB.CertFile, KeyFile: certB.KeyFile},
+				{ServerName: "a.local", CertFile: certA.CertFile, KeyFile: certA.KeyFile},
+			},
+		},
+		Routes: []config.Route{{ID: "r1", Host: "a.local", PathPrefix: "/", Pool: "p1"}},
+		Pools:  map[string]config.Pool{"p1": {Endpoints: []string{"127.0.0.1:1"}}},
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	store := runtime.NewStore(snap)
+
+	ca := testutil.WriteCA(t, "admin-ca")
+	serverCert := testutil.WriteServerCert(t, "admin.local", ca)
+	clientCert := testutil.WriteClientCert(t, "client", ca)
+	auth, err := admin.NewAuthenticator(admin.AuthConfig{Token: "secret", ClientCAFile: ca.CertFile})
+	if err != nil {
+		t.Fatalf("auth config: %v", err)
+	}
+	adminServer := startAdminServer(t, admin.NewHandler(admin.HandlerConfig{Store: store, Auth: auth}), newAdminTLSConfig(t, serverCert.CertFile, serverCert.KeyFile, ca.CertFile))
+	defer adminServer.Close()
+	adminClient := testutil.NewAdminClient(t, testutil.AdminClientConfig{CAFile: ca.CertFile, ClientCert: &clientCert, Token: "secret", ServerName: "admin.local"})
+
+	resp, err := adminClient.Do(mustAdminRequest(t, http.MethodGet, adminServer.URL+"/admin/certs", nil))
+	if err != nil {
+		t.Fatalf("admin certs: %v", err)
+	}
+	defer resp.Body.Close()
+	var inventory struct {
+		ExpiryWarningDays int `json:"expiry_warning_days"`
+		Certificates      []struct {
+			ServerName    string   `json:"server_name"`
+			DNSNames      []string `json:"dns_names"`
+			NotAfter      string   `json:"not_after"`
+			DaysRemaining int      `json:"days_remaining"`
+			ExpiringSoon  bool     `json:"expiring_soon"`
+			CertFile      string   `json:"cert_file"`
+			Fingerprint   string   `json:"fingerprint_sha256"`
+		} `json:"certificates"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&inventory); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected inventory response %d: %v", resp.StatusCode, err)
+	}
+	if inventory.ExpiryWarningDays != 14 || len(inventory.Certificates) != 2 {
+		t.Fatalf("unexpected inventory %+v", inventory)
+	}
+	fingerprint := sha256.Sum256(certA.Cert.Raw)
+	first := inventory.Certificates[0]
+	if first.ServerName != "a.local" || first.CertFile != certA.CertFile || first.Fingerprint != hex.EncodeToString(fingerprint[:]) {
+		t.Fatalf("unexpected certificate entry %+v", first)
+	}
+	if len(first.DNSNames) != 1 || first.DNSNames[0] != "a.local" || first.NotAfter == "" || first.DaysRemaining != 0 || !first.ExpiringSoon {
+		t.Fatalf("expected expiring certificate details, got %+v", first)
+	}
+
+	if expiring := runtime.NewCertWatcher(store, 0, nil).CheckExpiry(); expiring != 2 {
+		t.Fatalf("expected two expiring certificates, got %d", expiring)
+	}
+	metricsServer := httptest.NewServer(metrics.Handler())
+	defer metricsServer.Close()
+	text := fetchMetrics(t, metricsServer)
+	if !strings.Contains(text, "\nproxy_tls_certificates_expiring 2\n") {
+		t.Fatalf("expected expiring gauge of 2")
+	}
+}
+
+func TestTLSExpiryWarningValidation(t *testing.T) {
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	cert := testutil.WriteSelfSignedCert(t, "a.local")
+	cfg := &config.Config{
+		TLS: config.TLSConfig{
+			Enabled:           true,
+			Certs:             []config.TLSCert{{ServerName: "a.local", CertFile: cert.CertFile, KeyFile: cert.KeyFile}},
+			ExpiryWarningDays: -1,
+		},
+		Routes: []config.Route{{ID: "r1", Host: "a.local", PathPrefix: "/", Pool: "p1"}},
+		Pools:  map[string]config.Pool{"p1": {Endpoints: []string{"127.0.0.1:1"}}},
+	}
+	if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil); err == nil {
+		t.Fatalf("expected negative expiry_warning_days to be rejected")
+	}
+}
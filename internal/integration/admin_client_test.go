@@ -0,0 +1,229 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/admin"
+	"modern_reverse_proxy/internal/apply"
+	"modern_reverse_proxy/internal/bundle"
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+	"modern_reverse_proxy/pkg/adminclient"
+)
+
+func TestAdminClientAndOpenAPI(t *testing.T) {
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer closeUpstream()
+
+	configA := fmt.Sprintf(`{
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1"}],
+"pools": {"p1": {"endpoints": ["%s"]}}
+}`, upstreamAddr)
+	configB := fmt.Sprintf(`{
+"routes": [{"id": "r2", "host": "other.local", "path_prefix": "/", "pool": "p1"}],
+"pools": {"p1": {"endpoints": ["%s"]}}
+}`, upstreamAddr)
+
+	keyPair := testutil.WriteEd25519KeyPair(t, "bundle")
+	signed, err := bundle.NewSignedBundle([]byte(configA), bundle.Meta{
+		Version:   apply.ConfigVersion([]byte(configA)),
+		CreatedAt: time.Now().UTC().Format(time.RFC3339Nano),
+		Source:    "admin",
+	}, keyPair.PrivateKey)
+	if err != nil {
+		t.Fatalf("sign bundle: %v", err)
+	}
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	initialCfg, err := config.ParseJSON([]byte(configB))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	initialSnap, err := runtime.BuildSnapshot(initialCfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	store := runtime.NewStore(initialSnap)
+	applyManager := apply.NewManager(apply.ManagerConfig{Store: store, Registry: reg, TrafficRegistry: trafficReg})
+
+	ca := testutil.WriteCA(t, "admin-ca")
+	serverCert := testutil.WriteServerCert(t, "admin.local", ca)
+	clientCert := testutil.WriteClientCert(t, "client", ca)
+	auth, err := admin.NewAuthenticator(admin.AuthConfig{Token: "secret", ClientCAFile: ca.CertFile})
+	if err != nil {
+		t.Fatalf("auth config: %v", err)
+	}
+	adminServer := startAdminServer(t, admin.NewHandler(admin.HandlerConfig{
+		Store:         store,
+		ApplyManager:  applyManager,
+		Auth:          auth,
+		RateLimiter:   admin.NewRateLimiter(admin.RateLimitConfig{RPS: 1000, Burst: 1000}),
+		AdminStore:    admin.NewStore(),
+		PublicKey:     keyPair.PublicKey,
+		AllowUnsigned: true,
+		Registry:      reg,
+	}), newAdminTLSConfig(t, serverCert.CertFile, serverCert.KeyFile, ca.CertFile))
+	defer adminServer.Close()
+
+	adminClient := testutil.NewAdminClient(t, testutil.AdminClientConfig{CAFile: ca.CertFile, ClientCert: &clientCert, Token: "secret", ServerName: "admin.local"})
+	httpClient := adminClient.Client
+	client, err := adminclient.New(adminclient.Config{BaseURL: adminServer.URL, Token: "secret", HTTPClient: httpClient})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	ctx := context.Background()
+
+	if result, err := client.Validate(ctx, []byte(configA)); err != nil || !result.OK {
+		t.Fatalf("expected valid config, got %+v %v", result, err)
+	}
+	var apiErr *adminclient.APIError
+	if _, err := client.Validate(ctx, []byte(`{"routes": [{"id": "r1", "pool": "missing"}]}`)); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || apiErr.Message == "" {
+		t.Fatalf("expected 400 api error for invalid config, got %v", err)
+	}
+
+	bundled, err := client.ApplyBundle(ctx, adminclient.Bundle{
+		Meta: adminclient.BundleMeta{
+			Version:   signed.Meta.Version,
+			CreatedAt: signed.Meta.CreatedAt,
+			Source:    signed.Meta.Source,
+		},
+		ConfigBytesB64: signed.ConfigBytesB64,
+		ConfigSHA256:   signed.ConfigSHA256,
+		SignatureB64:   signed.SignatureB64,
+	})
+	if err != nil || !bundled.Applied || bundled.Version != signed.Meta.Version {
+		t.Fatalf("apply bundle: %+v %v", bundled, err)
+	}
+
+	applied, err := client.Apply(ctx, []byte(configB))
+	if err != nil || !applied.Applied {
+		t.Fatalf("apply config: %+v %v", applied, err)
+	}
+	snapshot, err := client.Snapshot(ctx)
+	if err != nil || snapshot.Version != applied.Version || snapshot.RouteCount != 1 {
+		t.Fatalf("expected snapshot %s, got %+v %v", applied.Version, snapshot, err)
+	}
+
+	rolledBack, err := client.Rollback(ctx, signed.Meta.Version)
+	if err != nil || !rolledBack.RolledBack || rolledBack.Version != signed.Meta.Version {
+		t.Fatalf("rollback: %+v %v", rolledBack, err)
+	}
+	if snapshot, err = client.Snapshot(ctx); err != nil || snapshot.Version != signed.Meta.Version {
+		t.Fatalf("expected rolled back snapshot, got %+v %v", snapshot, err)
+	}
+
+	health, err := client.Health(ctx)
+	if err != nil || len(health.Pools) != 1 || health.Pools[0].Name != "p1" || len(health.Pools[0].Endpoints) != 1 {
+		t.Fatalf("unexpected health: %+v %v", health, err)
+	}
+	if health.Pools[0].Endpoints[0].Addr != upstreamAddr {
+		t.Fatalf("expected endpoint %s, got %+v", upstreamAddr, health.Pools[0].Endpoints[0])
+	}
+
+	unauthenticated, err := adminclient.New(adminclient.Config{BaseURL: adminServer.URL, HTTPClient: httpClient})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	if _, err := unauthenticated.Snapshot(ctx); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without token, got %v", err)
+	}
+
+	resp, err := adminClient.Do(mustAdminRequest(t, http.MethodGet, adminServer.URL+"/admin/openapi.json", nil))
+	if err != nil {
+		t.Fatalf("openapi request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 for openapi, got %d", resp.StatusCode)
+	}
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]map[string]struct {
+			OperationID string `json:"operationId"`
+			Parameters  []struct {
+				Name string `json:"name"`
+				In   string `json:"in"`
+			} `json:"parameters"`
+			RequestBody *struct {
+				Content map[string]struct {
+					Schema map[string]string `json:"schema"`
+				} `json:"content"`
+			} `json:"requestBody"`
+		} `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		t.Fatalf("decode openapi: %v", err)
+	}
+	if doc.OpenAPI != "3.0.3" {
+		t.Fatalf("unexpected openapi version %q", doc.OpenAPI)
+	}
+	bundleOp, ok := doc.Paths["/admin/bundle"]["post"]
+	if !ok || bundleOp.RequestBody == nil || bundleOp.RequestBody.Content["application/json"].Schema["$ref"] != "#/components/schemas/Bundle" {
+		t.Fatalf("expected bundle operation with schema, got %+v", bundleOp)
+	}
+	if _, ok := doc.Components.Schemas["Bundle"]; !ok {
+		t.Fatalf("expected Bundle schema")
+	}
+	drain, ok := doc.Paths["/admin/pools/{name}/endpoints/{addr}/drain"]["post"]
+	if !ok || len(drain.Parameters) != 2 || drain.Parameters[0].Name != "name" || drain.Parameters[1].In != "path" {
+		t.Fatalf("expected drain path parameters, got %+v", drain)
+	}
+	for _, path := range []string{"/admin/config", "/admin/validate", "/admin/rollback", "/admin/snapshot", "/admin/health"} {
+		if len(doc.Paths[path]) == 0 {
+			t.Fatalf("expected %s in openapi document", path)
+		}
+	}
+
+	registered := make(map[string]bool)
+	for _, pattern := range admin.RoutePatterns() {
+		registered[pattern] = true
+		if len(doc.Paths[pattern]) == 0 {
+			t.Fatalf("route %s is registered but missing from openapi document", pattern)
+		}
+	}
+	for path := range doc.Paths {
+		if !registered[path] {
+			t.Fatalf("openapi path %s has no registered route", path)
+		}
+	}
+	pathParam := regexp.MustCompile(`\{[^}]+\}`)
+	for path, ops := range doc.Paths {
+		target := adminServer.URL + pathParam.ReplaceAllString(path, "x")
+		for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete} {
+			_, documented := ops[strings.ToLower(method)]
+			if documented && (method != http.MethodGet || path == "/admin/tap") {
+				continue
+			}
+			resp, err := adminClient.Do(mustAdminRequest(t, method, target, nil))
+			if err != nil {
+				t.Fatalf("%s %s: %v", method, path, err)
+			}
+			resp.Body.Close()
+			if documented && resp.StatusCode == http.StatusMethodNotAllowed {
+				t.Fatalf("%s %s is documented but the handler rejects it", method, path)
+			}
+			if !documented && resp.StatusCode != http.StatusMethodNotAllowed {
+				t.Fatalf("%s %s is not documented but the handler returned %d", method, path, resp.StatusCode)
+			}
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package integration
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"modern_reverse_proxy/internal/admin"
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+func TestAdminDebugEndpoints(t *testing.T) {
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer closeUpstream()
+
+	cfg, err := config.ParseJSON([]byte(`{
+"metrics": {"push": {"type": "otlp", "endpoint": "http://collector:4318", "headers": {"Authorization": "Bearer literal-push-token", "X-Ref": "secret://push_token"}}},
+"routes": [{"id": "keyed", "host": "keyed.local", "path_prefix": "/", "pool": "p", "policy": {"auth": "api_key", "api_key": {"keys": [{"id": "ci", "key": "literal-api-key"}]}}}],
+"pools": {"p": {"endpoints": ["` + upstreamAddr + `"]}}
+}`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+
+	ca := testutil.WriteCA(t, "admin-ca")
+	serverCert := testutil.WriteServerCert(t, "admin.local", ca)
+	clientCert := testutil.WriteClientCert(t, "client", ca)
+	auth, err := admin.NewAuthenticator(admin.AuthConfig{Token: "secret", ClientCAFile: ca.CertFile})
+	if err != nil {
+		t.Fatalf("auth config: %v", err)
+	}
+	adminServer := startAdminServer(t, admin.NewHandler(admin.HandlerConfig{
+		Store:      runtime.NewStore(snap),
+		Auth:       auth,
+		AdminStore: admin.NewStore(),
+		Registry:   reg,
+	}), newAdminTLSConfig(t, serverCert.CertFile, serverCert.KeyFile, ca.CertFile))
+	defer adminServer.Close()
+	adminClient := testutil.NewAdminClient(t, testutil.AdminClientConfig{CAFile: ca.CertFile, ClientCert: &clientCert, Token: "secret", ServerName: "admin.local"})
+
+	get := func(client *testutil.AdminClient, path string) (int, string) {
+		t.Helper()
+		resp, err := client.Do(mustAdminRequest(t, http.MethodGet, adminServer.URL+path, nil))
+		if err != nil {
+			t.Fatalf("admin request %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	status, body := get(adminClient, "/admin/debug/config")
+	if status != http.StatusOK {
+		t.Fatalf("expected config dump, got %d %s", status, body)
+	}
+	if strings.Contains(body, "literal-push-token") || strings.Contains(body, "literal-api-key") {
+		t.Fatalf("expected literal secrets to be redacted, got %s", body)
+	}
+	var dump struct {
+		Version string        `json:"version"`
+		Config  config.Config `json:"config"`
+	}
+	if err := json.Unmarshal([]byte(body), &dump); err != nil {
+		t.Fatalf("decode config dump: %v", err)
+	}
+	if dump.Version != snap.Version || len(dump.Config.Routes) != 1 || dump.Config.Routes[0].Policy.APIKey.Keys[0].Key != "[REDACTED]" {
+		t.Fatalf("unexpected config dump %+v", dump)
+	}
+	if headers := dump.Config.Metrics.Push.Headers; headers["Authorization"] != "[REDACTED]" || headers["X-Ref"] != "secret://push_token" {
+		t.Fatalf("expected literal headers redacted and secret refs kept, got %v", headers)
+	}
+
+	if status, body := get(adminClient, "/admin/debug/stacks"); status != http.StatusOK || !strings.Contains(body, "goroutine ") {
+		t.Fatalf("expected goroutine dump, got %d %s", status, body)
+	}
+	if status, body := get(adminClient, "/admin/debug/pprof/"); status != http.StatusOK || !strings.Contains(body, "heap") {
+		t.Fatalf("expected pprof index, got %d", status)
+	}
+	if status, body := get(adminClient, "/admin/debug/pprof/heap?debug=1"); status != http.StatusOK || !strings.Contains(body, "heap profile") {
+		t.Fatalf("expected heap profile, got %d", status)
+	}
+	if status, _ := get(adminClient, "/admin/debug/pprof/missing"); status != http.StatusNotFound {
+		t.Fatalf("expected unknown profile to 404, got %d", status)
+	}
+
+	anonymous := testutil.NewAdminClient(t, testutil.AdminClientConfig{CAFile: ca.CertFile, ClientCert: &clientCert, Token: "wrong", ServerName: "admin.local"})
+	for _, path := range []string{"/admin/debug/config", "/admin/debug/stacks", "/admin/debug/pprof/"} {
+		if status, _ := get(anonymous, path); status != http.StatusUnauthorized {
+			t.Fatalf("expected %s to require admin auth, got %d", path, status)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package integration
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/admin"
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestAdminEndpointDrainEjectRestore(t *testing.T) {
+	upstream := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Upstream", name)
+			_, _ = io.WriteString(w, name)
+		})
+	}
+	addrA, closeA := testutil.StartUpstream(t, upstream("a"))
+	defer closeA()
+	addrB, closeB := testutil.StartUpstream(t, upstream("b"))
+	defer closeB()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+	cfg := &config.Config{
+		Routes: []config.Route{{ID: "r1", Host: "example.local", PathPrefix: "/", Pool: "p1"}},
+		Pools:  map[string]config.Pool{"p1": {Endpoints: []string{addrA, addrB}, Health: config.HealthConfig{IntervalMS: 60000}}},
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	store := runtime.NewStore(snap)
+	proxyServer := httptest.NewServer(&proxy.Handler{Store: store, Registry: reg, Engine: proxy.NewEngine(reg, nil, nil, nil, nil)})
+	defer proxyServer.Close()
+
+	ca := testutil.WriteCA(t, "admin-ca")
+	serverCert := testutil.WriteServerCert(t, "admin.local", ca)
+	clientCert := testutil.WriteClientCert(t, "client", ca)
+	auth, err := admin.NewAuthenticator(admin.AuthConfig{Token: "secret", ClientCAFile: ca.CertFile})
+	if err != nil {
+		t.Fatalf("auth config: %v", err)
+	}
+	adminServer := startAdminServer(t, admin.NewHandler(admin.HandlerConfig{
+		Store:    store,
+		Auth:     auth,
+		Registry: reg,
+	}), newAdminTLSConfig(t, serverCert.CertFile, serverCert.KeyFile, ca.CertFile))
+	defer adminServer.Close()
+	adminClient := testutil.NewAdminClient(t, testutil.AdminClientConfig{CAFile: ca.CertFile, ClientCert: &clientCert, Token: "secret", ServerName: "admin.local"})
+	client := &http.Client{Timeout: 2 * time.Second}
+
+	adminPost := func(path string) (int, map[string]interface{}) {
+		t.Helper()
+		resp, err := adminClient.Do(mustAdminRequest(t, http.MethodPost, adminServer.URL+path, nil))
+		if err != nil {
+			t.Fatalf("admin request %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+		var payload map[string]interface{}
+		_ = json.NewDecoder(resp.Body).Decode(&payload)
+		return resp.StatusCode, payload
+	}
+	served := func() map[string]int {
+		counts := map[string]int{}
+		for i := 0; i < 6; i++ {
+			resp, _ := sendProxyRequest(t, client, proxyServer.URL, "example.local", http.MethodGet, "/")
+			counts[resp.Header.Get("X-Upstream")]++
+		}
+		return counts
+	}
+
+	status, payload := adminPost("/admin/pools/p1/endpoints/" + addrA + "/eject")
+	endpoint, _ := payload["endpoint"].(map[string]interface{})
+	if status != http.StatusOK || endpoint["override"] != "eject" || endpoint["ejected"] != true {
+		t.Fatalf("expected eject to succeed, got %d %v", status, payload)
+	}
+	if counts := served(); counts["a"] != 0 || counts["b"] != 6 {
+		t.Fatalf("expected ejected endpoint to receive no traffic, got %v", counts)
+	}
+
+	if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg); err != nil {
+		t.Fatalf("rebuild snapshot: %v", err)
+	}
+	if counts := served(); counts["a"] != 0 {
+		t.Fatalf("expected manual eject to survive config apply, got %v", counts)
+	}
+
+	if status, _ := adminPost("/admin/pools/p1/endpoints/" + addrA + "/restore"); status != http.StatusOK {
+		t.Fatalf("expected restore to succeed, got %d", status)
+	}
+	status, payload = adminPost("/admin/pools/p1/endpoints/" + addrB + "/drain")
+	endpoint, _ = payload["endpoint"].(map[string]interface{})
+	if status != http.StatusOK || endpoint["state"] != "draining" || endpoint["override"] != "drain" {
+		t.Fatalf("expected drain to succeed, got %d %v", status, payload)
+	}
+	if counts := served(); counts["a"] != 6 || counts["b"] != 0 {
+		t.Fatalf("expected drained endpoint to receive no traffic, got %v", counts)
+	}
+
+	if status, _ := adminPost("/admin/pools/p1/endpoints/" + addrB + "/restore"); status != http.StatusOK {
+		t.Fatalf("expected restore to succeed, got %d", status)
+	}
+	if counts := served(); counts["a"] != 3 || counts["b"] != 3 {
+		t.Fatalf("expected restored endpoints to share traffic, got %v", counts)
+	}
+
+	if status, _ := adminPost("/admin/pools/p1/endpoints/127.0.0.1:1/drain"); status != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown endpoint, got %d", status)
+	}
+	if status, _ := adminPost("/admin/pools/missing/endpoints/" + addrA + "/drain"); status != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown pool, got %d", status)
+	}
+}
@@ -0,0 +1,121 @@
+package integration
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"modern_reverse_proxy/internal/admin"
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/outlier"
+	"modern_reverse_proxy/internal/pool"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestAdminHealthReportsEndpointState(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	})
+	addrA, closeA := testutil.StartUpstream(t, handler)
+	defer closeA()
+	addrB, closeB := testutil.StartUpstream(t, handler)
+	defer closeB()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	outlierReg := outlier.NewRegistry(0, 0, nil)
+	defer outlierReg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+	cfg := &config.Config{
+		Routes: []config.Route{{ID: "r1", Host: "example.local", PathPrefix: "/", Pool: "p1"}},
+		Pools: map[string]config.Pool{
+			"p1": {
+				Endpoints: []string{addrA, addrB},
+				Health:    config.HealthConfig{IntervalMS: 60000, UnhealthyAfterFailures: 5},
+				Outlier:   config.OutlierConfig{Enabled: true, ConsecutiveFailures: 1, BaseEjectMS: 60000, MaxEjectMS: 60000, MaxEjectPercent: 100},
+			},
+		},
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, outlierReg, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	reg.PassiveFailure(pool.PoolKey("p1"), addrA)
+	reg.PassiveFailure(pool.PoolKey("p1"), addrA)
+	reg.InflightStart(pool.PoolKey("p1"), addrA)
+	defer reg.InflightDone(pool.PoolKey("p1"), addrA)
+	if ejected, _ := outlierReg.RecordResult("r1::p1", addrB, false, 0); !ejected {
+		t.Fatalf("expected outlier ejection for %s", addrB)
+	}
+
+	ca := testutil.WriteCA(t, "admin-ca")
+	serverCert := testutil.WriteServerCert(t, "admin.local", ca)
+	clientCert := testutil.WriteClientCert(t, "client", ca)
+	auth, err := admin.NewAuthenticator(admin.AuthConfig{Token: "secret", ClientCAFile: ca.CertFile})
+	if err != nil {
+		t.Fatalf("auth config: %v", err)
+	}
+	adminServer := startAdminServer(t, admin.NewHandler(admin.HandlerConfig{
+		Store:    runtime.NewStore(snap),
+		Auth:     auth,
+		Registry: reg,
+		Outlier:  outlierReg,
+	}), newAdminTLSConfig(t, serverCert.CertFile, serverCert.KeyFile, ca.CertFile))
+	defer adminServer.Close()
+	adminClient := testutil.NewAdminClient(t, testutil.AdminClientConfig{CAFile: ca.CertFile, ClientCert: &clientCert, Token: "secret", ServerName: "admin.local"})
+
+	resp, err := adminClient.Do(mustAdminRequest(t, http.MethodGet, adminServer.URL+"/admin/health", nil))
+	if err != nil {
+		t.Fatalf("admin health: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	var payload struct {
+		Pools []struct {
+			Name      string `json:"name"`
+			Healthy   int    `json:"healthy"`
+			Total     int    `json:"total"`
+			Endpoints []struct {
+				Addr                       string `json:"addr"`
+				State                      string `json:"state"`
+				Inflight                   int64  `json:"inflight"`
+				ConsecutivePassiveFailures int    `json:"consecutive_passive_failures"`
+				Outlier                    []struct {
+					Scope      string  `json:"scope"`
+					Ejected    bool    `json:"ejected"`
+					EjectUntil *string `json:"eject_until"`
+				} `json:"outlier"`
+			} `json:"endpoints"`
+		} `json:"pools"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		t.Fatalf("decode health: %v", err)
+	}
+	if len(payload.Pools) != 1 || payload.Pools[0].Name != "p1" || payload.Pools[0].Total != 2 || payload.Pools[0].Healthy != 2 {
+		t.Fatalf("unexpected pool health %+v", payload.Pools)
+	}
+	for _, ep := range payload.Pools[0].Endpoints {
+		if ep.State != "healthy" || len(ep.Outlier) != 1 || ep.Outlier[0].Scope != "r1::p1" {
+			t.Fatalf("unexpected endpoint health %+v", ep)
+		}
+		switch ep.Addr {
+		case addrA:
+			if ep.ConsecutivePassiveFailures != 2 || ep.Inflight != 1 || ep.Outlier[0].Ejected {
+				t.Fatalf("expected passive failures and inflight on %s, got %+v", addrA, ep)
+			}
+		case addrB:
+			if !ep.Outlier[0].Ejected || ep.Outlier[0].EjectUntil == nil {
+				t.Fatalf("expected outlier ejection on %s, got %+v", addrB, ep)
+			}
+		default:
+			t.Fatalf("unexpected endpoint %s", ep.Addr)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package integration
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"modern_reverse_proxy/internal/admin"
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/pool"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestAdminInspectRoutesAndPools(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	})
+	stableAddr, closeStable := testutil.StartUpstream(t, handler)
+	defer closeStable()
+	canaryAddr, closeCanary := testutil.StartUpstream(t, handler)
+	defer closeCanary()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+	health := config.HealthConfig{IntervalMS: 60000, UnhealthyAfterFailures: 1, BaseEjectMS: 60000}
+	cfg := &config.Config{
+		Routes: []config.Route{{
+			ID:         "r1",
+			Host:       "example.local",
+			PathPrefix: "/",
+			Pool:       "stable",
+			Policy: config.RoutePolicy{
+				RequestTimeoutMS: 1500,
+				Retry:            config.RetryConfig{Enabled: true, MaxAttempts: 3, RetryOnStatus: []int{503, 502}},
+				Traffic:          config.TrafficConfig{Enabled: true, StablePool: "stable", CanaryPool: "canary", StableWeight: 90, CanaryWeight: 10},
+			},
+		}},
+		Pools: map[string]config.Pool{
+			"stable": {Endpoints: []string{stableAddr}, Health: health},
+			"canary": {Endpoints: []string{canaryAddr}, Health: health},
+		},
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	store := runtime.NewStore(snap)
+	reg.PassiveFailure(pool.PoolKey("canary"), canaryAddr)
+
+	ca := testutil.WriteCA(t, "admin-ca")
+	serverCert := testutil.WriteServerCert(t, "admin.local", ca)
+	clientCert := testutil.WriteClientCert(t, "client", ca)
+	auth, err := admin.NewAuthenticator(admin.AuthConfig{Token: "secret", ClientCAFile: ca.CertFile})
+	if err != nil {
+		t.Fatalf("auth config: %v", err)
+	}
+	adminServer := startAdminServer(t, admin.NewHandler(admin.HandlerConfig{
+		Store:      store,
+		Auth:       auth,
+		AdminStore: admin.NewStore(),
+		Registry:   reg,
+	}), newAdminTLSConfig(t, serverCert.CertFile, serverCert.KeyFile, ca.CertFile))
+	defer adminServer.Close()
+	adminClient := testutil.NewAdminClient(t, testutil.AdminClientConfig{CAFile: ca.CertFile, ClientCert: &clientCert, Token: "secret", ServerName: "admin.local"})
+
+	adminGet := func(path string, out interface{}) int {
+		t.Helper()
+		resp, err := adminClient.Do(mustAdminRequest(t, http.MethodGet, adminServer.URL+path, nil))
+		if err != nil {
+			t.Fatalf("admin request %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+		if out != nil {
+			_ = json.NewDecoder(resp.Body).Decode(out)
+		}
+		return resp.StatusCode
+	}
+
+	var route struct {
+		Pool       string `json:"pool"`
+		CanaryPool string `json:"canary_pool"`
+		Timeouts   struct {
+			RequestMS int64 `json:"request_ms"`
+		} `json:"timeouts"`
+		Retry struct {
+			Enabled       bool  `json:"enabled"`
+			MaxAttempts   int   `json:"max_attempts"`
+			RetryOnStatus []int `json:"retry_on_status"`
+		} `json:"retry"`
+		Traffic struct {
+			StableWeight int `json:"stable_weight"`
+			CanaryWeight int `json:"canary_weight"`
+		} `json:"traffic"`
+	}
+	if status := adminGet("/admin/routes/r1", &route); status != http.StatusOK {
+		t.Fatalf("expected route detail, got %d", status)
+	}
+	if route.Pool != "stable" || route.CanaryPool != "canary" || route.Timeouts.RequestMS != 1500 {
+		t.Fatalf("unexpected route view %+v", route)
+	}
+	if !route.Retry.Enabled || route.Retry.MaxAttempts != 3 || len(route.Retry.RetryOnStatus) != 2 || route.Retry.RetryOnStatus[0] != 502 {
+		t.Fatalf("unexpected retry view %+v", route.Retry)
+	}
+	if route.Traffic.StableWeight != 90 || route.Traffic.CanaryWeight != 10 {
+		t.Fatalf("unexpected traffic weights %+v", route.Traffic)
+	}
+	if status := adminGet("/admin/routes/missing", nil); status != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown route, got %d", status)
+	}
+
+	var routes struct {
+		Routes []map[string]interface{} `json:"routes"`
+	}
+	if status := adminGet("/admin/routes", &routes); status != http.StatusOK || len(routes.Routes) != 1 || routes.Routes[0]["id"] != "r1" {
+		t.Fatalf("unexpected route list %d %v", status, routes.Routes)
+	}
+
+	type endpoint struct {
+		Addr       string  `json:"addr"`
+		State      string  `json:"state"`
+		Ejected    bool    `json:"ejected"`
+		EjectUntil *string `json:"eject_until"`
+	}
+	var pools struct {
+		Pools []struct {
+			Name      string     `json:"name"`
+			Endpoints []endpoint `json:"endpoints"`
+		} `json:"pools"`
+	}
+	if status := adminGet("/admin/pools", &pools); status != http.StatusOK || len(pools.Pools) != 2 || pools.Pools[0].Name != "canary" {
+		t.Fatalf("unexpected pool list %d %+v", status, pools.Pools)
+	}
+	var canary struct {
+		Endpoints []endpoint `json:"endpoints"`
+	}
+	if status := adminGet("/admin/pools/canary", &canary); status != http.StatusOK || len(canary.Endpoints) != 1 {
+		t.Fatalf("unexpected canary pool %d %+v", status, canary)
+	}
+	if ep := canary.Endpoints[0]; ep.Addr != canaryAddr || ep.State != "unhealthy" || !ep.Ejected || ep.EjectUntil == nil {
+		t.Fatalf("expected canary endpoint to be ejected, got %+v", ep)
+	}
+	if ep := pools.Pools[1].Endpoints[0]; ep.State != "healthy" || ep.Ejected {
+		t.Fatalf("expected stable endpoint to be healthy, got %+v", ep)
+	}
+	if status := adminGet("/admin/pools/missing", nil); status != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown pool, got %d", status)
+	}
+}
@@ -0,0 +1,129 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"modern_reverse_proxy/internal/admin"
+	"modern_reverse_proxy/internal/logging"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) Lines() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return strings.Split(strings.TrimSpace(b.buf.String()), "\n")
+}
+
+func TestAdminLoggingLevels(t *testing.T) {
+	previous := slog.Default()
+	output := &lockedBuffer{}
+	logging.Configure(output, true)
+	t.Cleanup(func() {
+		logging.SetLevel(slog.LevelInfo)
+		logging.ResetComponentLevel("pull")
+		logging.ResetComponentLevel("registry")
+		logging.Configure(os.Stderr, false)
+		slog.SetDefault(previous)
+	})
+
+	ca := testutil.WriteCA(t, "admin-ca")
+	serverCert := testutil.WriteServerCert(t, "admin.local", ca)
+	clientCert := testutil.WriteClientCert(t, "client", ca)
+	auth, err := admin.NewAuthenticator(admin.AuthConfig{Token: "secret", ClientCAFile: ca.CertFile})
+	if err != nil {
+		t.Fatalf("auth config: %v", err)
+	}
+	adminServer := startAdminServer(t, admin.NewHandler(admin.HandlerConfig{Auth: auth}), newAdminTLSConfig(t, serverCert.CertFile, serverCert.KeyFile, ca.CertFile))
+	defer adminServer.Close()
+	adminClient := testutil.NewAdminClient(t, testutil.AdminClientConfig{CAFile: ca.CertFile, ClientCert: &clientCert, Token: "secret", ServerName: "admin.local"})
+
+	adminCall := func(method string, body string, out *logging.Levels) int {
+		t.Helper()
+		resp, err := adminClient.Do(mustAdminRequest(t, method, adminServer.URL+"/admin/logging", []byte(body)))
+		if err != nil {
+			t.Fatalf("admin request: %v", err)
+		}
+		defer resp.Body.Close()
+		if out != nil {
+			_ = json.NewDecoder(resp.Body).Decode(out)
+		}
+		return resp.StatusCode
+	}
+
+	var levels logging.Levels
+	if status := adminCall(http.MethodGet, "", &levels); status != http.StatusOK || levels.Level != "info" || len(levels.Components) != 0 {
+		t.Fatalf("unexpected initial levels %d %+v", status, levels)
+	}
+
+	pullLogger := logging.For("pull")
+	registryLogger := logging.For("registry")
+	pullLogger.Debug("hidden_debug")
+
+	levels = logging.Levels{}
+	if status := adminCall(http.MethodPut, `{"level": "warn", "components": {"pull": "debug"}}`, &levels); status != http.StatusOK {
+		t.Fatalf("expected level update to succeed, got %d", status)
+	}
+	if levels.Level != "warn" || levels.Components["pull"] != "debug" {
+		t.Fatalf("unexpected levels after update %+v", levels)
+	}
+	pullLogger.Debug("pull_debug", "attempt", 1)
+	registryLogger.Info("registry_info")
+	registryLogger.Warn("registry_warn")
+
+	for _, body := range []string{`{"level": "loud"}`, `{"components": {"pull": "verbose"}}`, `{"components": {"": "info"}}`, `not json`} {
+		if status := adminCall(http.MethodPut, body, nil); status != http.StatusBadRequest {
+			t.Fatalf("expected %s to be rejected, got %d", body, status)
+		}
+	}
+
+	levels = logging.Levels{}
+	if status := adminCall(http.MethodPut, `{"components": {"pull": ""}}`, &levels); status != http.StatusOK || len(levels.Components) != 0 || levels.Level != "warn" {
+		t.Fatalf("expected component override reset, got %d %+v", status, levels)
+	}
+	pullLogger.Debug("pull_debug_after_reset")
+
+	var messages []string
+	for _, line := range output.Lines() {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("parse log line %q: %v", line, err)
+		}
+		if entry["ts"] == nil {
+			t.Fatalf("expected ts field in %q", line)
+		}
+		switch entry["msg"] {
+		case "pull_debug":
+			if entry["component"] != "pull" || entry["level"] != "DEBUG" || entry["attempt"] != float64(1) {
+				t.Fatalf("unexpected pull log entry %v", entry)
+			}
+		case "registry_warn":
+			if entry["component"] != "registry" {
+				t.Fatalf("unexpected registry log entry %v", entry)
+			}
+		}
+		if component, _ := entry["component"].(string); component != "admin" {
+			messages = append(messages, entry["msg"].(string))
+		}
+	}
+	if strings.Join(messages, ",") != "pull_debug,registry_warn" {
+		t.Fatalf("unexpected logged messages %v", messages)
+	}
+}
@@ -0,0 +1,102 @@
+package integration
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/admin"
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestAdminRouteMaintenance(t *testing.T) {
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer closeUpstream()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+	cfg := &config.Config{
+		Routes: []config.Route{
+			{ID: "r1", Host: "one.local", PathPrefix: "/", Pool: "p1"},
+			{ID: "r2", Host: "two.local", PathPrefix: "/", Pool: "p1"},
+		},
+		Pools: map[string]config.Pool{"p1": {Endpoints: []string{upstreamAddr}}},
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	store := runtime.NewStore(snap)
+	adminStore := admin.NewStore()
+	proxyServer := httptest.NewServer(&proxy.Handler{Store: store, Registry: reg, Engine: proxy.NewEngine(reg, nil, nil, nil, nil), Maintenance: adminStore})
+	defer proxyServer.Close()
+
+	ca := testutil.WriteCA(t, "admin-ca")
+	serverCert := testutil.WriteServerCert(t, "admin.local", ca)
+	clientCert := testutil.WriteClientCert(t, "client", ca)
+	auth, err := admin.NewAuthenticator(admin.AuthConfig{Token: "secret", ClientCAFile: ca.CertFile})
+	if err != nil {
+		t.Fatalf("auth config: %v", err)
+	}
+	adminServer := startAdminServer(t, admin.NewHandler(admin.HandlerConfig{
+		Store:      store,
+		Auth:       auth,
+		AdminStore: adminStore,
+	}), newAdminTLSConfig(t, serverCert.CertFile, serverCert.KeyFile, ca.CertFile))
+	defer adminServer.Close()
+	adminClient := testutil.NewAdminClient(t, testutil.AdminClientConfig{CAFile: ca.CertFile, ClientCert: &clientCert, Token: "secret", ServerName: "admin.local"})
+	client := &http.Client{Timeout: 2 * time.Second}
+
+	adminCall := func(method string, path string, body string) (int, map[string]interface{}) {
+		t.Helper()
+		resp, err := adminClient.Do(mustAdminRequest(t, method, adminServer.URL+path, []byte(body)))
+		if err != nil {
+			t.Fatalf("admin request %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+		var payload map[string]interface{}
+		_ = json.NewDecoder(resp.Body).Decode(&payload)
+		return resp.StatusCode, payload
+	}
+
+	if status, _ := adminCall(http.MethodPost, "/admin/routes/missing/disable", ""); status != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown route, got %d", status)
+	}
+	if status, _ := adminCall(http.MethodPost, "/admin/routes/r1/disable", `{"message":"back soon","retry_after_seconds":120}`); status != http.StatusOK {
+		t.Fatalf("expected disable to succeed, got %d", status)
+	}
+
+	resp, body := sendProxyRequest(t, client, proxyServer.URL, "one.local", http.MethodGet, "/")
+	if resp.StatusCode != http.StatusServiceUnavailable || resp.Header.Get("Retry-After") != "120" {
+		t.Fatalf("expected 503 maintenance with Retry-After, got %d %q", resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+	assertProxyError(t, resp, body, "maintenance")
+	if resp, _ := sendProxyRequest(t, client, proxyServer.URL, "two.local", http.MethodGet, "/"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected other routes to keep serving, got %d", resp.StatusCode)
+	}
+
+	status, snapshot := adminCall(http.MethodGet, "/admin/snapshot", "")
+	disabled, _ := snapshot["disabled_routes"].(map[string]interface{})
+	if status != http.StatusOK || disabled["r1"] == nil || len(disabled) != 1 {
+		t.Fatalf("expected snapshot to list disabled route, got %v", snapshot["disabled_routes"])
+	}
+
+	if status, _ := adminCall(http.MethodPost, "/admin/routes/r1/enable", ""); status != http.StatusOK {
+		t.Fatalf("expected enable to succeed, got %d", status)
+	}
+	if resp, _ := sendProxyRequest(t, client, proxyServer.URL, "one.local", http.MethodGet, "/"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected route to serve after enable, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,142 @@
+package integration
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"modern_reverse_proxy/internal/admin"
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+func TestAdminTestRoute(t *testing.T) {
+	var upstreamCalls atomic.Int32
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamCalls.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Upstream-Path", r.URL.Path)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, r.Method+" "+r.Header.Get("X-Tenant")+" "+string(body))
+	}))
+	defer closeUpstream()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	cfg, err := config.ParseJSON([]byte(`{
+"routes": [
+  {"id": "api", "host": "api.local", "path_prefix": "/v1", "pool": "p1", "methods": ["GET", "POST"], "policy": {"retry": {"enabled": true, "max_attempts": 3}}},
+  {"id": "canary", "host": "canary.local", "path_prefix": "/", "pool": "p1", "policy": {"traffic": {"enabled": true, "stable_pool": "p1", "canary_pool": "p2", "stable_weight": 0, "canary_weight": 100}}}
+],
+"pools": {
+  "p1": {"endpoints": ["` + upstreamAddr + `"]},
+  "p2": {"endpoints": ["127.0.0.1:1"]}
+}
+}`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	store := runtime.NewStore(snap)
+	proxyHandler := &proxy.Handler{
+		Store:    store,
+		Registry: reg,
+		Engine:   proxy.NewEngine(reg, nil, nil, nil, nil),
+	}
+
+	ca := testutil.WriteCA(t, "admin-ca")
+	serverCert := testutil.WriteServerCert(t, "admin.local", ca)
+	clientCert := testutil.WriteClientCert(t, "client", ca)
+	auth, err := admin.NewAuthenticator(admin.AuthConfig{Token: "secret", ClientCAFile: ca.CertFile})
+	if err != nil {
+		t.Fatalf("auth config: %v", err)
+	}
+	adminServer := startAdminServer(t, admin.NewHandler(admin.HandlerConfig{Store: store, Auth: auth, Registry: reg, Proxy: proxyHandler}), newAdminTLSConfig(t, serverCert.CertFile, serverCert.KeyFile, ca.CertFile))
+	defer adminServer.Close()
+	adminClient := testutil.NewAdminClient(t, testutil.AdminClientConfig{CAFile: ca.CertFile, ClientCert: &clientCert, Token: "secret", ServerName: "admin.local"})
+
+	type testRouteResult struct {
+		Matched bool `json:"matched"`
+		Route   *struct {
+			ID    string `json:"id"`
+			Retry struct {
+				Enabled     bool `json:"enabled"`
+				MaxAttempts int  `json:"max_attempts"`
+			} `json:"retry"`
+		} `json:"route"`
+		Pool     string `json:"pool"`
+		Variant  string `json:"variant"`
+		Endpoint *struct {
+			Addr string `json:"addr"`
+		} `json:"endpoint"`
+		Pools []struct {
+			Name      string `json:"name"`
+			Endpoints []struct {
+				Addr string `json:"addr"`
+			} `json:"endpoints"`
+		} `json:"pools"`
+		Response *struct {
+			Status  int                 `json:"status"`
+			Headers map[string][]string `json:"headers"`
+			Body    string              `json:"body"`
+		} `json:"response"`
+	}
+	testRoute := func(body string) (int, testRouteResult) {
+		t.Helper()
+		resp, err := adminClient.Do(mustAdminRequest(t, http.MethodPost, adminServer.URL+"/admin/test-route", []byte(body)))
+		if err != nil {
+			t.Fatalf("admin request: %v", err)
+		}
+		defer resp.Body.Close()
+		var result testRouteResult
+		_ = json.NewDecoder(resp.Body).Decode(&result)
+		return resp.StatusCode, result
+	}
+
+	status, result := testRoute(`{"method": "GET", "host": "api.local", "path": "/v1/orders"}`)
+	if status != http.StatusOK || !result.Matched || result.Route == nil || result.Route.ID != "api" {
+		t.Fatalf("expected api route match, got %d %+v", status, result)
+	}
+	if !result.Route.Retry.Enabled || result.Route.Retry.MaxAttempts != 3 || result.Pool != "p1" || result.Variant != "stable" {
+		t.Fatalf("unexpected effective policy %+v", result)
+	}
+	if result.Endpoint == nil || result.Endpoint.Addr != upstreamAddr || len(result.Pools) != 1 || len(result.Pools[0].Endpoints) != 1 {
+		t.Fatalf("unexpected endpoint selection %+v", result)
+	}
+	if result.Response != nil || upstreamCalls.Load() != 0 {
+		t.Fatalf("expected dry run to skip the upstream call")
+	}
+
+	status, result = testRoute(`{"method": "POST", "host": "api.local", "path": "/v1/orders", "headers": {"X-Tenant": "acme"}, "body": "hello", "execute": true}`)
+	if status != http.StatusOK || result.Response == nil || upstreamCalls.Load() != 1 {
+		t.Fatalf("expected upstream call, got %d %+v", status, result)
+	}
+	if result.Response.Status != http.StatusCreated || result.Response.Body != "POST acme hello" || result.Response.Headers["X-Upstream-Path"][0] != "/v1/orders" {
+		t.Fatalf("unexpected upstream response %+v", result.Response)
+	}
+
+	status, result = testRoute(`{"host": "canary.local", "path": "/"}`)
+	if status != http.StatusOK || result.Variant != "canary" || result.Pool != "p2" || result.Endpoint == nil || result.Endpoint.Addr != "127.0.0.1:1" {
+		t.Fatalf("expected canary pool selection, got %d %+v", status, result)
+	}
+
+	for _, body := range []string{`{"host": "api.local", "path": "/v1", "method": "DELETE"}`, `{"host": "other.local", "path": "/"}`} {
+		status, result = testRoute(body)
+		if status != http.StatusOK || result.Matched || result.Route != nil {
+			t.Fatalf("expected no match for %s, got %d %+v", body, status, result)
+		}
+	}
+	for _, body := range []string{`not json`, `{"path": "/"}`, `{"host": "api.local", "path": "v1"}`} {
+		if status, _ := testRoute(body); status != http.StatusBadRequest {
+			t.Fatalf("expected %s to be rejected, got %d", body, status)
+		}
+	}
+}
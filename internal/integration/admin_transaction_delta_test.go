@@ -0,0 +1,152 @@
+package integration
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/admin"
+	"modern_reverse_proxy/internal/apply"
+	"modern_reverse_proxy/internal/pool"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestAdminTransactionReusesUnchangedRoutesAndPools(t *testing.T) {
+	addrA, closeA := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "a")
+	}))
+	defer closeA()
+	addrB, closeB := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "b")
+	}))
+	defer closeB()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+	store := runtime.NewStore(nil)
+	applyManager := apply.NewManager(apply.ManagerConfig{Store: store, Registry: reg, TrafficRegistry: trafficReg})
+
+	proxyServer := httptest.NewServer(&proxy.Handler{Store: store, Registry: reg, Engine: proxy.NewEngine(reg, nil, nil, nil, nil)})
+	defer proxyServer.Close()
+
+	ca := testutil.WriteCA(t, "admin-ca")
+	serverCert := testutil.WriteServerCert(t, "admin.local", ca)
+	clientCert := testutil.WriteClientCert(t, "client", ca)
+	auth, err := admin.NewAuthenticator(admin.AuthConfig{Token: "secret", ClientCAFile: ca.CertFile})
+	if err != nil {
+		t.Fatalf("auth config: %v", err)
+	}
+	adminServer := startAdminServer(t, admin.NewHandler(admin.HandlerConfig{
+		Store:        store,
+		ApplyManager: applyManager,
+		Auth:         auth,
+	}), newAdminTLSConfig(t, serverCert.CertFile, serverCert.KeyFile, ca.CertFile))
+	defer adminServer.Close()
+	client := testutil.NewAdminClient(t, testutil.AdminClientConfig{CAFile: ca.CertFile, ClientCert: &clientCert, Token: "secret", ServerName: "admin.local"})
+	clientHTTP := &http.Client{Timeout: 5 * time.Second}
+
+	initial := fmt.Sprintf(`{
+"routes": [
+  {"id": "a", "host": "a.local", "path_prefix": "/", "pool": "pa", "policy": {"retry": {"enabled": true, "max_attempts": 2}}},
+  {"id": "b", "host": "b.local", "path_prefix": "/", "pool": "pb"}
+],
+"pools": {"pa": {"endpoints": ["%s"]}, "pb": {"endpoints": ["%s"]}}
+}`, addrA, addrB)
+	if status, body := postAdmin(t, client, adminServer.URL+"/admin/config", initial); status != http.StatusOK {
+		t.Fatalf("expected initial apply 200, got %d: %v", status, body)
+	}
+	if reuse := store.Get().Reuse; reuse.RoutesCompiled != 2 || reuse.PoolsReconciled != 2 || reuse.RoutesReused != 0 {
+		t.Fatalf("expected full build on initial apply, got %+v", reuse)
+	}
+
+	expectReuse := func(body map[string]interface{}, routesReused, routesCompiled, poolsReused, poolsReconciled int) {
+		t.Helper()
+		reuse, _ := body["reuse"].(map[string]interface{})
+		got := [4]int{}
+		for i, key := range []string{"routes_reused", "routes_compiled", "pools_reused", "pools_reconciled"} {
+			value, _ := reuse[key].(float64)
+			got[i] = int(value)
+		}
+		if want := [4]int{routesReused, routesCompiled, poolsReused, poolsReconciled}; got != want {
+			t.Fatalf("expected reuse %v, got %v", want, got)
+		}
+	}
+
+	addRoute := `{"operations": [{"op": "upsert_route", "route": {"id": "c", "host": "c.local", "path_prefix": "/", "pool": "pb"}}]}`
+	status, body := postAdmin(t, client, adminServer.URL+"/admin/transaction", addRoute)
+	if status != http.StatusOK {
+		t.Fatalf("expected transaction 200, got %d: %v", status, body)
+	}
+	expectReuse(body, 2, 1, 2, 0)
+	route, ok := store.Get().Router.Route("a")
+	if !ok || !route.Policy.Retry.Enabled || route.Policy.Retry.MaxAttempts != 2 {
+		t.Fatalf("expected reused route policy to be preserved, got %+v", route.Policy.Retry)
+	}
+
+	growPool := fmt.Sprintf(`{"operations": [{"op": "upsert_pool", "name": "pa", "pool": {"endpoints": ["%s", "%s"]}}]}`, addrA, addrB)
+	status, body = postAdmin(t, client, adminServer.URL+"/admin/transaction", growPool)
+	if status != http.StatusOK {
+		t.Fatalf("expected transaction 200, got %d: %v", status, body)
+	}
+	expectReuse(body, 3, 0, 1, 1)
+	if endpoints, _ := reg.Endpoints(pool.PoolKey("pa")); len(endpoints) != 2 {
+		t.Fatalf("expected changed pool to be reconciled, got %v", endpoints)
+	}
+
+	for host, want := range map[string]string{"b.local": "b", "c.local": "b"} {
+		resp, proxyBody := sendProxyRequest(t, clientHTTP, proxyServer.URL, host, http.MethodGet, "/")
+		if resp.StatusCode != http.StatusOK || string(proxyBody) != want {
+			t.Fatalf("expected %s to reach %q, got %d %q", host, want, resp.StatusCode, string(proxyBody))
+		}
+	}
+
+	if status, _ := postAdmin(t, client, adminServer.URL+"/admin/config", initial); status != http.StatusOK {
+		t.Fatalf("expected full reapply 200, got %d", status)
+	}
+	if reuse := store.Get().Reuse; reuse.RoutesReused != 0 || reuse.PoolsReused != 0 {
+		t.Fatalf("expected full apply to rebuild everything, got %+v", reuse)
+	}
+	if endpoints, _ := reg.Endpoints(pool.PoolKey("pa")); len(endpoints) != 1 {
+		t.Fatalf("expected full apply to reconcile pool back, got %v", endpoints)
+	}
+
+	pagePath := filepath.Join(t.TempDir(), "502.html")
+	if err := os.WriteFile(pagePath, []byte("v1"), 0o600); err != nil {
+		t.Fatalf("write error page: %v", err)
+	}
+	addMirror := fmt.Sprintf(`{"operations": [
+  {"op": "upsert_pool", "name": "pm", "pool": {"endpoints": ["%s"]}},
+  {"op": "upsert_route", "route": {"id": "m", "host": "m.local", "path_prefix": "/", "pool": "pa", "policy": {"mirror": {"pool": "pm", "percent": 100}, "error_pages": [{"status": [502], "format": "html", "file": %q}]}}}
+]}`, addrB, pagePath)
+	if status, body := postAdmin(t, client, adminServer.URL+"/admin/transaction", addMirror); status != http.StatusOK {
+		t.Fatalf("expected mirror transaction 200, got %d: %v", status, body)
+	}
+	deleteMirrorPool := `{"operations": [{"op": "delete_pool", "name": "pm"}]}`
+	if status, body := postAdmin(t, client, adminServer.URL+"/admin/transaction", deleteMirrorPool); status == http.StatusOK {
+		t.Fatalf("expected deleting a mirror pool referenced by a reused route to fail, got %v", body)
+	}
+
+	if err := os.WriteFile(pagePath, []byte("v2"), 0o600); err != nil {
+		t.Fatalf("rewrite error page: %v", err)
+	}
+	status, body = postAdmin(t, client, adminServer.URL+"/admin/transaction", `{"operations": [{"op": "delete_route", "id": "b"}]}`)
+	if status != http.StatusOK {
+		t.Fatalf("expected transaction 200, got %d: %v", status, body)
+	}
+	expectReuse(body, 1, 1, 2, 1)
+	route, ok = store.Get().Router.Route("m")
+	if !ok || len(route.Policy.ErrorPages) != 1 || route.Policy.ErrorPages[0].Body != "v2" {
+		t.Fatalf("expected changed error page file to recompile the route, got %+v", route.Policy.ErrorPages)
+	}
+}
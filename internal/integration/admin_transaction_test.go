@@ -0,0 +1,209 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/admin"
+	"modern_reverse_proxy/internal/apply"
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/provider"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestAdminTransactionAppliesAtomically(t *testing.T) {
+	oldAddr, closeOld := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "old")
+	}))
+	defer closeOld()
+	newAddr, closeNew := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "new")
+	}))
+	defer closeNew()
+
+	reg := registry.NewRegistry(0, 0)
+	trafficReg := traffic.NewRegistry(0, 0)
+	snap, err := runtime.FailSafeSnapshot(reg, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	store := runtime.NewStore(snap)
+
+	proxyServer := httptest.NewServer(&proxy.Handler{Store: store, Registry: reg, Engine: proxy.NewEngine(reg, nil, nil, nil, nil)})
+	defer proxyServer.Close()
+
+	adminProvider := provider.NewAdminPush()
+	applyManager := apply.NewManager(apply.ManagerConfig{
+		Store:           store,
+		Registry:        reg,
+		TrafficRegistry: trafficReg,
+		Providers:       []provider.Provider{adminProvider},
+		AdminProvider:   adminProvider,
+	})
+
+	ca := testutil.WriteCA(t, "admin-ca")
+	serverCert := testutil.WriteServerCert(t, "admin.local", ca)
+	clientCert := testutil.WriteClientCert(t, "client", ca)
+	adminTLS := newAdminTLSConfig(t, serverCert.CertFile, serverCert.KeyFile, ca.CertFile)
+	auth, err := admin.NewAuthenticator(admin.AuthConfig{Token: "secret", ClientCAFile: ca.CertFile})
+	if err != nil {
+		t.Fatalf("auth config: %v", err)
+	}
+	adminStore := admin.NewStore()
+	adminServer := startAdminServer(t, admin.NewHandler(admin.HandlerConfig{
+		Store:        store,
+		ApplyManager: applyManager,
+		Auth:         auth,
+		RateLimiter:  admin.NewRateLimiter(admin.RateLimitConfig{}),
+		AdminStore:   adminStore,
+	}), adminTLS)
+	defer adminServer.Close()
+	client := testutil.NewAdminClient(t, testutil.AdminClientConfig{CAFile: ca.CertFile, ClientCert: &clientCert, Token: "secret", ServerName: "admin.local"})
+	clientHTTP := &http.Client{Timeout: 5 * time.Second}
+
+	initial := fmt.Sprintf(`{
+"listen_addr": "127.0.0.1:0",
+"routes": [{"id": "legacy", "host": "example.local", "path_prefix": "/", "pool": "old"}],
+"pools": {"old": {"endpoints": ["%s"]}}
+}`, oldAddr)
+	status, _ := postAdmin(t, client, adminServer.URL+"/admin/config", initial)
+	if status != http.StatusOK {
+		t.Fatalf("expected initial apply 200, got %d", status)
+	}
+
+	migration := fmt.Sprintf(`{"operations": [
+{"op": "upsert_pool", "name": "new", "pool": {"endpoints": ["%s"]}},
+{"op": "upsert_route", "route": {"id": "current", "host": "example.local", "path_prefix": "/", "pool": "new"}},
+{"op": "delete_route", "id": "legacy"},
+{"op": "delete_pool", "name": "old"}
+]}`, newAddr)
+	status, body := postAdmin(t, client, adminServer.URL+"/admin/transaction", migration)
+	if status != http.StatusOK {
+		t.Fatalf("expected transaction 200, got %d: %v", status, body)
+	}
+	version, _ := body["version"].(string)
+	if version == "" || store.Get().Version != version {
+		t.Fatalf("expected snapshot version %q, got %q", version, store.Get().Version)
+	}
+	if _, ok := adminStore.Get(version); !ok {
+		t.Fatalf("expected transaction recorded in history")
+	}
+
+	resp, proxyBody := sendProxyRequest(t, clientHTTP, proxyServer.URL, "example.local", http.MethodGet, "/")
+	if resp.StatusCode != http.StatusOK || string(proxyBody) != "new" {
+		t.Fatalf("expected new upstream, got %d %q", resp.StatusCode, string(proxyBody))
+	}
+	if store.Get().RouteCount != 1 {
+		t.Fatalf("expected one route, got %d", store.Get().RouteCount)
+	}
+
+	broken := `{"operations": [
+{"op": "delete_route", "id": "current"},
+{"op": "upsert_route", "route": {"id": "broken", "host": "example.local", "path_prefix": "/", "pool": "missing"}}
+]}`
+	status, _ = postAdmin(t, client, adminServer.URL+"/admin/transaction", broken)
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid batch, got %d", status)
+	}
+	if store.Get().Version != version {
+		t.Fatalf("expected snapshot unchanged after failed batch")
+	}
+	resp, proxyBody = sendProxyRequest(t, clientHTTP, proxyServer.URL, "example.local", http.MethodGet, "/")
+	if resp.StatusCode != http.StatusOK || string(proxyBody) != "new" {
+		t.Fatalf("expected route intact after failed batch, got %d %q", resp.StatusCode, string(proxyBody))
+	}
+
+	status, _ = postAdmin(t, client, adminServer.URL+"/admin/transaction", `{"operations": [{"op": "delete_route", "id": "legacy"}]}`)
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for missing route, got %d", status)
+	}
+
+	stale := `{"base_version": "stale", "operations": [{"op": "delete_route", "id": "current"}]}`
+	status, _ = postAdmin(t, client, adminServer.URL+"/admin/transaction", stale)
+	if status != http.StatusConflict {
+		t.Fatalf("expected 409 for stale base, got %d", status)
+	}
+	if store.Get().Version != version {
+		t.Fatalf("expected snapshot unchanged after stale batch")
+	}
+}
+
+func TestAdminTransactionSerializedWithApplies(t *testing.T) {
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+	snap, err := runtime.FailSafeSnapshot(reg, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	store := runtime.NewStore(snap)
+	adminProvider := provider.NewAdminPush()
+	applyManager := apply.NewManager(apply.ManagerConfig{
+		Store:           store,
+		Registry:        reg,
+		TrafficRegistry: trafficReg,
+		Providers:       []provider.Provider{adminProvider},
+		AdminProvider:   adminProvider,
+	})
+	ctx := context.Background()
+
+	bundled := `{"routes": [{"id": "bundled", "host": "example.local", "path_prefix": "/", "pool": "p1"}], "pools": {"p1": {"endpoints": ["127.0.0.1:1"]}}}`
+	if _, err := applyManager.ApplyResolved(ctx, []byte(bundled), "bundle", apply.ModeApply); err != nil {
+		t.Fatalf("resolved apply: %v", err)
+	}
+
+	pushed := `{"routes": [{"id": "pushed", "host": "pushed.local", "path_prefix": "/", "pool": "p1"}], "pools": {"p1": {"endpoints": ["127.0.0.1:1"]}}}`
+	pushDone := make(chan string)
+	_, _, err = applyManager.Transact(ctx, "admin", func(cfg *config.Config) error {
+		if len(cfg.Routes) != 1 || cfg.Routes[0].ID != "bundled" {
+			return fmt.Errorf("expected transaction to start from the resolved config, got %+v", cfg.Routes)
+		}
+		go func() {
+			result, err := applyManager.Apply(ctx, []byte(pushed), "admin", apply.ModeApply)
+			if err != nil {
+				pushDone <- ""
+				return
+			}
+			pushDone <- result.Version
+		}()
+		select {
+		case <-pushDone:
+			return fmt.Errorf("config push committed while a transaction was in progress")
+		case <-time.After(100 * time.Millisecond):
+		}
+		cfg.Routes = append(cfg.Routes, config.Route{ID: "added", Host: "added.local", PathPrefix: "/", Pool: "p1"})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("transaction: %v", err)
+	}
+	if version := <-pushDone; version == "" || store.Get().Version != version {
+		t.Fatalf("expected config push to commit after the transaction, got %q", version)
+	}
+	if store.Get().RouteCount != 1 {
+		t.Fatalf("expected pushed config to replace the transaction result, got %d routes", store.Get().RouteCount)
+	}
+}
+
+func postAdmin(t *testing.T, client *testutil.AdminClient, url string, payload string) (int, map[string]interface{}) {
+	t.Helper()
+	resp, err := client.Do(mustAdminRequest(t, http.MethodPost, url, []byte(payload)))
+	if err != nil {
+		t.Fatalf("admin request: %v", err)
+	}
+	defer resp.Body.Close()
+	body := map[string]interface{}{}
+	_ = json.NewDecoder(resp.Body).Decode(&body)
+	return resp.StatusCode, body
+}
@@ -2,6 +2,7 @@ package integration
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -109,3 +110,84 @@ func TestAdminValidateDoesNotApply(t *testing.T) {
 		t.Fatalf("expected A response, got %q", string(body))
 	}
 }
+
+func TestAdminValidateReportsImpact(t *testing.T) {
+	addrA, closeA := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer closeA()
+	addrB, closeB := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer closeB()
+
+	initialConfig := fmt.Sprintf(`{
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1"}, {"id": "r2", "host": "two.local", "path_prefix": "/", "pool": "p2"}],
+"pools": {"p1": {"endpoints": ["%s"]}, "p2": {"endpoints": ["%s"]}}
+}`, addrA, addrB)
+	cfg, err := config.ParseJSON([]byte(initialConfig))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	store := runtime.NewStore(snap)
+	adminProvider := provider.NewAdminPush()
+	applyManager := apply.NewManager(apply.ManagerConfig{
+		Store:           store,
+		Registry:        reg,
+		TrafficRegistry: trafficReg,
+		Providers:       []provider.Provider{adminProvider},
+		AdminProvider:   adminProvider,
+	})
+
+	ca := testutil.WriteCA(t, "admin-ca")
+	serverCert := testutil.WriteServerCert(t, "admin.local", ca)
+	clientCert := testutil.WriteClientCert(t, "client", ca)
+	auth, err := admin.NewAuthenticator(admin.AuthConfig{Token: "secret", ClientCAFile: ca.CertFile})
+	if err != nil {
+		t.Fatalf("auth config: %v", err)
+	}
+	adminServer := startAdminServer(t, admin.NewHandler(admin.HandlerConfig{
+		Store:        store,
+		ApplyManager: applyManager,
+		Auth:         auth,
+		AdminStore:   admin.NewStore(),
+	}), newAdminTLSConfig(t, serverCert.CertFile, serverCert.KeyFile, ca.CertFile))
+	defer adminServer.Close()
+	client := testutil.NewAdminClient(t, testutil.AdminClientConfig{CAFile: ca.CertFile, ClientCert: &clientCert, Token: "secret", ServerName: "admin.local"})
+
+	validateConfig := fmt.Sprintf(`{
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1"}, {"id": "r3", "host": "three.local", "path_prefix": "/", "pool": "p3"}],
+"pools": {"p1": {"endpoints": ["%s"], "transport": {"max_idle_per_host": 7}}, "p3": {"endpoints": ["%s"]}}
+}`, addrB, addrA)
+	resp, err := client.Do(mustAdminRequest(t, http.MethodPost, adminServer.URL+"/admin/validate", []byte(validateConfig)))
+	if err != nil {
+		t.Fatalf("validate request: %v", err)
+	}
+	defer resp.Body.Close()
+	var payload struct {
+		Impact apply.Impact `json:"impact"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected impact report, got %d %v", resp.StatusCode, err)
+	}
+	impact := payload.Impact
+	if fmt.Sprint(impact.PoolsAdded) != "[p3]" || fmt.Sprint(impact.PoolsRemoved) != "[p2]" || fmt.Sprint(impact.PoolsReconciled) != "[p1]" {
+		t.Fatalf("unexpected pool impact %+v", impact)
+	}
+	if fmt.Sprint(impact.EndpointsDraining["p1"]) != fmt.Sprintf("[%s]", addrA) || fmt.Sprint(impact.EndpointsDraining["p2"]) != fmt.Sprintf("[%s]", addrB) {
+		t.Fatalf("unexpected draining endpoints %v", impact.EndpointsDraining)
+	}
+	if fmt.Sprint(impact.EndpointsAdded["p1"]) != fmt.Sprintf("[%s]", addrB) {
+		t.Fatalf("unexpected added endpoints %v", impact.EndpointsAdded)
+	}
+	if fmt.Sprint(impact.TransportsRebuilt) != "[p1]" || impact.TLS.Changed {
+		t.Fatalf("unexpected transport/tls impact %+v", impact)
+	}
+	if endpoints, _ := reg.Endpoints("p1"); fmt.Sprint(endpoints) != fmt.Sprintf("[%s]", addrA) {
+		t.Fatalf("expected validate to leave live pools untouched, got %v", endpoints)
+	}
+}
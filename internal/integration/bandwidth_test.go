@@ -0,0 +1,119 @@
+package integration
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestEgressBandwidthShaping(t *testing.T) {
+	payload := bytes.Repeat([]byte("x"), 64*1024)
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(payload)))
+		_, _ = w.Write(payload)
+	})
+	addr, closeUpstream := testutil.StartUpstream(t, upstream)
+	defer closeUpstream()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+
+	cfg := &config.Config{
+		Routes: []config.Route{
+			{
+				ID:         "shaped-route",
+				Host:       "route.local",
+				PathPrefix: "/",
+				Pool:       "p1",
+				Policy: config.RoutePolicy{
+					Bandwidth: config.BandwidthConfig{Enabled: true, RouteBytesPerSec: 64 * 1024, RouteBurstBytes: 16 * 1024},
+				},
+			},
+			{
+				ID:         "shaped-client",
+				Host:       "client.local",
+				PathPrefix: "/",
+				Pool:       "p1",
+				Policy: config.RoutePolicy{
+					Bandwidth: config.BandwidthConfig{Enabled: true, ClientBytesPerSec: 128 * 1024, ClientBurstBytes: 64 * 1024},
+				},
+			},
+			{ID: "open", Host: "open.local", PathPrefix: "/", Pool: "p1"},
+		},
+		Pools: map[string]config.Pool{
+			"p1": {Endpoints: []string{addr}},
+		},
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	proxyServer := httptest.NewServer(&proxy.Handler{
+		Store:    runtime.NewStore(snap),
+		Registry: reg,
+		Engine:   proxy.NewEngine(reg, nil, metrics, nil, nil),
+		Metrics:  metrics,
+	})
+	defer proxyServer.Close()
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	start := time.Now()
+	resp, body := sendProxyRequest(t, client, proxyServer.URL, "open.local", http.MethodGet, "/")
+	if resp.StatusCode != http.StatusOK || len(body) != len(payload) {
+		t.Fatalf("unexpected unshaped response %d len=%d", resp.StatusCode, len(body))
+	}
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Fatalf("unshaped route was delayed: %v", elapsed)
+	}
+
+	start = time.Now()
+	resp, body = sendProxyRequest(t, client, proxyServer.URL, "route.local", http.MethodGet, "/")
+	if resp.StatusCode != http.StatusOK || !bytes.Equal(body, payload) {
+		t.Fatalf("unexpected shaped response %d len=%d", resp.StatusCode, len(body))
+	}
+	if elapsed := time.Since(start); elapsed < 600*time.Millisecond {
+		t.Fatalf("expected route bandwidth shaping delay, got %v", elapsed)
+	}
+
+	resp, _ = sendProxyRequest(t, client, proxyServer.URL, "client.local", http.MethodGet, "/")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status %d", resp.StatusCode)
+	}
+	start = time.Now()
+	resp, body = sendProxyRequest(t, client, proxyServer.URL, "client.local", http.MethodGet, "/")
+	if resp.StatusCode != http.StatusOK || len(body) != len(payload) {
+		t.Fatalf("unexpected client shaped response %d len=%d", resp.StatusCode, len(body))
+	}
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+		t.Fatalf("expected client bandwidth shaping after burst, got %v", elapsed)
+	}
+
+	metricsServer := httptest.NewServer(metrics.Handler())
+	defer metricsServer.Close()
+	text := fetchMetrics(t, metricsServer)
+	if value, ok := metricValue(text, "proxy_egress_throttled_responses_total", nil); !ok || value < 2 {
+		t.Fatalf("expected throttled responses metric, got %v", value)
+	}
+	if value, ok := metricValue(text, "proxy_egress_throttle_wait_seconds_total", nil); !ok || value <= 0 {
+		t.Fatalf("expected throttle wait metric, got %v", value)
+	}
+
+	cfg.Routes[0].Policy.Bandwidth = config.BandwidthConfig{Enabled: true}
+	if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg); err == nil {
+		t.Fatalf("expected error for bandwidth without limits")
+	}
+}
@@ -0,0 +1,173 @@
+package integration
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/cache"
+)
+
+func TestCacheDiskStorePersistsAcrossRestart(t *testing.T) {
+	dir := t.TempDir()
+	store, err := cache.NewStore(cache.StoreConfig{Backend: "disk", Dir: dir})
+	if err != nil {
+		t.Fatalf("new disk store: %v", err)
+	}
+	entry := cache.Entry{
+		Status:    http.StatusOK,
+		Header:    http.Header{"Content-Type": []string{"text/plain"}},
+		Body:      []byte("persisted"),
+		StoredAt:  time.Now().UTC(),
+		ExpiresAt: time.Now().UTC().Add(time.Minute),
+	}
+	if err := store.Set("k1", entry); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if err := store.Set("k2", cache.Entry{Status: http.StatusOK, Body: []byte("expired"), ExpiresAt: time.Now().Add(-time.Second)}); err != nil {
+		t.Fatalf("set expired: %v", err)
+	}
+
+	reopened, err := cache.NewDiskStore(dir, 0, 0)
+	if err != nil {
+		t.Fatalf("reopen disk store: %v", err)
+	}
+	got, ok := reopened.Get("k1")
+	if !ok {
+		t.Fatalf("expected entry to survive reopen")
+	}
+	if got.Status != http.StatusOK || string(got.Body) != "persisted" || got.Header.Get("Content-Type") != "text/plain" {
+		t.Fatalf("unexpected entry %+v", got)
+	}
+	if _, ok := reopened.Get("k2"); ok {
+		t.Fatalf("expected expired entry to be dropped")
+	}
+	if reopened.Len() != 1 {
+		t.Fatalf("expected 1 entry after reopen, got %d", reopened.Len())
+	}
+
+	reopened.Delete("k1")
+	if _, ok := reopened.Get("k1"); ok {
+		t.Fatalf("expected deleted entry to miss")
+	}
+}
+
+func TestCacheDiskStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	dir := t.TempDir()
+	body := bytes.Repeat([]byte("x"), 1024)
+	probe, err := cache.NewDiskStore(t.TempDir(), 0, 0)
+	if err != nil {
+		t.Fatalf("new probe store: %v", err)
+	}
+	if err := probe.Set("a", cache.Entry{Status: http.StatusOK, Body: body}); err != nil {
+		t.Fatalf("probe set: %v", err)
+	}
+	entrySize := probe.Size()
+
+	store, err := cache.NewDiskStore(dir, entrySize*2+entrySize/2, 0)
+	if err != nil {
+		t.Fatalf("new disk store: %v", err)
+	}
+	for _, key := range []string{"a", "b"} {
+		if err := store.Set(key, cache.Entry{Status: http.StatusOK, Body: body}); err != nil {
+			t.Fatalf("set %s: %v", key, err)
+		}
+	}
+	if _, ok := store.Get("a"); !ok {
+		t.Fatalf("expected a to be cached")
+	}
+	if err := store.Set("c", cache.Entry{Status: http.StatusOK, Body: body}); err != nil {
+		t.Fatalf("set c: %v", err)
+	}
+	if _, ok := store.Get("b"); ok {
+		t.Fatalf("expected least recently used entry b to be evicted")
+	}
+	if _, ok := store.Get("a"); !ok {
+		t.Fatalf("expected recently used entry a to remain")
+	}
+	if _, ok := store.Get("c"); !ok {
+		t.Fatalf("expected newest entry c to remain")
+	}
+	if store.Size() > entrySize*2+entrySize/2 {
+		t.Fatalf("store size %d exceeds max", store.Size())
+	}
+}
+
+func TestCacheDiskStoreRecoversFromCorruptIndex(t *testing.T) {
+	dir := t.TempDir()
+	store, err := cache.NewDiskStore(dir, 0, 0)
+	if err != nil {
+		t.Fatalf("new disk store: %v", err)
+	}
+	if err := store.Set("k1", cache.Entry{Status: http.StatusOK, Body: []byte("ok")}); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "index.json"), []byte("{truncated"), 0o600); err != nil {
+		t.Fatalf("corrupt index: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".tmp-leftover"), []byte("partial"), 0o600); err != nil {
+		t.Fatalf("write temp file: %v", err)
+	}
+
+	reopened, err := cache.NewDiskStore(dir, 0, 0)
+	if err != nil {
+		t.Fatalf("reopen disk store: %v", err)
+	}
+	got, ok := reopened.Get("k1")
+	if !ok || string(got.Body) != "ok" {
+		t.Fatalf("expected entry rebuilt from data files")
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".tmp-leftover")); !os.IsNotExist(err) {
+		t.Fatalf("expected leftover temp file removed")
+	}
+
+	if _, err := cache.NewStore(cache.StoreConfig{Backend: "tape"}); err == nil {
+		t.Fatalf("expected unsupported backend error")
+	}
+}
+
+func TestCacheDiskStoreBatchesIndexWrites(t *testing.T) {
+	dir := t.TempDir()
+	store, err := cache.NewDiskStore(dir, 0, 0)
+	if err != nil {
+		t.Fatalf("new disk store: %v", err)
+	}
+	indexPath := filepath.Join(dir, "index.json")
+	initial, err := os.ReadFile(indexPath)
+	if err != nil {
+		t.Fatalf("read index: %v", err)
+	}
+	for _, key := range []string{"a", "b", "c"} {
+		if err := store.Set(key, cache.Entry{Status: http.StatusOK, Body: []byte(key)}); err != nil {
+			t.Fatalf("set %s: %v", key, err)
+		}
+	}
+	store.Delete("b")
+	if current, _ := os.ReadFile(indexPath); !bytes.Equal(current, initial) {
+		t.Fatalf("expected index writes to be deferred, got %s", current)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	flushed, err := os.ReadFile(indexPath)
+	if err != nil {
+		t.Fatalf("read flushed index: %v", err)
+	}
+	for key, want := range map[string]bool{`"a"`: true, `"b"`: false, `"c"`: true} {
+		if bytes.Contains(flushed, []byte(`"key":`+key)) != want {
+			t.Fatalf("expected key %s present=%v in flushed index %s", key, want, flushed)
+		}
+	}
+
+	reopened, err := cache.NewDiskStore(dir, 0, 0)
+	if err != nil {
+		t.Fatalf("reopen disk store: %v", err)
+	}
+	defer reopened.Close()
+	if reopened.Len() != 2 {
+		t.Fatalf("expected 2 entries after reopen, got %d", reopened.Len())
+	}
+}
@@ -0,0 +1,56 @@
+package integration
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/cache"
+)
+
+func TestShardedMemoryStoreConcurrentAccess(t *testing.T) {
+	store, err := cache.NewStore(cache.StoreConfig{Backend: "memory", MemoryShards: 8})
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	memory, ok := store.(*cache.MemoryStore)
+	if !ok {
+		t.Fatalf("expected memory store, got %T", store)
+	}
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				key := "w" + strconv.Itoa(worker) + "/k" + strconv.Itoa(i)
+				if err := memory.Set(key, cache.Entry{Status: 200, Body: []byte(key), ExpiresAt: time.Now().Add(time.Minute)}); err != nil {
+					t.Errorf("set %s: %v", key, err)
+					return
+				}
+				entry, ok := memory.Get(key)
+				if !ok || string(entry.Body) != key {
+					t.Errorf("expected %s to round trip", key)
+					return
+				}
+				if i%2 == 0 {
+					memory.Delete(key)
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+
+	if got := memory.Len(); got != 8*100 {
+		t.Fatalf("expected 800 entries across shards, got %d", got)
+	}
+
+	if err := memory.Set("expired", cache.Entry{Status: 200, ExpiresAt: time.Now().Add(-time.Second)}); err != nil {
+		t.Fatalf("set expired: %v", err)
+	}
+	if _, ok := memory.Get("expired"); ok {
+		t.Fatalf("expected expired entry to be dropped")
+	}
+}
@@ -0,0 +1,139 @@
+package integration
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/cache"
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestCacheNegativeResponses(t *testing.T) {
+	var mu sync.Mutex
+	hits := map[string]int{}
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.RequestURI()]++
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, "missing")
+		case "/moved":
+			w.Header().Set("Location", "/new")
+			w.WriteHeader(http.StatusMovedPermanently)
+		case "/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = io.WriteString(w, "broken")
+		default:
+			_, _ = io.WriteString(w, "ok")
+		}
+	})
+	addr, closeUpstream := testutil.StartUpstream(t, upstream)
+	defer closeUpstream()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+
+	negativeCache := config.CacheConfig{
+		Enabled: true,
+		Public:  true,
+		TTLMS:   60000,
+		CacheableStatuses: []config.CacheStatusConfig{
+			{Status: http.StatusOK},
+			{Status: http.StatusNotFound, TTLMS: 200},
+			{Status: http.StatusMovedPermanently},
+		},
+	}
+	cfg := &config.Config{
+		Routes: []config.Route{
+			{ID: "negative", Host: "negative.local", PathPrefix: "/", Pool: "p1", Policy: config.RoutePolicy{Cache: negativeCache}},
+			{ID: "default", Host: "default.local", PathPrefix: "/", Pool: "p1", Policy: config.RoutePolicy{Cache: config.CacheConfig{Enabled: true, Public: true, TTLMS: 60000}}},
+		},
+		Pools: map[string]config.Pool{
+			"p1": {Endpoints: []string{addr}},
+		},
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	cacheLayer := cache.NewCache(cache.NewMemoryStore(cache.DefaultMaxObjectBytes), cache.NewCoalescer(cache.DefaultMaxFlights))
+	proxyServer := httptest.NewServer(&proxy.Handler{
+		Store:    runtime.NewStore(snap),
+		Registry: reg,
+		Engine:   proxy.NewEngine(reg, nil, nil, nil, nil),
+		Cache:    cacheLayer,
+	})
+	defer proxyServer.Close()
+	client := &http.Client{
+		Timeout: 2 * time.Second,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	upstreamHits := func(key string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return hits[key]
+	}
+
+	for i := 0; i < 3; i++ {
+		resp, body := sendProxyRequest(t, client, proxyServer.URL, "negative.local", http.MethodGet, "/missing")
+		if resp.StatusCode != http.StatusNotFound || string(body) != "missing" {
+			t.Fatalf("expected cached 404, got %d %q", resp.StatusCode, string(body))
+		}
+		resp, _ = sendProxyRequest(t, client, proxyServer.URL, "negative.local", http.MethodGet, "/moved")
+		if resp.StatusCode != http.StatusMovedPermanently || resp.Header.Get("Location") != "/new" {
+			t.Fatalf("expected cached redirect, got %d %q", resp.StatusCode, resp.Header.Get("Location"))
+		}
+		resp, _ = sendProxyRequest(t, client, proxyServer.URL, "negative.local", http.MethodGet, "/broken")
+		if resp.StatusCode != http.StatusInternalServerError {
+			t.Fatalf("expected 500, got %d", resp.StatusCode)
+		}
+		resp, _ = sendProxyRequest(t, client, proxyServer.URL, "default.local", http.MethodGet, "/missing?route=default")
+		if resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("expected 404, got %d", resp.StatusCode)
+		}
+	}
+
+	if got := upstreamHits("/missing"); got != 1 {
+		t.Fatalf("expected cached 404 to hit upstream once, got %d", got)
+	}
+	if got := upstreamHits("/moved"); got != 1 {
+		t.Fatalf("expected cached 301 to hit upstream once, got %d", got)
+	}
+	if got := upstreamHits("/broken"); got != 3 {
+		t.Fatalf("expected 500 to bypass cache, got %d", got)
+	}
+	if got := upstreamHits("/missing?route=default"); got != 3 {
+		t.Fatalf("expected 404 to bypass cache by default, got %d", got)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	_, _ = sendProxyRequest(t, client, proxyServer.URL, "negative.local", http.MethodGet, "/missing")
+	if got := upstreamHits("/missing"); got != 2 {
+		t.Fatalf("expected 404 status ttl to expire independently, got %d", got)
+	}
+	_, _ = sendProxyRequest(t, client, proxyServer.URL, "negative.local", http.MethodGet, "/moved")
+	if got := upstreamHits("/moved"); got != 1 {
+		t.Fatalf("expected 301 to use route ttl, got %d", got)
+	}
+
+	cfg.Routes[0].Policy.Cache.CacheableStatuses = []config.CacheStatusConfig{{Status: http.StatusInternalServerError}}
+	if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg); err == nil {
+		t.Fatalf("expected error for uncacheable status")
+	}
+}
@@ -0,0 +1,202 @@
+package integration
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/cache"
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestCacheRangeRequests(t *testing.T) {
+	const content = "abcdefghijklmnopqrstuvwxyz"
+	var mu sync.Mutex
+	var fullFetches, rangeFetches int
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		if r.Header.Get("Range") != "" {
+			rangeFetches++
+		} else {
+			fullFetches++
+		}
+		mu.Unlock()
+		if r.URL.Path == "/slow" {
+			time.Sleep(100 * time.Millisecond)
+		}
+		w.Header().Set("ETag", `"v1"`)
+		if r.URL.Path == "/empty" {
+			http.ServeContent(w, r, "", time.Time{}, strings.NewReader(""))
+			return
+		}
+		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(content))
+	})
+	addr, closeUpstream := testutil.StartUpstream(t, upstream)
+	defer closeUpstream()
+	counts := func() (int, int) {
+		mu.Lock()
+		defer mu.Unlock()
+		return fullFetches, rangeFetches
+	}
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+
+	cacheCfg := config.CacheConfig{Enabled: true, Public: true, TTLMS: 60000}
+	collapseCfg := cacheCfg
+	collapseCfg.RangeCollapse = true
+	cfg := &config.Config{
+		Routes: []config.Route{
+			{ID: "plain", Host: "plain.local", PathPrefix: "/", Pool: "p1", Policy: config.RoutePolicy{Cache: cacheCfg}},
+			{ID: "collapse", Host: "collapse.local", PathPrefix: "/", Pool: "p1", Policy: config.RoutePolicy{Cache: collapseCfg}},
+		},
+		Pools: map[string]config.Pool{
+			"p1": {Endpoints: []string{addr}},
+		},
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	cacheLayer := cache.NewCache(cache.NewMemoryStore(cache.DefaultMaxObjectBytes), cache.NewCoalescer(cache.DefaultMaxFlights))
+	proxyServer := httptest.NewServer(&proxy.Handler{
+		Store:    runtime.NewStore(snap),
+		Registry: reg,
+		Engine:   proxy.NewEngine(reg, nil, nil, nil, nil),
+		Cache:    cacheLayer,
+	})
+	defer proxyServer.Close()
+	client := &http.Client{Timeout: 2 * time.Second}
+	rangeRequest := func(host, path, value string, extra map[string]string) (*http.Response, []byte) {
+		headers := map[string]string{"Range": value}
+		for key, v := range extra {
+			headers[key] = v
+		}
+		return sendProxyRequestWithHeaders(t, client, proxyServer.URL, host, http.MethodGet, path, headers)
+	}
+
+	resp, body := rangeRequest("plain.local", "/cold", "bytes=0-4", nil)
+	if resp.StatusCode != http.StatusPartialContent || string(body) != "abcde" {
+		t.Fatalf("expected upstream partial content, got %d %q", resp.StatusCode, string(body))
+	}
+	if full, ranged := counts(); full != 0 || ranged != 1 {
+		t.Fatalf("expected range miss to be forwarded, got full=%d range=%d", full, ranged)
+	}
+
+	resp, body = sendProxyRequest(t, client, proxyServer.URL, "plain.local", http.MethodGet, "/obj")
+	if resp.StatusCode != http.StatusOK || string(body) != content {
+		t.Fatalf("expected full body, got %d %q", resp.StatusCode, string(body))
+	}
+
+	resp, body = rangeRequest("plain.local", "/obj", "bytes=0-4", nil)
+	if resp.StatusCode != http.StatusPartialContent || string(body) != "abcde" {
+		t.Fatalf("expected cached range, got %d %q", resp.StatusCode, string(body))
+	}
+	if resp.Header.Get("Content-Range") != "bytes 0-4/26" {
+		t.Fatalf("unexpected content range %q", resp.Header.Get("Content-Range"))
+	}
+
+	resp, body = rangeRequest("plain.local", "/obj", "bytes=-3", nil)
+	if resp.StatusCode != http.StatusPartialContent || string(body) != "xyz" {
+		t.Fatalf("expected suffix range, got %d %q", resp.StatusCode, string(body))
+	}
+
+	resp, body = rangeRequest("plain.local", "/obj", "bytes=0-1,4-5", nil)
+	if resp.StatusCode != http.StatusPartialContent {
+		t.Fatalf("expected multipart range, got %d", resp.StatusCode)
+	}
+	mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/byteranges" {
+		t.Fatalf("unexpected multipart content type %q", resp.Header.Get("Content-Type"))
+	}
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	var parts []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read part: %v", err)
+		}
+		data, _ := io.ReadAll(part)
+		parts = append(parts, part.Header.Get("Content-Range")+"="+string(data))
+	}
+	if strings.Join(parts, ";") != "bytes 0-1/26=ab;bytes 4-5/26=ef" {
+		t.Fatalf("unexpected multipart parts %v", parts)
+	}
+
+	resp, body = rangeRequest("plain.local", "/obj", "bytes=8-9,0-2,1-3", nil)
+	if resp.StatusCode != http.StatusPartialContent || !strings.HasPrefix(resp.Header.Get("Content-Type"), "multipart/byteranges") {
+		t.Fatalf("expected multipart range, got %d", resp.StatusCode)
+	}
+	if !strings.Contains(string(body), "bytes 0-3/26") || strings.Contains(string(body), "bytes 1-3/26") {
+		t.Fatalf("expected overlapping ranges to coalesce, got %q", string(body))
+	}
+
+	resp, body = rangeRequest("plain.local", "/obj", "bytes=0-,0-", nil)
+	if resp.StatusCode != http.StatusOK || string(body) != content {
+		t.Fatalf("expected full body when ranges exceed the object, got %d %q", resp.StatusCode, string(body))
+	}
+	resp, body = rangeRequest("plain.local", "/obj", "bytes=0-0"+strings.Repeat(",2-2", 16), nil)
+	if resp.StatusCode != http.StatusOK || string(body) != content {
+		t.Fatalf("expected full body for too many ranges, got %d", resp.StatusCode)
+	}
+
+	resp, _ = rangeRequest("plain.local", "/obj", "bytes=100-", nil)
+	if resp.StatusCode != http.StatusRequestedRangeNotSatisfiable || resp.Header.Get("Content-Range") != "bytes */26" {
+		t.Fatalf("expected 416, got %d %q", resp.StatusCode, resp.Header.Get("Content-Range"))
+	}
+
+	resp, body = rangeRequest("plain.local", "/obj", "bytes=0-4", map[string]string{"If-Range": `"v0"`})
+	if resp.StatusCode != http.StatusOK || string(body) != content {
+		t.Fatalf("expected full body for stale If-Range, got %d", resp.StatusCode)
+	}
+	if full, ranged := counts(); full != 1 || ranged != 1 {
+		t.Fatalf("expected cached ranges to avoid upstream, got full=%d range=%d", full, ranged)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan string, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, body := rangeRequest("collapse.local", "/slow", "bytes=2-3", nil)
+			if resp.StatusCode != http.StatusPartialContent || string(body) != "cd" {
+				errs <- resp.Status + " " + string(body)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for msg := range errs {
+		t.Fatalf("unexpected collapsed range response: %s", msg)
+	}
+	if full, ranged := counts(); full != 2 || ranged != 1 {
+		t.Fatalf("expected concurrent ranges to collapse onto one full fetch, got full=%d range=%d", full, ranged)
+	}
+
+	resp, _ = sendProxyRequest(t, client, proxyServer.URL, "plain.local", http.MethodGet, "/empty")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected empty body, got %d", resp.StatusCode)
+	}
+	resp, _ = rangeRequest("plain.local", "/empty", "bytes=-5", nil)
+	if resp.StatusCode != http.StatusRequestedRangeNotSatisfiable || resp.Header.Get("Content-Range") != "bytes */0" {
+		t.Fatalf("expected 416 for suffix range on empty entity, got %d %q", resp.StatusCode, resp.Header.Get("Content-Range"))
+	}
+}
@@ -0,0 +1,149 @@
+package integration
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/cache"
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestCacheHonorsCacheControlAndRevalidates(t *testing.T) {
+	var fullResponses int32
+	var notModified int32
+	var privateHits int32
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/private" {
+			atomic.AddInt32(&privateHits, 1)
+			w.Header().Set("Cache-Control", "private, max-age=60")
+			w.Header().Set("Content-Length", "2")
+			_, _ = w.Write([]byte("pv"))
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=1")
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		atomic.AddInt32(&fullResponses, 1)
+		w.Header().Set("Content-Length", "5")
+		_, _ = w.Write([]byte("hello"))
+	})
+	addr, closeUpstream := testutil.StartUpstream(t, upstream)
+	defer closeUpstream()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+
+	cfg := &config.Config{
+		Routes: []config.Route{
+			{
+				ID:         "r1",
+				Host:       "example.local",
+				PathPrefix: "/",
+				Pool:       "p1",
+				Policy: config.RoutePolicy{
+					Cache: config.CacheConfig{Enabled: true, Public: true, TTLMS: 60000},
+				},
+			},
+		},
+		Pools: map[string]config.Pool{
+			"p1": {Endpoints: []string{addr}},
+		},
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	cacheLayer := cache.NewCache(cache.NewMemoryStore(cache.DefaultMaxObjectBytes), cache.NewCoalescer(cache.DefaultMaxFlights))
+	proxyServer := httptest.NewServer(&proxy.Handler{
+		Store:    runtime.NewStore(snap),
+		Registry: reg,
+		Engine:   proxy.NewEngine(reg, nil, metrics, nil, nil),
+		Metrics:  metrics,
+		Cache:    cacheLayer,
+	})
+	defer proxyServer.Close()
+	client := &http.Client{Timeout: 2 * time.Second}
+
+	for i := 0; i < 2; i++ {
+		resp, body := sendProxyRequest(t, client, proxyServer.URL, "example.local", http.MethodGet, "/")
+		if resp.StatusCode != http.StatusOK || string(body) != "hello" {
+			t.Fatalf("expected cached body, got %d %q", resp.StatusCode, string(body))
+		}
+	}
+	if atomic.LoadInt32(&fullResponses) != 1 {
+		t.Fatalf("expected one upstream fetch while fresh, got %d", fullResponses)
+	}
+
+	resp, _ := sendProxyRequestWithHeaders(t, client, proxyServer.URL, "example.local", http.MethodGet, "/", map[string]string{"If-None-Match": `"v1"`})
+	if resp.StatusCode != http.StatusNotModified {
+		t.Fatalf("expected 304 for matching client validator, got %d", resp.StatusCode)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+	resp, body := sendProxyRequest(t, client, proxyServer.URL, "example.local", http.MethodGet, "/")
+	if resp.StatusCode != http.StatusOK || string(body) != "hello" {
+		t.Fatalf("expected revalidated body, got %d %q", resp.StatusCode, string(body))
+	}
+	if atomic.LoadInt32(&notModified) != 1 || atomic.LoadInt32(&fullResponses) != 1 {
+		t.Fatalf("expected conditional revalidation, got full=%d not_modified=%d", fullResponses, notModified)
+	}
+	resp, _ = sendProxyRequest(t, client, proxyServer.URL, "example.local", http.MethodGet, "/")
+	if resp.StatusCode != http.StatusOK || atomic.LoadInt32(&notModified) != 1 {
+		t.Fatalf("expected refreshed entry to be served from cache")
+	}
+
+	for i := 0; i < 2; i++ {
+		_, _ = sendProxyRequest(t, client, proxyServer.URL, "example.local", http.MethodGet, "/private")
+	}
+	if atomic.LoadInt32(&privateHits) != 2 {
+		t.Fatalf("expected private responses to bypass cache, got %d upstream hits", privateHits)
+	}
+
+	metricsServer := httptest.NewServer(metrics.Handler())
+	defer metricsServer.Close()
+	text := fetchMetrics(t, metricsServer)
+	if value, ok := metricValue(text, "proxy_cache_requests_total", map[string]string{"status": "revalidated"}); !ok || value != 1 {
+		t.Fatalf("expected revalidated cache metric, got %v", value)
+	}
+}
+
+func TestCacheFreshnessLifetime(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name     string
+		header   http.Header
+		lifetime time.Duration
+		storable bool
+	}{
+		{"fallback", http.Header{}, time.Minute, true},
+		{"max_age", http.Header{"Cache-Control": {"max-age=30"}}, 30 * time.Second, true},
+		{"s_maxage_wins", http.Header{"Cache-Control": {"max-age=30, s-maxage=90"}}, 90 * time.Second, true},
+		{"age_subtracted", http.Header{"Cache-Control": {"max-age=30"}, "Age": {"10"}}, 20 * time.Second, true},
+		{"expires", http.Header{"Date": {now.Format(http.TimeFormat)}, "Expires": {now.Add(45 * time.Second).Format(http.TimeFormat)}}, 45 * time.Second, true},
+		{"invalid_expires", http.Header{"Expires": {"0"}}, 0, true},
+		{"no_cache", http.Header{"Cache-Control": {"no-cache"}}, 0, true},
+		{"no_store", http.Header{"Cache-Control": {"no-store"}}, 0, false},
+	}
+	for _, tc := range cases {
+		lifetime, storable := cache.FreshnessLifetime(tc.header, now, time.Minute)
+		if lifetime != tc.lifetime || storable != tc.storable {
+			t.Fatalf("%s: expected %v/%v, got %v/%v", tc.name, tc.lifetime, tc.storable, lifetime, storable)
+		}
+	}
+}
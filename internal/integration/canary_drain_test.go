@@ -0,0 +1,126 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestCanaryGracefulDrain(t *testing.T) {
+	stableAddr, closeStable := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Variant", "stable")
+	}))
+	defer closeStable()
+	canaryAddr, closeCanary := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Variant", "canary")
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer closeCanary()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+	trafficReg := traffic.NewRegistry(0, 0)
+	trafficReg.SetDrainObserver(metrics.SetDrainedCohorts)
+	defer trafficReg.Close()
+
+	cfg := &config.Config{
+		Routes: []config.Route{{
+			ID:         "r1",
+			Host:       "example.local",
+			PathPrefix: "/",
+			Pool:       "pStable",
+			Policy: config.RoutePolicy{
+				Traffic: config.TrafficConfig{
+					Enabled:      true,
+					StablePool:   "pStable",
+					CanaryPool:   "pCanary",
+					StableWeight: 50,
+					CanaryWeight: 50,
+					Cohort:       config.CohortConfig{Enabled: true, Key: "header:X-User-ID"},
+					AutoDrain: config.AutoDrainConfig{
+						Enabled:             true,
+						WindowMS:            500,
+						MinRequests:         5,
+						ErrorRateMultiplier: 2,
+						CooloffMS:           10000,
+						Mode:                "graceful",
+						GracePeriodMS:       2000,
+					},
+				},
+			},
+		}},
+		Pools: map[string]config.Pool{
+			"pStable": {Endpoints: []string{stableAddr}},
+			"pCanary": {Endpoints: []string{canaryAddr}},
+		},
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	proxyServer := httptest.NewServer(&proxy.Handler{Store: runtime.NewStore(snap), Registry: reg, Engine: proxy.NewEngine(reg, nil, nil, nil, nil)})
+	defer proxyServer.Close()
+	metricsServer := httptest.NewServer(metrics.Handler())
+	defer metricsServer.Close()
+	client := &http.Client{Timeout: 2 * time.Second}
+
+	variantFor := func(user string) string {
+		t.Helper()
+		resp, _ := sendProxyRequestWithHeaders(t, client, proxyServer.URL, "example.local", http.MethodGet, "/", map[string]string{"X-User-ID": user})
+		return resp.Header.Get("X-Variant")
+	}
+	split := traffic.Split{StableWeight: 50, CanaryWeight: 50}
+	canaryUsers := []string{}
+	for i := 0; i < 20; i++ {
+		user := fmt.Sprintf("user-%d", i)
+		if variantFor(user) == "canary" {
+			canaryUsers = append(canaryUsers, user)
+		}
+	}
+	if len(canaryUsers) < 5 {
+		t.Fatalf("expected enough canary cohorts before drain, got %d", len(canaryUsers))
+	}
+	newCanaryUser := ""
+	for i := 100; newCanaryUser == ""; i++ {
+		if candidate := fmt.Sprintf("user-%d", i); split.ChooseDeterministic(candidate) == traffic.VariantCanary {
+			newCanaryUser = candidate
+		}
+	}
+
+	drained := func(expected int) func() error {
+		return func() error {
+			text := fetchMetrics(t, metricsServer)
+			if value, ok := metricValue(text, "proxy_traffic_drained_cohorts", map[string]string{"route": "r1"}); !ok || value != float64(expected) {
+				return fmt.Errorf("expected %d drained cohorts, got %v", expected, value)
+			}
+			return nil
+		}
+	}
+	testutil.Eventually(t, 3*time.Second, 20*time.Millisecond, func() error {
+		variantFor(canaryUsers[0])
+		return drained(len(canaryUsers))()
+	})
+
+	if got := variantFor(canaryUsers[0]); got != "canary" {
+		t.Fatalf("expected existing canary cohort to stay on canary during grace, got %q", got)
+	}
+	if got := variantFor(newCanaryUser); got != "stable" {
+		t.Fatalf("expected new cohort to go stable while draining, got %q", got)
+	}
+
+	testutil.Eventually(t, 4*time.Second, 50*time.Millisecond, drained(0))
+	if got := variantFor(canaryUsers[0]); got != "stable" {
+		t.Fatalf("expected drained cohort to move to stable after grace, got %q", got)
+	}
+}
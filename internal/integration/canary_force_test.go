@@ -0,0 +1,116 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestCanaryForceOverride(t *testing.T) {
+	traffic.SetSeedForTests(4)
+	t.Setenv("CANARY_FORCE_SECRET", "force-secret")
+	stableAddr, closeStable := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Variant", "stable")
+	}))
+	defer closeStable()
+	canaryAddr, closeCanary := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Variant", "canary")
+	}))
+	defer closeCanary()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+	cfg := &config.Config{
+		Routes: []config.Route{{
+			ID:         "r1",
+			Host:       "example.local",
+			PathPrefix: "/",
+			Pool:       "pStable",
+			Policy: config.RoutePolicy{
+				Traffic: config.TrafficConfig{
+					Enabled:      true,
+					StablePool:   "pStable",
+					CanaryPool:   "pCanary",
+					StableWeight: 100,
+					CanaryWeight: 0,
+					Force: config.ForceConfig{
+						Header:          "X-Canary",
+						Cookie:          "canary_force",
+						CookieSecretEnv: "CANARY_FORCE_SECRET",
+					},
+				},
+			},
+		}},
+		Pools: map[string]config.Pool{
+			"pStable": {Endpoints: []string{stableAddr}},
+			"pCanary": {Endpoints: []string{canaryAddr}},
+		},
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	proxyServer := httptest.NewServer(&proxy.Handler{Store: runtime.NewStore(snap), Registry: reg, Engine: proxy.NewEngine(reg, nil, nil, nil, nil)})
+	defer proxyServer.Close()
+
+	oldStdout := os.Stdout
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe: %v", err)
+	}
+	os.Stdout = writer
+	defer func() {
+		os.Stdout = oldStdout
+	}()
+
+	client := &http.Client{Timeout: 2 * time.Second}
+	if counts := countVariantHeaders(t, client, proxyServer.URL, "example.local", "/", 5, nil); counts["stable"] != 5 {
+		t.Fatalf("expected weights to route to stable, got %v", counts)
+	}
+	if counts := countVariantHeaders(t, client, proxyServer.URL, "example.local", "/", 5, map[string]string{"X-Canary": "always"}); counts["canary"] != 5 {
+		t.Fatalf("expected forced header to route to canary, got %v", counts)
+	}
+	signed := "canary_force=" + traffic.SignForceCookie([]byte("force-secret"), traffic.VariantCanary)
+	if counts := countVariantHeaders(t, client, proxyServer.URL, "example.local", "/", 5, map[string]string{"Cookie": signed}); counts["canary"] != 5 {
+		t.Fatalf("expected signed cookie to route to canary, got %v", counts)
+	}
+	forged := "canary_force=" + traffic.SignForceCookie([]byte("wrong-secret"), traffic.VariantCanary)
+	if counts := countVariantHeaders(t, client, proxyServer.URL, "example.local", "/", 5, map[string]string{"Cookie": forged}); counts["stable"] != 5 {
+		t.Fatalf("expected forged cookie to be ignored, got %v", counts)
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatalf("close writer: %v", err)
+	}
+	modes := make(map[string]int)
+	for _, line := range readLines(t, reader) {
+		var payload map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &payload); err != nil {
+			continue
+		}
+		if mode, ok := payload["cohort_mode"].(string); ok {
+			modes[mode]++
+		}
+	}
+	if modes["forced"] != 10 || modes["random"] != 10 {
+		t.Fatalf("expected 10 forced and 10 random cohort modes, got %v", modes)
+	}
+
+	cfg.Routes[0].Policy.Traffic.Force.CookieSecretEnv = ""
+	if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg); err == nil || !strings.Contains(err.Error(), "cookie_secret_env") {
+		t.Fatalf("expected missing cookie secret error, got %v", err)
+	}
+}
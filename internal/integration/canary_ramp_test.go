@@ -0,0 +1,97 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestCanaryRamp(t *testing.T) {
+	traffic.SetSeedForTests(5)
+	stableAddr, closeStable := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Variant", "stable")
+	}))
+	defer closeStable()
+	canaryAddr, closeCanary := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Variant", "canary")
+	}))
+	defer closeCanary()
+	brokenAddr, closeBroken := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Variant", "canary")
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer closeBroken()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+	trafficReg := traffic.NewRegistry(0, 0)
+	trafficReg.SetRampObserver(metrics.RecordTrafficRamp)
+	defer trafficReg.Close()
+
+	rampRoute := func(id string, host string, canaryPool string, ramp config.RampConfig) config.Route {
+		return config.Route{ID: id, Host: host, PathPrefix: "/", Pool: "pStable", Policy: config.RoutePolicy{
+			Traffic: config.TrafficConfig{
+				Enabled:      true,
+				StablePool:   "pStable",
+				CanaryPool:   canaryPool,
+				StableWeight: 100,
+				Ramp:         ramp,
+			},
+		}}
+	}
+	cfg := &config.Config{
+		Routes: []config.Route{
+			rampRoute("healthy", "healthy.local", "pCanary", config.RampConfig{Enabled: true, Steps: []int{10, 50, 100}, StepIntervalMS: 100}),
+			rampRoute("failing", "failing.local", "pBroken", config.RampConfig{Enabled: true, Steps: []int{50, 100}, StepIntervalMS: 60000, MinRequests: 5, MaxErrorRate: 0.2, OnBreach: "revert"}),
+		},
+		Pools: map[string]config.Pool{
+			"pStable": {Endpoints: []string{stableAddr}},
+			"pCanary": {Endpoints: []string{canaryAddr}},
+			"pBroken": {Endpoints: []string{brokenAddr}},
+		},
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	proxyServer := httptest.NewServer(&proxy.Handler{Store: runtime.NewStore(snap), Registry: reg, Engine: proxy.NewEngine(reg, nil, nil, nil, nil)})
+	defer proxyServer.Close()
+	metricsServer := httptest.NewServer(metrics.Handler())
+	defer metricsServer.Close()
+	client := &http.Client{Timeout: 2 * time.Second}
+
+	rampState := func(route string, weight float64, state string) func() error {
+		return func() error {
+			text := fetchMetrics(t, metricsServer)
+			if value, ok := metricValue(text, "proxy_traffic_ramp_canary_weight", map[string]string{"route": route}); !ok || value != weight {
+				return fmt.Errorf("expected %s ramp weight %v, got %v", route, weight, value)
+			}
+			if value, ok := metricValue(text, "proxy_traffic_ramp_transitions_total", map[string]string{"route": route, "state": state}); !ok || value != 1 {
+				return fmt.Errorf("expected %s ramp state %s, got %v", route, state, value)
+			}
+			return nil
+		}
+	}
+
+	testutil.Eventually(t, 3*time.Second, 20*time.Millisecond, rampState("healthy", 100, "complete"))
+	if counts := countVariantHeaders(t, client, proxyServer.URL, "healthy.local", "/", 10, nil); counts["canary"] != 10 {
+		t.Fatalf("expected completed ramp to route all traffic to canary, got %v", counts)
+	}
+
+	countVariantHeaders(t, client, proxyServer.URL, "failing.local", "/", 30, nil)
+	testutil.Eventually(t, 3*time.Second, 20*time.Millisecond, rampState("failing", 0, "reverted"))
+	if counts := countVariantHeaders(t, client, proxyServer.URL, "failing.local", "/", 10, nil); counts["stable"] != 10 {
+		t.Fatalf("expected reverted ramp to route all traffic to stable, got %v", counts)
+	}
+}
@@ -0,0 +1,193 @@
+package integration
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestResponseCompressionGzip(t *testing.T) {
+	largeBody := strings.Repeat(`{"key":"value"}`, 400)
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/small":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, `{"ok":true}`)
+		case "/image":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = io.WriteString(w, largeBody)
+		case "/stream":
+			w.Header().Set("Content-Type", "text/plain")
+			flusher := w.(http.Flusher)
+			for i := 0; i < 4; i++ {
+				_, _ = io.WriteString(w, largeBody[:len(largeBody)/4])
+				flusher.Flush()
+			}
+		default:
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Accept-Ranges", "bytes")
+			_, _ = io.WriteString(w, largeBody)
+		}
+	})
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, upstream)
+	defer closeUpstream()
+
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+
+	cfg, err := config.ParseJSON([]byte(fmt.Sprintf(`{
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1",
+  "policy": {"compression": {"enabled": true, "algorithms": ["gzip", "deflate", "br"], "min_size_bytes": 256}}}],
+"pools": {"p1": {"endpoints": ["%s"]}}
+}`, upstreamAddr)))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	store := runtime.NewStore(snap)
+	proxyServer := httptest.NewServer(&proxy.Handler{Store: store, Registry: reg, Engine: proxy.NewEngine(reg, nil, metrics, nil, nil), Metrics: metrics})
+	defer proxyServer.Close()
+
+	client := &http.Client{Timeout: 2 * time.Second, Transport: &http.Transport{DisableCompression: true}}
+	gzipHeaders := map[string]string{"Accept-Encoding": "br;q=0, gzip;q=0.8"}
+
+	resp, body := sendProxyRequestWithHeaders(t, client, proxyServer.URL, "example.local", http.MethodGet, "/", gzipHeaders)
+	if resp.Header.Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected gzip encoding, got %q", resp.Header.Get("Content-Encoding"))
+	}
+	if resp.Header.Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("expected Vary Accept-Encoding, got %q", resp.Header.Get("Vary"))
+	}
+	if len(body) >= len(largeBody) {
+		t.Fatalf("expected compressed body smaller than %d, got %d", len(largeBody), len(body))
+	}
+	if decoded := gunzip(t, body); decoded != largeBody {
+		t.Fatalf("decompressed body mismatch")
+	}
+	if resp.Header.Get("ETag") != `W/"v1"` || resp.Header.Get("Accept-Ranges") != "" {
+		t.Fatalf("expected weak ETag and no Accept-Ranges, got %q %q", resp.Header.Get("ETag"), resp.Header.Get("Accept-Ranges"))
+	}
+
+	resp, body = sendProxyRequestWithHeaders(t, client, proxyServer.URL, "example.local", http.MethodGet, "/stream", gzipHeaders)
+	if resp.Header.Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected streamed response compressed, got %q", resp.Header.Get("Content-Encoding"))
+	}
+	if decoded := gunzip(t, body); decoded != largeBody {
+		t.Fatalf("decompressed streamed body mismatch")
+	}
+
+	resp, body = sendProxyRequestWithHeaders(t, client, proxyServer.URL, "example.local", http.MethodGet, "/", map[string]string{"Accept-Encoding": "deflate, gzip;q=0"})
+	if resp.Header.Get("Content-Encoding") != "deflate" {
+		t.Fatalf("expected deflate encoding, got %q", resp.Header.Get("Content-Encoding"))
+	}
+	if len(body) >= len(largeBody) {
+		t.Fatalf("expected deflated body smaller than %d, got %d", len(largeBody), len(body))
+	}
+	if decoded := inflate(t, body); decoded != largeBody {
+		t.Fatalf("inflated body mismatch")
+	}
+
+	resp, body = sendProxyRequestWithHeaders(t, client, proxyServer.URL, "example.local", http.MethodGet, "/", map[string]string{"Accept-Encoding": "br"})
+	if resp.Header.Get("Content-Encoding") != "br" {
+		t.Fatalf("expected br encoding, got %q", resp.Header.Get("Content-Encoding"))
+	}
+	decoded, err := io.ReadAll(brotli.NewReader(strings.NewReader(string(body))))
+	if err != nil || string(decoded) != largeBody {
+		t.Fatalf("brotli body mismatch: %v", err)
+	}
+
+	for _, tc := range []struct {
+		path    string
+		headers map[string]string
+	}{
+		{path: "/small", headers: gzipHeaders},
+		{path: "/image", headers: gzipHeaders},
+		{path: "/", headers: nil},
+	} {
+		resp, _ := sendProxyRequestWithHeaders(t, client, proxyServer.URL, "example.local", http.MethodGet, tc.path, tc.headers)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200 for %s, got %d", tc.path, resp.StatusCode)
+		}
+		if encoding := resp.Header.Get("Content-Encoding"); encoding != "" {
+			t.Fatalf("expected %s uncompressed, got %q", tc.path, encoding)
+		}
+		if tc.path == "/" && (resp.Header.Get("ETag") != `"v1"` || resp.Header.Get("Accept-Ranges") != "bytes") {
+			t.Fatalf("expected validators untouched without compression, got %q %q", resp.Header.Get("ETag"), resp.Header.Get("Accept-Ranges"))
+		}
+	}
+
+	metricsServer := httptest.NewServer(metrics.Handler())
+	defer metricsServer.Close()
+	text := fetchMetrics(t, metricsServer)
+	if value, ok := metricValue(text, "proxy_compression_responses_total", map[string]string{"route": "r1", "algorithm": "gzip"}); !ok || value != 2 {
+		t.Fatalf("expected 2 gzip responses, got %v", value)
+	}
+	if value, ok := metricValue(text, "proxy_compression_bytes_saved_total", map[string]string{"route": "r1", "algorithm": "gzip"}); !ok || value <= 0 {
+		t.Fatalf("expected bytes saved metric, got %v", value)
+	}
+}
+
+func TestCompressionRejectsUnsupportedAlgorithm(t *testing.T) {
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	cfg, err := config.ParseJSON([]byte(`{
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1",
+  "policy": {"compression": {"enabled": true, "algorithms": ["zstd"]}}}],
+"pools": {"p1": {"endpoints": ["127.0.0.1:1"]}}
+}`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil); err == nil || !strings.Contains(err.Error(), "must be gzip, deflate or br") {
+		t.Fatalf("expected unsupported algorithm to be rejected, got %v", err)
+	}
+}
+
+func gunzip(t *testing.T, body []byte) string {
+	t.Helper()
+	reader, err := gzip.NewReader(strings.NewReader(string(body)))
+	if err != nil {
+		t.Fatalf("gzip reader: %v", err)
+	}
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("gzip read: %v", err)
+	}
+	return string(decoded)
+}
+
+func inflate(t *testing.T, body []byte) string {
+	t.Helper()
+	reader, err := zlib.NewReader(strings.NewReader(string(body)))
+	if err != nil {
+		t.Fatalf("zlib reader: %v", err)
+	}
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("zlib read: %v", err)
+	}
+	return string(decoded)
+}
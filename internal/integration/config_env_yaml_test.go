@@ -0,0 +1,115 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/provider"
+)
+
+func TestYAMLConfigWithEnvInterpolation(t *testing.T) {
+	t.Setenv("PROXY_TEST_UPSTREAM", "10.0.0.7:8443")
+	t.Setenv("PROXY_TEST_TIMEOUT", "750")
+	t.Setenv("PROXY_TEST_TOKEN", `s3"cr\et`)
+	t.Setenv("PROXY_TEST_HOST", "api.local\n    pool: {injected: true}")
+
+	path := filepath.Join(t.TempDir(), "proxy.yaml")
+	body := `
+listen_addr: "${PROXY_TEST_LISTEN:-127.0.0.1:0}"
+routes:
+  - id: api
+    host: ${PROXY_TEST_HOST}
+    path_prefix: /
+    pool: api
+    policy:
+      request_timeout_ms: ${PROXY_TEST_TIMEOUT}
+pools:
+  api:
+    endpoints: ["${PROXY_TEST_UPSTREAM}"]
+metadata:
+  author: "ops$$team"
+  git_sha: "${PROXY_TEST_TOKEN}"
+`
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := provider.NewFileProvider(path).Load(context.Background())
+	if err != nil {
+		t.Fatalf("load yaml: %v", err)
+	}
+	if cfg.ListenAddr != "127.0.0.1:0" {
+		t.Fatalf("expected default listen addr, got %q", cfg.ListenAddr)
+	}
+	if len(cfg.Routes) != 1 || cfg.Routes[0].Policy.RequestTimeoutMS != 750 {
+		t.Fatalf("expected numeric env substitution, got %+v", cfg.Routes)
+	}
+	if endpoints := cfg.Pools["api"].Endpoints; len(endpoints) != 1 || endpoints[0] != "10.0.0.7:8443" {
+		t.Fatalf("expected env endpoint, got %v", endpoints)
+	}
+	if cfg.Metadata.Author != "ops$team" {
+		t.Fatalf("expected escaped dollar, got %q", cfg.Metadata.Author)
+	}
+	if cfg.Routes[0].Host != "api.local\n    pool: {injected: true}" || cfg.Routes[0].Pool != "api" {
+		t.Fatalf("expected env value to stay a single scalar, got host %q pool %q", cfg.Routes[0].Host, cfg.Routes[0].Pool)
+	}
+	if cfg.Metadata.GitSHA != `s3"cr\et` {
+		t.Fatalf("expected quoted env value kept verbatim, got %q", cfg.Metadata.GitSHA)
+	}
+
+	missingPath := filepath.Join(t.TempDir(), "missing.yaml")
+	if err := os.WriteFile(missingPath, []byte("listen_addr: ${PROXY_TEST_MISSING_B}\n# ${PROXY_TEST_IN_COMMENT}\nmetadata:\n  author: \"${PROXY_TEST_MISSING_A}\"\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	_, err = provider.NewFileProvider(missingPath).Load(context.Background())
+	var envErr *config.EnvError
+	if !errors.As(err, &envErr) || strings.Join(envErr.Missing, ",") != "PROXY_TEST_MISSING_A,PROXY_TEST_MISSING_B" {
+		t.Fatalf("expected both missing variables reported, got %v", err)
+	}
+
+	jsonPath := filepath.Join(t.TempDir(), "proxy.json")
+	jsonBody := `{"routes": [{"id": "r", "host": "${PROXY_TEST_TOKEN}", "path_prefix": "/", "pool": "p"}], "pools": {"p": {"endpoints": ["${PROXY_TEST_UPSTREAM}"]}}}`
+	if err := os.WriteFile(jsonPath, []byte(jsonBody), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err = provider.NewFileProvider(jsonPath).Load(context.Background())
+	if err != nil {
+		t.Fatalf("load json: %v", err)
+	}
+	if cfg.Routes[0].Host != `s3"cr\et` {
+		t.Fatalf("expected value to be JSON-escaped on substitution, got %q", cfg.Routes[0].Host)
+	}
+}
+
+func TestConfigEnvInterpolationReportsMissingVars(t *testing.T) {
+	lookup := func(name string) (string, bool) {
+		if name == "EMPTY" {
+			return "", true
+		}
+		return "", false
+	}
+	_, err := config.InterpolateEnv([]byte(`{"a": "${ZED}", "b": "${ALPHA:?set ALPHA to the upstream}", "c": "${EMPTY:-fallback}", "d": "${EMPTY}"}`), lookup, nil)
+	var envErr *config.EnvError
+	if !errors.As(err, &envErr) {
+		t.Fatalf("expected env error, got %v", err)
+	}
+	if got := strings.Join(envErr.Missing, "; "); got != "ALPHA (set ALPHA to the upstream); ZED" {
+		t.Fatalf("unexpected missing list: %s", got)
+	}
+
+	out, err := config.InterpolateEnv([]byte(`${EMPTY:-fallback} $HOME $${LITERAL}`), lookup, nil)
+	if err != nil {
+		t.Fatalf("interpolate: %v", err)
+	}
+	if string(out) != "fallback $HOME ${LITERAL}" {
+		t.Fatalf("unexpected interpolation: %q", string(out))
+	}
+
+	if _, err := config.InterpolateEnv([]byte(`${BAD-NAME}`), lookup, nil); err == nil {
+		t.Fatalf("expected invalid name error")
+	}
+}
@@ -0,0 +1,117 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/apply"
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/provider"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestConfigReloadFromProviders(t *testing.T) {
+	addrA, closeA := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "A")
+	}))
+	defer closeA()
+	addrB, closeB := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "B")
+	}))
+	defer closeB()
+
+	fileConfig := func(addr string) string {
+		return fmt.Sprintf(`{"routes": [{"id": "file", "host": "file.local", "path_prefix": "/", "pool": "p"}], "pools": {"p": {"endpoints": ["%s"]}}}`, addr)
+	}
+	filePath := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(filePath, []byte(fileConfig(addrA)), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	initialCfg, err := config.ParseJSON([]byte(fileConfig(addrA)))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+	snap, err := runtime.BuildSnapshot(initialCfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	store := runtime.NewStore(snap)
+	proxyServer := httptest.NewServer(&proxy.Handler{Store: store, Registry: reg, Engine: proxy.NewEngine(reg, nil, nil, nil, nil)})
+	defer proxyServer.Close()
+
+	adminProvider := provider.NewAdminPush()
+	manager := apply.NewManager(apply.ManagerConfig{
+		Store:           store,
+		Registry:        reg,
+		TrafficRegistry: trafficReg,
+		Providers:       []provider.Provider{adminProvider, provider.NewFileProvider(filePath)},
+		AdminProvider:   adminProvider,
+	})
+	pushed := fmt.Sprintf(`{"routes": [{"id": "pushed", "host": "pushed.local", "path_prefix": "/", "pool": "q"}], "pools": {"q": {"endpoints": ["%s"]}}}`, addrA)
+	if _, err := manager.Apply(context.Background(), []byte(pushed), "admin", apply.ModeApply); err != nil {
+		t.Fatalf("admin apply: %v", err)
+	}
+
+	client := &http.Client{Timeout: 2 * time.Second}
+	body := func(host string) string {
+		t.Helper()
+		resp, data := sendProxyRequest(t, client, proxyServer.URL, host, http.MethodGet, "/")
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200 from %s, got %d", host, resp.StatusCode)
+		}
+		return string(data)
+	}
+	if got := body("file.local"); got != "A" {
+		t.Fatalf("expected initial file route on A, got %q", got)
+	}
+
+	if err := os.WriteFile(filePath, []byte(fileConfig(addrB)), 0o600); err != nil {
+		t.Fatalf("rewrite config: %v", err)
+	}
+	result, err := manager.Reload(context.Background(), "sighup")
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if current := store.Get(); current.Source != "sighup" || current.Version != result.Version {
+		t.Fatalf("expected reloaded snapshot to be live, got source=%s version=%s", current.Source, current.Version)
+	}
+	if got := body("file.local"); got != "B" {
+		t.Fatalf("expected reload to pick up rewritten file, got %q", got)
+	}
+	if got := body("pushed.local"); got != "A" {
+		t.Fatalf("expected admin-pushed route to survive reload, got %q", got)
+	}
+
+	if err := os.WriteFile(filePath, []byte(`{"routes": [`), 0o600); err != nil {
+		t.Fatalf("write broken config: %v", err)
+	}
+	if _, err := manager.Reload(context.Background(), "sighup"); err == nil {
+		t.Fatalf("expected broken file to fail reload")
+	}
+	if current := store.Get(); current.Version != result.Version {
+		t.Fatalf("expected failed reload to keep the previous snapshot")
+	}
+	if got := body("file.local"); got != "B" {
+		t.Fatalf("expected traffic to stay on last good config, got %q", got)
+	}
+
+	if _, err := apply.NewManager(apply.ManagerConfig{Store: store}).Reload(context.Background(), "sighup"); err != apply.ErrNoProviders {
+		t.Fatalf("expected reload without providers to be refused, got %v", err)
+	}
+}
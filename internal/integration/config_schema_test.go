@@ -0,0 +1,102 @@
+package integration
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"modern_reverse_proxy/internal/config"
+)
+
+func TestConfigParseRejectsUnknownFields(t *testing.T) {
+	_, err := config.ParseJSON([]byte(`{"routes": [{"id": "r1", "host": "a.local", "path_prefix": "/", "pool": "p", "timeout_ms": 100}]}`))
+	if err == nil || !strings.Contains(err.Error(), `unknown field "timeout_ms"`) {
+		t.Fatalf("expected unknown field error, got %v", err)
+	}
+	if _, err := config.ParseYAML([]byte("pools:\n  p:\n    endpoint: [\"127.0.0.1:1\"]\n")); err == nil || !strings.Contains(err.Error(), "endpoint") {
+		t.Fatalf("expected yaml unknown field error, got %v", err)
+	}
+	if _, err := config.ParseJSON([]byte(`{"routes": []} {"pools": {}}`)); err == nil {
+		t.Fatalf("expected trailing document to be rejected")
+	}
+}
+
+func TestConfigParseValidatesEnumsAndRanges(t *testing.T) {
+	cases := []struct {
+		body string
+		path string
+	}{
+		{`{"routes": [{"id": "r1", "policy": {"auth": "basic"}}]}`, "routes[0].policy.auth"},
+		{`{"pools": {"p1": {"health": {"type": "udp"}}}}`, `pools["p1"].health.type`},
+		{`{"routes": [{"id": "r1", "policy": {"traffic": {"canary_weight": 140}}}]}`, "routes[0].policy.traffic.canary_weight"},
+		{`{"tls": {"min_version": "1.0"}}`, "tls.min_version"},
+	}
+	for _, tc := range cases {
+		_, err := config.ParseJSON([]byte(tc.body))
+		var schemaErr *config.SchemaError
+		if !errors.As(err, &schemaErr) {
+			t.Fatalf("expected schema error for %s, got %v", tc.body, err)
+		}
+		if schemaErr.Path != tc.path {
+			t.Fatalf("expected path %s, got %s", tc.path, schemaErr.Path)
+		}
+	}
+
+	if _, err := config.ParseJSON([]byte(`{"routes": [{"id": "r1", "policy": {"auth": "HMAC", "plugins": {"filters": [{"failure_mode": "fail_open"}]}}}]}`)); err != nil {
+		t.Fatalf("expected valid enums to parse, got %v", err)
+	}
+}
+
+func TestConfigExamplesMatchSchema(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("..", "..", "configs", "examples", "*.json"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("expected example configs, got %v (%v)", files, err)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("read %s: %v", file, err)
+		}
+		if _, err := config.ParseJSON(data); err != nil {
+			t.Fatalf("example %s does not match schema: %v", file, err)
+		}
+	}
+}
+
+func TestConfigSchemaDocument(t *testing.T) {
+	raw, err := json.Marshal(config.Schema())
+	if err != nil {
+		t.Fatalf("marshal schema: %v", err)
+	}
+	var doc struct {
+		Schema               string                 `json:"$schema"`
+		AdditionalProperties bool                   `json:"additionalProperties"`
+		Properties           map[string]interface{} `json:"properties"`
+		Defs                 map[string]struct {
+			Properties map[string]map[string]interface{} `json:"properties"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		t.Fatalf("decode schema: %v", err)
+	}
+	if doc.Schema != config.SchemaDialect || doc.AdditionalProperties {
+		t.Fatalf("unexpected schema header: %s additional=%v", doc.Schema, doc.AdditionalProperties)
+	}
+	for _, field := range []string{"listen_addr", "routes", "pools", "streams", "include"} {
+		if _, ok := doc.Properties[field]; !ok {
+			t.Fatalf("expected top-level property %s", field)
+		}
+	}
+	auth := doc.Defs["RoutePolicy"].Properties["auth"]
+	enum, _ := auth["enum"].([]interface{})
+	if len(enum) != 4 || enum[1] != "oidc" {
+		t.Fatalf("expected auth enum in schema, got %v", auth)
+	}
+	weight := doc.Defs["TrafficConfig"].Properties["canary_weight"]
+	if weight["minimum"] != float64(0) || weight["maximum"] != float64(100) {
+		t.Fatalf("expected weight range in schema, got %v", weight)
+	}
+}
@@ -0,0 +1,105 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"modern_reverse_proxy/internal/apply"
+	"modern_reverse_proxy/internal/bundle"
+	"modern_reverse_proxy/internal/configcheck"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+func TestConfigCheckReportsWarningsAndErrors(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.yaml")
+	writeConfigFiles(t, dir, map[string]string{
+		"valid.yaml": `
+routes:
+  - id: a
+    host: a.local
+    path_prefix: /
+    pool: shared
+    policy:
+      cache: {enabled: true, ttl_ms: 7200000}
+  - id: b
+    host: b.local
+    path_prefix: /
+    pool: shared
+pools:
+  shared:
+    endpoints: ["127.0.0.1:9"]
+`,
+		"invalid.json": `{"routes": [{"id": "a", "host": "a.local", "path_prefix": "/", "pool": "missing"}]}`,
+	})
+
+	report := configcheck.Run(context.Background(), configcheck.Input{ConfigFile: valid})
+	if !report.OK() {
+		t.Fatalf("expected valid config, got %v", report.Errors)
+	}
+	if report.Routes != 2 || report.Pools != 1 || len(report.Warnings) == 0 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	var out bytes.Buffer
+	report.Print(&out)
+	if !strings.Contains(out.String(), "warning: pool \"shared\" breaker disabled") || !strings.Contains(out.String(), "result: ok (2 routes, 1 pools") {
+		t.Fatalf("unexpected output:\n%s", out.String())
+	}
+
+	report = configcheck.Run(context.Background(), configcheck.Input{ConfigFile: filepath.Join(dir, "invalid.json")})
+	if report.OK() || !strings.Contains(strings.Join(report.Errors, "\n"), `missing pool "missing"`) {
+		t.Fatalf("expected missing pool error, got %+v", report)
+	}
+	out.Reset()
+	report.Print(&out)
+	if !strings.Contains(out.String(), "result: invalid (1 errors") {
+		t.Fatalf("unexpected output:\n%s", out.String())
+	}
+
+	report = configcheck.Run(context.Background(), configcheck.Input{ConfigFile: valid, ConfigDir: dir})
+	if report.OK() {
+		t.Fatalf("expected conflicting inputs to be rejected")
+	}
+}
+
+func TestConfigCheckVerifiesBundles(t *testing.T) {
+	trusted := testutil.WriteEd25519KeyPair(t, "trusted")
+	rogue := testutil.WriteEd25519KeyPair(t, "rogue")
+	configJSON := []byte(`{"routes": [{"id": "a", "host": "a.local", "path_prefix": "/", "pool": "p"}], "pools": {"p": {"endpoints": ["127.0.0.1:9"]}}}`)
+
+	writeBundle := func(name string, keyPair testutil.KeyPair) string {
+		t.Helper()
+		item, err := bundle.NewSignedBundle(configJSON, bundle.Meta{Version: name, Source: "ci"}, keyPair.PrivateKey)
+		if err != nil {
+			t.Fatalf("sign bundle: %v", err)
+		}
+		path := filepath.Join(t.TempDir(), name+".json")
+		if err := os.WriteFile(path, mustMarshalJSON(t, item), 0o600); err != nil {
+			t.Fatalf("write bundle: %v", err)
+		}
+		return path
+	}
+	keyring := bundle.NewKeyring(trusted.PublicKey)
+
+	report := configcheck.Run(context.Background(), configcheck.Input{BundleFile: writeBundle("good", trusted), Keyring: keyring})
+	if !report.OK() || report.Routes != 1 {
+		t.Fatalf("expected signed bundle to validate, got %+v", report)
+	}
+	if report.Version != apply.ConfigVersion(configJSON) {
+		t.Fatalf("expected version of bundle config bytes, got %s", report.Version)
+	}
+
+	report = configcheck.Run(context.Background(), configcheck.Input{BundleFile: writeBundle("bad", rogue), Keyring: keyring})
+	if report.OK() || !strings.Contains(report.Errors[0], "bad_sig") {
+		t.Fatalf("expected signature failure, got %+v", report)
+	}
+
+	report = configcheck.Run(context.Background(), configcheck.Input{BundleFile: writeBundle("unverified", rogue)})
+	if !report.OK() || len(report.Warnings) == 0 || !strings.Contains(report.Warnings[0], "not verified") {
+		t.Fatalf("expected unverified warning, got %+v", report)
+	}
+}
@@ -0,0 +1,160 @@
+package integration
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+func TestListenerKeepAliveLimits(t *testing.T) {
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, nil)
+	defer closeUpstream()
+
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+	obs.SetDefaultMetrics(metrics)
+	defer obs.SetDefaultMetrics(nil)
+
+	cfgJSON := buildProxyConfig(upstreamAddr, `"limits": {"read_header_timeout_ms": 1000, "max_requests_per_connection": 2, "keepalive_timeout_ms": 5000}`)
+	serverHandle, _, _, _ := startProxy(t, cfgJSON)
+	baseURL := "http://" + serverHandle.HTTPAddr
+	client := &http.Client{Timeout: 2 * time.Second, Transport: &http.Transport{}}
+
+	var closed []bool
+	for i := 0; i < 3; i++ {
+		resp, _ := sendProxyRequest(t, client, baseURL, "example.local", http.MethodGet, "/")
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200, got %d", resp.StatusCode)
+		}
+		closed = append(closed, resp.Close)
+	}
+	if closed[0] || !closed[1] || closed[2] {
+		t.Fatalf("expected connection close on every second request, got %v", closed)
+	}
+
+	metricsServer := httptest.NewServer(metrics.Handler())
+	defer metricsServer.Close()
+	text := fetchMetrics(t, metricsServer)
+	if value, ok := metricValue(text, "proxy_listener_connections_accepted_total", map[string]string{"listener": "http"}); !ok || value != 2 {
+		t.Fatalf("expected two accepted connections, got %v", value)
+	}
+	if value, ok := metricValue(text, "proxy_listener_active_connections", map[string]string{"listener": "http"}); !ok || value != 0 {
+		t.Fatalf("expected no active connections, got %v", value)
+	}
+	if value, ok := metricValue(text, "proxy_listener_open_connections", map[string]string{"listener": "http"}); !ok || value != 1 {
+		t.Fatalf("expected one idle keep-alive connection, got %v", value)
+	}
+}
+
+func TestListenerKeepAliveDisabled(t *testing.T) {
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, nil)
+	defer closeUpstream()
+
+	cfgJSON := buildProxyConfig(upstreamAddr, `"limits": {"read_header_timeout_ms": 1000, "keepalive_timeout_ms": -1}`)
+	serverHandle, _, _, _ := startProxy(t, cfgJSON)
+	client := &http.Client{Timeout: 2 * time.Second, Transport: &http.Transport{}}
+
+	resp, _ := sendProxyRequest(t, client, "http://"+serverHandle.HTTPAddr, "example.local", http.MethodGet, "/")
+	if resp.StatusCode != http.StatusOK || !resp.Close {
+		t.Fatalf("expected connection close with keep-alives disabled, got %d close=%v", resp.StatusCode, resp.Close)
+	}
+}
+
+func TestTLSHandshakeMetrics(t *testing.T) {
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, nil)
+	defer closeUpstream()
+
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+	obs.SetDefaultMetrics(metrics)
+	defer obs.SetDefaultMetrics(nil)
+
+	serverCert := testutil.WriteSelfSignedCert(t, "example.local")
+	cfg := &config.Config{
+		TLS: config.TLSConfig{
+			Enabled: true,
+			Addr:    "127.0.0.1:0",
+			Certs: []config.TLSCert{
+				{ServerName: "example.local", CertFile: serverCert.CertFile, KeyFile: serverCert.KeyFile},
+			},
+		},
+		Limits: config.LimitsConfig{ReadHeaderTimeoutMS: 300},
+		Routes: []config.Route{
+			{ID: "r1", Host: "example.local", PathPrefix: "/", Pool: "p1"},
+		},
+		Pools: map[string]config.Pool{
+			"p1": {Endpoints: []string{upstreamAddr}},
+		},
+	}
+	proxyServer, _, _ := startTLSProxy(t, cfg)
+
+	plain, err := net.Dial("tcp", proxyServer.TLSAddr)
+	if err != nil {
+		t.Fatalf("dial plain: %v", err)
+	}
+	defer plain.Close()
+	_, _ = io.WriteString(plain, "GET / HTTP/1.1\r\nHost: example.local\r\n\r\n")
+
+	stalled, err := net.Dial("tcp", proxyServer.TLSAddr)
+	if err != nil {
+		t.Fatalf("dial stalled: %v", err)
+	}
+	defer stalled.Close()
+
+	client := &http.Client{
+		Timeout: 2 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: x509CertPool(t, serverCert.Cert), ServerName: "example.local"},
+		},
+	}
+	resp, _ := sendProxyRequest(t, client, "https://"+proxyServer.TLSAddr, "example.local", http.MethodGet, "/")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 while other handshakes are pending, got %d", resp.StatusCode)
+	}
+
+	metricsServer := httptest.NewServer(metrics.Handler())
+	defer metricsServer.Close()
+	testutil.Eventually(t, 2*time.Second, 20*time.Millisecond, func() error {
+		text := fetchMetrics(t, metricsServer)
+		if value, ok := metricValue(text, "proxy_tls_handshake_errors_total", map[string]string{"listener": "tls", "reason": "not_tls"}); !ok || value != 1 {
+			return fmt.Errorf("expected one not_tls handshake error, got %v", value)
+		}
+		if value, ok := metricValue(text, "proxy_tls_handshake_errors_total", map[string]string{"listener": "tls", "reason": "timeout"}); !ok || value != 1 {
+			return fmt.Errorf("expected one handshake timeout, got %v", value)
+		}
+		return nil
+	})
+	text := fetchMetrics(t, metricsServer)
+	if value, ok := metricValue(text, "proxy_tls_handshake_duration_seconds_count", map[string]string{"listener": "tls"}); !ok || value != 1 {
+		t.Fatalf("expected one successful handshake observed, got %v", value)
+	}
+	if value, ok := metricValue(text, "proxy_listener_connections_accepted_total", map[string]string{"listener": "tls"}); !ok || value != 1 {
+		t.Fatalf("expected only the completed handshake to be accepted, got %v", value)
+	}
+}
+
+func TestListenerKeepAliveValidation(t *testing.T) {
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	for _, limitsCfg := range []string{
+		`{"max_requests_per_connection": -1}`,
+		`{"keepalive_timeout_ms": -2}`,
+	} {
+		cfg, err := config.ParseJSON([]byte(buildProxyConfig("127.0.0.1:1", `"limits": `+limitsCfg)))
+		if err != nil {
+			t.Fatalf("parse config: %v", err)
+		}
+		if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil); err == nil {
+			t.Fatalf("expected limits %s to be rejected", limitsCfg)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package integration
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+func TestCORSPolicy(t *testing.T) {
+	var upstreamHits int32
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&upstreamHits, 1)
+		w.Header().Set("Access-Control-Allow-Origin", "https://upstream.example")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer closeUpstream()
+
+	cfgJSON := `{
+"listen_addr": "127.0.0.1:0",
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1", "policy": {"cors": {
+  "enabled": true,
+  "allowed_origins": ["https://app.example.com", "https://*.example.org"],
+  "allowed_methods": ["GET", "PUT"],
+  "allowed_headers": ["Content-Type", "X-Api-Version"],
+  "exposed_headers": ["X-Request-Id"],
+  "allow_credentials": true,
+  "max_age_seconds": 600
+}}}],
+"pools": {"p1": {"endpoints": ["` + upstreamAddr + `"]}}
+}`
+	serverHandle, _, _, _ := startProxy(t, cfgJSON)
+
+	client := &http.Client{Timeout: 2 * time.Second}
+	send := func(method string, headers map[string]string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, "http://"+serverHandle.HTTPAddr+"/", nil)
+		if err != nil {
+			t.Fatalf("new request: %v", err)
+		}
+		req.Host = "example.local"
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	resp := send(http.MethodOptions, map[string]string{
+		"Origin":                         "https://app.example.com",
+		"Access-Control-Request-Method":  "PUT",
+		"Access-Control-Request-Headers": "content-type, x-api-version",
+	})
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected preflight 204, got %d", resp.StatusCode)
+	}
+	if resp.Header.Get("Access-Control-Allow-Origin") != "https://app.example.com" || resp.Header.Get("Access-Control-Allow-Credentials") != "true" {
+		t.Fatalf("unexpected preflight origin headers %v", resp.Header)
+	}
+	if resp.Header.Get("Access-Control-Allow-Methods") != "GET, PUT" || resp.Header.Get("Access-Control-Allow-Headers") != "Content-Type, X-Api-Version" || resp.Header.Get("Access-Control-Max-Age") != "600" {
+		t.Fatalf("unexpected preflight headers %v", resp.Header)
+	}
+	if atomic.LoadInt32(&upstreamHits) != 0 {
+		t.Fatalf("expected preflight to be answered by the proxy")
+	}
+
+	for _, headers := range []map[string]string{
+		{"Origin": "https://evil.example.com", "Access-Control-Request-Method": "GET"},
+		{"Origin": "https://app.example.com", "Access-Control-Request-Method": "DELETE"},
+		{"Origin": "https://app.example.com", "Access-Control-Request-Method": "GET", "Access-Control-Request-Headers": "X-Secret"},
+	} {
+		if resp := send(http.MethodOptions, headers); resp.StatusCode != http.StatusForbidden || resp.Header.Get("Access-Control-Allow-Origin") != "" {
+			t.Fatalf("expected preflight %v to be rejected, got %d", headers, resp.StatusCode)
+		}
+	}
+
+	resp = send(http.MethodGet, map[string]string{"Origin": "https://api.example.org"})
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Access-Control-Allow-Origin") != "https://api.example.org" {
+		t.Fatalf("expected wildcard origin to be allowed, got %d %v", resp.StatusCode, resp.Header)
+	}
+	if resp.Header.Get("Access-Control-Expose-Headers") != "X-Request-Id" || !strings.Contains(strings.Join(resp.Header.Values("Vary"), ","), "Origin") {
+		t.Fatalf("unexpected cors response headers %v", resp.Header)
+	}
+
+	resp = send(http.MethodGet, map[string]string{"Origin": "https://evil.example.com"})
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Access-Control-Allow-Origin") != "https://upstream.example" {
+		t.Fatalf("expected disallowed origin to pass through untouched, got %v", resp.Header)
+	}
+}
+
+func TestCORSValidation(t *testing.T) {
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	for _, cors := range []string{
+		`{"enabled": true}`,
+		`{"enabled": true, "allowed_origins": ["*"], "allow_credentials": true}`,
+		`{"enabled": true, "allowed_origins": ["app.example.com"]}`,
+		`{"enabled": true, "allowed_origins": ["https://[.example.com"]}`,
+		`{"enabled": true, "allowed_origins": ["*"], "allowed_headers": ["Bad Header"]}`,
+		`{"enabled": true, "allowed_origins": ["*"], "max_age_seconds": -1}`,
+	} {
+		cfg, err := config.ParseJSON([]byte(`{
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1", "policy": {"cors": ` + cors + `}}],
+"pools": {"p1": {"endpoints": ["127.0.0.1:1"]}}
+}`))
+		if err != nil {
+			t.Fatalf("parse config: %v", err)
+		}
+		if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil); err == nil {
+			t.Fatalf("expected cors %s to be rejected", cors)
+		}
+	}
+}
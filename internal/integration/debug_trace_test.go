@@ -0,0 +1,164 @@
+package integration
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/logging"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+func TestDebugTraceSignedHeader(t *testing.T) {
+	t.Setenv("DEBUG_TRACE_SECRET", "trace-secret")
+	previous := slog.Default()
+	output := &lockedBuffer{}
+	logging.Configure(output, true)
+	logging.SetLevel(slog.LevelWarn)
+	t.Cleanup(func() {
+		logging.SetLevel(slog.LevelInfo)
+		logging.Configure(os.Stderr, false)
+		slog.SetDefault(previous)
+	})
+
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Debug-Trace") != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer closeUpstream()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	cfg, err := config.ParseJSON([]byte(`{
+"debug_trace": {"enabled": true, "token_env": "DEBUG_TRACE_SECRET"},
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1", "policy": {"retry": {"enabled": true, "max_attempts": 2}}}],
+"pools": {"p1": {"endpoints": ["` + upstreamAddr + `"]}}
+}`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	proxyServer := httptest.NewServer(&proxy.Handler{
+		Store:    runtime.NewStore(snap),
+		Registry: reg,
+		Engine:   proxy.NewEngine(reg, nil, nil, nil, nil),
+	})
+	defer proxyServer.Close()
+	client := &http.Client{Timeout: 2 * time.Second}
+
+	validToken := proxy.SignDebugTraceToken([]byte("trace-secret"), time.Now().Add(time.Minute))
+	for _, token := range []string{
+		"",
+		proxy.SignDebugTraceToken([]byte("wrong-secret"), time.Now().Add(time.Minute)),
+		proxy.SignDebugTraceToken([]byte("trace-secret"), time.Now().Add(-time.Minute)),
+		validToken,
+	} {
+		headers := map[string]string{proxy.RequestIDHeader: "trace-" + token}
+		if token != "" {
+			headers["X-Debug-Trace"] = token
+		}
+		resp, body := sendProxyRequestWithHeaders(t, client, proxyServer.URL, "example.local", http.MethodGet, "/orders", headers)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200 with trace header stripped, got %d %s", resp.StatusCode, body)
+		}
+	}
+
+	phases := map[string]map[string]interface{}{}
+	rejected := 0
+	for _, line := range output.Lines() {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("parse log line %q: %v", line, err)
+		}
+		switch {
+		case entry["msg"] == "debug_trace_rejected":
+			rejected++
+		case entry["component"] == "trace":
+			if entry["request_id"] != "trace-"+validToken || entry["level"] != "DEBUG" {
+				t.Fatalf("unexpected trace entry %v", entry)
+			}
+			phases[entry["msg"].(string)] = entry
+		default:
+			t.Fatalf("unexpected log entry %v", entry)
+		}
+	}
+	if rejected != 0 {
+		t.Fatalf("expected rejected trace tokens to stay below warn, got %d", rejected)
+	}
+	for _, phase := range []string{"request_start", "route_match", "pool_select", "upstream_pick", "upstream_attempt", "request_complete"} {
+		if phases[phase] == nil {
+			t.Fatalf("expected %s phase in trace, got %v", phase, phases)
+		}
+	}
+	if phases["route_match"]["route"] != "r1" || phases["upstream_pick"]["addr"] != upstreamAddr || phases["upstream_attempt"]["status"] != float64(200) {
+		t.Fatalf("unexpected trace details %v", phases)
+	}
+	if phases["request_complete"]["status"] != float64(200) {
+		t.Fatalf("unexpected request_complete %v", phases["request_complete"])
+	}
+
+	logging.SetComponentLevel("proxy", slog.LevelDebug)
+	defer logging.ResetComponentLevel("proxy")
+	seen := len(output.Lines())
+	badToken := proxy.SignDebugTraceToken([]byte("wrong-secret"), time.Now().Add(time.Minute))
+	resp, body := sendProxyRequestWithHeaders(t, client, proxyServer.URL, "example.local", http.MethodGet, "/orders", map[string]string{
+		proxy.RequestIDHeader: "trace-rejected",
+		"X-Debug-Trace":       badToken,
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 with rejected trace token, got %d %s", resp.StatusCode, body)
+	}
+	rejected = 0
+	for _, line := range output.Lines()[seen:] {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("parse log line %q: %v", line, err)
+		}
+		if entry["msg"] == "debug_trace_rejected" {
+			if entry["level"] != "DEBUG" || entry["request_id"] != "trace-rejected" {
+				t.Fatalf("unexpected rejection entry %v", entry)
+			}
+			rejected++
+		}
+	}
+	if rejected != 1 {
+		t.Fatalf("expected rejected trace token at debug level, got %d", rejected)
+	}
+}
+
+func TestDebugTraceValidation(t *testing.T) {
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	for _, trace := range []string{
+		`{"enabled": true}`,
+		`{"enabled": true, "token_env": "DEBUG_TRACE_SECRET_UNSET"}`,
+		`{"enabled": true, "token_env": "PATH", "header": "Bad Header"}`,
+	} {
+		cfg, err := config.ParseJSON([]byte(`{
+"debug_trace": ` + trace + `,
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1"}],
+"pools": {"p1": {"endpoints": ["127.0.0.1:1"]}}
+}`))
+		if err != nil {
+			t.Fatalf("parse config: %v", err)
+		}
+		if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil); err == nil {
+			t.Fatalf("expected debug_trace %s to be rejected", trace)
+		}
+	}
+}
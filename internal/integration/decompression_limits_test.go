@@ -0,0 +1,153 @@
+package integration
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestDecompressionGlobalLimits(t *testing.T) {
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, nil)
+	defer closeUpstream()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+	cfg, err := config.ParseJSON([]byte(fmt.Sprintf(`{
+"limits": {"max_body_bytes": 4194304, "max_decompressed_body_bytes": 262144, "max_decompression_ratio": 10},
+"routes": [
+  {"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1",
+   "policy": {"request_decompression": {"enabled": true, "max_ratio": 1000}}}
+],
+"pools": {"p1": {"endpoints": ["%s"]}}
+}`, upstreamAddr)))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	proxyServer := httptest.NewServer(&proxy.Handler{Store: runtime.NewStore(snap), Registry: reg, Engine: proxy.NewEngine(reg, nil, metrics, nil, nil), Metrics: metrics})
+	defer proxyServer.Close()
+	client := &http.Client{Timeout: 2 * time.Second}
+
+	ratioBomb := gzipBytes(t, make([]byte, 200*1024))
+	resp, body := sendEncodedRequest(t, client, proxyServer.URL, "example.local", "gzip", ratioBomb)
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 from global ratio cap, got %d", resp.StatusCode)
+	}
+	assertProxyError(t, resp, body, "decompression_bomb")
+
+	random := make([]byte, 300*1024)
+	if _, err := rand.Read(random); err != nil {
+		t.Fatalf("random: %v", err)
+	}
+	resp, body = sendEncodedRequest(t, client, proxyServer.URL, "example.local", "gzip", gzipBytes(t, random))
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 from absolute decompressed cap, got %d", resp.StatusCode)
+	}
+	assertProxyError(t, resp, body, "decompression_bomb")
+
+	resp, _ = sendEncodedRequest(t, client, proxyServer.URL, "example.local", "gzip", gzipBytes(t, random[:64*1024]))
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 within limits, got %d", resp.StatusCode)
+	}
+
+	metricsServer := httptest.NewServer(metrics.Handler())
+	defer metricsServer.Close()
+	text := fetchMetrics(t, metricsServer)
+	if value, ok := metricValue(text, "proxy_decompression_rejected_total", map[string]string{"reason": "ratio"}); !ok || value != 1 {
+		t.Fatalf("expected ratio rejection metric, got %v", value)
+	}
+	if value, ok := metricValue(text, "proxy_decompression_rejected_total", map[string]string{"reason": "size"}); !ok || value != 1 {
+		t.Fatalf("expected size rejection metric, got %v", value)
+	}
+
+	badLimits := config.LimitsConfig{MaxDecompressionRatio: -1}
+	cfg.Limits = badLimits
+	if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg); err == nil {
+		t.Fatalf("expected negative max_decompression_ratio to be rejected")
+	}
+}
+
+func TestDecompressionRouteBodyLimit(t *testing.T) {
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, nil)
+	defer closeUpstream()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+	cfg, err := config.ParseJSON([]byte(fmt.Sprintf(`{
+"limits": {"max_body_bytes": 4194304, "max_decompressed_body_bytes": 1048576},
+"routes": [
+  {"id": "buffered", "host": "buffered.local", "path_prefix": "/", "pool": "p1",
+   "policy": {"request_body": {"max_body_bytes": 1024}, "request_decompression": {"enabled": true, "max_ratio": 1000}}},
+  {"id": "stream", "host": "stream.local", "path_prefix": "/", "pool": "p1",
+   "policy": {"request_body": {"mode": "stream", "max_body_bytes": 1024}, "request_decompression": {"enabled": true, "max_ratio": 1000}}}
+],
+"pools": {"p1": {"endpoints": ["%s"]}}
+}`, upstreamAddr)))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	proxyServer := httptest.NewServer(&proxy.Handler{Store: runtime.NewStore(snap), Registry: reg, Engine: proxy.NewEngine(reg, nil, nil, nil, nil)})
+	defer proxyServer.Close()
+	client := &http.Client{Timeout: 2 * time.Second}
+
+	resp, body := sendEncodedRequest(t, client, proxyServer.URL, "buffered.local", "gzip", gzipBytes(t, make([]byte, 4096)))
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 from route body limit on decompressed size, got %d", resp.StatusCode)
+	}
+	assertProxyError(t, resp, body, "decompression_bomb")
+
+	var padded bytes.Buffer
+	writer := gzip.NewWriter(&padded)
+	writer.Extra = make([]byte, 4096)
+	if _, err := writer.Write([]byte("small")); err != nil {
+		t.Fatalf("gzip write: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("gzip close: %v", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, proxyServer.URL+"/", io.MultiReader(bytes.NewReader(padded.Bytes())))
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	req.Host = "stream.local"
+	req.Header.Set("Content-Encoding", "gzip")
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("do request: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 when the compressed stream exceeds the route limit, got %d %s", resp.StatusCode, body)
+	}
+	assertProxyError(t, resp, body, "request_too_large")
+}
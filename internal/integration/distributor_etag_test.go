@@ -0,0 +1,179 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/apply"
+	"modern_reverse_proxy/internal/bundle"
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/distributor"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/pull"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/rollout"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestDistributorETagNotModified(t *testing.T) {
+	keyPair := testutil.WriteEd25519KeyPair(t, "bundle")
+	configJSON := `{"listen_addr": "127.0.0.1:0", "routes": [], "pools": {}}`
+	signed, err := bundle.NewSignedBundle([]byte(configJSON), bundle.Meta{Version: "etag-v1", Source: "distributor"}, keyPair.PrivateKey)
+	if err != nil {
+		t.Fatalf("sign bundle: %v", err)
+	}
+	storage := bundle.NewMemoryStorage()
+	if err := storage.Put(signed); err != nil {
+		t.Fatalf("store bundle: %v", err)
+	}
+	distributorServer := httptest.NewServer(distributor.NewHandler(distributor.Config{Storage: storage}))
+	defer distributorServer.Close()
+
+	resp, err := distributorServer.Client().Get(distributorServer.URL + "/bundles/latest")
+	if err != nil {
+		t.Fatalf("get latest: %v", err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || etag == "" {
+		t.Fatalf("expected 200 with etag, got %d %q", resp.StatusCode, etag)
+	}
+	if etag != signed.ETag() {
+		t.Fatalf("expected etag %q, got %q", signed.ETag(), etag)
+	}
+
+	for _, path := range []string{"/bundles/latest", "/bundles/etag-v1"} {
+		req, err := http.NewRequest(http.MethodGet, distributorServer.URL+path, nil)
+		if err != nil {
+			t.Fatalf("new request: %v", err)
+		}
+		req.Header.Set("If-None-Match", etag)
+		resp, err := distributorServer.Client().Do(req)
+		if err != nil {
+			t.Fatalf("conditional get: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotModified {
+			t.Fatalf("expected 304 for %s, got %d", path, resp.StatusCode)
+		}
+		if len(body) != 0 {
+			t.Fatalf("expected empty body for %s, got %q", path, string(body))
+		}
+	}
+}
+
+func TestPullerUsesConditionalRequests(t *testing.T) {
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, nil)
+	defer closeUpstream()
+
+	configJSON := fmt.Sprintf(`{
+"listen_addr": "127.0.0.1:0",
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1"}],
+"pools": {"p1": {"endpoints": ["%s"]}}
+}`, upstreamAddr)
+	keyPair := testutil.WriteEd25519KeyPair(t, "bundle")
+	meta := bundle.Meta{
+		Version:   "etag-pull-v1",
+		CreatedAt: time.Now().UTC().Format(time.RFC3339Nano),
+		Source:    "distributor",
+	}
+	signed, err := bundle.NewSignedBundle([]byte(configJSON), meta, keyPair.PrivateKey)
+	if err != nil {
+		t.Fatalf("sign bundle: %v", err)
+	}
+	storage := bundle.NewMemoryStorage()
+	if err := storage.Put(signed); err != nil {
+		t.Fatalf("store bundle: %v", err)
+	}
+
+	var fullResponses atomic.Int64
+	var notModified atomic.Int64
+	distributorHandler := distributor.NewHandler(distributor.Config{Storage: storage})
+	distributorServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := httptest.NewRecorder()
+		distributorHandler.ServeHTTP(recorder, r)
+		switch recorder.Code {
+		case http.StatusOK:
+			fullResponses.Add(1)
+		case http.StatusNotModified:
+			notModified.Add(1)
+		}
+		for key, values := range recorder.Header() {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(recorder.Code)
+		_, _ = w.Write(recorder.Body.Bytes())
+	}))
+	defer distributorServer.Close()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+	obs.SetDefaultMetrics(metrics)
+	defer obs.SetDefaultMetrics(nil)
+
+	cfg, err := config.ParseJSON([]byte(configJSON))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	store := runtime.NewStore(snap)
+	applyManager := apply.NewManager(apply.ManagerConfig{
+		Store:           store,
+		Registry:        reg,
+		TrafficRegistry: trafficReg,
+	})
+	rolloutManager := rollout.NewManager(rollout.Config{
+		ApplyManager:     applyManager,
+		Store:            store,
+		Metrics:          metrics,
+		LockedBake:       20 * time.Millisecond,
+		ErrorRateWindow:  500 * time.Millisecond,
+		ErrorRatePercent: 50,
+	})
+	puller := pull.NewPuller(pull.Config{
+		Enabled:        true,
+		BaseURL:        distributorServer.URL,
+		Interval:       20 * time.Millisecond,
+		PublicKey:      keyPair.PublicKey,
+		RolloutManager: rolloutManager,
+		Store:          store,
+		HTTPClient:     distributorServer.Client(),
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go puller.Run(ctx)
+
+	testutil.Eventually(t, 2*time.Second, 20*time.Millisecond, func() error {
+		if notModified.Load() < 3 {
+			return fmt.Errorf("expected conditional responses, got %d", notModified.Load())
+		}
+		return nil
+	})
+	if fullResponses.Load() != 1 {
+		t.Fatalf("expected a single full bundle response, got %d", fullResponses.Load())
+	}
+	if store.Get().Version != meta.Version {
+		t.Fatalf("expected version %q, got %q", meta.Version, store.Get().Version)
+	}
+	status := puller.Status()
+	if status.LastResult != "not_modified" || status.ConsecutiveFailures != 0 {
+		t.Fatalf("unexpected pull status %+v", status)
+	}
+}
@@ -0,0 +1,138 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/apply"
+	"modern_reverse_proxy/internal/bundle"
+	"modern_reverse_proxy/internal/distributor"
+	"modern_reverse_proxy/internal/distributor/proto"
+	"modern_reverse_proxy/internal/pull"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/rollout"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+
+	"google.golang.org/grpc"
+)
+
+func TestDistributorStreamPushesBundlesAndFallsBackToPull(t *testing.T) {
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer closeUpstream()
+
+	configJSON := []byte(fmt.Sprintf(`{
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1"}],
+"pools": {"p1": {"endpoints": ["%s"]}}
+}`, upstreamAddr))
+	trustedKey := testutil.WriteEd25519KeyPair(t, "trusted")
+	rogueKey := testutil.WriteEd25519KeyPair(t, "rogue")
+	signed := func(version string, keyPair testutil.KeyPair) bundle.Bundle {
+		item, err := bundle.NewSignedBundle(configJSON, bundle.Meta{Version: version, Source: "distributor"}, keyPair.PrivateKey)
+		if err != nil {
+			t.Fatalf("sign bundle: %v", err)
+		}
+		return item
+	}
+
+	storage := bundle.NewMemoryStorage()
+	if err := storage.Put(signed("stream-v1", trustedKey)); err != nil {
+		t.Fatalf("store bundle: %v", err)
+	}
+	httpServer := httptest.NewServer(distributor.NewHandler(distributor.Config{Storage: storage}))
+	defer httpServer.Close()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	grpcServer := grpc.NewServer()
+	distributorpb.RegisterBundleStreamServer(grpcServer, distributor.NewStreamServer(distributor.StreamConfig{Storage: storage, Refresh: 10 * time.Millisecond}))
+	go func() {
+		_ = grpcServer.Serve(ln)
+	}()
+	defer grpcServer.Stop()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+	store := runtime.NewStore(nil)
+	applyManager := apply.NewManager(apply.ManagerConfig{Store: store, Registry: reg, TrafficRegistry: trafficReg})
+	rolloutManager := rollout.NewManager(rollout.Config{ApplyManager: applyManager, Store: store, LockedBake: 10 * time.Millisecond})
+	puller := pull.NewPuller(pull.Config{
+		Enabled:        true,
+		BaseURL:        httpServer.URL,
+		Interval:       20 * time.Millisecond,
+		PublicKey:      trustedKey.PublicKey,
+		RolloutManager: rolloutManager,
+		Store:          store,
+		HTTPClient:     httpServer.Client(),
+		StreamAddr:     ln.Addr().String(),
+		NodeID:         "node-a",
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go puller.Run(ctx)
+
+	waitFor := func(check func(pull.Status) error) {
+		t.Helper()
+		testutil.Eventually(t, 2*time.Second, 10*time.Millisecond, func() error {
+			return check(puller.Status())
+		})
+	}
+
+	waitFor(func(status pull.Status) error {
+		if status.Mode != "stream" || !status.StreamConnected {
+			return fmt.Errorf("expected connected stream, got %+v", status)
+		}
+		if status.LastAppliedVersion != "stream-v1" {
+			return fmt.Errorf("expected stream-v1 applied, got %q", status.LastAppliedVersion)
+		}
+		return nil
+	})
+
+	if err := storage.Put(signed("stream-v2", rogueKey)); err != nil {
+		t.Fatalf("store bundle: %v", err)
+	}
+	waitFor(func(status pull.Status) error {
+		if status.LastResult != "bad_sig" || status.LastVersionSeen != "stream-v2" {
+			return fmt.Errorf("expected rejected stream-v2, got %+v", status)
+		}
+		return nil
+	})
+	if snap := store.Get(); snap == nil || snap.Version != "stream-v1" {
+		t.Fatalf("expected stream-v1 to remain active after NACK")
+	}
+
+	if err := storage.Put(signed("stream-v3", trustedKey)); err != nil {
+		t.Fatalf("store bundle: %v", err)
+	}
+	waitFor(func(status pull.Status) error {
+		if status.LastAppliedVersion != "stream-v3" || !status.StreamConnected {
+			return fmt.Errorf("expected stream-v3 pushed, got %+v", status)
+		}
+		return nil
+	})
+
+	grpcServer.Stop()
+	if err := storage.Put(signed("stream-v4", trustedKey)); err != nil {
+		t.Fatalf("store bundle: %v", err)
+	}
+	waitFor(func(status pull.Status) error {
+		if status.StreamConnected {
+			return fmt.Errorf("expected stream to be disconnected")
+		}
+		if status.LastAppliedVersion != "stream-v4" {
+			return fmt.Errorf("expected pull fallback to apply stream-v4, got %q", status.LastAppliedVersion)
+		}
+		return nil
+	})
+}
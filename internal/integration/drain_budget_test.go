@@ -0,0 +1,159 @@
+package integration
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/pool"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestEndpointDrainHonorsPoolTimeout(t *testing.T) {
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	})
+	aAddr, closeA := testutil.StartUpstream(t, upstream)
+	defer closeA()
+	bAddr, closeB := testutil.StartUpstream(t, upstream)
+	defer closeB()
+
+	reg := registry.NewRegistry(20*time.Millisecond, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+
+	drain := config.PoolDrainConfig{TimeoutMS: 600}
+	cfg := &config.Config{
+		Routes: []config.Route{{ID: "r1", Host: "example.local", PathPrefix: "/", Pool: "p1"}},
+		Pools: map[string]config.Pool{
+			"p1": {Endpoints: []string{aAddr, bAddr}, Drain: drain},
+		},
+	}
+	if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg); err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	cfg.Pools["p1"] = config.Pool{Endpoints: []string{bAddr}, Drain: drain}
+	if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg); err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+
+	poolKey := pool.PoolKey("p1")
+	time.Sleep(300 * time.Millisecond)
+	if !reg.HasEndpoint(poolKey, aAddr) {
+		t.Fatalf("expected endpoint to remain during pool drain timeout")
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) && reg.HasEndpoint(poolKey, aAddr) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if reg.HasEndpoint(poolKey, aAddr) {
+		t.Fatalf("expected endpoint to be removed after drain timeout")
+	}
+
+	cfg.Pools["p1"] = config.Pool{Endpoints: []string{bAddr}, Drain: config.PoolDrainConfig{TimeoutMS: 500, MaxBudgetMS: 100}}
+	if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg); err == nil {
+		t.Fatalf("expected error for max budget below timeout")
+	}
+}
+
+func TestEndpointDrainBudgetCutsOffInflight(t *testing.T) {
+	block := make(chan struct{})
+	started := make(chan struct{})
+	var startedOnce atomic.Bool
+
+	upstreamA := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if startedOnce.CompareAndSwap(false, true) {
+			close(started)
+		}
+		select {
+		case <-block:
+		case <-r.Context().Done():
+		}
+	})
+	upstreamB := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "B")
+	})
+
+	aAddr, closeA := testutil.StartUpstream(t, upstreamA)
+	defer closeA()
+	bAddr, closeB := testutil.StartUpstream(t, upstreamB)
+	defer closeB()
+	defer close(block)
+
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+	reg := registry.NewRegistry(20*time.Millisecond, 0)
+	defer reg.Close()
+	reg.SetDrainCutoffObserver(metrics.RecordDrainCutoff)
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+
+	drain := config.PoolDrainConfig{TimeoutMS: 50, MaxBudgetMS: 300}
+	cfg := &config.Config{
+		Routes: []config.Route{{ID: "r1", Host: "example.local", PathPrefix: "/", Pool: "p1"}},
+		Pools: map[string]config.Pool{
+			"p1": {Endpoints: []string{aAddr, bAddr}, Drain: drain},
+		},
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	store := runtime.NewStore(snap)
+	proxyServer := httptest.NewServer(&proxy.Handler{Store: store, Registry: reg, Engine: proxy.NewEngine(reg, nil, metrics, nil, nil), Metrics: metrics})
+	defer proxyServer.Close()
+
+	client := &http.Client{Timeout: 3 * time.Second}
+	type result struct {
+		resp *http.Response
+		body []byte
+	}
+	resultCh := make(chan result, 1)
+	go func() {
+		resp, body := sendProxyRequest(t, client, proxyServer.URL, "example.local", http.MethodGet, "/")
+		resultCh <- result{resp: resp, body: body}
+	}()
+	<-started
+
+	cfg.Pools["p1"] = config.Pool{Endpoints: []string{bAddr}, Drain: drain}
+	snap, err = runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	if err := store.Swap(snap); err != nil {
+		t.Fatalf("swap snapshot: %v", err)
+	}
+
+	start := time.Now()
+	select {
+	case res := <-resultCh:
+		if res.resp.StatusCode != http.StatusBadGateway {
+			t.Fatalf("expected 502 for cut off request, got %d", res.resp.StatusCode)
+		}
+		assertProxyError(t, res.resp, res.body, "upstream_drained")
+		if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+			t.Fatalf("request cut off before drain budget: %v", elapsed)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for drain cutoff")
+	}
+	if reg.HasEndpoint(pool.PoolKey("p1"), aAddr) {
+		t.Fatalf("expected endpoint to be removed after drain budget")
+	}
+
+	metricsServer := httptest.NewServer(metrics.Handler())
+	defer metricsServer.Close()
+	text := fetchMetrics(t, metricsServer)
+	if value, ok := metricValue(text, "proxy_drain_cutoff_requests_total", nil); !ok || value != 1 {
+		t.Fatalf("expected drain cutoff metric 1, got %v", value)
+	}
+}
@@ -0,0 +1,108 @@
+package integration
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+)
+
+func TestRouteErrorPages(t *testing.T) {
+	pageFile := filepath.Join(t.TempDir(), "502.html")
+	if err := os.WriteFile(pageFile, []byte("<h1>Bad gateway</h1><p>ref {{request_id}} ({{category}})</p>"), 0o600); err != nil {
+		t.Fatalf("write page: %v", err)
+	}
+	deadAddr := unusedAddr(t)
+	cfgJSON := `{
+"listen_addr": "127.0.0.1:0",
+"routes": [
+  {"id": "web", "host": "web.local", "path_prefix": "/", "pool": "p1", "policy": {"error_pages": [
+    {"status": [502], "format": "html", "file": "` + pageFile + `"},
+    {"format": "html", "body": "<p>{{status}} {{message}}</p>"}
+  ]}},
+  {"id": "api", "host": "api.local", "path_prefix": "/", "pool": "p1", "policy": {"error_pages": [
+    {"status": [502], "body": "{\"error\": \"{{category}}\", \"ref\": \"{{request_id}}\", \"code\": {{status}}}"}
+  ]}},
+  {"id": "plain", "host": "plain.local", "path_prefix": "/", "pool": "p1"}
+],
+"pools": {"p1": {"endpoints": ["` + deadAddr + `"]}}
+}`
+	serverHandle, _, _, _ := startProxy(t, cfgJSON)
+
+	client := &http.Client{Timeout: 2 * time.Second}
+	fetch := func(host string) (*http.Response, string) {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, "http://"+serverHandle.HTTPAddr+"/", nil)
+		if err != nil {
+			t.Fatalf("new request: %v", err)
+		}
+		req.Host = host
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+
+	resp, body := fetch("web.local")
+	requestID := resp.Header.Get(proxy.RequestIDHeader)
+	if resp.StatusCode != http.StatusBadGateway || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		t.Fatalf("expected html 502, got %d %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	if body != "<h1>Bad gateway</h1><p>ref "+requestID+" (upstream_connect_failed)</p>" {
+		t.Fatalf("unexpected html error page %q", body)
+	}
+
+	resp, body = fetch("api.local")
+	var apiBody struct {
+		Error string `json:"error"`
+		Ref   string `json:"ref"`
+		Code  int    `json:"code"`
+	}
+	if err := json.Unmarshal([]byte(body), &apiBody); err != nil {
+		t.Fatalf("expected json error page, got %q: %v", body, err)
+	}
+	if resp.Header.Get("Content-Type") != "application/json" || apiBody.Code != http.StatusBadGateway || apiBody.Ref != resp.Header.Get(proxy.RequestIDHeader) || apiBody.Error == "" {
+		t.Fatalf("unexpected json error page %q", body)
+	}
+
+	resp, body = fetch("plain.local")
+	var defaultBody proxy.ProxyErrorBody
+	if err := json.Unmarshal([]byte(body), &defaultBody); err != nil || defaultBody.Status != http.StatusBadGateway {
+		t.Fatalf("expected default json error body, got %q", body)
+	}
+}
+
+func TestRouteErrorPagesValidation(t *testing.T) {
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	for _, pages := range []string{
+		`[{"format": "xml", "body": "x"}]`,
+		`[{"status": [200], "body": "x"}]`,
+		`[{"status": [502]}]`,
+		`[{"body": "x", "file": "/tmp/page.html"}]`,
+		`[{"file": "/nonexistent/page.html"}]`,
+	} {
+		cfg, err := config.ParseJSON([]byte(`{
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1", "policy": {"error_pages": ` + pages + `}}],
+"pools": {"p1": {"endpoints": ["127.0.0.1:1"]}}
+}`))
+		if err != nil {
+			t.Fatalf("parse config: %v", err)
+		}
+		if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil); err == nil {
+			t.Fatalf("expected error_pages %s to be rejected", pages)
+		}
+	}
+}
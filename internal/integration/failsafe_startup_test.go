@@ -0,0 +1,95 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/server"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestFailSafeSnapshotServes503UntilConfigLands(t *testing.T) {
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer closeUpstream()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+
+	failSafe, err := runtime.FailSafeSnapshot(reg, trafficReg)
+	if err != nil {
+		t.Fatalf("fail-safe snapshot: %v", err)
+	}
+	store := runtime.NewStore(failSafe)
+
+	mux := http.NewServeMux()
+	mux.Handle("/readyz", server.ReadinessHandler(store))
+	mux.Handle("/", &proxy.Handler{Store: store, Registry: reg, Engine: proxy.NewEngine(reg, nil, nil, nil, nil)})
+	proxyServer := httptest.NewServer(mux)
+	defer proxyServer.Close()
+
+	client := &http.Client{Timeout: 2 * time.Second}
+	resp, body := sendProxyRequest(t, client, proxyServer.URL, "example.local", http.MethodGet, "/")
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 in fail-safe mode, got %d", resp.StatusCode)
+	}
+	assertProxyError(t, resp, body, "not_ready")
+	if ready := readiness(t, client, proxyServer.URL); ready {
+		t.Fatalf("expected not ready in fail-safe mode")
+	}
+
+	cfg, err := config.ParseJSON([]byte(fmt.Sprintf(`{
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1"}],
+"pools": {"p1": {"endpoints": ["%s"]}}
+}`, upstreamAddr)))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	if err := store.Swap(snap); err != nil {
+		t.Fatalf("swap snapshot: %v", err)
+	}
+
+	if ready := readiness(t, client, proxyServer.URL); !ready {
+		t.Fatalf("expected ready after valid snapshot")
+	}
+	resp, body = sendProxyRequest(t, client, proxyServer.URL, "example.local", http.MethodGet, "/")
+	if resp.StatusCode != http.StatusOK || string(body) != "ok" {
+		t.Fatalf("expected upstream response, got %d %q", resp.StatusCode, string(body))
+	}
+}
+
+func readiness(t *testing.T, client *http.Client, baseURL string) bool {
+	t.Helper()
+	resp, err := client.Get(baseURL + "/readyz")
+	if err != nil {
+		t.Fatalf("readyz: %v", err)
+	}
+	defer resp.Body.Close()
+	var payload struct {
+		Ready bool `json:"ready"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		t.Fatalf("decode readyz: %v", err)
+	}
+	if payload.Ready != (resp.StatusCode == http.StatusOK) {
+		t.Fatalf("readyz status %d disagrees with body %v", resp.StatusCode, payload.Ready)
+	}
+	return payload.Ready
+}
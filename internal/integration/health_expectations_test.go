@@ -0,0 +1,153 @@
+package integration
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/pool"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+func buildHealthPool(t *testing.T, reg *registry.Registry, name string, endpoints []string, health string) {
+	t.Helper()
+	cfg, err := config.ParseJSON([]byte(`{
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "` + name + `"}],
+"pools": {"` + name + `": {"endpoints": ["` + strings.Join(endpoints, `", "`) + `"], "health": ` + health + `}}
+}`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil); err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+}
+
+func waitEndpointState(t *testing.T, reg *registry.Registry, poolName string, addr string, want string) {
+	t.Helper()
+	testutil.Eventually(t, 3*time.Second, 10*time.Millisecond, func() error {
+		statuses, ok := reg.PoolStatus(pool.PoolKey(poolName))
+		if !ok {
+			return fmt.Errorf("pool %s missing", poolName)
+		}
+		for _, status := range statuses {
+			if status.Addr == addr {
+				if status.State != want {
+					return fmt.Errorf("endpoint %s state %s, want %s", addr, status.State, want)
+				}
+				return nil
+			}
+		}
+		return fmt.Errorf("endpoint %s missing", addr)
+	})
+}
+
+func TestActiveHealthExpectations(t *testing.T) {
+	probeHandler := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost || r.Header.Get("X-Probe") != "1" || r.Host != "health.local" {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = io.WriteString(w, body)
+		}
+	}
+	good, closeGood := testutil.StartUpstream(t, probeHandler("status=ok"))
+	defer closeGood()
+	degraded, closeDegraded := testutil.StartUpstream(t, probeHandler("status=degraded"))
+	defer closeDegraded()
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	redirected := closed.Addr().String()
+	_ = closed.Close()
+	_, goodPort, _ := net.SplitHostPort(good)
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	buildHealthPool(t, reg, "http", []string{good, degraded, redirected}, `{
+  "interval_ms": 20, "unhealthy_after_failures": 2, "healthy_after_successes": 1,
+  "method": "post", "headers": {"X-Probe": "1", "Host": "health.local"},
+  "expected_statuses": ["202"], "body_contains": "status=ok",
+  "endpoint_ports": {"`+redirected+`": `+goodPort+`}
+}`)
+	waitEndpointState(t, reg, "http", degraded, "unhealthy")
+	time.Sleep(100 * time.Millisecond)
+	waitEndpointState(t, reg, "http", good, "healthy")
+	waitEndpointState(t, reg, "http", redirected, "healthy")
+
+	var tlsHealthy atomic.Bool
+	secure := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !tlsHealthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer secure.Close()
+	secureAddr := strings.TrimPrefix(secure.URL, "https://")
+	buildHealthPool(t, reg, "https", []string{secureAddr}, `{"interval_ms": 20, "unhealthy_after_failures": 1, "healthy_after_successes": 1, "tls": true, "tls_insecure_skip_verify": true, "expected_statuses": ["2xx"]}`)
+	waitEndpointState(t, reg, "https", secureAddr, "unhealthy")
+	tlsHealthy.Store(true)
+	waitEndpointState(t, reg, "https", secureAddr, "healthy")
+
+	grpcListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	grpcServer := grpc.NewServer()
+	healthServer := grpchealth.NewServer()
+	healthServer.SetServingStatus("orders", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	go func() {
+		_ = grpcServer.Serve(grpcListener)
+	}()
+	defer grpcServer.Stop()
+	grpcAddr := grpcListener.Addr().String()
+	buildHealthPool(t, reg, "grpc", []string{grpcAddr}, `{"type": "grpc", "grpc_service": "orders", "interval_ms": 20, "unhealthy_after_failures": 1, "healthy_after_successes": 1}`)
+	time.Sleep(100 * time.Millisecond)
+	waitEndpointState(t, reg, "grpc", grpcAddr, "healthy")
+	healthServer.SetServingStatus("orders", healthpb.HealthCheckResponse_NOT_SERVING)
+	waitEndpointState(t, reg, "grpc", grpcAddr, "unhealthy")
+	healthServer.SetServingStatus("orders", healthpb.HealthCheckResponse_SERVING)
+	waitEndpointState(t, reg, "grpc", grpcAddr, "healthy")
+}
+
+func TestActiveHealthExpectationsValidation(t *testing.T) {
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	for _, health := range []string{
+		`{"expected_statuses": ["6xx"]}`,
+		`{"expected_statuses": ["299-200"]}`,
+		`{"endpoint_ports": {"127.0.0.1:2": 9000}}`,
+		`{"port": 70000}`,
+		`{"method": "GE T"}`,
+	} {
+		cfg, err := config.ParseJSON([]byte(`{
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1"}],
+"pools": {"p1": {"endpoints": ["127.0.0.1:1"], "health": ` + health + `}}
+}`))
+		if err != nil {
+			t.Fatalf("parse config: %v", err)
+		}
+		if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil); err == nil {
+			t.Fatalf("expected health %s to be rejected", health)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestHedgedRequests(t *testing.T) {
+	var canceled int32
+	slowAddr, closeSlow := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(2 * time.Second):
+			w.Header().Set("X-Upstream", "slow")
+		case <-r.Context().Done():
+			atomic.AddInt32(&canceled, 1)
+		}
+	}))
+	defer closeSlow()
+	fastAddr, closeFast := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Upstream", "fast")
+	}))
+	defer closeFast()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+
+	cfg := &config.Config{
+		Routes: []config.Route{{
+			ID:         "r1",
+			Host:       "example.local",
+			PathPrefix: "/",
+			Pool:       "p1",
+			Policy: config.RoutePolicy{
+				Hedge: config.HedgeConfig{DelayMS: 50, MaxHedges: 1},
+			},
+		}},
+		Pools: map[string]config.Pool{
+			"p1": {Endpoints: []string{slowAddr, fastAddr}},
+		},
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	proxyServer := httptest.NewServer(&proxy.Handler{Store: runtime.NewStore(snap), Registry: reg, Engine: proxy.NewEngine(reg, nil, metrics, nil, nil), Metrics: metrics})
+	defer proxyServer.Close()
+	metricsServer := httptest.NewServer(metrics.Handler())
+	defer metricsServer.Close()
+
+	client := &http.Client{Timeout: 2 * time.Second}
+	for i := 0; i < 4; i++ {
+		start := time.Now()
+		resp, _ := sendProxyRequest(t, client, proxyServer.URL, "example.local", http.MethodGet, "/")
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("X-Upstream") != "fast" {
+			t.Fatalf("expected hedged response from fast upstream, got %d %q", resp.StatusCode, resp.Header.Get("X-Upstream"))
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Fatalf("expected hedge to cut tail latency, took %v", elapsed)
+		}
+	}
+
+	text := fetchMetrics(t, metricsServer)
+	fired, _ := metricValue(text, "proxy_hedge_requests_total", map[string]string{"route": "r1", "result": "fired"})
+	won, _ := metricValue(text, "proxy_hedge_requests_total", map[string]string{"route": "r1", "result": "won"})
+	if fired < 1 || won != fired {
+		t.Fatalf("expected every fired hedge to win, got fired=%v won=%v", fired, won)
+	}
+	testutil.Eventually(t, time.Second, 20*time.Millisecond, func() error {
+		if got := atomic.LoadInt32(&canceled); float64(got) != fired {
+			return fmt.Errorf("expected %v cancelled losers, got %d", fired, got)
+		}
+		return nil
+	})
+
+	cfg.Routes[0].Policy.Hedge = config.HedgeConfig{MaxHedges: 2}
+	if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg); err == nil || !strings.Contains(err.Error(), "delay_ms") {
+		t.Fatalf("expected hedge delay validation error, got %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+package integration
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+func TestIdempotencyKeyRetryAndReplay(t *testing.T) {
+	var executions atomic.Int32
+	var failNext atomic.Bool
+	release := make(chan struct{})
+	slowStarted := make(chan struct{})
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.URL.Path == "/slow" {
+			close(slowStarted)
+			<-release
+		}
+		count := executions.Add(1)
+		if failNext.CompareAndSwap(true, false) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("X-Order", fmt.Sprint(count))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = fmt.Fprintf(w, "order-%d:%s", count, body)
+	})
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, upstream)
+	defer closeUpstream()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	retryReg := registry.NewRetryRegistry(0, 0)
+	defer retryReg.Close()
+	cfg := &config.Config{
+		Routes: []config.Route{{
+			ID:         "r1",
+			Host:       "example.local",
+			PathPrefix: "/",
+			Pool:       "p1",
+			Policy: config.RoutePolicy{
+				Retry: config.RetryConfig{
+					Enabled:         true,
+					MaxAttempts:     2,
+					RetryOnStatus:   []int{http.StatusServiceUnavailable},
+					BufferBodyBytes: 1024,
+				},
+				Idempotency: config.IdempotencyConfig{Enabled: true, ReplayTTLMS: 5000},
+			},
+		}},
+		Pools: map[string]config.Pool{"p1": {Endpoints: []string{upstreamAddr}}},
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	proxyServer := httptest.NewServer(&proxy.Handler{
+		Store:         runtime.NewStore(snap),
+		Registry:      reg,
+		RetryRegistry: retryReg,
+		Engine:        proxy.NewEngine(reg, retryReg, nil, nil, nil),
+	})
+	defer proxyServer.Close()
+
+	client := &http.Client{Timeout: 3 * time.Second}
+	post := func(path string, key string, body string) (*http.Response, string) {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, proxyServer.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("new request: %v", err)
+		}
+		req.Host = "example.local"
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("request: %v", err)
+		}
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(resp.Body)
+		return resp, string(respBody)
+	}
+
+	failNext.Store(true)
+	resp, body := post("/orders", "", "flaky")
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected unkeyed POST not to be retried, got %d", resp.StatusCode)
+	}
+
+	failNext.Store(true)
+	resp, body = post("/orders", "key-1", "flaky")
+	if resp.StatusCode != http.StatusCreated || body != "order-3:flaky" {
+		t.Fatalf("expected keyed POST to be retried, got %d %q", resp.StatusCode, body)
+	}
+
+	var replayLog string
+	lines := captureLogs(t, func() {
+		resp, body = post("/orders", "key-1", "flaky")
+	})
+	if resp.StatusCode != http.StatusCreated || body != "order-3:flaky" || resp.Header.Get("X-Order") != "3" {
+		t.Fatalf("expected original response to be replayed, got %d %q", resp.StatusCode, body)
+	}
+	if resp.Header.Get(proxy.IdempotentReplayedHeader) != "true" {
+		t.Fatalf("expected %s header on replay", proxy.IdempotentReplayedHeader)
+	}
+	if len(lines) == 1 {
+		replayLog = lines[0]
+	}
+	if !strings.Contains(replayLog, `"idempotency_status":"replayed"`) {
+		t.Fatalf("expected replay in access log, got %q", replayLog)
+	}
+	if got := executions.Load(); got != 3 {
+		t.Fatalf("expected replay not to reach upstream, got %d executions", got)
+	}
+
+	resp, body = post("/orders", "key-2", "other")
+	if resp.StatusCode != http.StatusCreated || body != "order-4:other" {
+		t.Fatalf("expected new key to execute, got %d %q", resp.StatusCode, body)
+	}
+
+	done := make(chan string, 1)
+	go func() {
+		_, slowBody := post("/slow", "key-3", "slow")
+		done <- slowBody
+	}()
+	<-slowStarted
+	resp, _ = post("/slow", "key-3", "slow")
+	close(release)
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected 409 while key is in flight, got %d", resp.StatusCode)
+	}
+	if slowBody := <-done; slowBody != "order-5:slow" {
+		t.Fatalf("expected in-flight request to complete, got %q", slowBody)
+	}
+}
+
+func TestIdempotencyValidation(t *testing.T) {
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	for _, idempotency := range []string{
+		`{"enabled": true, "replay_ttl_ms": -1}`,
+		`{"enabled": true, "replay_ttl_ms": 90000000}`,
+		`{"enabled": true, "header": "Bad Header"}`,
+	} {
+		cfg, err := config.ParseJSON([]byte(`{
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1", "policy": {"idempotency": ` + idempotency + `}}],
+"pools": {"p1": {"endpoints": ["127.0.0.1:1"]}}
+}`))
+		if err != nil {
+			t.Fatalf("parse config: %v", err)
+		}
+		if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil); err == nil {
+			t.Fatalf("expected idempotency %s to be rejected", idempotency)
+		}
+	}
+}
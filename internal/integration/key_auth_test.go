@@ -0,0 +1,195 @@
+package integration
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/keyauth"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestAPIKeyAuth(t *testing.T) {
+	keysFile := filepath.Join(t.TempDir(), "keys.json")
+	writeKeysFile(t, keysFile, `{"keys":[{"id":"batch","key":"file-secret"}]}`)
+	t.Setenv("KEY_AUTH_TEST_REPORTING", "reporting-secret")
+
+	proxyServer, metrics, closeProxy := startKeyAuthProxy(t, config.RoutePolicy{
+		Auth: "api_key",
+		APIKey: config.APIKeyConfig{
+			Keys: []config.AuthKeyConfig{
+				{ID: "reporting", KeyEnv: "KEY_AUTH_TEST_REPORTING", RateLimitRPS: 1, RateLimitBurst: 2},
+			},
+			KeysFile: keysFile,
+		},
+	})
+	defer closeProxy()
+	client := &http.Client{Timeout: 2 * time.Second}
+
+	resp, body := sendProxyRequest(t, client, proxyServer.URL, "example.local", http.MethodGet, "/")
+	assertProxyError(t, resp, body, "auth_required")
+
+	resp, body = sendProxyRequestWithHeaders(t, client, proxyServer.URL, "example.local", http.MethodGet, "/", map[string]string{"X-API-Key": "wrong"})
+	assertProxyError(t, resp, body, "auth_failed")
+
+	for i := 0; i < 2; i++ {
+		resp, body = sendProxyRequestWithHeaders(t, client, proxyServer.URL, "example.local", http.MethodGet, "/", map[string]string{
+			"X-API-Key":     "reporting-secret",
+			"X-Auth-Key-Id": "spoofed",
+		})
+		if resp.StatusCode != http.StatusOK || string(body) != "key=reporting api_key=" {
+			t.Fatalf("expected reporting key accepted, got %d %q", resp.StatusCode, body)
+		}
+	}
+	resp, body = sendProxyRequestWithHeaders(t, client, proxyServer.URL, "example.local", http.MethodGet, "/", map[string]string{"X-API-Key": "reporting-secret"})
+	assertProxyError(t, resp, body, "rate_limited")
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") == "" {
+		t.Fatalf("expected 429 with Retry-After, got %d", resp.StatusCode)
+	}
+
+	resp, body = sendProxyRequestWithHeaders(t, client, proxyServer.URL, "example.local", http.MethodGet, "/", map[string]string{"X-API-Key": "file-secret"})
+	if resp.StatusCode != http.StatusOK || string(body) != "key=batch api_key=" {
+		t.Fatalf("expected file key accepted, got %d %q", resp.StatusCode, body)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+	writeKeysFile(t, keysFile, `{"keys":[{"id":"batch-v2","key":"rotated-secret"}]}`)
+	resp, body = sendProxyRequestWithHeaders(t, client, proxyServer.URL, "example.local", http.MethodGet, "/", map[string]string{"X-API-Key": "file-secret"})
+	assertProxyError(t, resp, body, "auth_failed")
+	resp, body = sendProxyRequestWithHeaders(t, client, proxyServer.URL, "example.local", http.MethodGet, "/", map[string]string{"X-API-Key": "rotated-secret"})
+	if resp.StatusCode != http.StatusOK || string(body) != "key=batch-v2 api_key=" {
+		t.Fatalf("expected rotated file key accepted, got %d %q", resp.StatusCode, body)
+	}
+
+	metricsServer := httptest.NewServer(metrics.Handler())
+	defer metricsServer.Close()
+	text := fetchMetrics(t, metricsServer)
+	if value, ok := metricValue(text, "proxy_auth_key_requests_total", map[string]string{"key": "reporting", "result": "allowed"}); !ok || value != 2 {
+		t.Fatalf("expected two allowed requests for reporting key, got %v", value)
+	}
+	if value, ok := metricValue(text, "proxy_auth_key_requests_total", map[string]string{"key": "reporting", "result": "rate_limited"}); !ok || value != 1 {
+		t.Fatalf("expected one rate limited request for reporting key, got %v", value)
+	}
+}
+
+func TestHMACAuth(t *testing.T) {
+	secret := []byte("hmac-shared-secret")
+	proxyServer, metrics, closeProxy := startKeyAuthProxy(t, config.RoutePolicy{
+		Auth: "hmac",
+		HMAC: config.HMACConfig{
+			Keys:         []config.AuthKeyConfig{{ID: "billing", Key: string(secret)}},
+			MaxSkewMS:    60000,
+			MaxBodyBytes: 64,
+		},
+	})
+	defer closeProxy()
+	client := &http.Client{Timeout: 2 * time.Second}
+
+	send := func(path string, payload string, timestamp time.Time, signPayload string) (*http.Response, []byte) {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, proxyServer.URL+path, strings.NewReader(payload))
+		if err != nil {
+			t.Fatalf("new request: %v", err)
+		}
+		req.Host = "example.local"
+		ts := strconv.FormatInt(timestamp.Unix(), 10)
+		signature := keyauth.Sign(secret, http.MethodPost, path, "example.local", ts, []byte(signPayload))
+		req.Header.Set("X-Signature", keyauth.SignatureHeader("billing", ts, signature))
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("read body: %v", err)
+		}
+		return resp, body
+	}
+
+	resp, body := send("/charge?id=7", `{"amount":10}`, time.Now(), `{"amount":10}`)
+	if resp.StatusCode != http.StatusOK || string(body) != `key=billing api_key= body={"amount":10}` {
+		t.Fatalf("expected signed request accepted, got %d %q", resp.StatusCode, body)
+	}
+
+	resp, body = send("/charge?id=7", `{"amount":9999}`, time.Now(), `{"amount":10}`)
+	assertProxyError(t, resp, body, "auth_failed")
+
+	resp, body = send("/charge?id=7", `{"amount":10}`, time.Now().Add(-5*time.Minute), `{"amount":10}`)
+	assertProxyError(t, resp, body, "auth_failed")
+
+	large := strings.Repeat("x", 128)
+	resp, body = send("/charge", large, time.Now(), large)
+	assertProxyError(t, resp, body, "auth_failed")
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 for oversized signed body, got %d", resp.StatusCode)
+	}
+
+	resp, body = sendProxyRequest(t, client, proxyServer.URL, "example.local", http.MethodGet, "/")
+	assertProxyError(t, resp, body, "auth_required")
+
+	metricsServer := httptest.NewServer(metrics.Handler())
+	defer metricsServer.Close()
+	text := fetchMetrics(t, metricsServer)
+	if value, ok := metricValue(text, "proxy_auth_results_total", map[string]string{"mode": "hmac", "result": "invalid"}); !ok || value != 2 {
+		t.Fatalf("expected two invalid hmac results, got %v", value)
+	}
+}
+
+func startKeyAuthProxy(t *testing.T, routePolicy config.RoutePolicy) (*httptest.Server, *obs.Metrics, func()) {
+	t.Helper()
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		view := fmt.Sprintf("key=%s api_key=%s", r.Header.Get("X-Auth-Key-Id"), r.Header.Get("X-API-Key"))
+		if r.Method == http.MethodPost {
+			payload, _ := io.ReadAll(r.Body)
+			view += " body=" + string(payload)
+		}
+		_, _ = io.WriteString(w, view)
+	})
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, upstream)
+
+	reg := registry.NewRegistry(0, 0)
+	trafficReg := traffic.NewRegistry(0, 0)
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+	cfg := &config.Config{
+		Routes: []config.Route{{ID: "r1", Host: "example.local", PathPrefix: "/", Pool: "p1", Policy: routePolicy}},
+		Pools: map[string]config.Pool{
+			"p1": {Endpoints: []string{upstreamAddr}},
+		},
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	server := httptest.NewServer(&proxy.Handler{
+		Store:    runtime.NewStore(snap),
+		Registry: reg,
+		Engine:   proxy.NewEngine(reg, nil, metrics, nil, nil),
+		Metrics:  metrics,
+	})
+	return server, metrics, func() {
+		server.Close()
+		reg.Close()
+		closeUpstream()
+	}
+}
+
+func writeKeysFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write keys file: %v", err)
+	}
+}
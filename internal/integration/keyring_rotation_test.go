@@ -0,0 +1,211 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/admin"
+	"modern_reverse_proxy/internal/apply"
+	"modern_reverse_proxy/internal/bundle"
+	"modern_reverse_proxy/internal/distributor"
+	"modern_reverse_proxy/internal/pull"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/rollout"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestAdminKeyringRotation(t *testing.T) {
+	addr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer closeUpstream()
+
+	oldKey := testutil.WriteEd25519KeyPair(t, "old")
+	newKey := testutil.WriteEd25519KeyPair(t, "new")
+	signed := func(label string, keyPair testutil.KeyPair) bundle.Bundle {
+		raw := []byte(fmt.Sprintf(`{
+"routes": [{"id": "r1", "host": "%s.local", "path_prefix": "/", "pool": "p1"}],
+"pools": {"p1": {"endpoints": ["%s"]}}
+}`, label, addr))
+		meta := bundle.Meta{Version: apply.ConfigVersion(raw), Source: "admin", Label: label, KeyID: bundle.KeyID(keyPair.PublicKey)}
+		item, err := bundle.NewSignedBundle(raw, meta, keyPair.PrivateKey)
+		if err != nil {
+			t.Fatalf("sign bundle: %v", err)
+		}
+		return item
+	}
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+	store := runtime.NewStore(nil)
+	applyManager := apply.NewManager(apply.ManagerConfig{Store: store, Registry: reg, TrafficRegistry: trafficReg})
+
+	ca := testutil.WriteCA(t, "admin-ca")
+	serverCert := testutil.WriteServerCert(t, "admin.local", ca)
+	clientCert := testutil.WriteClientCert(t, "client", ca)
+	auth, err := admin.NewAuthenticator(admin.AuthConfig{Token: "secret", ClientCAFile: ca.CertFile})
+	if err != nil {
+		t.Fatalf("auth config: %v", err)
+	}
+	adminServer := startAdminServer(t, admin.NewHandler(admin.HandlerConfig{
+		Store:        store,
+		ApplyManager: applyManager,
+		Auth:         auth,
+		AdminStore:   admin.NewStore(),
+		PublicKey:    oldKey.PublicKey,
+	}), newAdminTLSConfig(t, serverCert.CertFile, serverCert.KeyFile, ca.CertFile))
+	defer adminServer.Close()
+	client := testutil.NewAdminClient(t, testutil.AdminClientConfig{CAFile: ca.CertFile, ClientCert: &clientCert, Token: "secret", ServerName: "admin.local"})
+
+	postBundle := func(item bundle.Bundle) int {
+		t.Helper()
+		resp, _, err := client.PostJSON(adminServer.URL+"/admin/bundle", mustMarshalJSON(t, item))
+		if err != nil {
+			t.Fatalf("post bundle: %v", err)
+		}
+		return resp.StatusCode
+	}
+	postKeyring := func(doc bundle.KeyringDocument) int {
+		t.Helper()
+		resp, _, err := client.PostJSON(adminServer.URL+"/admin/keyring", mustMarshalJSON(t, doc))
+		if err != nil {
+			t.Fatalf("post keyring: %v", err)
+		}
+		return resp.StatusCode
+	}
+
+	if status := postBundle(signed("before", newKey)); status != http.StatusBadRequest {
+		t.Fatalf("expected bundle signed by untrusted key to be rejected, got %d", status)
+	}
+
+	forged, err := bundle.SignKeyring(bundle.NewKeyringDocument(1, newKey.PublicKey), newKey.PrivateKey)
+	if err != nil {
+		t.Fatalf("sign keyring: %v", err)
+	}
+	if status := postKeyring(forged); status != http.StatusBadRequest {
+		t.Fatalf("expected keyring signed by untrusted key to be rejected, got %d", status)
+	}
+
+	transition, err := bundle.SignKeyring(bundle.NewKeyringDocument(1, oldKey.PublicKey, newKey.PublicKey), oldKey.PrivateKey)
+	if err != nil {
+		t.Fatalf("sign keyring: %v", err)
+	}
+	if status := postKeyring(transition); status != http.StatusOK {
+		t.Fatalf("expected keyring rotation to succeed, got %d", status)
+	}
+	if status := postKeyring(transition); status != http.StatusConflict {
+		t.Fatalf("expected replayed keyring to conflict, got %d", status)
+	}
+	if status := postBundle(signed("old", oldKey)); status != http.StatusOK {
+		t.Fatalf("expected old key to remain trusted, got %d", status)
+	}
+	if status := postBundle(signed("new", newKey)); status != http.StatusOK {
+		t.Fatalf("expected new key to be trusted, got %d", status)
+	}
+
+	retire, err := bundle.SignKeyring(bundle.NewKeyringDocument(2, newKey.PublicKey), newKey.PrivateKey)
+	if err != nil {
+		t.Fatalf("sign keyring: %v", err)
+	}
+	if status := postKeyring(retire); status != http.StatusOK {
+		t.Fatalf("expected old key retirement to succeed, got %d", status)
+	}
+	if status := postBundle(signed("retired", oldKey)); status != http.StatusBadRequest {
+		t.Fatalf("expected retired key to be rejected, got %d", status)
+	}
+
+	resp, err := client.Do(mustAdminRequest(t, http.MethodGet, adminServer.URL+"/admin/keyring", nil))
+	if err != nil {
+		t.Fatalf("keyring request: %v", err)
+	}
+	defer resp.Body.Close()
+	var view struct {
+		Version int64    `json:"version"`
+		KeyIDs  []string `json:"key_ids"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&view); err != nil {
+		t.Fatalf("decode keyring: %v", err)
+	}
+	if view.Version != 2 || len(view.KeyIDs) != 1 || view.KeyIDs[0] != bundle.KeyID(newKey.PublicKey) {
+		t.Fatalf("unexpected keyring view %+v", view)
+	}
+}
+
+func TestPullFetchesRotatedKeyring(t *testing.T) {
+	addr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer closeUpstream()
+
+	oldKey := testutil.WriteEd25519KeyPair(t, "old")
+	newKey := testutil.WriteEd25519KeyPair(t, "new")
+	raw := []byte(fmt.Sprintf(`{
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1"}],
+"pools": {"p1": {"endpoints": ["%s"]}}
+}`, addr))
+	item, err := bundle.NewSignedBundle(raw, bundle.Meta{Version: "rotated-v1", Source: "distributor", KeyID: bundle.KeyID(newKey.PublicKey)}, newKey.PrivateKey)
+	if err != nil {
+		t.Fatalf("sign bundle: %v", err)
+	}
+	storage := bundle.NewMemoryStorage()
+	if err := storage.Put(item); err != nil {
+		t.Fatalf("store bundle: %v", err)
+	}
+	distributorHandler := distributor.NewHandler(distributor.Config{Storage: storage})
+	distributorServer := httptest.NewServer(distributorHandler)
+	defer distributorServer.Close()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+	store := runtime.NewStore(nil)
+	applyManager := apply.NewManager(apply.ManagerConfig{Store: store, Registry: reg, TrafficRegistry: trafficReg})
+	rolloutManager := rollout.NewManager(rollout.Config{ApplyManager: applyManager, Store: store, LockedBake: 10 * time.Millisecond})
+	keyring := bundle.NewKeyring(oldKey.PublicKey)
+	puller := pull.NewPuller(pull.Config{
+		Enabled:        true,
+		BaseURL:        distributorServer.URL,
+		Interval:       20 * time.Millisecond,
+		Keyring:        keyring,
+		RolloutManager: rolloutManager,
+		Store:          store,
+		HTTPClient:     distributorServer.Client(),
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go puller.Run(ctx)
+
+	testutil.Eventually(t, 2*time.Second, 20*time.Millisecond, func() error {
+		if status := puller.Status(); status.LastResult != "unknown_key" {
+			return fmt.Errorf("expected unknown_key result, got %q", status.LastResult)
+		}
+		return nil
+	})
+
+	doc, err := bundle.SignKeyring(bundle.NewKeyringDocument(1, oldKey.PublicKey, newKey.PublicKey), oldKey.PrivateKey)
+	if err != nil {
+		t.Fatalf("sign keyring: %v", err)
+	}
+	distributorHandler.SetKeyring(doc)
+
+	testutil.Eventually(t, 2*time.Second, 20*time.Millisecond, func() error {
+		status := puller.Status()
+		if status.KeyringVersion != 1 {
+			return fmt.Errorf("expected keyring version 1, got %d", status.KeyringVersion)
+		}
+		if status.LastAppliedVersion != "rotated-v1" {
+			return fmt.Errorf("expected rotated bundle applied, got %+v", status)
+		}
+		return nil
+	})
+	if keyring.Len() != 2 {
+		t.Fatalf("expected two trusted keys, got %v", keyring.KeyIDs())
+	}
+}
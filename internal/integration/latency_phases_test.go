@@ -0,0 +1,95 @@
+package integration
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+func TestLatencyPhasesInAccessLog(t *testing.T) {
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(40 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, "first")
+		w.(http.Flusher).Flush()
+		time.Sleep(30 * time.Millisecond)
+		_, _ = io.WriteString(w, "second")
+	}))
+	defer closeUpstream()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	cfg, err := config.ParseJSON([]byte(`{
+"routes": [
+  {"id": "timed", "host": "timed.local", "path_prefix": "/", "pool": "p1", "policy": {"server_timing": true}},
+  {"id": "plain", "host": "plain.local", "path_prefix": "/", "pool": "p1"}
+],
+"pools": {"p1": {"endpoints": ["` + upstreamAddr + `"]}}
+}`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	proxyServer := httptest.NewServer(&proxy.Handler{
+		Store:    runtime.NewStore(snap),
+		Registry: reg,
+		Engine:   proxy.NewEngine(reg, nil, nil, nil, nil),
+	})
+	defer proxyServer.Close()
+	client := &http.Client{Timeout: 2 * time.Second}
+
+	var serverTiming string
+	lines := captureLogs(t, func() {
+		resp, body := sendProxyRequestWithHeaders(t, client, proxyServer.URL, "timed.local", http.MethodGet, "/", nil)
+		if resp.StatusCode != http.StatusOK || string(body) != "firstsecond" {
+			t.Fatalf("unexpected response %d %q", resp.StatusCode, body)
+		}
+		serverTiming = resp.Header.Get("Server-Timing")
+	})
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 log line, got %d", len(lines))
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("parse log json: %v", err)
+	}
+	for field, minimum := range map[string]float64{
+		"upstream_ttfb_us":  40000,
+		"upstream_body_us":  30000,
+		"response_write_us": 30000,
+	} {
+		if value, ok := entry[field].(float64); !ok || value < minimum {
+			t.Fatalf("expected %s >= %v, got %v", field, minimum, entry[field])
+		}
+	}
+	if _, ok := entry["plugin_request_us"]; ok {
+		t.Fatalf("expected no plugin phase without plugins, got %v", entry["plugin_request_us"])
+	}
+
+	if !strings.HasPrefix(serverTiming, "route;dur=") || !strings.Contains(serverTiming, ", upstream;dur=") {
+		t.Fatalf("unexpected Server-Timing %q", serverTiming)
+	}
+	_, upstreamDur, _ := strings.Cut(serverTiming, "upstream;dur=")
+	if value, err := strconv.ParseFloat(upstreamDur, 64); err != nil || value < 40 {
+		t.Fatalf("expected upstream timing >= 40ms, got %q", upstreamDur)
+	}
+
+	resp, _ := sendProxyRequestWithHeaders(t, client, proxyServer.URL, "plain.local", http.MethodGet, "/", nil)
+	if resp.Header.Get("Server-Timing") != "" {
+		t.Fatalf("expected no Server-Timing without opt-in, got %q", resp.Header.Get("Server-Timing"))
+	}
+}
@@ -0,0 +1,142 @@
+package integration
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+func TestListenerConcurrencyLimitQueuesAndSheds(t *testing.T) {
+	started := make(chan struct{}, 4)
+	release := make(chan struct{})
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer closeUpstream()
+
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+	obs.SetDefaultMetrics(metrics)
+	defer obs.SetDefaultMetrics(nil)
+
+	cfgJSON := buildProxyConfig(upstreamAddr, `"limits": {"read_header_timeout_ms": 1000, "max_concurrent_requests": 1, "max_queued_requests": 1, "queue_timeout_ms": 2000}`)
+	serverHandle, _, _, _ := startProxy(t, cfgJSON)
+	baseURL := "http://" + serverHandle.HTTPAddr
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	firstDone := make(chan int, 1)
+	go func() {
+		resp, _ := sendProxyRequest(t, client, baseURL, "example.local", http.MethodGet, "/")
+		firstDone <- resp.StatusCode
+	}()
+	<-started
+
+	secondDone := make(chan int, 1)
+	go func() {
+		resp, _ := sendProxyRequest(t, client, baseURL, "example.local", http.MethodGet, "/")
+		secondDone <- resp.StatusCode
+	}()
+
+	metricsServer := httptest.NewServer(metrics.Handler())
+	defer metricsServer.Close()
+	testutil.Eventually(t, time.Second, 10*time.Millisecond, func() error {
+		text := fetchMetrics(t, metricsServer)
+		if value, ok := metricValue(text, "proxy_listener_queued_requests", map[string]string{"listener": "http"}); !ok || value != 1 {
+			return fmt.Errorf("expected one queued request, got %v", value)
+		}
+		return nil
+	})
+
+	resp, body := sendProxyRequest(t, client, baseURL, "example.local", http.MethodGet, "/")
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 when queue full, got %d", resp.StatusCode)
+	}
+	assertProxyError(t, resp, body, "overloaded")
+
+	close(release)
+	if status := <-firstDone; status != http.StatusOK {
+		t.Fatalf("expected first request 200, got %d", status)
+	}
+	if status := <-secondDone; status != http.StatusOK {
+		t.Fatalf("expected queued request 200, got %d", status)
+	}
+
+	text := fetchMetrics(t, metricsServer)
+	if value, ok := metricValue(text, "proxy_listener_shed_total", map[string]string{"listener": "http", "reason": "queue_full"}); !ok || value != 1 {
+		t.Fatalf("expected one queue_full shed, got %v", value)
+	}
+	if value, ok := metricValue(text, "proxy_listener_inflight_requests", map[string]string{"listener": "http"}); !ok || value != 0 {
+		t.Fatalf("expected inflight gauge to return to 0, got %v", value)
+	}
+}
+
+func TestListenerConnectionLimitClosesExcess(t *testing.T) {
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, nil)
+	defer closeUpstream()
+
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+	obs.SetDefaultMetrics(metrics)
+	defer obs.SetDefaultMetrics(nil)
+
+	cfgJSON := buildProxyConfig(upstreamAddr, `"limits": {"read_header_timeout_ms": 1000, "max_connections": 1}`)
+	serverHandle, _, _, _ := startProxy(t, cfgJSON)
+
+	first, err := net.Dial("tcp", serverHandle.HTTPAddr)
+	if err != nil {
+		t.Fatalf("dial first: %v", err)
+	}
+	defer first.Close()
+
+	metricsServer := httptest.NewServer(metrics.Handler())
+	defer metricsServer.Close()
+	testutil.Eventually(t, time.Second, 10*time.Millisecond, func() error {
+		text := fetchMetrics(t, metricsServer)
+		if value, ok := metricValue(text, "proxy_listener_open_connections", map[string]string{"listener": "http"}); !ok || value != 1 {
+			return fmt.Errorf("expected one open connection, got %v", value)
+		}
+		return nil
+	})
+
+	second, err := net.Dial("tcp", serverHandle.HTTPAddr)
+	if err != nil {
+		t.Fatalf("dial second: %v", err)
+	}
+	defer second.Close()
+	_ = second.SetReadDeadline(time.Now().Add(time.Second))
+	buffer := make([]byte, 1)
+	if _, err := second.Read(buffer); err == nil {
+		t.Fatalf("expected excess connection to be closed")
+	}
+
+	text := fetchMetrics(t, metricsServer)
+	if value, ok := metricValue(text, "proxy_listener_shed_total", map[string]string{"listener": "http", "reason": "connections"}); !ok || value < 1 {
+		t.Fatalf("expected connection shed metric, got %v", value)
+	}
+
+	first.Close()
+	client := &http.Client{Timeout: time.Second}
+	testutil.Eventually(t, time.Second, 20*time.Millisecond, func() error {
+		req, err := http.NewRequest(http.MethodGet, "http://"+serverHandle.HTTPAddr+"/", nil)
+		if err != nil {
+			return err
+		}
+		req.Host = "example.local"
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("expected 200, got %d", resp.StatusCode)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,59 @@
+package integration
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/server"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+func TestListenerSocketOptions(t *testing.T) {
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer closeUpstream()
+
+	cfgJSON := buildProxyConfig(upstreamAddr, `"limits": {"read_header_timeout_ms": 1000, "socket": {"reuse_port": true, "backlog": 64, "defer_accept_ms": 500, "keepalive_ms": 5000, "no_delay": false}}`)
+	serverHandle, store, _, _ := startProxy(t, cfgJSON)
+	socket := store.Get().Limits.Socket
+	if !socket.ReusePort || socket.Backlog != 64 || socket.DeferAccept != 500*time.Millisecond || socket.KeepAlive != 5*time.Second || socket.NoDelay {
+		t.Fatalf("unexpected socket options %+v", socket)
+	}
+
+	client := &http.Client{Timeout: 2 * time.Second}
+	resp, _ := sendProxyRequest(t, client, "http://"+serverHandle.HTTPAddr, "example.local", http.MethodGet, "/")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected tuned listener to serve, got %d", resp.StatusCode)
+	}
+
+	sibling, err := server.StartServers(http.NotFoundHandler(), nil, serverHandle.HTTPAddr, "", server.Options{Limits: store.Get().Limits})
+	if err != nil {
+		t.Fatalf("expected reuse_port to allow a second listener on %s: %v", serverHandle.HTTPAddr, err)
+	}
+	_ = sibling.Close()
+
+	plain := store.Get().Limits
+	plain.Socket.ReusePort = false
+	if _, err := server.StartServers(http.NotFoundHandler(), nil, serverHandle.HTTPAddr, "", server.Options{Limits: plain}); err == nil {
+		t.Fatalf("expected bind without reuse_port to fail while the address is in use")
+	}
+}
+
+func TestListenerSocketOptionsValidation(t *testing.T) {
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	for _, socket := range []string{`{"keepalive_ms": -5}`, `{"backlog": -1}`, `{"defer_accept_ms": -1}`} {
+		cfg, err := config.ParseJSON([]byte(buildProxyConfig("127.0.0.1:1", `"limits": {"read_header_timeout_ms": 1000, "socket": `+socket+`}`)))
+		if err != nil {
+			t.Fatalf("parse config: %v", err)
+		}
+		if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil); err == nil {
+			t.Fatalf("expected socket %s to be rejected", socket)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package integration
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+func TestZoneAwareLoadBalancing(t *testing.T) {
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+	metricsServer := httptest.NewServer(metrics.Handler())
+	defer metricsServer.Close()
+
+	var local []string
+	for i := 0; i < 3; i++ {
+		addr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, "local")
+		}))
+		defer closeUpstream()
+		local = append(local, addr)
+	}
+	remote, closeRemote := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer closeRemote()
+
+	cfg, err := config.ParseJSON([]byte(fmt.Sprintf(`{
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1"}],
+"pools": {"p1": {
+  "endpoints": [%[1]q, %[2]q, %[3]q, %[4]q],
+  "locality": {"local_zone": "zone-a", "min_local_healthy_percent": 70, "endpoint_zones": {%[1]q: "zone-a", %[2]q: "zone-a", %[3]q: "zone-a", %[4]q: "zone-b"}}
+}}
+}`, local[0], local[1], local[2], remote)))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	proxyServer := httptest.NewServer(&proxy.Handler{Store: runtime.NewStore(snap), Registry: reg, Engine: proxy.NewEngine(reg, nil, metrics, nil, nil), Metrics: metrics})
+	defer proxyServer.Close()
+
+	remoteShare := func() float64 {
+		picks := 0
+		for i := 0; i < 2000; i++ {
+			result, _ := reg.Pick("p1", nil)
+			if result.Addr == remote {
+				picks++
+			}
+		}
+		return float64(picks) / 2000
+	}
+	if got := remoteShare(); got != 0 {
+		t.Fatalf("expected healthy local zone to take all traffic, remote share %.2f", got)
+	}
+
+	client := &http.Client{Timeout: 2 * time.Second}
+	for i := 0; i < 3; i++ {
+		resp, body := sendProxyRequest(t, client, proxyServer.URL, "example.local", http.MethodGet, "/")
+		if resp.StatusCode != http.StatusOK || string(body) != "local" {
+			t.Fatalf("expected local endpoint to serve, got %d %q", resp.StatusCode, body)
+		}
+	}
+
+	reg.EjectEndpoint("p1", local[0])
+	reg.EjectEndpoint("p1", local[1])
+	if got := remoteShare(); got < 0.40 || got > 0.65 {
+		t.Fatalf("expected proportional failover with 1/3 local capacity, remote share %.2f", got)
+	}
+
+	for i := 0; i < 20; i++ {
+		sendProxyRequest(t, client, proxyServer.URL, "example.local", http.MethodGet, "/")
+	}
+	text := fetchMetrics(t, metricsServer)
+	if value, ok := metricValue(text, "proxy_upstream_zone_requests_total", map[string]string{"pool": "p1", "zone": "zone-a"}); !ok || value < 3 {
+		t.Fatalf("expected zone-a requests to be counted, got %v", value)
+	}
+	if value, ok := metricValue(text, "proxy_upstream_zone_errors_total", map[string]string{"pool": "p1", "zone": "zone-b"}); !ok || value < 1 {
+		t.Fatalf("expected zone-b errors to be counted, got %v", value)
+	}
+	if _, ok := metricValue(text, "proxy_upstream_zone_errors_total", map[string]string{"pool": "p1", "zone": "zone-a"}); ok {
+		t.Fatalf("expected no zone-a errors")
+	}
+}
+
+func TestZoneAwareValidation(t *testing.T) {
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	for _, locality := range []string{
+		`{"local_zone": "a"}`,
+		`{"local_zone": "a", "endpoint_zones": {"127.0.0.1:2": "a"}}`,
+		`{"local_zone": "a", "endpoint_zones": {"127.0.0.1:1": "a"}, "min_local_healthy_percent": 101}`,
+	} {
+		cfg, err := config.ParseJSON([]byte(`{
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1"}],
+"pools": {"p1": {"endpoints": ["127.0.0.1:1"], "locality": ` + locality + `}}
+}`))
+		if err != nil {
+			t.Fatalf("parse config: %v", err)
+		}
+		if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil); err == nil {
+			t.Fatalf("expected locality %s to be rejected", locality)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package integration
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protodelim"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+func TestMetricsHistogramBucketsAndExemplars(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer collector.Close()
+	tracer, err := obs.NewTracer(obs.TracerConfig{Endpoint: collector.URL, Sampler: obs.Sampler{Ratio: 1, ParentBased: true}})
+	if err != nil {
+		t.Fatalf("tracer: %v", err)
+	}
+	defer tracer.Close()
+	obs.SetDefaultTracer(tracer)
+	defer obs.SetDefaultTracer(nil)
+
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer closeUpstream()
+
+	metrics := obs.NewMetrics(obs.MetricsConfig{DurationBuckets: []float64{0.05, 0.5, 5}, NativeHistograms: true})
+	cfg := &config.Config{
+		Routes: []config.Route{{ID: "r", Host: "exemplar.local", PathPrefix: "/", Pool: "p"}},
+		Pools:  map[string]config.Pool{"p": {Endpoints: []string{upstreamAddr}}},
+	}
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	proxyServer := httptest.NewServer(&proxy.Handler{Store: runtime.NewStore(snap), Registry: reg, Engine: proxy.NewEngine(reg, nil, metrics, nil, nil), Metrics: metrics})
+	defer proxyServer.Close()
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	send := func(traceparent string) {
+		req, _ := http.NewRequest(http.MethodGet, proxyServer.URL+"/", nil)
+		req.Host = "exemplar.local"
+		req.Header.Set("traceparent", traceparent)
+		resp, err := proxyServer.Client().Do(req)
+		if err != nil {
+			t.Fatalf("request: %v", err)
+		}
+		resp.Body.Close()
+	}
+	send("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-00")
+	send("00-" + traceID + "-00f067aa0ba902b7-01")
+
+	scrape := func(accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Accept", accept)
+		recorder := httptest.NewRecorder()
+		metrics.Handler().ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	openMetrics := scrape("application/openmetrics-text; version=1.0.0")
+	var requestBuckets, roundTripExemplar bool
+	scanner := bufio.NewScanner(openMetrics.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "proxy_request_duration_seconds_bucket") {
+			if strings.Contains(line, `le="0.005"`) {
+				t.Fatalf("expected default buckets to be replaced, got %q", line)
+			}
+			if strings.Contains(line, `le="0.05"`) {
+				requestBuckets = strings.Contains(line, `# {trace_id="`+traceID+`"}`)
+			}
+		}
+		if strings.HasPrefix(line, "proxy_upstream_roundtrip_seconds_bucket") && strings.Contains(line, `# {trace_id="`+traceID+`"}`) {
+			roundTripExemplar = true
+		}
+	}
+	if !requestBuckets || !roundTripExemplar {
+		t.Fatalf("expected sampled trace id exemplars on latency histograms")
+	}
+	if strings.Contains(openMetrics.Body.String(), "0af7651916cd43dd8448eb211c80319c") {
+		t.Fatalf("expected unsampled trace to be left out of exemplars")
+	}
+
+	proto := scrape("application/vnd.google.protobuf;proto=io.prometheus.client.MetricFamily;encoding=delimited")
+	reader := bufio.NewReader(proto.Body)
+	native := false
+	for {
+		family := &dto.MetricFamily{}
+		if err := protodelim.UnmarshalFrom(reader, family); err != nil {
+			if err != io.EOF {
+				t.Fatalf("decode: %v", err)
+			}
+			break
+		}
+		if family.GetName() != "proxy_request_duration_seconds" {
+			continue
+		}
+		histogram := family.GetMetric()[0].GetHistogram()
+		native = histogram.Schema != nil && len(histogram.GetPositiveSpan()) > 0 && len(histogram.GetBucket()) == 3
+	}
+	if !native {
+		t.Fatalf("expected native histogram alongside classic buckets")
+	}
+}
+
+func TestMetricsHistogramValidation(t *testing.T) {
+	cases := map[string]string{
+		`{"buckets": [0.1, 0.1]}`:     "strictly increasing",
+		`{"buckets": [-1]}`:           "positive finite",
+		`{"native_bucket_factor": 1}`: "must be > 1",
+		`{"native_max_buckets": -5}`:  "must be >= 0",
+	}
+	for histograms, want := range cases {
+		cfg, err := config.ParseJSON([]byte(`{"metrics": {"histograms": ` + histograms + `}}`))
+		if err != nil {
+			t.Fatalf("parse %s: %v", histograms, err)
+		}
+		if _, err := config.Validate(cfg); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %s to fail with %q, got %v", histograms, want, err)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package integration
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
+)
+
+func readStatsD(t *testing.T, conn net.PacketConn) []string {
+	t.Helper()
+	var lines []string
+	buf := make([]byte, 65536)
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return lines
+		}
+		if n > 1432 {
+			t.Fatalf("expected statsd packets to fit a single datagram, got %d bytes", n)
+		}
+		lines = append(lines, strings.Split(string(buf[:n]), "\n")...)
+	}
+}
+
+func hasLine(lines []string, want string) bool {
+	for _, line := range lines {
+		if line == want {
+			return true
+		}
+	}
+	return false
+}
+
+func TestMetricsPushStatsDSendsDeltas(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer conn.Close()
+
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+	pusher, err := obs.NewPusher(metrics, obs.PushConfig{Type: obs.PushStatsD, Address: conn.LocalAddr().String(), Prefix: "edge."})
+	if err != nil {
+		t.Fatalf("pusher: %v", err)
+	}
+
+	metrics.RecordConfigApply("success")
+	metrics.RecordConfigApply("success")
+	metrics.RecordConfigApplyDuration(250 * time.Millisecond)
+	if err := pusher.Push(); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	first := readStatsD(t, conn)
+	if !hasLine(first, "edge.proxy_config_apply_total:2|c|#result:success") {
+		t.Fatalf("expected tagged counter line, got %v", first)
+	}
+	if !hasLine(first, "edge.proxy_config_apply_duration_seconds.count:1|c") || !hasLine(first, "edge.proxy_config_apply_duration_seconds.sum:0.25|c") {
+		t.Fatalf("expected histogram count and sum, got %v", first)
+	}
+
+	metrics.RecordConfigApply("success")
+	if err := pusher.Push(); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	second := readStatsD(t, conn)
+	if !hasLine(second, "edge.proxy_config_apply_total:1|c|#result:success") {
+		t.Fatalf("expected only the delta on second push, got %v", second)
+	}
+	for _, line := range second {
+		if strings.HasPrefix(line, "edge.proxy_config_apply_duration_seconds.") {
+			t.Fatalf("expected unchanged histogram to be skipped, got %q", line)
+		}
+	}
+}
+
+func TestMetricsPushOTLP(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		bodies = append(bodies, r.URL.Path+" "+r.Header.Get("Authorization")+" "+string(body))
+	}))
+	defer collector.Close()
+
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+	pusher, err := obs.NewPusher(metrics, obs.PushConfig{Type: obs.PushOTLP, Endpoint: collector.URL, Headers: map[string]string{"Authorization": "Bearer push"}, ServiceName: "edge-metrics"})
+	if err != nil {
+		t.Fatalf("pusher: %v", err)
+	}
+	metrics.RecordConfigApply("success")
+	metrics.RecordConfigApplyDuration(250 * time.Millisecond)
+	if err := pusher.Push(); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 1 || !strings.HasPrefix(bodies[0], "/v1/metrics Bearer push ") {
+		t.Fatalf("unexpected export requests %v", bodies)
+	}
+	var payload struct {
+		ResourceMetrics []struct {
+			Resource struct {
+				Attributes []struct {
+					Key   string `json:"key"`
+					Value struct {
+						StringValue string `json:"stringValue"`
+					} `json:"value"`
+				} `json:"attributes"`
+			} `json:"resource"`
+			ScopeMetrics []struct {
+				Metrics []struct {
+					Name string `json:"name"`
+					Sum  *struct {
+						IsMonotonic bool `json:"isMonotonic"`
+						DataPoints  []struct {
+							AsDouble float64 `json:"asDouble"`
+						} `json:"dataPoints"`
+					} `json:"sum"`
+					Histogram *struct {
+						DataPoints []struct {
+							Count          string    `json:"count"`
+							ExplicitBounds []float64 `json:"explicitBounds"`
+							BucketCounts   []string  `json:"bucketCounts"`
+						} `json:"dataPoints"`
+					} `json:"histogram"`
+				} `json:"metrics"`
+			} `json:"scopeMetrics"`
+		} `json:"resourceMetrics"`
+	}
+	if err := json.Unmarshal([]byte(strings.SplitN(bodies[0], " ", 4)[3]), &payload); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	resource := payload.ResourceMetrics[0]
+	if len(resource.Resource.Attributes) != 1 || resource.Resource.Attributes[0].Value.StringValue != "edge-metrics" {
+		t.Fatalf("expected service.name resource, got %+v", resource.Resource)
+	}
+	var sawSum, sawHistogram bool
+	for _, metric := range resource.ScopeMetrics[0].Metrics {
+		switch metric.Name {
+		case "proxy_config_apply_total":
+			sawSum = metric.Sum != nil && metric.Sum.IsMonotonic && len(metric.Sum.DataPoints) == 1 && metric.Sum.DataPoints[0].AsDouble == 1
+		case "proxy_config_apply_duration_seconds":
+			if metric.Histogram == nil || len(metric.Histogram.DataPoints) != 1 {
+				break
+			}
+			point := metric.Histogram.DataPoints[0]
+			sawHistogram = point.Count == "1" && len(point.BucketCounts) == len(point.ExplicitBounds)+1
+		}
+	}
+	if !sawSum || !sawHistogram {
+		t.Fatalf("expected monotonic sum and histogram, got %+v", resource.ScopeMetrics[0].Metrics)
+	}
+}
+
+func TestMetricsPushValidation(t *testing.T) {
+	cases := map[string]string{
+		`{"type": "statsd", "address": "statsd"}`:                   "host:port",
+		`{"type": "otlp", "endpoint": "collector"}`:                 "absolute http(s) url",
+		`{"type": "statsd", "address": ":8125", "interval_ms": -1}`: "must be >= 0",
+	}
+	for push, want := range cases {
+		cfg, err := config.ParseJSON([]byte(`{"metrics": {"push": ` + push + `}}`))
+		if err != nil {
+			t.Fatalf("parse %s: %v", push, err)
+		}
+		if _, err := config.Validate(cfg); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %s to fail with %q, got %v", push, want, err)
+		}
+	}
+	if _, err := config.ParseJSON([]byte(`{"metrics": {"push": {"type": "graphite"}}}`)); err == nil {
+		t.Fatalf("expected unknown push type to be rejected by schema")
+	}
+}
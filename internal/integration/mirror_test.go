@@ -0,0 +1,150 @@
+package integration
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestRequestMirroring(t *testing.T) {
+	primaryAddr, closePrimary := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, _ := io.ReadAll(r.Body)
+		_, _ = io.WriteString(w, "primary:"+string(payload))
+	}))
+	defer closePrimary()
+
+	mirrored := make(chan string, 8)
+	release := make(chan struct{})
+	shadowAddr, closeShadow := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, _ := io.ReadAll(r.Body)
+		mirrored <- fmt.Sprintf("%s %s %s", r.Method, r.URL.RequestURI(), payload)
+		<-release
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer closeShadow()
+
+	ignore := false
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+	cfg := &config.Config{
+		Routes: []config.Route{
+			{ID: "shadowed", Host: "example.local", PathPrefix: "/", Pool: "primary", Policy: config.RoutePolicy{
+				Mirror: config.MirrorConfig{Pool: "shadow", Percent: 100, IgnoreResponse: &ignore, MaxInflight: 1},
+			}},
+			{ID: "broken", Host: "broken.local", PathPrefix: "/", Pool: "primary", Policy: config.RoutePolicy{
+				Mirror: config.MirrorConfig{Pool: "dead", Percent: 100},
+			}},
+			{ID: "streamed", Host: "streamed.local", PathPrefix: "/", Pool: "primary", Policy: config.RoutePolicy{
+				RequestBody: config.RequestBodyConfig{Mode: "stream"},
+				Mirror:      config.MirrorConfig{Pool: "shadow", Percent: 100},
+			}},
+		},
+		Pools: map[string]config.Pool{
+			"primary": {Endpoints: []string{primaryAddr}},
+			"shadow":  {Endpoints: []string{shadowAddr}},
+			"dead":    {Endpoints: []string{"127.0.0.1:1"}},
+		},
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, traffic.NewRegistry(0, 0))
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	proxyServer := httptest.NewServer(&proxy.Handler{
+		Store:    runtime.NewStore(snap),
+		Registry: reg,
+		Engine:   proxy.NewEngine(reg, nil, metrics, nil, nil),
+		Metrics:  metrics,
+	})
+	defer proxyServer.Close()
+	client := &http.Client{Timeout: 2 * time.Second}
+
+	sendBody := func(host string, payload io.Reader) string {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, proxyServer.URL+"/orders?id=1", payload)
+		if err != nil {
+			t.Fatalf("new request: %v", err)
+		}
+		req.Host = host
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected primary response, got %d %q", resp.StatusCode, body)
+		}
+		return string(body)
+	}
+	send := func(host string, payload string) string {
+		t.Helper()
+		return sendBody(host, strings.NewReader(payload))
+	}
+
+	start := time.Now()
+	if got := send("example.local", "first"); got != "primary:first" {
+		t.Fatalf("expected primary body, got %q", got)
+	}
+	select {
+	case got := <-mirrored:
+		if got != "POST /orders?id=1 first" {
+			t.Fatalf("unexpected mirrored request %q", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected mirrored request")
+	}
+	if got := send("example.local", "second"); got != "primary:second" {
+		t.Fatalf("expected primary body while shadow is blocked, got %q", got)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected shadow latency to be hidden from client, took %v", elapsed)
+	}
+	close(release)
+
+	if got := send("broken.local", "third"); got != "primary:third" {
+		t.Fatalf("expected primary body with failing shadow, got %q", got)
+	}
+	if got := sendBody("streamed.local", io.MultiReader(strings.NewReader("fourth"))); got != "primary:fourth" {
+		t.Fatalf("expected primary body for chunked request, got %q", got)
+	}
+
+	metricsServer := httptest.NewServer(metrics.Handler())
+	defer metricsServer.Close()
+	testutil.Eventually(t, 2*time.Second, 20*time.Millisecond, func() error {
+		text := fetchMetrics(t, metricsServer)
+		expected := []map[string]string{
+			{"route": "shadowed", "pool": "shadow", "result": "5xx"},
+			{"route": "shadowed", "pool": "shadow", "result": "dropped"},
+			{"route": "broken", "pool": "dead", "result": "connect_failed"},
+			{"route": "streamed", "pool": "shadow", "result": "body_unknown_length"},
+		}
+		for _, labels := range expected {
+			if value, ok := metricValue(text, "proxy_mirror_requests_total", labels); !ok || value != 1 {
+				return fmt.Errorf("expected one mirror result %v, got %v", labels, value)
+			}
+		}
+		if value, ok := metricValue(text, "proxy_mirror_inflight_requests", map[string]string{"pool": "shadow"}); !ok || value != 0 {
+			return errors.New("expected shadow inflight to return to zero")
+		}
+		return nil
+	})
+
+	cfg.Routes[1].Policy.Mirror.Pool = "missing"
+	if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil); err == nil || !strings.Contains(err.Error(), "mirror references missing pool") {
+		t.Fatalf("expected missing mirror pool error, got %v", err)
+	}
+}
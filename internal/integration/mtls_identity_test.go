@@ -0,0 +1,127 @@
+package integration
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+func TestMTLSIdentityAuthorization(t *testing.T) {
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "identity="+r.Header.Get("X-Client-Identity")+" peer="+r.Header.Get("X-Peer-Id"))
+	})
+	addr, closeUpstream := testutil.StartUpstream(t, upstream)
+	defer closeUpstream()
+
+	serverCert := testutil.WriteSelfSignedCert(t, "svc.local")
+	clientCA := testutil.WriteCA(t, "mesh-ca")
+	ordersSVID := testutil.WriteSVID(t, "spiffe://example.org/ns/prod/sa/orders", nil, clientCA)
+	stagingSVID := testutil.WriteSVID(t, "spiffe://example.org/ns/staging/sa/orders", nil, clientCA)
+	billingCert := testutil.WriteSVID(t, "spiffe://other.org/billing", []string{"api.billing.internal"}, clientCA)
+
+	cfg := &config.Config{
+		TLS: config.TLSConfig{
+			Enabled:      true,
+			Addr:         "127.0.0.1:0",
+			ClientCAFile: clientCA.CertFile,
+			Certs:        []config.TLSCert{{ServerName: "svc.local", CertFile: serverCert.CertFile, KeyFile: serverCert.KeyFile}},
+		},
+		Routes: []config.Route{
+			{ID: "prod", Host: "svc.local", PathPrefix: "/prod", Pool: "p1", Policy: config.RoutePolicy{
+				RequireMTLS: true,
+				MTLS:        config.MTLSConfig{AllowedSPIFFEIDs: []string{"spiffe://example.org/ns/prod/sa/*"}},
+			}},
+			{ID: "billing", Host: "svc.local", PathPrefix: "/billing", Pool: "p1", Policy: config.RoutePolicy{
+				RequireMTLS: true,
+				MTLS:        config.MTLSConfig{AllowedSANs: []string{"*.billing.internal"}, IdentityHeader: "X-Peer-Id"},
+			}},
+		},
+		Pools: map[string]config.Pool{
+			"p1": {Endpoints: []string{addr}},
+		},
+	}
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+	proxyServer, _, _ := startTLSProxyWithMetrics(t, cfg, metrics)
+
+	oldStdout := os.Stdout
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe: %v", err)
+	}
+	os.Stdout = writer
+	defer func() {
+		os.Stdout = oldStdout
+	}()
+
+	rootPool := x509CertPool(t, serverCert.Cert)
+	clientFor := func(files testutil.CertFiles) *http.Client {
+		pair, err := tls.LoadX509KeyPair(files.CertFile, files.KeyFile)
+		if err != nil {
+			t.Fatalf("load client cert: %v", err)
+		}
+		return &http.Client{
+			Timeout: 2 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{RootCAs: rootPool, ServerName: "svc.local", Certificates: []tls.Certificate{pair}},
+			},
+		}
+	}
+	baseURL := "https://" + proxyServer.TLSAddr
+
+	resp, body := sendProxyRequestWithHeaders(t, clientFor(ordersSVID), baseURL, "svc.local", http.MethodGet, "/prod", map[string]string{"X-Client-Identity": "spoofed"})
+	if resp.StatusCode != http.StatusOK || string(body) != "identity=spiffe://example.org/ns/prod/sa/orders peer=" {
+		t.Fatalf("expected prod svid accepted with identity header, got %d %q", resp.StatusCode, body)
+	}
+
+	resp, body = sendProxyRequest(t, clientFor(stagingSVID), baseURL, "svc.local", http.MethodGet, "/prod")
+	assertProxyError(t, resp, body, "mtls_identity_denied")
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 for staging svid, got %d", resp.StatusCode)
+	}
+
+	resp, body = sendProxyRequest(t, clientFor(billingCert), baseURL, "svc.local", http.MethodGet, "/billing")
+	if resp.StatusCode != http.StatusOK || string(body) != "identity= peer=spiffe://other.org/billing" {
+		t.Fatalf("expected billing SAN accepted, got %d %q", resp.StatusCode, body)
+	}
+
+	resp, body = sendProxyRequest(t, clientFor(ordersSVID), baseURL, "svc.local", http.MethodGet, "/billing")
+	assertProxyError(t, resp, body, "mtls_identity_denied")
+
+	if err := writer.Close(); err != nil {
+		t.Fatalf("close writer: %v", err)
+	}
+	identities := make(map[string]bool)
+	for _, line := range readLines(t, reader) {
+		var payload map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &payload); err != nil {
+			continue
+		}
+		if identity, ok := payload["mtls_identity"].(string); ok {
+			identities[identity] = true
+		}
+	}
+	for _, expected := range []string{"spiffe://example.org/ns/prod/sa/orders", "spiffe://example.org/ns/staging/sa/orders", "spiffe://other.org/billing"} {
+		if !identities[expected] {
+			t.Fatalf("expected access log identity %q, got %v", expected, identities)
+		}
+	}
+
+	metricsServer := httptest.NewServer(metrics.Handler())
+	defer metricsServer.Close()
+	text := fetchMetrics(t, metricsServer)
+	if value, ok := metricValue(text, "proxy_mtls_identity_requests_total", map[string]string{"identity": "spiffe://example.org/ns/staging/sa/orders", "result": "denied"}); !ok || value != 1 {
+		t.Fatalf("expected one denied staging identity, got %v", value)
+	}
+	if value, ok := metricValue(text, "proxy_mtls_identity_requests_total", map[string]string{"identity": "spiffe://other.org/billing", "result": "allowed"}); !ok || value != 1 {
+		t.Fatalf("expected one allowed billing identity, got %v", value)
+	}
+}
@@ -0,0 +1,177 @@
+package integration
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+type multipartTestPart struct {
+	name        string
+	filename    string
+	contentType string
+	size        int
+}
+
+func buildMultipartBody(t *testing.T, parts []multipartTestPart) ([]byte, string) {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for _, part := range parts {
+		header := textproto.MIMEHeader{}
+		disposition := `form-data; name="` + part.name + `"`
+		if part.filename != "" {
+			disposition += `; filename="` + part.filename + `"`
+		}
+		header.Set("Content-Disposition", disposition)
+		if part.contentType != "" {
+			header.Set("Content-Type", part.contentType)
+		}
+		partWriter, err := writer.CreatePart(header)
+		if err != nil {
+			t.Fatalf("create part: %v", err)
+		}
+		_, _ = partWriter.Write(bytes.Repeat([]byte("x"), part.size))
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("close multipart: %v", err)
+	}
+	return body.Bytes(), writer.FormDataContentType()
+}
+
+func TestMultipartUploadLimits(t *testing.T) {
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reader, err := r.MultipartReader()
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var names []string
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			data, _ := io.ReadAll(part)
+			names = append(names, part.FormName()+":"+part.Header.Get("Content-Type")+":"+strconv.Itoa(len(data)))
+		}
+		_, _ = io.WriteString(w, strings.Join(names, ","))
+	}))
+	defer closeUpstream()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	cfg, err := config.ParseJSON([]byte(`{
+"routes": [
+  {"id": "strip", "host": "strip.local", "path_prefix": "/", "pool": "p1", "policy": {"request_body": {"mode": "stream", "multipart": {"enabled": true, "max_part_bytes": 500, "max_parts": 4, "allowed_content_types": ["image/*", "application/pdf"], "strip_disallowed": true}}}},
+  {"id": "strict", "host": "strict.local", "path_prefix": "/", "pool": "p1", "policy": {"request_body": {"multipart": {"enabled": true, "allowed_content_types": ["image/png"]}}}}
+],
+"pools": {"p1": {"endpoints": ["` + upstreamAddr + `"]}}
+}`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	proxyServer := httptest.NewServer(&proxy.Handler{
+		Store:    runtime.NewStore(snap),
+		Registry: reg,
+		Engine:   proxy.NewEngine(reg, nil, nil, nil, nil),
+	})
+	defer proxyServer.Close()
+	client := &http.Client{Timeout: 2 * time.Second}
+
+	send := func(host string, parts []multipartTestPart) (*http.Response, []byte) {
+		t.Helper()
+		body, contentType := buildMultipartBody(t, parts)
+		req, err := http.NewRequest(http.MethodPost, proxyServer.URL+"/upload", bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("new request: %v", err)
+		}
+		req.Host = host
+		req.Header.Set("Content-Type", contentType)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("upload: %v", err)
+		}
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(resp.Body)
+		return resp, respBody
+	}
+
+	resp, body := send("strip.local", []multipartTestPart{
+		{name: "note", size: 100},
+		{name: "avatar", filename: "a.png", contentType: "image/png", size: 400},
+		{name: "payload", filename: "run.exe", contentType: "application/x-msdownload", size: 300},
+		{name: "doc", filename: "d.pdf", contentType: "application/pdf", size: 200},
+	})
+	if resp.StatusCode != http.StatusOK || string(body) != "note::100,avatar:image/png:400,doc:application/pdf:200" {
+		t.Fatalf("expected disallowed part to be stripped, got %d %q", resp.StatusCode, body)
+	}
+
+	resp, body = send("strip.local", []multipartTestPart{{name: "avatar", filename: "a.png", contentType: "image/png", size: 600}})
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected oversized part to be rejected, got %d %q", resp.StatusCode, body)
+	}
+	assertProxyError(t, resp, body, "multipart_part_too_large")
+
+	resp, body = send("strip.local", []multipartTestPart{{name: "a"}, {name: "b"}, {name: "c"}, {name: "d"}, {name: "e"}})
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected too many parts to be rejected, got %d %q", resp.StatusCode, body)
+	}
+	assertProxyError(t, resp, body, "multipart_too_many_parts")
+
+	resp, body = send("strict.local", []multipartTestPart{{name: "doc", filename: "d.pdf", contentType: "application/pdf", size: 10}})
+	if resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Fatalf("expected disallowed content type to be rejected, got %d %q", resp.StatusCode, body)
+	}
+	assertProxyError(t, resp, body, "multipart_content_type")
+
+	resp, body = send("strict.local", []multipartTestPart{{name: "title", size: 10}, {name: "image", filename: "i.png", contentType: "image/png", size: 900}})
+	if resp.StatusCode != http.StatusOK || string(body) != "title::10,image:image/png:900" {
+		t.Fatalf("expected allowed upload to pass, got %d %q", resp.StatusCode, body)
+	}
+}
+
+func TestMultipartPolicyValidation(t *testing.T) {
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	for _, multipartCfg := range []string{
+		`{"enabled": true, "max_part_bytes": -1}`,
+		`{"enabled": true, "max_parts": -1}`,
+		`{"enabled": true, "strip_disallowed": true}`,
+		`{"enabled": true, "allowed_content_types": ["image"]}`,
+		`{"enabled": true, "allowed_content_types": ["*/*"]}`,
+		`{"enabled": true, "allowed_content_types": ["image/p*"]}`,
+	} {
+		cfg, err := config.ParseJSON([]byte(`{
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1", "policy": {"request_body": {"multipart": ` + multipartCfg + `}}}],
+"pools": {"p1": {"endpoints": ["127.0.0.1:1"]}}
+}`))
+		if err != nil {
+			t.Fatalf("parse config: %v", err)
+		}
+		if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil); err == nil {
+			t.Fatalf("expected multipart %s to be rejected", multipartCfg)
+		}
+	}
+}
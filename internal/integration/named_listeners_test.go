@@ -0,0 +1,201 @@
+package integration
+
+import (
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/server"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+func TestNamedListenersRouteAttachment(t *testing.T) {
+	upstream := func(name string) string {
+		addr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, name)
+		}))
+		t.Cleanup(closeUpstream)
+		return addr
+	}
+
+	cfg, err := config.ParseJSON([]byte(`{
+"listeners": [{"name": "internal", "addr": "127.0.0.1:0", "protocol": "http", "limits": {"max_header_bytes": 1024, "read_header_timeout_ms": 1000}}],
+"routes": [
+  {"id": "internal", "host": "svc.local", "path_prefix": "/internal", "pool": "internal", "listeners": ["internal"]},
+  {"id": "public", "host": "svc.local", "path_prefix": "/public", "pool": "public", "listeners": ["default"]},
+  {"id": "shared", "host": "svc.local", "path_prefix": "/", "pool": "shared"}
+],
+"pools": {
+  "internal": {"endpoints": ["` + upstream("internal") + `"]},
+  "public": {"endpoints": ["` + upstream("public") + `"]},
+  "shared": {"endpoints": ["` + upstream("shared") + `"]}
+}
+}`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	listeners := make([]runtime.Listener, 0, len(snap.Listeners))
+	for _, listener := range snap.Listeners {
+		listeners = append(listeners, listener)
+	}
+	sort.Slice(listeners, func(i, j int) bool { return listeners[i].Name < listeners[j].Name })
+
+	handler := &proxy.Handler{Store: runtime.NewStore(snap), Registry: reg, Engine: proxy.NewEngine(reg, nil, nil, nil, nil)}
+	serverHandle, err := server.StartServers(handler, nil, "127.0.0.1:0", "", server.Options{Limits: snap.Limits, Listeners: listeners})
+	if err != nil {
+		t.Fatalf("start servers: %v", err)
+	}
+	defer serverHandle.Close()
+	internalAddr := serverHandle.ListenerAddrs["internal"]
+	if internalAddr == "" {
+		t.Fatalf("expected internal listener address, got %v", serverHandle.ListenerAddrs)
+	}
+
+	client := &http.Client{Timeout: 2 * time.Second}
+	cases := []struct {
+		addr  string
+		path  string
+		route string
+	}{
+		{serverHandle.HTTPAddr, "/internal/x", "shared"},
+		{internalAddr, "/internal/x", "internal"},
+		{serverHandle.HTTPAddr, "/public/x", "public"},
+		{internalAddr, "/public/x", "shared"},
+		{serverHandle.HTTPAddr, "/other", "shared"},
+		{internalAddr, "/other", "shared"},
+	}
+	for _, tc := range cases {
+		resp, body := sendProxyRequest(t, client, "http://"+tc.addr, "svc.local", http.MethodGet, tc.path)
+		if resp.StatusCode != http.StatusOK || string(body) != tc.route {
+			t.Fatalf("%s%s: expected route %s, got %d %q", tc.addr, tc.path, tc.route, resp.StatusCode, body)
+		}
+	}
+
+	bigHeader := strings.Repeat("a", 32*1024)
+	headerRequest := func(addr string) int {
+		req, err := http.NewRequest(http.MethodGet, "http://"+addr+"/other", nil)
+		if err != nil {
+			t.Fatalf("new request: %v", err)
+		}
+		req.Host = "svc.local"
+		req.Header.Set("X-Big", bigHeader)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("request: %v", err)
+		}
+		defer resp.Body.Close()
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return resp.StatusCode
+	}
+	if status := headerRequest(serverHandle.HTTPAddr); status != http.StatusOK {
+		t.Fatalf("expected default listener to accept large header, got %d", status)
+	}
+	if status := headerRequest(internalAddr); status != http.StatusRequestHeaderFieldsTooLarge {
+		t.Fatalf("expected internal listener limits to reject large header, got %d", status)
+	}
+}
+
+func TestNamedListenersValidation(t *testing.T) {
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	cases := map[string]string{
+		"missing listener": `"routes": [{"id": "r", "host": "a.local", "path_prefix": "/", "pool": "p", "listeners": ["internal"]}]`,
+		"https without tls": `"listeners": [{"name": "internal", "addr": "127.0.0.1:0", "protocol": "https"}],
+"routes": [{"id": "r", "host": "a.local", "path_prefix": "/", "pool": "p"}]`,
+		"duplicate name": `"listeners": [{"name": "internal", "addr": "127.0.0.1:1"}, {"name": "internal", "addr": "127.0.0.1:2"}],
+"routes": [{"id": "r", "host": "a.local", "path_prefix": "/", "pool": "p"}]`,
+		"reserved name": `"listeners": [{"name": "default", "addr": "127.0.0.1:1"}],
+"routes": [{"id": "r", "host": "a.local", "path_prefix": "/", "pool": "p"}]`,
+		"queue without concurrency": `"listeners": [{"name": "internal", "addr": "127.0.0.1:1", "max_queued_requests": 2}],
+"routes": [{"id": "r", "host": "a.local", "path_prefix": "/", "pool": "p"}]`,
+		"negative connections": `"listeners": [{"name": "internal", "addr": "127.0.0.1:1", "max_connections": -1}],
+"routes": [{"id": "r", "host": "a.local", "path_prefix": "/", "pool": "p"}]`,
+	}
+	for name, body := range cases {
+		cfg, err := config.ParseJSON([]byte(`{` + body + `, "pools": {"p": {"endpoints": ["127.0.0.1:1"]}}}`))
+		if err != nil {
+			t.Fatalf("%s: parse config: %v", name, err)
+		}
+		if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil); err == nil {
+			t.Fatalf("%s: expected snapshot build to fail", name)
+		}
+	}
+}
+
+func TestNamedListenerConcurrencyOverrides(t *testing.T) {
+	started := make(chan struct{}, 4)
+	release := make(chan struct{})
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			started <- struct{}{}
+			<-release
+		}
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer closeUpstream()
+
+	cfg, err := config.ParseJSON([]byte(`{
+"limits": {"read_header_timeout_ms": 1000, "max_connections": 1},
+"listeners": [{"name": "internal", "addr": "127.0.0.1:0", "max_concurrent_requests": 1, "max_connections": 0}],
+"routes": [{"id": "r", "host": "svc.local", "path_prefix": "/", "pool": "p"}],
+"pools": {"p": {"endpoints": ["` + upstreamAddr + `"]}}
+}`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	internal := snap.Listeners["internal"]
+	if internal.Limits.MaxConcurrentRequests != 1 || internal.Limits.MaxConnections != 0 || internal.Limits.ReadHeaderTimeout != time.Second {
+		t.Fatalf("unexpected internal listener limits: %+v", internal.Limits)
+	}
+
+	handler := &proxy.Handler{Store: runtime.NewStore(snap), Registry: reg, Engine: proxy.NewEngine(reg, nil, nil, nil, nil)}
+	serverHandle, err := server.StartServers(handler, nil, "", "", server.Options{Limits: snap.Limits, Listeners: []runtime.Listener{internal}})
+	if err != nil {
+		t.Fatalf("start servers: %v", err)
+	}
+	defer serverHandle.Close()
+	baseURL := "http://" + serverHandle.ListenerAddrs["internal"]
+
+	firstDone := make(chan int, 1)
+	go func() {
+		client := &http.Client{Timeout: 5 * time.Second}
+		resp, _ := sendProxyRequest(t, client, baseURL, "svc.local", http.MethodGet, "/slow")
+		firstDone <- resp.StatusCode
+	}()
+	<-started
+
+	client := &http.Client{Timeout: 2 * time.Second}
+	resp, body := sendProxyRequest(t, client, baseURL, "svc.local", http.MethodGet, "/fast")
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected second connection to be accepted and shed with 503, got %d", resp.StatusCode)
+	}
+	assertProxyError(t, resp, body, "overloaded")
+
+	close(release)
+	if status := <-firstDone; status != http.StatusOK {
+		t.Fatalf("expected first request 200, got %d", status)
+	}
+	resp, _ = sendProxyRequest(t, client, baseURL, "svc.local", http.MethodGet, "/fast")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 once the slot is free, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,198 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestOIDCLoginFlow(t *testing.T) {
+	provider := testutil.StartOIDCProvider(t, "dashboard")
+	defer provider.Close()
+	proxyServer, metrics, closeProxy := startOIDCProxy(t, provider)
+	defer closeProxy()
+	client := &http.Client{
+		Timeout: 2 * time.Second,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	resp, body := sendProxyRequest(t, client, proxyServer.URL, "dash.local", http.MethodGet, "/api/status")
+	assertProxyError(t, resp, body, "auth_required")
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for api request, got %d", resp.StatusCode)
+	}
+
+	resp, body = sendProxyRequestWithHeaders(t, client, proxyServer.URL, "dash.local", http.MethodGet, "/api/status", map[string]string{
+		"X-Auth-Subject": "admin",
+	})
+	assertProxyError(t, resp, body, "auth_required")
+
+	session := oidcLogin(t, client, proxyServer.URL, "dash.local", "/reports?range=7d")
+
+	resp, body = sendProxyRequestWithHeaders(t, client, proxyServer.URL, "dash.local", http.MethodGet, "/reports", map[string]string{
+		"Cookie":         session.Name + "=" + session.Value + "; theme=dark",
+		"X-Auth-Subject": "admin",
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected authenticated request to pass, got %d %s", resp.StatusCode, body)
+	}
+	if got := string(body); got != "subject=user-123 email=user@example.com cookie=theme=dark" {
+		t.Fatalf("unexpected upstream view %q", got)
+	}
+
+	resp, body = sendProxyRequestWithHeaders(t, client, proxyServer.URL, "dash.local", http.MethodGet, "/reports", map[string]string{
+		"Cookie": session.Name + "=" + session.Value[:len(session.Value)-4] + "AAAA",
+	})
+	assertProxyError(t, resp, body, "auth_required")
+
+	resp, body = sendProxyRequestWithHeaders(t, client, proxyServer.URL, "dash.local", http.MethodGet, "/oauth2/callback?code=x&state=forged", nil)
+	assertProxyError(t, resp, body, "auth_failed")
+
+	metricsServer := httptest.NewServer(metrics.Handler())
+	defer metricsServer.Close()
+	text := fetchMetrics(t, metricsServer)
+	for result, minimum := range map[string]float64{"login_redirect": 1, "callback": 1, "authenticated": 1, "unauthenticated": 2, "invalid": 1} {
+		if value, ok := metricValue(text, "proxy_auth_results_total", map[string]string{"route": "dash", "mode": "oidc", "result": result}); !ok || value < minimum {
+			t.Fatalf("expected auth result %s recorded, got %v", result, value)
+		}
+	}
+}
+
+func TestOIDCSessionRefresh(t *testing.T) {
+	provider := testutil.StartOIDCProvider(t, "dashboard")
+	defer provider.Close()
+	proxyServer, _, closeProxy := startOIDCProxy(t, provider)
+	defer closeProxy()
+	client := &http.Client{
+		Timeout: 2 * time.Second,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	session := oidcLogin(t, client, proxyServer.URL, "short.local", "/")
+	time.Sleep(100 * time.Millisecond)
+
+	resp, body := sendProxyRequestWithHeaders(t, client, proxyServer.URL, "short.local", http.MethodGet, "/", map[string]string{
+		"Cookie": session.Name + "=" + session.Value,
+	})
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(string(body), "subject=user-123") {
+		t.Fatalf("expected refreshed session to pass, got %d %s", resp.StatusCode, body)
+	}
+	if provider.Refreshes() != 1 {
+		t.Fatalf("expected one refresh grant, got %d", provider.Refreshes())
+	}
+	refreshed := findCookie(resp, session.Name)
+	if refreshed == nil || refreshed.Value == "" || refreshed.Value == session.Value {
+		t.Fatalf("expected refreshed session cookie")
+	}
+}
+
+func oidcLogin(t *testing.T, client *http.Client, proxyURL string, host string, path string) *http.Cookie {
+	t.Helper()
+	resp, _ := sendProxyRequestWithHeaders(t, client, proxyURL, host, http.MethodGet, path, map[string]string{
+		"Accept": "text/html",
+	})
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("expected login redirect, got %d", resp.StatusCode)
+	}
+	login := findCookie(resp, "_proxy_oidc_login")
+	if login == nil {
+		t.Fatalf("expected login state cookie")
+	}
+
+	authorize, err := client.Get(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("authorize request: %v", err)
+	}
+	authorize.Body.Close()
+	if authorize.StatusCode != http.StatusFound {
+		t.Fatalf("expected provider redirect, got %d", authorize.StatusCode)
+	}
+	callback, err := url.Parse(authorize.Header.Get("Location"))
+	if err != nil || callback.Host != host || callback.Path != "/oauth2/callback" {
+		t.Fatalf("unexpected callback location %q", authorize.Header.Get("Location"))
+	}
+
+	resp, body := sendProxyRequestWithHeaders(t, client, proxyURL, host, http.MethodGet, callback.RequestURI(), map[string]string{
+		"Cookie": login.Name + "=" + login.Value,
+	})
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("expected callback redirect, got %d %s", resp.StatusCode, body)
+	}
+	if location := resp.Header.Get("Location"); location != path {
+		t.Fatalf("expected redirect back to %q, got %q", path, location)
+	}
+	session := findCookie(resp, "_proxy_oidc")
+	if session == nil || session.Value == "" || !session.HttpOnly {
+		t.Fatalf("expected http-only session cookie")
+	}
+	return session
+}
+
+func findCookie(resp *http.Response, name string) *http.Cookie {
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	return nil
+}
+
+func startOIDCProxy(t *testing.T, provider *testutil.OIDCProvider) (*httptest.Server, *obs.Metrics, func()) {
+	t.Helper()
+	t.Setenv("OIDC_TEST_COOKIE_SECRET", "0123456789abcdef0123456789abcdef")
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "subject=%s email=%s cookie=%s", r.Header.Get("X-Auth-Subject"), r.Header.Get("X-Auth-Email"), r.Header.Get("Cookie"))
+	})
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, upstream)
+
+	reg := registry.NewRegistry(0, 0)
+	trafficReg := traffic.NewRegistry(0, 0)
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+	oidcCfg := config.OIDCConfig{
+		Issuer:          provider.URL,
+		ClientID:        provider.ClientID,
+		CookieSecretEnv: "OIDC_TEST_COOKIE_SECRET",
+	}
+	shortCfg := oidcCfg
+	shortCfg.SessionTTLMS = 50
+	cfg := &config.Config{
+		Routes: []config.Route{
+			{ID: "dash", Host: "dash.local", PathPrefix: "/", Pool: "p1", Policy: config.RoutePolicy{Auth: "oidc", OIDC: oidcCfg}},
+			{ID: "short", Host: "short.local", PathPrefix: "/", Pool: "p1", Policy: config.RoutePolicy{Auth: "oidc", OIDC: shortCfg}},
+		},
+		Pools: map[string]config.Pool{
+			"p1": {Endpoints: []string{upstreamAddr}},
+		},
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	server := httptest.NewServer(&proxy.Handler{
+		Store:    runtime.NewStore(snap),
+		Registry: reg,
+		Engine:   proxy.NewEngine(reg, nil, metrics, nil, nil),
+		Metrics:  metrics,
+	})
+	return server, metrics, func() {
+		server.Close()
+		reg.Close()
+		closeUpstream()
+	}
+}
@@ -0,0 +1,69 @@
+package integration
+
+import (
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/outlier"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+)
+
+func TestOutlierSharedAcrossPoolKeys(t *testing.T) {
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	outlierReg := outlier.NewRegistry(0, 0, nil)
+	defer outlierReg.Close()
+
+	const addr = "127.0.0.1:9001"
+	build := func(shared bool) {
+		t.Helper()
+		outlierCfg := config.OutlierConfig{Enabled: true, ConsecutiveFailures: 2, BaseEjectMS: 60000, MaxEjectMS: 60000, MaxEjectPercent: 100, Shared: shared}
+		cfg := &config.Config{
+			Routes: []config.Route{
+				{ID: "r1", Host: "a.local", PathPrefix: "/", Pool: "p1"},
+				{ID: "r2", Host: "b.local", PathPrefix: "/", Pool: "p1"},
+				{ID: "r3", Host: "c.local", PathPrefix: "/", Pool: "p2"},
+				{ID: "r4", Host: "d.local", PathPrefix: "/", Pool: "p3"},
+			},
+			Pools: map[string]config.Pool{
+				"p1": {Endpoints: []string{addr}, Outlier: outlierCfg},
+				"p2": {Endpoints: []string{addr}, Outlier: outlierCfg},
+				"p3": {Endpoints: []string{addr}, Outlier: config.OutlierConfig{Enabled: true, ConsecutiveFailures: 2, BaseEjectMS: 60000, MaxEjectMS: 60000, MaxEjectPercent: 100}},
+			},
+		}
+		if _, err := runtime.BuildSnapshot(cfg, reg, nil, outlierReg, nil); err != nil {
+			t.Fatalf("build snapshot: %v", err)
+		}
+	}
+
+	build(true)
+	outlierReg.RecordResult("r1::p1", addr, false, 0)
+	if ejected, _ := outlierReg.RecordResult("r3::p2", addr, false, 0); !ejected {
+		t.Fatalf("expected failures from different pool keys to accumulate on the shared endpoint")
+	}
+	now := time.Now()
+	for _, key := range []string{"r1::p1", "r2::p1", "r3::p2"} {
+		if !outlierReg.IsEjected(key, addr, now) {
+			t.Fatalf("expected %s to see the shared ejection", key)
+		}
+	}
+	if outlierReg.IsEjected("r4::p3", addr, now) {
+		t.Fatalf("expected pool without shared outlier state to stay independent")
+	}
+
+	outlierReg.Reset("r2::p1", addr)
+	if outlierReg.IsEjected("r3::p2", addr, time.Now()) {
+		t.Fatalf("expected reset through one pool key to clear the shared state")
+	}
+
+	build(false)
+	outlierReg.RecordResult("r1::p1", addr, false, 0)
+	if ejected, _ := outlierReg.RecordResult("r1::p1", addr, false, 0); !ejected {
+		t.Fatalf("expected per-key ejection after disabling shared state")
+	}
+	if outlierReg.IsEjected("r2::p1", addr, time.Now()) {
+		t.Fatalf("expected per-key state once shared is disabled")
+	}
+}
@@ -0,0 +1,62 @@
+package integration
+
+import (
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/pool"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+func TestPassiveHealthByStatus(t *testing.T) {
+	good, closeGood := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "good")
+	}))
+	defer closeGood()
+	broken, closeBroken := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer closeBroken()
+
+	serverHandle, _, reg, _ := startProxy(t, `{
+"listen_addr": "127.0.0.1:0",
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1"}],
+"pools": {"p1": {"endpoints": ["`+good+`", "`+broken+`"], "health": {"interval_ms": 60000, "unhealthy_after_failures": 1, "passive_failure_statuses": ["503"], "unhealthy_after_status_failures": 3}}}
+}`)
+	client := &http.Client{Timeout: 2 * time.Second}
+	proxyURL := "http://" + serverHandle.HTTPAddr
+
+	brokenStatus := func() pool.EndpointStatus {
+		statuses, _ := reg.PoolStatus("p1")
+		for _, status := range statuses {
+			if status.Addr == broken {
+				return status
+			}
+		}
+		t.Fatalf("endpoint %s missing", broken)
+		return pool.EndpointStatus{}
+	}
+
+	for i := 0; i < 4; i++ {
+		sendProxyRequest(t, client, proxyURL, "example.local", http.MethodGet, "/")
+	}
+	if status := brokenStatus(); status.State != "healthy" || status.ConsecutiveStatusFailures != 2 {
+		t.Fatalf("expected status failures to use their own threshold, got %+v", status)
+	}
+
+	for i := 0; i < 2; i++ {
+		sendProxyRequest(t, client, proxyURL, "example.local", http.MethodGet, "/")
+	}
+	if status := brokenStatus(); status.State != "unhealthy" {
+		t.Fatalf("expected repeated 503s to mark endpoint unhealthy, got %+v", status)
+	}
+
+	for i := 0; i < 6; i++ {
+		resp, body := sendProxyRequest(t, client, proxyURL, "example.local", http.MethodGet, "/")
+		if resp.StatusCode != http.StatusOK || string(body) != "good" {
+			t.Fatalf("expected traffic to avoid the broken endpoint, got %d %q", resp.StatusCode, body)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package integration
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/plugin"
+	"modern_reverse_proxy/internal/plugin/proto"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+type inspectedBody struct {
+	phase     pluginpb.BodyChunk_Phase
+	data      []byte
+	truncated bool
+}
+
+func TestPluginBodyInspection(t *testing.T) {
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.URL.Path == "/leak" {
+			_, _ = io.WriteString(w, "card=4111111111111111")
+			return
+		}
+		_, _ = w.Write(body)
+	})
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, upstream)
+	defer closeUpstream()
+
+	var mu sync.Mutex
+	var seen []inspectedBody
+	pluginAddr, closePlugin := testutil.StartPluginServer(t, testutil.PluginHandlers{
+		InspectBody: func(stream pluginpb.FilterService_InspectBodyServer) error {
+			var inspected inspectedBody
+			for {
+				chunk, err := stream.Recv()
+				if err != nil {
+					if errors.Is(err, io.EOF) {
+						return nil
+					}
+					return err
+				}
+				inspected.phase = chunk.GetPhase()
+				inspected.data = append(inspected.data, chunk.GetData()...)
+				if bytes.Contains(inspected.data, []byte("attack")) || bytes.Contains(inspected.data, []byte("4111")) {
+					return stream.Send(&pluginpb.BodyVerdict{
+						Action:          pluginpb.BodyVerdict_RESPOND,
+						ResponseStatus:  http.StatusForbidden,
+						ResponseHeaders: map[string]string{"X-Blocked-By": "waf"},
+						ResponseBody:    []byte("blocked"),
+					})
+				}
+				if chunk.GetLast() {
+					inspected.truncated = chunk.GetTruncated()
+					mu.Lock()
+					seen = append(seen, inspected)
+					mu.Unlock()
+					return stream.Send(&pluginpb.BodyVerdict{Action: pluginpb.BodyVerdict_CONTINUE})
+				}
+			}
+		},
+	})
+	defer closePlugin()
+
+	proxyServer, metrics, closeProxy := startBodyPluginProxy(t, upstreamAddr, pluginAddr, 1024, 0)
+	defer closeProxy()
+	client := &http.Client{Timeout: 2 * time.Second}
+
+	resp, body := sendProxyBody(t, client, proxyServer.URL, "/echo", "hello world")
+	if resp.StatusCode != http.StatusOK || body != "hello world" {
+		t.Fatalf("expected echoed body, got %d %q", resp.StatusCode, body)
+	}
+
+	resp, body = sendProxyBody(t, client, proxyServer.URL, "/echo", "an attack payload")
+	if resp.StatusCode != http.StatusForbidden || body != "blocked" {
+		t.Fatalf("expected request body rejection, got %d %q", resp.StatusCode, body)
+	}
+	if resp.Header.Get("X-Blocked-By") != "waf" {
+		t.Fatalf("expected plugin response header")
+	}
+
+	large := strings.Repeat("a", 4096)
+	resp, body = sendProxyBody(t, client, proxyServer.URL, "/echo", large)
+	if resp.StatusCode != http.StatusOK || body != large {
+		t.Fatalf("expected full large body forwarded, got %d len=%d", resp.StatusCode, len(body))
+	}
+	mu.Lock()
+	var truncated *inspectedBody
+	for i := range seen {
+		if seen[i].phase == pluginpb.BodyChunk_REQUEST && seen[i].truncated {
+			truncated = &seen[i]
+		}
+	}
+	mu.Unlock()
+	if truncated == nil || len(truncated.data) != 1024 {
+		t.Fatalf("expected truncated request inspection capped at 1024 bytes")
+	}
+
+	resp, body = sendProxyBody(t, client, proxyServer.URL, "/leak", "")
+	if resp.StatusCode != http.StatusForbidden || body != "blocked" {
+		t.Fatalf("expected response body rejection, got %d %q", resp.StatusCode, body)
+	}
+
+	metricsServer := httptest.NewServer(metrics.Handler())
+	defer metricsServer.Close()
+	text := fetchMetrics(t, metricsServer)
+	if value, ok := metricValue(text, "proxy_plugin_calls_total", map[string]string{"filter": "waf", "phase": "request_body", "result": "success"}); !ok || value < 2 {
+		t.Fatalf("expected request body plugin calls recorded")
+	}
+}
+
+func TestPluginBodyInspectionTimeoutFailOpen(t *testing.T) {
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	})
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, upstream)
+	defer closeUpstream()
+
+	pluginAddr, closePlugin := testutil.StartPluginServer(t, testutil.PluginHandlers{
+		InspectBody: func(stream pluginpb.FilterService_InspectBodyServer) error {
+			<-stream.Context().Done()
+			return stream.Context().Err()
+		},
+	})
+	defer closePlugin()
+
+	proxyServer, metrics, closeProxy := startBodyPluginProxy(t, upstreamAddr, pluginAddr, 0, 50)
+	defer closeProxy()
+	client := &http.Client{Timeout: 2 * time.Second}
+
+	resp, body := sendProxyBody(t, client, proxyServer.URL, "/echo", "payload")
+	if resp.StatusCode != http.StatusOK || body != "payload" {
+		t.Fatalf("expected body forwarded on fail open, got %d %q", resp.StatusCode, body)
+	}
+	metricsServer := httptest.NewServer(metrics.Handler())
+	defer metricsServer.Close()
+	text := fetchMetrics(t, metricsServer)
+	if value, ok := metricValue(text, "proxy_plugin_calls_total", map[string]string{"filter": "waf", "phase": "request_body", "result": "timeout"}); !ok || value < 1 {
+		t.Fatalf("expected body inspection timeout recorded")
+	}
+}
+
+func startBodyPluginProxy(t *testing.T, upstreamAddr string, pluginAddr string, maxBodyBytes int64, bodyTimeoutMS int) (*httptest.Server, *obs.Metrics, func()) {
+	t.Helper()
+	reg := registry.NewRegistry(0, 0)
+	trafficReg := traffic.NewRegistry(0, 0)
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+	pluginReg := plugin.NewRegistry(0)
+
+	cfg := &config.Config{
+		Routes: []config.Route{{
+			ID:         "r1",
+			Host:       "example.local",
+			PathPrefix: "/",
+			Pool:       "p1",
+			Policy: config.RoutePolicy{
+				Plugins: config.PluginConfig{
+					Enabled: true,
+					Filters: []config.PluginFilter{{
+						Name:          "waf",
+						Addr:          pluginAddr,
+						FailureMode:   "fail_open",
+						InspectBody:   true,
+						MaxBodyBytes:  maxBodyBytes,
+						BodyTimeoutMS: bodyTimeoutMS,
+					}},
+				},
+			},
+		}},
+		Pools: map[string]config.Pool{
+			"p1": {Endpoints: []string{upstreamAddr}},
+		},
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	server := httptest.NewServer(&proxy.Handler{
+		Store:          runtime.NewStore(snap),
+		Registry:       reg,
+		Engine:         proxy.NewEngine(reg, nil, metrics, nil, nil),
+		Metrics:        metrics,
+		PluginRegistry: pluginReg,
+	})
+	return server, metrics, func() {
+		server.Close()
+		pluginReg.Close()
+		reg.Close()
+	}
+}
+
+func sendProxyBody(t *testing.T, client *http.Client, baseURL string, path string, payload string) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, baseURL+path, strings.NewReader(payload))
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	req.Host = "example.local"
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	return resp, string(body)
+}
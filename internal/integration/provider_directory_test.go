@@ -0,0 +1,116 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"modern_reverse_proxy/internal/provider"
+)
+
+func writeConfigFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, body := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+}
+
+func TestDirectoryProviderMergesFilesAndIncludes(t *testing.T) {
+	root := t.TempDir()
+	confDir := filepath.Join(root, "conf.d")
+	writeConfigFiles(t, root, map[string]string{
+		"conf.d/10-base.json": `{
+"listen_addr": "127.0.0.1:0",
+"limits": {"max_header_bytes": 4096},
+"include": ["../shared/*.json"]
+}`,
+		"conf.d/20-payments.json": `{
+"routes": [{"id": "payments", "host": "pay.local", "path_prefix": "/", "pool": "shared"}]
+}`,
+		"conf.d/30-search.json": `{
+"routes": [{"id": "search", "host": "search.local", "path_prefix": "/", "pool": "search"}],
+"pools": {"search": {"endpoints": ["127.0.0.1:9002"]}}
+}`,
+		"conf.d/notes.txt":   `ignored`,
+		"shared/pools.json":  `{"pools": {"shared": {"endpoints": ["127.0.0.1:9001"]}}}`,
+		"shared/routes.json": `{"routes": [{"id": "health", "host": "health.local", "path_prefix": "/", "pool": "shared"}]}`,
+	})
+
+	cfg, err := provider.NewDirectoryProvider(confDir).Load(context.Background())
+	if err != nil {
+		t.Fatalf("load dir: %v", err)
+	}
+	var ids []string
+	for _, route := range cfg.Routes {
+		ids = append(ids, route.ID)
+	}
+	if got := strings.Join(ids, ","); got != "health,payments,search" {
+		t.Fatalf("expected includes first then lexical order, got %s", got)
+	}
+	if len(cfg.Pools) != 2 || len(cfg.Pools["shared"].Endpoints) != 1 {
+		t.Fatalf("expected included pool to merge, got %+v", cfg.Pools)
+	}
+	if cfg.ListenAddr != "127.0.0.1:0" || cfg.Limits.MaxHeaderBytes != 4096 {
+		t.Fatalf("expected globals from base file, got listen=%q limits=%+v", cfg.ListenAddr, cfg.Limits)
+	}
+}
+
+func TestDirectoryProviderConflictNamesFiles(t *testing.T) {
+	confDir := t.TempDir()
+	writeConfigFiles(t, confDir, map[string]string{
+		"10-team-a.json": `{"routes": [{"id": "api", "host": "a.local", "path_prefix": "/", "pool": "p"}], "pools": {"p": {"endpoints": ["127.0.0.1:9001"]}}}`,
+		"20-team-b.json": `{"routes": [{"id": "api", "host": "b.local", "path_prefix": "/", "pool": "p"}]}`,
+	})
+
+	_, err := provider.NewDirectoryProvider(confDir).Load(context.Background())
+	var conflict *provider.ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("expected conflict error, got %v", err)
+	}
+	if conflict.ObjectID != "api" || conflict.Field != "host" {
+		t.Fatalf("unexpected conflict target: %+v", conflict)
+	}
+	if conflict.ExistingProvider != "dir:10-team-a.json" || conflict.IncomingProvider != "dir:20-team-b.json" {
+		t.Fatalf("expected per-file attribution, got %s vs %s", conflict.ExistingProvider, conflict.IncomingProvider)
+	}
+
+	writeConfigFiles(t, confDir, map[string]string{
+		"20-team-b.json": `{"limits": {"max_header_bytes": 1024}}`,
+		"30-team-c.json": `{"limits": {"max_header_bytes": 2048}}`,
+	})
+	_, err = provider.NewDirectoryProvider(confDir).Load(context.Background())
+	if !errors.As(err, &conflict) || conflict.ObjectID != "limits" || conflict.IncomingProvider != "dir:30-team-c.json" {
+		t.Fatalf("expected limits conflict from team-c, got %v", err)
+	}
+}
+
+func TestDirectoryProviderRejectsIncludeErrors(t *testing.T) {
+	confDir := t.TempDir()
+	writeConfigFiles(t, confDir, map[string]string{
+		"a.json":        `{"include": ["parts/b.json"]}`,
+		"parts/b.json":  `{"include": ["../a.json"]}`,
+		"z-missing.txt": ``,
+	})
+	_, err := provider.NewDirectoryProvider(confDir).Load(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "include cycle: a.json -> parts/b.json -> a.json") {
+		t.Fatalf("expected include cycle error, got %v", err)
+	}
+
+	missingDir := t.TempDir()
+	writeConfigFiles(t, missingDir, map[string]string{
+		"a.json": `{"include": ["nope.json"]}`,
+	})
+	_, err = provider.NewDirectoryProvider(missingDir).Load(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "nope.json") {
+		t.Fatalf("expected missing include error, got %v", err)
+	}
+}
@@ -0,0 +1,197 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/admin"
+	"modern_reverse_proxy/internal/apply"
+	"modern_reverse_proxy/internal/bundle"
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/distributor"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/pull"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/rollout"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestPullStatusReportsFailuresAndRecovery(t *testing.T) {
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer closeUpstream()
+
+	configJSON := fmt.Sprintf(`{
+"listen_addr": "127.0.0.1:0",
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1"}],
+"pools": {"p1": {"endpoints": ["%s"]}}
+}`, upstreamAddr)
+
+	trustedKey := testutil.WriteEd25519KeyPair(t, "trusted")
+	rogueKey := testutil.WriteEd25519KeyPair(t, "rogue")
+
+	meta := bundle.Meta{
+		Version:   "pull-status-v1",
+		CreatedAt: time.Now().UTC().Format(time.RFC3339Nano),
+		Source:    "distributor",
+	}
+	badBundle, err := bundle.NewSignedBundle([]byte(configJSON), meta, rogueKey.PrivateKey)
+	if err != nil {
+		t.Fatalf("sign bad bundle: %v", err)
+	}
+	goodMeta := meta
+	goodMeta.Version = "pull-status-v2"
+	goodMeta.CreatedAt = time.Now().Add(10 * time.Millisecond).UTC().Format(time.RFC3339Nano)
+	goodBundle, err := bundle.NewSignedBundle([]byte(configJSON), goodMeta, trustedKey.PrivateKey)
+	if err != nil {
+		t.Fatalf("sign good bundle: %v", err)
+	}
+
+	storage := bundle.NewMemoryStorage()
+	if err := storage.Put(badBundle); err != nil {
+		t.Fatalf("store bad bundle: %v", err)
+	}
+	distributorServer := httptest.NewServer(distributor.NewHandler(distributor.Config{Storage: storage}))
+	defer distributorServer.Close()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+	obs.SetDefaultMetrics(metrics)
+	defer obs.SetDefaultMetrics(nil)
+
+	cfg, err := config.ParseJSON([]byte(configJSON))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	store := runtime.NewStore(snap)
+
+	applyManager := apply.NewManager(apply.ManagerConfig{
+		Store:           store,
+		Registry:        reg,
+		TrafficRegistry: trafficReg,
+	})
+	rolloutManager := rollout.NewManager(rollout.Config{
+		ApplyManager:     applyManager,
+		Store:            store,
+		Metrics:          metrics,
+		LockedBake:       20 * time.Millisecond,
+		ErrorRateWindow:  500 * time.Millisecond,
+		ErrorRatePercent: 50,
+	})
+	puller := pull.NewPuller(pull.Config{
+		Enabled:        true,
+		BaseURL:        distributorServer.URL,
+		Interval:       20 * time.Millisecond,
+		PublicKey:      trustedKey.PublicKey,
+		RolloutManager: rolloutManager,
+		Store:          store,
+		HTTPClient:     distributorServer.Client(),
+	})
+
+	ca := testutil.WriteCA(t, "admin-ca")
+	serverCert := testutil.WriteServerCert(t, "admin.local", ca)
+	clientCert := testutil.WriteClientCert(t, "client", ca)
+	adminTLS := newAdminTLSConfig(t, serverCert.CertFile, serverCert.KeyFile, ca.CertFile)
+	auth, err := admin.NewAuthenticator(admin.AuthConfig{Token: "secret", ClientCAFile: ca.CertFile})
+	if err != nil {
+		t.Fatalf("auth config: %v", err)
+	}
+	adminServer := startAdminServer(t, admin.NewHandler(admin.HandlerConfig{
+		Store:          store,
+		ApplyManager:   applyManager,
+		Auth:           auth,
+		RolloutManager: rolloutManager,
+		Puller:         puller,
+	}), adminTLS)
+	defer adminServer.Close()
+	client := testutil.NewAdminClient(t, testutil.AdminClientConfig{CAFile: ca.CertFile, ClientCert: &clientCert, Token: "secret", ServerName: "admin.local"})
+
+	fetchStatus := func() pull.Status {
+		resp, err := client.Do(mustAdminRequest(t, http.MethodGet, adminServer.URL+"/admin/pull/status", nil))
+		if err != nil {
+			t.Fatalf("status request: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200, got %d", resp.StatusCode)
+		}
+		var status pull.Status
+		if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+			t.Fatalf("decode status: %v", err)
+		}
+		return status
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go puller.Run(ctx)
+
+	testutil.Eventually(t, 2*time.Second, 20*time.Millisecond, func() error {
+		status := fetchStatus()
+		if status.ConsecutiveFailures < 2 {
+			return fmt.Errorf("expected repeated failures, got %d", status.ConsecutiveFailures)
+		}
+		if status.LastResult != "bad_sig" {
+			return fmt.Errorf("expected bad_sig result, got %q", status.LastResult)
+		}
+		if status.VerificationFailures < 2 || status.LastVerifyError == "" {
+			return fmt.Errorf("expected verification failures recorded, got %+v", status)
+		}
+		if status.LastVersionSeen != meta.Version {
+			return fmt.Errorf("expected version seen %q, got %q", meta.Version, status.LastVersionSeen)
+		}
+		if !status.LastSuccessAt.IsZero() {
+			return fmt.Errorf("expected no successful pull, got %v", status.LastSuccessAt)
+		}
+		return nil
+	})
+
+	metricsServer := httptest.NewServer(metrics.Handler())
+	defer metricsServer.Close()
+	text := fetchMetrics(t, metricsServer)
+	if value, ok := metricValue(text, "proxy_pull_total", map[string]string{"result": "bad_sig"}); !ok || value < 2 {
+		t.Fatalf("expected bad_sig pull metric, got %v", value)
+	}
+	if value, ok := parseMetricCount(text, "proxy_pull_consecutive_failures"); !ok || value < 2 {
+		t.Fatalf("expected consecutive failure gauge, got %v", value)
+	}
+
+	if err := storage.Put(goodBundle); err != nil {
+		t.Fatalf("store good bundle: %v", err)
+	}
+
+	testutil.Eventually(t, 2*time.Second, 20*time.Millisecond, func() error {
+		status := fetchStatus()
+		if status.ConsecutiveFailures != 0 {
+			return fmt.Errorf("expected failures reset, got %d", status.ConsecutiveFailures)
+		}
+		if status.LastAppliedVersion != goodMeta.Version {
+			return fmt.Errorf("expected applied version %q, got %q", goodMeta.Version, status.LastAppliedVersion)
+		}
+		if status.LastSuccessAt.IsZero() {
+			return fmt.Errorf("expected last success time")
+		}
+		return nil
+	})
+
+	text = fetchMetrics(t, metricsServer)
+	if value, ok := parseMetricCount(text, "proxy_pull_consecutive_failures"); !ok || value != 0 {
+		t.Fatalf("expected consecutive failure gauge reset, got %v", value)
+	}
+}
@@ -0,0 +1,137 @@
+package integration
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+func TestRequestBodyStreaming(t *testing.T) {
+	firstChunk := make(chan struct{}, 1)
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		head := make([]byte, 300)
+		if _, err := io.ReadFull(r.Body, head); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		select {
+		case firstChunk <- struct{}{}:
+		default:
+		}
+		rest, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = io.WriteString(w, strconv.Itoa(len(head)+len(rest)))
+	}))
+	defer closeUpstream()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	cfg, err := config.ParseJSON([]byte(`{
+"limits": {"max_body_bytes": 100},
+"routes": [
+  {"id": "stream", "host": "stream.local", "path_prefix": "/", "pool": "p1", "policy": {"request_body": {"mode": "stream", "max_body_bytes": 1000}}},
+  {"id": "buffer", "host": "buffer.local", "path_prefix": "/", "pool": "p1", "policy": {"request_body": {"max_body_bytes": 1000}}},
+  {"id": "default", "host": "default.local", "path_prefix": "/", "pool": "p1"}
+],
+"pools": {"p1": {"endpoints": ["` + upstreamAddr + `"]}}
+}`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	proxyServer := httptest.NewServer(&proxy.Handler{
+		Store:    runtime.NewStore(snap),
+		Registry: reg,
+		Engine:   proxy.NewEngine(reg, nil, nil, nil, nil),
+	})
+	defer proxyServer.Close()
+	proxyAddr := strings.TrimPrefix(proxyServer.URL, "http://")
+	client := &http.Client{Timeout: 2 * time.Second}
+
+	reader, writer := io.Pipe()
+	go func() {
+		_, _ = writer.Write(bytes.Repeat([]byte("a"), 300))
+		select {
+		case <-firstChunk:
+			_, _ = writer.Write(bytes.Repeat([]byte("a"), 300))
+			writer.Close()
+		case <-time.After(time.Second):
+			writer.CloseWithError(io.ErrUnexpectedEOF)
+		}
+	}()
+	req, err := http.NewRequest(http.MethodPost, proxyServer.URL+"/", reader)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	req.Host = "stream.local"
+	streamed, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("streamed request: %v", err)
+	}
+	body, _ := io.ReadAll(streamed.Body)
+	streamed.Body.Close()
+	if streamed.StatusCode != http.StatusOK || string(body) != "600" {
+		t.Fatalf("expected upstream to receive the body before the client finished, got %d %q", streamed.StatusCode, body)
+	}
+
+	resp, body := sendProxyRequestChunked(t, client, proxyAddr, "buffer.local", bytes.Repeat([]byte("b"), 600))
+	if resp.StatusCode != http.StatusOK || string(body) != "600" {
+		t.Fatalf("expected buffered body within route limit, got %d %q", resp.StatusCode, body)
+	}
+
+	for _, host := range []string{"stream.local", "buffer.local"} {
+		resp, body = sendProxyRequestChunked(t, client, proxyAddr, host, bytes.Repeat([]byte("c"), 2000))
+		if resp.StatusCode != http.StatusRequestEntityTooLarge {
+			t.Fatalf("expected 413 for oversized chunked body via %s, got %d %q", host, resp.StatusCode, body)
+		}
+		assertProxyError(t, resp, body, "request_too_large")
+
+		resp, body = sendProxyRequestWithBody(t, client, proxyAddr, host, bytes.Repeat([]byte("d"), 2000))
+		if resp.StatusCode != http.StatusRequestEntityTooLarge {
+			t.Fatalf("expected 413 for oversized body via %s, got %d %q", host, resp.StatusCode, body)
+		}
+		assertProxyError(t, resp, body, "request_too_large")
+	}
+
+	resp, body = sendProxyRequestChunked(t, client, proxyAddr, "default.local", bytes.Repeat([]byte("e"), 600))
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected global limit without route override, got %d %q", resp.StatusCode, body)
+	}
+}
+
+func TestRequestBodyPolicyValidation(t *testing.T) {
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	for _, bodyCfg := range []string{
+		`{"mode": "spool"}`,
+		`{"mode": "stream", "max_body_bytes": -1}`,
+	} {
+		cfg, err := config.ParseJSON([]byte(`{
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1", "policy": {"request_body": ` + bodyCfg + `}}],
+"pools": {"p1": {"endpoints": ["127.0.0.1:1"]}}
+}`))
+		if err != nil {
+			t.Fatalf("parse config: %v", err)
+		}
+		if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil); err == nil {
+			t.Fatalf("expected request_body %s to be rejected", bodyCfg)
+		}
+	}
+}
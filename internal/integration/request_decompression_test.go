@@ -0,0 +1,128 @@
+package integration
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestRequestDecompression(t *testing.T) {
+	var mu sync.Mutex
+	var lastBody []byte
+	var lastEncoding string
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		lastBody = body
+		lastEncoding = r.Header.Get("Content-Encoding")
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer closeUpstream()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+	cfg, err := config.ParseJSON([]byte(fmt.Sprintf(`{
+"limits": {"max_body_bytes": 2097152},
+"routes": [
+  {"id": "legacy", "host": "legacy.local", "path_prefix": "/", "pool": "p1",
+   "policy": {"request_decompression": {"enabled": true, "max_ratio": 20}}},
+  {"id": "modern", "host": "modern.local", "path_prefix": "/", "pool": "p1"}
+],
+"pools": {"p1": {"endpoints": ["%s"]}}
+}`, upstreamAddr)))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	proxyServer := httptest.NewServer(&proxy.Handler{Store: runtime.NewStore(snap), Registry: reg, Engine: proxy.NewEngine(reg, nil, nil, nil, nil)})
+	defer proxyServer.Close()
+	client := &http.Client{Timeout: 2 * time.Second}
+
+	plain := strings.Repeat("legacy payload ", 20)
+	compressed := gzipBytes(t, []byte(plain))
+	resp, _ := sendEncodedRequest(t, client, proxyServer.URL, "legacy.local", "gzip", compressed)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	mu.Lock()
+	if string(lastBody) != plain || lastEncoding != "" {
+		t.Fatalf("expected decompressed body without encoding, got %q encoding=%q", string(lastBody), lastEncoding)
+	}
+	mu.Unlock()
+
+	resp, _ = sendEncodedRequest(t, client, proxyServer.URL, "modern.local", "gzip", compressed)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	mu.Lock()
+	if !bytes.Equal(lastBody, compressed) || lastEncoding != "gzip" {
+		t.Fatalf("expected compressed passthrough on route without decompression")
+	}
+	mu.Unlock()
+
+	bomb := gzipBytes(t, make([]byte, 1024*1024))
+	resp, body := sendEncodedRequest(t, client, proxyServer.URL, "legacy.local", "gzip", bomb)
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 for bomb-like ratio, got %d", resp.StatusCode)
+	}
+	assertProxyError(t, resp, body, "decompression_bomb")
+
+	resp, body = sendEncodedRequest(t, client, proxyServer.URL, "legacy.local", "gzip", []byte("not gzip"))
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid gzip, got %d", resp.StatusCode)
+	}
+	assertProxyError(t, resp, body, "bad_content_encoding")
+}
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	if _, err := writer.Write(data); err != nil {
+		t.Fatalf("gzip write: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("gzip close: %v", err)
+	}
+	return buffer.Bytes()
+}
+
+func sendEncodedRequest(t *testing.T, client *http.Client, baseURL, host, encoding string, body []byte) (*http.Response, []byte) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, baseURL+"/", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	req.Host = host
+	req.Header.Set("Content-Encoding", encoding)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("do request: %v", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	return resp, respBody
+}
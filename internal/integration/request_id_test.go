@@ -0,0 +1,130 @@
+package integration
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+func TestRequestIDTrustModes(t *testing.T) {
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Header.Get(proxy.RequestIDHeader))
+	}))
+	defer closeUpstream()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+
+	startProxyWithRequestID := func(requestID string) *httptest.Server {
+		t.Helper()
+		cfgJSON := `{
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1"}],
+"pools": {"p1": {"endpoints": ["` + upstreamAddr + `"]}}`
+		if requestID != "" {
+			cfgJSON += `, "request_id": ` + requestID
+		}
+		cfg, err := config.ParseJSON([]byte(cfgJSON + `}`))
+		if err != nil {
+			t.Fatalf("parse config: %v", err)
+		}
+		snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil)
+		if err != nil {
+			t.Fatalf("build snapshot: %v", err)
+		}
+		server := httptest.NewServer(&proxy.Handler{
+			Store:    runtime.NewStore(snap),
+			Registry: reg,
+			Engine:   proxy.NewEngine(reg, nil, nil, nil, nil),
+		})
+		t.Cleanup(server.Close)
+		return server
+	}
+
+	client := &http.Client{Timeout: 2 * time.Second}
+	send := func(server *httptest.Server, inbound string) (string, string, map[string]interface{}) {
+		t.Helper()
+		var responseID, upstreamID string
+		lines := captureLogs(t, func() {
+			headers := map[string]string{}
+			if inbound != "" {
+				headers[proxy.RequestIDHeader] = inbound
+			}
+			resp, body := sendProxyRequestWithHeaders(t, client, server.URL, "example.local", http.MethodGet, "/", headers)
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("expected 200, got %d", resp.StatusCode)
+			}
+			responseID = resp.Header.Get(proxy.RequestIDHeader)
+			upstreamID = string(body)
+		})
+		if len(lines) != 1 {
+			t.Fatalf("expected 1 log line, got %d", len(lines))
+		}
+		var payload map[string]interface{}
+		if err := json.Unmarshal([]byte(lines[0]), &payload); err != nil {
+			t.Fatalf("parse log json: %v", err)
+		}
+		if responseID != upstreamID || payload["request_id"] != responseID {
+			t.Fatalf("expected one request id across response %q, upstream %q and log %v", responseID, upstreamID, payload["request_id"])
+		}
+		return responseID, upstreamID, payload
+	}
+
+	accept := startProxyWithRequestID("")
+	if id, _, payload := send(accept, "client-id-1"); id != "client-id-1" || payload["original_request_id"] != nil {
+		t.Fatalf("expected accept mode to keep client id, got %q %v", id, payload["original_request_id"])
+	}
+	if id, _, _ := send(accept, ""); id == "" {
+		t.Fatalf("expected generated request id")
+	}
+	if id, _, payload := send(accept, strings.Repeat("x", 200)); len(id) != 32 || payload["original_request_id"] != strings.Repeat("x", 200) {
+		t.Fatalf("expected oversized client id to be replaced, got %q", id)
+	}
+
+	regenerate := startProxyWithRequestID(`{"mode": "regenerate"}`)
+	if id, _, payload := send(regenerate, "client-id-2"); id == "client-id-2" || payload["original_request_id"] != "client-id-2" {
+		t.Fatalf("expected regenerate mode to replace client id and log the original, got %q %v", id, payload["original_request_id"])
+	}
+
+	trustedLocal := startProxyWithRequestID(`{"mode": "trusted", "trusted_cidrs": ["127.0.0.0/8"]}`)
+	if id, _, _ := send(trustedLocal, "client-id-3"); id != "client-id-3" {
+		t.Fatalf("expected trusted peer id to be kept, got %q", id)
+	}
+
+	trustedRemote := startProxyWithRequestID(`{"mode": "trusted", "trusted_cidrs": ["10.0.0.0/8"]}`)
+	if id, _, payload := send(trustedRemote, "client-id-4"); id == "client-id-4" || payload["original_request_id"] != "client-id-4" {
+		t.Fatalf("expected untrusted peer id to be replaced, got %q", id)
+	}
+}
+
+func TestRequestIDValidation(t *testing.T) {
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	for _, requestID := range []string{
+		`{"mode": "sometimes"}`,
+		`{"mode": "trusted"}`,
+		`{"mode": "trusted", "trusted_cidrs": ["10.0.0.0/33"]}`,
+		`{"mode": "regenerate", "trusted_cidrs": ["10.0.0.0/8"]}`,
+	} {
+		cfg, err := config.ParseJSON([]byte(`{
+"request_id": ` + requestID + `,
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1"}],
+"pools": {"p1": {"endpoints": ["127.0.0.1:1"]}}
+}`))
+		if err != nil {
+			t.Fatalf("parse config: %v", err)
+		}
+		if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil); err == nil {
+			t.Fatalf("expected request_id %s to be rejected", requestID)
+		}
+	}
+}
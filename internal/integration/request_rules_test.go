@@ -0,0 +1,130 @@
+package integration
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+func TestRequestRules(t *testing.T) {
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer closeUpstream()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+
+	cfg, err := config.ParseJSON([]byte(`{
+"request_rules": [
+  {"name": "monitor", "action": "allow", "headers": {"User-Agent": "^healthcheck/"}},
+  {"name": "badbot", "action": "block", "headers": {"User-Agent": "(?i)badbot|^$"}}
+],
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1", "policy": {"request_rules": [
+  {"name": "no-internal-writes", "action": "block", "methods": ["POST", "DELETE"], "path_regex": "^/internal", "status": 405},
+  {"name": "slow-scanner", "action": "tarpit", "path_regex": "\\.php$", "tarpit_ms": 200, "status": 429}
+]}}],
+"pools": {"p1": {"endpoints": ["` + upstreamAddr + `"]}}
+}`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	proxyServer := httptest.NewServer(&proxy.Handler{
+		Store:    runtime.NewStore(snap),
+		Registry: reg,
+		Engine:   proxy.NewEngine(reg, nil, metrics, nil, nil),
+		Metrics:  metrics,
+	})
+	defer proxyServer.Close()
+
+	client := &http.Client{Timeout: 2 * time.Second}
+	cases := []struct {
+		method    string
+		path      string
+		userAgent string
+		status    int
+	}{
+		{method: http.MethodGet, path: "/", userAgent: "Mozilla/5.0", status: http.StatusOK},
+		{method: http.MethodGet, path: "/", userAgent: "BadBot/1.0", status: http.StatusForbidden},
+		{method: http.MethodGet, path: "/", userAgent: "", status: http.StatusForbidden},
+		{method: http.MethodGet, path: "/internal/users", userAgent: "Mozilla/5.0", status: http.StatusOK},
+		{method: http.MethodDelete, path: "/internal/users", userAgent: "Mozilla/5.0", status: http.StatusMethodNotAllowed},
+		{method: http.MethodDelete, path: "/internal/users", userAgent: "healthcheck/1", status: http.StatusOK},
+	}
+	for _, tc := range cases {
+		resp, body := sendProxyRequestWithHeaders(t, client, proxyServer.URL, "example.local", tc.method, tc.path, map[string]string{"User-Agent": tc.userAgent})
+		if resp.StatusCode != tc.status {
+			t.Fatalf("%s %s ua=%q expected %d, got %d %s", tc.method, tc.path, tc.userAgent, tc.status, resp.StatusCode, body)
+		}
+		if tc.status != http.StatusOK {
+			assertProxyError(t, resp, body, "request_blocked")
+		}
+	}
+
+	start := time.Now()
+	resp, _ := sendProxyRequestWithHeaders(t, client, proxyServer.URL, "example.local", http.MethodGet, "/wp-login.php", map[string]string{"User-Agent": "Mozilla/5.0"})
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected tarpit 429, got %d", resp.StatusCode)
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Fatalf("expected tarpit delay, took %s", elapsed)
+	}
+
+	metricsServer := httptest.NewServer(metrics.Handler())
+	defer metricsServer.Close()
+	text := fetchMetrics(t, metricsServer)
+	for _, labels := range []map[string]string{
+		{"scope": "global", "rule": "badbot", "action": "block"},
+		{"scope": "global", "rule": "monitor", "action": "allow"},
+		{"scope": "route", "rule": "slow-scanner", "action": "tarpit"},
+	} {
+		if value, ok := metricValue(text, "proxy_request_rule_matches_total", labels); !ok || value < 1 {
+			t.Fatalf("expected rule matches recorded for %v", labels)
+		}
+	}
+	if value, _ := metricValue(text, "proxy_request_rule_matches_total", map[string]string{"rule": "badbot"}); value != 2 {
+		t.Fatalf("expected 2 badbot matches, got %v", value)
+	}
+}
+
+func TestRequestRulesValidation(t *testing.T) {
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	for _, rules := range []string{
+		`[{"action": "block", "path_regex": "^/x"}]`,
+		`[{"name": "a", "action": "drop", "path_regex": "^/x"}]`,
+		`[{"name": "a", "action": "block"}]`,
+		`[{"name": "a", "action": "block", "path_regex": "("}]`,
+		`[{"name": "a", "action": "block", "headers": {"Bad Header": "x"}}]`,
+		`[{"name": "a", "action": "block", "path_regex": "^/x", "status": 200}]`,
+		`[{"name": "a", "action": "tarpit", "path_regex": "^/x", "tarpit_ms": 120000}]`,
+		`[{"name": "a", "action": "block", "path_regex": "^/x"}, {"name": "a", "action": "allow", "path_regex": "^/y"}]`,
+	} {
+		for _, cfgJSON := range []string{
+			`{"request_rules": ` + rules + `, "routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1"}], "pools": {"p1": {"endpoints": ["127.0.0.1:1"]}}}`,
+			`{"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1", "policy": {"request_rules": ` + rules + `}}], "pools": {"p1": {"endpoints": ["127.0.0.1:1"]}}}`,
+		} {
+			cfg, err := config.ParseJSON([]byte(cfgJSON))
+			if err != nil {
+				t.Fatalf("parse config: %v", err)
+			}
+			if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil); err == nil {
+				t.Fatalf("expected request_rules %s to be rejected", rules)
+			}
+		}
+	}
+}
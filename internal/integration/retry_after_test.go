@@ -0,0 +1,125 @@
+package integration
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/retry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestRetryAfterHonored(t *testing.T) {
+	var mu sync.Mutex
+	attempts := make(map[string][]time.Time)
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts[r.URL.Path] = append(attempts[r.URL.Path], time.Now())
+		first := len(attempts[r.URL.Path]) == 1
+		mu.Unlock()
+		if !first && r.URL.Path != "/deadline" {
+			return
+		}
+		switch r.URL.Path {
+		case "/retry-after", "/deadline":
+			w.Header().Set("Retry-After", "5")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/status":
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer closeUpstream()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+	retryReg := registry.NewRetryRegistry(0, 0)
+	defer retryReg.Close()
+
+	retryCfg := config.RetryConfig{
+		Enabled:           true,
+		MaxAttempts:       2,
+		RetryOnStatus:     []int{429, 503},
+		StatusBackoffMS:   map[int]int{429: 200},
+		RespectRetryAfter: true,
+		RetryAfterCapMS:   300,
+	}
+	deadlineRetry := retryCfg
+	deadlineRetry.RetryAfterCapMS = 0
+	cfg := &config.Config{
+		Routes: []config.Route{
+			{ID: "capped", Host: "capped.local", PathPrefix: "/", Pool: "p1", Policy: config.RoutePolicy{Retry: retryCfg}},
+			{ID: "deadline", Host: "deadline.local", PathPrefix: "/", Pool: "p1", Policy: config.RoutePolicy{RequestTimeoutMS: 500, Retry: deadlineRetry}},
+		},
+		Pools: map[string]config.Pool{
+			"p1": {Endpoints: []string{upstreamAddr}},
+		},
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	proxyServer := httptest.NewServer(&proxy.Handler{Store: runtime.NewStore(snap), Registry: reg, RetryRegistry: retryReg, Engine: proxy.NewEngine(reg, retryReg, nil, nil, nil)})
+	defer proxyServer.Close()
+	client := &http.Client{Timeout: 3 * time.Second}
+
+	gap := func(path string) time.Duration {
+		t.Helper()
+		mu.Lock()
+		defer mu.Unlock()
+		if len(attempts[path]) != 2 {
+			t.Fatalf("expected 2 attempts for %s, got %d", path, len(attempts[path]))
+		}
+		return attempts[path][1].Sub(attempts[path][0])
+	}
+
+	if resp, _ := sendProxyRequest(t, client, proxyServer.URL, "capped.local", http.MethodGet, "/retry-after"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected retry after Retry-After to succeed, got %d", resp.StatusCode)
+	}
+	if delay := gap("/retry-after"); delay < 250*time.Millisecond || delay > 2*time.Second {
+		t.Fatalf("expected Retry-After capped near 300ms, got %v", delay)
+	}
+
+	if resp, _ := sendProxyRequest(t, client, proxyServer.URL, "capped.local", http.MethodGet, "/status"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected retry after 429 to succeed, got %d", resp.StatusCode)
+	}
+	if delay := gap("/status"); delay < 150*time.Millisecond {
+		t.Fatalf("expected per-status backoff of 200ms, got %v", delay)
+	}
+
+	start := time.Now()
+	resp, _ := sendProxyRequest(t, client, proxyServer.URL, "deadline.local", http.MethodGet, "/deadline")
+	if resp.StatusCode != http.StatusServiceUnavailable || resp.Header.Get("Retry-After") != "5" {
+		t.Fatalf("expected upstream 503 passed through when Retry-After exceeds deadline, got %d", resp.StatusCode)
+	}
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Fatalf("expected no wait past the request deadline, took %v", elapsed)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(attempts["/deadline"]) != 1 {
+		t.Fatalf("expected a single attempt when Retry-After exceeds deadline, got %d", len(attempts["/deadline"]))
+	}
+}
+
+func TestRetryAfterClampsLargeValues(t *testing.T) {
+	for _, value := range []string{"9223372036", "99999999999999999"} {
+		resp := &http.Response{Header: http.Header{"Retry-After": []string{value}}}
+		delay, ok := retry.RetryAfter(resp, time.Now(), 0)
+		if !ok || delay < 290*365*24*time.Hour {
+			t.Fatalf("Retry-After %s: expected a clamped large delay, got %v %v", value, delay, ok)
+		}
+		delay, ok = retry.RetryAfter(resp, time.Now(), 10*time.Second)
+		if !ok || delay != 10*time.Second {
+			t.Fatalf("Retry-After %s: expected the 10s cap, got %v %v", value, delay, ok)
+		}
+	}
+}
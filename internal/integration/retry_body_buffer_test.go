@@ -0,0 +1,141 @@
+package integration
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+func TestRetryBufferedPostBody(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		attempt := len(bodies)
+		mu.Unlock()
+		if attempt%2 == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write(body)
+	})
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, upstream)
+	defer closeUpstream()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	retryReg := registry.NewRetryRegistry(0, 0)
+	defer retryReg.Close()
+
+	cfg := &config.Config{
+		Routes: []config.Route{{
+			ID:         "r1",
+			Host:       "example.local",
+			PathPrefix: "/",
+			Pool:       "p1",
+			Policy: config.RoutePolicy{
+				Retry: config.RetryConfig{
+					Enabled:         true,
+					MaxAttempts:     2,
+					RetryOnStatus:   []int{http.StatusServiceUnavailable},
+					BufferBodyBytes: 16,
+					NonIdempotent:   true,
+				},
+			},
+		}},
+		Pools: map[string]config.Pool{"p1": {Endpoints: []string{upstreamAddr}}},
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	proxyServer := httptest.NewServer(&proxy.Handler{
+		Store:         runtime.NewStore(snap),
+		Registry:      reg,
+		RetryRegistry: retryReg,
+		Engine:        proxy.NewEngine(reg, retryReg, nil, nil, nil),
+	})
+	defer proxyServer.Close()
+
+	client := &http.Client{Timeout: 2 * time.Second}
+	post := func(body string, chunked bool) (*http.Response, string) {
+		t.Helper()
+		var reader io.Reader = strings.NewReader(body)
+		if chunked {
+			reader = io.MultiReader(strings.NewReader(body))
+		}
+		req, err := http.NewRequest(http.MethodPost, proxyServer.URL+"/", reader)
+		if err != nil {
+			t.Fatalf("new request: %v", err)
+		}
+		req.Host = "example.local"
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("request: %v", err)
+		}
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(resp.Body)
+		return resp, string(respBody)
+	}
+	logPayload := func(lines []string) map[string]interface{} {
+		t.Helper()
+		if len(lines) != 1 {
+			t.Fatalf("expected 1 log line, got %d", len(lines))
+		}
+		var payload map[string]interface{}
+		if err := json.Unmarshal([]byte(lines[0]), &payload); err != nil {
+			t.Fatalf("parse log json: %v", err)
+		}
+		return payload
+	}
+
+	for _, chunked := range []bool{false, true} {
+		var resp *http.Response
+		var body string
+		payload := logPayload(captureLogs(t, func() {
+			resp, body = post("small-body", chunked)
+		}))
+		if resp.StatusCode != http.StatusOK || body != "small-body" {
+			t.Fatalf("chunked=%v: expected replayed body after retry, got %d %q", chunked, resp.StatusCode, body)
+		}
+		if toInt(payload["retry_count"]) != 1 || payload["retry_skip_reason"] != nil {
+			t.Fatalf("chunked=%v: unexpected retry log %v", chunked, payload)
+		}
+	}
+
+	large := strings.Repeat("x", 64)
+	for _, chunked := range []bool{false, true} {
+		mu.Lock()
+		bodies = nil
+		mu.Unlock()
+		var resp *http.Response
+		payload := logPayload(captureLogs(t, func() {
+			resp, _ = post(large, chunked)
+		}))
+		if resp.StatusCode != http.StatusServiceUnavailable {
+			t.Fatalf("chunked=%v: expected unretried 503, got %d", chunked, resp.StatusCode)
+		}
+		mu.Lock()
+		seen := append([]string(nil), bodies...)
+		mu.Unlock()
+		if len(seen) != 1 || seen[0] != large {
+			t.Fatalf("chunked=%v: expected one attempt with the full body, got %d attempts", chunked, len(seen))
+		}
+		if toInt(payload["retry_count"]) != 0 || payload["retry_skip_reason"] != "body_too_large_to_retry" {
+			t.Fatalf("chunked=%v: expected body_too_large_to_retry, got %v", chunked, payload)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package integration
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestRetryExcludesAttemptedEndpoint(t *testing.T) {
+	var proxyURL string
+	client := &http.Client{Timeout: 2 * time.Second}
+	var mu sync.Mutex
+	badHits := make(map[string]int)
+	nestedHost := map[string]string{"/plain": "nested-plain.local", "/excluding": "nested-excluding.local"}
+
+	badAddr, closeBad := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		badHits[r.URL.Path]++
+		first := badHits[r.URL.Path] == 1
+		mu.Unlock()
+		if host, ok := nestedHost[r.URL.Path]; ok && first {
+			sendProxyRequest(t, client, proxyURL, host, http.MethodGet, "/nested")
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer closeBad()
+	goodAddr, closeGood := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Upstream", "good")
+	}))
+	defer closeGood()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+
+	retryRoute := func(id string, poolName string, exclude bool) config.Route {
+		return config.Route{ID: id, Host: id + ".local", PathPrefix: "/", Pool: poolName, Policy: config.RoutePolicy{
+			Retry: config.RetryConfig{Enabled: true, MaxAttempts: 2, RetryOnStatus: []int{503}, ExcludeAttempted: exclude},
+		}}
+	}
+	cfg := &config.Config{
+		Routes: []config.Route{
+			retryRoute("plain", "pPlain", false),
+			retryRoute("excluding", "pExcluding", true),
+			{ID: "nested-plain", Host: "nested-plain.local", PathPrefix: "/", Pool: "pPlain"},
+			{ID: "nested-excluding", Host: "nested-excluding.local", PathPrefix: "/", Pool: "pExcluding"},
+		},
+		Pools: map[string]config.Pool{
+			"pPlain":     {Endpoints: []string{badAddr, goodAddr}},
+			"pExcluding": {Endpoints: []string{badAddr, goodAddr}},
+		},
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	proxyServer := httptest.NewServer(&proxy.Handler{Store: runtime.NewStore(snap), Registry: reg, Engine: proxy.NewEngine(reg, nil, nil, nil, nil)})
+	defer proxyServer.Close()
+	proxyURL = proxyServer.URL
+
+	resp, _ := sendProxyRequest(t, client, proxyServer.URL, "plain.local", http.MethodGet, "/plain")
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected retry without exclusion to land on the failing endpoint again, got %d", resp.StatusCode)
+	}
+
+	resp, _ = sendProxyRequest(t, client, proxyServer.URL, "excluding.local", http.MethodGet, "/excluding")
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("X-Upstream") != "good" {
+		t.Fatalf("expected retry to avoid the attempted endpoint, got %d", resp.StatusCode)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if badHits["/plain"] != 2 || badHits["/excluding"] != 1 {
+		t.Fatalf("expected failing endpoint hits plain=2 excluding=1, got %v", badHits)
+	}
+}
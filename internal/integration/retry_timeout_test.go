@@ -94,3 +94,74 @@ func TestRetryTotalBudgetRespected(t *testing.T) {
 		t.Fatalf("expected 504, got %d", resp.StatusCode)
 	}
 }
+
+func TestRetryPerTryTimeoutBoundsHeadersOnly(t *testing.T) {
+	var slowHeaderAttempts int32
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow-headers" {
+			if atomic.AddInt32(&slowHeaderAttempts, 1) == 1 {
+				time.Sleep(300 * time.Millisecond)
+			}
+			_, _ = io.WriteString(w, "headers")
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		flusher := w.(http.Flusher)
+		for i := 0; i < 5; i++ {
+			_, _ = io.WriteString(w, "chunk")
+			flusher.Flush()
+			time.Sleep(60 * time.Millisecond)
+		}
+	})
+	addr, closeUpstream := testutil.StartUpstream(t, upstream)
+	defer closeUpstream()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+
+	cfg := &config.Config{
+		Routes: []config.Route{
+			{
+				ID:         "r1",
+				Host:       "example.local",
+				PathPrefix: "/",
+				Pool:       "p1",
+				Policy: config.RoutePolicy{
+					RequestTimeoutMS: 1000,
+					Retry: config.RetryConfig{
+						Enabled:         true,
+						MaxAttempts:     2,
+						PerTryTimeoutMS: 100,
+						RetryOnErrors:   []string{"timeout"},
+					},
+				},
+			},
+		},
+		Pools: map[string]config.Pool{
+			"p1": {Endpoints: []string{addr}},
+		},
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	proxyServer := httptest.NewServer(&proxy.Handler{
+		Store:    runtime.NewStore(snap),
+		Registry: reg,
+		Engine:   proxy.NewEngine(reg, nil, nil, nil, nil),
+	})
+	defer proxyServer.Close()
+	client := &http.Client{Timeout: 2 * time.Second}
+
+	resp, body := sendProxyRequest(t, client, proxyServer.URL, "example.local", http.MethodGet, "/slow-headers")
+	if resp.StatusCode != http.StatusOK || string(body) != "headers" || atomic.LoadInt32(&slowHeaderAttempts) != 2 {
+		t.Fatalf("expected slow headers to be retried, got %d %q after %d attempts", resp.StatusCode, string(body), slowHeaderAttempts)
+	}
+
+	resp, body = sendProxyRequest(t, client, proxyServer.URL, "example.local", http.MethodGet, "/slow-body")
+	if resp.StatusCode != http.StatusOK || string(body) != "chunkchunkchunkchunkchunk" {
+		t.Fatalf("expected body streamed past the per-try timeout, got %d %q", resp.StatusCode, string(body))
+	}
+}
@@ -0,0 +1,122 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestRouteLabelsInLogsAndMetrics(t *testing.T) {
+	addr, closeUpstream := testutil.StartUpstream(t, nil)
+	defer closeUpstream()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+
+	cfg := &config.Config{
+		Routes: []config.Route{
+			{
+				ID:         "r1",
+				Host:       "example.local",
+				PathPrefix: "/",
+				Pool:       "p1",
+				Labels:     map[string]string{"team": "payments", "tier": "critical"},
+			},
+		},
+		Pools: map[string]config.Pool{
+			"p1": {Endpoints: []string{addr}},
+		},
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	store := runtime.NewStore(snap)
+	proxyServer := httptest.NewServer(&proxy.Handler{Store: store, Registry: reg, Engine: proxy.NewEngine(reg, nil, metrics, nil, nil), Metrics: metrics})
+	defer proxyServer.Close()
+
+	oldStdout := os.Stdout
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe: %v", err)
+	}
+	os.Stdout = writer
+	defer func() {
+		os.Stdout = oldStdout
+	}()
+
+	client := &http.Client{Timeout: 2 * time.Second}
+	_, _ = sendProxyRequest(t, client, proxyServer.URL, "example.local", http.MethodGet, "/")
+
+	if err := writer.Close(); err != nil {
+		t.Fatalf("close writer: %v", err)
+	}
+	lines := readLines(t, reader)
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 log line, got %d", len(lines))
+	}
+	var payload struct {
+		RouteLabels map[string]string `json:"route_labels"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &payload); err != nil {
+		t.Fatalf("parse log json: %v", err)
+	}
+	if payload.RouteLabels["team"] != "payments" || payload.RouteLabels["tier"] != "critical" {
+		t.Fatalf("expected route labels in access log, got %v", payload.RouteLabels)
+	}
+
+	metricsServer := httptest.NewServer(metrics.Handler())
+	defer metricsServer.Close()
+	text := fetchMetrics(t, metricsServer)
+	if value, ok := metricValue(text, "proxy_route_label_info", map[string]string{"route": "r1", "key": "team", "value": "payments"}); !ok || value != 1 {
+		t.Fatalf("expected team label info series, got %v", value)
+	}
+
+	cfg.Routes[0].Labels = map[string]string{"team": "billing"}
+	next, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	if err := store.Swap(next); err != nil {
+		t.Fatalf("swap: %v", err)
+	}
+	os.Stdout = oldStdout
+	_, _ = sendProxyRequest(t, client, proxyServer.URL, "example.local", http.MethodGet, "/")
+	text = fetchMetrics(t, metricsServer)
+	if _, ok := metricValue(text, "proxy_route_label_info", map[string]string{"route": "r1", "key": "team", "value": "payments"}); ok {
+		t.Fatalf("expected stale label series removed")
+	}
+	if value, ok := metricValue(text, "proxy_route_label_info", map[string]string{"route": "r1", "key": "team", "value": "billing"}); !ok || value != 1 {
+		t.Fatalf("expected updated label series, got %v", value)
+	}
+}
+
+func TestRouteLabelsRejectInvalidKeys(t *testing.T) {
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	cfg := &config.Config{
+		Routes: []config.Route{
+			{ID: "r1", Host: "example.local", PathPrefix: "/", Pool: "p1", Labels: map[string]string{"bad-key": "x"}},
+		},
+		Pools: map[string]config.Pool{
+			"p1": {Endpoints: []string{"127.0.0.1:1"}},
+		},
+	}
+	if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil); err == nil {
+		t.Fatalf("expected invalid label key to be rejected")
+	}
+}
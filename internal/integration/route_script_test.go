@@ -0,0 +1,174 @@
+package integration
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+const routeScript = `
+if request.headers["x-block"] == "1" then
+  deny(451, "blocked by script")
+end
+if request.headers["x-api-key"] == nil then
+  deny(401)
+end
+local tier = request.headers["x-tier"] or "free"
+set_var("tier", tier)
+set_var("label", (table.concat({tier, string.format("%03d", 7)}, "-"):gsub("%-", function(sep) return sep .. sep end)))
+set_header("X-Tier", tier)
+if tier == "gold" then
+  use_pool("gold")
+end
+`
+
+func TestRouteScript(t *testing.T) {
+	echo := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, name+":"+r.Header.Get("X-Tier"))
+		})
+	}
+	stdAddr, closeStd := testutil.StartUpstream(t, echo("std"))
+	defer closeStd()
+	goldAddr, closeGold := testutil.StartUpstream(t, echo("gold"))
+	defer closeGold()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+
+	scriptRoute := func(id string, prefix string, scriptCfg config.ScriptConfig) config.Route {
+		scriptCfg.Enabled = true
+		return config.Route{
+			ID:         id,
+			Host:       "example.local",
+			PathPrefix: prefix,
+			Pool:       "std",
+			Policy:     config.RoutePolicy{Script: scriptCfg},
+		}
+	}
+	cfg := &config.Config{
+		Routes: []config.Route{
+			scriptRoute("spin-open", "/spin-open", config.ScriptConfig{Lua: "while true do end", TimeoutMS: 5, FailureMode: "fail_open"}),
+			scriptRoute("spin", "/spin", config.ScriptConfig{Lua: "while true do end", TimeoutMS: 5}),
+			scriptRoute("sandbox", "/sandbox", config.ScriptConfig{Lua: "os.exit(1)"}),
+			scriptRoute("steps", "/steps", config.ScriptConfig{Lua: "while true do end", TimeoutMS: 1000, MaxSteps: 10000}),
+			scriptRoute("grow", "/grow", config.ScriptConfig{Lua: `local s = "x" while true do s = s .. s end`, TimeoutMS: 1000}),
+			scriptRoute("amplify", "/amplify", config.ScriptConfig{Lua: `local s = string.format("%99s", "") for i = 1, 9 do s = s:gsub(" ", function() return s end) end`, TimeoutMS: 1000}),
+			scriptRoute("rep", "/rep", config.ScriptConfig{Lua: `local s = string.rep("x", 1e9)`}),
+			scriptRoute("short-rep", "/short-rep", config.ScriptConfig{Lua: `set_header("X-Tier", string.rep("ab", 3))`}),
+			scriptRoute("main", "/", config.ScriptConfig{Lua: routeScript}),
+		},
+		Pools: map[string]config.Pool{
+			"std":  {Endpoints: []string{stdAddr}},
+			"gold": {Endpoints: []string{goldAddr}},
+		},
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	proxyServer := httptest.NewServer(&proxy.Handler{
+		Store:    runtime.NewStore(snap),
+		Registry: reg,
+		Engine:   proxy.NewEngine(reg, nil, metrics, nil, nil),
+		Metrics:  metrics,
+	})
+	defer proxyServer.Close()
+	client := &http.Client{Timeout: 2 * time.Second}
+
+	resp, body := sendProxyRequestWithHeaders(t, client, proxyServer.URL, "example.local", http.MethodGet, "/", map[string]string{"X-Api-Key": "k"})
+	if resp.StatusCode != http.StatusOK || string(body) != "std:free" {
+		t.Fatalf("expected default pool with tier header, got %d %q", resp.StatusCode, string(body))
+	}
+
+	resp, body = sendProxyRequestWithHeaders(t, client, proxyServer.URL, "example.local", http.MethodGet, "/", map[string]string{"X-Api-Key": "k", "X-Tier": "gold"})
+	if resp.StatusCode != http.StatusOK || string(body) != "gold:gold" {
+		t.Fatalf("expected script pool selection, got %d %q", resp.StatusCode, string(body))
+	}
+
+	resp, body = sendProxyRequest(t, client, proxyServer.URL, "example.local", http.MethodGet, "/")
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without api key, got %d", resp.StatusCode)
+	}
+	assertProxyError(t, resp, body, "script_denied")
+
+	resp, body = sendProxyRequestWithHeaders(t, client, proxyServer.URL, "example.local", http.MethodGet, "/", map[string]string{"X-Api-Key": "k", "X-Block": "1"})
+	if resp.StatusCode != 451 || string(body) != "blocked by script" {
+		t.Fatalf("expected custom deny, got %d %q", resp.StatusCode, string(body))
+	}
+
+	resp, body = sendProxyRequest(t, client, proxyServer.URL, "example.local", http.MethodGet, "/spin")
+	assertProxyError(t, resp, body, "script_timeout")
+
+	resp, body = sendProxyRequest(t, client, proxyServer.URL, "example.local", http.MethodGet, "/spin-open")
+	if resp.StatusCode != http.StatusOK || string(body) != "std:" {
+		t.Fatalf("expected fail open on script timeout, got %d %q", resp.StatusCode, string(body))
+	}
+
+	resp, body = sendProxyRequest(t, client, proxyServer.URL, "example.local", http.MethodGet, "/sandbox")
+	assertProxyError(t, resp, body, "script_error")
+
+	for _, path := range []string{"/steps", "/grow", "/amplify", "/rep"} {
+		resp, body = sendProxyRequest(t, client, proxyServer.URL, "example.local", http.MethodGet, path)
+		assertProxyError(t, resp, body, "script_error")
+	}
+
+	resp, body = sendProxyRequest(t, client, proxyServer.URL, "example.local", http.MethodGet, "/short-rep")
+	if resp.StatusCode != http.StatusOK || string(body) != "std:ababab" {
+		t.Fatalf("expected string.rep within the size limit to run, got %d %q", resp.StatusCode, string(body))
+	}
+
+	metricsServer := httptest.NewServer(metrics.Handler())
+	defer metricsServer.Close()
+	text := fetchMetrics(t, metricsServer)
+	for result, expected := range map[string]float64{"allow": 2, "deny": 2, "timeout": 2, "error": 5} {
+		if value, ok := metricValue(text, "proxy_script_results_total", map[string]string{"result": result}); !ok || value < expected {
+			t.Fatalf("expected %v script results %q, got %v", expected, result, value)
+		}
+	}
+}
+
+func TestRouteScriptValidation(t *testing.T) {
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+
+	cases := []struct {
+		name   string
+		script config.ScriptConfig
+		errMsg string
+	}{
+		{name: "syntax error", script: config.ScriptConfig{Enabled: true, Lua: "if then"}, errMsg: "script compile"},
+		{name: "missing source", script: config.ScriptConfig{Enabled: true}, errMsg: "requires lua or file"},
+		{name: "timeout too large", script: config.ScriptConfig{Enabled: true, Lua: "x = 1", TimeoutMS: 5000}, errMsg: "timeout_ms"},
+		{name: "negative max steps", script: config.ScriptConfig{Enabled: true, Lua: "x = 1", MaxSteps: -1}, errMsg: "max_steps"},
+		{name: "missing file", script: config.ScriptConfig{Enabled: true, File: "/nonexistent/route.lua"}, errMsg: "script file"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.Config{
+				Routes: []config.Route{{ID: "r1", Host: "example.local", PathPrefix: "/", Pool: "p1", Policy: config.RoutePolicy{Script: tc.script}}},
+				Pools:  map[string]config.Pool{"p1": {Endpoints: []string{"127.0.0.1:1"}}},
+			}
+			_, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+			if err == nil || !strings.Contains(err.Error(), tc.errMsg) {
+				t.Fatalf("expected error containing %q, got %v", tc.errMsg, err)
+			}
+		})
+	}
+}
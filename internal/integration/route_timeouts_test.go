@@ -0,0 +1,148 @@
+package integration
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+func TestRouteIdleAndStreamTimeouts(t *testing.T) {
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("first"))
+		w.(http.Flusher).Flush()
+		pause := 20 * time.Millisecond
+		if r.URL.Path == "/stalled" {
+			pause = 500 * time.Millisecond
+		}
+		for i := 0; i < 5; i++ {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(pause):
+			}
+			_, _ = w.Write([]byte("-chunk"))
+			w.(http.Flusher).Flush()
+		}
+	})
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, upstream)
+	defer closeUpstream()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	retryReg := registry.NewRetryRegistry(0, 0)
+	defer retryReg.Close()
+
+	cfg, err := config.ParseJSON([]byte(`{
+"limits": {"response_stream_timeout_ms": 5000},
+"routes": [
+  {"id": "idle", "host": "example.local", "path_prefix": "/", "pool": "p1", "policy": {"idle_timeout_ms": 150}},
+  {"id": "stream", "host": "stream.local", "path_prefix": "/", "pool": "p1", "policy": {"response_stream_timeout_ms": 50}}
+],
+"pools": {"p1": {"endpoints": ["` + upstreamAddr + `"]}}
+}`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	proxyServer := httptest.NewServer(&proxy.Handler{
+		Store:         runtime.NewStore(snap),
+		Registry:      reg,
+		RetryRegistry: retryReg,
+		Engine:        proxy.NewEngine(reg, retryReg, nil, nil, nil),
+	})
+	defer proxyServer.Close()
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	fetch := func(host string, path string) (string, time.Duration) {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, proxyServer.URL+path, nil)
+		if err != nil {
+			t.Fatalf("new request: %v", err)
+		}
+		req.Host = host
+		start := time.Now()
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body), time.Since(start)
+	}
+
+	body, _ := fetch("example.local", "/steady")
+	if body != "first"+strings.Repeat("-chunk", 5) {
+		t.Fatalf("expected steady stream to complete under idle timeout, got %q", body)
+	}
+
+	body, elapsed := fetch("example.local", "/stalled")
+	if body != "first" {
+		t.Fatalf("expected stalled stream to be cut after first chunk, got %q", body)
+	}
+	if elapsed >= 500*time.Millisecond {
+		t.Fatalf("expected idle timeout to cut stream early, took %s", elapsed)
+	}
+
+	body, _ = fetch("stream.local", "/steady")
+	if body == "first"+strings.Repeat("-chunk", 5) {
+		t.Fatalf("expected route response_stream_timeout_ms to override global limit")
+	}
+}
+
+func TestRouteTimeoutHierarchyValidation(t *testing.T) {
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+
+	for _, tc := range []struct {
+		extra  string
+		policy string
+		want   string
+	}{
+		{policy: `{"request_timeout_ms": 1000, "retry": {"enabled": true, "per_try_timeout_ms": 1000}}`, want: "per_try_timeout_ms"},
+		{policy: `{"idle_timeout_ms": 2000, "response_stream_timeout_ms": 1000}`, want: "idle_timeout_ms"},
+		{policy: `{"idle_timeout_ms": -1}`, want: "timeouts must be >= 0"},
+		{extra: `"limits": {"write_timeout_ms": 10000},`, policy: `{"request_timeout_ms": 10000}`, want: `listener "default" write_timeout_ms`},
+		{extra: `"listeners": [{"name": "internal", "addr": "127.0.0.1:0", "limits": {"write_timeout_ms": 500}}],`, policy: `{"request_timeout_ms": 1000}`, want: `listener "internal" write_timeout_ms`},
+	} {
+		cfg, err := config.ParseJSON([]byte(`{` + tc.extra + `
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1", "policy": ` + tc.policy + `}],
+"pools": {"p1": {"endpoints": ["127.0.0.1:1"]}}
+}`))
+		if err != nil {
+			t.Fatalf("parse config: %v", err)
+		}
+		_, err = runtime.BuildSnapshot(cfg, reg, nil, nil, nil)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("expected policy %s to be rejected with %q, got %v", tc.policy, tc.want, err)
+		}
+	}
+
+	cfg, err := config.ParseJSON([]byte(`{
+"limits": {"write_timeout_ms": 10000},
+"listeners": [{"name": "internal", "addr": "127.0.0.1:0", "limits": {"write_timeout_ms": 500}}],
+"routes": [
+  {"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1", "listeners": ["default"], "policy": {"request_timeout_ms": 5000, "retry": {"enabled": true, "per_try_timeout_ms": 1000}}},
+  {"id": "r2", "host": "default.local", "path_prefix": "/", "pool": "p1"}
+],
+"pools": {"p1": {"endpoints": ["127.0.0.1:1"]}}
+}`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil); err != nil {
+		t.Fatalf("expected consistent timeouts and the default request timeout to be accepted: %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"modern_reverse_proxy/internal/admin"
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/secrets"
+)
+
+func TestSecretsFileSourceRefreshAndRedaction(t *testing.T) {
+	dir := t.TempDir()
+	writeSecret := func(name string, value string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(value+"\n"), 0o600); err != nil {
+			t.Fatalf("write secret: %v", err)
+		}
+	}
+	writeSecret("admin-token", "first-admin-token")
+
+	manager := secrets.NewManager(secrets.Config{Source: &secrets.FileSource{Dir: dir}})
+	auth, err := admin.NewAuthenticator(admin.AuthConfig{TokenFunc: manager.Func("secret://admin-token")})
+	if err != nil {
+		t.Fatalf("authenticator: %v", err)
+	}
+	authenticate := func(token string) error {
+		req := httptest.NewRequest(http.MethodGet, "https://admin.local/admin/snapshot", nil)
+		req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{}}}
+		req.Header.Set("Authorization", "Bearer "+token)
+		return auth.Authenticate(req)
+	}
+	if err := authenticate("first-admin-token"); err != nil {
+		t.Fatalf("expected initial token to authenticate: %v", err)
+	}
+
+	writeSecret("admin-token", "second-admin-token")
+	manager.Refresh(context.Background())
+	if err := authenticate("first-admin-token"); err == nil {
+		t.Fatalf("expected rotated-out token to be rejected")
+	}
+	if err := authenticate("second-admin-token"); err != nil {
+		t.Fatalf("expected rotated token to authenticate: %v", err)
+	}
+
+	var out bytes.Buffer
+	writer := secrets.NewRedactingWriter(&out, manager)
+	_, _ = writer.Write([]byte(`token=first-admin-token next="second-admin-token"` + "\n"))
+	if strings.Contains(out.String(), "admin-token") || strings.Count(out.String(), secrets.Redacted) != 2 {
+		t.Fatalf("expected old and new values redacted, got %q", out.String())
+	}
+
+	if _, err := manager.Resolve(context.Background(), "secret://missing"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if _, err := manager.Resolve(context.Background(), "secret://../etc/passwd"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Fatalf("expected path traversal to stay inside secrets dir, got %v", err)
+	}
+	if value, err := manager.Resolve(context.Background(), "plain-value"); err != nil || value != "plain-value" {
+		t.Fatalf("expected plain values to pass through, got %q %v", value, err)
+	}
+}
+
+func TestSecretsVaultSource(t *testing.T) {
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "vault-root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/v1/kv/data/proxy/pull" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data": {"data": {"token": "pull-secret-value", "value": "default-field"}}}`))
+	}))
+	defer vault.Close()
+
+	manager := secrets.NewManager(secrets.Config{Source: &secrets.VaultSource{Addr: vault.URL, Token: "vault-root", Mount: "kv", Client: vault.Client()}})
+	if value, err := manager.Resolve(context.Background(), "secret://proxy/pull#token"); err != nil || value != "pull-secret-value" {
+		t.Fatalf("expected vault field, got %q %v", value, err)
+	}
+	if value, err := manager.Resolve(context.Background(), "secret://proxy/pull"); err != nil || value != "default-field" {
+		t.Fatalf("expected default value field, got %q %v", value, err)
+	}
+	if _, err := manager.Resolve(context.Background(), "secret://proxy/other"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+
+	denied := secrets.NewManager(secrets.Config{Source: &secrets.VaultSource{Addr: vault.URL, Token: "wrong", Mount: "kv", Client: vault.Client()}})
+	if _, err := denied.Resolve(context.Background(), "secret://proxy/pull#token"); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Fatalf("expected vault status error, got %v", err)
+	}
+}
+
+func TestSecretsFileAndEnvSourcesFeedConfigLookups(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "metrics"), []byte("metrics-bearer-token\n"), 0o600); err != nil {
+		t.Fatalf("write secret: %v", err)
+	}
+	manager := secrets.NewManager(secrets.Config{Source: &secrets.FileSource{Dir: dir}})
+	secrets.SetDefault(manager)
+	t.Cleanup(func() { secrets.SetDefault(nil) })
+
+	if value := secrets.LookupEnv("secret://metrics"); value != "metrics-bearer-token" {
+		t.Fatalf("expected file secret, got %q", value)
+	}
+	cfg := &config.Config{Metrics: &config.MetricsConfig{RequireToken: true, TokenEnv: "secret://metrics"}}
+	if _, err := config.Validate(cfg); err != nil {
+		t.Fatalf("expected secret-backed metrics token to validate: %v", err)
+	}
+	cfg.Metrics.TokenEnv = "secret://absent"
+	if _, err := config.Validate(cfg); err == nil {
+		t.Fatalf("expected missing secret to fail validation")
+	}
+
+	t.Setenv("PROXY_SECRET_PULL_TOKEN", "from-env")
+	envManager := secrets.NewManager(secrets.Config{Source: &secrets.EnvSource{Prefix: "PROXY_SECRET_"}})
+	if value, err := envManager.Resolve(context.Background(), "secret://pull-token"); err != nil || value != "from-env" {
+		t.Fatalf("expected env secret, got %q %v", value, err)
+	}
+}
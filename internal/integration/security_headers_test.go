@@ -0,0 +1,115 @@
+package integration
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+func TestSecurityHeadersProfiles(t *testing.T) {
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Frame-Options", "ALLOWALL")
+		w.Header().Set("X-Upstream", "yes")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer closeUpstream()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	retryReg := registry.NewRetryRegistry(0, 0)
+	defer retryReg.Close()
+
+	cfg, err := config.ParseJSON([]byte(`{
+"routes": [
+  {"id": "strict", "host": "strict.local", "path_prefix": "/", "pool": "p1", "policy": {"security_headers": {"profile": "strict", "headers": {"content-security-policy": "default-src 'self' cdn.example.com", "Permissions-Policy": ""}}}},
+  {"id": "relaxed", "host": "relaxed.local", "path_prefix": "/", "pool": "p1", "policy": {"security_headers": {"profile": "relaxed"}}},
+  {"id": "off", "host": "off.local", "path_prefix": "/", "pool": "p1"}
+],
+"pools": {"p1": {"endpoints": ["` + upstreamAddr + `"]}}
+}`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	handler := &proxy.Handler{
+		Store:         runtime.NewStore(snap),
+		Registry:      reg,
+		RetryRegistry: retryReg,
+		Engine:        proxy.NewEngine(reg, retryReg, nil, nil, nil),
+	}
+	plainServer := httptest.NewServer(handler)
+	defer plainServer.Close()
+	tlsServer := httptest.NewTLSServer(handler)
+	defer tlsServer.Close()
+
+	fetch := func(server *httptest.Server, host string) http.Header {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/", nil)
+		if err != nil {
+			t.Fatalf("new request: %v", err)
+		}
+		req.Host = host
+		resp, err := server.Client().Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp.Header
+	}
+
+	header := fetch(tlsServer, "strict.local")
+	if header.Get("Strict-Transport-Security") != "max-age=63072000; includeSubDomains" {
+		t.Fatalf("expected strict hsts over tls, got %q", header.Get("Strict-Transport-Security"))
+	}
+	if header.Get("X-Frame-Options") != "DENY" || header.Get("X-Content-Type-Options") != "nosniff" || header.Get("X-Upstream") != "yes" {
+		t.Fatalf("unexpected strict headers %v", header)
+	}
+	if header.Get("Content-Security-Policy") != "default-src 'self' cdn.example.com" || header.Get("Permissions-Policy") != "" {
+		t.Fatalf("expected header overrides to apply, got %v", header)
+	}
+
+	header = fetch(plainServer, "strict.local")
+	if header.Get("Strict-Transport-Security") != "" || header.Get("X-Frame-Options") != "DENY" {
+		t.Fatalf("expected no hsts over plain http, got %v", header)
+	}
+
+	header = fetch(tlsServer, "relaxed.local")
+	if header.Get("X-Frame-Options") != "SAMEORIGIN" || header.Get("Referrer-Policy") != "strict-origin-when-cross-origin" || header.Get("Content-Security-Policy") != "" {
+		t.Fatalf("unexpected relaxed headers %v", header)
+	}
+
+	header = fetch(tlsServer, "off.local")
+	if header.Get("X-Frame-Options") != "ALLOWALL" || header.Get("Strict-Transport-Security") != "" {
+		t.Fatalf("expected upstream headers untouched without profile, got %v", header)
+	}
+}
+
+func TestSecurityHeadersValidation(t *testing.T) {
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	for _, headers := range []string{
+		`{"profile": "paranoid"}`,
+		`{"headers": {"Bad Header": "x"}}`,
+		`{"headers": {"X-Test": "a\nb"}}`,
+	} {
+		cfg, err := config.ParseJSON([]byte(`{
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1", "policy": {"security_headers": ` + headers + `}}],
+"pools": {"p1": {"endpoints": ["127.0.0.1:1"]}}
+}`))
+		if err != nil {
+			t.Fatalf("parse config: %v", err)
+		}
+		if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil); err == nil {
+			t.Fatalf("expected security_headers %s to be rejected", headers)
+		}
+	}
+}
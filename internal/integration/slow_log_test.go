@@ -0,0 +1,152 @@
+package integration
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/admin"
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+func TestSlowLogAndSlowRoutesReport(t *testing.T) {
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow":
+			time.Sleep(80 * time.Millisecond)
+		case "/medium":
+			time.Sleep(60 * time.Millisecond)
+		}
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer closeUpstream()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	cfg, err := config.ParseJSON([]byte(`{
+"slow_log": {"enabled": true, "threshold_ms": 50},
+"routes": [
+  {"id": "api", "host": "api.local", "path_prefix": "/", "pool": "p1"},
+  {"id": "reports", "host": "reports.local", "path_prefix": "/", "pool": "p1", "policy": {"slow_log_threshold_ms": 500}}
+],
+"pools": {"p1": {"endpoints": ["` + upstreamAddr + `"]}}
+}`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	slowRoutes := obs.NewSlowRouteTracker()
+	store := runtime.NewStore(snap)
+	proxyServer := httptest.NewServer(&proxy.Handler{
+		Store:      store,
+		Registry:   reg,
+		Engine:     proxy.NewEngine(reg, nil, nil, nil, nil),
+		SlowRoutes: slowRoutes,
+	})
+	defer proxyServer.Close()
+	client := &http.Client{Timeout: 2 * time.Second}
+
+	var slowRequestID string
+	lines := captureLogs(t, func() {
+		for _, request := range []struct{ host, path string }{
+			{"api.local", "/fast"},
+			{"api.local", "/slow"},
+			{"reports.local", "/medium"},
+			{"reports.local", "/fast"},
+		} {
+			resp, _ := sendProxyRequestWithHeaders(t, client, proxyServer.URL, request.host, http.MethodGet, request.path, nil)
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("expected 200, got %d", resp.StatusCode)
+			}
+			if request.host == "api.local" && request.path == "/slow" {
+				slowRequestID = resp.Header.Get(proxy.RequestIDHeader)
+			}
+		}
+	})
+	var slowEntries []map[string]interface{}
+	for _, line := range lines {
+		if !strings.Contains(line, `"log":"slow_request"`) {
+			continue
+		}
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("parse slow log: %v", err)
+		}
+		slowEntries = append(slowEntries, entry)
+	}
+	if len(slowEntries) != 1 {
+		t.Fatalf("expected 1 slow log entry, got %d", len(slowEntries))
+	}
+	entry := slowEntries[0]
+	if entry["route_id"] != "api" || entry["request_id"] != slowRequestID || entry["upstream_addr"] != upstreamAddr || entry["threshold_ms"] != float64(50) {
+		t.Fatalf("unexpected slow log entry %v", entry)
+	}
+	if ttfb, _ := entry["upstream_ttfb_us"].(float64); ttfb < 80000 {
+		t.Fatalf("expected phase breakdown in slow log, got %v", entry)
+	}
+
+	ca := testutil.WriteCA(t, "admin-ca")
+	serverCert := testutil.WriteServerCert(t, "admin.local", ca)
+	clientCert := testutil.WriteClientCert(t, "client", ca)
+	auth, err := admin.NewAuthenticator(admin.AuthConfig{Token: "secret", ClientCAFile: ca.CertFile})
+	if err != nil {
+		t.Fatalf("auth config: %v", err)
+	}
+	adminServer := startAdminServer(t, admin.NewHandler(admin.HandlerConfig{Store: store, Auth: auth, SlowRoutes: slowRoutes}), newAdminTLSConfig(t, serverCert.CertFile, serverCert.KeyFile, ca.CertFile))
+	defer adminServer.Close()
+	adminClient := testutil.NewAdminClient(t, testutil.AdminClientConfig{CAFile: ca.CertFile, ClientCert: &clientCert, Token: "secret", ServerName: "admin.local"})
+
+	adminGet := func(query string, out interface{}) int {
+		t.Helper()
+		resp, err := adminClient.Do(mustAdminRequest(t, http.MethodGet, adminServer.URL+"/admin/slow-routes"+query, nil))
+		if err != nil {
+			t.Fatalf("admin request: %v", err)
+		}
+		defer resp.Body.Close()
+		if out != nil {
+			_ = json.NewDecoder(resp.Body).Decode(out)
+		}
+		return resp.StatusCode
+	}
+	var report struct {
+		Window string                 `json:"window"`
+		Routes []obs.SlowRouteSummary `json:"routes"`
+	}
+	if status := adminGet("?limit=1&window=10m", &report); status != http.StatusOK {
+		t.Fatalf("expected report, got %d", status)
+	}
+	if report.Window != "10m0s" || len(report.Routes) != 1 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	top := report.Routes[0]
+	if top.Route != "api" || top.Requests != 2 || top.SlowRequests != 1 || top.MaxMS < 80 || top.Slowest.RequestID != slowRequestID {
+		t.Fatalf("unexpected top route %+v", top)
+	}
+	report.Routes = nil
+	adminGet("", &report)
+	if len(report.Routes) != 2 {
+		t.Fatalf("expected both routes in default report, got %+v", report.Routes)
+	}
+	for _, summary := range report.Routes {
+		if summary.Route == "reports" && summary.SlowRequests != 0 {
+			t.Fatalf("expected route threshold override to apply, got %+v", summary)
+		}
+	}
+	for _, query := range []string{"?limit=0", "?limit=abc", "?window=2h", "?window=10s"} {
+		if status := adminGet(query, nil); status != http.StatusBadRequest {
+			t.Fatalf("expected %s to be rejected, got %d", query, status)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package integration
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+func TestSlowStartRampsNewEndpoint(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	existing, closeExisting := testutil.StartUpstream(t, ok)
+	defer closeExisting()
+	added, closeAdded := testutil.StartUpstream(t, ok)
+	defer closeAdded()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	build := func(endpoints string) {
+		cfg, err := config.ParseJSON([]byte(`{
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1"}],
+"pools": {"p1": {"endpoints": [` + endpoints + `], "slow_start": {"window_ms": 400, "min_weight_percent": 10}}}
+}`))
+		if err != nil {
+			t.Fatalf("parse config: %v", err)
+		}
+		if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil); err != nil {
+			t.Fatalf("build snapshot: %v", err)
+		}
+	}
+	build(`"` + existing + `"`)
+	build(`"` + existing + `", "` + added + `"`)
+
+	share := func() float64 {
+		picks := 0
+		for i := 0; i < 2000; i++ {
+			result, found := reg.Pick("p1", nil)
+			if !found {
+				t.Fatalf("pool p1 missing")
+			}
+			if result.Addr == added {
+				picks++
+			}
+		}
+		return float64(picks) / 2000
+	}
+
+	if got := share(); got <= 0 || got > 0.25 {
+		t.Fatalf("expected new endpoint to start with a small share, got %.2f", got)
+	}
+	time.Sleep(450 * time.Millisecond)
+	if got := share(); got < 0.45 || got > 0.55 {
+		t.Fatalf("expected new endpoint to reach an even share after the window, got %.2f", got)
+	}
+
+	cfg, err := config.ParseJSON([]byte(`{
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1"}],
+"pools": {"p1": {"endpoints": ["127.0.0.1:1"], "slow_start": {"window_ms": 100, "min_weight_percent": 150}}}
+}`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil); err == nil {
+		t.Fatalf("expected min_weight_percent above 100 to be rejected")
+	}
+}
@@ -0,0 +1,107 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/apply"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/provider"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestSnapshotProvenance(t *testing.T) {
+	addr, closeUpstream := testutil.StartUpstream(t, nil)
+	defer closeUpstream()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+
+	store := runtime.NewStore(nil)
+	adminProvider := provider.NewAdminPush()
+	applyManager := apply.NewManager(apply.ManagerConfig{
+		Store:           store,
+		Registry:        reg,
+		TrafficRegistry: trafficReg,
+		Providers:       []provider.Provider{adminProvider},
+		AdminProvider:   adminProvider,
+	})
+
+	raw := []byte(fmt.Sprintf(`{
+"metadata": {"version_label": "release-42", "git_sha": "0a1b2c3d", "author": "deploy-bot"},
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1"}],
+"pools": {"p1": {"endpoints": ["%s"]}}
+}`, addr))
+	result, err := applyManager.Apply(context.Background(), raw, "admin", apply.ModeApply)
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	provenance := result.Snapshot.Provenance
+	if provenance.Label != "release-42" || provenance.GitSHA != "0a1b2c3d" || provenance.Author != "deploy-bot" {
+		t.Fatalf("unexpected provenance %+v", provenance)
+	}
+
+	result, err = applyManager.ApplyResolvedProvenance(context.Background(), raw, "bundle", "v7", runtime.Provenance{Label: "release-43", Author: "alice"}, apply.ModeApply)
+	if err != nil {
+		t.Fatalf("apply resolved: %v", err)
+	}
+	provenance = result.Snapshot.Provenance
+	if provenance.Label != "release-43" || provenance.GitSHA != "0a1b2c3d" || provenance.Author != "alice" {
+		t.Fatalf("expected bundle metadata to override config metadata, got %+v", provenance)
+	}
+
+	if _, err := applyManager.ApplyResolvedProvenance(context.Background(), raw, "bundle", "v8", runtime.Provenance{GitSHA: "not-a-sha"}, apply.ModeValidate); err == nil {
+		t.Fatalf("expected invalid git sha to be rejected")
+	}
+
+	proxyServer := httptest.NewServer(&proxy.Handler{Store: store, Registry: reg, Engine: proxy.NewEngine(reg, nil, metrics, nil, nil), Metrics: metrics})
+	defer proxyServer.Close()
+
+	oldStdout := os.Stdout
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe: %v", err)
+	}
+	os.Stdout = writer
+	defer func() {
+		os.Stdout = oldStdout
+	}()
+
+	client := &http.Client{Timeout: 2 * time.Second}
+	_, _ = sendProxyRequest(t, client, proxyServer.URL, "example.local", http.MethodGet, "/")
+	if err := writer.Close(); err != nil {
+		t.Fatalf("close writer: %v", err)
+	}
+	lines := readLines(t, reader)
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 log line, got %d", len(lines))
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &payload); err != nil {
+		t.Fatalf("parse log json: %v", err)
+	}
+	if payload["snapshot_version"] != "v7" || payload["snapshot_label"] != "release-43" || payload["snapshot_git_sha"] != "0a1b2c3d" || payload["snapshot_author"] != "alice" {
+		t.Fatalf("expected snapshot provenance in access log, got %v", payload)
+	}
+
+	metricsServer := httptest.NewServer(metrics.Handler())
+	defer metricsServer.Close()
+	text := fetchMetrics(t, metricsServer)
+	labels := map[string]string{"version": "v7", "label": "release-43", "git_sha": "0a1b2c3d", "author": "alice"}
+	if value, ok := metricValue(text, "proxy_active_snapshot_info", labels); !ok || value != 1 {
+		t.Fatalf("expected snapshot info with provenance labels, got %v", value)
+	}
+}
@@ -6,10 +6,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
 	"modern_reverse_proxy/internal/proxy"
 	"modern_reverse_proxy/internal/registry"
 	"modern_reverse_proxy/internal/runtime"
@@ -141,6 +143,120 @@ func TestSnapshotSwapAtomicity(t *testing.T) {
 	}
 }
 
+type gatedObserver struct {
+	once    sync.Once
+	reached chan struct{}
+	release chan struct{}
+}
+
+func (g *gatedObserver) ObserveSnapshot(phase string, snapshot *runtime.Snapshot) {
+	if phase != proxy.SnapshotPhaseRouteMatch {
+		return
+	}
+	g.once.Do(func() {
+		close(g.reached)
+		<-g.release
+	})
+}
+
+func TestSnapshotSwapRematch(t *testing.T) {
+	addrA, closeA := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "A")
+	}))
+	defer closeA()
+	addrB, closeB := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "B")
+	}))
+	defer closeB()
+
+	cases := []struct {
+		name     string
+		swap     string
+		expected string
+		action   string
+	}{
+		{name: "default keeps acquired snapshot", swap: `{}`, expected: "A", action: "kept"},
+		{name: "rematch uses new snapshot", swap: `{"rematch": true}`, expected: "B", action: "rematched"},
+		{name: "young requests are kept", swap: `{"rematch": true, "rematch_after_ms": 60000}`, expected: "A", action: "kept"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			reg := registry.NewRegistry(0, 0)
+			defer reg.Close()
+			trafficReg := traffic.NewRegistry(0, 0)
+			defer trafficReg.Close()
+			metrics := obs.NewMetrics(obs.MetricsConfig{})
+
+			build := func(addr string) *runtime.Snapshot {
+				raw := fmt.Sprintf(`{
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1", "policy": {"snapshot_swap": %s}}],
+"pools": {"p1": {"endpoints": ["%s"]}}
+}`, tc.swap, addr)
+				cfg, err := config.ParseJSON([]byte(raw))
+				if err != nil {
+					t.Fatalf("parse config: %v", err)
+				}
+				snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+				if err != nil {
+					t.Fatalf("build snapshot: %v", err)
+				}
+				return snap
+			}
+			store := runtime.NewStore(build(addrA))
+			observer := &gatedObserver{reached: make(chan struct{}), release: make(chan struct{})}
+			proxyServer := httptest.NewServer(&proxy.Handler{
+				Store:            store,
+				Registry:         reg,
+				Engine:           proxy.NewEngine(reg, nil, metrics, nil, nil),
+				Metrics:          metrics,
+				SnapshotObserver: observer,
+			})
+			defer proxyServer.Close()
+
+			bodyCh := make(chan string, 1)
+			go func() {
+				client := &http.Client{Timeout: 2 * time.Second}
+				_, body := sendProxyRequest(t, client, proxyServer.URL, "example.local", http.MethodGet, "/")
+				bodyCh <- string(body)
+			}()
+
+			<-observer.reached
+			nextCfg := fmt.Sprintf(`{
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p2", "policy": {"snapshot_swap": %s}}],
+"pools": {"p1": {"endpoints": ["%s"]}, "p2": {"endpoints": ["%s"]}}
+}`, tc.swap, addrA, addrB)
+			cfg, err := config.ParseJSON([]byte(nextCfg))
+			if err != nil {
+				t.Fatalf("parse config: %v", err)
+			}
+			nextSnap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+			if err != nil {
+				t.Fatalf("build snapshot: %v", err)
+			}
+			if err := store.Swap(nextSnap); err != nil {
+				t.Fatalf("swap: %v", err)
+			}
+			close(observer.release)
+
+			select {
+			case body := <-bodyCh:
+				if body != tc.expected {
+					t.Fatalf("expected %q, got %q", tc.expected, body)
+				}
+			case <-time.After(3 * time.Second):
+				t.Fatalf("timed out waiting for response")
+			}
+
+			metricsServer := httptest.NewServer(metrics.Handler())
+			defer metricsServer.Close()
+			text := fetchMetrics(t, metricsServer)
+			if value, ok := metricValue(text, "proxy_stale_snapshot_requests_total", map[string]string{"action": tc.action}); !ok || value != 1 {
+				t.Fatalf("expected stale snapshot action %q recorded, got %v", tc.action, value)
+			}
+		})
+	}
+}
+
 func makeRequest(client *http.Client, baseURL, host string) (string, error) {
 	req, err := http.NewRequest(http.MethodGet, baseURL+"/", nil)
 	if err != nil {
@@ -0,0 +1,103 @@
+package integration
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/handoff"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+const handoffListener = "handoff-test.http"
+
+func TestSocketHandoffChild(t *testing.T) {
+	if os.Getenv("HANDOFF_TEST_CHILD") != "1" {
+		t.Skip("helper process for TestSocketHandoff")
+	}
+	ln, err := handoff.Listen(handoffListener, "")
+	if err != nil {
+		t.Fatalf("inherit listener: %v", err)
+	}
+	exit := make(chan struct{})
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/exit" {
+			close(exit)
+		}
+		_, _ = fmt.Fprintf(w, "child %d", os.Getpid())
+	})}
+	go func() {
+		_ = server.Serve(ln)
+	}()
+	if !handoff.Inherited() {
+		t.Fatalf("expected child to see the upgrade handshake")
+	}
+	if err := handoff.Ready(); err != nil {
+		t.Fatalf("ready: %v", err)
+	}
+	select {
+	case <-exit:
+	case <-time.After(10 * time.Second):
+	}
+	time.Sleep(50 * time.Millisecond)
+}
+
+func TestSocketHandoff(t *testing.T) {
+	ln, err := handoff.Listen(handoffListener, "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	parent := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "parent")
+	})}
+	go func() {
+		_ = parent.Serve(ln)
+	}()
+	client := &http.Client{Timeout: 2 * time.Second, Transport: &http.Transport{DisableKeepAlives: true}}
+	get := func(path string) string {
+		t.Helper()
+		resp, err := client.Get("http://" + addr + path)
+		if err != nil {
+			t.Fatalf("request %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+	if got := get("/"); got != "parent" {
+		t.Fatalf("expected parent to serve before upgrade, got %q", got)
+	}
+
+	if _, err := handoff.Upgrade(handoff.UpgradeConfig{Path: os.Args[0], Args: []string{"-test.run=^$"}, Timeout: 10 * time.Second}); err == nil || !strings.Contains(err.Error(), "exited before becoming ready") {
+		t.Fatalf("expected upgrade to fail when the new process never signals ready, got %v", err)
+	}
+	if got := get("/"); got != "parent" {
+		t.Fatalf("expected parent to keep serving after failed upgrade, got %q", got)
+	}
+
+	pid, err := handoff.Upgrade(handoff.UpgradeConfig{
+		Path:    os.Args[0],
+		Args:    []string{"-test.run=^TestSocketHandoffChild$"},
+		Env:     append(os.Environ(), "HANDOFF_TEST_CHILD=1"),
+		Timeout: 10 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("upgrade: %v", err)
+	}
+	if err := parent.Close(); err != nil {
+		t.Fatalf("close parent: %v", err)
+	}
+	want := fmt.Sprintf("child %d", pid)
+	testutil.Eventually(t, 2*time.Second, 10*time.Millisecond, func() error {
+		if got := get("/"); got != want {
+			return fmt.Errorf("expected %q after parent closed its listener, got %q", want, got)
+		}
+		return nil
+	})
+	get("/exit")
+}
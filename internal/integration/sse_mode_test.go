@@ -0,0 +1,138 @@
+package integration
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+func TestSSEModeStreamsEvents(t *testing.T) {
+	release := make(chan struct{})
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher := w.(http.Flusher)
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "data: one\n\n")
+		flusher.Flush()
+		_, _ = io.WriteString(w, "data: tw")
+		flusher.Flush()
+		time.Sleep(250 * time.Millisecond)
+		_, _ = io.WriteString(w, "o\n\n")
+		flusher.Flush()
+		select {
+		case <-release:
+		case <-time.After(2 * time.Second):
+		}
+		_, _ = io.WriteString(w, "data: three\n\n")
+	}))
+	defer closeUpstream()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+	cfg, err := config.ParseJSON([]byte(`{
+"routes": [{"id": "events", "host": "events.local", "path_prefix": "/", "pool": "p1", "policy": {"request_timeout_ms": 200, "compression": {"enabled": true, "min_size_bytes": 1}, "sse": {"enabled": true, "heartbeat_interval_ms": 100}}}],
+"pools": {"p1": {"endpoints": ["` + upstreamAddr + `"]}}
+}`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	proxyServer := httptest.NewUnstartedServer(&proxy.Handler{
+		Store:    runtime.NewStore(snap),
+		Registry: reg,
+		Engine:   proxy.NewEngine(reg, nil, metrics, nil, nil),
+		Metrics:  metrics,
+	})
+	proxyServer.Config.WriteTimeout = 300 * time.Millisecond
+	proxyServer.Start()
+	defer proxyServer.Close()
+	metricsServer := httptest.NewServer(metrics.Handler())
+	defer metricsServer.Close()
+
+	req, err := http.NewRequest(http.MethodGet, proxyServer.URL+"/stream", nil)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	req.Host = "events.local"
+	req.Header.Set("Accept-Encoding", "gzip")
+	start := time.Now()
+	resp, err := (&http.Transport{DisableCompression: true}).RoundTrip(req)
+	if err != nil {
+		t.Fatalf("stream request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Encoding") != "" {
+		t.Fatalf("expected uncompressed event stream, got %d %q", resp.StatusCode, resp.Header.Get("Content-Encoding"))
+	}
+	reader := bufio.NewReader(resp.Body)
+	readLine := func() string {
+		t.Helper()
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read stream after %s: %v", time.Since(start), err)
+		}
+		return strings.TrimRight(line, "\n")
+	}
+
+	if line := readLine(); line != "data: one" || time.Since(start) > 200*time.Millisecond {
+		t.Fatalf("expected first event to be flushed immediately, got %q after %s", line, time.Since(start))
+	}
+	readLine()
+	if line := readLine(); line != "data: two" {
+		t.Fatalf("expected partial event to be held until complete, got %q", line)
+	}
+	readLine()
+	if line := readLine(); line != ":" {
+		t.Fatalf("expected heartbeat while upstream stalls, got %q", line)
+	}
+	readLine()
+
+	text := fetchMetrics(t, metricsServer)
+	if value, ok := metricValue(text, "proxy_sse_active_streams", map[string]string{"route": "events"}); !ok || value != 1 {
+		t.Fatalf("expected one active stream, got %v", value)
+	}
+	time.Sleep(300 * time.Millisecond)
+	close(release)
+	for {
+		line := readLine()
+		if line == "data: three" {
+			break
+		}
+		if line != ":" && line != "" {
+			t.Fatalf("unexpected stream line %q", line)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 500*time.Millisecond {
+		t.Fatalf("expected stream to outlive request and write timeouts, took %s", elapsed)
+	}
+	resp.Body.Close()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		text = fetchMetrics(t, metricsServer)
+		if value, _ := metricValue(text, "proxy_sse_active_streams", map[string]string{"route": "events"}); value == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected active stream gauge to return to zero")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if value, ok := metricValue(text, "proxy_sse_heartbeats_total", map[string]string{"route": "events"}); !ok || value < 2 {
+		t.Fatalf("expected heartbeats recorded, got %v", value)
+	}
+}
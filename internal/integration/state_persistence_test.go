@@ -0,0 +1,114 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/breaker"
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/outlier"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+)
+
+func TestStatePersistenceRestoresBreakersAndOutliers(t *testing.T) {
+	const addrA = "127.0.0.1:9001"
+	const addrB = "127.0.0.1:9002"
+	path := filepath.Join(t.TempDir(), "state.json")
+	cfg := &config.Config{
+		Routes: []config.Route{{ID: "r1", Host: "example.local", PathPrefix: "/", Pool: "p1"}},
+		Pools: map[string]config.Pool{
+			"p1": {
+				Endpoints: []string{addrA, addrB},
+				Outlier:   config.OutlierConfig{Enabled: true, ConsecutiveFailures: 1, BaseEjectMS: 60000, MaxEjectMS: 60000, MaxEjectPercent: 100},
+			},
+		},
+	}
+	breakerCfg := breaker.Config{Enabled: true, FailureRateThresholdPercent: 50, MinimumRequests: 1, EvaluationWindow: time.Second, OpenDuration: time.Minute, HalfOpenMaxProbes: 1}
+
+	start := func() (*breaker.Registry, *outlier.Registry) {
+		t.Helper()
+		reg := registry.NewRegistry(0, 0)
+		t.Cleanup(reg.Close)
+		breakerReg := breaker.NewRegistry(0, 0)
+		t.Cleanup(breakerReg.Close)
+		outlierReg := outlier.NewRegistry(0, 0, nil)
+		t.Cleanup(outlierReg.Close)
+		if _, err := runtime.BuildSnapshot(cfg, reg, breakerReg, outlierReg, nil); err != nil {
+			t.Fatalf("build snapshot: %v", err)
+		}
+		return breakerReg, outlierReg
+	}
+	persistence, err := runtime.StatePersistenceFromConfig(config.StatePersistenceConfig{Path: path, MaxAgeMS: 60000})
+	if err != nil {
+		t.Fatalf("state persistence config: %v", err)
+	}
+
+	breakerReg, outlierReg := start()
+	if _, err := breakerReg.Report("r1::p1", breakerCfg, false); err != nil {
+		t.Fatalf("report: %v", err)
+	}
+	if ejected, _ := outlierReg.RecordResult("r1::p1", addrA, false, 0); !ejected {
+		t.Fatalf("expected %s to be ejected", addrA)
+	}
+	if err := runtime.NewStatePersister(persistence, breakerReg, outlierReg).Stop(context.Background()); err != nil {
+		t.Fatalf("save state: %v", err)
+	}
+
+	breakerReg, outlierReg = start()
+	restored, err := runtime.NewStatePersister(persistence, breakerReg, outlierReg).Restore()
+	if err != nil {
+		t.Fatalf("restore state: %v", err)
+	}
+	if restored.Stale || restored.Breakers != 1 || restored.Outliers != 1 {
+		t.Fatalf("unexpected restore result %+v", restored)
+	}
+	if state, allowed, _ := breakerReg.Allow("r1::p1", breakerCfg); allowed || state != breaker.StateOpen {
+		t.Fatalf("expected restored breaker to reject, got state=%s allowed=%v", state, allowed)
+	}
+	now := time.Now()
+	if !outlierReg.IsEjected("r1::p1", addrA, now) || outlierReg.IsEjected("r1::p1", addrB, now) {
+		t.Fatalf("expected only %s to be restored as ejected", addrA)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read state: %v", err)
+	}
+	var saved map[string]interface{}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("decode state: %v", err)
+	}
+	saved["saved_at"] = time.Now().Add(-2 * time.Minute).Format(time.RFC3339Nano)
+	stale, err := json.Marshal(saved)
+	if err != nil {
+		t.Fatalf("encode state: %v", err)
+	}
+	if err := os.WriteFile(path, stale, 0600); err != nil {
+		t.Fatalf("write state: %v", err)
+	}
+	breakerReg, outlierReg = start()
+	restored, err = runtime.NewStatePersister(persistence, breakerReg, outlierReg).Restore()
+	if err != nil {
+		t.Fatalf("restore stale state: %v", err)
+	}
+	if !restored.Stale || restored.Breakers != 0 || restored.Outliers != 0 {
+		t.Fatalf("expected stale state to be ignored, got %+v", restored)
+	}
+	if _, allowed, _ := breakerReg.Allow("r1::p1", breakerCfg); !allowed {
+		t.Fatalf("expected breaker to start closed after stale state")
+	}
+	if outlierReg.IsEjected("r1::p1", addrA, time.Now()) {
+		t.Fatalf("expected outlier state to start clean after stale state")
+	}
+
+	for _, invalid := range []config.StatePersistenceConfig{{Path: path, IntervalMS: -1}, {Path: path, MaxAgeMS: -1}, {IntervalMS: 1000}} {
+		if _, err := runtime.StatePersistenceFromConfig(invalid); err == nil {
+			t.Fatalf("expected %+v to be rejected", invalid)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package integration
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/outlier"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/stream"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+func TestStreamProxy(t *testing.T) {
+	alphaCert := testutil.WriteSelfSignedCert(t, "alpha.test")
+	betaCert := testutil.WriteSelfSignedCert(t, "api.beta.test")
+	plainAddr, closePlain := testutil.StartTCPUpstream(t, nil, "plain")
+	defer closePlain()
+	alphaAddr, closeAlpha := testutil.StartTCPUpstream(t, streamTLSConfig(t, alphaCert), "alpha")
+	defer closeAlpha()
+	betaAddr, closeBeta := testutil.StartTCPUpstream(t, streamTLSConfig(t, betaCert), "beta")
+	defer closeBeta()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	outlierReg := outlier.NewRegistry(0, 0, nil)
+	defer outlierReg.Close()
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+
+	streamCfg := config.Stream{
+		ID:   "edge",
+		Addr: "127.0.0.1:0",
+		Mode: "tcp",
+		Pool: "plain",
+		SNIRoutes: []config.StreamSNIRoute{
+			{ServerNames: []string{"alpha.test"}, Pool: "alpha"},
+			{ServerNames: []string{"*.beta.test"}, Pool: "beta"},
+		},
+	}
+	cfg := &config.Config{
+		Streams: []config.Stream{streamCfg},
+		Pools: map[string]config.Pool{
+			"plain": {Endpoints: []string{plainAddr}},
+			"alpha": {Endpoints: []string{alphaAddr}},
+			"beta":  {Endpoints: []string{betaAddr}},
+		},
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, outlierReg, nil)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	store := runtime.NewStore(snap)
+	listener, err := stream.Listen(stream.Config{
+		ID:              "edge",
+		Addr:            snap.Streams["edge"].Addr,
+		Store:           store,
+		Registry:        reg,
+		OutlierRegistry: outlierReg,
+		Metrics:         metrics,
+	})
+	if err != nil {
+		t.Fatalf("listen stream: %v", err)
+	}
+	defer listener.Stop(context.Background())
+
+	if got := streamExchange(t, listener.Addr(), nil, "hello"); got != "plain:hello" {
+		t.Fatalf("expected plain tcp routed to default pool, got %q", got)
+	}
+	if got := streamExchange(t, listener.Addr(), &tls.Config{ServerName: "alpha.test", RootCAs: x509CertPool(t, alphaCert.Cert)}, "hello"); got != "alpha:hello" {
+		t.Fatalf("expected alpha.test routed by sni, got %q", got)
+	}
+	if got := streamExchange(t, listener.Addr(), &tls.Config{ServerName: "api.beta.test", RootCAs: x509CertPool(t, betaCert.Cert)}, "hello"); got != "beta:hello" {
+		t.Fatalf("expected api.beta.test routed by wildcard sni, got %q", got)
+	}
+
+	streamCfg.Pool = ""
+	cfg.Streams = []config.Stream{streamCfg}
+	next, err := runtime.BuildSnapshot(cfg, reg, nil, outlierReg, nil)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	if err := store.Swap(next); err != nil {
+		t.Fatalf("swap snapshot: %v", err)
+	}
+	conn, err := tls.Dial("tcp", listener.Addr(), &tls.Config{ServerName: "unknown.test", InsecureSkipVerify: true})
+	if err == nil {
+		conn.Close()
+		t.Fatalf("expected unmatched sni to be rejected without default pool")
+	}
+
+	metricsServer := httptest.NewServer(metrics.Handler())
+	defer metricsServer.Close()
+	text := fetchMetrics(t, metricsServer)
+	for _, poolName := range []string{"plain", "alpha", "beta"} {
+		if value, ok := metricValue(text, "proxy_stream_connections_total", map[string]string{"listener": "edge", "pool": poolName, "result": "proxied"}); !ok || value != 1 {
+			t.Fatalf("expected one proxied connection for pool %s, got %v", poolName, value)
+		}
+	}
+	if value, ok := metricValue(text, "proxy_stream_connections_total", map[string]string{"listener": "edge", "pool": "", "result": "no_route"}); !ok || value != 1 {
+		t.Fatalf("expected one no_route connection, got %v", value)
+	}
+	if value, ok := metricValue(text, "proxy_stream_bytes_total", map[string]string{"listener": "edge", "direction": "downstream"}); !ok || value <= 0 {
+		t.Fatalf("expected downstream bytes recorded, got %v", value)
+	}
+}
+
+func TestStreamListenersReconcileOnSwap(t *testing.T) {
+	plainAddr, closePlain := testutil.StartTCPUpstream(t, nil, "plain")
+	defer closePlain()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	outlierReg := outlier.NewRegistry(0, 0, nil)
+	defer outlierReg.Close()
+	pools := map[string]config.Pool{"plain": {Endpoints: []string{plainAddr}}}
+
+	initial, err := runtime.BuildSnapshot(&config.Config{Pools: pools}, reg, nil, outlierReg, nil)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	store := runtime.NewStore(initial)
+	manager := stream.NewManager(stream.ManagerConfig{Store: store, Registry: reg, OutlierRegistry: outlierReg, Metrics: obs.NewMetrics(obs.MetricsConfig{})})
+	defer manager.Stop(context.Background())
+	if err := manager.Reconcile(initial); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	store.OnSwap(func(next *runtime.Snapshot) {
+		_ = manager.Reconcile(next)
+	})
+
+	withStream, err := runtime.BuildSnapshot(&config.Config{
+		Streams: []config.Stream{{ID: "edge", Addr: "127.0.0.1:0", Mode: "tcp", Pool: "plain"}},
+		Pools:   pools,
+	}, reg, nil, outlierReg, nil)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	if err := store.Swap(withStream); err != nil {
+		t.Fatalf("swap: %v", err)
+	}
+	addr, ok := manager.Addr("edge")
+	if !ok {
+		t.Fatalf("expected stream added by apply to be listening")
+	}
+	if got := streamExchange(t, addr, nil, "hello"); got != "plain:hello" {
+		t.Fatalf("expected added stream to proxy, got %q", got)
+	}
+
+	if err := store.Swap(initial); err != nil {
+		t.Fatalf("swap: %v", err)
+	}
+	if _, ok := manager.Addr("edge"); ok {
+		t.Fatalf("expected removed stream to stop listening")
+	}
+	testutil.Eventually(t, time.Second, 20*time.Millisecond, func() error {
+		conn, err := net.DialTimeout("tcp", addr, 100*time.Millisecond)
+		if err != nil {
+			return nil
+		}
+		conn.Close()
+		return fmt.Errorf("removed stream %s still accepts connections", addr)
+	})
+}
+
+func TestStreamConfigValidation(t *testing.T) {
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	pools := map[string]config.Pool{"p1": {Endpoints: []string{"127.0.0.1:1"}}}
+
+	cases := []struct {
+		name   string
+		stream config.Stream
+		pools  map[string]config.Pool
+		want   string
+	}{
+		{name: "mode", stream: config.Stream{ID: "s1", Addr: "127.0.0.1:0", Mode: "udp", Pool: "p1"}, want: "mode must be tcp"},
+		{name: "missing pool", stream: config.Stream{ID: "s1", Addr: "127.0.0.1:0", Mode: "tcp", Pool: "missing"}, want: "missing pool"},
+		{name: "no target", stream: config.Stream{ID: "s1", Addr: "127.0.0.1:0", Mode: "tcp"}, want: "requires pool or sni_routes"},
+		{name: "bad sni", stream: config.Stream{ID: "s1", Addr: "127.0.0.1:0", Mode: "tcp", SNIRoutes: []config.StreamSNIRoute{{ServerNames: []string{"a.*.test"}, Pool: "p1"}}}, want: "is invalid"},
+		{name: "health type", stream: config.Stream{ID: "s1", Addr: "127.0.0.1:0", Mode: "tcp", Pool: "p1"}, pools: map[string]config.Pool{"p1": {Endpoints: []string{"127.0.0.1:1"}, Health: config.HealthConfig{Type: "udp"}}}, want: "health type"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfgPools := pools
+			if tc.pools != nil {
+				cfgPools = tc.pools
+			}
+			_, err := runtime.BuildSnapshot(&config.Config{Streams: []config.Stream{tc.stream}, Pools: cfgPools}, reg, nil, nil, nil)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func streamTLSConfig(t *testing.T, files testutil.CertFiles) *tls.Config {
+	t.Helper()
+	cert, err := tls.LoadX509KeyPair(files.CertFile, files.KeyFile)
+	if err != nil {
+		t.Fatalf("load cert: %v", err)
+	}
+	return &tls.Config{Certificates: []tls.Certificate{cert}}
+}
+
+func streamExchange(t *testing.T, addr string, tlsConfig *tls.Config, line string) string {
+	t.Helper()
+	dialer := &net.Dialer{Timeout: 2 * time.Second}
+	var conn net.Conn
+	var err error
+	if tlsConfig != nil {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		t.Fatalf("dial stream: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(2 * time.Second))
+	if _, err := fmt.Fprintf(conn, "%s\n", line); err != nil {
+		t.Fatalf("write stream: %v", err)
+	}
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		t.Fatalf("read stream: %v", err)
+	}
+	return strings.TrimSpace(reply)
+}
@@ -0,0 +1,161 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/handoff"
+)
+
+func TestSystemdActivationChild(t *testing.T) {
+	if os.Getenv("SYSTEMD_TEST_CHILD") != "1" {
+		t.Skip("helper process for TestSystemdActivation")
+	}
+	_ = os.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	ln, err := handoff.Listen("http", os.Getenv("SYSTEMD_TEST_ADDR"))
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	if os.Getenv("LISTEN_FDS") != "" {
+		t.Fatalf("expected LISTEN_FDS to be cleared once consumed")
+	}
+	exit := make(chan struct{})
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/exit" {
+			close(exit)
+		}
+		_, _ = io.WriteString(w, "activated")
+	})}
+	go func() {
+		_ = server.Serve(ln)
+	}()
+	if err := handoff.Ready(); err != nil {
+		t.Fatalf("ready: %v", err)
+	}
+	if err := handoff.Notify("READY=1"); err != nil {
+		t.Fatalf("notify ready: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go handoff.RunWatchdog(ctx, nil)
+	select {
+	case <-exit:
+	case <-time.After(10 * time.Second):
+	}
+	if err := handoff.Notify("STOPPING=1"); err != nil {
+		t.Fatalf("notify stopping: %v", err)
+	}
+}
+
+func TestSystemdActivation(t *testing.T) {
+	cases := []struct {
+		name    string
+		fdNames string
+	}{
+		{name: "named", fdNames: "http"},
+		{name: "by_address", fdNames: "proxy.socket"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			notifyPath := filepath.Join(t.TempDir(), "notify.sock")
+			notify, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: notifyPath, Net: "unixgram"})
+			if err != nil {
+				t.Fatalf("notify socket: %v", err)
+			}
+			defer notify.Close()
+
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("listen: %v", err)
+			}
+			addr := ln.Addr().String()
+			file, err := ln.(*net.TCPListener).File()
+			if err != nil {
+				t.Fatalf("listener file: %v", err)
+			}
+			_ = ln.Close()
+
+			childAddr := ""
+			if tc.fdNames != "http" {
+				childAddr = addr
+			}
+			cmd := exec.Command(os.Args[0], "-test.run=^TestSystemdActivationChild$")
+			cmd.Env = append(os.Environ(),
+				"SYSTEMD_TEST_CHILD=1",
+				"SYSTEMD_TEST_ADDR="+childAddr,
+				"LISTEN_FDS=1",
+				"LISTEN_FDNAMES="+tc.fdNames,
+				"NOTIFY_SOCKET="+notifyPath,
+				"WATCHDOG_USEC=100000",
+			)
+			cmd.ExtraFiles = []*os.File{file}
+			if err := cmd.Start(); err != nil {
+				t.Fatalf("start child: %v", err)
+			}
+			_ = file.Close()
+			defer func() {
+				_ = cmd.Process.Kill()
+				_ = cmd.Wait()
+			}()
+
+			states := make(chan string, 64)
+			go func() {
+				buf := make([]byte, 256)
+				for {
+					n, err := notify.Read(buf)
+					if err != nil {
+						close(states)
+						return
+					}
+					states <- string(buf[:n])
+				}
+			}()
+			waitState := func(want string) {
+				t.Helper()
+				deadline := time.After(5 * time.Second)
+				for {
+					select {
+					case state, ok := <-states:
+						if !ok {
+							t.Fatalf("notify socket closed before %s", want)
+						}
+						if state == want {
+							return
+						}
+					case <-deadline:
+						t.Fatalf("expected %s notification", want)
+					}
+				}
+			}
+			waitState("READY=1")
+
+			client := &http.Client{Timeout: 2 * time.Second, Transport: &http.Transport{DisableKeepAlives: true}}
+			get := func(path string) string {
+				t.Helper()
+				resp, err := client.Get(fmt.Sprintf("http://%s%s", addr, path))
+				if err != nil {
+					t.Fatalf("request %s: %v", path, err)
+				}
+				defer resp.Body.Close()
+				body, _ := io.ReadAll(resp.Body)
+				return string(body)
+			}
+			if got := get("/"); !strings.Contains(got, "activated") {
+				t.Fatalf("expected activated socket to be served by child, got %q", got)
+			}
+			waitState("WATCHDOG=1")
+			get("/exit")
+			waitState("STOPPING=1")
+		})
+	}
+}
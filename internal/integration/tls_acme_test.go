@@ -0,0 +1,147 @@
+package integration
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestACMECachedCertificateServed(t *testing.T) {
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	addr, closeUpstream := testutil.StartUpstream(t, upstream)
+	defer closeUpstream()
+
+	directory := httptest.NewServer(http.NotFoundHandler())
+	defer directory.Close()
+	cacheDir := filepath.Join(t.TempDir(), "acme")
+	cached := testutil.WriteACMECacheCert(t, cacheDir, "acme.example.com")
+
+	cfg := &config.Config{
+		TLS: config.TLSConfig{
+			Enabled: true,
+			Addr:    "127.0.0.1:0",
+			ACME: config.ACMEConfig{
+				Enabled:       true,
+				Hostnames:     []string{"acme.example.com"},
+				DirectoryURL:  directory.URL,
+				CacheDir:      cacheDir,
+				RenewBeforeMS: int(time.Hour / time.Millisecond),
+			},
+		},
+		Routes: []config.Route{
+			{ID: "r1", Host: "acme.example.com", PathPrefix: "/", Pool: "p1"},
+		},
+		Pools: map[string]config.Pool{
+			"p1": {Endpoints: []string{addr}},
+		},
+	}
+	proxyServer, _, _ := startTLSProxy(t, cfg)
+
+	client := &http.Client{
+		Timeout: 2 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: x509CertPool(t, cached), ServerName: "acme.example.com"},
+		},
+	}
+	resp, _ := sendProxyRequest(t, client, "https://"+proxyServer.TLSAddr, "acme.example.com", http.MethodGet, "/")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 over acme certificate, got %d", resp.StatusCode)
+	}
+	if resp.TLS == nil || len(resp.TLS.PeerCertificates) == 0 || resp.TLS.PeerCertificates[0].SerialNumber.Cmp(cached.SerialNumber) != 0 {
+		t.Fatalf("expected cached acme certificate to be served")
+	}
+
+	conn, err := tls.Dial("tcp", proxyServer.TLSAddr, &tls.Config{ServerName: "other.example.com", InsecureSkipVerify: true})
+	if err == nil {
+		conn.Close()
+		t.Fatalf("expected handshake failure for host outside acme hostnames")
+	}
+}
+
+func TestACMEHTTP01ChallengeIntercepted(t *testing.T) {
+	var upstreamHits atomic.Int64
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamHits.Add(1)
+		w.WriteHeader(http.StatusOK)
+	})
+	addr, closeUpstream := testutil.StartUpstream(t, upstream)
+	defer closeUpstream()
+
+	cacheDir := filepath.Join(t.TempDir(), "acme")
+	testutil.WriteACMECacheCert(t, cacheDir, "challenge.example.com")
+	cfg := &config.Config{
+		TLS: config.TLSConfig{
+			Enabled: true,
+			Addr:    "127.0.0.1:0",
+			ACME: config.ACMEConfig{
+				Enabled:       true,
+				Hostnames:     []string{"challenge.example.com"},
+				DirectoryURL:  "https://acme.invalid/directory",
+				CacheDir:      cacheDir,
+				RenewBeforeMS: int(time.Hour / time.Millisecond),
+			},
+		},
+		Routes: []config.Route{
+			{ID: "r1", Host: "challenge.example.com", PathPrefix: "/", Pool: "p1"},
+		},
+		Pools: map[string]config.Pool{
+			"p1": {Endpoints: []string{addr}},
+		},
+	}
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, traffic.NewRegistry(0, 0))
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	if !containsString(snap.TLSConfig.NextProtos, "acme-tls/1") {
+		t.Fatalf("expected acme-tls/1 advertised for tls-alpn-01")
+	}
+	proxyServer := httptest.NewServer(&proxy.Handler{
+		Store:    runtime.NewStore(snap),
+		Registry: reg,
+		Engine:   proxy.NewEngine(reg, nil, nil, nil, nil),
+	})
+	defer proxyServer.Close()
+	client := &http.Client{Timeout: 2 * time.Second}
+
+	resp, _ := sendProxyRequest(t, client, proxyServer.URL, "challenge.example.com", http.MethodGet, "/.well-known/acme-challenge/unknown-token")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected unknown challenge token to 404, got %d", resp.StatusCode)
+	}
+	resp, _ = sendProxyRequest(t, client, proxyServer.URL, "challenge.example.com", http.MethodGet, "/app")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected regular request proxied, got %d", resp.StatusCode)
+	}
+	if upstreamHits.Load() != 1 {
+		t.Fatalf("expected challenge request not proxied upstream, got %d upstream hits", upstreamHits.Load())
+	}
+
+	cfg.TLS.ACME.CacheDir = ""
+	if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, traffic.NewRegistry(0, 0)); err == nil || !strings.Contains(err.Error(), "cache_dir") {
+		t.Fatalf("expected missing cache_dir rejected, got %v", err)
+	}
+}
+
+func containsString(values []string, want string) bool {
+	for _, value := range values {
+		if value == want {
+			return true
+		}
+	}
+	return false
+}
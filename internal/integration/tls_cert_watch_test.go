@@ -0,0 +1,140 @@
+package integration
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+func TestTLSCertWatchReload(t *testing.T) {
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	addr, closeUpstream := testutil.StartUpstream(t, upstream)
+	defer closeUpstream()
+
+	cert1 := testutil.WriteSelfSignedCert(t, "rotate.local")
+	cert2 := testutil.WriteSelfSignedCert(t, "rotate.local")
+	liveDir := t.TempDir()
+	liveCert := filepath.Join(liveDir, "tls.crt")
+	liveKey := filepath.Join(liveDir, "tls.key")
+	installCertFiles(t, cert1, liveCert, liveKey, time.Now().Add(-time.Minute))
+
+	cfg := &config.Config{
+		TLS: config.TLSConfig{
+			Enabled: true,
+			Addr:    "127.0.0.1:0",
+			Certs:   []config.TLSCert{{ServerName: "rotate.local", CertFile: liveCert, KeyFile: liveKey}},
+		},
+		Routes: []config.Route{
+			{ID: "r1", Host: "rotate.local", PathPrefix: "/", Pool: "p1"},
+		},
+		Pools: map[string]config.Pool{
+			"p1": {Endpoints: []string{addr}},
+		},
+	}
+	proxyServer, store, _ := startTLSProxy(t, cfg)
+	versionBefore := store.Get().Version
+
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+	watcher := runtime.NewCertWatcher(store, 20*time.Millisecond, metrics.RecordCertReload)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan struct{}, 1)
+	go watcher.Run(ctx, signals)
+
+	client := &http.Client{
+		Timeout: 2 * time.Second,
+		Transport: &http.Transport{
+			DisableKeepAlives: true,
+			TLSClientConfig:   &tls.Config{RootCAs: x509CertPool(t, cert1.Cert, cert2.Cert), ServerName: "rotate.local"},
+		},
+	}
+	servedFingerprint := func() string {
+		resp, _ := sendProxyRequest(t, client, "https://"+proxyServer.TLSAddr, "rotate.local", http.MethodGet, "/")
+		return fingerprintCert(t, resp)
+	}
+	waitForFingerprint := func(expected string) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for servedFingerprint() != expected {
+			if time.Now().After(deadline) {
+				t.Fatalf("served certificate did not change")
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+	first := servedFingerprint()
+
+	installCertFiles(t, cert2, liveCert, liveKey, time.Now())
+	waitForFingerprint(certFingerprint(cert2))
+	if store.Get().Version != versionBefore {
+		t.Fatalf("expected cert reload to keep snapshot version")
+	}
+
+	if err := os.WriteFile(liveCert, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatalf("write broken cert: %v", err)
+	}
+	if err := os.Chtimes(liveCert, time.Now().Add(time.Minute), time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("touch cert: %v", err)
+	}
+	if result := watcher.Reload("watch", false); result != "error" {
+		t.Fatalf("expected broken cert to fail reload, got %s", result)
+	}
+	if servedFingerprint() == first {
+		t.Fatalf("expected last good certificate to stay active")
+	}
+
+	cancel()
+	signalCtx, signalCancel := context.WithCancel(context.Background())
+	defer signalCancel()
+	go runtime.NewCertWatcher(store, time.Hour, metrics.RecordCertReload).Run(signalCtx, signals)
+	installCertFiles(t, cert1, liveCert, liveKey, time.Time{})
+	signals <- struct{}{}
+	waitForFingerprint(first)
+
+	metricsServer := httptest.NewServer(metrics.Handler())
+	defer metricsServer.Close()
+	text := fetchMetrics(t, metricsServer)
+	if value, ok := metricValue(text, "proxy_tls_cert_reloads_total", map[string]string{"trigger": "signal", "result": "reloaded"}); !ok || value != 1 {
+		t.Fatalf("expected one signal reload, got %v", value)
+	}
+	if value, ok := metricValue(text, "proxy_tls_cert_reloads_total", map[string]string{"trigger": "watch", "result": "error"}); !ok || value < 1 {
+		t.Fatalf("expected watch reload error recorded, got %v", value)
+	}
+}
+
+func installCertFiles(t *testing.T, files testutil.CertFiles, certPath string, keyPath string, modTime time.Time) {
+	t.Helper()
+	for src, dst := range map[string]string{files.CertFile: certPath, files.KeyFile: keyPath} {
+		data, err := os.ReadFile(src)
+		if err != nil {
+			t.Fatalf("read %s: %v", src, err)
+		}
+		if err := os.WriteFile(dst, data, 0o600); err != nil {
+			t.Fatalf("write %s: %v", dst, err)
+		}
+		if !modTime.IsZero() {
+			if err := os.Chtimes(dst, modTime, modTime); err != nil {
+				t.Fatalf("touch %s: %v", dst, err)
+			}
+		}
+	}
+}
+
+func certFingerprint(files testutil.CertFiles) string {
+	sum := sha256.Sum256(files.Cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,119 @@
+package integration
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestTLSFingerprintLoggingAndPolicy(t *testing.T) {
+	addr, closeUpstream := testutil.StartUpstream(t, nil)
+	defer closeUpstream()
+
+	serverCert := testutil.WriteSelfSignedCert(t, "example.local")
+	cfg := &config.Config{
+		TLS: config.TLSConfig{
+			Enabled: true,
+			Addr:    "127.0.0.1:0",
+			Certs: []config.TLSCert{
+				{ServerName: "example.local", CertFile: serverCert.CertFile, KeyFile: serverCert.KeyFile},
+			},
+		},
+		Routes: []config.Route{
+			{ID: "r1", Host: "example.local", PathPrefix: "/", Pool: "p1"},
+		},
+		Pools: map[string]config.Pool{
+			"p1": {Endpoints: []string{addr}},
+		},
+	}
+	proxyServer, store, reg := startTLSProxy(t, cfg)
+
+	oldStdout := os.Stdout
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe: %v", err)
+	}
+	os.Stdout = writer
+	defer func() {
+		os.Stdout = oldStdout
+	}()
+
+	client := &http.Client{
+		Timeout: 2 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: x509CertPool(t, serverCert.Cert), ServerName: "example.local"},
+		},
+	}
+	baseURL := "https://" + proxyServer.TLSAddr
+	resp, _ := sendProxyRequest(t, client, baseURL, "example.local", http.MethodGet, "/")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("close writer: %v", err)
+	}
+	os.Stdout = oldStdout
+	lines := readLines(t, reader)
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 log line, got %d", len(lines))
+	}
+	var payload struct {
+		JA3 string `json:"tls_ja3"`
+		JA4 string `json:"tls_ja4"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &payload); err != nil {
+		t.Fatalf("parse log json: %v", err)
+	}
+	if !regexp.MustCompile(`^[0-9a-f]{32}$`).MatchString(payload.JA3) {
+		t.Fatalf("expected ja3 hash in log, got %q", payload.JA3)
+	}
+	if !regexp.MustCompile(`^t13d\d{4}[0-9a-z]{2}_[0-9a-f]{12}_[0-9a-f]{12}$`).MatchString(payload.JA4) {
+		t.Fatalf("expected ja4 fingerprint in log, got %q", payload.JA4)
+	}
+
+	swapFingerprintPolicy(t, store, reg, cfg, config.FingerprintConfig{Deny: []string{payload.JA4}})
+	resp, body := sendProxyRequest(t, client, baseURL, "example.local", http.MethodGet, "/")
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 for denied fingerprint, got %d", resp.StatusCode)
+	}
+	assertProxyError(t, resp, body, "fingerprint_denied")
+
+	swapFingerprintPolicy(t, store, reg, cfg, config.FingerprintConfig{Allow: []string{payload.JA3}})
+	resp, _ = sendProxyRequest(t, client, baseURL, "example.local", http.MethodGet, "/")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 for allowed ja3, got %d", resp.StatusCode)
+	}
+
+	swapFingerprintPolicy(t, store, reg, cfg, config.FingerprintConfig{RateLimitRPS: 1, RateLimitBurst: 1})
+	resp, _ = sendProxyRequest(t, client, baseURL, "example.local", http.MethodGet, "/")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected first request within rate limit, got %d", resp.StatusCode)
+	}
+	resp, body = sendProxyRequest(t, client, baseURL, "example.local", http.MethodGet, "/")
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 for rate limited fingerprint, got %d", resp.StatusCode)
+	}
+	assertProxyError(t, resp, body, "fingerprint_rate_limited")
+}
+
+func swapFingerprintPolicy(t *testing.T, store *runtime.Store, reg *registry.Registry, cfg *config.Config, fingerprintCfg config.FingerprintConfig) {
+	t.Helper()
+	cfg.Routes[0].Policy.TLSFingerprint = fingerprintCfg
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, traffic.NewRegistry(0, 0))
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	if err := store.Swap(snap); err != nil {
+		t.Fatalf("swap snapshot: %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package integration
+
+import (
+	"crypto/tls"
+	"net/http/httptest"
+	"testing"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+func TestTLSHandshakeAndCertificateStats(t *testing.T) {
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, nil)
+	defer closeUpstream()
+
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+	obs.SetDefaultMetrics(metrics)
+	defer obs.SetDefaultMetrics(nil)
+
+	certA := testutil.WriteSelfSignedCert(t, "a.local")
+	certB := testutil.WriteSelfSignedCert(t, "b.local")
+	cfg := &config.Config{
+		TLS: config.TLSConfig{
+			Enabled: true,
+			Addr:    "127.0.0.1:0",
+			Certs: []config.TLSCert{
+				{ServerName: "a.local", CertFile: certA.CertFile, KeyFile: certA.KeyFile},
+				{ServerName: "B.local", CertFile: certB.CertFile, KeyFile: certB.KeyFile},
+			},
+		},
+		Routes: []config.Route{
+			{ID: "r1", Host: "a.local", PathPrefix: "/", Pool: "p1"},
+		},
+		Pools: map[string]config.Pool{
+			"p1": {Endpoints: []string{upstreamAddr}},
+		},
+	}
+	proxyServer, store, _ := startTLSProxy(t, cfg)
+
+	for _, serverName := range []string{"b.local", "unknown.local", ""} {
+		conn, err := tls.Dial("tcp", proxyServer.TLSAddr, &tls.Config{ServerName: serverName, InsecureSkipVerify: true})
+		if err != nil {
+			t.Fatalf("handshake with sni %q: %v", serverName, err)
+		}
+		_ = conn.Close()
+	}
+	runtime.NewCertWatcher(store, 0, nil).Reload("watch", false)
+
+	metricsServer := httptest.NewServer(metrics.Handler())
+	defer metricsServer.Close()
+	text := fetchMetrics(t, metricsServer)
+	if value, ok := metricValue(text, "proxy_tls_handshakes_total", map[string]string{"listener": "tls", "version": "TLS 1.3"}); !ok || value != 3 {
+		t.Fatalf("expected three TLS 1.3 handshakes, got %v", value)
+	}
+	if value, ok := metricValue(text, "proxy_tls_certificate_selections_total", map[string]string{"certificate": "b.local"}); !ok || value != 1 {
+		t.Fatalf("expected b.local selected once, got %v", value)
+	}
+	if value, ok := metricValue(text, "proxy_tls_certificate_selections_total", map[string]string{"certificate": "a.local"}); !ok || value != 2 {
+		t.Fatalf("expected default certificate selected twice, got %v", value)
+	}
+	for _, reason := range []string{"unknown_sni", "no_sni"} {
+		if value, ok := metricValue(text, "proxy_tls_sni_misses_total", map[string]string{"reason": reason}); !ok || value != 1 {
+			t.Fatalf("expected one %s miss, got %v", reason, value)
+		}
+	}
+	for _, name := range []string{"a.local", "b.local"} {
+		if value, ok := metricValue(text, "proxy_tls_certificate_expiry_days", map[string]string{"certificate": name}); !ok || value <= 0.9 || value > 1 {
+			t.Fatalf("expected %s to expire in about a day, got %v", name, value)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
 	"modern_reverse_proxy/internal/proxy"
 	"modern_reverse_proxy/internal/registry"
 	"modern_reverse_proxy/internal/runtime"
@@ -14,6 +15,11 @@ import (
 )
 
 func startTLSProxy(t *testing.T, cfg *config.Config) (*server.Server, *runtime.Store, *registry.Registry) {
+	t.Helper()
+	return startTLSProxyWithMetrics(t, cfg, nil)
+}
+
+func startTLSProxyWithMetrics(t *testing.T, cfg *config.Config, metrics *obs.Metrics) (*server.Server, *runtime.Store, *registry.Registry) {
 	t.Helper()
 	reg := registry.NewRegistry(0, 0)
 	trafficReg := traffic.NewRegistry(0, 0)
@@ -30,8 +36,13 @@ func startTLSProxy(t *testing.T, cfg *config.Config) (*server.Server, *runtime.S
 		reg.Close()
 		t.Fatalf("shutdown config: %v", err)
 	}
+	http3Config, err := runtime.HTTP3FromConfig(cfg.TLS)
+	if err != nil {
+		reg.Close()
+		t.Fatalf("http3 config: %v", err)
+	}
 	inflight := runtime.NewInflightTracker()
-	proxyHandler := &proxy.Handler{Store: store, Registry: reg, Engine: proxy.NewEngine(reg, nil, nil, nil, nil), Inflight: inflight}
+	proxyHandler := &proxy.Handler{Store: store, Registry: reg, Engine: proxy.NewEngine(reg, nil, metrics, nil, nil), Metrics: metrics, Inflight: inflight}
 	mux := http.NewServeMux()
 	mux.Handle("/", proxyHandler)
 
@@ -40,6 +51,7 @@ func startTLSProxy(t *testing.T, cfg *config.Config) (*server.Server, *runtime.S
 		Shutdown: shutdownConfig,
 		Inflight: inflight,
 		Stoppers: []server.Stopper{reg, trafficReg},
+		HTTP3:    http3Config,
 	})
 	if err != nil {
 		reg.Close()
@@ -0,0 +1,80 @@
+package integration
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go/http3"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+func TestHTTP3Listener(t *testing.T) {
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "path="+r.URL.Path)
+	})
+	addr, closeUpstream := testutil.StartUpstream(t, upstream)
+	defer closeUpstream()
+
+	serverCert := testutil.WriteSelfSignedCert(t, "quic.local")
+	cfg := &config.Config{
+		TLS: config.TLSConfig{
+			Enabled: true,
+			Addr:    "127.0.0.1:0",
+			Certs:   []config.TLSCert{{ServerName: "quic.local", CertFile: serverCert.CertFile, KeyFile: serverCert.KeyFile}},
+			HTTP3:   config.HTTP3Config{Enabled: true, AltSvcMaxAgeMS: 3600000},
+		},
+		Routes: []config.Route{{ID: "r1", Host: "quic.local", PathPrefix: "/", Pool: "p1"}},
+		Pools:  map[string]config.Pool{"p1": {Endpoints: []string{addr}}},
+	}
+	proxyServer, _, _ := startTLSProxy(t, cfg)
+	if proxyServer.HTTP3Addr == "" {
+		t.Fatalf("expected http3 listener address")
+	}
+	_, tlsPort, _ := net.SplitHostPort(proxyServer.TLSAddr)
+	_, h3Port, _ := net.SplitHostPort(proxyServer.HTTP3Addr)
+	if tlsPort != h3Port {
+		t.Fatalf("expected http3 to share tls port %s, got %s", tlsPort, h3Port)
+	}
+
+	tlsClientConfig := &tls.Config{RootCAs: x509CertPool(t, serverCert.Cert), ServerName: "quic.local"}
+	tlsClient := &http.Client{Timeout: 2 * time.Second, Transport: &http.Transport{TLSClientConfig: tlsClientConfig}}
+	resp, _ := sendProxyRequest(t, tlsClient, "https://"+proxyServer.TLSAddr, "quic.local", http.MethodGet, "/")
+	if expected := fmt.Sprintf(`h3=":%s"; ma=3600`, h3Port); resp.Header.Get("Alt-Svc") != expected {
+		t.Fatalf("expected Alt-Svc %q, got %q", expected, resp.Header.Get("Alt-Svc"))
+	}
+
+	roundTripper := &http3.RoundTripper{TLSClientConfig: tlsClientConfig}
+	defer roundTripper.Close()
+	h3Client := &http.Client{Timeout: 2 * time.Second, Transport: roundTripper}
+	resp, body := sendProxyRequest(t, h3Client, "https://"+proxyServer.HTTP3Addr, "quic.local", http.MethodGet, "/over-quic")
+	if resp.StatusCode != http.StatusOK || string(body) != "path=/over-quic" {
+		t.Fatalf("expected proxied http3 response, got %d %q", resp.StatusCode, body)
+	}
+	if resp.ProtoMajor != 3 {
+		t.Fatalf("expected HTTP/3 response, got %s", resp.Proto)
+	}
+	if resp.Header.Get("Alt-Svc") != "" {
+		t.Fatalf("expected no Alt-Svc over http3")
+	}
+
+	if err := proxyServer.Close(); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	req, err := http.NewRequest(http.MethodGet, "https://"+proxyServer.HTTP3Addr+"/", nil)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	req.Host = "quic.local"
+	freshClient := &http.Client{Timeout: time.Second, Transport: &http3.RoundTripper{TLSClientConfig: tlsClientConfig}}
+	if resp, err := freshClient.Do(req); err == nil {
+		resp.Body.Close()
+		t.Fatalf("expected http3 listener closed after shutdown")
+	}
+}
@@ -0,0 +1,162 @@
+package integration
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+func TestOCSPStapling(t *testing.T) {
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	addr, closeUpstream := testutil.StartUpstream(t, upstream)
+	defer closeUpstream()
+
+	ca := testutil.WriteCA(t, "staple-ca")
+	responder := testutil.StartOCSPResponder(t, ca)
+	defer responder.Close()
+	serverCert := testutil.WriteServerCertChain(t, "staple.local", ca, responder.URL)
+
+	cfg := &config.Config{
+		TLS: config.TLSConfig{
+			Enabled:      true,
+			Addr:         "127.0.0.1:0",
+			OCSPStapling: true,
+			Certs:        []config.TLSCert{{ServerName: "staple.local", CertFile: serverCert.CertFile, KeyFile: serverCert.KeyFile}},
+		},
+		Routes: []config.Route{{ID: "r1", Host: "staple.local", PathPrefix: "/", Pool: "p1"}},
+		Pools:  map[string]config.Pool{"p1": {Endpoints: []string{addr}}},
+	}
+	proxyServer, _, _ := startTLSProxy(t, cfg)
+
+	var staple []byte
+	deadline := time.Now().Add(2 * time.Second)
+	for len(staple) == 0 {
+		conn, err := tls.Dial("tcp", proxyServer.TLSAddr, &tls.Config{RootCAs: x509CertPool(t, ca.Cert), ServerName: "staple.local"})
+		if err != nil {
+			t.Fatalf("tls dial: %v", err)
+		}
+		staple = conn.ConnectionState().OCSPResponse
+		_ = conn.Close()
+		if len(staple) == 0 {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected stapled OCSP response")
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+	response, err := ocsp.ParseResponseForCert(staple, serverCert.Cert, ca.Cert)
+	if err != nil {
+		t.Fatalf("parse staple: %v", err)
+	}
+	if response.Status != ocsp.Good {
+		t.Fatalf("expected good staple, got status %d", response.Status)
+	}
+}
+
+func TestMTLSRevocationChecks(t *testing.T) {
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	addr, closeUpstream := testutil.StartUpstream(t, upstream)
+	defer closeUpstream()
+
+	serverCert := testutil.WriteSelfSignedCert(t, "mesh.local")
+	clientCA := testutil.WriteCA(t, "client-ca")
+	responder := testutil.StartOCSPResponder(t, clientCA)
+	defer responder.Close()
+	deadResponder := testutil.StartOCSPResponder(t, clientCA)
+	deadResponder.Close()
+
+	goodCert := testutil.WriteClientCertWithOCSP(t, "good", clientCA, responder.URL)
+	revokedCert := testutil.WriteClientCertWithOCSP(t, "revoked", clientCA, responder.URL)
+	responder.SetStatus(revokedCert.Cert.SerialNumber, ocsp.Revoked)
+	crlRevokedCert := testutil.WriteClientCert(t, "crl-revoked", clientCA)
+	crlFile := testutil.WriteCRL(t, clientCA, crlRevokedCert.Cert.SerialNumber)
+	unreachableCert := testutil.WriteClientCertWithOCSP(t, "unreachable", clientCA, deadResponder.URL)
+
+	mtlsRoute := func(id string, revocation config.RevocationConfig) config.Route {
+		return config.Route{ID: id, Host: "mesh.local", PathPrefix: "/" + id, Pool: "p1", Policy: config.RoutePolicy{
+			RequireMTLS: true,
+			MTLS:        config.MTLSConfig{Revocation: revocation},
+		}}
+	}
+	cfg := &config.Config{
+		TLS: config.TLSConfig{
+			Enabled:      true,
+			Addr:         "127.0.0.1:0",
+			ClientCAFile: clientCA.CertFile,
+			Certs:        []config.TLSCert{{ServerName: "mesh.local", CertFile: serverCert.CertFile, KeyFile: serverCert.KeyFile}},
+		},
+		Routes: []config.Route{
+			mtlsRoute("ocsp", config.RevocationConfig{OCSP: true, TimeoutMS: 500}),
+			mtlsRoute("crl", config.RevocationConfig{CRLFile: crlFile}),
+			mtlsRoute("soft", config.RevocationConfig{OCSP: true, SoftFail: true, TimeoutMS: 500}),
+		},
+		Pools: map[string]config.Pool{"p1": {Endpoints: []string{addr}}},
+	}
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+	proxyServer, _, _ := startTLSProxyWithMetrics(t, cfg, metrics)
+
+	rootPool := x509CertPool(t, serverCert.Cert)
+	send := func(files testutil.CertFiles, path string) (*http.Response, []byte) {
+		t.Helper()
+		pair, err := tls.LoadX509KeyPair(files.CertFile, files.KeyFile)
+		if err != nil {
+			t.Fatalf("load client cert: %v", err)
+		}
+		client := &http.Client{
+			Timeout: 2 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{RootCAs: rootPool, ServerName: "mesh.local", Certificates: []tls.Certificate{pair}},
+			},
+		}
+		return sendProxyRequest(t, client, "https://"+proxyServer.TLSAddr, "mesh.local", http.MethodGet, path)
+	}
+
+	for i := 0; i < 2; i++ {
+		if resp, _ := send(goodCert, "/ocsp"); resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected good cert accepted, got %d", resp.StatusCode)
+		}
+	}
+	if requests := responder.Requests(); requests != 1 {
+		t.Fatalf("expected cached OCSP result, responder saw %d requests", requests)
+	}
+
+	resp, body := send(revokedCert, "/ocsp")
+	assertProxyError(t, resp, body, "mtls_revoked")
+
+	resp, body = send(crlRevokedCert, "/crl")
+	assertProxyError(t, resp, body, "mtls_revoked")
+	if resp, _ := send(goodCert, "/crl"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected cert missing from CRL accepted, got %d", resp.StatusCode)
+	}
+
+	resp, body = send(unreachableCert, "/ocsp")
+	assertProxyError(t, resp, body, "mtls_revocation_unavailable")
+	if resp, _ := send(unreachableCert, "/soft"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected soft-fail route to accept unreachable responder, got %d", resp.StatusCode)
+	}
+
+	metricsServer := httptest.NewServer(metrics.Handler())
+	defer metricsServer.Close()
+	text := fetchMetrics(t, metricsServer)
+	if value, ok := metricValue(text, "proxy_mtls_revocation_checks_total", map[string]string{"method": "ocsp", "result": "revoked"}); !ok || value != 1 {
+		t.Fatalf("expected one ocsp revoked result, got %v", value)
+	}
+	if value, ok := metricValue(text, "proxy_mtls_revocation_checks_total", map[string]string{"method": "crl", "result": "revoked"}); !ok || value != 1 {
+		t.Fatalf("expected one crl revoked result, got %v", value)
+	}
+	if value, ok := metricValue(text, "proxy_mtls_revocation_checks_total", map[string]string{"method": "ocsp", "result": "error"}); !ok || value != 2 {
+		t.Fatalf("expected two ocsp error results, got %v", value)
+	}
+}
@@ -0,0 +1,156 @@
+package integration
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+func TestTLSSessionTicketsSharedAcrossReplicas(t *testing.T) {
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, nil)
+	defer closeUpstream()
+
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+	obs.SetDefaultMetrics(metrics)
+	defer obs.SetDefaultMetrics(nil)
+	t.Setenv("TICKET_SEED_A", "replica-shared-ticket-seed")
+	t.Setenv("TICKET_SEED_B", "some-other-ticket-seed-value")
+
+	serverCert := testutil.WriteSelfSignedCert(t, "example.local")
+	newConfig := func(tickets config.SessionTicketsConfig) *config.Config {
+		return &config.Config{
+			TLS: config.TLSConfig{
+				Enabled:        true,
+				Addr:           "127.0.0.1:0",
+				Certs:          []config.TLSCert{{ServerName: "example.local", CertFile: serverCert.CertFile, KeyFile: serverCert.KeyFile}},
+				SessionTickets: tickets,
+			},
+			Routes: []config.Route{{ID: "r1", Host: "example.local", PathPrefix: "/", Pool: "p1"}},
+			Pools:  map[string]config.Pool{"p1": {Endpoints: []string{upstreamAddr}}},
+		}
+	}
+	shared := config.SessionTicketsConfig{Enabled: true, RotationIntervalMS: 60000, SeedEnv: "TICKET_SEED_A"}
+	replicaA, _, _ := startTLSProxy(t, newConfig(shared))
+	replicaB, _, _ := startTLSProxy(t, newConfig(shared))
+	outsider, _, _ := startTLSProxy(t, newConfig(config.SessionTicketsConfig{Enabled: true, RotationIntervalMS: 60000, SeedEnv: "TICKET_SEED_B"}))
+
+	client := &http.Client{
+		Timeout: 2 * time.Second,
+		Transport: &http.Transport{
+			DisableKeepAlives: true,
+			TLSClientConfig: &tls.Config{
+				RootCAs:            x509CertPool(t, serverCert.Cert),
+				ServerName:         "example.local",
+				ClientSessionCache: tls.NewLRUClientSessionCache(4),
+			},
+		},
+	}
+	resumed := func(addr string) bool {
+		t.Helper()
+		resp, _ := sendProxyRequest(t, client, "https://"+addr, "example.local", http.MethodGet, "/")
+		if resp.StatusCode != http.StatusOK || resp.TLS == nil {
+			t.Fatalf("expected 200 over tls, got %d", resp.StatusCode)
+		}
+		return resp.TLS.DidResume
+	}
+
+	if resumed(replicaA.TLSAddr) {
+		t.Fatalf("expected first handshake to be full")
+	}
+	if !resumed(replicaB.TLSAddr) {
+		t.Fatalf("expected ticket from replica A to resume on replica B")
+	}
+	if resumed(outsider.TLSAddr) {
+		t.Fatalf("expected ticket to be rejected by a proxy with a different seed")
+	}
+
+	metricsServer := httptest.NewServer(metrics.Handler())
+	defer metricsServer.Close()
+	text := fetchMetrics(t, metricsServer)
+	if value, ok := metricValue(text, "proxy_tls_handshakes_total", map[string]string{"listener": "tls", "resumed": "true"}); !ok || value != 1 {
+		t.Fatalf("expected one resumed handshake, got %v", value)
+	}
+}
+
+func TestTLSSessionTicketKeysRotate(t *testing.T) {
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, nil)
+	defer closeUpstream()
+
+	serverCert := testutil.WriteSelfSignedCert(t, "example.local")
+	cfg := &config.Config{
+		TLS: config.TLSConfig{
+			Enabled:        true,
+			Addr:           "127.0.0.1:0",
+			Certs:          []config.TLSCert{{ServerName: "example.local", CertFile: serverCert.CertFile, KeyFile: serverCert.KeyFile}},
+			SessionTickets: config.SessionTicketsConfig{Enabled: true, RotationIntervalMS: 1000, RetainedKeys: -1},
+		},
+		Routes: []config.Route{{ID: "r1", Host: "example.local", PathPrefix: "/", Pool: "p1"}},
+		Pools:  map[string]config.Pool{"p1": {Endpoints: []string{upstreamAddr}}},
+	}
+	proxyServer, _, _ := startTLSProxy(t, cfg)
+
+	client := &http.Client{
+		Timeout: 2 * time.Second,
+		Transport: &http.Transport{
+			DisableKeepAlives: true,
+			TLSClientConfig: &tls.Config{
+				RootCAs:            x509CertPool(t, serverCert.Cert),
+				ServerName:         "example.local",
+				ClientSessionCache: tls.NewLRUClientSessionCache(4),
+			},
+		},
+	}
+	send := func() *tls.ConnectionState {
+		t.Helper()
+		resp, _ := sendProxyRequest(t, client, "https://"+proxyServer.TLSAddr, "example.local", http.MethodGet, "/")
+		if resp.StatusCode != http.StatusOK || resp.TLS == nil {
+			t.Fatalf("expected 200 over tls, got %d", resp.StatusCode)
+		}
+		return resp.TLS
+	}
+
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second + 20*time.Millisecond)))
+	send()
+	if state := send(); !state.DidResume {
+		t.Fatalf("expected resumption within the rotation interval")
+	}
+	time.Sleep(2100 * time.Millisecond)
+	if state := send(); state.DidResume {
+		t.Fatalf("expected ticket to be rejected once its key rotated out")
+	}
+}
+
+func TestTLSSessionTicketValidation(t *testing.T) {
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	t.Setenv("SHORT_TICKET_SEED", "short")
+	serverCert := testutil.WriteSelfSignedCert(t, "example.local")
+	for _, tickets := range []config.SessionTicketsConfig{
+		{Enabled: true, RotationIntervalMS: 500},
+		{Enabled: true, RotationIntervalMS: -1},
+		{Enabled: true, RetainedKeys: -2},
+		{Enabled: true, SeedEnv: "MISSING_TICKET_SEED"},
+		{Enabled: true, SeedEnv: "SHORT_TICKET_SEED"},
+	} {
+		cfg := &config.Config{
+			TLS: config.TLSConfig{
+				Enabled:        true,
+				Certs:          []config.TLSCert{{ServerName: "example.local", CertFile: serverCert.CertFile, KeyFile: serverCert.KeyFile}},
+				SessionTickets: tickets,
+			},
+			Routes: []config.Route{{ID: "r1", Host: "example.local", PathPrefix: "/", Pool: "p1"}},
+			Pools:  map[string]config.Pool{"p1": {Endpoints: []string{"127.0.0.1:1"}}},
+		}
+		if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil); err == nil {
+			t.Fatalf("expected session tickets %+v to be rejected", tickets)
+		}
+	}
+}
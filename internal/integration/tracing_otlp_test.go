@@ -0,0 +1,266 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+type otlpSpan struct {
+	TraceID      string `json:"traceId"`
+	SpanID       string `json:"spanId"`
+	ParentSpanID string `json:"parentSpanId"`
+	Name         string `json:"name"`
+	Kind         int    `json:"kind"`
+	Events       []struct {
+		Name string `json:"name"`
+	} `json:"events"`
+	Status struct {
+		Code int `json:"code"`
+	} `json:"status"`
+}
+
+type otlpCollector struct {
+	mu    sync.Mutex
+	spans []otlpSpan
+	seen  []string
+}
+
+func (c *otlpCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	var payload struct {
+		ResourceSpans []struct {
+			Resource struct {
+				Attributes []struct {
+					Key   string `json:"key"`
+					Value struct {
+						StringValue string `json:"stringValue"`
+					} `json:"value"`
+				} `json:"attributes"`
+			} `json:"resource"`
+			ScopeSpans []struct {
+				Spans []otlpSpan `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seen = append(c.seen, r.URL.Path+" "+r.Header.Get("Content-Type"))
+	for _, resource := range payload.ResourceSpans {
+		for _, attr := range resource.Resource.Attributes {
+			c.seen = append(c.seen, attr.Key+"="+attr.Value.StringValue)
+		}
+		for _, scope := range resource.ScopeSpans {
+			c.spans = append(c.spans, scope.Spans...)
+		}
+	}
+}
+
+func (c *otlpCollector) snapshot() ([]otlpSpan, []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]otlpSpan(nil), c.spans...), append([]string(nil), c.seen...)
+}
+
+func TestOTLPTracingSpansAndPropagation(t *testing.T) {
+	collector := &otlpCollector{}
+	collectorServer := httptest.NewServer(collector)
+	defer collectorServer.Close()
+
+	var calls atomic.Int64
+	var upstreamParents sync.Map
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		upstreamParents.Store(n, r.Header.Get("traceparent")+"|"+r.Header.Get("tracestate"))
+		if n == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer closeUpstream()
+
+	tracer, err := obs.NewTracer(obs.TracerConfig{Endpoint: collectorServer.URL, ServiceName: "edge-test", Sampler: obs.Sampler{Ratio: 1, ParentBased: true}, FlushInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("tracer: %v", err)
+	}
+	obs.SetDefaultTracer(tracer)
+	defer obs.SetDefaultTracer(nil)
+
+	cfg, err := config.ParseJSON([]byte(fmt.Sprintf(`{
+"routes": [{"id": "traced", "host": "traced.local", "path_prefix": "/", "pool": "p", "policy": {"retry": {"enabled": true, "max_attempts": 2, "retry_on_status": [503]}}}],
+"pools": {"p": {"endpoints": ["%s"]}}
+}`, upstreamAddr)))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	proxyServer := httptest.NewServer(&proxy.Handler{Store: runtime.NewStore(snap), Registry: reg, Engine: proxy.NewEngine(reg, registry.NewRetryRegistry(0, 0), nil, nil, nil)})
+	defer proxyServer.Close()
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	const parentID = "00f067aa0ba902b7"
+	req, _ := http.NewRequest(http.MethodGet, proxyServer.URL+"/", nil)
+	req.Host = "traced.local"
+	req.Header.Set("traceparent", "00-"+traceID+"-"+parentID+"-01")
+	req.Header.Set("tracestate", "vendor=abc")
+	resp, err := proxyServer.Client().Do(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected retry to succeed, got %d", resp.StatusCode)
+	}
+	tracer.Close()
+
+	spans, seen := collector.snapshot()
+	byName := make(map[string][]otlpSpan)
+	for _, span := range spans {
+		if span.TraceID != traceID {
+			t.Fatalf("expected incoming trace id on every span, got %+v", span)
+		}
+		byName[span.Name] = append(byName[span.Name], span)
+	}
+	server := byName["proxy.request"]
+	if len(server) != 1 || server[0].ParentSpanID != parentID || server[0].Kind != obs.SpanKindServer {
+		t.Fatalf("expected server span parented to incoming span, got %+v", server)
+	}
+	if len(server[0].Events) != 1 || server[0].Events[0].Name != "retry" {
+		t.Fatalf("expected retry event on server span, got %+v", server[0].Events)
+	}
+	if match := byName["route_match"]; len(match) != 1 || match[0].ParentSpanID != server[0].SpanID {
+		t.Fatalf("expected route_match child span, got %+v", match)
+	}
+	attempts := byName["upstream_attempt"]
+	if len(attempts) != 2 {
+		t.Fatalf("expected one span per upstream attempt, got %+v", attempts)
+	}
+	for i, attempt := range attempts {
+		if attempt.ParentSpanID != server[0].SpanID || attempt.Kind != obs.SpanKindClient {
+			t.Fatalf("expected attempt span under server span, got %+v", attempt)
+		}
+		forwarded, _ := upstreamParents.Load(int64(i + 1))
+		if forwarded != "00-"+traceID+"-"+attempt.SpanID+"-01|vendor=abc" {
+			t.Fatalf("expected upstream traceparent to carry attempt span %s, got %v", attempt.SpanID, forwarded)
+		}
+	}
+	if attempts[0].Status.Code != 2 {
+		t.Fatalf("expected failed attempt to carry error status, got %+v", attempts[0])
+	}
+	joined := strings.Join(seen, ",")
+	if !strings.Contains(joined, "/v1/traces application/json") || !strings.Contains(joined, "service.name=edge-test") {
+		t.Fatalf("unexpected export requests %s", joined)
+	}
+}
+
+func TestOTLPTracingSamplerDecisions(t *testing.T) {
+	collector := &otlpCollector{}
+	collectorServer := httptest.NewServer(collector)
+	defer collectorServer.Close()
+
+	var forwarded atomic.Value
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded.Store(r.Header.Get("traceparent"))
+	}))
+	defer closeUpstream()
+
+	sampler, err := obs.ParseSampler("parentbased_traceidratio", "0")
+	if err != nil {
+		t.Fatalf("sampler: %v", err)
+	}
+	tracer, err := obs.NewTracer(obs.TracerConfig{Endpoint: collectorServer.URL + "/custom/v1/traces", Sampler: sampler, FlushInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("tracer: %v", err)
+	}
+	obs.SetDefaultTracer(tracer)
+	defer obs.SetDefaultTracer(nil)
+
+	cfg := &config.Config{
+		Routes: []config.Route{{ID: "r", Host: "sampled.local", PathPrefix: "/", Pool: "p"}},
+		Pools:  map[string]config.Pool{"p": {Endpoints: []string{upstreamAddr}}},
+	}
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	proxyServer := httptest.NewServer(&proxy.Handler{Store: runtime.NewStore(snap), Registry: reg, Engine: proxy.NewEngine(reg, nil, nil, nil, nil)})
+	defer proxyServer.Close()
+
+	send := func(traceparent string) string {
+		req, _ := http.NewRequest(http.MethodGet, proxyServer.URL+"/", nil)
+		req.Host = "sampled.local"
+		if traceparent != "" {
+			req.Header.Set("traceparent", traceparent)
+		}
+		resp, err := proxyServer.Client().Do(req)
+		if err != nil {
+			t.Fatalf("request: %v", err)
+		}
+		resp.Body.Close()
+		value, _ := forwarded.Load().(string)
+		return value
+	}
+
+	unsampled := send("")
+	if !strings.HasPrefix(unsampled, "00-") || !strings.HasSuffix(unsampled, "-00") {
+		t.Fatalf("expected fresh unsampled traceparent, got %q", unsampled)
+	}
+	sampledParent := "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+	if got := send(sampledParent); !strings.HasPrefix(got, "00-0af7651916cd43dd8448eb211c80319c-") || !strings.HasSuffix(got, "-01") || strings.Contains(got, "b7ad6b7169203331") {
+		t.Fatalf("expected parent-based sampling to follow the caller, got %q", got)
+	}
+	if got := send("00-zz-bad-01"); strings.Contains(got, "zz") || !strings.HasSuffix(got, "-00") {
+		t.Fatalf("expected malformed traceparent to start a new trace, got %q", got)
+	}
+	tracer.Close()
+
+	spans, seen := collector.snapshot()
+	if len(spans) == 0 || len(seen) == 0 || !strings.HasPrefix(seen[0], "/custom/v1/traces ") {
+		t.Fatalf("expected only the sampled request exported to the configured path, got %v", seen)
+	}
+	for _, span := range spans {
+		if span.TraceID != "0af7651916cd43dd8448eb211c80319c" {
+			t.Fatalf("unexpected exported span %+v", span)
+		}
+	}
+
+	if _, err := obs.ParseSampler("sometimes", ""); err == nil {
+		t.Fatalf("expected unknown sampler to be rejected")
+	}
+	if _, err := obs.ParseSampler("traceidratio", "2"); err == nil {
+		t.Fatalf("expected ratio above 1 to be rejected")
+	}
+	half := obs.Sampler{Ratio: 0.5}
+	low := [16]byte{8: 0x10}
+	high := [16]byte{8: 0xf0}
+	if !half.ShouldSample(low, false, false) || half.ShouldSample(high, false, false) {
+		t.Fatalf("expected ratio sampling to be decided by trace id")
+	}
+}
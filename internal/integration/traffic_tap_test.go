@@ -0,0 +1,154 @@
+package integration
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/admin"
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+func TestAdminTrafficTap(t *testing.T) {
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Set-Cookie", "session=abc")
+		_, _ = io.WriteString(w, "echo:"+string(body))
+	}))
+	defer closeUpstream()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	cfg, err := config.ParseJSON([]byte(`{
+"routes": [
+  {"id": "api", "host": "api.local", "path_prefix": "/", "pool": "p1"},
+  {"id": "web", "host": "web.local", "path_prefix": "/", "pool": "p1"}
+],
+"pools": {"p1": {"endpoints": ["` + upstreamAddr + `"]}}
+}`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	tap := obs.NewTap()
+	store := runtime.NewStore(snap)
+	proxyServer := httptest.NewServer(&proxy.Handler{
+		Store:    store,
+		Registry: reg,
+		Engine:   proxy.NewEngine(reg, nil, nil, nil, nil),
+		Tap:      tap,
+	})
+	defer proxyServer.Close()
+	client := &http.Client{Timeout: 2 * time.Second}
+
+	ca := testutil.WriteCA(t, "admin-ca")
+	serverCert := testutil.WriteServerCert(t, "admin.local", ca)
+	clientCert := testutil.WriteClientCert(t, "client", ca)
+	auth, err := admin.NewAuthenticator(admin.AuthConfig{Token: "secret", ClientCAFile: ca.CertFile})
+	if err != nil {
+		t.Fatalf("auth config: %v", err)
+	}
+	adminServer := startAdminServer(t, admin.NewHandler(admin.HandlerConfig{Store: store, Auth: auth, Tap: tap}), newAdminTLSConfig(t, serverCert.CertFile, serverCert.KeyFile, ca.CertFile))
+	defer adminServer.Close()
+	adminClient := testutil.NewAdminClient(t, testutil.AdminClientConfig{CAFile: ca.CertFile, ClientCert: &clientCert, Token: "secret", ServerName: "admin.local"})
+
+	for _, query := range []string{"?sample=0", "?sample=2", "?body_bytes=-1", "?body_bytes=1000000"} {
+		resp, err := adminClient.Do(mustAdminRequest(t, http.MethodGet, adminServer.URL+"/admin/tap"+query, nil))
+		if err != nil {
+			t.Fatalf("admin request: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected %s to be rejected, got %d", query, resp.StatusCode)
+		}
+	}
+	resp, err := adminClient.Do(mustAdminRequest(t, http.MethodGet, adminServer.URL+"/admin/tap?route=missing", nil))
+	if err != nil {
+		t.Fatalf("admin request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected unknown route to be rejected, got %d", resp.StatusCode)
+	}
+
+	stream, err := adminClient.Do(mustAdminRequest(t, http.MethodGet, adminServer.URL+"/admin/tap?route=api&sample=1&body_bytes=8", nil))
+	if err != nil {
+		t.Fatalf("open tap: %v", err)
+	}
+	defer stream.Body.Close()
+	if stream.StatusCode != http.StatusOK || stream.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected tap response %d %q", stream.StatusCode, stream.Header.Get("Content-Type"))
+	}
+	events := make(chan obs.TapEvent, 4)
+	go func() {
+		scanner := bufio.NewScanner(stream.Body)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+			if !ok {
+				continue
+			}
+			var event obs.TapEvent
+			if err := json.Unmarshal([]byte(data), &event); err == nil {
+				events <- event
+			}
+		}
+		close(events)
+	}()
+
+	sendProxyRequestWithHeaders(t, client, proxyServer.URL, "web.local", http.MethodGet, "/ignored", nil)
+	req, err := http.NewRequest(http.MethodPost, proxyServer.URL+"/orders", strings.NewReader("payload-1234"))
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	req.Host = "api.local"
+	req.Header.Set("Authorization", "Bearer hidden")
+	req.Header.Set("X-Trace", "t1")
+	proxyResp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("proxy request: %v", err)
+	}
+	proxyBody, _ := io.ReadAll(proxyResp.Body)
+	proxyResp.Body.Close()
+	if string(proxyBody) != "echo:payload-1234" {
+		t.Fatalf("unexpected proxied body %q", proxyBody)
+	}
+
+	var event obs.TapEvent
+	select {
+	case event = <-events:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected tap event")
+	}
+	if event.RouteID != "api" || event.Method != http.MethodPost || event.Path != "/orders" || event.Status != http.StatusOK || event.UpstreamAddr != upstreamAddr {
+		t.Fatalf("unexpected tap event %+v", event)
+	}
+	if event.RequestID != proxyResp.Header.Get(proxy.RequestIDHeader) {
+		t.Fatalf("expected request id %q, got %q", proxyResp.Header.Get(proxy.RequestIDHeader), event.RequestID)
+	}
+	if event.RequestHeaders["Authorization"][0] != "[redacted]" || event.RequestHeaders["X-Trace"][0] != "t1" || event.ResponseHeaders["Set-Cookie"][0] != "[redacted]" {
+		t.Fatalf("unexpected tap headers %v %v", event.RequestHeaders, event.ResponseHeaders)
+	}
+	if event.RequestBody != "payload-" || !event.RequestBodyTruncated || event.ResponseBody != "echo:pay" || !event.ResponseBodyTruncated {
+		t.Fatalf("unexpected tap bodies %+v", event)
+	}
+	select {
+	case extra, ok := <-events:
+		if ok {
+			t.Fatalf("expected only the api route to be tapped, got %+v", extra)
+		}
+	case <-time.After(100 * time.Millisecond):
+	}
+}
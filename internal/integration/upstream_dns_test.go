@@ -0,0 +1,182 @@
+package integration
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+type fakeDNS struct {
+	addr    string
+	mu      sync.Mutex
+	queries map[string]int
+	failing atomic.Bool
+}
+
+func startFakeDNS(t *testing.T, records map[string]uint32) *fakeDNS {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("dns listen: %v", err)
+	}
+	server := &fakeDNS{addr: conn.LocalAddr().String(), queries: make(map[string]int)}
+	t.Cleanup(func() { _ = conn.Close() })
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, from, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var req dnsmessage.Message
+			if err := req.Unpack(buf[:n]); err != nil || len(req.Questions) != 1 {
+				continue
+			}
+			question := req.Questions[0]
+			name := question.Name.String()
+			server.mu.Lock()
+			server.queries[name]++
+			server.mu.Unlock()
+
+			resp := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: req.ID, Response: true, RecursionAvailable: true},
+				Questions: req.Questions,
+			}
+			ttl, known := records[name]
+			switch {
+			case server.failing.Load():
+				resp.RCode = dnsmessage.RCodeServerFailure
+			case !known:
+				resp.RCode = dnsmessage.RCodeNameError
+			case question.Type == dnsmessage.TypeA:
+				resp.Answers = []dnsmessage.Resource{{
+					Header: dnsmessage.ResourceHeader{Name: question.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: ttl},
+					Body:   &dnsmessage.AResource{A: [4]byte{127, 0, 0, 1}},
+				}}
+			}
+			packed, err := resp.Pack()
+			if err != nil {
+				continue
+			}
+			_, _ = conn.WriteTo(packed, from)
+		}
+	}()
+	return server
+}
+
+func (f *fakeDNS) Queries(name string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.queries[name]
+}
+
+func TestUpstreamDNSCache(t *testing.T) {
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+	obs.SetDefaultMetrics(metrics)
+	defer obs.SetDefaultMetrics(nil)
+	metricsServer := httptest.NewServer(metrics.Handler())
+	defer metricsServer.Close()
+
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Connection", "close")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer closeUpstream()
+	_, upstreamPort, _ := net.SplitHostPort(upstreamAddr)
+
+	dns := startFakeDNS(t, map[string]uint32{"svc.test.": 1})
+	dnsCfg := `"transport": {"dns": {"enabled": true, "resolver": "` + dns.addr + `", "min_ttl_ms": 100, "negative_ttl_ms": 5000}}`
+	cfgJSON := `{
+"listen_addr": "127.0.0.1:0",
+"routes": [
+  {"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1"},
+  {"id": "r2", "host": "missing.local", "path_prefix": "/", "pool": "missing"}
+],
+"pools": {
+  "p1": {"endpoints": ["svc.test:` + upstreamPort + `"], ` + dnsCfg + `},
+  "missing": {"endpoints": ["missing.test:` + upstreamPort + `"], ` + dnsCfg + `}
+}
+}`
+	serverHandle, _, _, _ := startProxy(t, cfgJSON)
+	client := &http.Client{Timeout: 2 * time.Second}
+	proxyURL := "http://" + serverHandle.HTTPAddr
+
+	expectStatus := func(host string, status int) {
+		t.Helper()
+		resp, body := sendProxyRequest(t, client, proxyURL, host, http.MethodGet, "/")
+		if resp.StatusCode != status {
+			t.Fatalf("%s: expected %d, got %d %q", host, status, resp.StatusCode, body)
+		}
+	}
+
+	expectStatus("example.local", http.StatusOK)
+	expectStatus("example.local", http.StatusOK)
+	if got := dns.Queries("svc.test."); got != 2 {
+		t.Fatalf("expected one A and one AAAA query while cached, got %d", got)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+	expectStatus("example.local", http.StatusOK)
+	if got := dns.Queries("svc.test."); got != 4 {
+		t.Fatalf("expected record TTL expiry to trigger a new resolution, got %d queries", got)
+	}
+
+	dns.failing.Store(true)
+	time.Sleep(1100 * time.Millisecond)
+	expectStatus("example.local", http.StatusOK)
+
+	expectStatus("missing.local", http.StatusBadGateway)
+	expectStatus("missing.local", http.StatusBadGateway)
+	if got := dns.Queries("missing.test."); got != 1 {
+		t.Fatalf("expected NXDOMAIN to be negatively cached, got %d queries", got)
+	}
+
+	text := fetchMetrics(t, metricsServer)
+	for _, check := range []struct {
+		metric string
+		labels map[string]string
+	}{
+		{"proxy_dns_cache_total", map[string]string{"pool": "p1", "result": "hit"}},
+		{"proxy_dns_cache_total", map[string]string{"pool": "p1", "result": "stale"}},
+		{"proxy_dns_cache_total", map[string]string{"pool": "missing", "result": "negative_hit"}},
+		{"proxy_dns_resolution_seconds_count", map[string]string{"pool": "p1", "result": "success"}},
+		{"proxy_dns_resolution_seconds_count", map[string]string{"pool": "p1", "result": "failure"}},
+	} {
+		if value, ok := metricValue(text, check.metric, check.labels); !ok || value < 1 {
+			t.Fatalf("expected %s%v to be recorded, got %v", check.metric, check.labels, value)
+		}
+	}
+}
+
+func TestUpstreamDNSValidation(t *testing.T) {
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	for _, dnsCfg := range []string{
+		`{"enabled": true, "resolver": "dns.example:53"}`,
+		`{"enabled": true, "min_ttl_ms": 2000, "max_ttl_ms": 1000}`,
+		`{"enabled": true, "negative_ttl_ms": -1}`,
+	} {
+		cfg, err := config.ParseJSON([]byte(`{
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1"}],
+"pools": {"p1": {"endpoints": ["127.0.0.1:1"], "transport": {"dns": ` + dnsCfg + `}}}
+}`))
+		if err != nil {
+			t.Fatalf("parse config: %v", err)
+		}
+		if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil); err == nil {
+			t.Fatalf("expected dns %s to be rejected", dnsCfg)
+		}
+	}
+}
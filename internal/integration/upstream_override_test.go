@@ -0,0 +1,115 @@
+package integration
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestDebugUpstreamOverride(t *testing.T) {
+	t.Setenv("DEBUG_UPSTREAM_SECRET", "s3cret")
+
+	upstreamHandler := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Debug-Upstream") != "" || r.Header.Get("X-Debug-Upstream-Token") != "" {
+				w.Header().Set("X-Leaked", "true")
+			}
+			w.Header().Set("X-Upstream", name)
+			_, _ = io.WriteString(w, name)
+		})
+	}
+	aAddr, closeA := testutil.StartUpstream(t, upstreamHandler("A"))
+	defer closeA()
+	bAddr, closeB := testutil.StartUpstream(t, upstreamHandler("B"))
+	defer closeB()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+
+	cfg := &config.Config{
+		Routes: []config.Route{
+			{
+				ID: "trusted", Host: "trusted.local", PathPrefix: "/", Pool: "p1",
+				Policy: config.RoutePolicy{DebugUpstream: config.DebugUpstreamConfig{Enabled: true, TrustedCIDRs: []string{"127.0.0.0/8", "::1/128"}}},
+			},
+			{
+				ID: "signed", Host: "signed.local", PathPrefix: "/", Pool: "p1",
+				Policy: config.RoutePolicy{DebugUpstream: config.DebugUpstreamConfig{Enabled: true, TrustedCIDRs: []string{"10.0.0.0/8"}, TokenEnv: "DEBUG_UPSTREAM_SECRET"}},
+			},
+		},
+		Pools: map[string]config.Pool{
+			"p1": {Endpoints: []string{aAddr, bAddr}},
+		},
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	proxyServer := httptest.NewServer(&proxy.Handler{Store: runtime.NewStore(snap), Registry: reg, Engine: proxy.NewEngine(reg, nil, nil, nil, nil)})
+	defer proxyServer.Close()
+	client := &http.Client{Timeout: 2 * time.Second}
+
+	for i := 0; i < 4; i++ {
+		resp, _ := sendProxyRequestWithHeaders(t, client, proxyServer.URL, "trusted.local", http.MethodGet, "/", map[string]string{"X-Debug-Upstream": bAddr})
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("X-Upstream") != "B" {
+			t.Fatalf("expected pinned upstream B, got %d %q", resp.StatusCode, resp.Header.Get("X-Upstream"))
+		}
+		if resp.Header.Get("X-Leaked") != "" {
+			t.Fatalf("expected debug headers to be stripped before forwarding")
+		}
+	}
+
+	resp, body := sendProxyRequestWithHeaders(t, client, proxyServer.URL, "trusted.local", http.MethodGet, "/", map[string]string{"X-Debug-Upstream": "127.0.0.1:1"})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown endpoint, got %d", resp.StatusCode)
+	}
+	assertProxyError(t, resp, body, "debug_upstream_unknown")
+
+	resp, body = sendProxyRequestWithHeaders(t, client, proxyServer.URL, "signed.local", http.MethodGet, "/", map[string]string{"X-Debug-Upstream": aAddr})
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 without token, got %d", resp.StatusCode)
+	}
+	assertProxyError(t, resp, body, "debug_upstream_forbidden")
+
+	secret := []byte("s3cret")
+	cases := []struct {
+		name   string
+		token  string
+		status int
+	}{
+		{"valid", proxy.SignDebugUpstreamToken(secret, aAddr, time.Now().Add(time.Minute)), http.StatusOK},
+		{"expired", proxy.SignDebugUpstreamToken(secret, aAddr, time.Now().Add(-time.Minute)), http.StatusForbidden},
+		{"other_addr", proxy.SignDebugUpstreamToken(secret, bAddr, time.Now().Add(time.Minute)), http.StatusForbidden},
+		{"wrong_secret", proxy.SignDebugUpstreamToken([]byte("nope"), aAddr, time.Now().Add(time.Minute)), http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		for i := 0; i < 2; i++ {
+			resp, _ := sendProxyRequestWithHeaders(t, client, proxyServer.URL, "signed.local", http.MethodGet, "/", map[string]string{
+				"X-Debug-Upstream":       aAddr,
+				"X-Debug-Upstream-Token": tc.token,
+			})
+			if resp.StatusCode != tc.status {
+				t.Fatalf("%s: expected %d, got %d", tc.name, tc.status, resp.StatusCode)
+			}
+			if tc.status == http.StatusOK && resp.Header.Get("X-Upstream") != "A" {
+				t.Fatalf("%s: expected pinned upstream A, got %q", tc.name, resp.Header.Get("X-Upstream"))
+			}
+		}
+	}
+
+	cfg.Routes[1].Policy.DebugUpstream = config.DebugUpstreamConfig{Enabled: true}
+	if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg); err == nil {
+		t.Fatalf("expected error when override has no authorization")
+	}
+}
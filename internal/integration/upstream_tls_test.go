@@ -0,0 +1,104 @@
+package integration
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+func TestUpstreamTLSServerNameAndALPN(t *testing.T) {
+	ca := testutil.WriteCA(t, "upstream-ca")
+	serverCert := testutil.WriteServerCert(t, "backend.internal", ca)
+	cert, err := tls.LoadX509KeyPair(serverCert.CertFile, serverCert.KeyFile)
+	if err != nil {
+		t.Fatalf("load server cert: %v", err)
+	}
+	upstream := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s", r.TLS.ServerName, r.Proto)
+	}))
+	upstream.EnableHTTP2 = true
+	upstream.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	upstream.StartTLS()
+	defer upstream.Close()
+	upstreamAddr := strings.TrimPrefix(upstream.URL, "https://")
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	cfg, err := config.ParseJSON([]byte(`{
+"routes": [
+  {"id": "h1", "host": "h1.local", "path_prefix": "/", "pool": "h1"},
+  {"id": "h2", "host": "h2.local", "path_prefix": "/", "pool": "h2"},
+  {"id": "untrusted", "host": "untrusted.local", "path_prefix": "/", "pool": "untrusted"}
+],
+"pools": {
+  "h1": {"endpoints": ["` + upstreamAddr + `"], "transport": {"tls": {"enabled": true, "server_name": "backend.internal", "alpn": ["http/1.1"], "ca_file": "` + ca.CertFile + `"}}},
+  "h2": {"endpoints": ["` + upstreamAddr + `"], "transport": {"tls": {"enabled": true, "server_name": "backend.internal", "alpn": ["h2", "http/1.1"], "ca_file": "` + ca.CertFile + `"}}},
+  "untrusted": {"endpoints": ["` + upstreamAddr + `"], "transport": {"tls": {"enabled": true, "server_name": "backend.internal"}}}
+}
+}`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	proxyServer := httptest.NewServer(&proxy.Handler{
+		Store:    runtime.NewStore(snap),
+		Registry: reg,
+		Engine:   proxy.NewEngine(reg, nil, nil, nil, nil),
+	})
+	defer proxyServer.Close()
+	client := &http.Client{Timeout: 2 * time.Second}
+
+	for host, expected := range map[string]string{
+		"h1.local": "backend.internal HTTP/1.1",
+		"h2.local": "backend.internal HTTP/2.0",
+	} {
+		resp, body := sendProxyRequestWithHeaders(t, client, proxyServer.URL, host, http.MethodGet, "/", nil)
+		if resp.StatusCode != http.StatusOK || string(body) != expected {
+			t.Fatalf("expected %q via %s, got %d %q", expected, host, resp.StatusCode, body)
+		}
+	}
+	resp, _ := sendProxyRequestWithHeaders(t, client, proxyServer.URL, "untrusted.local", http.MethodGet, "/", nil)
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Fatalf("expected upstream verification failure without pool ca_file, got %d", resp.StatusCode)
+	}
+}
+
+func TestUpstreamTLSValidation(t *testing.T) {
+	ca := testutil.WriteCA(t, "upstream-ca")
+	serverCert := testutil.WriteServerCert(t, "backend.internal", ca)
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	for _, tlsCfg := range []string{
+		`{"server_name": "backend.internal"}`,
+		`{"enabled": true, "server_name": "backend.internal:443"}`,
+		`{"enabled": true, "alpn": ["h2", "h2"]}`,
+		`{"enabled": true, "alpn": [""]}`,
+		`{"enabled": true, "ca_file": "/nonexistent/ca.pem"}`,
+		`{"enabled": true, "ca_file": "` + serverCert.KeyFile + `"}`,
+		`{"enabled": true, "ca_file": "` + ca.CertFile + `", "insecure_skip_verify": true}`,
+	} {
+		cfg, err := config.ParseJSON([]byte(`{
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1"}],
+"pools": {"p1": {"endpoints": ["127.0.0.1:1"], "transport": {"tls": ` + tlsCfg + `}}}
+}`))
+		if err != nil {
+			t.Fatalf("parse config: %v", err)
+		}
+		if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil); err == nil {
+			t.Fatalf("expected pool tls %s to be rejected", tlsCfg)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+)
+
+func TestUpstreamWarmup(t *testing.T) {
+	var mu sync.Mutex
+	warmConns := make(map[string]bool)
+	proxiedConns := make(map[string]bool)
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		if r.URL.Path == "/warm" {
+			warmConns[r.RemoteAddr] = true
+		} else {
+			proxiedConns[r.RemoteAddr] = true
+		}
+		mu.Unlock()
+		if r.URL.Path == "/warm" {
+			time.Sleep(20 * time.Millisecond)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer closeUpstream()
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	results := make(map[string]int)
+	reg.SetWarmupObserver(func(poolKey string, result string) {
+		mu.Lock()
+		results[poolKey+"/"+result]++
+		mu.Unlock()
+	})
+	resultCount := func(key string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return results[key]
+	}
+
+	cfg, err := config.ParseJSON([]byte(`{
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1"}],
+"pools": {
+  "p1": {"endpoints": ["` + upstreamAddr + `"], "transport": {"warmup": {"connections": 3, "path": "/warm"}}},
+  "dead": {"endpoints": ["127.0.0.1:1"], "transport": {"warmup": {"connections": 2, "timeout_ms": 500}}}
+}
+}`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil); err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+
+	testutil.Eventually(t, 2*time.Second, 10*time.Millisecond, func() error {
+		if got := resultCount("p1/success"); got != 3 {
+			return fmt.Errorf("expected 3 warm-up successes, got %d", got)
+		}
+		if got := resultCount("dead/failure"); got != 2 {
+			return fmt.Errorf("expected 2 warm-up failures, got %d", got)
+		}
+		return nil
+	})
+	mu.Lock()
+	warmed := len(warmConns)
+	mu.Unlock()
+	if warmed != 3 {
+		t.Fatalf("expected 3 pre-established connections, got %d", warmed)
+	}
+
+	upstream := reg.Transport("p1")
+	for i := 0; i < 3; i++ {
+		req, _ := http.NewRequest(http.MethodGet, "http://"+upstreamAddr+"/", nil)
+		resp, err := upstream.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("round trip: %v", err)
+		}
+		_ = resp.Body.Close()
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for addr := range proxiedConns {
+		if !warmConns[addr] {
+			t.Fatalf("expected request to reuse a warmed connection, got new connection %s", addr)
+		}
+	}
+}
+
+func TestUpstreamWarmupValidation(t *testing.T) {
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	for _, transport := range []string{`{"warmup": {"connections": -1}}`, `{"warmup": {"connections": 1, "timeout_ms": -1}}`, `{"max_idle_per_host": 2, "warmup": {"connections": 3}}`} {
+		cfg, err := config.ParseJSON([]byte(`{
+"routes": [{"id": "r1", "host": "example.local", "path_prefix": "/", "pool": "p1"}],
+"pools": {"p1": {"endpoints": ["127.0.0.1:1"], "transport": ` + transport + `}}
+}`))
+		if err != nil {
+			t.Fatalf("parse config: %v", err)
+		}
+		if _, err := runtime.BuildSnapshot(cfg, reg, nil, nil, nil); err == nil {
+			t.Fatalf("expected transport %s to be rejected", transport)
+		}
+	}
+}
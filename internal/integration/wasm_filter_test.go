@@ -0,0 +1,295 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/plugin"
+	"modern_reverse_proxy/internal/proxy"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/testutil"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+func TestWASMFilter(t *testing.T) {
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-From-Wasm") == "yes" {
+			w.Header().Set("X-Upstream-Saw-Wasm", "yes")
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	upstreamAddr, closeUpstream := testutil.StartUpstream(t, upstream)
+	defer closeUpstream()
+
+	mutate := testutil.WriteWASMFilter(t, testutil.WASMFilterSpec{
+		RequestOutput:  `{"action":"continue","mutated_headers":{"X-From-Wasm":"yes"}}`,
+		ResponseOutput: `{"mutated_headers":{"X-Resp-From-Wasm":"yes"}}`,
+	})
+	deny := testutil.WriteWASMFilter(t, testutil.WASMFilterSpec{
+		RequestOutput: `{"action":"respond","response_status":403,"response_headers":{"X-Denied-By":"wasm"},"response_body":"denied"}`,
+	})
+	spin := testutil.WriteWASMFilter(t, testutil.WASMFilterSpec{Spin: true})
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+	metrics := obs.NewMetrics(obs.MetricsConfig{})
+
+	wasmRoute := func(id string, prefix string, filter config.PluginFilter) config.Route {
+		filter.Type = "wasm"
+		return config.Route{
+			ID:         id,
+			Host:       "example.local",
+			PathPrefix: prefix,
+			Pool:       "p1",
+			Policy: config.RoutePolicy{
+				Plugins: config.PluginConfig{Enabled: true, Filters: []config.PluginFilter{filter}},
+			},
+		}
+	}
+	cfg := &config.Config{
+		Routes: []config.Route{
+			wasmRoute("mutate", "/mutate", config.PluginFilter{Name: "mutate", Module: mutate}),
+			wasmRoute("deny", "/deny", config.PluginFilter{Name: "deny", Module: deny}),
+			wasmRoute("spin", "/spin", config.PluginFilter{Name: "spin", Module: spin, RequestTimeoutMS: 20, FailureMode: "fail_closed"}),
+		},
+		Pools: map[string]config.Pool{"p1": {Endpoints: []string{upstreamAddr}}},
+	}
+	snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	proxyServer := httptest.NewServer(&proxy.Handler{
+		Store:    runtime.NewStore(snap),
+		Registry: reg,
+		Engine:   proxy.NewEngine(reg, nil, metrics, nil, nil),
+		Metrics:  metrics,
+	})
+	defer proxyServer.Close()
+	client := &http.Client{Timeout: 2 * time.Second}
+
+	resp, _ := sendProxyRequest(t, client, proxyServer.URL, "example.local", http.MethodGet, "/mutate")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if resp.Header.Get("X-Upstream-Saw-Wasm") != "yes" {
+		t.Fatalf("expected upstream to see wasm request header")
+	}
+	if resp.Header.Get("X-Resp-From-Wasm") != "yes" {
+		t.Fatalf("expected wasm response header")
+	}
+
+	resp, body := sendProxyRequest(t, client, proxyServer.URL, "example.local", http.MethodGet, "/deny")
+	if resp.StatusCode != http.StatusForbidden || string(body) != "denied" {
+		t.Fatalf("expected wasm short circuit, got %d %q", resp.StatusCode, string(body))
+	}
+	if resp.Header.Get("X-Denied-By") != "wasm" {
+		t.Fatalf("expected wasm response header on short circuit")
+	}
+
+	start := time.Now()
+	resp, body = sendProxyRequest(t, client, proxyServer.URL, "example.local", http.MethodGet, "/spin")
+	assertProxyError(t, resp, body, "plugin_timeout")
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected spinning filter interrupted, took %v", elapsed)
+	}
+}
+
+func TestWASMFilterValidation(t *testing.T) {
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+
+	invalid := testutil.WriteWASMFilter(t, testutil.WASMFilterSpec{})
+	cases := []struct {
+		name   string
+		filter config.PluginFilter
+		errMsg string
+	}{
+		{name: "missing module", filter: config.PluginFilter{Name: "f", Type: "wasm"}, errMsg: "module required"},
+		{name: "unknown type", filter: config.PluginFilter{Name: "f", Type: "lua"}, errMsg: "type must be grpc or wasm"},
+		{name: "body inspection", filter: config.PluginFilter{Name: "f", Type: "wasm", Module: invalid, InspectBody: true}, errMsg: "inspect_body"},
+		{name: "unreadable module", filter: config.PluginFilter{Name: "f", Type: "wasm", Module: invalid + ".missing"}, errMsg: "module:"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.Config{
+				Routes: []config.Route{{
+					ID:         "r1",
+					Host:       "example.local",
+					PathPrefix: "/",
+					Pool:       "p1",
+					Policy: config.RoutePolicy{
+						Plugins: config.PluginConfig{Enabled: true, Filters: []config.PluginFilter{tc.filter}},
+					},
+				}},
+				Pools: map[string]config.Pool{"p1": {Endpoints: []string{"127.0.0.1:1"}}},
+			}
+			_, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+			if err == nil || !strings.Contains(err.Error(), tc.errMsg) {
+				t.Fatalf("expected error containing %q, got %v", tc.errMsg, err)
+			}
+		})
+	}
+}
+
+func TestWASMModulesReleasedWithSnapshots(t *testing.T) {
+	plugin.SetWASMModuleTTL(time.Nanosecond)
+	defer plugin.SetWASMModuleTTL(0)
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+
+	build := func(module string) (*runtime.Snapshot, *plugin.WASMModule) {
+		t.Helper()
+		cfg := &config.Config{
+			Routes: []config.Route{{
+				ID:         "r1",
+				Host:       "example.local",
+				PathPrefix: "/",
+				Pool:       "p1",
+				Policy: config.RoutePolicy{
+					Plugins: config.PluginConfig{Enabled: true, Filters: []config.PluginFilter{{Name: "f", Type: "wasm", Module: module}}},
+				},
+			}},
+			Pools: map[string]config.Pool{"p1": {Endpoints: []string{"127.0.0.1:1"}}},
+		}
+		snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+		if err != nil {
+			t.Fatalf("build snapshot: %v", err)
+		}
+		return snap, snap.Router.Routes()[0].Policy.Plugins.Filters[0].WASM
+	}
+	apply := func(module *plugin.WASMModule) error {
+		_, err := module.ApplyRequest(context.Background(), plugin.WASMRequest{RouteID: "r1"})
+		return err
+	}
+
+	first := testutil.WriteWASMFilter(t, testutil.WASMFilterSpec{RequestOutput: `{"action":"continue"}`})
+	second := testutil.WriteWASMFilter(t, testutil.WASMFilterSpec{RequestOutput: `{"action":"continue","mutated_headers":{"X-Second":"yes"}}`})
+	firstSnap, firstModule := build(first)
+	store := runtime.NewStore(firstSnap)
+	held := store.Acquire()
+	otherSnap, otherModule := build(first)
+	if otherModule != firstModule {
+		t.Fatalf("expected identical modules to share the cache entry")
+	}
+	other := runtime.NewStore(otherSnap)
+
+	secondSnap, secondModule := build(second)
+	if err := store.Swap(secondSnap); err != nil {
+		t.Fatalf("swap: %v", err)
+	}
+	if err := apply(firstModule); err != nil {
+		t.Fatalf("expected module of in-use retired snapshot to stay open, got %v", err)
+	}
+
+	store.Release(held)
+	if err := apply(firstModule); err != nil {
+		t.Fatalf("expected module still current in another store to stay open, got %v", err)
+	}
+	otherNext, _ := build(second)
+	if err := other.Swap(otherNext); err != nil {
+		t.Fatalf("swap: %v", err)
+	}
+	if err := apply(firstModule); err == nil {
+		t.Fatalf("expected module of reaped snapshot to be closed")
+	}
+	if err := apply(secondModule); err != nil {
+		t.Fatalf("expected module of current snapshot to stay open, got %v", err)
+	}
+	reloaded, err := plugin.LoadWASMModule(first, 0)
+	if err != nil {
+		t.Fatalf("reload module: %v", err)
+	}
+	if reloaded == firstModule {
+		t.Fatalf("expected closed module to be evicted from the cache")
+	}
+	if err := apply(reloaded); err != nil {
+		t.Fatalf("expected reloaded module to work, got %v", err)
+	}
+}
+
+func TestWASMModulesSurviveConcurrentSwaps(t *testing.T) {
+	plugin.SetWASMModuleTTL(time.Nanosecond)
+	defer plugin.SetWASMModuleTTL(0)
+
+	reg := registry.NewRegistry(0, 0)
+	defer reg.Close()
+	trafficReg := traffic.NewRegistry(0, 0)
+	defer trafficReg.Close()
+
+	modules := []string{
+		testutil.WriteWASMFilter(t, testutil.WASMFilterSpec{RequestOutput: `{"action":"continue","mutated_headers":{"X-Swap":"a"}}`}),
+		testutil.WriteWASMFilter(t, testutil.WASMFilterSpec{RequestOutput: `{"action":"continue","mutated_headers":{"X-Swap":"b"}}`}),
+	}
+	build := func(module string) *runtime.Snapshot {
+		t.Helper()
+		cfg := &config.Config{
+			Routes: []config.Route{{
+				ID:         "r1",
+				Host:       "example.local",
+				PathPrefix: "/",
+				Pool:       "p1",
+				Policy: config.RoutePolicy{
+					Plugins: config.PluginConfig{Enabled: true, Filters: []config.PluginFilter{{Name: "f", Type: "wasm", Module: module}}},
+				},
+			}},
+			Pools: map[string]config.Pool{"p1": {Endpoints: []string{"127.0.0.1:1"}}},
+		}
+		snap, err := runtime.BuildSnapshot(cfg, reg, nil, nil, trafficReg)
+		if err != nil {
+			t.Fatalf("build snapshot: %v", err)
+		}
+		return snap
+	}
+
+	store := runtime.NewStore(build(modules[0]))
+	stop := make(chan struct{})
+	errs := make(chan error, 4)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				snap := store.Acquire()
+				module := snap.Router.Routes()[0].Policy.Plugins.Filters[0].WASM
+				_, err := module.ApplyRequest(context.Background(), plugin.WASMRequest{RouteID: "r1"})
+				store.Release(snap)
+				if err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	for i := 1; i <= 40; i++ {
+		if err := store.Swap(build(modules[i%2])); err != nil {
+			t.Fatalf("swap: %v", err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("expected acquired snapshot modules to stay open, got %v", err)
+	}
+}
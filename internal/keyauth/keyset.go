@@ -0,0 +1,204 @@
+package keyauth
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"modern_reverse_proxy/internal/bandwidth"
+	"modern_reverse_proxy/internal/logging"
+	"modern_reverse_proxy/internal/secrets"
+)
+
+var logger = logging.For("keyauth")
+
+const fileCheckInterval = time.Second
+
+var (
+	ErrMissingCredentials = errors.New("credentials missing")
+	ErrInvalidCredentials = errors.New("credentials invalid")
+	ErrRateLimited        = errors.New("key rate limited")
+	ErrBodyTooLarge       = errors.New("body too large to verify")
+)
+
+type KeySpec struct {
+	ID             string `json:"id"`
+	Key            string `json:"key"`
+	KeyEnv         string `json:"key_env"`
+	RateLimitRPS   int64  `json:"rate_limit_rps"`
+	RateLimitBurst int64  `json:"rate_limit_burst"`
+}
+
+type Key struct {
+	ID      string
+	Secret  []byte
+	limiter *bandwidth.Bucket
+	rate    int64
+	burst   int64
+}
+
+func (k *Key) Allow() bool {
+	return k.limiter.Allow(1)
+}
+
+type keyIndex struct {
+	byID     map[string]*Key
+	byDigest map[[sha256.Size]byte]*Key
+}
+
+type KeySet struct {
+	static []*Key
+	path   string
+
+	mu        sync.Mutex
+	index     *keyIndex
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+func NewKeySet(specs []KeySpec, path string) (*KeySet, error) {
+	static, err := buildKeys(specs, nil)
+	if err != nil {
+		return nil, err
+	}
+	set := &KeySet{static: static, path: path}
+	if path != "" {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		fileKeys, err := loadKeyFile(path, nil)
+		if err != nil {
+			return nil, err
+		}
+		set.modTime = info.ModTime()
+		set.checkedAt = time.Now()
+		set.index, err = indexKeys(static, fileKeys)
+		if err != nil {
+			return nil, err
+		}
+		return set, nil
+	}
+	if len(static) == 0 {
+		return nil, errors.New("requires keys or keys_file")
+	}
+	set.index, err = indexKeys(static, nil)
+	if err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+func (s *KeySet) ByID(id string) (*Key, bool) {
+	key, ok := s.current().byID[id]
+	return key, ok
+}
+
+func (s *KeySet) ByValue(value string) (*Key, bool) {
+	key, ok := s.current().byDigest[sha256.Sum256([]byte(value))]
+	return key, ok
+}
+
+func (s *KeySet) current() *keyIndex {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.path == "" || time.Since(s.checkedAt) < fileCheckInterval {
+		return s.index
+	}
+	s.checkedAt = time.Now()
+	info, err := os.Stat(s.path)
+	if err != nil {
+		logger.Warn("keyauth_reload_failed", "path", s.path, "err", err)
+		return s.index
+	}
+	if info.ModTime().Equal(s.modTime) {
+		return s.index
+	}
+	fileKeys, err := loadKeyFile(s.path, s.index.byID)
+	if err == nil {
+		var index *keyIndex
+		index, err = indexKeys(s.static, fileKeys)
+		if err == nil {
+			s.index = index
+			s.modTime = info.ModTime()
+			logger.Info("keyauth_reloaded", "path", s.path, "keys", len(index.byID))
+			return s.index
+		}
+	}
+	logger.Warn("keyauth_reload_failed", "path", s.path, "err", err)
+	return s.index
+}
+
+func loadKeyFile(path string, previous map[string]*Key) ([]*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Keys []KeySpec `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("keys_file %s: %v", path, err)
+	}
+	return buildKeys(file.Keys, previous)
+}
+
+func buildKeys(specs []KeySpec, previous map[string]*Key) ([]*Key, error) {
+	keys := make([]*Key, 0, len(specs))
+	for _, spec := range specs {
+		id := strings.TrimSpace(spec.ID)
+		if id == "" {
+			return nil, errors.New("key id required")
+		}
+		value := spec.Key
+		if env := strings.TrimSpace(spec.KeyEnv); env != "" {
+			if value != "" {
+				return nil, fmt.Errorf("key %q must set only one of key or key_env", id)
+			}
+			value = strings.TrimSpace(secrets.LookupEnv(env))
+			if value == "" {
+				return nil, fmt.Errorf("key %q missing in %s", id, env)
+			}
+		}
+		if value == "" {
+			return nil, fmt.Errorf("key %q requires key or key_env", id)
+		}
+		if spec.RateLimitRPS < 0 || spec.RateLimitBurst < 0 {
+			return nil, fmt.Errorf("key %q rate limits must be non-negative", id)
+		}
+		key := &Key{ID: id, Secret: []byte(value), rate: spec.RateLimitRPS, burst: spec.RateLimitBurst}
+		if prior, ok := previous[id]; ok && prior.rate == key.rate && prior.burst == key.burst {
+			key.limiter = prior.limiter
+		} else {
+			key.limiter = bandwidth.NewBucket(spec.RateLimitRPS, spec.RateLimitBurst)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func indexKeys(static []*Key, fileKeys []*Key) (*keyIndex, error) {
+	index := &keyIndex{
+		byID:     make(map[string]*Key, len(static)+len(fileKeys)),
+		byDigest: make(map[[sha256.Size]byte]*Key, len(static)+len(fileKeys)),
+	}
+	for _, group := range [][]*Key{static, fileKeys} {
+		for _, key := range group {
+			if _, ok := index.byID[key.ID]; ok {
+				return nil, fmt.Errorf("duplicate key id %q", key.ID)
+			}
+			digest := sha256.Sum256(key.Secret)
+			if _, ok := index.byDigest[digest]; ok {
+				return nil, fmt.Errorf("key %q reuses another key value", key.ID)
+			}
+			index.byID[key.ID] = key
+			index.byDigest[digest] = key
+		}
+	}
+	return index, nil
+}
@@ -0,0 +1,131 @@
+package keyauth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultAPIKeyHeader    = "X-API-Key"
+	DefaultHMACHeader      = "X-Signature"
+	DefaultHMACMaxSkew     = 5 * time.Minute
+	DefaultHMACMaxBodySize = 1 << 20
+
+	KeyIDHeader = "X-Auth-Key-Id"
+)
+
+type APIKeyVerifier struct {
+	Header string
+	Keys   *KeySet
+}
+
+func (v *APIKeyVerifier) Verify(r *http.Request) (*Key, error) {
+	value := strings.TrimSpace(r.Header.Get(v.Header))
+	if value == "" {
+		return nil, ErrMissingCredentials
+	}
+	r.Header.Del(v.Header)
+	key, ok := v.Keys.ByValue(value)
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	return key, nil
+}
+
+type HMACVerifier struct {
+	Header       string
+	MaxSkew      time.Duration
+	MaxBodyBytes int64
+	Keys         *KeySet
+}
+
+func (v *HMACVerifier) Verify(r *http.Request) (*Key, error) {
+	value := strings.TrimSpace(r.Header.Get(v.Header))
+	if value == "" {
+		return nil, ErrMissingCredentials
+	}
+	params := parseSignatureHeader(value)
+	keyID, timestamp, signature := params["keyId"], params["ts"], params["sig"]
+	if keyID == "" || timestamp == "" || signature == "" {
+		return nil, fmt.Errorf("%w: malformed signature header", ErrInvalidCredentials)
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: bad timestamp", ErrInvalidCredentials)
+	}
+	skew := time.Since(time.Unix(seconds, 0))
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > v.MaxSkew {
+		return nil, fmt.Errorf("%w: timestamp outside allowed skew", ErrInvalidCredentials)
+	}
+	provided, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return nil, fmt.Errorf("%w: bad signature encoding", ErrInvalidCredentials)
+	}
+	key, ok := v.Keys.ByID(keyID)
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown key", ErrInvalidCredentials)
+	}
+
+	body, err := v.readBody(r)
+	if err != nil {
+		return nil, err
+	}
+	expected := Sign(key.Secret, r.Method, r.URL.RequestURI(), r.Host, timestamp, body)
+	if !hmac.Equal(provided, expected) {
+		return nil, fmt.Errorf("%w: signature mismatch", ErrInvalidCredentials)
+	}
+	r.Header.Del(v.Header)
+	return key, nil
+}
+
+func (v *HMACVerifier) readBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, v.MaxBodyBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > v.MaxBodyBytes {
+		r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+		return nil, ErrBodyTooLarge
+	}
+	_ = r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+func Sign(secret []byte, method string, requestURI string, host string, timestamp string, body []byte) []byte {
+	bodyDigest := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strings.ToUpper(method) + "\n" + requestURI + "\n" + strings.ToLower(host) + "\n" + timestamp + "\n" + hex.EncodeToString(bodyDigest[:])))
+	return mac.Sum(nil)
+}
+
+func SignatureHeader(keyID string, timestamp string, signature []byte) string {
+	return fmt.Sprintf("keyId=%q,ts=%q,sig=%q", keyID, timestamp, base64.StdEncoding.EncodeToString(signature))
+}
+
+func parseSignatureHeader(value string) map[string]string {
+	params := make(map[string]string, 3)
+	for _, part := range strings.Split(value, ",") {
+		name, raw, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		params[strings.TrimSpace(name)] = strings.Trim(strings.TrimSpace(raw), `"`)
+	}
+	return params
+}
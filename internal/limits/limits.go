@@ -14,6 +14,8 @@ const (
 	defaultMaxBodyBytes      = 10 * 1024 * 1024
 	defaultReadHeaderTimeout = 2 * time.Second
 	defaultIdleTimeout       = 30 * time.Second
+	defaultQueueTimeout      = time.Second
+	defaultDecompressRatio   = 100
 )
 
 type Limits struct {
@@ -26,6 +28,23 @@ type Limits struct {
 	WriteTimeout          time.Duration
 	IdleTimeout           time.Duration
 	ResponseStreamTimeout time.Duration
+	MaxConcurrentRequests int
+	MaxQueuedRequests     int
+	QueueTimeout          time.Duration
+	MaxConnections        int
+	MaxRequestsPerConn    int
+	KeepAliveTimeout      time.Duration
+	MaxDecompressedBytes  int64
+	MaxDecompressionRatio int
+	Socket                Socket
+}
+
+type Socket struct {
+	ReusePort   bool
+	KeepAlive   time.Duration
+	Backlog     int
+	DeferAccept time.Duration
+	NoDelay     bool
 }
 
 func Default() Limits {
@@ -39,6 +58,13 @@ func Default() Limits {
 		WriteTimeout:          0,
 		IdleTimeout:           defaultIdleTimeout,
 		ResponseStreamTimeout: 0,
+		MaxConcurrentRequests: 0,
+		MaxQueuedRequests:     0,
+		QueueTimeout:          defaultQueueTimeout,
+		MaxConnections:        0,
+		MaxDecompressedBytes:  0,
+		MaxDecompressionRatio: defaultDecompressRatio,
+		Socket:                Socket{NoDelay: true},
 	}
 }
 
@@ -67,6 +93,35 @@ func FromConfig(cfg config.LimitsConfig) (Limits, error) {
 		limits.IdleTimeout = time.Duration(cfg.IdleTimeoutMS) * time.Millisecond
 	}
 	limits.ResponseStreamTimeout = durationOrZero(cfg.ResponseStreamTimeoutMS)
+	limits.MaxConcurrentRequests = cfg.MaxConcurrentRequests
+	limits.MaxQueuedRequests = cfg.MaxQueuedRequests
+	if cfg.QueueTimeoutMS > 0 {
+		limits.QueueTimeout = time.Duration(cfg.QueueTimeoutMS) * time.Millisecond
+	} else if cfg.QueueTimeoutMS < 0 {
+		return Limits{}, fmt.Errorf("queue_timeout_ms must be positive")
+	}
+	limits.MaxConnections = cfg.MaxConnections
+	limits.MaxRequestsPerConn = cfg.MaxRequestsPerConn
+	switch {
+	case cfg.KeepAliveTimeoutMS > 0:
+		limits.KeepAliveTimeout = time.Duration(cfg.KeepAliveTimeoutMS) * time.Millisecond
+	case cfg.KeepAliveTimeoutMS == -1:
+		limits.KeepAliveTimeout = -1
+	case cfg.KeepAliveTimeoutMS < 0:
+		return Limits{}, fmt.Errorf("keepalive_timeout_ms must be positive or -1")
+	}
+	limits.MaxDecompressedBytes = cfg.MaxDecompressedBytes
+	if cfg.MaxDecompressionRatio > 0 {
+		limits.MaxDecompressionRatio = cfg.MaxDecompressionRatio
+	} else if cfg.MaxDecompressionRatio < 0 {
+		return Limits{}, fmt.Errorf("max_decompression_ratio must be positive")
+	}
+
+	socket, err := socketFromConfig(cfg.Socket)
+	if err != nil {
+		return Limits{}, err
+	}
+	limits.Socket = socket
 
 	if limits.MaxHeaderBytes <= 0 {
 		return Limits{}, fmt.Errorf("max_header_bytes must be positive")
@@ -83,12 +138,67 @@ func FromConfig(cfg config.LimitsConfig) (Limits, error) {
 	if limits.ReadHeaderTimeout <= 0 {
 		return Limits{}, fmt.Errorf("read_header_timeout_ms must be positive")
 	}
+	if limits.MaxConcurrentRequests < 0 {
+		return Limits{}, fmt.Errorf("max_concurrent_requests must be non-negative")
+	}
+	if limits.MaxQueuedRequests < 0 {
+		return Limits{}, fmt.Errorf("max_queued_requests must be non-negative")
+	}
+	if limits.MaxQueuedRequests > 0 && limits.MaxConcurrentRequests == 0 {
+		return Limits{}, fmt.Errorf("max_queued_requests requires max_concurrent_requests")
+	}
+	if limits.MaxConnections < 0 {
+		return Limits{}, fmt.Errorf("max_connections must be non-negative")
+	}
+	if limits.MaxRequestsPerConn < 0 {
+		return Limits{}, fmt.Errorf("max_requests_per_connection must be non-negative")
+	}
+	if limits.MaxDecompressedBytes < 0 {
+		return Limits{}, fmt.Errorf("max_decompressed_body_bytes must be non-negative")
+	}
 	return limits, nil
 }
 
+func (l Limits) DecompressedBodyLimit(routeMaxBodyBytes int64) int64 {
+	if l.MaxDecompressedBytes <= 0 {
+		if routeMaxBodyBytes > 0 {
+			return routeMaxBodyBytes
+		}
+		return l.MaxBodyBytes
+	}
+	if routeMaxBodyBytes > 0 && routeMaxBodyBytes < l.MaxDecompressedBytes {
+		return routeMaxBodyBytes
+	}
+	return l.MaxDecompressedBytes
+}
+
 func durationOrZero(milliseconds int) time.Duration {
 	if milliseconds <= 0 {
 		return 0
 	}
 	return time.Duration(milliseconds) * time.Millisecond
 }
+
+func socketFromConfig(cfg config.SocketConfig) (Socket, error) {
+	socket := Socket{ReusePort: cfg.ReusePort, NoDelay: true}
+	switch {
+	case cfg.KeepAliveMS > 0:
+		socket.KeepAlive = time.Duration(cfg.KeepAliveMS) * time.Millisecond
+	case cfg.KeepAliveMS == -1:
+		socket.KeepAlive = -1
+	case cfg.KeepAliveMS < 0:
+		return Socket{}, fmt.Errorf("socket keepalive_ms must be positive or -1")
+	}
+	if cfg.Backlog < 0 {
+		return Socket{}, fmt.Errorf("socket backlog must be non-negative")
+	}
+	socket.Backlog = cfg.Backlog
+	if cfg.DeferAcceptMS < 0 {
+		return Socket{}, fmt.Errorf("socket defer_accept_ms must be non-negative")
+	}
+	socket.DeferAccept = durationOrZero(cfg.DeferAcceptMS)
+	if cfg.NoDelay != nil {
+		socket.NoDelay = *cfg.NoDelay
+	}
+	return socket, nil
+}
@@ -0,0 +1,148 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultComponent = "default"
+
+type levelRegistry struct {
+	mu         sync.RWMutex
+	base       slog.Level
+	components map[string]slog.Level
+}
+
+var (
+	levels          = &levelRegistry{base: slog.LevelInfo, components: make(map[string]slog.Level)}
+	output          atomic.Pointer[slog.Handler]
+	fallbackHandler = slog.Default().Handler()
+)
+
+type Levels struct {
+	Level      string            `json:"level"`
+	Components map[string]string `json:"components"`
+}
+
+func Configure(w io.Writer, jsonEnabled bool) {
+	options := &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if len(groups) == 0 && attr.Key == slog.TimeKey {
+				attr.Key = "ts"
+				attr.Value = slog.StringValue(attr.Value.Time().UTC().Format(time.RFC3339Nano))
+			}
+			return attr
+		},
+	}
+	var handler slog.Handler
+	if jsonEnabled {
+		handler = slog.NewJSONHandler(w, options)
+	} else {
+		handler = slog.NewTextHandler(w, options)
+	}
+	output.Store(&handler)
+	slog.SetDefault(slog.New(&componentHandler{component: defaultComponent}))
+}
+
+func For(component string) *slog.Logger {
+	return slog.New(&componentHandler{component: component})
+}
+
+func Verbose(component string) *slog.Logger {
+	return slog.New(&componentHandler{component: component, force: true})
+}
+
+func ParseLevel(value string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(value))); err != nil {
+		return 0, fmt.Errorf("invalid log level %q", value)
+	}
+	return level, nil
+}
+
+func SetLevel(level slog.Level) {
+	levels.mu.Lock()
+	levels.base = level
+	levels.mu.Unlock()
+}
+
+func SetComponentLevel(component string, level slog.Level) {
+	levels.mu.Lock()
+	levels.components[component] = level
+	levels.mu.Unlock()
+}
+
+func ResetComponentLevel(component string) {
+	levels.mu.Lock()
+	delete(levels.components, component)
+	levels.mu.Unlock()
+}
+
+func CurrentLevels() Levels {
+	levels.mu.RLock()
+	defer levels.mu.RUnlock()
+	current := Levels{Level: levelName(levels.base), Components: make(map[string]string, len(levels.components))}
+	for component, level := range levels.components {
+		current.Components[component] = levelName(level)
+	}
+	return current
+}
+
+func levelName(level slog.Level) string {
+	return strings.ToLower(level.String())
+}
+
+func (r *levelRegistry) enabled(component string, level slog.Level) bool {
+	r.mu.RLock()
+	minimum, ok := r.components[component]
+	if !ok {
+		minimum = r.base
+	}
+	r.mu.RUnlock()
+	return level >= minimum
+}
+
+type componentHandler struct {
+	component string
+	force     bool
+	wrap      []func(slog.Handler) slog.Handler
+}
+
+func (h *componentHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.force || levels.enabled(h.component, level)
+}
+
+func (h *componentHandler) Handle(ctx context.Context, record slog.Record) error {
+	inner := fallbackHandler
+	if handler := output.Load(); handler != nil {
+		inner = *handler
+	}
+	if h.component != defaultComponent {
+		inner = inner.WithAttrs([]slog.Attr{slog.String("component", h.component)})
+	}
+	for _, wrap := range h.wrap {
+		inner = wrap(inner)
+	}
+	return inner.Handle(ctx, record)
+}
+
+func (h *componentHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(inner slog.Handler) slog.Handler { return inner.WithAttrs(attrs) })
+}
+
+func (h *componentHandler) WithGroup(name string) slog.Handler {
+	return h.with(func(inner slog.Handler) slog.Handler { return inner.WithGroup(name) })
+}
+
+func (h *componentHandler) with(wrap func(slog.Handler) slog.Handler) slog.Handler {
+	next := &componentHandler{component: h.component, force: h.force}
+	next.wrap = append(append(next.wrap, h.wrap...), wrap)
+	return next
+}
@@ -0,0 +1,117 @@
+package obs
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type sinkWriter interface {
+	WriteBatch(lines [][]byte) error
+	Close() error
+}
+
+type batchQueue struct {
+	writer    sinkWriter
+	queue     chan []byte
+	batchSize int
+	interval  time.Duration
+	onDrop    func(reason string, count int, err error)
+
+	startOnce sync.Once
+	closeOnce sync.Once
+	closed    atomic.Bool
+	stop      chan struct{}
+	done      chan struct{}
+	dropped   atomic.Uint64
+}
+
+func newBatchQueue(writer sinkWriter, bufferSize int, batchSize int, interval time.Duration, onDrop func(reason string, count int, err error)) *batchQueue {
+	return &batchQueue{
+		writer:    writer,
+		queue:     make(chan []byte, bufferSize),
+		batchSize: batchSize,
+		interval:  interval,
+		onDrop:    onDrop,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+func (q *batchQueue) Dropped() uint64 {
+	return q.dropped.Load()
+}
+
+func (q *batchQueue) Push(item []byte) {
+	if q.closed.Load() {
+		q.drop("closed", 1, nil)
+		return
+	}
+	q.startOnce.Do(func() {
+		go q.run()
+	})
+	select {
+	case q.queue <- item:
+	default:
+		q.drop("queue_full", 1, nil)
+	}
+}
+
+func (q *batchQueue) Close() {
+	q.closeOnce.Do(func() {
+		q.closed.Store(true)
+		q.startOnce.Do(func() {
+			go q.run()
+		})
+		close(q.stop)
+		<-q.done
+	})
+}
+
+func (q *batchQueue) run() {
+	defer close(q.done)
+	ticker := time.NewTicker(q.interval)
+	defer ticker.Stop()
+	batch := make([][]byte, 0, q.batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := q.writer.WriteBatch(batch); err != nil {
+			q.drop("write_error", len(batch), err)
+		}
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case item := <-q.queue:
+			batch = append(batch, item)
+			if len(batch) >= q.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-q.stop:
+			for {
+				select {
+				case item := <-q.queue:
+					batch = append(batch, item)
+					if len(batch) >= q.batchSize {
+						flush()
+					}
+				default:
+					flush()
+					_ = q.writer.Close()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (q *batchQueue) drop(reason string, count int, err error) {
+	q.dropped.Add(uint64(count))
+	if q.onDrop != nil {
+		q.onDrop(reason, count, err)
+	}
+}
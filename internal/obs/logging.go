@@ -3,61 +3,145 @@ package obs
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"reflect"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
 type AccessLogEntry struct {
-	Timestamp            string   `json:"ts"`
-	RequestID            string   `json:"request_id"`
-	Method               string   `json:"method"`
-	Host                 string   `json:"host"`
-	Path                 string   `json:"path"`
-	RouteID              string   `json:"route_id"`
-	PoolKey              string   `json:"pool_key"`
-	UpstreamAddr         string   `json:"upstream_addr"`
-	PluginFilters        []string `json:"plugin_filters,omitempty"`
-	PluginBypassed       bool     `json:"plugin_bypassed"`
-	PluginBypassReason   string   `json:"plugin_bypass_reason,omitempty"`
-	PluginFailureMode    string   `json:"plugin_failure_mode,omitempty"`
-	PluginShortCircuit   bool     `json:"plugin_shortcircuit"`
-	PluginMutationDenied bool     `json:"plugin_mutation_denied"`
-	Status               int      `json:"status"`
-	DurationMS           int64    `json:"duration_ms"`
-	BytesIn              int64    `json:"bytes_in"`
-	BytesOut             int64    `json:"bytes_out"`
-	ErrorCategory        string   `json:"error_category"`
-	RetryCount           int      `json:"retry_count"`
-	RetryLastReason      string   `json:"retry_last_reason"`
-	RetryBudgetExhausted bool     `json:"retry_budget_exhausted"`
-	CacheStatus          string   `json:"cache_status"`
-	SnapshotVersion      string   `json:"snapshot_version"`
-	SnapshotSource       string   `json:"snapshot_source"`
-	TrafficVariant       string   `json:"traffic_variant"`
-	CohortMode           string   `json:"cohort_mode"`
-	CohortKeyPresent     bool     `json:"cohort_key_present"`
-	OverloadRejected     bool     `json:"overload_rejected"`
-	AutoDrainActive      bool     `json:"autodrain_active"`
-	UserAgent            string   `json:"user_agent,omitempty"`
-	RemoteAddr           string   `json:"remote_addr,omitempty"`
-	BreakerState         string   `json:"breaker_state,omitempty"`
-	BreakerDenied        bool     `json:"breaker_denied"`
-	OutlierIgnored       bool     `json:"outlier_ignored"`
-	EndpointEjected      bool     `json:"endpoint_ejected"`
-	TLS                  bool     `json:"tls"`
-	MTLSRouteRequired    bool     `json:"mtls_route_required"`
-	MTLSVerified         bool     `json:"mtls_verified"`
+	Timestamp            string            `json:"ts"`
+	RequestID            string            `json:"request_id"`
+	OriginalRequestID    string            `json:"original_request_id,omitempty"`
+	Method               string            `json:"method"`
+	Host                 string            `json:"host"`
+	Path                 string            `json:"path"`
+	RouteID              string            `json:"route_id"`
+	RouteLabels          map[string]string `json:"route_labels,omitempty"`
+	PoolKey              string            `json:"pool_key"`
+	UpstreamAddr         string            `json:"upstream_addr"`
+	PluginFilters        []string          `json:"plugin_filters,omitempty"`
+	PluginBypassed       bool              `json:"plugin_bypassed"`
+	PluginBypassReason   string            `json:"plugin_bypass_reason,omitempty"`
+	PluginFailureMode    string            `json:"plugin_failure_mode,omitempty"`
+	PluginShortCircuit   bool              `json:"plugin_shortcircuit"`
+	PluginMutationDenied bool              `json:"plugin_mutation_denied"`
+	Status               int               `json:"status"`
+	DurationMS           int64             `json:"duration_ms"`
+	BytesIn              int64             `json:"bytes_in"`
+	BytesOut             int64             `json:"bytes_out"`
+	ErrorCategory        string            `json:"error_category"`
+	RetryCount           int               `json:"retry_count"`
+	RetryLastReason      string            `json:"retry_last_reason"`
+	RetrySkipReason      string            `json:"retry_skip_reason,omitempty"`
+	IdempotencyStatus    string            `json:"idempotency_status,omitempty"`
+	RequestRule          string            `json:"request_rule,omitempty"`
+	RetryBudgetExhausted bool              `json:"retry_budget_exhausted"`
+	CacheStatus          string            `json:"cache_status"`
+	SnapshotVersion      string            `json:"snapshot_version"`
+	SnapshotSource       string            `json:"snapshot_source"`
+	SnapshotLabel        string            `json:"snapshot_label,omitempty"`
+	SnapshotGitSHA       string            `json:"snapshot_git_sha,omitempty"`
+	SnapshotAuthor       string            `json:"snapshot_author,omitempty"`
+	TrafficVariant       string            `json:"traffic_variant"`
+	CohortMode           string            `json:"cohort_mode"`
+	CohortKeyPresent     bool              `json:"cohort_key_present"`
+	OverloadRejected     bool              `json:"overload_rejected"`
+	AutoDrainActive      bool              `json:"autodrain_active"`
+	UserAgent            string            `json:"user_agent,omitempty"`
+	RemoteAddr           string            `json:"remote_addr,omitempty"`
+	BreakerState         string            `json:"breaker_state,omitempty"`
+	BreakerDenied        bool              `json:"breaker_denied"`
+	OutlierIgnored       bool              `json:"outlier_ignored"`
+	EndpointEjected      bool              `json:"endpoint_ejected"`
+	TLS                  bool              `json:"tls"`
+	TLSJA3               string            `json:"tls_ja3,omitempty"`
+	TLSJA4               string            `json:"tls_ja4,omitempty"`
+	UpstreamOverride     bool              `json:"upstream_override,omitempty"`
+	ScriptVars           map[string]string `json:"script_vars,omitempty"`
+	AuthSubject          string            `json:"auth_subject,omitempty"`
+	ClientIP             string            `json:"client_ip,omitempty"`
+	MTLSRouteRequired    bool              `json:"mtls_route_required"`
+	MTLSVerified         bool              `json:"mtls_verified"`
+	MTLSIdentity         string            `json:"mtls_identity,omitempty"`
+	RouteMatchUS         int64             `json:"route_match_us,omitempty"`
+	PluginRequestUS      int64             `json:"plugin_request_us,omitempty"`
+	UpstreamTTFBUS       int64             `json:"upstream_ttfb_us,omitempty"`
+	UpstreamBodyUS       int64             `json:"upstream_body_us,omitempty"`
+	ResponseWriteUS      int64             `json:"response_write_us,omitempty"`
 }
 
 func LogAccess(ctx RequestContext) {
-	entry := AccessLogEntry{
+	LogAccessTo(nil, ctx, nil, nil)
+}
+
+func LogAccessTo(w io.Writer, ctx RequestContext, fields []string, rename map[string]string) {
+	if w == nil {
+		w = accessLogOutput()
+	}
+	entry := newAccessLogEntry(ctx)
+	data, err := json.Marshal(entry)
+	if err == nil && (len(fields) > 0 || len(rename) > 0) {
+		data, err = projectAccessLog(data, fields, rename)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "log_marshal_error request_id=%s error=%v\n", entry.RequestID, err)
+		return
+	}
+	if redact, ok := accessLogRedactor.Load().(func(string) string); ok && redact != nil {
+		data = []byte(redact(string(data)))
+	}
+	_, _ = w.Write(append(data, '\n'))
+}
+
+func AccessLogFieldNames() []string {
+	t := reflect.TypeOf(AccessLogEntry{})
+	names := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		names = append(names, name)
+	}
+	return names
+}
+
+func projectAccessLog(data []byte, fields []string, rename map[string]string) ([]byte, error) {
+	var full map[string]json.RawMessage
+	if err := json.Unmarshal(data, &full); err != nil {
+		return nil, err
+	}
+	projected := full
+	if len(fields) > 0 {
+		projected = make(map[string]json.RawMessage, len(fields))
+		for _, field := range fields {
+			if value, ok := full[field]; ok {
+				projected[field] = value
+			}
+		}
+	}
+	if len(rename) == 0 {
+		return json.Marshal(projected)
+	}
+	renamed := make(map[string]json.RawMessage, len(projected))
+	for field, value := range projected {
+		if to, ok := rename[field]; ok {
+			field = to
+		}
+		renamed[field] = value
+	}
+	return json.Marshal(renamed)
+}
+
+func newAccessLogEntry(ctx RequestContext) AccessLogEntry {
+	return AccessLogEntry{
 		Timestamp:            time.Now().UTC().Format(time.RFC3339Nano),
 		RequestID:            defaultString(ctx.RequestID, "none"),
 		Method:               ctx.Method,
 		Host:                 ctx.Host,
 		Path:                 ctx.Path,
 		RouteID:              defaultString(ctx.RouteID, "none"),
+		RouteLabels:          ctx.RouteLabels,
 		PoolKey:              defaultString(ctx.PoolKey, "none"),
 		UpstreamAddr:         defaultString(ctx.UpstreamAddr, "none"),
 		PluginFilters:        ctx.PluginFilters,
@@ -73,10 +157,17 @@ func LogAccess(ctx RequestContext) {
 		ErrorCategory:        defaultString(ctx.ErrorCategory, "none"),
 		RetryCount:           ctx.RetryCount,
 		RetryLastReason:      defaultString(ctx.RetryLastReason, "none"),
+		RetrySkipReason:      ctx.RetrySkipReason,
+		IdempotencyStatus:    ctx.IdempotencyStatus,
+		RequestRule:          ctx.RequestRule,
+		OriginalRequestID:    ctx.OriginalRequestID,
 		RetryBudgetExhausted: ctx.RetryBudgetExhausted,
 		CacheStatus:          defaultString(ctx.CacheStatus, "bypass"),
 		SnapshotVersion:      defaultString(ctx.SnapshotVersion, "none"),
 		SnapshotSource:       defaultString(ctx.SnapshotSource, "none"),
+		SnapshotLabel:        ctx.SnapshotLabel,
+		SnapshotGitSHA:       ctx.SnapshotGitSHA,
+		SnapshotAuthor:       ctx.SnapshotAuthor,
 		TrafficVariant:       defaultString(ctx.TrafficVariant, "stable"),
 		CohortMode:           defaultString(ctx.CohortMode, "random"),
 		CohortKeyPresent:     ctx.CohortKeyPresent,
@@ -89,16 +180,45 @@ func LogAccess(ctx RequestContext) {
 		OutlierIgnored:       ctx.OutlierIgnored,
 		EndpointEjected:      ctx.EndpointEjected,
 		TLS:                  ctx.TLS,
+		TLSJA3:               ctx.TLSJA3,
+		TLSJA4:               ctx.TLSJA4,
+		UpstreamOverride:     ctx.UpstreamOverride,
+		ScriptVars:           ctx.ScriptVars,
+		AuthSubject:          ctx.AuthSubject,
+		ClientIP:             ctx.ClientIP,
 		MTLSRouteRequired:    ctx.MTLSRouteRequired,
 		MTLSVerified:         ctx.MTLSVerified,
+		MTLSIdentity:         ctx.MTLSIdentity,
+		RouteMatchUS:         ctx.Phases.RouteMatch.Microseconds(),
+		PluginRequestUS:      ctx.Phases.PluginRequest.Microseconds(),
+		UpstreamTTFBUS:       ctx.Phases.UpstreamTTFB.Microseconds(),
+		UpstreamBodyUS:       ctx.Phases.UpstreamBody.Microseconds(),
+		ResponseWriteUS:      ctx.Phases.ResponseWrite.Microseconds(),
 	}
+}
 
-	data, err := json.Marshal(entry)
-	if err != nil {
-		_, _ = fmt.Fprintf(os.Stdout, "log_marshal_error request_id=%s error=%v\n", entry.RequestID, err)
+var (
+	accessLogWriter   atomic.Value
+	accessLogRedactor atomic.Value
+)
+
+func SetAccessLogRedactor(redact func(string) string) {
+	accessLogRedactor.Store(redact)
+}
+
+func SetAccessLogOutput(w io.Writer) {
+	if w == nil {
+		accessLogWriter.Store((*io.Writer)(nil))
 		return
 	}
-	_, _ = os.Stdout.Write(append(data, '\n'))
+	accessLogWriter.Store(&w)
+}
+
+func accessLogOutput() io.Writer {
+	if w, ok := accessLogWriter.Load().(*io.Writer); ok && w != nil {
+		return *w
+	}
+	return os.Stdout
 }
 
 func defaultString(value string, fallback string) string {
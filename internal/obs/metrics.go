@@ -3,6 +3,7 @@ package obs
 import (
 	"fmt"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
@@ -10,10 +11,19 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+const (
+	defaultNativeHistogramFactor     = 1.1
+	defaultNativeHistogramMaxBuckets = 160
+)
+
 type MetricsConfig struct {
-	RouteTopK         int
-	PoolTopK          int
-	RecomputeInterval time.Duration
+	RouteTopK                 int
+	PoolTopK                  int
+	RecomputeInterval         time.Duration
+	DurationBuckets           []float64
+	NativeHistograms          bool
+	NativeHistogramFactor     float64
+	NativeHistogramMaxBuckets uint32
 }
 
 type Metrics struct {
@@ -48,10 +58,66 @@ type Metrics struct {
 	bundleVerify           *prometheus.CounterVec
 	rolloutStage           *prometheus.CounterVec
 	rollbackTotal          *prometheus.CounterVec
+	pullTotal              *prometheus.CounterVec
+	pullFailures           prometheus.Gauge
+	pullLastSuccess        prometheus.Gauge
+	listenerInflight       *prometheus.GaugeVec
+	listenerQueued         *prometheus.GaugeVec
+	listenerConnections    *prometheus.GaugeVec
+	listenerAccepted       *prometheus.CounterVec
+	listenerActive         *prometheus.GaugeVec
+	tlsHandshakeDuration   *prometheus.HistogramVec
+	tlsHandshakeErrors     *prometheus.CounterVec
+	tlsHandshakes          *prometheus.CounterVec
+	tlsCertSelections      *prometheus.CounterVec
+	tlsSNIMisses           *prometheus.CounterVec
+	tlsCertExpiry          *prometheus.GaugeVec
+	tlsCertExpiring        prometheus.Gauge
+	listenerShed           *prometheus.CounterVec
+	compressionResponses   *prometheus.CounterVec
+	compressionSaved       *prometheus.CounterVec
+	decompressionReject    *prometheus.CounterVec
+	multipartReject        *prometheus.CounterVec
+	fingerprintReject      *prometheus.CounterVec
+	drainCutoff            *prometheus.CounterVec
+	upstreamWarmup         *prometheus.CounterVec
+	dnsResolution          *prometheus.HistogramVec
+	dnsCache               *prometheus.CounterVec
+	zoneRequests           *prometheus.CounterVec
+	zoneErrors             *prometheus.CounterVec
+	egressThrottled        *prometheus.CounterVec
+	egressThrottleWait     *prometheus.CounterVec
+	staleSnapshot          *prometheus.CounterVec
+	scriptResults          *prometheus.CounterVec
+	authResults            *prometheus.CounterVec
+	authKeyRequests        *prometheus.CounterVec
+	accessDenied           *prometheus.CounterVec
+	requestRuleMatches     *prometheus.CounterVec
+	certReloads            *prometheus.CounterVec
+	mtlsIdentity           *prometheus.CounterVec
+	revocationChecks       *prometheus.CounterVec
+	ocspStaples            *prometheus.CounterVec
+	streamConnections      *prometheus.CounterVec
+	streamActive           *prometheus.GaugeVec
+	sseActive              *prometheus.GaugeVec
+	sseHeartbeats          *prometheus.CounterVec
+	streamBytes            *prometheus.CounterVec
+	mirrorRequests         *prometheus.CounterVec
+	mirrorInflight         *prometheus.GaugeVec
+	hedgeRequests          *prometheus.CounterVec
+	concurrencyLimit       *prometheus.GaugeVec
+	concurrencyDrops       *prometheus.CounterVec
+	breakerResets          *prometheus.CounterVec
+	rampWeight             *prometheus.GaugeVec
+	rampTransitions        *prometheus.CounterVec
+	drainedCohorts         *prometheus.GaugeVec
+	routeLabelInfo         *prometheus.GaugeVec
+	accessLogDropped       *prometheus.CounterVec
+	traceSpansDropped      *prometheus.CounterVec
 	requestWindow          *rollingCounter
 	mu                     sync.Mutex
-	lastVersion            string
-	lastSource             string
+	lastSnapshotInfo       []string
+	routeLabels            map[string]map[string]string
 }
 
 var (
@@ -186,17 +252,9 @@ func NewMetrics(cfg MetricsConfig) *Metrics {
 		Help: "Total plugin fail-closed responses",
 	}, []string{"filter"})
 
-	requestDuration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
-		Name:    "proxy_request_duration_seconds",
-		Help:    "Proxy request duration",
-		Buckets: prometheus.DefBuckets,
-	}, []string{"route"})
+	requestDuration := prometheus.NewHistogramVec(durationHistogramOpts(cfg, "proxy_request_duration_seconds", "Proxy request duration"), []string{"route"})
 
-	upstreamRoundTrip := prometheus.NewHistogramVec(prometheus.HistogramOpts{
-		Name:    "proxy_upstream_roundtrip_seconds",
-		Help:    "Upstream roundtrip duration",
-		Buckets: prometheus.DefBuckets,
-	}, []string{"pool"})
+	upstreamRoundTrip := prometheus.NewHistogramVec(durationHistogramOpts(cfg, "proxy_upstream_roundtrip_seconds", "Upstream roundtrip duration"), []string{"pool"})
 
 	bundleVerify := prometheus.NewCounterVec(prometheus.CounterOpts{
 		Name: "proxy_bundle_verify_total",
@@ -213,6 +271,283 @@ func NewMetrics(cfg MetricsConfig) *Metrics {
 		Help: "Total rollback attempts",
 	}, []string{"result"})
 
+	pullTotal := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_pull_total",
+		Help: "Total pull attempts",
+	}, []string{"result"})
+
+	pullFailures := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "proxy_pull_consecutive_failures",
+		Help: "Consecutive failed pull attempts",
+	})
+
+	pullLastSuccess := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "proxy_pull_last_success_timestamp_seconds",
+		Help: "Unix time of the last successful pull",
+	})
+
+	listenerInflight := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "proxy_listener_inflight_requests",
+		Help: "In-flight requests per listener",
+	}, []string{"listener"})
+
+	listenerQueued := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "proxy_listener_queued_requests",
+		Help: "Requests waiting for a concurrency slot per listener",
+	}, []string{"listener"})
+
+	listenerConnections := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "proxy_listener_open_connections",
+		Help: "Open connections per listener",
+	}, []string{"listener"})
+
+	listenerAccepted := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_listener_connections_accepted_total",
+		Help: "Total connections accepted per listener",
+	}, []string{"listener"})
+
+	listenerActive := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "proxy_listener_active_connections",
+		Help: "Connections currently serving a request per listener",
+	}, []string{"listener"})
+
+	tlsHandshakeDuration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "proxy_tls_handshake_duration_seconds",
+		Help:    "TLS handshake latency per listener",
+		Buckets: []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5},
+	}, []string{"listener"})
+
+	tlsHandshakeErrors := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_tls_handshake_errors_total",
+		Help: "Total failed TLS handshakes per listener by reason",
+	}, []string{"listener", "reason"})
+
+	tlsHandshakes := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_tls_handshakes_total",
+		Help: "Total completed TLS handshakes per listener by negotiated version, cipher suite and session resumption",
+	}, []string{"listener", "version", "cipher", "resumed"})
+
+	tlsCertSelections := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_tls_certificate_selections_total",
+		Help: "Total TLS handshakes served by each configured certificate",
+	}, []string{"certificate"})
+
+	tlsSNIMisses := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_tls_sni_misses_total",
+		Help: "Total TLS handshakes whose SNI matched no configured certificate by reason",
+	}, []string{"reason"})
+
+	tlsCertExpiry := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "proxy_tls_certificate_expiry_days",
+		Help: "Days until each configured certificate expires",
+	}, []string{"certificate"})
+
+	tlsCertExpiring := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "proxy_tls_certificates_expiring",
+		Help: "Configured certificates within the expiry warning window",
+	})
+
+	listenerShed := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_listener_shed_total",
+		Help: "Total requests or connections shed by listener limits",
+	}, []string{"listener", "reason"})
+
+	compressionResponses := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_compression_responses_total",
+		Help: "Total compressed responses",
+	}, []string{"route", "algorithm"})
+
+	compressionSaved := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_compression_bytes_saved_total",
+		Help: "Total response bytes saved by compression",
+	}, []string{"route", "algorithm"})
+
+	decompressionReject := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_decompression_rejected_total",
+		Help: "Total request bodies rejected by decompression limits",
+	}, []string{"route", "reason"})
+
+	multipartReject := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_multipart_parts_rejected_total",
+		Help: "Total multipart request parts rejected or stripped by multipart limits",
+	}, []string{"route", "reason"})
+
+	fingerprintReject := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_tls_fingerprint_rejected_total",
+		Help: "Total requests rejected by TLS fingerprint policy",
+	}, []string{"route", "reason"})
+
+	upstreamWarmup := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_upstream_warmup_total",
+		Help: "Total upstream connection warm-up attempts by result",
+	}, []string{"pool", "result"})
+
+	dnsResolution := prometheus.NewHistogramVec(durationHistogramOpts(cfg, "proxy_dns_resolution_seconds", "Upstream DNS resolution duration"), []string{"pool", "result"})
+
+	dnsCache := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_dns_cache_total",
+		Help: "Total upstream DNS cache lookups by result",
+	}, []string{"pool", "result"})
+
+	zoneRequests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_upstream_zone_requests_total",
+		Help: "Total upstream attempts by endpoint zone",
+	}, []string{"pool", "zone"})
+
+	zoneErrors := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_upstream_zone_errors_total",
+		Help: "Total failed upstream attempts by endpoint zone",
+	}, []string{"pool", "zone"})
+
+	drainCutoff := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_drain_cutoff_requests_total",
+		Help: "Total in-flight requests cut off when an endpoint exceeded its drain budget",
+	}, []string{"pool"})
+
+	egressThrottled := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_egress_throttled_responses_total",
+		Help: "Total responses delayed by egress bandwidth shaping",
+	}, []string{"route"})
+
+	egressThrottleWait := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_egress_throttle_wait_seconds_total",
+		Help: "Total time responses spent waiting on egress bandwidth shaping",
+	}, []string{"route"})
+
+	staleSnapshot := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_stale_snapshot_requests_total",
+		Help: "Total requests whose snapshot was retired before upstream pick",
+	}, []string{"route", "action"})
+
+	scriptResults := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_script_results_total",
+		Help: "Total route script executions by result",
+	}, []string{"route", "result"})
+
+	authResults := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_auth_results_total",
+		Help: "Total route authentication outcomes",
+	}, []string{"route", "mode", "result"})
+
+	authKeyRequests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_auth_key_requests_total",
+		Help: "Total requests authenticated by API key or HMAC key",
+	}, []string{"route", "key", "result"})
+
+	accessDenied := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_access_denied_total",
+		Help: "Total requests rejected by route access policy",
+	}, []string{"route", "reason"})
+
+	requestRuleMatches := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_request_rule_matches_total",
+		Help: "Total requests matched by request rules by scope, rule, and action",
+	}, []string{"route", "scope", "rule", "action"})
+
+	certReloads := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_tls_cert_reloads_total",
+		Help: "Total TLS certificate reload attempts by trigger and result",
+	}, []string{"trigger", "result"})
+
+	mtlsIdentity := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_mtls_identity_requests_total",
+		Help: "Total mTLS requests by verified client identity and authorization result",
+	}, []string{"route", "identity", "result"})
+
+	revocationChecks := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_mtls_revocation_checks_total",
+		Help: "Total client certificate revocation checks by method and result",
+	}, []string{"route", "method", "result"})
+
+	ocspStaples := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_ocsp_staple_refresh_total",
+		Help: "Total OCSP staple refreshes for served certificates by result",
+	}, []string{"result"})
+
+	streamConnections := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_stream_connections_total",
+		Help: "Total stream proxy connections by listener, pool and result",
+	}, []string{"listener", "pool", "result"})
+
+	streamActive := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "proxy_stream_active_connections",
+		Help: "Open stream proxy connections per listener",
+	}, []string{"listener"})
+
+	sseActive := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "proxy_sse_active_streams",
+		Help: "Open server-sent event streams per route",
+	}, []string{"route"})
+
+	sseHeartbeats := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_sse_heartbeats_total",
+		Help: "Total heartbeats injected into stalled server-sent event streams",
+	}, []string{"route"})
+
+	streamBytes := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_stream_bytes_total",
+		Help: "Total bytes relayed by stream listeners by direction",
+	}, []string{"listener", "direction"})
+
+	mirrorRequests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_mirror_requests_total",
+		Help: "Total mirrored requests by route, shadow pool and result",
+	}, []string{"route", "pool", "result"})
+
+	mirrorInflight := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "proxy_mirror_inflight_requests",
+		Help: "In-flight mirrored requests per shadow pool",
+	}, []string{"pool"})
+
+	rampWeight := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "proxy_traffic_ramp_canary_weight",
+		Help: "Current canary weight percent set by the ramp controller",
+	}, []string{"route"})
+
+	rampTransitions := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_traffic_ramp_transitions_total",
+		Help: "Total canary ramp transitions by resulting state",
+	}, []string{"route", "state"})
+
+	drainedCohorts := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "proxy_traffic_drained_cohorts",
+		Help: "Sticky cohorts still routed to the canary during an autodrain grace period",
+	}, []string{"route"})
+
+	hedgeRequests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_hedge_requests_total",
+		Help: "Total hedged upstream attempts by route and result",
+	}, []string{"route", "result"})
+	concurrencyLimit := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "proxy_adaptive_concurrency_limit",
+		Help: "Current adaptive concurrency limit per pool",
+	}, []string{"pool"})
+
+	concurrencyDrops := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_adaptive_concurrency_drops_total",
+		Help: "Total requests rejected by the adaptive concurrency limiter",
+	}, []string{"pool"})
+
+	breakerResets := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_breaker_manual_resets_total",
+		Help: "Breakers force-closed through the admin API",
+	}, []string{"pool"})
+
+	routeLabelInfo := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "proxy_route_label_info",
+		Help: "Route labels for attribution",
+	}, []string{"route", "key", "value"})
+
+	accessLogDropped := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_access_log_dropped_total",
+		Help: "Access log lines dropped by a sink",
+	}, []string{"sink", "reason"})
+
+	traceSpansDropped := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "proxy_trace_spans_dropped_total",
+		Help: "Trace spans dropped before export",
+	}, []string{"reason"})
+
 	breakerOpen := prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "proxy_breaker_open",
 		Help: "Breaker open state",
@@ -221,9 +556,9 @@ func NewMetrics(cfg MetricsConfig) *Metrics {
 	snapshotInfoGauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "proxy_active_snapshot_info",
 		Help: "Active snapshot metadata",
-	}, []string{"version", "source"})
+	}, []string{"version", "source", "label", "git_sha", "author"})
 
-	registry.MustRegister(requests, upstreamErrors, proxyErrors, retries, retryBudgetExhausted, configApply, configApplyDuration, configConflicts, circuitOpen, outlierEjections, outlierFailOpen, mtlsReject, cacheRequests, cacheCoalesceBreakaway, cacheStoreFail, variantRequests, variantErrors, overloadRejects, pluginCalls, pluginBypass, pluginShortCircuit, pluginFailClosed, requestDuration, upstreamRoundTrip, snapshotInfoGauge, breakerOpen, bundleVerify, rolloutStage, rollbackTotal)
+	registry.MustRegister(requests, upstreamErrors, proxyErrors, retries, retryBudgetExhausted, configApply, configApplyDuration, configConflicts, circuitOpen, outlierEjections, outlierFailOpen, mtlsReject, cacheRequests, cacheCoalesceBreakaway, cacheStoreFail, variantRequests, variantErrors, overloadRejects, pluginCalls, pluginBypass, pluginShortCircuit, pluginFailClosed, requestDuration, upstreamRoundTrip, snapshotInfoGauge, breakerOpen, bundleVerify, rolloutStage, rollbackTotal, pullTotal, pullFailures, pullLastSuccess, listenerInflight, listenerQueued, listenerConnections, listenerAccepted, listenerActive, tlsHandshakeDuration, tlsHandshakeErrors, tlsHandshakes, tlsCertSelections, tlsSNIMisses, tlsCertExpiry, tlsCertExpiring, listenerShed, compressionResponses, compressionSaved, decompressionReject, multipartReject, fingerprintReject, drainCutoff, upstreamWarmup, dnsResolution, dnsCache, zoneRequests, zoneErrors, egressThrottled, egressThrottleWait, staleSnapshot, scriptResults, authResults, authKeyRequests, accessDenied, requestRuleMatches, certReloads, mtlsIdentity, revocationChecks, ocspStaples, streamConnections, streamActive, sseActive, sseHeartbeats, streamBytes, mirrorRequests, mirrorInflight, rampWeight, rampTransitions, drainedCohorts, hedgeRequests, concurrencyLimit, concurrencyDrops, breakerResets, routeLabelInfo, accessLogDropped, traceSpansDropped)
 
 	return &Metrics{
 		registry:               registry,
@@ -257,6 +592,63 @@ func NewMetrics(cfg MetricsConfig) *Metrics {
 		bundleVerify:           bundleVerify,
 		rolloutStage:           rolloutStage,
 		rollbackTotal:          rollbackTotal,
+		pullTotal:              pullTotal,
+		pullFailures:           pullFailures,
+		pullLastSuccess:        pullLastSuccess,
+		listenerInflight:       listenerInflight,
+		listenerQueued:         listenerQueued,
+		listenerConnections:    listenerConnections,
+		listenerAccepted:       listenerAccepted,
+		listenerActive:         listenerActive,
+		tlsHandshakeDuration:   tlsHandshakeDuration,
+		tlsHandshakeErrors:     tlsHandshakeErrors,
+		tlsHandshakes:          tlsHandshakes,
+		tlsCertSelections:      tlsCertSelections,
+		tlsSNIMisses:           tlsSNIMisses,
+		tlsCertExpiry:          tlsCertExpiry,
+		tlsCertExpiring:        tlsCertExpiring,
+		listenerShed:           listenerShed,
+		compressionResponses:   compressionResponses,
+		compressionSaved:       compressionSaved,
+		decompressionReject:    decompressionReject,
+		multipartReject:        multipartReject,
+		fingerprintReject:      fingerprintReject,
+		drainCutoff:            drainCutoff,
+		upstreamWarmup:         upstreamWarmup,
+		dnsResolution:          dnsResolution,
+		dnsCache:               dnsCache,
+		zoneRequests:           zoneRequests,
+		zoneErrors:             zoneErrors,
+		egressThrottled:        egressThrottled,
+		egressThrottleWait:     egressThrottleWait,
+		staleSnapshot:          staleSnapshot,
+		scriptResults:          scriptResults,
+		authResults:            authResults,
+		authKeyRequests:        authKeyRequests,
+		accessDenied:           accessDenied,
+		requestRuleMatches:     requestRuleMatches,
+		certReloads:            certReloads,
+		mtlsIdentity:           mtlsIdentity,
+		revocationChecks:       revocationChecks,
+		ocspStaples:            ocspStaples,
+		streamConnections:      streamConnections,
+		streamActive:           streamActive,
+		sseActive:              sseActive,
+		sseHeartbeats:          sseHeartbeats,
+		streamBytes:            streamBytes,
+		mirrorRequests:         mirrorRequests,
+		mirrorInflight:         mirrorInflight,
+		hedgeRequests:          hedgeRequests,
+		concurrencyLimit:       concurrencyLimit,
+		concurrencyDrops:       concurrencyDrops,
+		breakerResets:          breakerResets,
+		rampWeight:             rampWeight,
+		rampTransitions:        rampTransitions,
+		drainedCohorts:         drainedCohorts,
+		routeLabelInfo:         routeLabelInfo,
+		accessLogDropped:       accessLogDropped,
+		traceSpansDropped:      traceSpansDropped,
+		routeLabels:            make(map[string]map[string]string),
 		requestWindow:          newRollingCounter(10 * time.Second),
 	}
 }
@@ -267,7 +659,38 @@ func (m *Metrics) Handler() http.Handler {
 			w.WriteHeader(http.StatusServiceUnavailable)
 		})
 	}
-	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{EnableOpenMetrics: true})
+}
+
+func durationHistogramOpts(cfg MetricsConfig, name string, help string) prometheus.HistogramOpts {
+	opts := prometheus.HistogramOpts{
+		Name:    name,
+		Help:    help,
+		Buckets: prometheus.DefBuckets,
+	}
+	if len(cfg.DurationBuckets) > 0 {
+		opts.Buckets = append([]float64(nil), cfg.DurationBuckets...)
+	}
+	if cfg.NativeHistograms {
+		opts.NativeHistogramBucketFactor = cfg.NativeHistogramFactor
+		if opts.NativeHistogramBucketFactor <= 1 {
+			opts.NativeHistogramBucketFactor = defaultNativeHistogramFactor
+		}
+		opts.NativeHistogramMaxBucketNumber = cfg.NativeHistogramMaxBuckets
+		if opts.NativeHistogramMaxBucketNumber == 0 {
+			opts.NativeHistogramMaxBucketNumber = defaultNativeHistogramMaxBuckets
+		}
+		opts.NativeHistogramMinResetDuration = time.Hour
+	}
+	return opts
+}
+
+func observeWithExemplar(observer prometheus.Observer, value float64, traceID string) {
+	if exemplar, ok := observer.(prometheus.ExemplarObserver); ok && traceID != "" {
+		exemplar.ObserveWithExemplar(value, prometheus.Labels{"trace_id": traceID})
+		return
+	}
+	observer.Observe(value)
 }
 
 func (m *Metrics) ObserveRequest(routeID string, poolKey string, status int, duration time.Duration) {
@@ -298,7 +721,7 @@ func (m *Metrics) Canonicalize(routeID string, poolKey string) (string, string)
 	return m.topk.CanonRoute(routeID), m.topk.CanonPool(poolKey)
 }
 
-func (m *Metrics) ObserveRequestCanonical(canonRoute string, status int, duration time.Duration) {
+func (m *Metrics) ObserveRequestCanonical(canonRoute string, status int, duration time.Duration, traceID string) {
 	if m == nil {
 		return
 	}
@@ -311,11 +734,11 @@ func (m *Metrics) ObserveRequestCanonical(canonRoute string, status int, duratio
 	}
 	statusClass := statusClass(status)
 	m.requests.WithLabelValues(canonRoute, statusClass).Inc()
-	m.requestDuration.WithLabelValues(canonRoute).Observe(duration.Seconds())
+	observeWithExemplar(m.requestDuration.WithLabelValues(canonRoute), duration.Seconds(), traceID)
 	m.requestWindow.Record(status)
 }
 
-func (m *Metrics) ObserveUpstreamRoundTrip(poolKey string, duration time.Duration) {
+func (m *Metrics) ObserveUpstreamRoundTrip(poolKey string, duration time.Duration, traceID string) {
 	if m == nil {
 		return
 	}
@@ -325,7 +748,7 @@ func (m *Metrics) ObserveUpstreamRoundTrip(poolKey string, duration time.Duratio
 
 	m.topk.ObserveHit("", poolKey)
 	canonPool := m.topk.CanonPool(poolKey)
-	m.upstreamRoundTrip.WithLabelValues(canonPool).Observe(duration.Seconds())
+	observeWithExemplar(m.upstreamRoundTrip.WithLabelValues(canonPool), duration.Seconds(), traceID)
 }
 
 func (m *Metrics) RecordUpstreamError(poolKey string, category string) {
@@ -719,34 +1142,65 @@ func (m *Metrics) RecordRollback(result string) {
 	m.rollbackTotal.WithLabelValues(result).Inc()
 }
 
-func (m *Metrics) Rolling5xx(window time.Duration) (int, int) {
-	if m == nil || m.requestWindow == nil {
-		return 0, 0
+func (m *Metrics) RecordPull(result string, consecutiveFailures int, lastSuccess time.Time) {
+	if m == nil {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+	if result == "" {
+		result = "unknown"
+	}
+	m.pullTotal.WithLabelValues(result).Inc()
+	m.pullFailures.Set(float64(consecutiveFailures))
+	if !lastSuccess.IsZero() {
+		m.pullLastSuccess.Set(float64(lastSuccess.Unix()))
 	}
-	return m.requestWindow.Counts(window)
 }
 
-func (m *Metrics) RecordPluginCall(filter string, phase string, result string) {
+func (m *Metrics) SetListenerInflight(listener string, value int64) {
 	if m == nil {
 		return
 	}
 	defer func() {
 		_ = recover()
 	}()
+	m.listenerInflight.WithLabelValues(listener).Set(float64(value))
+}
 
-	if filter == "" {
-		filter = "unknown"
+func (m *Metrics) SetListenerQueued(listener string, value int64) {
+	if m == nil {
+		return
 	}
-	if phase == "" {
-		phase = "unknown"
+	defer func() {
+		_ = recover()
+	}()
+	m.listenerQueued.WithLabelValues(listener).Set(float64(value))
+}
+
+func (m *Metrics) SetListenerConnections(listener string, value int64) {
+	if m == nil {
+		return
 	}
-	if result == "" {
-		result = "unknown"
+	defer func() {
+		_ = recover()
+	}()
+	m.listenerConnections.WithLabelValues(listener).Set(float64(value))
+}
+
+func (m *Metrics) RecordListenerAccepted(listener string) {
+	if m == nil {
+		return
 	}
-	m.pluginCalls.WithLabelValues(filter, phase, result).Inc()
+	defer func() {
+		_ = recover()
+	}()
+
+	m.listenerAccepted.WithLabelValues(listener).Inc()
 }
 
-func (m *Metrics) RecordPluginBypass(filter string, reason string) {
+func (m *Metrics) SetListenerActiveConnections(listener string, value int64) {
 	if m == nil {
 		return
 	}
@@ -754,44 +1208,101 @@ func (m *Metrics) RecordPluginBypass(filter string, reason string) {
 		_ = recover()
 	}()
 
-	if filter == "" {
-		filter = "unknown"
+	m.listenerActive.WithLabelValues(listener).Set(float64(value))
+}
+
+func (m *Metrics) ObserveTLSHandshake(listener string, version string, cipher string, resumed bool, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+
+	m.tlsHandshakeDuration.WithLabelValues(listener).Observe(duration.Seconds())
+	m.tlsHandshakes.WithLabelValues(listener, version, cipher, strconv.FormatBool(resumed)).Inc()
+}
+
+func (m *Metrics) RecordTLSHandshakeError(listener string, reason string) {
+	if m == nil {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+
+	m.tlsHandshakeErrors.WithLabelValues(listener, reason).Inc()
+}
+
+func (m *Metrics) RecordListenerShed(listener string, reason string) {
+	if m == nil {
+		return
 	}
+	defer func() {
+		_ = recover()
+	}()
 	if reason == "" {
 		reason = "unknown"
 	}
-	m.pluginBypass.WithLabelValues(filter, reason).Inc()
+	m.listenerShed.WithLabelValues(listener, reason).Inc()
 }
 
-func (m *Metrics) RecordPluginShortCircuit(filter string) {
+func (m *Metrics) RecordCompressionCanonical(canonRoute string, algorithm string, bytesIn int64, bytesOut int64) {
 	if m == nil {
 		return
 	}
 	defer func() {
 		_ = recover()
 	}()
+	if canonRoute == "" {
+		canonRoute = "none"
+	}
+	m.compressionResponses.WithLabelValues(canonRoute, algorithm).Inc()
+	if saved := bytesIn - bytesOut; saved > 0 {
+		m.compressionSaved.WithLabelValues(canonRoute, algorithm).Add(float64(saved))
+	}
+}
 
-	if filter == "" {
-		filter = "unknown"
+func (m *Metrics) RecordEgressThrottleCanonical(canonRoute string, waited time.Duration) {
+	if m == nil {
+		return
 	}
-	m.pluginShortCircuit.WithLabelValues(filter).Inc()
+	defer func() {
+		_ = recover()
+	}()
+	if canonRoute == "" {
+		canonRoute = "none"
+	}
+	m.egressThrottled.WithLabelValues(canonRoute).Inc()
+	m.egressThrottleWait.WithLabelValues(canonRoute).Add(waited.Seconds())
 }
 
-func (m *Metrics) RecordPluginFailClosed(filter string) {
+func (m *Metrics) RecordStaleSnapshotCanonical(canonRoute string, action string) {
 	if m == nil {
 		return
 	}
 	defer func() {
 		_ = recover()
 	}()
+	if canonRoute == "" {
+		canonRoute = "none"
+	}
+	m.staleSnapshot.WithLabelValues(canonRoute, action).Inc()
+}
 
-	if filter == "" {
-		filter = "unknown"
+func (m *Metrics) RecordDecompressionReject(routeID string, reason string) {
+	if m == nil {
+		return
 	}
-	m.pluginFailClosed.WithLabelValues(filter).Inc()
+	defer func() {
+		_ = recover()
+	}()
+
+	canonRoute := m.topk.CanonRoute(routeID)
+	m.decompressionReject.WithLabelValues(canonRoute, reason).Inc()
 }
 
-func (m *Metrics) SetBreakerOpen(poolKey string, open bool) {
+func (m *Metrics) RecordMultipartReject(routeID string, reason string) {
 	if m == nil {
 		return
 	}
@@ -799,31 +1310,579 @@ func (m *Metrics) SetBreakerOpen(poolKey string, open bool) {
 		_ = recover()
 	}()
 
-	m.topk.ObserveHit("", poolKey)
-	canonPool := m.topk.CanonPool(poolKey)
-	value := 0.0
-	if open {
-		value = 1.0
+	canonRoute := m.topk.CanonRoute(routeID)
+	m.multipartReject.WithLabelValues(canonRoute, reason).Inc()
+}
+
+func (m *Metrics) RecordScriptResult(routeID string, result string) {
+	if m == nil {
+		return
 	}
-	m.breakerOpen.WithLabelValues(canonPool).Set(value)
+	defer func() {
+		_ = recover()
+	}()
+
+	canonRoute := m.topk.CanonRoute(routeID)
+	m.scriptResults.WithLabelValues(canonRoute, result).Inc()
 }
 
-func (m *Metrics) SetSnapshotInfo(version string, source string) {
-	if m == nil || version == "" {
+func (m *Metrics) RecordAuthResult(routeID string, mode string, result string) {
+	if m == nil {
 		return
 	}
-	if source == "" {
-		source = "unknown"
+	defer func() {
+		_ = recover()
+	}()
+
+	canonRoute := m.topk.CanonRoute(routeID)
+	m.authResults.WithLabelValues(canonRoute, mode, result).Inc()
+}
+
+func (m *Metrics) RecordAuthKey(routeID string, keyID string, result string) {
+	if m == nil {
+		return
 	}
+	defer func() {
+		_ = recover()
+	}()
 
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	if m.lastVersion != "" {
-		m.snapshotInfo.WithLabelValues(m.lastVersion, m.lastSource).Set(0)
+	canonRoute := m.topk.CanonRoute(routeID)
+	m.authKeyRequests.WithLabelValues(canonRoute, keyID, result).Inc()
+}
+
+func (m *Metrics) RecordAccessDenied(routeID string, reason string) {
+	if m == nil {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+
+	canonRoute := m.topk.CanonRoute(routeID)
+	m.accessDenied.WithLabelValues(canonRoute, reason).Inc()
+}
+
+func (m *Metrics) RecordRequestRuleMatch(routeID string, scope string, rule string, action string) {
+	if m == nil {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+
+	canonRoute := m.topk.CanonRoute(routeID)
+	m.requestRuleMatches.WithLabelValues(canonRoute, scope, rule, action).Inc()
+}
+
+func (m *Metrics) RecordMTLSIdentity(routeID string, identity string, result string) {
+	if m == nil {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+
+	canonRoute := m.topk.CanonRoute(routeID)
+	if identity == "" {
+		identity = "unknown"
+	}
+	m.mtlsIdentity.WithLabelValues(canonRoute, identity, result).Inc()
+}
+
+func (m *Metrics) RecordRevocationCheck(routeID string, method string, result string) {
+	if m == nil {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+
+	canonRoute := m.topk.CanonRoute(routeID)
+	m.revocationChecks.WithLabelValues(canonRoute, method, result).Inc()
+}
+
+func (m *Metrics) RecordCertificateSelection(certificate string, missReason string) {
+	if m == nil {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+
+	if certificate == "" {
+		certificate = "none"
+	}
+	m.tlsCertSelections.WithLabelValues(certificate).Inc()
+	if missReason != "" {
+		m.tlsSNIMisses.WithLabelValues(missReason).Inc()
+	}
+}
+
+func (m *Metrics) SetCertificateExpiry(expiries map[string]time.Time) {
+	if m == nil {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+
+	m.tlsCertExpiry.Reset()
+	now := time.Now()
+	for certificate, notAfter := range expiries {
+		m.tlsCertExpiry.WithLabelValues(certificate).Set(notAfter.Sub(now).Hours() / 24)
+	}
+}
+
+func (m *Metrics) SetCertificatesExpiring(count int) {
+	if m == nil {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+
+	m.tlsCertExpiring.Set(float64(count))
+}
+
+func (m *Metrics) RecordOCSPStaple(result string) {
+	if m == nil {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+
+	m.ocspStaples.WithLabelValues(result).Inc()
+}
+
+func (m *Metrics) SetDrainedCohorts(routeID string, remaining int) {
+	if m == nil {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+
+	m.topk.ObserveHit(routeID, "")
+	canonRoute := m.topk.CanonRoute(routeID)
+	m.drainedCohorts.WithLabelValues(canonRoute).Set(float64(remaining))
+}
+
+func (m *Metrics) RecordHedge(routeID string, result string) {
+	if m == nil {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+
+	m.topk.ObserveHit(routeID, "")
+	canonRoute := m.topk.CanonRoute(routeID)
+	m.hedgeRequests.WithLabelValues(canonRoute, result).Inc()
+}
+
+func (m *Metrics) SetConcurrencyLimit(poolKey string, limit int) {
+	if m == nil {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+
+	m.topk.ObserveHit("", poolKey)
+	canonPool := m.topk.CanonPool(poolKey)
+	m.concurrencyLimit.WithLabelValues(canonPool).Set(float64(limit))
+}
+
+func (m *Metrics) RecordConcurrencyDrop(poolKey string) {
+	if m == nil {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+
+	m.topk.ObserveHit("", poolKey)
+	canonPool := m.topk.CanonPool(poolKey)
+	m.concurrencyDrops.WithLabelValues(canonPool).Inc()
+}
+
+func (m *Metrics) RecordBreakerReset(poolKey string) {
+	if m == nil {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+
+	m.topk.ObserveHit("", poolKey)
+	canonPool := m.topk.CanonPool(poolKey)
+	m.breakerResets.WithLabelValues(canonPool).Inc()
+}
+
+func (m *Metrics) RecordTrafficRamp(routeID string, weight int, state string) {
+	if m == nil {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+
+	m.topk.ObserveHit(routeID, "")
+	canonRoute := m.topk.CanonRoute(routeID)
+	m.rampWeight.WithLabelValues(canonRoute).Set(float64(weight))
+	m.rampTransitions.WithLabelValues(canonRoute, state).Inc()
+}
+
+func (m *Metrics) RecordMirror(routeID string, poolName string, result string) {
+	if m == nil {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+
+	canonRoute := m.topk.CanonRoute(routeID)
+	m.mirrorRequests.WithLabelValues(canonRoute, poolName, result).Inc()
+}
+
+func (m *Metrics) AddMirrorInflight(poolName string, delta int) {
+	if m == nil {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+
+	m.mirrorInflight.WithLabelValues(poolName).Add(float64(delta))
+}
+
+func (m *Metrics) RecordStreamConnection(listener string, poolName string, result string) {
+	if m == nil {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+
+	m.streamConnections.WithLabelValues(listener, poolName, result).Inc()
+}
+
+func (m *Metrics) AddSSEActiveCanonical(canonRoute string, delta int) {
+	if m == nil {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+	if canonRoute == "" {
+		canonRoute = "none"
+	}
+	m.sseActive.WithLabelValues(canonRoute).Add(float64(delta))
+}
+
+func (m *Metrics) RecordSSEHeartbeatCanonical(canonRoute string) {
+	if m == nil {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+	if canonRoute == "" {
+		canonRoute = "none"
+	}
+	m.sseHeartbeats.WithLabelValues(canonRoute).Inc()
+}
+
+func (m *Metrics) AddStreamActive(listener string, delta int) {
+	if m == nil {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+
+	m.streamActive.WithLabelValues(listener).Add(float64(delta))
+}
+
+func (m *Metrics) RecordStreamBytes(listener string, direction string, n int64) {
+	if m == nil || n <= 0 {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+
+	m.streamBytes.WithLabelValues(listener, direction).Add(float64(n))
+}
+
+func (m *Metrics) RecordCertReload(trigger string, result string) {
+	if m == nil {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+
+	m.certReloads.WithLabelValues(trigger, result).Inc()
+}
+
+func (m *Metrics) RecordFingerprintReject(routeID string, reason string) {
+	if m == nil {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+
+	canonRoute := m.topk.CanonRoute(routeID)
+	m.fingerprintReject.WithLabelValues(canonRoute, reason).Inc()
+}
+
+func (m *Metrics) RecordDrainCutoff(poolKey string, inflight int64) {
+	if m == nil || inflight <= 0 {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+
+	canonPool := m.topk.CanonPool(poolKey)
+	m.drainCutoff.WithLabelValues(canonPool).Add(float64(inflight))
+}
+
+func (m *Metrics) RecordUpstreamWarmup(poolKey string, result string) {
+	if m == nil {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+
+	m.topk.ObserveHit("", poolKey)
+	canonPool := m.topk.CanonPool(poolKey)
+	m.upstreamWarmup.WithLabelValues(canonPool, result).Inc()
+}
+
+func (m *Metrics) RecordZoneRequest(poolKey string, zone string, success bool) {
+	if m == nil {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+
+	m.topk.ObserveHit("", poolKey)
+	canonPool := m.topk.CanonPool(poolKey)
+	m.zoneRequests.WithLabelValues(canonPool, zone).Inc()
+	if !success {
+		m.zoneErrors.WithLabelValues(canonPool, zone).Inc()
+	}
+}
+
+func (m *Metrics) ObserveDNSResolution(poolKey string, result string, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+
+	m.topk.ObserveHit("", poolKey)
+	canonPool := m.topk.CanonPool(poolKey)
+	m.dnsResolution.WithLabelValues(canonPool, result).Observe(duration.Seconds())
+}
+
+func (m *Metrics) RecordDNSCache(poolKey string, result string) {
+	if m == nil {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+
+	m.topk.ObserveHit("", poolKey)
+	canonPool := m.topk.CanonPool(poolKey)
+	m.dnsCache.WithLabelValues(canonPool, result).Inc()
+}
+
+func (m *Metrics) Rolling5xx(window time.Duration) (int, int) {
+	if m == nil || m.requestWindow == nil {
+		return 0, 0
+	}
+	return m.requestWindow.Counts(window)
+}
+
+func (m *Metrics) RecordPluginCall(filter string, phase string, result string) {
+	if m == nil {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+
+	if filter == "" {
+		filter = "unknown"
+	}
+	if phase == "" {
+		phase = "unknown"
+	}
+	if result == "" {
+		result = "unknown"
+	}
+	m.pluginCalls.WithLabelValues(filter, phase, result).Inc()
+}
+
+func (m *Metrics) RecordPluginBypass(filter string, reason string) {
+	if m == nil {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+
+	if filter == "" {
+		filter = "unknown"
+	}
+	if reason == "" {
+		reason = "unknown"
+	}
+	m.pluginBypass.WithLabelValues(filter, reason).Inc()
+}
+
+func (m *Metrics) RecordPluginShortCircuit(filter string) {
+	if m == nil {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+
+	if filter == "" {
+		filter = "unknown"
+	}
+	m.pluginShortCircuit.WithLabelValues(filter).Inc()
+}
+
+func (m *Metrics) RecordPluginFailClosed(filter string) {
+	if m == nil {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+
+	if filter == "" {
+		filter = "unknown"
+	}
+	m.pluginFailClosed.WithLabelValues(filter).Inc()
+}
+
+func (m *Metrics) SetBreakerOpen(poolKey string, open bool) {
+	if m == nil {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+
+	m.topk.ObserveHit("", poolKey)
+	canonPool := m.topk.CanonPool(poolKey)
+	value := 0.0
+	if open {
+		value = 1.0
+	}
+	m.breakerOpen.WithLabelValues(canonPool).Set(value)
+}
+
+func (m *Metrics) SetSnapshotInfo(version string, source string, label string, gitSHA string, author string) {
+	if m == nil || version == "" {
+		return
+	}
+	if source == "" {
+		source = "unknown"
+	}
+
+	values := []string{version, source, label, gitSHA, author}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.lastSnapshotInfo != nil {
+		if sameValues(m.lastSnapshotInfo, values) {
+			return
+		}
+		m.snapshotInfo.WithLabelValues(m.lastSnapshotInfo...).Set(0)
+	}
+	m.snapshotInfo.WithLabelValues(values...).Set(1)
+	m.lastSnapshotInfo = values
+}
+
+func sameValues(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *Metrics) SetRouteLabelsCanonical(canonRoute string, labels map[string]string) {
+	if m == nil || canonRoute == "" || canonRoute == "none" || canonRoute == "other" {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	previous, seen := m.routeLabels[canonRoute]
+	if seen && sameLabels(previous, labels) {
+		return
+	}
+	for key, value := range previous {
+		m.routeLabelInfo.DeleteLabelValues(canonRoute, key, value)
+	}
+	copied := make(map[string]string, len(labels))
+	for key, value := range labels {
+		m.routeLabelInfo.WithLabelValues(canonRoute, key, value).Set(1)
+		copied[key] = value
+	}
+	m.routeLabels[canonRoute] = copied
+}
+
+func (m *Metrics) RecordAccessLogDropped(sink string, reason string, count int) {
+	if m == nil || count <= 0 {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+	m.accessLogDropped.WithLabelValues(sink, reason).Add(float64(count))
+}
+
+func (m *Metrics) RecordTraceSpansDropped(reason string, count int) {
+	if m == nil || count <= 0 {
+		return
+	}
+	defer func() {
+		_ = recover()
+	}()
+	m.traceSpansDropped.WithLabelValues(reason).Add(float64(count))
+}
+
+func sameLabels(a map[string]string, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if other, ok := b[key]; !ok || other != value {
+			return false
+		}
 	}
-	m.snapshotInfo.WithLabelValues(version, source).Set(1)
-	m.lastVersion = version
-	m.lastSource = source
+	return true
 }
 
 func statusClass(status int) string {
@@ -8,6 +8,7 @@ type RequestContext struct {
 	Host                 string
 	Path                 string
 	RouteID              string
+	RouteLabels          map[string]string
 	PoolKey              string
 	UpstreamAddr         string
 	PluginFilters        []string
@@ -23,10 +24,17 @@ type RequestContext struct {
 	ErrorCategory        string
 	RetryCount           int
 	RetryLastReason      string
+	RetrySkipReason      string
+	IdempotencyStatus    string
+	RequestRule          string
+	OriginalRequestID    string
 	RetryBudgetExhausted bool
 	CacheStatus          string
 	SnapshotVersion      string
 	SnapshotSource       string
+	SnapshotLabel        string
+	SnapshotGitSHA       string
+	SnapshotAuthor       string
 	TrafficVariant       string
 	CohortMode           string
 	CohortKeyPresent     bool
@@ -39,6 +47,22 @@ type RequestContext struct {
 	OutlierIgnored       bool
 	EndpointEjected      bool
 	TLS                  bool
+	TLSJA3               string
+	TLSJA4               string
+	UpstreamOverride     bool
+	ScriptVars           map[string]string
+	AuthSubject          string
+	ClientIP             string
 	MTLSRouteRequired    bool
 	MTLSVerified         bool
+	MTLSIdentity         string
+	Phases               PhaseTimings
+}
+
+type PhaseTimings struct {
+	RouteMatch    time.Duration
+	PluginRequest time.Duration
+	UpstreamTTFB  time.Duration
+	UpstreamBody  time.Duration
+	ResponseWrite time.Duration
 }
@@ -0,0 +1,280 @@
+package obs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTracerServiceName = "modern_reverse_proxy"
+	defaultTracerQueueSize   = 2048
+	defaultTracerBatchSize   = 256
+	defaultTracerFlush       = 2 * time.Second
+	otlpTracesPath           = "/v1/traces"
+	tracerScopeName          = "modern_reverse_proxy/internal/obs"
+)
+
+type Sampler struct {
+	Ratio       float64
+	ParentBased bool
+}
+
+func ParseSampler(name string, arg string) (Sampler, error) {
+	ratio := 1.0
+	if strings.TrimSpace(arg) != "" {
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(arg), 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			return Sampler{}, fmt.Errorf("sampler arg %q must be a ratio between 0 and 1", arg)
+		}
+		ratio = parsed
+	}
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "parentbased_always_on":
+		return Sampler{Ratio: 1, ParentBased: true}, nil
+	case "parentbased_always_off":
+		return Sampler{Ratio: 0, ParentBased: true}, nil
+	case "parentbased_traceidratio":
+		return Sampler{Ratio: ratio, ParentBased: true}, nil
+	case "always_on":
+		return Sampler{Ratio: 1}, nil
+	case "always_off":
+		return Sampler{Ratio: 0}, nil
+	case "traceidratio":
+		return Sampler{Ratio: ratio}, nil
+	default:
+		return Sampler{}, fmt.Errorf("unknown sampler %q", name)
+	}
+}
+
+func (s Sampler) ShouldSample(traceID [16]byte, hasParent bool, parentSampled bool) bool {
+	if s.ParentBased && hasParent {
+		return parentSampled
+	}
+	if s.Ratio >= 1 {
+		return true
+	}
+	if s.Ratio <= 0 {
+		return false
+	}
+	bound := uint64(s.Ratio * math.MaxInt64)
+	return binary.BigEndian.Uint64(traceID[8:])>>1 < bound
+}
+
+type TracerConfig struct {
+	Endpoint      string
+	Headers       map[string]string
+	ServiceName   string
+	Sampler       Sampler
+	QueueSize     int
+	BatchSize     int
+	FlushInterval time.Duration
+	Timeout       time.Duration
+}
+
+type Tracer struct {
+	sampler Sampler
+	queue   *batchQueue
+}
+
+var (
+	defaultTracerMu sync.RWMutex
+	defaultTracer   *Tracer
+)
+
+func SetDefaultTracer(tracer *Tracer) {
+	defaultTracerMu.Lock()
+	defer defaultTracerMu.Unlock()
+	defaultTracer = tracer
+}
+
+func DefaultTracer() *Tracer {
+	defaultTracerMu.RLock()
+	defer defaultTracerMu.RUnlock()
+	return defaultTracer
+}
+
+func NewTracer(cfg TracerConfig) (*Tracer, error) {
+	endpoint, err := otlpURL(cfg.Endpoint, otlpTracesPath)
+	if err != nil {
+		return nil, err
+	}
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = defaultTracerServiceName
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultSinkTimeout
+	}
+	queueSize := cfg.QueueSize
+	if queueSize <= 0 {
+		queueSize = defaultTracerQueueSize
+	}
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultTracerBatchSize
+	}
+	interval := cfg.FlushInterval
+	if interval <= 0 {
+		interval = defaultTracerFlush
+	}
+	resource, err := json.Marshal(map[string]interface{}{
+		"attributes": otlpAttributes([]spanAttribute{{key: "service.name", value: serviceName}}),
+	})
+	if err != nil {
+		return nil, err
+	}
+	writer := &otlpTraceWriter{url: endpoint, headers: cfg.Headers, resource: resource, client: &http.Client{Timeout: timeout}}
+	tracer := &Tracer{sampler: cfg.Sampler}
+	tracer.queue = newBatchQueue(writer, queueSize, batchSize, interval, func(reason string, count int, err error) {
+		DefaultMetrics().RecordTraceSpansDropped(reason, count)
+		if err != nil {
+			logger.Warn("trace_export", "result", "error", "dropped", count, "reason", err)
+		}
+	})
+	return tracer, nil
+}
+
+func (t *Tracer) Close() {
+	if t == nil {
+		return
+	}
+	t.queue.Close()
+}
+
+func (t *Tracer) Dropped() uint64 {
+	if t == nil {
+		return 0
+	}
+	return t.queue.Dropped()
+}
+
+func (t *Tracer) startServerSpan(traceParent string, name string) *Span {
+	traceID, parentID, parentSampled, hasParent := parseTraceParent(traceParent)
+	if !hasParent {
+		traceID = newTraceIDBytes()
+		parentID = [8]byte{}
+	}
+	return &Span{
+		tracer:   t,
+		traceID:  traceID,
+		spanID:   newSpanIDBytes(),
+		parentID: parentID,
+		sampled:  t.sampler.ShouldSample(traceID, hasParent, parentSampled),
+		name:     name,
+		kind:     SpanKindServer,
+		start:    time.Now(),
+	}
+}
+
+func (t *Tracer) export(span *Span) {
+	data, err := json.Marshal(span.otlp())
+	if err != nil {
+		t.queue.drop("encode_error", 1, err)
+		return
+	}
+	t.queue.Push(data)
+}
+
+func (s *Span) otlp() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := map[string]interface{}{
+		"traceId":           hex.EncodeToString(s.traceID[:]),
+		"spanId":            hex.EncodeToString(s.spanID[:]),
+		"name":              s.name,
+		"kind":              s.kind,
+		"startTimeUnixNano": strconv.FormatInt(s.start.UnixNano(), 10),
+		"endTimeUnixNano":   strconv.FormatInt(s.end.UnixNano(), 10),
+		"attributes":        otlpAttributes(s.attrs),
+		"status":            map[string]interface{}{"code": s.statusCode, "message": s.statusMessage},
+	}
+	if s.parentID != [8]byte{} {
+		out["parentSpanId"] = hex.EncodeToString(s.parentID[:])
+	}
+	if len(s.events) > 0 {
+		events := make([]map[string]interface{}, 0, len(s.events))
+		for _, event := range s.events {
+			events = append(events, map[string]interface{}{
+				"name":         event.name,
+				"timeUnixNano": strconv.FormatInt(event.at.UnixNano(), 10),
+				"attributes":   otlpAttributes(event.attrs),
+			})
+		}
+		out["events"] = events
+	}
+	return out
+}
+
+func otlpAttributes(attrs []spanAttribute) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(attrs))
+	for _, attr := range attrs {
+		out = append(out, map[string]interface{}{"key": attr.key, "value": otlpValue(attr.value)})
+	}
+	return out
+}
+
+func otlpValue(value interface{}) map[string]interface{} {
+	switch v := value.(type) {
+	case bool:
+		return map[string]interface{}{"boolValue": v}
+	case int:
+		return map[string]interface{}{"intValue": strconv.FormatInt(int64(v), 10)}
+	case int64:
+		return map[string]interface{}{"intValue": strconv.FormatInt(v, 10)}
+	case float64:
+		return map[string]interface{}{"doubleValue": v}
+	case string:
+		return map[string]interface{}{"stringValue": v}
+	default:
+		return map[string]interface{}{"stringValue": fmt.Sprint(v)}
+	}
+}
+
+func otlpURL(endpoint string, signalPath string) (string, error) {
+	endpoint = strings.TrimSpace(endpoint)
+	if endpoint == "" {
+		return "", errors.New("otlp endpoint is required")
+	}
+	parsed, err := url.Parse(endpoint)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", fmt.Errorf("otlp endpoint %q must be an absolute http(s) url", endpoint)
+	}
+	if !strings.HasSuffix(parsed.Path, signalPath) {
+		parsed.Path = strings.TrimRight(parsed.Path, "/") + signalPath
+	}
+	return parsed.String(), nil
+}
+
+type otlpTraceWriter struct {
+	url      string
+	headers  map[string]string
+	resource []byte
+	client   *http.Client
+}
+
+func (w *otlpTraceWriter) WriteBatch(spans [][]byte) error {
+	var body bytes.Buffer
+	body.WriteString(`{"resourceSpans":[{"resource":`)
+	body.Write(w.resource)
+	body.WriteString(`,"scopeSpans":[{"scope":{"name":"` + tracerScopeName + `"},"spans":[`)
+	body.Write(bytes.Join(spans, []byte(",")))
+	body.WriteString(`]}]}]}`)
+	return postBatch(w.client, w.url, "application/json", w.headers, body.Bytes())
+}
+
+func (w *otlpTraceWriter) Close() error {
+	w.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,309 @@
+package obs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"modern_reverse_proxy/internal/logging"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+var logger = logging.For("obs")
+
+const (
+	PushStatsD = "statsd"
+	PushOTLP   = "otlp"
+
+	defaultPushInterval = 10 * time.Second
+	otlpMetricsPath     = "/v1/metrics"
+	statsdMaxPacket     = 1432
+)
+
+type PushConfig struct {
+	Type        string
+	Address     string
+	Endpoint    string
+	Headers     map[string]string
+	Prefix      string
+	ServiceName string
+	Interval    time.Duration
+	Timeout     time.Duration
+}
+
+type pushExporter interface {
+	Export(families []*dto.MetricFamily, now time.Time) error
+	Close() error
+}
+
+type Pusher struct {
+	metrics  *Metrics
+	exporter pushExporter
+	interval time.Duration
+}
+
+func NewPusher(metrics *Metrics, cfg PushConfig) (*Pusher, error) {
+	if metrics == nil {
+		return nil, errors.New("metrics are required")
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultSinkTimeout
+	}
+	var exporter pushExporter
+	switch strings.ToLower(strings.TrimSpace(cfg.Type)) {
+	case PushStatsD:
+		if cfg.Address == "" {
+			return nil, errors.New("statsd push requires address")
+		}
+		conn, err := net.Dial("udp", cfg.Address)
+		if err != nil {
+			return nil, err
+		}
+		exporter = &statsdExporter{conn: conn, prefix: cfg.Prefix, previous: make(map[string]float64)}
+	case PushOTLP:
+		endpoint, err := otlpURL(cfg.Endpoint, otlpMetricsPath)
+		if err != nil {
+			return nil, err
+		}
+		serviceName := cfg.ServiceName
+		if serviceName == "" {
+			serviceName = defaultTracerServiceName
+		}
+		exporter = &otlpMetricsExporter{
+			url:     endpoint,
+			headers: cfg.Headers,
+			prefix:  cfg.Prefix,
+			service: serviceName,
+			start:   time.Now(),
+			client:  &http.Client{Timeout: timeout},
+		}
+	default:
+		return nil, fmt.Errorf("unknown metrics push type %q", cfg.Type)
+	}
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = defaultPushInterval
+	}
+	return &Pusher{metrics: metrics, exporter: exporter, interval: interval}, nil
+}
+
+func (p *Pusher) Push() error {
+	families, err := p.metrics.registry.Gather()
+	if err != nil {
+		return err
+	}
+	return p.exporter.Export(families, time.Now())
+}
+
+func (p *Pusher) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	defer p.exporter.Close()
+	for {
+		select {
+		case <-ctx.Done():
+			if err := p.Push(); err != nil {
+				logger.Warn("metrics_push", "result", "error", "reason", err)
+			}
+			return
+		case <-ticker.C:
+			if err := p.Push(); err != nil {
+				logger.Warn("metrics_push", "result", "error", "reason", err)
+			}
+		}
+	}
+}
+
+type statsdExporter struct {
+	conn     net.Conn
+	prefix   string
+	previous map[string]float64
+}
+
+func (s *statsdExporter) Export(families []*dto.MetricFamily, now time.Time) error {
+	_ = now
+	var lines []string
+	for _, family := range families {
+		name := s.prefix + family.GetName()
+		for _, metric := range family.GetMetric() {
+			tags := statsdTags(metric.GetLabel())
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				lines = s.appendDelta(lines, name, tags, metric.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				lines = append(lines, statsdLine(name, metric.GetGauge().GetValue(), "g", tags))
+			case dto.MetricType_UNTYPED:
+				lines = append(lines, statsdLine(name, metric.GetUntyped().GetValue(), "g", tags))
+			case dto.MetricType_HISTOGRAM:
+				histogram := metric.GetHistogram()
+				lines = s.appendDelta(lines, name+".count", tags, float64(histogram.GetSampleCount()))
+				lines = s.appendDelta(lines, name+".sum", tags, histogram.GetSampleSum())
+			}
+		}
+	}
+	return s.send(lines)
+}
+
+func (s *statsdExporter) appendDelta(lines []string, name string, tags string, value float64) []string {
+	key := name + "|" + tags
+	delta := value - s.previous[key]
+	s.previous[key] = value
+	if delta <= 0 {
+		return lines
+	}
+	return append(lines, statsdLine(name, delta, "c", tags))
+}
+
+func (s *statsdExporter) send(lines []string) error {
+	var packet bytes.Buffer
+	flush := func() error {
+		if packet.Len() == 0 {
+			return nil
+		}
+		_, err := s.conn.Write(packet.Bytes())
+		packet.Reset()
+		return err
+	}
+	for _, line := range lines {
+		if packet.Len() > 0 && packet.Len()+1+len(line) > statsdMaxPacket {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+	return flush()
+}
+
+func (s *statsdExporter) Close() error {
+	return s.conn.Close()
+}
+
+func statsdLine(name string, value float64, kind string, tags string) string {
+	line := name + ":" + strconv.FormatFloat(value, 'f', -1, 64) + "|" + kind
+	if tags != "" {
+		line += "|#" + tags
+	}
+	return line
+}
+
+func statsdTags(labels []*dto.LabelPair) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	tags := make([]string, 0, len(labels))
+	for _, label := range labels {
+		value := strings.NewReplacer(",", "_", "|", "_", "#", "_").Replace(label.GetValue())
+		tags = append(tags, label.GetName()+":"+value)
+	}
+	sort.Strings(tags)
+	return strings.Join(tags, ",")
+}
+
+type otlpMetricsExporter struct {
+	url     string
+	headers map[string]string
+	prefix  string
+	service string
+	start   time.Time
+	client  *http.Client
+}
+
+func (o *otlpMetricsExporter) Export(families []*dto.MetricFamily, now time.Time) error {
+	start := strconv.FormatInt(o.start.UnixNano(), 10)
+	timestamp := strconv.FormatInt(now.UnixNano(), 10)
+	metrics := make([]map[string]interface{}, 0, len(families))
+	for _, family := range families {
+		points := make([]map[string]interface{}, 0, len(family.GetMetric()))
+		for _, metric := range family.GetMetric() {
+			point := map[string]interface{}{
+				"attributes":        otlpLabelAttributes(metric.GetLabel()),
+				"startTimeUnixNano": start,
+				"timeUnixNano":      timestamp,
+			}
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				point["asDouble"] = metric.GetCounter().GetValue()
+			case dto.MetricType_GAUGE:
+				point["asDouble"] = metric.GetGauge().GetValue()
+			case dto.MetricType_UNTYPED:
+				point["asDouble"] = metric.GetUntyped().GetValue()
+			case dto.MetricType_HISTOGRAM:
+				histogram := metric.GetHistogram()
+				bounds := make([]float64, 0, len(histogram.GetBucket()))
+				counts := make([]string, 0, len(histogram.GetBucket())+1)
+				previous := uint64(0)
+				for _, bucket := range histogram.GetBucket() {
+					bounds = append(bounds, bucket.GetUpperBound())
+					counts = append(counts, strconv.FormatUint(bucket.GetCumulativeCount()-previous, 10))
+					previous = bucket.GetCumulativeCount()
+				}
+				counts = append(counts, strconv.FormatUint(histogram.GetSampleCount()-previous, 10))
+				point["count"] = strconv.FormatUint(histogram.GetSampleCount(), 10)
+				point["sum"] = histogram.GetSampleSum()
+				point["explicitBounds"] = bounds
+				point["bucketCounts"] = counts
+			default:
+				continue
+			}
+			points = append(points, point)
+		}
+		if len(points) == 0 {
+			continue
+		}
+		metric := map[string]interface{}{
+			"name":        o.prefix + family.GetName(),
+			"description": family.GetHelp(),
+		}
+		switch family.GetType() {
+		case dto.MetricType_COUNTER:
+			metric["sum"] = map[string]interface{}{"dataPoints": points, "aggregationTemporality": 2, "isMonotonic": true}
+		case dto.MetricType_HISTOGRAM:
+			metric["histogram"] = map[string]interface{}{"dataPoints": points, "aggregationTemporality": 2}
+		default:
+			metric["gauge"] = map[string]interface{}{"dataPoints": points}
+		}
+		metrics = append(metrics, metric)
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"resourceMetrics": []interface{}{map[string]interface{}{
+			"resource": map[string]interface{}{
+				"attributes": otlpAttributes([]spanAttribute{{key: "service.name", value: o.service}}),
+			},
+			"scopeMetrics": []interface{}{map[string]interface{}{
+				"scope":   map[string]interface{}{"name": tracerScopeName},
+				"metrics": metrics,
+			}},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	return postBatch(o.client, o.url, "application/json", o.headers, body)
+}
+
+func (o *otlpMetricsExporter) Close() error {
+	o.client.CloseIdleConnections()
+	return nil
+}
+
+func otlpLabelAttributes(labels []*dto.LabelPair) []map[string]interface{} {
+	attrs := make([]spanAttribute, 0, len(labels))
+	for _, label := range labels {
+		attrs = append(attrs, spanAttribute{key: label.GetName(), value: label.GetValue()})
+	}
+	return otlpAttributes(attrs)
+}
@@ -0,0 +1,367 @@
+package obs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/syslog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	SinkStdout = "stdout"
+	SinkFile   = "file"
+	SinkSyslog = "syslog"
+	SinkHTTP   = "http"
+	SinkKafka  = "kafka"
+
+	defaultSinkBufferSize    = 4096
+	defaultSinkBatchSize     = 100
+	defaultSinkFlushInterval = time.Second
+	defaultSinkTimeout       = 5 * time.Second
+	defaultSinkMaxSize       = 100 << 20
+	defaultSinkMaxBackups    = 5
+	defaultSyslogTag         = "proxy"
+)
+
+type AccessLogSinkSpec struct {
+	Type          string
+	Path          string
+	MaxSizeBytes  int64
+	MaxBackups    int
+	Network       string
+	Address       string
+	Tag           string
+	URL           string
+	Topic         string
+	Headers       map[string]string
+	BufferSize    int
+	BatchSize     int
+	FlushInterval time.Duration
+	Timeout       time.Duration
+}
+
+type AccessLogSink struct {
+	name  string
+	queue *batchQueue
+}
+
+var accessLogSinks = struct {
+	mu    sync.Mutex
+	sinks map[string]*AccessLogSink
+}{sinks: make(map[string]*AccessLogSink)}
+
+func AccessLogSinkFor(spec AccessLogSinkSpec) (*AccessLogSink, error) {
+	spec.Type = strings.ToLower(strings.TrimSpace(spec.Type))
+	key, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	accessLogSinks.mu.Lock()
+	defer accessLogSinks.mu.Unlock()
+	if sink, ok := accessLogSinks.sinks[string(key)]; ok {
+		return sink, nil
+	}
+	sink, err := newAccessLogSink(spec)
+	if err != nil {
+		return nil, err
+	}
+	accessLogSinks.sinks[string(key)] = sink
+	return sink, nil
+}
+
+func CloseAccessLogSinks() {
+	accessLogSinks.mu.Lock()
+	sinks := accessLogSinks.sinks
+	accessLogSinks.sinks = make(map[string]*AccessLogSink)
+	accessLogSinks.mu.Unlock()
+	for _, sink := range sinks {
+		sink.Close()
+	}
+}
+
+func newAccessLogSink(spec AccessLogSinkSpec) (*AccessLogSink, error) {
+	timeout := spec.Timeout
+	if timeout <= 0 {
+		timeout = defaultSinkTimeout
+	}
+	var writer sinkWriter
+	switch spec.Type {
+	case SinkStdout:
+		writer = stdoutWriter{}
+	case SinkFile:
+		if spec.Path == "" {
+			return nil, errors.New("file sink requires path")
+		}
+		maxSize := spec.MaxSizeBytes
+		if maxSize <= 0 {
+			maxSize = defaultSinkMaxSize
+		}
+		maxBackups := spec.MaxBackups
+		if maxBackups <= 0 {
+			maxBackups = defaultSinkMaxBackups
+		}
+		writer = &rotatingFile{path: spec.Path, maxSize: maxSize, maxBackups: maxBackups}
+	case SinkSyslog:
+		tag := spec.Tag
+		if tag == "" {
+			tag = defaultSyslogTag
+		}
+		writer = &syslogWriter{network: spec.Network, address: spec.Address, tag: tag}
+	case SinkHTTP:
+		if spec.URL == "" {
+			return nil, errors.New("http sink requires url")
+		}
+		writer = &httpWriter{url: spec.URL, headers: spec.Headers, client: &http.Client{Timeout: timeout}}
+	case SinkKafka:
+		if spec.URL == "" || spec.Topic == "" {
+			return nil, errors.New("kafka sink requires url and topic")
+		}
+		writer = &kafkaWriter{
+			url:     strings.TrimRight(spec.URL, "/") + "/topics/" + spec.Topic,
+			headers: spec.Headers,
+			client:  &http.Client{Timeout: timeout},
+		}
+	default:
+		return nil, fmt.Errorf("unknown sink type %q", spec.Type)
+	}
+	bufferSize := spec.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = defaultSinkBufferSize
+	}
+	batchSize := spec.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultSinkBatchSize
+	}
+	interval := spec.FlushInterval
+	if interval <= 0 {
+		interval = defaultSinkFlushInterval
+	}
+	sink := &AccessLogSink{name: spec.Type}
+	sink.queue = newBatchQueue(writer, bufferSize, batchSize, interval, func(reason string, count int, err error) {
+		DefaultMetrics().RecordAccessLogDropped(sink.name, reason, count)
+		if err != nil {
+			logger.Warn("access_log_sink", "sink", sink.name, "result", "error", "dropped", count, "reason", err)
+		}
+	})
+	return sink, nil
+}
+
+func (s *AccessLogSink) Name() string {
+	return s.name
+}
+
+func (s *AccessLogSink) Dropped() uint64 {
+	return s.queue.Dropped()
+}
+
+func (s *AccessLogSink) Write(p []byte) (int, error) {
+	line := make([]byte, len(p))
+	copy(line, p)
+	s.queue.Push(line)
+	return len(p), nil
+}
+
+func (s *AccessLogSink) Close() {
+	s.queue.Close()
+}
+
+type stdoutWriter struct{}
+
+func (stdoutWriter) WriteBatch(lines [][]byte) error {
+	output := accessLogOutput()
+	for _, line := range lines {
+		if _, err := output.Write(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (stdoutWriter) Close() error {
+	return nil
+}
+
+type rotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func (f *rotatingFile) WriteBatch(lines [][]byte) error {
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return err
+		}
+	}
+	for _, line := range lines {
+		if f.size > 0 && f.size+int64(len(line)) > f.maxSize {
+			if err := f.rotate(); err != nil {
+				return err
+			}
+		}
+		n, err := f.file.Write(line)
+		f.size += int64(n)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+func (f *rotatingFile) rotate() error {
+	if err := f.Close(); err != nil {
+		return err
+	}
+	for i := f.maxBackups - 1; i >= 1; i-- {
+		from := fmt.Sprintf("%s.%d", f.path, i)
+		if _, err := os.Stat(from); err == nil {
+			if err := os.Rename(from, fmt.Sprintf("%s.%d", f.path, i+1)); err != nil {
+				return err
+			}
+		}
+	}
+	if err := os.Rename(f.path, f.path+".1"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return f.open()
+}
+
+func (f *rotatingFile) Close() error {
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	f.size = 0
+	return err
+}
+
+type syslogWriter struct {
+	network string
+	address string
+	tag     string
+	writer  *syslog.Writer
+}
+
+func (w *syslogWriter) WriteBatch(lines [][]byte) error {
+	if w.writer == nil {
+		writer, err := syslog.Dial(w.network, w.address, syslog.LOG_INFO|syslog.LOG_LOCAL0, w.tag)
+		if err != nil {
+			return err
+		}
+		w.writer = writer
+	}
+	for _, line := range lines {
+		if err := w.writer.Info(string(bytes.TrimRight(line, "\n"))); err != nil {
+			_ = w.Close()
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *syslogWriter) Close() error {
+	if w.writer == nil {
+		return nil
+	}
+	err := w.writer.Close()
+	w.writer = nil
+	return err
+}
+
+type httpWriter struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func (w *httpWriter) WriteBatch(lines [][]byte) error {
+	return postBatch(w.client, w.url, "application/x-ndjson", w.headers, bytes.Join(lines, nil))
+}
+
+func (w *httpWriter) Close() error {
+	w.client.CloseIdleConnections()
+	return nil
+}
+
+type kafkaWriter struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func (w *kafkaWriter) WriteBatch(lines [][]byte) error {
+	type record struct {
+		Value json.RawMessage `json:"value"`
+	}
+	payload := struct {
+		Records []record `json:"records"`
+	}{Records: make([]record, 0, len(lines))}
+	for _, line := range lines {
+		value := bytes.TrimSpace(line)
+		if !json.Valid(value) {
+			encoded, err := json.Marshal(string(value))
+			if err != nil {
+				return err
+			}
+			value = encoded
+		}
+		payload.Records = append(payload.Records, record{Value: value})
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return postBatch(w.client, w.url, "application/vnd.kafka.json.v2+json", w.headers, body)
+}
+
+func (w *kafkaWriter) Close() error {
+	w.client.CloseIdleConnections()
+	return nil
+}
+
+func postBatch(client *http.Client, url string, contentType string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package obs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	slowRouteBucketWidth = time.Minute
+	slowRouteBucketCount = 60
+
+	SlowRouteMaxWindow = slowRouteBucketWidth * slowRouteBucketCount
+)
+
+type SlowLogEntry struct {
+	Timestamp       string `json:"ts"`
+	Log             string `json:"log"`
+	RequestID       string `json:"request_id"`
+	Method          string `json:"method"`
+	Host            string `json:"host"`
+	Path            string `json:"path"`
+	RouteID         string `json:"route_id"`
+	PoolKey         string `json:"pool_key"`
+	UpstreamAddr    string `json:"upstream_addr"`
+	Status          int    `json:"status"`
+	DurationMS      int64  `json:"duration_ms"`
+	ThresholdMS     int64  `json:"threshold_ms"`
+	ErrorCategory   string `json:"error_category"`
+	RetryCount      int    `json:"retry_count"`
+	CacheStatus     string `json:"cache_status"`
+	RouteMatchUS    int64  `json:"route_match_us"`
+	PluginRequestUS int64  `json:"plugin_request_us"`
+	UpstreamTTFBUS  int64  `json:"upstream_ttfb_us"`
+	UpstreamBodyUS  int64  `json:"upstream_body_us"`
+	ResponseWriteUS int64  `json:"response_write_us"`
+}
+
+func LogSlowRequest(w io.Writer, ctx RequestContext, threshold time.Duration) {
+	if w == nil {
+		w = accessLogOutput()
+	}
+	entry := SlowLogEntry{
+		Timestamp:       time.Now().UTC().Format(time.RFC3339Nano),
+		Log:             "slow_request",
+		RequestID:       defaultString(ctx.RequestID, "none"),
+		Method:          ctx.Method,
+		Host:            ctx.Host,
+		Path:            ctx.Path,
+		RouteID:         defaultString(ctx.RouteID, "none"),
+		PoolKey:         defaultString(ctx.PoolKey, "none"),
+		UpstreamAddr:    defaultString(ctx.UpstreamAddr, "none"),
+		Status:          ctx.Status,
+		DurationMS:      ctx.Duration.Milliseconds(),
+		ThresholdMS:     threshold.Milliseconds(),
+		ErrorCategory:   defaultString(ctx.ErrorCategory, "none"),
+		RetryCount:      ctx.RetryCount,
+		CacheStatus:     defaultString(ctx.CacheStatus, "bypass"),
+		RouteMatchUS:    ctx.Phases.RouteMatch.Microseconds(),
+		PluginRequestUS: ctx.Phases.PluginRequest.Microseconds(),
+		UpstreamTTFBUS:  ctx.Phases.UpstreamTTFB.Microseconds(),
+		UpstreamBodyUS:  ctx.Phases.UpstreamBody.Microseconds(),
+		ResponseWriteUS: ctx.Phases.ResponseWrite.Microseconds(),
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "log_marshal_error request_id=%s error=%v\n", entry.RequestID, err)
+		return
+	}
+	if redact, ok := accessLogRedactor.Load().(func(string) string); ok && redact != nil {
+		data = []byte(redact(string(data)))
+	}
+	_, _ = w.Write(append(data, '\n'))
+}
+
+type SlowRequestSample struct {
+	RequestID    string    `json:"request_id"`
+	DurationMS   int64     `json:"duration_ms"`
+	UpstreamAddr string    `json:"upstream_addr"`
+	At           time.Time `json:"at"`
+}
+
+type SlowRouteSummary struct {
+	Route        string            `json:"route"`
+	Requests     int64             `json:"requests"`
+	SlowRequests int64             `json:"slow_requests"`
+	AvgMS        float64           `json:"avg_ms"`
+	MaxMS        int64             `json:"max_ms"`
+	Slowest      SlowRequestSample `json:"slowest"`
+}
+
+type slowRouteBucket struct {
+	start    time.Time
+	requests int64
+	slow     int64
+	total    time.Duration
+	slowest  time.Duration
+	sample   SlowRequestSample
+}
+
+type SlowRouteTracker struct {
+	mu     sync.Mutex
+	routes map[string]*[slowRouteBucketCount]slowRouteBucket
+	now    func() time.Time
+}
+
+func NewSlowRouteTracker() *SlowRouteTracker {
+	return &SlowRouteTracker{
+		routes: make(map[string]*[slowRouteBucketCount]slowRouteBucket),
+		now:    time.Now,
+	}
+}
+
+func (t *SlowRouteTracker) Observe(route string, duration time.Duration, slow bool, requestID string, upstreamAddr string) {
+	if t == nil || route == "" {
+		return
+	}
+	now := t.now()
+	start := now.Truncate(slowRouteBucketWidth)
+	index := int(start.Unix()/int64(slowRouteBucketWidth/time.Second)) % slowRouteBucketCount
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	buckets, ok := t.routes[route]
+	if !ok {
+		buckets = &[slowRouteBucketCount]slowRouteBucket{}
+		t.routes[route] = buckets
+	}
+	bucket := &buckets[index]
+	if !bucket.start.Equal(start) {
+		*bucket = slowRouteBucket{start: start}
+	}
+	bucket.requests++
+	bucket.total += duration
+	if slow {
+		bucket.slow++
+	}
+	if duration > bucket.slowest {
+		bucket.slowest = duration
+		bucket.sample = SlowRequestSample{
+			RequestID:    requestID,
+			DurationMS:   duration.Milliseconds(),
+			UpstreamAddr: upstreamAddr,
+			At:           now.UTC(),
+		}
+	}
+}
+
+func (t *SlowRouteTracker) Top(n int, window time.Duration) []SlowRouteSummary {
+	if t == nil || n <= 0 {
+		return nil
+	}
+	if window <= 0 || window > SlowRouteMaxWindow {
+		window = SlowRouteMaxWindow
+	}
+	cutoff := t.now().Truncate(slowRouteBucketWidth).Add(slowRouteBucketWidth - window)
+
+	t.mu.Lock()
+	summaries := make([]SlowRouteSummary, 0, len(t.routes))
+	for route, buckets := range t.routes {
+		summary := SlowRouteSummary{Route: route}
+		var total, slowest time.Duration
+		for i := range buckets {
+			bucket := buckets[i]
+			if bucket.requests == 0 || bucket.start.Before(cutoff) {
+				continue
+			}
+			summary.Requests += bucket.requests
+			summary.SlowRequests += bucket.slow
+			total += bucket.total
+			if bucket.slowest > slowest {
+				slowest = bucket.slowest
+				summary.Slowest = bucket.sample
+			}
+		}
+		if summary.Requests == 0 {
+			continue
+		}
+		summary.AvgMS = float64(total.Microseconds()) / float64(summary.Requests) / 1000
+		summary.MaxMS = slowest.Milliseconds()
+		summaries = append(summaries, summary)
+	}
+	t.mu.Unlock()
+
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Slowest.DurationMS != summaries[j].Slowest.DurationMS {
+			return summaries[i].Slowest.DurationMS > summaries[j].Slowest.DurationMS
+		}
+		if summaries[i].AvgMS != summaries[j].AvgMS {
+			return summaries[i].AvgMS > summaries[j].AvgMS
+		}
+		return summaries[i].Route < summaries[j].Route
+	})
+	if len(summaries) > n {
+		summaries = summaries[:n]
+	}
+	return summaries
+}
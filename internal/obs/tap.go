@@ -0,0 +1,237 @@
+package obs
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	TapMaxSubscribers = 8
+	TapMaxBodyBytes   = 64 * 1024
+
+	tapSubscriberBuffer = 64
+)
+
+type TapEvent struct {
+	Timestamp             string              `json:"ts"`
+	RequestID             string              `json:"request_id"`
+	Method                string              `json:"method"`
+	Host                  string              `json:"host"`
+	Path                  string              `json:"path"`
+	RouteID               string              `json:"route_id"`
+	PoolKey               string              `json:"pool_key"`
+	UpstreamAddr          string              `json:"upstream_addr"`
+	ClientIP              string              `json:"client_ip,omitempty"`
+	Status                int                 `json:"status"`
+	DurationMS            int64               `json:"duration_ms"`
+	ErrorCategory         string              `json:"error_category"`
+	RetryCount            int                 `json:"retry_count"`
+	RequestHeaders        map[string][]string `json:"request_headers"`
+	ResponseHeaders       map[string][]string `json:"response_headers"`
+	RequestBody           string              `json:"request_body,omitempty"`
+	RequestBodyTruncated  bool                `json:"request_body_truncated,omitempty"`
+	ResponseBody          string              `json:"response_body,omitempty"`
+	ResponseBodyTruncated bool                `json:"response_body_truncated,omitempty"`
+}
+
+type TapFilter struct {
+	Route      string
+	SampleRate float64
+	BodyBytes  int
+}
+
+type TapSubscription struct {
+	filter  TapFilter
+	events  chan TapEvent
+	dropped atomic.Int64
+}
+
+func (s *TapSubscription) Events() <-chan TapEvent {
+	return s.events
+}
+
+func (s *TapSubscription) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+type Tap struct {
+	mu          sync.RWMutex
+	subscribers map[*TapSubscription]struct{}
+	active      atomic.Int32
+}
+
+func NewTap() *Tap {
+	return &Tap{subscribers: make(map[*TapSubscription]struct{})}
+}
+
+func (t *Tap) Subscribe(filter TapFilter) (*TapSubscription, bool) {
+	if t == nil {
+		return nil, false
+	}
+	if filter.BodyBytes > TapMaxBodyBytes {
+		filter.BodyBytes = TapMaxBodyBytes
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.subscribers) >= TapMaxSubscribers {
+		return nil, false
+	}
+	sub := &TapSubscription{filter: filter, events: make(chan TapEvent, tapSubscriberBuffer)}
+	t.subscribers[sub] = struct{}{}
+	t.active.Store(int32(len(t.subscribers)))
+	return sub, true
+}
+
+func (t *Tap) Unsubscribe(sub *TapSubscription) {
+	if t == nil || sub == nil {
+		return
+	}
+	t.mu.Lock()
+	delete(t.subscribers, sub)
+	t.active.Store(int32(len(t.subscribers)))
+	t.mu.Unlock()
+}
+
+func (t *Tap) Sample(route string) *TapCapture {
+	if t == nil || t.active.Load() == 0 {
+		return nil
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var capture *TapCapture
+	for sub := range t.subscribers {
+		if sub.filter.Route != "" && sub.filter.Route != route {
+			continue
+		}
+		if sub.filter.SampleRate < 1 && rand.Float64() >= sub.filter.SampleRate {
+			continue
+		}
+		if capture == nil {
+			capture = &TapCapture{}
+		}
+		capture.subscribers = append(capture.subscribers, sub)
+		if sub.filter.BodyBytes > capture.bodyBytes {
+			capture.bodyBytes = sub.filter.BodyBytes
+		}
+	}
+	return capture
+}
+
+type TapCapture struct {
+	subscribers  []*TapSubscription
+	bodyBytes    int
+	requestBody  tapBuffer
+	responseBody tapBuffer
+}
+
+func (c *TapCapture) WrapRequestBody(body io.ReadCloser) io.ReadCloser {
+	if c == nil || c.bodyBytes == 0 || body == nil || body == http.NoBody {
+		return body
+	}
+	c.requestBody.limit = c.bodyBytes
+	return &tapReadCloser{inner: body, buffer: &c.requestBody}
+}
+
+func (c *TapCapture) WrapResponseBody(body io.ReadCloser) io.ReadCloser {
+	if c == nil || c.bodyBytes == 0 || body == nil || body == http.NoBody {
+		return body
+	}
+	c.responseBody.limit = c.bodyBytes
+	return &tapReadCloser{inner: body, buffer: &c.responseBody}
+}
+
+func (c *TapCapture) Publish(ctx RequestContext, requestHeaders http.Header, responseHeaders http.Header) {
+	if c == nil {
+		return
+	}
+	event := TapEvent{
+		Timestamp:       time.Now().UTC().Format(time.RFC3339Nano),
+		RequestID:       ctx.RequestID,
+		Method:          ctx.Method,
+		Host:            ctx.Host,
+		Path:            ctx.Path,
+		RouteID:         defaultString(ctx.RouteID, "none"),
+		PoolKey:         defaultString(ctx.PoolKey, "none"),
+		UpstreamAddr:    defaultString(ctx.UpstreamAddr, "none"),
+		ClientIP:        ctx.ClientIP,
+		Status:          ctx.Status,
+		DurationMS:      ctx.Duration.Milliseconds(),
+		ErrorCategory:   defaultString(ctx.ErrorCategory, "none"),
+		RetryCount:      ctx.RetryCount,
+		RequestHeaders:  tapHeaders(requestHeaders),
+		ResponseHeaders: tapHeaders(responseHeaders),
+	}
+	for _, sub := range c.subscribers {
+		subEvent := event
+		subEvent.RequestBody, subEvent.RequestBodyTruncated = c.requestBody.slice(sub.filter.BodyBytes)
+		subEvent.ResponseBody, subEvent.ResponseBodyTruncated = c.responseBody.slice(sub.filter.BodyBytes)
+		select {
+		case sub.events <- subEvent:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+func tapHeaders(headers http.Header) map[string][]string {
+	out := make(map[string][]string, len(headers))
+	for name, values := range headers {
+		redacted := make([]string, len(values))
+		for i, value := range values {
+			redacted[i] = RedactHeaderValue(name, value)
+		}
+		out[name] = redacted
+	}
+	return out
+}
+
+type tapBuffer struct {
+	mu        sync.Mutex
+	limit     int
+	data      bytes.Buffer
+	truncated bool
+}
+
+func (b *tapBuffer) write(p []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if remaining := b.limit - b.data.Len(); remaining < len(p) {
+		p = p[:max(remaining, 0)]
+		b.truncated = true
+	}
+	b.data.Write(p)
+}
+
+func (b *tapBuffer) slice(limit int) (string, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	data := b.data.Bytes()
+	truncated := b.truncated
+	if len(data) > limit {
+		data = data[:limit]
+		truncated = true
+	}
+	return string(data), truncated
+}
+
+type tapReadCloser struct {
+	inner  io.ReadCloser
+	buffer *tapBuffer
+}
+
+func (r *tapReadCloser) Read(p []byte) (int, error) {
+	n, err := r.inner.Read(p)
+	if n > 0 {
+		r.buffer.write(p[:n])
+	}
+	return n, err
+}
+
+func (r *tapReadCloser) Close() error {
+	return r.inner.Close()
+}
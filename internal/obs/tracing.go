@@ -3,30 +3,83 @@ package obs
 import (
 	"context"
 	"crypto/rand"
+	"encoding/binary"
 	"encoding/hex"
+	"fmt"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 )
 
+const (
+	SpanKindInternal = 1
+	SpanKindServer   = 2
+	SpanKindClient   = 3
+
+	spanStatusOK    = 1
+	spanStatusError = 2
+)
+
 type TraceContext struct {
 	TraceParent string
 	TraceState  string
 	SpanID      string
+	Span        *Span
 	mu          sync.Mutex
 	phases      map[string]time.Time
 }
 
 type traceKey struct{}
 
+type spanKey struct{}
+
+type Span struct {
+	tracer   *Tracer
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte
+	sampled  bool
+	name     string
+	kind     int
+	start    time.Time
+
+	mu            sync.Mutex
+	end           time.Time
+	attrs         []spanAttribute
+	events        []spanEvent
+	statusCode    int
+	statusMessage string
+	ended         bool
+}
+
+type spanAttribute struct {
+	key   string
+	value interface{}
+}
+
+type spanEvent struct {
+	name  string
+	at    time.Time
+	attrs []spanAttribute
+}
+
 func StartTrace(ctx context.Context, req *http.Request) context.Context {
 	trace := &TraceContext{
 		TraceParent: req.Header.Get("traceparent"),
 		TraceState:  req.Header.Get("tracestate"),
-		SpanID:      newSpanID(),
 		phases:      make(map[string]time.Time),
 	}
-	return context.WithValue(ctx, traceKey{}, trace)
+	tracer := DefaultTracer()
+	if tracer == nil {
+		trace.SpanID = newSpanID()
+		return context.WithValue(ctx, traceKey{}, trace)
+	}
+	span := tracer.startServerSpan(trace.TraceParent, "proxy.request")
+	trace.Span = span
+	trace.SpanID = hex.EncodeToString(span.spanID[:])
+	ctx = context.WithValue(ctx, traceKey{}, trace)
+	return context.WithValue(ctx, spanKey{}, span)
 }
 
 func TraceFromContext(ctx context.Context) (*TraceContext, bool) {
@@ -34,12 +87,40 @@ func TraceFromContext(ctx context.Context) (*TraceContext, bool) {
 	return trace, ok
 }
 
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+func StartSpan(ctx context.Context, name string, kind int) (context.Context, *Span) {
+	parent := SpanFromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+	span := &Span{
+		tracer:   parent.tracer,
+		traceID:  parent.traceID,
+		spanID:   newSpanIDBytes(),
+		parentID: parent.spanID,
+		sampled:  parent.sampled,
+		name:     name,
+		kind:     kind,
+		start:    time.Now(),
+	}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
 func InjectTraceHeaders(req *http.Request, ctx context.Context) {
 	trace, ok := TraceFromContext(ctx)
 	if !ok {
 		return
 	}
-	if trace.TraceParent != "" {
+	if span := SpanFromContext(ctx); span != nil {
+		req.Header.Set("traceparent", span.TraceParent())
+	} else if trace.TraceParent != "" {
 		req.Header.Set("traceparent", trace.TraceParent)
 	}
 	if trace.TraceState != "" {
@@ -57,6 +138,169 @@ func MarkPhase(ctx context.Context, name string) {
 	trace.phases[name] = time.Now()
 }
 
+func PhaseTimingsFromContext(ctx context.Context) PhaseTimings {
+	trace, ok := TraceFromContext(ctx)
+	if !ok {
+		return PhaseTimings{}
+	}
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+	between := func(start string, end string) time.Duration {
+		startAt, okStart := trace.phases[start]
+		endAt, okEnd := trace.phases[end]
+		if !okStart || !okEnd || endAt.Before(startAt) {
+			return 0
+		}
+		return endAt.Sub(startAt)
+	}
+	return PhaseTimings{
+		RouteMatch:    between("route_match", "route_match_end"),
+		PluginRequest: between("plugin_request", "plugin_request_end"),
+		UpstreamTTFB:  between("upstream_roundtrip_start", "upstream_roundtrip_end"),
+		UpstreamBody:  between("upstream_roundtrip_end", "upstream_body_end"),
+		ResponseWrite: between("response_write", "response_write_end"),
+	}
+}
+
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return hex.EncodeToString(s.traceID[:])
+}
+
+func (s *Span) SpanID() string {
+	if s == nil {
+		return ""
+	}
+	return hex.EncodeToString(s.spanID[:])
+}
+
+func (s *Span) Sampled() bool {
+	return s != nil && s.sampled
+}
+
+func (s *Span) TraceParent() string {
+	if s == nil {
+		return ""
+	}
+	flags := "00"
+	if s.sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", s.TraceID(), s.SpanID(), flags)
+}
+
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil || !s.sampled {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.attrs {
+		if s.attrs[i].key == key {
+			s.attrs[i].value = value
+			return
+		}
+	}
+	s.attrs = append(s.attrs, spanAttribute{key: key, value: value})
+}
+
+func (s *Span) AddEvent(name string, attrs map[string]interface{}) {
+	if s == nil || !s.sampled {
+		return
+	}
+	event := spanEvent{name: name, at: time.Now()}
+	for key, value := range attrs {
+		event.attrs = append(event.attrs, spanAttribute{key: key, value: value})
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+}
+
+func (s *Span) SetError(message string) {
+	if s == nil || !s.sampled {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statusCode = spanStatusError
+	s.statusMessage = message
+}
+
+func (s *Span) SetOK() {
+	if s == nil || !s.sampled {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.statusCode == 0 {
+		s.statusCode = spanStatusOK
+	}
+}
+
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mu.Unlock()
+	if s.sampled && s.tracer != nil {
+		s.tracer.export(s)
+	}
+}
+
+func parseTraceParent(value string) (traceID [16]byte, spanID [8]byte, sampled bool, ok bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return traceID, spanID, false, false
+	}
+	if parts[0] == "00" && len(parts) != 4 {
+		return traceID, spanID, false, false
+	}
+	if len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return traceID, spanID, false, false
+	}
+	if _, err := hex.Decode(traceID[:], []byte(parts[1])); err != nil || traceID == [16]byte{} {
+		return traceID, spanID, false, false
+	}
+	if _, err := hex.Decode(spanID[:], []byte(parts[2])); err != nil || spanID == [8]byte{} {
+		return traceID, spanID, false, false
+	}
+	var flags [1]byte
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return traceID, spanID, false, false
+	}
+	return traceID, spanID, flags[0]&1 == 1, true
+}
+
+func newTraceIDBytes() [16]byte {
+	var id [16]byte
+	for id == [16]byte{} {
+		if _, err := rand.Read(id[:]); err != nil {
+			binary.BigEndian.PutUint64(id[8:], uint64(time.Now().UnixNano()))
+		}
+	}
+	return id
+}
+
+func newSpanIDBytes() [8]byte {
+	var id [8]byte
+	for id == [8]byte{} {
+		if _, err := rand.Read(id[:]); err != nil {
+			binary.BigEndian.PutUint64(id[:], uint64(time.Now().UnixNano()))
+		}
+	}
+	return id
+}
+
 func newSpanID() string {
 	buf := make([]byte, 8)
 	if _, err := rand.Read(buf); err != nil {
@@ -0,0 +1,153 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"strings"
+	"time"
+)
+
+const clockSkew = time.Minute
+
+var ErrInvalidToken = errors.New("invalid id token")
+
+type Claims struct {
+	Issuer   string   `json:"iss"`
+	Subject  string   `json:"sub"`
+	Audience audience `json:"aud"`
+	Expiry   int64    `json:"exp"`
+	IssuedAt int64    `json:"iat"`
+	Nonce    string   `json:"nonce"`
+	Email    string   `json:"email"`
+	Name     string   `json:"name"`
+}
+
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+func (a audience) contains(value string) bool {
+	for _, item := range a {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Provider) VerifyIDToken(ctx context.Context, raw string, clientID string, nonce string) (*Claims, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed", ErrInvalidToken)
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrInvalidToken, err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature encoding", ErrInvalidToken)
+	}
+	key, err := p.Key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: claims: %v", ErrInvalidToken, err)
+	}
+	now := time.Now()
+	switch {
+	case strings.TrimSuffix(claims.Issuer, "/") != p.issuer:
+		return nil, fmt.Errorf("%w: issuer mismatch", ErrInvalidToken)
+	case !claims.Audience.contains(clientID):
+		return nil, fmt.Errorf("%w: audience mismatch", ErrInvalidToken)
+	case claims.Subject == "":
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidToken)
+	case claims.Expiry == 0 || now.After(time.Unix(claims.Expiry, 0).Add(clockSkew)):
+		return nil, fmt.Errorf("%w: expired", ErrInvalidToken)
+	case claims.IssuedAt != 0 && time.Unix(claims.IssuedAt, 0).After(now.Add(clockSkew)):
+		return nil, fmt.Errorf("%w: issued in the future", ErrInvalidToken)
+	case nonce != "" && claims.Nonce != nonce:
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidToken)
+	}
+	return &claims, nil
+}
+
+func verifySignature(alg string, key crypto.PublicKey, signed string, signature []byte) error {
+	var hasher hash.Hash
+	var hashID crypto.Hash
+	switch alg {
+	case "RS256", "ES256":
+		hasher, hashID = sha256.New(), crypto.SHA256
+	case "RS384", "ES384":
+		hasher, hashID = sha512.New384(), crypto.SHA384
+	case "RS512":
+		hasher, hashID = sha512.New(), crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported alg %q", alg)
+	}
+	hasher.Write([]byte(signed))
+	digest := hasher.Sum(nil)
+
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		if alg[:2] != "RS" {
+			return errors.New("alg does not match key")
+		}
+		return rsa.VerifyPKCS1v15(pub, hashID, digest, signature)
+	case *ecdsa.PublicKey:
+		if alg[:2] != "ES" {
+			return errors.New("alg does not match key")
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.New("bad signature length")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return errors.New("signature mismatch")
+		}
+		return nil
+	default:
+		return errors.New("unsupported key")
+	}
+}
+
+func decodeSegment(segment string, out interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,269 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	discoveryPath      = "/.well-known/openid-configuration"
+	discoveryTTL       = time.Hour
+	keyRefreshInterval = 30 * time.Second
+	maxProviderBody    = 1 << 20
+	providerTimeout    = 5 * time.Second
+)
+
+var (
+	ErrUnknownKey    = errors.New("oidc signing key not found")
+	ErrProviderError = errors.New("oidc provider error")
+)
+
+type Metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+	EndSessionEndpoint    string `json:"end_session_endpoint"`
+}
+
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	IDToken      string `json:"id_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+	TokenType    string `json:"token_type"`
+}
+
+type Provider struct {
+	issuer string
+	client *http.Client
+
+	mu          sync.Mutex
+	meta        *Metadata
+	fetchedAt   time.Time
+	keys        map[string]crypto.PublicKey
+	keysFetched time.Time
+}
+
+var providers = struct {
+	mu        sync.Mutex
+	providers map[string]*Provider
+}{providers: make(map[string]*Provider)}
+
+func GetProvider(issuer string) *Provider {
+	issuer = strings.TrimSuffix(issuer, "/")
+	providers.mu.Lock()
+	defer providers.mu.Unlock()
+	if provider, ok := providers.providers[issuer]; ok {
+		return provider
+	}
+	provider := &Provider{
+		issuer: issuer,
+		client: &http.Client{Timeout: providerTimeout},
+	}
+	providers.providers[issuer] = provider
+	return provider
+}
+
+func (p *Provider) Issuer() string {
+	return p.issuer
+}
+
+func (p *Provider) Metadata(ctx context.Context) (*Metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.meta != nil && time.Since(p.fetchedAt) < discoveryTTL {
+		return p.meta, nil
+	}
+	var meta Metadata
+	if err := p.getJSON(ctx, p.issuer+discoveryPath, &meta); err != nil {
+		if p.meta != nil {
+			return p.meta, nil
+		}
+		return nil, err
+	}
+	if strings.TrimSuffix(meta.Issuer, "/") != p.issuer {
+		return nil, fmt.Errorf("%w: issuer mismatch %q", ErrProviderError, meta.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: incomplete discovery document", ErrProviderError)
+	}
+	p.meta = &meta
+	p.fetchedAt = time.Now()
+	return p.meta, nil
+}
+
+func (p *Provider) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	meta, err := p.Metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	if p.keys != nil && time.Since(p.keysFetched) < keyRefreshInterval {
+		return nil, ErrUnknownKey
+	}
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.getJSON(ctx, meta.JWKSURI, &set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	p.keys = keys
+	p.keysFetched = time.Now()
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, ErrUnknownKey
+}
+
+func (p *Provider) lookupKey(kid string) (crypto.PublicKey, bool) {
+	if key, ok := p.keys[kid]; ok {
+		return key, true
+	}
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+func (p *Provider) Exchange(ctx context.Context, form url.Values) (*TokenResponse, error) {
+	meta, err := p.Metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxProviderBody))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: token endpoint status %d", ErrProviderError, resp.StatusCode)
+	}
+	var tokens TokenResponse
+	if err := json.Unmarshal(body, &tokens); err != nil {
+		return nil, err
+	}
+	return &tokens, nil
+}
+
+func (p *Provider) getJSON(ctx context.Context, target string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s status %d", ErrProviderError, target, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxProviderBody)).Decode(out)
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 {
+			return nil, errors.New("invalid rsa exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("ec point not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, errors.New("empty key parameter")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,423 @@
+package oidc
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultCallbackPath = "/oauth2/callback"
+	DefaultCookieName   = "_proxy_oidc"
+	DefaultSessionTTL   = time.Hour
+
+	SubjectHeader = "X-Auth-Subject"
+	EmailHeader   = "X-Auth-Email"
+	NameHeader    = "X-Auth-Name"
+
+	loginStateTTL = 10 * time.Minute
+)
+
+const (
+	ResultAuthenticated   = "authenticated"
+	ResultRefreshed       = "refreshed"
+	ResultLoginRedirect   = "login_redirect"
+	ResultCallback        = "callback"
+	ResultLogout          = "logout"
+	ResultUnauthenticated = "unauthenticated"
+	ResultInvalid         = "invalid"
+	ResultError           = "error"
+)
+
+var ErrCallback = errors.New("oidc callback rejected")
+
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	CallbackPath string
+	LogoutPath   string
+	Scopes       []string
+	CookieName   string
+	CookieSecret []byte
+	CookieSecure bool
+	SessionTTL   time.Duration
+}
+
+type RelyingParty struct {
+	provider     *Provider
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	callbackPath string
+	logoutPath   string
+	scopes       []string
+	cookieName   string
+	cookieSecure bool
+	sessionTTL   time.Duration
+	sealer       *sealer
+}
+
+func NewRelyingParty(cfg Config) (*RelyingParty, error) {
+	if cfg.Issuer == "" {
+		return nil, errors.New("issuer required")
+	}
+	issuer, err := url.Parse(cfg.Issuer)
+	if err != nil || (issuer.Scheme != "https" && issuer.Scheme != "http") || issuer.Host == "" {
+		return nil, fmt.Errorf("invalid issuer %q", cfg.Issuer)
+	}
+	if cfg.ClientID == "" {
+		return nil, errors.New("client_id required")
+	}
+	if len(cfg.CookieSecret) < 32 {
+		return nil, errors.New("cookie secret must be at least 32 bytes")
+	}
+	callbackPath := cfg.CallbackPath
+	if cfg.RedirectURL != "" {
+		redirect, err := url.Parse(cfg.RedirectURL)
+		if err != nil || !redirect.IsAbs() {
+			return nil, fmt.Errorf("invalid redirect_url %q", cfg.RedirectURL)
+		}
+		if callbackPath != "" && callbackPath != redirect.Path {
+			return nil, errors.New("callback_path must match redirect_url path")
+		}
+		callbackPath = redirect.Path
+	}
+	if callbackPath == "" {
+		callbackPath = DefaultCallbackPath
+	}
+	if !strings.HasPrefix(callbackPath, "/") {
+		return nil, fmt.Errorf("invalid callback_path %q", callbackPath)
+	}
+	if cfg.LogoutPath != "" && !strings.HasPrefix(cfg.LogoutPath, "/") {
+		return nil, fmt.Errorf("invalid logout_path %q", cfg.LogoutPath)
+	}
+	scopes := cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "email", "profile"}
+	}
+	hasOpenID := false
+	for _, scope := range scopes {
+		if scope == "openid" {
+			hasOpenID = true
+		}
+	}
+	if !hasOpenID {
+		scopes = append([]string{"openid"}, scopes...)
+	}
+	cookieName := cfg.CookieName
+	if cookieName == "" {
+		cookieName = DefaultCookieName
+	}
+	sessionTTL := cfg.SessionTTL
+	if sessionTTL <= 0 {
+		sessionTTL = DefaultSessionTTL
+	}
+	sealer, err := newSealer(cfg.CookieSecret)
+	if err != nil {
+		return nil, err
+	}
+	return &RelyingParty{
+		provider:     GetProvider(cfg.Issuer),
+		clientID:     cfg.ClientID,
+		clientSecret: cfg.ClientSecret,
+		redirectURL:  cfg.RedirectURL,
+		callbackPath: callbackPath,
+		logoutPath:   cfg.LogoutPath,
+		scopes:       scopes,
+		cookieName:   cookieName,
+		cookieSecure: cfg.CookieSecure,
+		sessionTTL:   sessionTTL,
+		sealer:       sealer,
+	}, nil
+}
+
+func (rp *RelyingParty) Handle(w http.ResponseWriter, r *http.Request) (*Session, string, error) {
+	switch {
+	case r.URL.Path == rp.callbackPath:
+		if err := rp.callback(w, r); err != nil {
+			if errors.Is(err, ErrCallback) || errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrUnknownKey) {
+				return nil, ResultInvalid, err
+			}
+			return nil, ResultError, err
+		}
+		return nil, ResultCallback, nil
+	case rp.logoutPath != "" && r.URL.Path == rp.logoutPath:
+		rp.clearCookie(w, r, rp.cookieName, "/")
+		http.Redirect(w, r, "/", http.StatusFound)
+		return nil, ResultLogout, nil
+	}
+
+	var session Session
+	if cookie, err := r.Cookie(rp.cookieName); err == nil {
+		if err := rp.sealer.open(rp.cookieName, cookie.Value, &session); err == nil && session.Subject != "" {
+			if time.Now().Before(time.UnixMilli(session.ExpiryMS)) {
+				return &session, ResultAuthenticated, nil
+			}
+			if session.RefreshToken != "" {
+				if refreshed, err := rp.refresh(r, session); err == nil {
+					if err := rp.setSession(w, r, refreshed); err == nil {
+						return refreshed, ResultRefreshed, nil
+					}
+				}
+			}
+		}
+		rp.clearCookie(w, r, rp.cookieName, "/")
+	}
+
+	if !isBrowserRequest(r) {
+		return nil, ResultUnauthenticated, nil
+	}
+	if err := rp.redirectToLogin(w, r); err != nil {
+		return nil, ResultError, err
+	}
+	return nil, ResultLoginRedirect, nil
+}
+
+func (rp *RelyingParty) StripCookies(r *http.Request) {
+	cookies := r.Cookies()
+	if len(cookies) == 0 {
+		return
+	}
+	r.Header.Del("Cookie")
+	for _, cookie := range cookies {
+		if cookie.Name == rp.cookieName || cookie.Name == rp.loginCookieName() {
+			continue
+		}
+		r.AddCookie(cookie)
+	}
+}
+
+func (rp *RelyingParty) redirectToLogin(w http.ResponseWriter, r *http.Request) error {
+	meta, err := rp.provider.Metadata(r.Context())
+	if err != nil {
+		return err
+	}
+	state, err := randomToken()
+	if err != nil {
+		return err
+	}
+	nonce, err := randomToken()
+	if err != nil {
+		return err
+	}
+	verifier, err := randomToken()
+	if err != nil {
+		return err
+	}
+	sealed, err := rp.sealer.seal(rp.loginCookieName(), loginState{
+		State:    state,
+		Nonce:    nonce,
+		Verifier: verifier,
+		ReturnTo: r.URL.RequestURI(),
+		Expiry:   time.Now().Add(loginStateTTL).Unix(),
+	})
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     rp.loginCookieName(),
+		Value:    sealed,
+		Path:     rp.callbackPath,
+		MaxAge:   int(loginStateTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   rp.secure(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	challenge := sha256.Sum256([]byte(verifier))
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", rp.clientID)
+	query.Set("redirect_uri", rp.redirectURI(r))
+	query.Set("scope", strings.Join(rp.scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+	target := meta.AuthorizationEndpoint
+	if strings.Contains(target, "?") {
+		target += "&" + query.Encode()
+	} else {
+		target += "?" + query.Encode()
+	}
+	http.Redirect(w, r, target, http.StatusFound)
+	return nil
+}
+
+func (rp *RelyingParty) callback(w http.ResponseWriter, r *http.Request) error {
+	cookie, err := r.Cookie(rp.loginCookieName())
+	if err != nil {
+		return fmt.Errorf("%w: missing login state", ErrCallback)
+	}
+	var login loginState
+	if err := rp.sealer.open(rp.loginCookieName(), cookie.Value, &login); err != nil {
+		return fmt.Errorf("%w: %v", ErrCallback, err)
+	}
+	rp.clearCookie(w, r, rp.loginCookieName(), rp.callbackPath)
+	if time.Now().After(time.Unix(login.Expiry, 0)) {
+		return fmt.Errorf("%w: login state expired", ErrCallback)
+	}
+	query := r.URL.Query()
+	if errCode := query.Get("error"); errCode != "" {
+		return fmt.Errorf("%w: provider returned %s", ErrCallback, errCode)
+	}
+	if subtle.ConstantTimeCompare([]byte(query.Get("state")), []byte(login.State)) != 1 {
+		return fmt.Errorf("%w: state mismatch", ErrCallback)
+	}
+	code := query.Get("code")
+	if code == "" {
+		return fmt.Errorf("%w: missing code", ErrCallback)
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", rp.redirectURI(r))
+	form.Set("code_verifier", login.Verifier)
+	tokens, err := rp.exchange(r, form)
+	if err != nil {
+		return err
+	}
+	if tokens.IDToken == "" {
+		return fmt.Errorf("%w: token response missing id_token", ErrCallback)
+	}
+	claims, err := rp.provider.VerifyIDToken(r.Context(), tokens.IDToken, rp.clientID, login.Nonce)
+	if err != nil {
+		return err
+	}
+	session := rp.sessionFromClaims(claims, tokens)
+	if err := rp.setSession(w, r, session); err != nil {
+		return err
+	}
+	returnTo := login.ReturnTo
+	if !strings.HasPrefix(returnTo, "/") || strings.HasPrefix(returnTo, "//") || returnTo == rp.callbackPath {
+		returnTo = "/"
+	}
+	http.Redirect(w, r, returnTo, http.StatusFound)
+	return nil
+}
+
+func (rp *RelyingParty) refresh(r *http.Request, session Session) (*Session, error) {
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", session.RefreshToken)
+	tokens, err := rp.exchange(r, form)
+	if err != nil {
+		return nil, err
+	}
+	if tokens.IDToken == "" {
+		refreshed := session
+		if tokens.RefreshToken != "" {
+			refreshed.RefreshToken = tokens.RefreshToken
+		}
+		refreshed.ExpiryMS = rp.expiry(0, tokens.ExpiresIn)
+		return &refreshed, nil
+	}
+	claims, err := rp.provider.VerifyIDToken(r.Context(), tokens.IDToken, rp.clientID, "")
+	if err != nil {
+		return nil, err
+	}
+	if claims.Subject != session.Subject {
+		return nil, fmt.Errorf("%w: subject changed on refresh", ErrInvalidToken)
+	}
+	if tokens.RefreshToken == "" {
+		tokens.RefreshToken = session.RefreshToken
+	}
+	return rp.sessionFromClaims(claims, tokens), nil
+}
+
+func (rp *RelyingParty) exchange(r *http.Request, form url.Values) (*TokenResponse, error) {
+	form.Set("client_id", rp.clientID)
+	if rp.clientSecret != "" {
+		form.Set("client_secret", rp.clientSecret)
+	}
+	return rp.provider.Exchange(r.Context(), form)
+}
+
+func (rp *RelyingParty) sessionFromClaims(claims *Claims, tokens *TokenResponse) *Session {
+	return &Session{
+		Subject:      claims.Subject,
+		Email:        claims.Email,
+		Name:         claims.Name,
+		ExpiryMS:     rp.expiry(claims.Expiry, tokens.ExpiresIn),
+		RefreshToken: tokens.RefreshToken,
+	}
+}
+
+func (rp *RelyingParty) expiry(tokenExpiry int64, expiresIn int64) int64 {
+	expiry := time.Now().Add(rp.sessionTTL)
+	if tokenExpiry > 0 && time.Unix(tokenExpiry, 0).Before(expiry) {
+		expiry = time.Unix(tokenExpiry, 0)
+	}
+	if expiresIn > 0 {
+		if byLifetime := time.Now().Add(time.Duration(expiresIn) * time.Second); byLifetime.Before(expiry) {
+			expiry = byLifetime
+		}
+	}
+	return expiry.UnixMilli()
+}
+
+func (rp *RelyingParty) setSession(w http.ResponseWriter, r *http.Request, session *Session) error {
+	sealed, err := rp.sealer.seal(rp.cookieName, session)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     rp.cookieName,
+		Value:    sealed,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   rp.secure(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+func (rp *RelyingParty) clearCookie(w http.ResponseWriter, r *http.Request, name string, path string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    "",
+		Path:     path,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   rp.secure(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func (rp *RelyingParty) redirectURI(r *http.Request) string {
+	if rp.redirectURL != "" {
+		return rp.redirectURL
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + rp.callbackPath
+}
+
+func (rp *RelyingParty) secure(r *http.Request) bool {
+	return rp.cookieSecure || r.TLS != nil
+}
+
+func (rp *RelyingParty) loginCookieName() string {
+	return rp.cookieName + "_login"
+}
+
+func isBrowserRequest(r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if r.Header.Get("X-Requested-With") != "" {
+		return false
+	}
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
@@ -0,0 +1,87 @@
+package oidc
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+var ErrInvalidCookie = errors.New("invalid oidc cookie")
+
+type Session struct {
+	Subject      string `json:"sub"`
+	Email        string `json:"email,omitempty"`
+	Name         string `json:"name,omitempty"`
+	ExpiryMS     int64  `json:"exp_ms"`
+	RefreshToken string `json:"rt,omitempty"`
+}
+
+type loginState struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	ReturnTo string `json:"return_to"`
+	Expiry   int64  `json:"exp"`
+}
+
+type sealer struct {
+	aead cipher.AEAD
+}
+
+func newSealer(secret []byte) (*sealer, error) {
+	key := sha256.Sum256(secret)
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &sealer{aead: aead}, nil
+}
+
+func (s *sealer) seal(name string, value interface{}) (string, error) {
+	plaintext, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := s.aead.Seal(nonce, nonce, plaintext, []byte(name))
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+func (s *sealer) open(name string, encoded string, out interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return ErrInvalidCookie
+	}
+	size := s.aead.NonceSize()
+	if len(data) < size {
+		return ErrInvalidCookie
+	}
+	plaintext, err := s.aead.Open(nil, data[:size], data[size:], []byte(name))
+	if err != nil {
+		return ErrInvalidCookie
+	}
+	if err := json.Unmarshal(plaintext, out); err != nil {
+		return ErrInvalidCookie
+	}
+	return nil
+}
+
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
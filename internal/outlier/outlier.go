@@ -20,6 +20,7 @@ type Config struct {
 	LatencyMinSamples           int
 	LatencyMultiplier           int
 	LatencyConsecutiveIntervals int
+	Shared                      bool
 }
 
 type EndpointState struct {
@@ -34,6 +35,13 @@ type EndpointState struct {
 	latencyWindow       *LatencyWindow
 }
 
+type EndpointStatus struct {
+	Ejected             bool
+	EjectUntil          time.Time
+	ConsecutiveFailures int
+	EjectCount          int
+}
+
 func NewEndpointState(cfg Config) *EndpointState {
 	state := &EndpointState{}
 	state.UpdateConfig(cfg)
@@ -56,6 +64,33 @@ func (e *EndpointState) IsEjected(now time.Time) bool {
 	return until > 0 && now.UnixNano() < until
 }
 
+func (e *EndpointState) Status(now time.Time) EndpointStatus {
+	status := EndpointStatus{
+		Ejected:             e.IsEjected(now),
+		ConsecutiveFailures: int(e.consecutiveFails.Load()),
+		EjectCount:          int(e.ejectCount.Load()),
+	}
+	if status.Ejected {
+		status.EjectUntil = time.Unix(0, e.ejectUntil.Load())
+	}
+	return status
+}
+
+func (e *EndpointState) Reset() {
+	e.ejectUntil.Store(0)
+	e.consecutiveFails.Store(0)
+	e.windowReq.Store(0)
+	e.windowFail.Store(0)
+	e.latencyBadIntervals.Store(0)
+}
+
+func (e *EndpointState) Restore(ejectUntil time.Time, ejectCount int, now time.Time) {
+	e.ejectUntil.Store(ejectUntil.UnixNano())
+	e.ejectCount.Store(int32(ejectCount))
+	e.lastEjectAt.Store(now.UnixNano())
+	e.consecutiveFails.Store(0)
+}
+
 func (e *EndpointState) RecordResult(cfg Config, success bool, now time.Time) (bool, string) {
 	if !cfg.Enabled {
 		return false, ""
@@ -16,6 +16,7 @@ type EjectionObserver func(poolKey string, reason string)
 type Registry struct {
 	mu           sync.Mutex
 	pools        map[string]*poolEntry
+	shared       map[string]*EndpointState
 	reapInterval time.Duration
 	ttl          time.Duration
 	stopCh       chan struct{}
@@ -41,6 +42,7 @@ func NewRegistry(reapInterval time.Duration, ttl time.Duration, observer Ejectio
 
 	registry := &Registry{
 		pools:        make(map[string]*poolEntry),
+		shared:       make(map[string]*EndpointState),
 		reapInterval: reapInterval,
 		ttl:          ttl,
 		stopCh:       make(chan struct{}),
@@ -68,17 +70,27 @@ func (r *Registry) Reconcile(poolKey string, endpoints []string, cfg Config) {
 	for _, addr := range endpoints {
 		desired[addr] = struct{}{}
 		state := entry.endpoints[addr]
-		if state == nil {
+		shared := state != nil && r.shared[addr] == state
+		switch {
+		case cfg.Shared && !shared:
+			state = r.shared[addr]
+			if state == nil {
+				state = NewEndpointState(cfg)
+				r.shared[addr] = state
+			}
+			entry.endpoints[addr] = state
+		case !cfg.Shared && (state == nil || shared):
 			entry.endpoints[addr] = NewEndpointState(cfg)
-		} else {
-			state.UpdateConfig(cfg)
+			continue
 		}
+		state.UpdateConfig(cfg)
 	}
 	for addr := range entry.endpoints {
 		if _, ok := desired[addr]; !ok {
 			delete(entry.endpoints, addr)
 		}
 	}
+	r.pruneSharedLocked()
 
 	if cfg.Enabled && cfg.LatencyEnabled {
 		interval := cfg.LatencyEvalInterval
@@ -133,6 +145,66 @@ func (r *Registry) IsEjected(poolKey string, addr string, now time.Time) bool {
 	return endpoint.IsEjected(now)
 }
 
+func (r *Registry) EndpointStatus(poolKey string, addr string, now time.Time) (EndpointStatus, bool) {
+	if r == nil || poolKey == "" || addr == "" {
+		return EndpointStatus{}, false
+	}
+	config, endpoint := r.endpoint(poolKey, addr)
+	if endpoint == nil || !config.Enabled {
+		return EndpointStatus{}, false
+	}
+	return endpoint.Status(now), true
+}
+
+func (r *Registry) Reset(poolKey string, addr string) bool {
+	if r == nil || poolKey == "" || addr == "" {
+		return false
+	}
+	_, endpoint := r.endpoint(poolKey, addr)
+	if endpoint == nil {
+		return false
+	}
+	endpoint.Reset()
+	return true
+}
+
+func (r *Registry) Ejections(now time.Time) map[string]map[string]EndpointStatus {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ejections := make(map[string]map[string]EndpointStatus)
+	for key, entry := range r.pools {
+		if !entry.config.Enabled {
+			continue
+		}
+		for addr, state := range entry.endpoints {
+			status := state.Status(now)
+			if !status.Ejected {
+				continue
+			}
+			if ejections[key] == nil {
+				ejections[key] = make(map[string]EndpointStatus)
+			}
+			ejections[key][addr] = status
+		}
+	}
+	return ejections
+}
+
+func (r *Registry) Restore(poolKey string, addr string, status EndpointStatus) bool {
+	if r == nil || poolKey == "" || addr == "" {
+		return false
+	}
+	_, endpoint := r.endpoint(poolKey, addr)
+	if endpoint == nil {
+		return false
+	}
+	endpoint.Restore(status.EjectUntil, status.EjectCount, time.Now())
+	return true
+}
+
 func (r *Registry) Close() {
 	if r == nil {
 		return
@@ -207,9 +279,25 @@ func (r *Registry) reapOnce() {
 			delete(r.pools, key)
 		}
 	}
+	r.pruneSharedLocked()
 	r.mu.Unlock()
 }
 
+func (r *Registry) pruneSharedLocked() {
+	for addr, state := range r.shared {
+		referenced := false
+		for _, entry := range r.pools {
+			if entry.endpoints[addr] == state {
+				referenced = true
+				break
+			}
+		}
+		if !referenced {
+			delete(r.shared, addr)
+		}
+	}
+}
+
 func (r *Registry) notify(poolKey string, reason string) {
 	if r == nil || r.observer == nil || reason == "" {
 		return
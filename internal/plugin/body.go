@@ -0,0 +1,124 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"modern_reverse_proxy/internal/plugin/proto"
+)
+
+const BodyChunkBytes = 16 * 1024
+
+var ErrNoVerdict = errors.New("plugin closed body stream without verdict")
+
+type BodyChunkMeta struct {
+	RequestID string
+	RouteID   string
+	Phase     pluginpb.BodyChunk_Phase
+}
+
+type BodyInspection struct {
+	Verdict   *pluginpb.BodyVerdict
+	Consumed  []byte
+	Truncated bool
+}
+
+type bodyInspector interface {
+	InspectBody(ctx context.Context) (pluginpb.FilterService_InspectBodyClient, error)
+}
+
+func InspectBody(ctx context.Context, client bodyInspector, meta BodyChunkMeta, body io.Reader, maxBytes int64) (*BodyInspection, error) {
+	result := &BodyInspection{}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := client.InspectBody(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	verdictCh := make(chan *pluginpb.BodyVerdict, 1)
+	recvErr := make(chan error, 1)
+	go func() {
+		verdict, err := stream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = ErrNoVerdict
+			}
+			recvErr <- err
+			return
+		}
+		verdictCh <- verdict
+	}()
+
+	send := func(data []byte, last bool) error {
+		return stream.Send(&pluginpb.BodyChunk{
+			RequestId: meta.RequestID,
+			RouteId:   meta.RouteID,
+			Phase:     meta.Phase,
+			Data:      data,
+			Last:      last,
+			Truncated: last && result.Truncated,
+		})
+	}
+
+	buf := make([]byte, BodyChunkBytes)
+	remaining := maxBytes
+	decided := false
+	sendFailed := false
+	for remaining > 0 && !decided && !sendFailed {
+		size := int64(len(buf))
+		if size > remaining {
+			size = remaining
+		}
+		n, readErr := body.Read(buf[:size])
+		if n > 0 {
+			chunk := append([]byte(nil), buf[:n]...)
+			result.Consumed = append(result.Consumed, chunk...)
+			remaining -= int64(n)
+			if err := send(chunk, false); err != nil {
+				sendFailed = true
+				break
+			}
+		}
+		if readErr != nil {
+			if !errors.Is(readErr, io.EOF) {
+				return result, readErr
+			}
+			break
+		}
+		select {
+		case verdict := <-verdictCh:
+			result.Verdict = verdict
+			decided = true
+		default:
+		}
+		if remaining == 0 {
+			var probe [1]byte
+			n, _ := io.ReadFull(body, probe[:])
+			if n > 0 {
+				result.Consumed = append(result.Consumed, probe[:n]...)
+				result.Truncated = true
+			}
+		}
+	}
+
+	if !decided && !sendFailed {
+		_ = send(nil, true)
+	}
+	_ = stream.CloseSend()
+	if result.Verdict != nil {
+		return result, nil
+	}
+
+	select {
+	case verdict := <-verdictCh:
+		result.Verdict = verdict
+		return result, nil
+	case err := <-recvErr:
+		return result, err
+	case <-ctx.Done():
+		return result, ctx.Err()
+	}
+}
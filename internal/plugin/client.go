@@ -31,6 +31,13 @@ func (c *Client) ApplyResponse(ctx context.Context, req *pluginpb.ApplyResponseR
 	return c.stub.ApplyResponse(ctx, req)
 }
 
+func (c *Client) InspectBody(ctx context.Context) (pluginpb.FilterService_InspectBodyClient, error) {
+	if c == nil {
+		return nil, grpc.ErrClientConnClosing
+	}
+	return c.stub.InspectBody(ctx)
+}
+
 func (c *Client) Close() error {
 	if c == nil || c.conn == nil {
 		return nil
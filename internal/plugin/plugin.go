@@ -22,11 +22,16 @@ type BreakerConfig struct {
 
 type Filter struct {
 	Name            string
+	Type            string
 	Addr            string
+	WASM            *WASMModule
 	RequestTimeout  time.Duration
 	ResponseTimeout time.Duration
 	FailureMode     FailureMode
 	Breaker         BreakerConfig
+	InspectBody     bool
+	MaxBodyBytes    int64
+	BodyTimeout     time.Duration
 }
 
 type Policy struct {
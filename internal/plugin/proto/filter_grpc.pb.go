@@ -21,6 +21,7 @@ const _ = grpc.SupportPackageIsVersion8
 const (
 	FilterService_ApplyRequest_FullMethodName  = "/pluginpb.FilterService/ApplyRequest"
 	FilterService_ApplyResponse_FullMethodName = "/pluginpb.FilterService/ApplyResponse"
+	FilterService_InspectBody_FullMethodName   = "/pluginpb.FilterService/InspectBody"
 )
 
 // FilterServiceClient is the client API for FilterService service.
@@ -29,6 +30,7 @@ const (
 type FilterServiceClient interface {
 	ApplyRequest(ctx context.Context, in *ApplyRequestRequest, opts ...grpc.CallOption) (*ApplyRequestResponse, error)
 	ApplyResponse(ctx context.Context, in *ApplyResponseRequest, opts ...grpc.CallOption) (*ApplyResponseResponse, error)
+	InspectBody(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[BodyChunk, BodyVerdict], error)
 }
 
 type filterServiceClient struct {
@@ -59,12 +61,26 @@ func (c *filterServiceClient) ApplyResponse(ctx context.Context, in *ApplyRespon
 	return out, nil
 }
 
+func (c *filterServiceClient) InspectBody(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[BodyChunk, BodyVerdict], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &FilterService_ServiceDesc.Streams[0], FilterService_InspectBody_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[BodyChunk, BodyVerdict]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FilterService_InspectBodyClient = grpc.BidiStreamingClient[BodyChunk, BodyVerdict]
+
 // FilterServiceServer is the server API for FilterService service.
 // All implementations must embed UnimplementedFilterServiceServer
 // for forward compatibility
 type FilterServiceServer interface {
 	ApplyRequest(context.Context, *ApplyRequestRequest) (*ApplyRequestResponse, error)
 	ApplyResponse(context.Context, *ApplyResponseRequest) (*ApplyResponseResponse, error)
+	InspectBody(grpc.BidiStreamingServer[BodyChunk, BodyVerdict]) error
 	mustEmbedUnimplementedFilterServiceServer()
 }
 
@@ -78,6 +94,9 @@ func (UnimplementedFilterServiceServer) ApplyRequest(context.Context, *ApplyRequ
 func (UnimplementedFilterServiceServer) ApplyResponse(context.Context, *ApplyResponseRequest) (*ApplyResponseResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ApplyResponse not implemented")
 }
+func (UnimplementedFilterServiceServer) InspectBody(grpc.BidiStreamingServer[BodyChunk, BodyVerdict]) error {
+	return status.Errorf(codes.Unimplemented, "method InspectBody not implemented")
+}
 func (UnimplementedFilterServiceServer) mustEmbedUnimplementedFilterServiceServer() {}
 
 // UnsafeFilterServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -127,6 +146,13 @@ func _FilterService_ApplyResponse_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _FilterService_InspectBody_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(FilterServiceServer).InspectBody(&grpc.GenericServerStream[BodyChunk, BodyVerdict]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FilterService_InspectBodyServer = grpc.BidiStreamingServer[BodyChunk, BodyVerdict]
+
 // FilterService_ServiceDesc is the grpc.ServiceDesc for FilterService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -143,6 +169,13 @@ var FilterService_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _FilterService_ApplyResponse_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "InspectBody",
+			Handler:       _FilterService_InspectBody_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
 	Metadata: "internal/plugin/proto/filter.proto",
 }
@@ -66,6 +66,98 @@ func (ApplyRequestResponse_Action) EnumDescriptor() ([]byte, []int) {
 	return file_internal_plugin_proto_filter_proto_rawDescGZIP(), []int{1, 0}
 }
 
+type BodyChunk_Phase int32
+
+const (
+	BodyChunk_REQUEST  BodyChunk_Phase = 0
+	BodyChunk_RESPONSE BodyChunk_Phase = 1
+)
+
+// Enum value maps for BodyChunk_Phase.
+var (
+	BodyChunk_Phase_name = map[int32]string{
+		0: "REQUEST",
+		1: "RESPONSE",
+	}
+	BodyChunk_Phase_value = map[string]int32{
+		"REQUEST":  0,
+		"RESPONSE": 1,
+	}
+)
+
+func (x BodyChunk_Phase) Enum() *BodyChunk_Phase {
+	p := new(BodyChunk_Phase)
+	*p = x
+	return p
+}
+
+func (x BodyChunk_Phase) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (BodyChunk_Phase) Descriptor() protoreflect.EnumDescriptor {
+	return file_internal_plugin_proto_filter_proto_enumTypes[1].Descriptor()
+}
+
+func (BodyChunk_Phase) Type() protoreflect.EnumType {
+	return &file_internal_plugin_proto_filter_proto_enumTypes[1]
+}
+
+func (x BodyChunk_Phase) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use BodyChunk_Phase.Descriptor instead.
+func (BodyChunk_Phase) EnumDescriptor() ([]byte, []int) {
+	return file_internal_plugin_proto_filter_proto_rawDescGZIP(), []int{4, 0}
+}
+
+type BodyVerdict_Action int32
+
+const (
+	BodyVerdict_CONTINUE BodyVerdict_Action = 0
+	BodyVerdict_RESPOND  BodyVerdict_Action = 1
+)
+
+// Enum value maps for BodyVerdict_Action.
+var (
+	BodyVerdict_Action_name = map[int32]string{
+		0: "CONTINUE",
+		1: "RESPOND",
+	}
+	BodyVerdict_Action_value = map[string]int32{
+		"CONTINUE": 0,
+		"RESPOND":  1,
+	}
+)
+
+func (x BodyVerdict_Action) Enum() *BodyVerdict_Action {
+	p := new(BodyVerdict_Action)
+	*p = x
+	return p
+}
+
+func (x BodyVerdict_Action) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (BodyVerdict_Action) Descriptor() protoreflect.EnumDescriptor {
+	return file_internal_plugin_proto_filter_proto_enumTypes[2].Descriptor()
+}
+
+func (BodyVerdict_Action) Type() protoreflect.EnumType {
+	return &file_internal_plugin_proto_filter_proto_enumTypes[2]
+}
+
+func (x BodyVerdict_Action) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use BodyVerdict_Action.Descriptor instead.
+func (BodyVerdict_Action) EnumDescriptor() ([]byte, []int) {
+	return file_internal_plugin_proto_filter_proto_rawDescGZIP(), []int{5, 0}
+}
+
 type ApplyRequestRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -358,6 +450,164 @@ func (x *ApplyResponseResponse) GetMutatedHeaders() map[string]string {
 	return nil
 }
 
+type BodyChunk struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RequestId string          `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	RouteId   string          `protobuf:"bytes,2,opt,name=route_id,json=routeId,proto3" json:"route_id,omitempty"`
+	Phase     BodyChunk_Phase `protobuf:"varint,3,opt,name=phase,proto3,enum=pluginpb.BodyChunk_Phase" json:"phase,omitempty"`
+	Data      []byte          `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
+	Last      bool            `protobuf:"varint,5,opt,name=last,proto3" json:"last,omitempty"`
+	Truncated bool            `protobuf:"varint,6,opt,name=truncated,proto3" json:"truncated,omitempty"`
+}
+
+func (x *BodyChunk) Reset() {
+	*x = BodyChunk{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_plugin_proto_filter_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BodyChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BodyChunk) ProtoMessage() {}
+
+func (x *BodyChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_plugin_proto_filter_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BodyChunk.ProtoReflect.Descriptor instead.
+func (*BodyChunk) Descriptor() ([]byte, []int) {
+	return file_internal_plugin_proto_filter_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BodyChunk) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *BodyChunk) GetRouteId() string {
+	if x != nil {
+		return x.RouteId
+	}
+	return ""
+}
+
+func (x *BodyChunk) GetPhase() BodyChunk_Phase {
+	if x != nil {
+		return x.Phase
+	}
+	return BodyChunk_REQUEST
+}
+
+func (x *BodyChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *BodyChunk) GetLast() bool {
+	if x != nil {
+		return x.Last
+	}
+	return false
+}
+
+func (x *BodyChunk) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+type BodyVerdict struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Action          BodyVerdict_Action `protobuf:"varint,1,opt,name=action,proto3,enum=pluginpb.BodyVerdict_Action" json:"action,omitempty"`
+	ResponseStatus  int32              `protobuf:"varint,2,opt,name=response_status,json=responseStatus,proto3" json:"response_status,omitempty"`
+	ResponseHeaders map[string]string  `protobuf:"bytes,3,rep,name=response_headers,json=responseHeaders,proto3" json:"response_headers,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	ResponseBody    []byte             `protobuf:"bytes,4,opt,name=response_body,json=responseBody,proto3" json:"response_body,omitempty"`
+}
+
+func (x *BodyVerdict) Reset() {
+	*x = BodyVerdict{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_plugin_proto_filter_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BodyVerdict) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BodyVerdict) ProtoMessage() {}
+
+func (x *BodyVerdict) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_plugin_proto_filter_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BodyVerdict.ProtoReflect.Descriptor instead.
+func (*BodyVerdict) Descriptor() ([]byte, []int) {
+	return file_internal_plugin_proto_filter_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *BodyVerdict) GetAction() BodyVerdict_Action {
+	if x != nil {
+		return x.Action
+	}
+	return BodyVerdict_CONTINUE
+}
+
+func (x *BodyVerdict) GetResponseStatus() int32 {
+	if x != nil {
+		return x.ResponseStatus
+	}
+	return 0
+}
+
+func (x *BodyVerdict) GetResponseHeaders() map[string]string {
+	if x != nil {
+		return x.ResponseHeaders
+	}
+	return nil
+}
+
+func (x *BodyVerdict) GetResponseBody() []byte {
+	if x != nil {
+		return x.ResponseBody
+	}
+	return nil
+}
+
 var File_internal_plugin_proto_filter_proto protoreflect.FileDescriptor
 
 var file_internal_plugin_proto_filter_proto_rawDesc = []byte{
@@ -445,23 +695,62 @@ var file_internal_plugin_proto_filter_proto_rawDesc = []byte{
 	0x4d, 0x75, 0x74, 0x61, 0x74, 0x65, 0x64, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x45, 0x6e,
 	0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
 	0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x32,
-	0xb0, 0x01, 0x0a, 0x0d, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63,
-	0x65, 0x12, 0x4d, 0x0a, 0x0c, 0x41, 0x70, 0x70, 0x6c, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x12, 0x1d, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x70, 0x62, 0x2e, 0x41, 0x70, 0x70,
-	0x6c, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x1a, 0x1e, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x70, 0x62, 0x2e, 0x41, 0x70, 0x70, 0x6c,
-	0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
-	0x12, 0x50, 0x0a, 0x0d, 0x41, 0x70, 0x70, 0x6c, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x12, 0x1e, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x70, 0x62, 0x2e, 0x41, 0x70, 0x70,
-	0x6c, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x1a, 0x1f, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x70, 0x62, 0x2e, 0x41, 0x70, 0x70,
-	0x6c, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
-	0x73, 0x65, 0x42, 0x35, 0x5a, 0x33, 0x6d, 0x6f, 0x64, 0x65, 0x72, 0x6e, 0x5f, 0x72, 0x65, 0x76,
-	0x65, 0x72, 0x73, 0x65, 0x5f, 0x70, 0x72, 0x6f, 0x78, 0x79, 0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72,
-	0x6e, 0x61, 0x6c, 0x2f, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x3b, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x33,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22,
+	0xe0, 0x01, 0x0a, 0x09, 0x42, 0x6f, 0x64, 0x79, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x12, 0x1d, 0x0a,
+	0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x12, 0x19, 0x0a, 0x08,
+	0x72, 0x6f, 0x75, 0x74, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
+	0x72, 0x6f, 0x75, 0x74, 0x65, 0x49, 0x64, 0x12, 0x2f, 0x0a, 0x05, 0x70, 0x68, 0x61, 0x73, 0x65,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x19, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x70,
+	0x62, 0x2e, 0x42, 0x6f, 0x64, 0x79, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x2e, 0x50, 0x68, 0x61, 0x73,
+	0x65, 0x52, 0x05, 0x70, 0x68, 0x61, 0x73, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x12, 0x0a, 0x04,
+	0x6c, 0x61, 0x73, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x6c, 0x61, 0x73, 0x74,
+	0x12, 0x1c, 0x0a, 0x09, 0x74, 0x72, 0x75, 0x6e, 0x63, 0x61, 0x74, 0x65, 0x64, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x09, 0x74, 0x72, 0x75, 0x6e, 0x63, 0x61, 0x74, 0x65, 0x64, 0x22, 0x22,
+	0x0a, 0x05, 0x50, 0x68, 0x61, 0x73, 0x65, 0x12, 0x0b, 0x0a, 0x07, 0x52, 0x45, 0x51, 0x55, 0x45,
+	0x53, 0x54, 0x10, 0x00, 0x12, 0x0c, 0x0a, 0x08, 0x52, 0x45, 0x53, 0x50, 0x4f, 0x4e, 0x53, 0x45,
+	0x10, 0x01, 0x22, 0xd1, 0x02, 0x0a, 0x0b, 0x42, 0x6f, 0x64, 0x79, 0x56, 0x65, 0x72, 0x64, 0x69,
+	0x63, 0x74, 0x12, 0x34, 0x0a, 0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0e, 0x32, 0x1c, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x70, 0x62, 0x2e, 0x42, 0x6f,
+	0x64, 0x79, 0x56, 0x65, 0x72, 0x64, 0x69, 0x63, 0x74, 0x2e, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+	0x52, 0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x27, 0x0a, 0x0f, 0x72, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x0e, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x12, 0x55, 0x0a, 0x10, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x5f, 0x68, 0x65,
+	0x61, 0x64, 0x65, 0x72, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x2a, 0x2e, 0x70, 0x6c,
+	0x75, 0x67, 0x69, 0x6e, 0x70, 0x62, 0x2e, 0x42, 0x6f, 0x64, 0x79, 0x56, 0x65, 0x72, 0x64, 0x69,
+	0x63, 0x74, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x48, 0x65, 0x61, 0x64, 0x65,
+	0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0f, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x12, 0x23, 0x0a, 0x0d, 0x72, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x5f, 0x62, 0x6f, 0x64, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x0c, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x6f, 0x64, 0x79, 0x1a, 0x42, 0x0a,
+	0x14, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38,
+	0x01, 0x22, 0x23, 0x0a, 0x06, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x0c, 0x0a, 0x08, 0x43,
+	0x4f, 0x4e, 0x54, 0x49, 0x4e, 0x55, 0x45, 0x10, 0x00, 0x12, 0x0b, 0x0a, 0x07, 0x52, 0x45, 0x53,
+	0x50, 0x4f, 0x4e, 0x44, 0x10, 0x01, 0x32, 0xef, 0x01, 0x0a, 0x0d, 0x46, 0x69, 0x6c, 0x74, 0x65,
+	0x72, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x4d, 0x0a, 0x0c, 0x41, 0x70, 0x70, 0x6c,
+	0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1d, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69,
+	0x6e, 0x70, 0x62, 0x2e, 0x41, 0x70, 0x70, 0x6c, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e,
+	0x70, 0x62, 0x2e, 0x41, 0x70, 0x70, 0x6c, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x50, 0x0a, 0x0d, 0x41, 0x70, 0x70, 0x6c, 0x79,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1e, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69,
+	0x6e, 0x70, 0x62, 0x2e, 0x41, 0x70, 0x70, 0x6c, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1f, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69,
+	0x6e, 0x70, 0x62, 0x2e, 0x41, 0x70, 0x70, 0x6c, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3d, 0x0a, 0x0b, 0x49, 0x6e, 0x73,
+	0x70, 0x65, 0x63, 0x74, 0x42, 0x6f, 0x64, 0x79, 0x12, 0x13, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69,
+	0x6e, 0x70, 0x62, 0x2e, 0x42, 0x6f, 0x64, 0x79, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x1a, 0x15, 0x2e,
+	0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x70, 0x62, 0x2e, 0x42, 0x6f, 0x64, 0x79, 0x56, 0x65, 0x72,
+	0x64, 0x69, 0x63, 0x74, 0x28, 0x01, 0x30, 0x01, 0x42, 0x35, 0x5a, 0x33, 0x6d, 0x6f, 0x64, 0x65,
+	0x72, 0x6e, 0x5f, 0x72, 0x65, 0x76, 0x65, 0x72, 0x73, 0x65, 0x5f, 0x70, 0x72, 0x6f, 0x78, 0x79,
+	0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e,
+	0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x3b, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x70, 0x62, 0x62,
+	0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -476,36 +765,46 @@ func file_internal_plugin_proto_filter_proto_rawDescGZIP() []byte {
 	return file_internal_plugin_proto_filter_proto_rawDescData
 }
 
-var file_internal_plugin_proto_filter_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_internal_plugin_proto_filter_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_internal_plugin_proto_filter_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_internal_plugin_proto_filter_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
 var file_internal_plugin_proto_filter_proto_goTypes = []any{
 	(ApplyRequestResponse_Action)(0), // 0: pluginpb.ApplyRequestResponse.Action
-	(*ApplyRequestRequest)(nil),      // 1: pluginpb.ApplyRequestRequest
-	(*ApplyRequestResponse)(nil),     // 2: pluginpb.ApplyRequestResponse
-	(*ApplyResponseRequest)(nil),     // 3: pluginpb.ApplyResponseRequest
-	(*ApplyResponseResponse)(nil),    // 4: pluginpb.ApplyResponseResponse
-	nil,                              // 5: pluginpb.ApplyRequestRequest.HeadersEntry
-	nil,                              // 6: pluginpb.ApplyRequestResponse.MutatedHeadersEntry
-	nil,                              // 7: pluginpb.ApplyRequestResponse.ResponseHeadersEntry
-	nil,                              // 8: pluginpb.ApplyResponseRequest.UpstreamHeadersEntry
-	nil,                              // 9: pluginpb.ApplyResponseResponse.MutatedHeadersEntry
+	(BodyChunk_Phase)(0),             // 1: pluginpb.BodyChunk.Phase
+	(BodyVerdict_Action)(0),          // 2: pluginpb.BodyVerdict.Action
+	(*ApplyRequestRequest)(nil),      // 3: pluginpb.ApplyRequestRequest
+	(*ApplyRequestResponse)(nil),     // 4: pluginpb.ApplyRequestResponse
+	(*ApplyResponseRequest)(nil),     // 5: pluginpb.ApplyResponseRequest
+	(*ApplyResponseResponse)(nil),    // 6: pluginpb.ApplyResponseResponse
+	(*BodyChunk)(nil),                // 7: pluginpb.BodyChunk
+	(*BodyVerdict)(nil),              // 8: pluginpb.BodyVerdict
+	nil,                              // 9: pluginpb.ApplyRequestRequest.HeadersEntry
+	nil,                              // 10: pluginpb.ApplyRequestResponse.MutatedHeadersEntry
+	nil,                              // 11: pluginpb.ApplyRequestResponse.ResponseHeadersEntry
+	nil,                              // 12: pluginpb.ApplyResponseRequest.UpstreamHeadersEntry
+	nil,                              // 13: pluginpb.ApplyResponseResponse.MutatedHeadersEntry
+	nil,                              // 14: pluginpb.BodyVerdict.ResponseHeadersEntry
 }
 var file_internal_plugin_proto_filter_proto_depIdxs = []int32{
-	5, // 0: pluginpb.ApplyRequestRequest.headers:type_name -> pluginpb.ApplyRequestRequest.HeadersEntry
-	0, // 1: pluginpb.ApplyRequestResponse.action:type_name -> pluginpb.ApplyRequestResponse.Action
-	6, // 2: pluginpb.ApplyRequestResponse.mutated_headers:type_name -> pluginpb.ApplyRequestResponse.MutatedHeadersEntry
-	7, // 3: pluginpb.ApplyRequestResponse.response_headers:type_name -> pluginpb.ApplyRequestResponse.ResponseHeadersEntry
-	8, // 4: pluginpb.ApplyResponseRequest.upstream_headers:type_name -> pluginpb.ApplyResponseRequest.UpstreamHeadersEntry
-	9, // 5: pluginpb.ApplyResponseResponse.mutated_headers:type_name -> pluginpb.ApplyResponseResponse.MutatedHeadersEntry
-	1, // 6: pluginpb.FilterService.ApplyRequest:input_type -> pluginpb.ApplyRequestRequest
-	3, // 7: pluginpb.FilterService.ApplyResponse:input_type -> pluginpb.ApplyResponseRequest
-	2, // 8: pluginpb.FilterService.ApplyRequest:output_type -> pluginpb.ApplyRequestResponse
-	4, // 9: pluginpb.FilterService.ApplyResponse:output_type -> pluginpb.ApplyResponseResponse
-	8, // [8:10] is the sub-list for method output_type
-	6, // [6:8] is the sub-list for method input_type
-	6, // [6:6] is the sub-list for extension type_name
-	6, // [6:6] is the sub-list for extension extendee
-	0, // [0:6] is the sub-list for field type_name
+	9,  // 0: pluginpb.ApplyRequestRequest.headers:type_name -> pluginpb.ApplyRequestRequest.HeadersEntry
+	0,  // 1: pluginpb.ApplyRequestResponse.action:type_name -> pluginpb.ApplyRequestResponse.Action
+	10, // 2: pluginpb.ApplyRequestResponse.mutated_headers:type_name -> pluginpb.ApplyRequestResponse.MutatedHeadersEntry
+	11, // 3: pluginpb.ApplyRequestResponse.response_headers:type_name -> pluginpb.ApplyRequestResponse.ResponseHeadersEntry
+	12, // 4: pluginpb.ApplyResponseRequest.upstream_headers:type_name -> pluginpb.ApplyResponseRequest.UpstreamHeadersEntry
+	13, // 5: pluginpb.ApplyResponseResponse.mutated_headers:type_name -> pluginpb.ApplyResponseResponse.MutatedHeadersEntry
+	1,  // 6: pluginpb.BodyChunk.phase:type_name -> pluginpb.BodyChunk.Phase
+	2,  // 7: pluginpb.BodyVerdict.action:type_name -> pluginpb.BodyVerdict.Action
+	14, // 8: pluginpb.BodyVerdict.response_headers:type_name -> pluginpb.BodyVerdict.ResponseHeadersEntry
+	3,  // 9: pluginpb.FilterService.ApplyRequest:input_type -> pluginpb.ApplyRequestRequest
+	5,  // 10: pluginpb.FilterService.ApplyResponse:input_type -> pluginpb.ApplyResponseRequest
+	7,  // 11: pluginpb.FilterService.InspectBody:input_type -> pluginpb.BodyChunk
+	4,  // 12: pluginpb.FilterService.ApplyRequest:output_type -> pluginpb.ApplyRequestResponse
+	6,  // 13: pluginpb.FilterService.ApplyResponse:output_type -> pluginpb.ApplyResponseResponse
+	8,  // 14: pluginpb.FilterService.InspectBody:output_type -> pluginpb.BodyVerdict
+	12, // [12:15] is the sub-list for method output_type
+	9,  // [9:12] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
 }
 
 func init() { file_internal_plugin_proto_filter_proto_init() }
@@ -562,14 +861,38 @@ func file_internal_plugin_proto_filter_proto_init() {
 				return nil
 			}
 		}
+		file_internal_plugin_proto_filter_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*BodyChunk); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_plugin_proto_filter_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*BodyVerdict); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_internal_plugin_proto_filter_proto_rawDesc,
-			NumEnums:      1,
-			NumMessages:   9,
+			NumEnums:      3,
+			NumMessages:   12,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
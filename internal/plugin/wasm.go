@@ -0,0 +1,251 @@
+package plugin
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+)
+
+const (
+	FilterTypeGRPC = "grpc"
+	FilterTypeWASM = "wasm"
+
+	DefaultWASMMemoryPages = 16
+	defaultWASMModuleTTL   = time.Minute
+	maxWASMOutputBytes     = 1 << 20
+)
+
+var (
+	ErrWASMExport = errors.New("wasm module missing export")
+	ErrWASMOutput = errors.New("wasm filter output out of range")
+)
+
+type WASMRequest struct {
+	RequestID string            `json:"request_id"`
+	RouteID   string            `json:"route_id"`
+	Method    string            `json:"method"`
+	Host      string            `json:"host"`
+	Path      string            `json:"path"`
+	Headers   map[string]string `json:"headers"`
+}
+
+type WASMRequestResult struct {
+	Action          string            `json:"action"`
+	MutatedHeaders  map[string]string `json:"mutated_headers"`
+	ResponseStatus  int               `json:"response_status"`
+	ResponseHeaders map[string]string `json:"response_headers"`
+	ResponseBody    string            `json:"response_body"`
+}
+
+type WASMResponse struct {
+	RequestID string            `json:"request_id"`
+	RouteID   string            `json:"route_id"`
+	Status    int               `json:"status"`
+	Headers   map[string]string `json:"headers"`
+}
+
+type WASMResponseResult struct {
+	MutatedHeaders map[string]string `json:"mutated_headers"`
+}
+
+type WASMModule struct {
+	Hash        string
+	runtime     wazero.Runtime
+	compiled    wazero.CompiledModule
+	hasResponse bool
+	loadedAt    time.Time
+	refs        int
+}
+
+var wasmModules = struct {
+	mu      sync.Mutex
+	modules map[string]*WASMModule
+	ttl     time.Duration
+}{modules: make(map[string]*WASMModule), ttl: defaultWASMModuleTTL}
+
+func LoadWASMModule(path string, memoryPages uint32) (*WASMModule, error) {
+	code, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return CompileWASMModule(code, memoryPages)
+}
+
+func CompileWASMModule(code []byte, memoryPages uint32) (*WASMModule, error) {
+	if memoryPages == 0 {
+		memoryPages = DefaultWASMMemoryPages
+	}
+	sum := sha256.Sum256(code)
+	key := fmt.Sprintf("%s:%d", hex.EncodeToString(sum[:]), memoryPages)
+
+	wasmModules.mu.Lock()
+	defer wasmModules.mu.Unlock()
+	if module, ok := wasmModules.modules[key]; ok {
+		module.loadedAt = time.Now()
+		return module, nil
+	}
+
+	ctx := context.Background()
+	runtime := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithCloseOnContextDone(true).
+		WithMemoryLimitPages(memoryPages))
+	compiled, err := runtime.CompileModule(ctx, code)
+	if err != nil {
+		_ = runtime.Close(ctx)
+		return nil, err
+	}
+	exports := compiled.ExportedFunctions()
+	for _, name := range []string{"alloc", "on_request"} {
+		if _, ok := exports[name]; !ok {
+			_ = runtime.Close(ctx)
+			return nil, fmt.Errorf("%w %q", ErrWASMExport, name)
+		}
+	}
+	if _, ok := compiled.ExportedMemories()["memory"]; !ok {
+		_ = runtime.Close(ctx)
+		return nil, fmt.Errorf("%w %q", ErrWASMExport, "memory")
+	}
+	_, hasResponse := exports["on_response"]
+	module := &WASMModule{
+		Hash:        hex.EncodeToString(sum[:]),
+		runtime:     runtime,
+		compiled:    compiled,
+		hasResponse: hasResponse,
+		loadedAt:    time.Now(),
+	}
+	wasmModules.modules[key] = module
+	return module, nil
+}
+
+func SetWASMModuleTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = defaultWASMModuleTTL
+	}
+	wasmModules.mu.Lock()
+	wasmModules.ttl = ttl
+	wasmModules.mu.Unlock()
+}
+
+func RetainWASMModules(modules map[*WASMModule]struct{}) {
+	wasmModules.mu.Lock()
+	for module := range modules {
+		module.refs++
+	}
+	wasmModules.mu.Unlock()
+}
+
+func ReleaseWASMModules(modules map[*WASMModule]struct{}) {
+	if len(modules) == 0 {
+		return
+	}
+	wasmModules.mu.Lock()
+	for module := range modules {
+		if module.refs > 0 {
+			module.refs--
+		}
+	}
+	wasmModules.mu.Unlock()
+	PruneWASMModules()
+}
+
+func PruneWASMModules() {
+	var stale []*WASMModule
+	wasmModules.mu.Lock()
+	cutoff := time.Now().Add(-wasmModules.ttl)
+	for key, module := range wasmModules.modules {
+		if module.refs > 0 || module.loadedAt.After(cutoff) {
+			continue
+		}
+		delete(wasmModules.modules, key)
+		stale = append(stale, module)
+	}
+	wasmModules.mu.Unlock()
+	for _, module := range stale {
+		_ = module.runtime.Close(context.Background())
+	}
+}
+
+func (m *WASMModule) HandlesResponse() bool {
+	return m != nil && m.hasResponse
+}
+
+func (m *WASMModule) ApplyRequest(ctx context.Context, req WASMRequest) (*WASMRequestResult, error) {
+	var result WASMRequestResult
+	if err := m.call(ctx, "on_request", req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (m *WASMModule) ApplyResponse(ctx context.Context, resp WASMResponse) (*WASMResponseResult, error) {
+	var result WASMResponseResult
+	if !m.HandlesResponse() {
+		return &result, nil
+	}
+	if err := m.call(ctx, "on_response", resp, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (m *WASMModule) call(ctx context.Context, fn string, input interface{}, output interface{}) error {
+	if m == nil {
+		return errors.New("wasm module missing")
+	}
+	payload, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	instance, err := m.runtime.InstantiateModule(ctx, m.compiled, wazero.NewModuleConfig().WithName(""))
+	if err != nil {
+		return wasmContextError(ctx, err)
+	}
+	defer instance.Close(context.Background())
+
+	allocated, err := instance.ExportedFunction("alloc").Call(ctx, uint64(len(payload)))
+	if err != nil {
+		return wasmContextError(ctx, err)
+	}
+	ptr := uint32(allocated[0])
+	memory := instance.ExportedMemory("memory")
+	if !memory.Write(ptr, payload) {
+		return ErrWASMOutput
+	}
+	packed, err := instance.ExportedFunction(fn).Call(ctx, uint64(ptr), uint64(len(payload)))
+	if err != nil {
+		return wasmContextError(ctx, err)
+	}
+	return readWASMOutput(memory, packed[0], output)
+}
+
+func readWASMOutput(memory api.Memory, packed uint64, output interface{}) error {
+	ptr := uint32(packed >> 32)
+	size := uint32(packed)
+	if size == 0 {
+		return nil
+	}
+	if size > maxWASMOutputBytes {
+		return ErrWASMOutput
+	}
+	data, ok := memory.Read(ptr, size)
+	if !ok {
+		return ErrWASMOutput
+	}
+	return json.Unmarshal(data, output)
+}
+
+func wasmContextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
@@ -1,9 +1,20 @@
 package policy
 
 import (
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
 	"time"
 
+	"modern_reverse_proxy/internal/bandwidth"
+	"modern_reverse_proxy/internal/fingerprint"
+	"modern_reverse_proxy/internal/keyauth"
+	"modern_reverse_proxy/internal/oidc"
 	"modern_reverse_proxy/internal/plugin"
+	"modern_reverse_proxy/internal/script"
+	"modern_reverse_proxy/internal/tlsstore"
 	"modern_reverse_proxy/internal/traffic"
 )
 
@@ -11,6 +22,8 @@ type Policy struct {
 	RequestTimeout                time.Duration
 	UpstreamDialTimeout           time.Duration
 	UpstreamResponseHeaderTimeout time.Duration
+	IdleTimeout                   time.Duration
+	ResponseStreamTimeout         time.Duration
 	Retry                         RetryPolicy
 	RetryBudget                   RetryBudgetPolicy
 	ClientRetryCap                ClientRetryCapPolicy
@@ -18,6 +31,28 @@ type Policy struct {
 	MTLSClientCA                  string
 	Cache                         CachePolicy
 	Plugins                       plugin.Policy
+	Compression                   CompressionPolicy
+	RequestDecompression          DecompressionPolicy
+	RequestBody                   RequestBodyPolicy
+	SSE                           SSEPolicy
+	TLSFingerprint                FingerprintPolicy
+	Bandwidth                     BandwidthPolicy
+	DebugUpstream                 DebugUpstreamPolicy
+	SnapshotSwap                  SnapshotSwapPolicy
+	Script                        ScriptPolicy
+	Auth                          AuthPolicy
+	Access                        AccessPolicy
+	MTLS                          MTLSPolicy
+	Mirror                        MirrorPolicy
+	Hedge                         HedgePolicy
+	Idempotency                   IdempotencyPolicy
+	ErrorPages                    []ErrorPage
+	CORS                          CORSPolicy
+	SecurityHeaders               SecurityHeadersPolicy
+	RequestRules                  []RequestRule
+	ServerTiming                  bool
+	SlowLogThreshold              time.Duration
+	AccessLog                     AccessLogPolicy
 }
 
 type RetryPolicy struct {
@@ -29,6 +64,28 @@ type RetryPolicy struct {
 	RetryOnErrors    map[string]bool
 	Backoff          time.Duration
 	BackoffJitter    time.Duration
+	StatusBackoff    map[int]time.Duration
+	RetryAfter       bool
+	RetryAfterCap    time.Duration
+	ExcludeAttempted bool
+	BufferBodyBytes  int64
+	NonIdempotent    bool
+}
+
+type AccessLogPolicy struct {
+	Disabled      bool
+	SampleRate    float64
+	ErrorsOnly    bool
+	SlowThreshold time.Duration
+	Fields        []string
+	Rename        map[string]string
+	Sink          io.Writer
+}
+
+type HedgePolicy struct {
+	Enabled   bool
+	Delay     time.Duration
+	MaxHedges int
 }
 
 type RetryBudgetPolicy struct {
@@ -54,6 +111,165 @@ type CachePolicy struct {
 	CoalesceEnabled     bool
 	CoalesceTimeout     time.Duration
 	OnlyIfContentLength bool
+	RespectCacheControl bool
+	RevalidateWindow    time.Duration
+	StatusTTLs          map[int]time.Duration
+	RangeCollapse       bool
+}
+
+type CompressionPolicy struct {
+	Enabled      bool
+	Algorithms   []string
+	MinSize      int64
+	ContentTypes []string
+}
+
+type DecompressionPolicy struct {
+	Enabled  bool
+	MaxRatio int
+}
+
+type FingerprintPolicy struct {
+	Allow   map[string]bool
+	Deny    map[string]bool
+	Limiter *fingerprint.Limiter
+}
+
+type RequestBodyPolicy struct {
+	Stream       bool
+	MaxBodyBytes int64
+	Multipart    MultipartPolicy
+}
+
+type MultipartPolicy struct {
+	Enabled             bool
+	MaxPartBytes        int64
+	MaxParts            int
+	AllowedContentTypes []string
+	StripDisallowed     bool
+}
+
+type SSEPolicy struct {
+	Enabled           bool
+	HeartbeatInterval time.Duration
+}
+
+type BandwidthPolicy struct {
+	Route   *bandwidth.Bucket
+	Clients *bandwidth.Limiter
+}
+
+type DebugUpstreamPolicy struct {
+	Enabled     bool
+	Header      string
+	TrustedNets []*net.IPNet
+	TokenSecret []byte
+}
+
+type SnapshotSwapPolicy struct {
+	Rematch      bool
+	RematchAfter time.Duration
+}
+
+type ScriptPolicy struct {
+	Program  *script.Program
+	FailOpen bool
+}
+
+type AuthPolicy struct {
+	Mode   string
+	OIDC   *oidc.RelyingParty
+	APIKey *keyauth.APIKeyVerifier
+	HMAC   *keyauth.HMACVerifier
+}
+
+type MTLSPolicy struct {
+	AllowedSANs      []string
+	AllowedSPIFFEIDs []string
+	IdentityHeader   string
+	Revocation       *tlsstore.RevocationChecker
+}
+
+type MirrorPolicy struct {
+	Enabled        bool
+	Pool           string
+	Percent        float64
+	IgnoreResponse bool
+	Timeout        time.Duration
+	MaxBodyBytes   int64
+	MaxInflight    int
+}
+
+type IdempotencyPolicy struct {
+	Enabled      bool
+	Header       string
+	ReplayTTL    time.Duration
+	MaxEntries   int
+	MaxBodyBytes int64
+}
+
+type ErrorPage struct {
+	Statuses map[int]bool
+	Format   string
+	Body     string
+}
+
+type CORSPolicy struct {
+	Enabled          bool
+	AllowAllOrigins  bool
+	AllowedOrigins   []string
+	AllowedMethods   map[string]bool
+	AllowMethods     string
+	AllowAllHeaders  bool
+	AllowedHeaders   map[string]bool
+	AllowHeaders     string
+	ExposeHeaders    string
+	AllowCredentials bool
+	MaxAge           string
+}
+
+type SecurityHeadersPolicy struct {
+	Headers http.Header
+	HSTS    string
+}
+
+type RequestIDPolicy struct {
+	Mode         string
+	TrustedCIDRs []*net.IPNet
+}
+
+type SlowLogPolicy struct {
+	Enabled   bool
+	Threshold time.Duration
+	Sink      io.Writer
+}
+
+type DebugTracePolicy struct {
+	Enabled     bool
+	Header      string
+	TokenSecret []byte
+}
+
+type RequestRule struct {
+	Name    string
+	Action  string
+	Methods map[string]bool
+	Path    *regexp.Regexp
+	Headers []HeaderMatcher
+	Status  int
+	Tarpit  time.Duration
+}
+
+type HeaderMatcher struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+type AccessPolicy struct {
+	Enabled        bool
+	Allow          []*net.IPNet
+	Deny           []*net.IPNet
+	TrustedProxies []*net.IPNet
 }
 
 type Route struct {
@@ -61,10 +277,51 @@ type Route struct {
 	Host           string
 	PathPrefix     string
 	Methods        map[string]bool
+	Listeners      map[string]bool
 	PoolName       string
 	CanaryPoolName string
 	StablePoolKey  string
 	CanaryPoolKey  string
 	TrafficPlan    *traffic.Plan
 	Policy         Policy
+	Labels         map[string]string
+}
+
+type Stream struct {
+	ID             string
+	Addr           string
+	Default        StreamTarget
+	SNIRoutes      []StreamSNIRoute
+	ConnectTimeout time.Duration
+	IdleTimeout    time.Duration
+}
+
+type StreamSNIRoute struct {
+	ServerName string
+	Target     StreamTarget
+}
+
+type StreamTarget struct {
+	PoolName   string
+	OutlierKey string
+}
+
+func (s Stream) Resolve(serverName string) (StreamTarget, bool) {
+	serverName = strings.ToLower(strings.TrimSuffix(serverName, "."))
+	if serverName != "" {
+		for _, route := range s.SNIRoutes {
+			if route.ServerName == serverName {
+				return route.Target, true
+			}
+		}
+		if dot := strings.IndexByte(serverName, '.'); dot > 0 {
+			wildcard := "*" + serverName[dot:]
+			for _, route := range s.SNIRoutes {
+				if route.ServerName == wildcard {
+					return route.Target, true
+				}
+			}
+		}
+	}
+	return s.Default, s.Default.PoolName != ""
 }
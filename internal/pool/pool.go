@@ -1,6 +1,8 @@
 package pool
 
 import (
+	"context"
+	"math/rand"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -21,6 +23,33 @@ const (
 	stateDraining
 )
 
+const (
+	overrideNone int32 = iota
+	overrideDrain
+	overrideEject
+)
+
+type DrainConfig struct {
+	Timeout   time.Duration
+	MaxBudget time.Duration
+}
+
+type SlowStartConfig struct {
+	Window           time.Duration
+	MinWeightPercent int
+}
+
+type LocalityConfig struct {
+	LocalZone              string
+	EndpointZones          map[string]string
+	MinLocalHealthyPercent int
+}
+
+type DrainCutoff struct {
+	Addr     string
+	Inflight int64
+}
+
 type PoolRuntime struct {
 	key          PoolKey
 	healthConfig health.Config
@@ -28,7 +57,9 @@ type PoolRuntime struct {
 	order        []string
 	rr           uint64
 	mu           sync.RWMutex
-	drainTimeout time.Duration
+	drain        DrainConfig
+	slowStart    SlowStartConfig
+	locality     LocalityConfig
 }
 
 type PickResult struct {
@@ -37,25 +68,31 @@ type PickResult struct {
 	SelectedFailOpen bool
 	OutlierIgnored   bool
 	EndpointEjected  bool
+	Zone             string
 }
 
-func NewPoolRuntime(key PoolKey, cfg health.Config, drainTimeout time.Duration) *PoolRuntime {
+func NewPoolRuntime(key PoolKey, cfg health.Config, drain DrainConfig, slowStart SlowStartConfig, locality LocalityConfig) *PoolRuntime {
 	return &PoolRuntime{
 		key:          key,
 		healthConfig: cfg,
 		endpoints:    make(map[string]*EndpointRuntime),
-		drainTimeout: drainTimeout,
+		drain:        drain,
+		slowStart:    slowStart,
+		locality:     locality,
 	}
 }
 
-func (p *PoolRuntime) Reconcile(endpoints []string, cfg health.Config, drainTimeout time.Duration) bool {
+func (p *PoolRuntime) Reconcile(endpoints []string, cfg health.Config, drain DrainConfig, slowStart SlowStartConfig, locality LocalityConfig) bool {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
 	p.healthConfig = cfg
-	p.drainTimeout = drainTimeout
+	p.drain = drain
+	p.slowStart = slowStart
+	p.locality = locality
 	p.order = append(p.order[:0], endpoints...)
 	desired := make(map[string]struct{}, len(endpoints))
+	established := len(p.endpoints) > 0
 
 	for _, addr := range endpoints {
 		desired[addr] = struct{}{}
@@ -64,7 +101,11 @@ func (p *PoolRuntime) Reconcile(endpoints []string, cfg health.Config, drainTime
 			endpoint.Restore()
 			continue
 		}
-		p.endpoints[addr] = NewEndpointRuntime(addr, cfg)
+		endpoint := NewEndpointRuntime(addr, cfg)
+		if established {
+			endpoint.startSlowStart()
+		}
+		p.endpoints[addr] = endpoint
 	}
 
 	removed := false
@@ -72,18 +113,24 @@ func (p *PoolRuntime) Reconcile(endpoints []string, cfg health.Config, drainTime
 		if _, ok := desired[addr]; ok {
 			continue
 		}
-		endpoint.MarkDraining(drainTimeout)
+		endpoint.MarkDraining(drain)
 		removed = true
 	}
 	return removed
 }
 
 func (p *PoolRuntime) Pick(outlierEjected func(addr string, now time.Time) bool) PickResult {
+	return p.PickExcluding(outlierEjected, nil)
+}
+
+func (p *PoolRuntime) PickExcluding(outlierEjected func(addr string, now time.Time) bool, exclude map[string]bool) PickResult {
 	p.mu.RLock()
 	if len(p.endpoints) == 0 {
 		p.mu.RUnlock()
 		return PickResult{}
 	}
+	slowStart := p.slowStart
+	locality := p.locality
 
 	all := make([]*EndpointRuntime, 0, len(p.endpoints))
 	for _, addr := range p.order {
@@ -98,6 +145,18 @@ func (p *PoolRuntime) Pick(outlierEjected func(addr string, now time.Time) bool)
 	}
 	p.mu.RUnlock()
 
+	if len(exclude) > 0 {
+		remaining := make([]*EndpointRuntime, 0, len(all))
+		for _, endpoint := range all {
+			if !exclude[endpoint.addr] {
+				remaining = append(remaining, endpoint)
+			}
+		}
+		if len(remaining) > 0 {
+			all = remaining
+		}
+	}
+
 	now := time.Now()
 	eligible := make([]*EndpointRuntime, 0, len(all))
 	nonDraining := make([]*EndpointRuntime, 0, len(all))
@@ -108,22 +167,24 @@ func (p *PoolRuntime) Pick(outlierEjected func(addr string, now time.Time) bool)
 		if outlierEjected != nil {
 			isOutlierEjected = outlierEjected(endpoint.addr, now)
 		}
-		if !endpoint.IsDraining() {
+		draining := endpoint.IsDraining() || endpoint.override.Load() == overrideDrain
+		if !draining {
 			nonDraining = append(nonDraining, endpoint)
 		}
 		isHealthy := endpoint.IsHealthy() && !endpoint.IsEjected(now)
-		if endpoint.IsHealthy() && !endpoint.IsDraining() && !endpoint.IsEjected(now) && !isOutlierEjected {
+		if isHealthy && !draining && !isOutlierEjected {
 			eligible = append(eligible, endpoint)
-		} else if isHealthy && !endpoint.IsDraining() && isOutlierEjected {
+		} else if isHealthy && !draining && isOutlierEjected {
 			outlierSuppressed = true
 		}
 	}
 
 	if len(eligible) > 0 {
-		picked := p.pickFrom(eligible)
+		picked := p.pickSlowStart(localityCandidates(eligible, nonDraining, locality), slowStart, now)
 		return PickResult{
 			Addr:            picked.addr,
 			SelectedHealthy: true,
+			Zone:            locality.EndpointZones[picked.addr],
 		}
 	}
 	if len(nonDraining) > 0 {
@@ -137,6 +198,7 @@ func (p *PoolRuntime) Pick(outlierEjected func(addr string, now time.Time) bool)
 			SelectedFailOpen: true,
 			OutlierIgnored:   outlierSuppressed,
 			EndpointEjected:  endpointEjected,
+			Zone:             locality.EndpointZones[picked.addr],
 		}
 	}
 	picked := p.pickFrom(all)
@@ -149,7 +211,41 @@ func (p *PoolRuntime) Pick(outlierEjected func(addr string, now time.Time) bool)
 		SelectedFailOpen: true,
 		OutlierIgnored:   outlierSuppressed,
 		EndpointEjected:  endpointEjected,
+		Zone:             locality.EndpointZones[picked.addr],
+	}
+}
+
+func localityCandidates(eligible []*EndpointRuntime, nonDraining []*EndpointRuntime, locality LocalityConfig) []*EndpointRuntime {
+	if locality.LocalZone == "" {
+		return eligible
+	}
+	localTotal := 0
+	for _, endpoint := range nonDraining {
+		if locality.EndpointZones[endpoint.addr] == locality.LocalZone {
+			localTotal++
+		}
+	}
+	if localTotal == 0 {
+		return eligible
+	}
+	local := make([]*EndpointRuntime, 0, len(eligible))
+	remote := make([]*EndpointRuntime, 0, len(eligible))
+	for _, endpoint := range eligible {
+		if locality.EndpointZones[endpoint.addr] == locality.LocalZone {
+			local = append(local, endpoint)
+		} else {
+			remote = append(remote, endpoint)
+		}
 	}
+	if len(local) == 0 || len(remote) == 0 {
+		return eligible
+	}
+	healthyPercent := float64(len(local)) * 100 / float64(localTotal)
+	threshold := float64(locality.MinLocalHealthyPercent)
+	if threshold <= 0 || healthyPercent >= threshold || rand.Float64() < healthyPercent/threshold {
+		return local
+	}
+	return remote
 }
 
 func (p *PoolRuntime) pickFrom(endpoints []*EndpointRuntime) *EndpointRuntime {
@@ -159,25 +255,91 @@ func (p *PoolRuntime) pickFrom(endpoints []*EndpointRuntime) *EndpointRuntime {
 	return endpoint
 }
 
+func (p *PoolRuntime) pickSlowStart(endpoints []*EndpointRuntime, slowStart SlowStartConfig, now time.Time) *EndpointRuntime {
+	if slowStart.Window <= 0 {
+		return p.pickFrom(endpoints)
+	}
+	weights := make([]float64, len(endpoints))
+	total := 0.0
+	ramping := false
+	for i, endpoint := range endpoints {
+		weights[i] = endpoint.slowStartWeight(slowStart, now)
+		ramping = ramping || weights[i] < 1
+		total += weights[i]
+	}
+	if !ramping {
+		return p.pickFrom(endpoints)
+	}
+	target := rand.Float64() * total
+	picked := endpoints[len(endpoints)-1]
+	for i, endpoint := range endpoints {
+		target -= weights[i]
+		if target < 0 {
+			picked = endpoint
+			break
+		}
+	}
+	picked.MarkSeen()
+	return picked
+}
+
 func (p *PoolRuntime) Endpoint(addr string) *EndpointRuntime {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 	return p.endpoints[addr]
 }
 
-func (p *PoolRuntime) Reap(now time.Time) []string {
+func (p *PoolRuntime) Endpoints() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return append([]string(nil), p.order...)
+}
+
+func (p *PoolRuntime) Status(now time.Time) []EndpointStatus {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	statuses := make([]EndpointStatus, 0, len(p.endpoints))
+	seen := make(map[string]struct{}, len(p.endpoints))
+	for _, addr := range p.order {
+		if endpoint := p.endpoints[addr]; endpoint != nil {
+			statuses = append(statuses, endpoint.Status(now))
+			seen[addr] = struct{}{}
+		}
+	}
+	for addr, endpoint := range p.endpoints {
+		if _, ok := seen[addr]; !ok {
+			statuses = append(statuses, endpoint.Status(now))
+		}
+	}
+	return statuses
+}
+
+func (p *PoolRuntime) Reap(now time.Time) ([]string, []DrainCutoff) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
 	removed := []string{}
+	var cutoffs []DrainCutoff
 	for addr, endpoint := range p.endpoints {
-		if endpoint.IsDraining() && endpoint.DrainDeadlineReached(now) && endpoint.Inflight() == 0 {
+		if !endpoint.IsDraining() {
+			continue
+		}
+		inflight := endpoint.Inflight()
+		if endpoint.DrainDeadlineReached(now) && inflight <= 0 {
 			endpoint.Stop()
 			delete(p.endpoints, addr)
 			removed = append(removed, addr)
+			continue
+		}
+		if endpoint.DrainBudgetExceeded(now) {
+			endpoint.CutOff()
+			endpoint.Stop()
+			delete(p.endpoints, addr)
+			removed = append(removed, addr)
+			cutoffs = append(cutoffs, DrainCutoff{Addr: addr, Inflight: inflight})
 		}
 	}
-	return removed
+	return removed, cutoffs
 }
 
 func (p *PoolRuntime) Stop() {
@@ -198,6 +360,18 @@ func (p *PoolRuntime) Stop() {
 	}
 }
 
+type EndpointStatus struct {
+	Addr                       string
+	State                      string
+	Ejected                    bool
+	EjectUntil                 time.Time
+	Inflight                   int64
+	ConsecutiveActiveFailures  int
+	ConsecutivePassiveFailures int
+	ConsecutiveStatusFailures  int
+	Override                   string
+}
+
 type EndpointRuntime struct {
 	addr                     string
 	state                    atomic.Int32
@@ -205,11 +379,17 @@ type EndpointRuntime struct {
 	consecutiveActiveFails   atomic.Int32
 	consecutiveActiveSuccess atomic.Int32
 	consecutivePassiveFails  atomic.Int32
+	consecutiveStatusFails   atomic.Int32
 	inflight                 atomic.Int64
 	ejectCount               atomic.Int32
 	lastHealthyAt            atomic.Int64
 	lastSeen                 atomic.Int64
 	drainUntil               atomic.Int64
+	drainBudgetUntil         atomic.Int64
+	override                 atomic.Int32
+	slowStartAt              atomic.Int64
+	drainCtx                 context.Context
+	drainCancel              context.CancelFunc
 	config                   atomic.Value
 	activeMu                 sync.Mutex
 	stopCh                   chan struct{}
@@ -218,6 +398,7 @@ type EndpointRuntime struct {
 
 func NewEndpointRuntime(addr string, cfg health.Config) *EndpointRuntime {
 	endpoint := &EndpointRuntime{addr: addr}
+	endpoint.drainCtx, endpoint.drainCancel = context.WithCancel(context.Background())
 	endpoint.state.Store(stateHealthy)
 	endpoint.config.Store(cfg)
 	endpoint.startActive(cfg)
@@ -232,7 +413,9 @@ func (e *EndpointRuntime) UpdateConfig(cfg health.Config) {
 func (e *EndpointRuntime) Restore() {
 	if e.state.Load() == stateDraining {
 		e.state.Store(stateHealthy)
+		e.startSlowStart()
 		e.drainUntil.Store(0)
+		e.drainBudgetUntil.Store(0)
 		cfg := e.config.Load().(health.Config)
 		e.restartActive(cfg)
 	}
@@ -242,12 +425,22 @@ func (e *EndpointRuntime) Address() string {
 	return e.addr
 }
 
-func (e *EndpointRuntime) MarkDraining(timeout time.Duration) {
+func (e *EndpointRuntime) MarkDraining(drain DrainConfig) {
 	if e.state.Load() == stateDraining {
 		return
 	}
+	now := time.Now()
 	e.state.Store(stateDraining)
-	e.drainUntil.Store(time.Now().Add(timeout).UnixNano())
+	e.drainUntil.Store(now.Add(drain.Timeout).UnixNano())
+	if drain.MaxBudget > 0 {
+		budget := drain.MaxBudget
+		if budget < drain.Timeout {
+			budget = drain.Timeout
+		}
+		e.drainBudgetUntil.Store(now.Add(budget).UnixNano())
+	} else {
+		e.drainBudgetUntil.Store(0)
+	}
 	e.stopActive()
 }
 
@@ -260,15 +453,77 @@ func (e *EndpointRuntime) DrainDeadlineReached(now time.Time) bool {
 	return deadline > 0 && now.UnixNano() >= deadline
 }
 
+func (e *EndpointRuntime) DrainBudgetExceeded(now time.Time) bool {
+	deadline := e.drainBudgetUntil.Load()
+	return deadline > 0 && now.UnixNano() >= deadline
+}
+
+func (e *EndpointRuntime) DrainContext() context.Context {
+	return e.drainCtx
+}
+
+func (e *EndpointRuntime) CutOff() {
+	e.drainCancel()
+}
+
 func (e *EndpointRuntime) IsHealthy() bool {
 	return e.state.Load() == stateHealthy
 }
 
 func (e *EndpointRuntime) IsEjected(now time.Time) bool {
+	if e.override.Load() == overrideEject {
+		return true
+	}
 	until := e.ejectUntil.Load()
 	return until > 0 && now.UnixNano() < until
 }
 
+func (e *EndpointRuntime) Status(now time.Time) EndpointStatus {
+	status := EndpointStatus{
+		Addr:                       e.addr,
+		State:                      "healthy",
+		Ejected:                    e.IsEjected(now),
+		Inflight:                   e.Inflight(),
+		ConsecutiveActiveFailures:  int(e.consecutiveActiveFails.Load()),
+		ConsecutivePassiveFailures: int(e.consecutivePassiveFails.Load()),
+		ConsecutiveStatusFailures:  int(e.consecutiveStatusFails.Load()),
+	}
+	switch e.state.Load() {
+	case stateUnhealthy:
+		status.State = "unhealthy"
+	case stateDraining:
+		status.State = "draining"
+	}
+	switch e.override.Load() {
+	case overrideDrain:
+		status.State = "draining"
+		status.Override = "drain"
+	case overrideEject:
+		status.Override = "eject"
+	}
+	if until := e.ejectUntil.Load(); until > now.UnixNano() {
+		status.EjectUntil = time.Unix(0, until)
+	}
+	return status
+}
+
+func (e *EndpointRuntime) ManualDrain() {
+	e.override.Store(overrideDrain)
+}
+
+func (e *EndpointRuntime) ManualEject() {
+	e.override.Store(overrideEject)
+}
+
+func (e *EndpointRuntime) ManualRestore() {
+	if e.override.Swap(overrideNone) != overrideNone {
+		e.startSlowStart()
+	}
+	if !e.IsDraining() {
+		e.markHealthy()
+	}
+}
+
 func (e *EndpointRuntime) MarkSeen() {
 	e.lastSeen.Store(time.Now().UnixNano())
 }
@@ -312,6 +567,20 @@ func (e *EndpointRuntime) RecordPassiveFailure() {
 	}
 }
 
+func (e *EndpointRuntime) RecordPassiveStatus(code int) {
+	if e.IsDraining() {
+		return
+	}
+	cfg := e.config.Load().(health.Config)
+	if !cfg.PassiveStatusFailure(code) {
+		e.consecutiveStatusFails.Store(0)
+		return
+	}
+	if cfg.UnhealthyAfterStatusFailures > 0 && int(e.consecutiveStatusFails.Add(1)) >= cfg.UnhealthyAfterStatusFailures {
+		e.eject(cfg)
+	}
+}
+
 func (e *EndpointRuntime) InflightInc() {
 	e.inflight.Add(1)
 }
@@ -329,13 +598,36 @@ func (e *EndpointRuntime) Stop() {
 }
 
 func (e *EndpointRuntime) markHealthy() {
-	e.state.Store(stateHealthy)
+	if e.state.Swap(stateHealthy) != stateHealthy {
+		e.startSlowStart()
+	}
 	e.ejectUntil.Store(0)
 	e.consecutivePassiveFails.Store(0)
+	e.consecutiveStatusFails.Store(0)
 	e.consecutiveActiveFails.Store(0)
 	e.lastHealthyAt.Store(time.Now().UnixNano())
 }
 
+func (e *EndpointRuntime) startSlowStart() {
+	e.slowStartAt.Store(time.Now().UnixNano())
+}
+
+func (e *EndpointRuntime) slowStartWeight(slowStart SlowStartConfig, now time.Time) float64 {
+	since := e.slowStartAt.Load()
+	if since == 0 {
+		return 1
+	}
+	elapsed := now.Sub(time.Unix(0, since))
+	if elapsed >= slowStart.Window {
+		return 1
+	}
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	floor := float64(slowStart.MinWeightPercent) / 100
+	return floor + (1-floor)*float64(elapsed)/float64(slowStart.Window)
+}
+
 func (e *EndpointRuntime) eject(cfg health.Config) {
 	e.state.Store(stateUnhealthy)
 	now := time.Now()
@@ -0,0 +1,230 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"modern_reverse_proxy/internal/config"
+)
+
+type Directory struct {
+	Path string
+}
+
+func NewDirectoryProvider(path string) *Directory {
+	return &Directory{Path: path}
+}
+
+func (d *Directory) Name() string {
+	return "dir"
+}
+
+func (d *Directory) Priority() int {
+	return FilePriority
+}
+
+func (d *Directory) Load(ctx context.Context) (*config.Config, error) {
+	if d == nil || d.Path == "" {
+		return nil, nil
+	}
+	info, err := os.Stat(d.Path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("config dir %s is not a directory", d.Path)
+	}
+	files, err := configFiles(d.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	loader := &includeLoader{root: d.Path, loaded: make(map[string]bool)}
+	for _, file := range files {
+		if err := loader.load(file, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	providers := make([]Provider, 0, len(loader.files))
+	for _, file := range loader.files {
+		providers = append(providers, file)
+	}
+	merged, err := Merge(ctx, providers)
+	if err != nil {
+		return nil, err
+	}
+	if err := mergeGlobals(merged, loader.files); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+type includeLoader struct {
+	root   string
+	loaded map[string]bool
+	files  []*dirFile
+}
+
+func (l *includeLoader) load(path string, stack []string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	for _, seen := range stack {
+		if seen == abs {
+			return fmt.Errorf("include cycle: %s", strings.Join(append(l.relAll(stack), l.rel(abs)), " -> "))
+		}
+	}
+	if l.loaded[abs] {
+		return nil
+	}
+
+	data, err := os.ReadFile(abs)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.ParseFile(abs, data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", l.rel(abs), err)
+	}
+
+	stack = append(stack, abs)
+	for _, pattern := range cfg.Include {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(filepath.Dir(abs), pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return fmt.Errorf("%s: include %q: %w", l.rel(abs), pattern, err)
+		}
+		if len(matches) == 0 && !hasGlobMeta(pattern) {
+			return fmt.Errorf("%s: include %q: file not found", l.rel(abs), l.rel(pattern))
+		}
+		sort.Strings(matches)
+		for _, match := range matches {
+			if err := l.load(match, stack); err != nil {
+				return err
+			}
+		}
+	}
+
+	l.loaded[abs] = true
+	l.files = append(l.files, &dirFile{name: "dir:" + l.rel(abs), cfg: cfg})
+	return nil
+}
+
+func (l *includeLoader) rel(path string) string {
+	root, err := filepath.Abs(l.root)
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+func (l *includeLoader) relAll(paths []string) []string {
+	out := make([]string, 0, len(paths))
+	for _, path := range paths {
+		out = append(out, l.rel(path))
+	}
+	return out
+}
+
+func configFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		if strings.EqualFold(filepath.Ext(name), ".json") || config.IsYAMLPath(name) {
+			files = append(files, filepath.Join(dir, name))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func hasGlobMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+type dirFile struct {
+	name string
+	cfg  *config.Config
+}
+
+func (f *dirFile) Name() string {
+	return f.name
+}
+
+func (f *dirFile) Priority() int {
+	return FilePriority
+}
+
+func (f *dirFile) Load(ctx context.Context) (*config.Config, error) {
+	_ = ctx
+	return f.cfg, nil
+}
+
+func mergeGlobals(result *config.Config, files []*dirFile) error {
+	sections := []struct {
+		name  string
+		value func(*config.Config) interface{}
+		set   func(*config.Config, interface{})
+	}{
+		{"limits", func(c *config.Config) interface{} { return c.Limits }, func(c *config.Config, v interface{}) { c.Limits = v.(config.LimitsConfig) }},
+		{"shutdown", func(c *config.Config) interface{} { return c.Shutdown }, func(c *config.Config, v interface{}) { c.Shutdown = v.(config.ShutdownConfig) }},
+		{"state_persistence", func(c *config.Config) interface{} { return c.StatePersistence }, func(c *config.Config, v interface{}) { c.StatePersistence = v.(config.StatePersistenceConfig) }},
+		{"logging", func(c *config.Config) interface{} { return c.Logging }, func(c *config.Config, v interface{}) { c.Logging = v.(config.LoggingConfig) }},
+		{"metrics", func(c *config.Config) interface{} { return c.Metrics }, func(c *config.Config, v interface{}) { c.Metrics = v.(*config.MetricsConfig) }},
+		{"cache", func(c *config.Config) interface{} { return c.Cache }, func(c *config.Config, v interface{}) { c.Cache = v.(config.CacheStoreConfig) }},
+		{"metadata", func(c *config.Config) interface{} { return c.Metadata }, func(c *config.Config, v interface{}) { c.Metadata = v.(config.MetadataConfig) }},
+		{"request_id", func(c *config.Config) interface{} { return c.RequestID }, func(c *config.Config, v interface{}) { c.RequestID = v.(config.RequestIDConfig) }},
+		{"debug_trace", func(c *config.Config) interface{} { return c.DebugTrace }, func(c *config.Config, v interface{}) { c.DebugTrace = v.(config.DebugTraceConfig) }},
+		{"slow_log", func(c *config.Config) interface{} { return c.SlowLog }, func(c *config.Config, v interface{}) { c.SlowLog = v.(config.SlowLogConfig) }},
+		{"request_rules", func(c *config.Config) interface{} { return c.RequestRules }, func(c *config.Config, v interface{}) { c.RequestRules = v.([]config.RequestRuleConfig) }},
+	}
+
+	for _, section := range sections {
+		owner := ""
+		var current interface{}
+		for _, file := range files {
+			value := section.value(file.cfg)
+			if reflect.ValueOf(value).IsZero() {
+				continue
+			}
+			if owner == "" {
+				owner = file.name
+				current = value
+				continue
+			}
+			if !reflect.DeepEqual(current, value) {
+				return &ConflictError{
+					ObjectType:       "config",
+					ObjectID:         section.name,
+					Field:            section.name,
+					ExistingProvider: owner,
+					IncomingProvider: file.name,
+				}
+			}
+		}
+		if owner != "" {
+			section.set(result, current)
+		}
+	}
+	return nil
+}
@@ -34,5 +34,5 @@ func (f *File) Load(ctx context.Context) (*config.Config, error) {
 	if err != nil {
 		return nil, err
 	}
-	return config.ParseJSON(data)
+	return config.ParseFile(f.Path, data)
 }
@@ -39,6 +39,10 @@ func Merge(ctx context.Context, providers []Provider) (*config.Config, error) {
 	routeIndex := make(map[string]int)
 	routeProviders := make(map[string]string)
 	poolProviders := make(map[string]string)
+	streamIndex := make(map[string]int)
+	streamProviders := make(map[string]string)
+	listenerIndex := make(map[string]int)
+	listenerProviders := make(map[string]string)
 	listenProvider := ""
 	tlsProvider := ""
 
@@ -158,6 +162,52 @@ func Merge(ctx context.Context, providers []Provider) (*config.Config, error) {
 				IncomingProvider: providerName,
 			}
 		}
+
+		for _, stream := range cfg.Streams {
+			if stream.ID == "" {
+				continue
+			}
+			idx, ok := streamIndex[stream.ID]
+			if !ok {
+				result.Streams = append(result.Streams, stream)
+				streamIndex[stream.ID] = len(result.Streams) - 1
+				streamProviders[stream.ID] = providerName
+				continue
+			}
+			if reflect.DeepEqual(result.Streams[idx], stream) {
+				continue
+			}
+			return nil, &ConflictError{
+				ObjectType:       "stream",
+				ObjectID:         stream.ID,
+				Field:            "stream",
+				ExistingProvider: streamProviders[stream.ID],
+				IncomingProvider: providerName,
+			}
+		}
+
+		for _, listener := range cfg.Listeners {
+			if listener.Name == "" {
+				continue
+			}
+			idx, ok := listenerIndex[listener.Name]
+			if !ok {
+				result.Listeners = append(result.Listeners, listener)
+				listenerIndex[listener.Name] = len(result.Listeners) - 1
+				listenerProviders[listener.Name] = providerName
+				continue
+			}
+			if reflect.DeepEqual(result.Listeners[idx], listener) {
+				continue
+			}
+			return nil, &ConflictError{
+				ObjectType:       "listener",
+				ObjectID:         listener.Name,
+				Field:            "listener",
+				ExistingProvider: listenerProviders[listener.Name],
+				IncomingProvider: providerName,
+			}
+		}
 	}
 
 	if result.Pools == nil {
@@ -225,6 +275,9 @@ func routeConflictField(a config.Route, b config.Route) string {
 	if a.Pool != b.Pool {
 		return "pool"
 	}
+	if !reflect.DeepEqual(a.Listeners, b.Listeners) {
+		return "listeners"
+	}
 	if a.Policy.RequestTimeoutMS != b.Policy.RequestTimeoutMS {
 		return "policy.request_timeout_ms"
 	}
@@ -0,0 +1,82 @@
+package proxy
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"modern_reverse_proxy/internal/policy"
+)
+
+func (h *Handler) enforceAccessPolicy(recorder *ResponseRecorder, r *http.Request, route policy.Route, requestID string) (string, bool) {
+	accessPolicy := route.Policy.Access
+	if !accessPolicy.Enabled {
+		return "", false
+	}
+	clientIP := resolveClientIP(r, accessPolicy.TrustedProxies)
+	reason := ""
+	switch {
+	case clientIP == nil:
+		reason = "unknown_client"
+	case matchesAny(accessPolicy.Deny, clientIP):
+		reason = "deny_cidr"
+	case len(accessPolicy.Allow) > 0 && !matchesAny(accessPolicy.Allow, clientIP):
+		reason = "not_allowed"
+	}
+	clientLabel := ""
+	if clientIP != nil {
+		clientLabel = clientIP.String()
+	}
+	if reason == "" {
+		return clientLabel, false
+	}
+	if h.Metrics != nil {
+		h.Metrics.RecordAccessDenied(route.ID, reason)
+	}
+	WriteProxyError(recorder, requestID, http.StatusForbidden, "access_denied", "access denied")
+	return clientLabel, true
+}
+
+func resolveClientIP(r *http.Request, trustedProxies []*net.IPNet) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	clientIP := net.ParseIP(host)
+	if clientIP == nil || len(trustedProxies) == 0 || !matchesAny(trustedProxies, clientIP) {
+		return clientIP
+	}
+	hops := forwardedHops(r.Header.Values("X-Forwarded-For"))
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(hops[i])
+		if hop == nil {
+			return nil
+		}
+		clientIP = hop
+		if !matchesAny(trustedProxies, hop) {
+			return clientIP
+		}
+	}
+	return clientIP
+}
+
+func forwardedHops(values []string) []string {
+	hops := make([]string, 0, len(values))
+	for _, value := range values {
+		for _, hop := range strings.Split(value, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	return hops
+}
+
+func matchesAny(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
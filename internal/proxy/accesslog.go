@@ -0,0 +1,27 @@
+package proxy
+
+import (
+	"math/rand"
+	"net/http"
+	"time"
+
+	"modern_reverse_proxy/internal/policy"
+)
+
+func shouldLogAccess(logPolicy policy.AccessLogPolicy, status int, errorCategory string, duration time.Duration) bool {
+	if logPolicy.Disabled {
+		return false
+	}
+	failed := status >= http.StatusInternalServerError || (errorCategory != "" && errorCategory != "none")
+	slow := logPolicy.SlowThreshold > 0 && duration >= logPolicy.SlowThreshold
+	if failed || slow {
+		return true
+	}
+	if logPolicy.ErrorsOnly {
+		return false
+	}
+	if logPolicy.SampleRate >= 1 {
+		return true
+	}
+	return logPolicy.SampleRate > 0 && rand.Float64() < logPolicy.SampleRate
+}
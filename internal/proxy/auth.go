@@ -0,0 +1,109 @@
+package proxy
+
+import (
+	"errors"
+	"net/http"
+
+	"modern_reverse_proxy/internal/keyauth"
+	"modern_reverse_proxy/internal/logging"
+	"modern_reverse_proxy/internal/oidc"
+	"modern_reverse_proxy/internal/policy"
+)
+
+var logger = logging.For("proxy")
+
+func (h *Handler) enforceRouteAuth(recorder *ResponseRecorder, r *http.Request, route policy.Route, requestID string) (string, bool) {
+	authPolicy := route.Policy.Auth
+	switch {
+	case authPolicy.OIDC != nil:
+		return h.enforceOIDC(recorder, r, route, requestID)
+	case authPolicy.APIKey != nil:
+		key, err := authPolicy.APIKey.Verify(r)
+		return h.enforceKeyAuth(recorder, r, route, requestID, key, err)
+	case authPolicy.HMAC != nil:
+		key, err := authPolicy.HMAC.Verify(r)
+		return h.enforceKeyAuth(recorder, r, route, requestID, key, err)
+	}
+	return "", false
+}
+
+func (h *Handler) enforceOIDC(recorder *ResponseRecorder, r *http.Request, route policy.Route, requestID string) (string, bool) {
+	authPolicy := route.Policy.Auth
+	r.Header.Del(oidc.SubjectHeader)
+	r.Header.Del(oidc.EmailHeader)
+	r.Header.Del(oidc.NameHeader)
+
+	recorder.Header().Set(RequestIDHeader, requestID)
+	session, result, err := authPolicy.OIDC.Handle(recorder, r)
+	if h.Metrics != nil {
+		h.Metrics.RecordAuthResult(route.ID, authPolicy.Mode, result)
+	}
+	switch result {
+	case oidc.ResultAuthenticated, oidc.ResultRefreshed:
+		authPolicy.OIDC.StripCookies(r)
+		r.Header.Set(oidc.SubjectHeader, session.Subject)
+		if session.Email != "" {
+			r.Header.Set(oidc.EmailHeader, session.Email)
+		}
+		if session.Name != "" {
+			r.Header.Set(oidc.NameHeader, session.Name)
+		}
+		return session.Subject, false
+	case oidc.ResultCallback, oidc.ResultLogout, oidc.ResultLoginRedirect:
+		return "", true
+	case oidc.ResultUnauthenticated:
+		recorder.Header().Set("WWW-Authenticate", `Bearer realm="`+route.ID+`"`)
+		WriteProxyError(recorder, requestID, http.StatusUnauthorized, "auth_required", "authentication required")
+		return "", true
+	case oidc.ResultInvalid:
+		logger.Info("auth_rejected", "request_id", requestID, "route", route.ID, "mode", authPolicy.Mode, "err", err)
+		WriteProxyError(recorder, requestID, http.StatusUnauthorized, "auth_failed", "authentication failed")
+		return "", true
+	default:
+		logger.Error("auth_error", "request_id", requestID, "route", route.ID, "mode", authPolicy.Mode, "err", err)
+		WriteProxyError(recorder, requestID, http.StatusBadGateway, "auth_unavailable", "identity provider unavailable")
+		return "", true
+	}
+}
+
+func (h *Handler) enforceKeyAuth(recorder *ResponseRecorder, r *http.Request, route policy.Route, requestID string, key *keyauth.Key, err error) (string, bool) {
+	mode := route.Policy.Auth.Mode
+	r.Header.Del(keyauth.KeyIDHeader)
+	if err != nil {
+		result := "invalid"
+		switch {
+		case errors.Is(err, keyauth.ErrMissingCredentials):
+			result = "missing"
+		case errors.Is(err, keyauth.ErrBodyTooLarge):
+			result = "body_too_large"
+		}
+		if h.Metrics != nil {
+			h.Metrics.RecordAuthResult(route.ID, mode, result)
+		}
+		switch result {
+		case "missing":
+			WriteProxyError(recorder, requestID, http.StatusUnauthorized, "auth_required", "authentication required")
+		case "body_too_large":
+			WriteProxyError(recorder, requestID, http.StatusRequestEntityTooLarge, "auth_failed", "request body too large to verify")
+		default:
+			logger.Info("auth_rejected", "request_id", requestID, "route", route.ID, "mode", mode, "err", err)
+			WriteProxyError(recorder, requestID, http.StatusUnauthorized, "auth_failed", "authentication failed")
+		}
+		return "", true
+	}
+	if !key.Allow() {
+		if h.Metrics != nil {
+			h.Metrics.RecordAuthResult(route.ID, mode, "rate_limited")
+			h.Metrics.RecordAuthKey(route.ID, key.ID, "rate_limited")
+		}
+		recorder.Header().Set("Retry-After", "1")
+		WriteProxyError(recorder, requestID, http.StatusTooManyRequests, "rate_limited", "key rate limit exceeded")
+		return key.ID, true
+	}
+	if h.Metrics != nil {
+		h.Metrics.RecordAuthResult(route.ID, mode, "authenticated")
+		h.Metrics.RecordAuthKey(route.ID, key.ID, "allowed")
+	}
+	r.Header.Set(keyauth.KeyIDHeader, key.ID)
+	return key.ID, false
+}
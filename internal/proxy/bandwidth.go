@@ -0,0 +1,23 @@
+package proxy
+
+import (
+	"net"
+	"net/http"
+
+	"modern_reverse_proxy/internal/bandwidth"
+	"modern_reverse_proxy/internal/policy"
+)
+
+func newBandwidthWriter(w http.ResponseWriter, r *http.Request, bandwidthPolicy policy.BandwidthPolicy) *bandwidth.Writer {
+	if bandwidthPolicy.Route == nil && bandwidthPolicy.Clients == nil {
+		return nil
+	}
+	return bandwidth.NewWriter(r.Context(), w, bandwidthPolicy.Route, bandwidthPolicy.Clients.Bucket(bandwidthClientKey(r)))
+}
+
+func bandwidthClientKey(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil && host != "" {
+		return host
+	}
+	return r.RemoteAddr
+}
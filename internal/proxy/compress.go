@@ -0,0 +1,226 @@
+package proxy
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+
+	"modern_reverse_proxy/internal/policy"
+)
+
+type compressWriter struct {
+	writer      http.ResponseWriter
+	policy      policy.CompressionPolicy
+	algorithm   string
+	encoder     io.WriteCloser
+	counter     *countingWriter
+	buffer      []byte
+	status      int
+	wroteHeader bool
+	pending     bool
+	compressing bool
+	bytesIn     int64
+}
+
+type countingWriter struct {
+	writer io.Writer
+	count  int64
+}
+
+func (c *countingWriter) Write(data []byte) (int, error) {
+	n, err := c.writer.Write(data)
+	c.count += int64(n)
+	return n, err
+}
+
+func newCompressWriter(w http.ResponseWriter, r *http.Request, compressionPolicy policy.CompressionPolicy) *compressWriter {
+	if !compressionPolicy.Enabled || r.Method == http.MethodHead {
+		return nil
+	}
+	algorithm := negotiateEncoding(r.Header.Get("Accept-Encoding"), compressionPolicy.Algorithms)
+	if algorithm == "" {
+		return nil
+	}
+	return &compressWriter{writer: w, policy: compressionPolicy, algorithm: algorithm}
+}
+
+func (c *compressWriter) Header() http.Header {
+	return c.writer.Header()
+}
+
+func (c *compressWriter) WriteHeader(status int) {
+	if c.wroteHeader {
+		return
+	}
+	c.wroteHeader = true
+	c.status = status
+	if !c.eligible(status) {
+		c.writer.WriteHeader(status)
+		return
+	}
+	c.Header().Add("Vary", "Accept-Encoding")
+	if raw := c.Header().Get("Content-Length"); raw != "" {
+		length, err := strconv.ParseInt(raw, 10, 64)
+		if err == nil && length < c.policy.MinSize {
+			c.writer.WriteHeader(status)
+			return
+		}
+		c.start()
+		return
+	}
+	c.pending = true
+}
+
+func (c *compressWriter) Write(data []byte) (int, error) {
+	if !c.wroteHeader {
+		c.WriteHeader(http.StatusOK)
+	}
+	if c.pending {
+		c.buffer = append(c.buffer, data...)
+		if int64(len(c.buffer)) < c.policy.MinSize {
+			return len(data), nil
+		}
+		c.start()
+		buffered := c.buffer
+		c.buffer = nil
+		if _, err := c.encoder.Write(buffered); err != nil {
+			return 0, err
+		}
+		c.bytesIn += int64(len(buffered))
+		return len(data), nil
+	}
+	if c.compressing {
+		n, err := c.encoder.Write(data)
+		c.bytesIn += int64(n)
+		return n, err
+	}
+	return c.writer.Write(data)
+}
+
+func (c *compressWriter) Close() error {
+	if c.pending {
+		c.pending = false
+		c.writer.WriteHeader(c.status)
+		if len(c.buffer) > 0 {
+			_, err := c.writer.Write(c.buffer)
+			c.buffer = nil
+			return err
+		}
+		return nil
+	}
+	if c.compressing && c.encoder != nil {
+		return c.encoder.Close()
+	}
+	return nil
+}
+
+func (c *compressWriter) Compressed() (string, int64, int64, bool) {
+	if !c.compressing || c.counter == nil {
+		return "", 0, 0, false
+	}
+	return c.algorithm, c.bytesIn, c.counter.count, true
+}
+
+func (c *compressWriter) start() {
+	c.pending = false
+	c.compressing = true
+	header := c.Header()
+	header.Del("Content-Length")
+	header.Del("Accept-Ranges")
+	if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		header.Set("ETag", "W/"+etag)
+	}
+	header.Set("Content-Encoding", c.algorithm)
+	c.writer.WriteHeader(c.status)
+	c.counter = &countingWriter{writer: c.writer}
+	switch c.algorithm {
+	case "br":
+		c.encoder = brotli.NewWriterLevel(c.counter, brotli.DefaultCompression)
+	case "deflate":
+		c.encoder = zlib.NewWriter(c.counter)
+	default:
+		c.encoder = gzip.NewWriter(c.counter)
+	}
+}
+
+func (c *compressWriter) eligible(status int) bool {
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified || status == http.StatusPartialContent {
+		return false
+	}
+	header := c.Header()
+	if encoding := strings.TrimSpace(header.Get("Content-Encoding")); encoding != "" && !strings.EqualFold(encoding, "identity") {
+		return false
+	}
+	if hasNoTransform(header) {
+		return false
+	}
+	contentType := strings.ToLower(strings.TrimSpace(header.Get("Content-Type")))
+	if contentType == "" || isEventStream(header) {
+		return false
+	}
+	for _, allowed := range c.policy.ContentTypes {
+		if strings.HasPrefix(contentType, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasNoTransform(header http.Header) bool {
+	for _, value := range header.Values("Cache-Control") {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), "no-transform") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func negotiateEncoding(acceptEncoding string, algorithms []string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+	accepted := make(map[string]bool)
+	wildcard := false
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		fields := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		if name == "" {
+			continue
+		}
+		enabled := true
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+			quality, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64)
+			if err == nil && quality <= 0 {
+				enabled = false
+			}
+		}
+		if name == "*" {
+			wildcard = enabled
+			continue
+		}
+		accepted[name] = enabled
+	}
+	for _, algorithm := range algorithms {
+		if enabled, ok := accepted[algorithm]; ok {
+			if enabled {
+				return algorithm
+			}
+			continue
+		}
+		if wildcard {
+			return algorithm
+		}
+	}
+	return ""
+}
@@ -0,0 +1,104 @@
+package proxy
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	"modern_reverse_proxy/internal/policy"
+)
+
+func applyCORS(recorder *ResponseRecorder, r *http.Request, corsPolicy policy.CORSPolicy, requestID string) bool {
+	if !corsPolicy.Enabled {
+		return false
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+	recorder.Header().Add("Vary", "Origin")
+	allowed := corsOriginAllowed(corsPolicy, origin)
+
+	requestMethod := r.Header.Get("Access-Control-Request-Method")
+	if r.Method != http.MethodOptions || requestMethod == "" {
+		if allowed {
+			headers := make(http.Header)
+			setCORSOrigin(headers, corsPolicy, origin)
+			if corsPolicy.ExposeHeaders != "" {
+				headers.Set("Access-Control-Expose-Headers", corsPolicy.ExposeHeaders)
+			}
+			recorder.SetResponseHeaders(headers)
+		}
+		return false
+	}
+
+	recorder.Header().Add("Vary", "Access-Control-Request-Method")
+	recorder.Header().Add("Vary", "Access-Control-Request-Headers")
+	requestedHeaders := corsRequestedHeaders(r)
+	if !allowed || !corsPolicy.AllowedMethods[strings.ToUpper(requestMethod)] || !corsHeadersAllowed(corsPolicy, requestedHeaders) {
+		WriteProxyError(recorder, requestID, http.StatusForbidden, "cors_rejected", "cors preflight rejected")
+		return true
+	}
+	header := recorder.Header()
+	setCORSOrigin(header, corsPolicy, origin)
+	header.Set("Access-Control-Allow-Methods", corsPolicy.AllowMethods)
+	if corsPolicy.AllowAllHeaders && len(requestedHeaders) > 0 {
+		header.Set("Access-Control-Allow-Headers", strings.Join(requestedHeaders, ", "))
+	} else if corsPolicy.AllowHeaders != "" {
+		header.Set("Access-Control-Allow-Headers", corsPolicy.AllowHeaders)
+	}
+	if corsPolicy.MaxAge != "" {
+		header.Set("Access-Control-Max-Age", corsPolicy.MaxAge)
+	}
+	header.Set(RequestIDHeader, requestID)
+	recorder.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+func corsOriginAllowed(corsPolicy policy.CORSPolicy, origin string) bool {
+	if corsPolicy.AllowAllOrigins {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	for _, pattern := range corsPolicy.AllowedOrigins {
+		if matched, _ := path.Match(pattern, origin); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func setCORSOrigin(header http.Header, corsPolicy policy.CORSPolicy, origin string) {
+	if corsPolicy.AllowAllOrigins {
+		header.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	header.Set("Access-Control-Allow-Origin", origin)
+	if corsPolicy.AllowCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func corsRequestedHeaders(r *http.Request) []string {
+	var headers []string
+	for _, value := range r.Header.Values("Access-Control-Request-Headers") {
+		for _, header := range strings.Split(value, ",") {
+			if header = strings.TrimSpace(header); header != "" {
+				headers = append(headers, header)
+			}
+		}
+	}
+	return headers
+}
+
+func corsHeadersAllowed(corsPolicy policy.CORSPolicy, headers []string) bool {
+	if corsPolicy.AllowAllHeaders {
+		return true
+	}
+	for _, header := range headers {
+		if !corsPolicy.AllowedHeaders[strings.ToLower(header)] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,63 @@
+package proxy
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"modern_reverse_proxy/internal/logging"
+	"modern_reverse_proxy/internal/policy"
+)
+
+const debugTraceKey contextKey = "debug_trace"
+
+func SignDebugTraceToken(secret []byte, expires time.Time) string {
+	expiry := strconv.FormatInt(expires.Unix(), 10)
+	return expiry + "." + debugTraceSignature(secret, expiry)
+}
+
+func debugTraceSignature(secret []byte, expiry string) string {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write([]byte("debug_trace|" + expiry))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func debugTraceAuthorized(tracePolicy policy.DebugTracePolicy, token string, now time.Time) bool {
+	expiry, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	expiresAt, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil || now.Unix() > expiresAt {
+		return false
+	}
+	expected := debugTraceSignature(tracePolicy.TokenSecret, expiry)
+	return hmac.Equal([]byte(signature), []byte(expected))
+}
+
+func withDebugTrace(ctx context.Context, tracePolicy policy.DebugTracePolicy, token string, requestID string) (context.Context, bool) {
+	if !tracePolicy.Enabled || token == "" {
+		return ctx, false
+	}
+	if !debugTraceAuthorized(tracePolicy, strings.TrimSpace(token), time.Now()) {
+		logger.Debug("debug_trace_rejected", "request_id", requestID)
+		return ctx, false
+	}
+	return context.WithValue(ctx, debugTraceKey, logging.Verbose("trace").With("request_id", requestID)), true
+}
+
+func debugTrace(ctx context.Context, phase string, args ...any) {
+	if ctx == nil {
+		return
+	}
+	traceLogger, ok := ctx.Value(debugTraceKey).(*slog.Logger)
+	if !ok {
+		return
+	}
+	traceLogger.Debug(phase, args...)
+}
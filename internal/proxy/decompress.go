@@ -0,0 +1,121 @@
+package proxy
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"modern_reverse_proxy/internal/limits"
+	"modern_reverse_proxy/internal/policy"
+)
+
+const decompressionRatioFloor = 64 * 1024
+
+var (
+	errDecompressedTooLarge = errors.New("decompressed size limit exceeded")
+	errDecompressionRatio   = errors.New("decompression ratio limit exceeded")
+)
+
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (c *countingReader) Read(buffer []byte) (int, error) {
+	n, err := c.reader.Read(buffer)
+	c.count += int64(n)
+	return n, err
+}
+
+func decompressRequest(recorder *ResponseRecorder, requestID string, request *http.Request, decompressionPolicy policy.DecompressionPolicy, bodyPolicy policy.RequestBodyPolicy, limitConfig limits.Limits) (string, bool) {
+	if request == nil || !decompressionPolicy.Enabled {
+		return "", false
+	}
+	if request.Body == nil || request.Body == http.NoBody {
+		return "", false
+	}
+	encoding := strings.ToLower(strings.TrimSpace(request.Header.Get("Content-Encoding")))
+	if encoding != "gzip" && encoding != "x-gzip" && encoding != "deflate" {
+		return "", false
+	}
+
+	maxRatio := decompressionPolicy.MaxRatio
+	if limitConfig.MaxDecompressionRatio > 0 && (maxRatio <= 0 || limitConfig.MaxDecompressionRatio < maxRatio) {
+		maxRatio = limitConfig.MaxDecompressionRatio
+	}
+	body, err := decompressBody(request.Body, encoding, limitConfig.DecompressedBodyLimit(bodyPolicy.MaxBodyBytes), maxRatio)
+	if err != nil {
+		switch {
+		case errors.Is(err, errDecompressedTooLarge):
+			WriteProxyError(recorder, requestID, http.StatusRequestEntityTooLarge, "decompression_bomb", "decompressed body too large")
+			return "size", true
+		case errors.Is(err, errDecompressionRatio):
+			WriteProxyError(recorder, requestID, http.StatusRequestEntityTooLarge, "decompression_bomb", "decompression ratio too high")
+			return "ratio", true
+		case isRequestBodyTooLarge(err):
+			WriteProxyError(recorder, requestID, http.StatusRequestEntityTooLarge, "request_too_large", "request body too large")
+			return "body_size", true
+		}
+		WriteProxyError(recorder, requestID, http.StatusBadRequest, "bad_content_encoding", "request body could not be decompressed")
+		return "malformed", true
+	}
+	request.Body = io.NopCloser(bytes.NewReader(body))
+	request.ContentLength = int64(len(body))
+	request.Header.Del("Content-Encoding")
+	request.Header.Del("Content-Length")
+	return "", false
+}
+
+func decompressBody(body io.ReadCloser, encoding string, maxBytes int64, maxRatio int) ([]byte, error) {
+	defer body.Close()
+	compressed := &countingReader{reader: body}
+	var decoder io.ReadCloser
+	var err error
+	if encoding == "deflate" {
+		decoder, err = zlib.NewReader(compressed)
+	} else {
+		decoder, err = gzip.NewReader(compressed)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer decoder.Close()
+
+	var output bytes.Buffer
+	chunk := make([]byte, 32*1024)
+	for {
+		n, readErr := decoder.Read(chunk)
+		if n > 0 {
+			output.Write(chunk[:n])
+			if err := checkDecompressionLimits(int64(output.Len()), compressed.count, maxBytes, maxRatio); err != nil {
+				return nil, err
+			}
+		}
+		if readErr == io.EOF {
+			return output.Bytes(), nil
+		}
+		if readErr != nil {
+			return nil, readErr
+		}
+	}
+}
+
+func checkDecompressionLimits(decompressed int64, compressed int64, maxBytes int64, maxRatio int) error {
+	if maxBytes > 0 && decompressed > maxBytes {
+		return errDecompressedTooLarge
+	}
+	if maxRatio <= 0 || decompressed <= decompressionRatioFloor {
+		return nil
+	}
+	if compressed <= 0 {
+		compressed = 1
+	}
+	if decompressed > compressed*int64(maxRatio) {
+		return errDecompressionRatio
+	}
+	return nil
+}
@@ -1,12 +1,15 @@
 package proxy
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"io"
 	"net"
 	"net/http"
 	"net/url"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"modern_reverse_proxy/internal/breaker"
@@ -24,6 +27,8 @@ type Engine struct {
 	metrics    *obs.Metrics
 	breakerReg *breaker.Registry
 	outlierReg *outlier.Registry
+	mirrors    mirrorCounters
+	replays    idempotencyStore
 }
 
 func NewEngine(reg *registry.Registry, retryReg *registry.RetryRegistry, metrics *obs.Metrics, breakerReg *breaker.Registry, outlierReg *outlier.Registry) *Engine {
@@ -33,6 +38,7 @@ func NewEngine(reg *registry.Registry, retryReg *registry.RetryRegistry, metrics
 type ForwardResult struct {
 	RetryCount           int
 	RetryReason          string
+	RetrySkipReason      string
 	RetryBudgetExhausted bool
 	UpstreamAddr         string
 	SelectedHealthy      bool
@@ -43,8 +49,9 @@ type ForwardResult struct {
 
 var errNoUpstream = errors.New("no upstream available")
 var errTransportUnavailable = errors.New("upstream transport unavailable")
+var errDrainCutoff = errors.New("upstream endpoint drain budget exceeded")
 
-func (e *Engine) ForwardWithRetry(w http.ResponseWriter, r *http.Request, poolKey pool.PoolKey, stablePoolKey string, picker func() (pool.PickResult, bool), policy policy.Policy, routeID string, breakerCfg breaker.Config, requestID string) ForwardResult {
+func (e *Engine) ForwardWithRetry(w http.ResponseWriter, r *http.Request, poolKey pool.PoolKey, stablePoolKey string, picker func(exclude map[string]bool) (pool.PickResult, bool), policy policy.Policy, routeID string, breakerCfg breaker.Config, requestID string) ForwardResult {
 	retryResult, result := e.roundTripWithRetry(r, poolKey, stablePoolKey, picker, policy, routeID, breakerCfg)
 	if retryResult.Response != nil {
 		WriteUpstreamResponse(w, retryResult.Response, requestID)
@@ -57,20 +64,24 @@ func (e *Engine) ForwardWithRetry(w http.ResponseWriter, r *http.Request, poolKe
 	return result
 }
 
-func (e *Engine) roundTripWithRetry(r *http.Request, poolKey pool.PoolKey, stablePoolKey string, picker func() (pool.PickResult, bool), policy policy.Policy, routeID string, breakerCfg breaker.Config) (retry.Result, ForwardResult) {
+func (e *Engine) roundTripWithRetry(r *http.Request, poolKey pool.PoolKey, stablePoolKey string, picker func(exclude map[string]bool) (pool.PickResult, bool), policy policy.Policy, routeID string, breakerCfg breaker.Config) (retry.Result, ForwardResult) {
 	result := ForwardResult{}
 	if picker == nil {
 		return retry.Result{Err: errNoUpstream}, result
 	}
 
-	transport, err := e.transportFor(poolKey)
+	transport, scheme, err := e.transportFor(poolKey)
 	if err != nil {
 		return retry.Result{Err: errTransportUnavailable}, result
 	}
 
 	allowRetry := policy.Retry.Enabled && policy.Retry.MaxAttempts > 1
-	allowRetry = allowRetry && retry.IsIdempotentMethod(r.Method)
-	allowRetry = allowRetry && retry.IsReplayableBody(r)
+	allowRetry = allowRetry && (retry.IsIdempotentMethod(r.Method) || policy.Retry.NonIdempotent || idempotencyKey(r, policy.Idempotency) != "")
+	var replayBody []byte
+	if allowRetry && !retry.IsReplayableBody(r) {
+		replayBody, result.RetrySkipReason = bufferRetryBody(r, policy.Retry.BufferBodyBytes)
+		allowRetry = replayBody != nil
+	}
 
 	budgetEnabled := policy.RetryBudget.Enabled || policy.ClientRetryCap.Enabled
 	budgetErr := false
@@ -96,31 +107,74 @@ func (e *Engine) roundTripWithRetry(r *http.Request, poolKey pool.PoolKey, stabl
 		body = http.NoBody
 	}
 
+	allowHedge := policy.Hedge.Enabled && retry.IsIdempotentMethod(r.Method) && (retry.IsReplayableBody(r) || replayBody != nil)
+	attempted := &attemptedAddrs{}
+
+	var pickMu sync.Mutex
 	var lastPick pool.PickResult
+	var attemptCount atomic.Int64
 	attempt := func(ctx context.Context) (*http.Response, error, string) {
-		pickResult, ok := picker()
+		pickResult, ok := picker(attempted.exclude(policy.Retry.ExcludeAttempted))
+		pickMu.Lock()
 		if !ok || pickResult.Addr == "" {
 			lastPick = pool.PickResult{}
+			pickMu.Unlock()
+			debugTrace(r.Context(), "upstream_pick", "pool", string(poolKey), "result", "no_upstream")
 			return nil, errNoUpstream, ""
 		}
 		lastPick = pickResult
+		pickMu.Unlock()
+		attempted.start(pickResult.Addr)
+		defer attempted.finish(pickResult.Addr)
 		if pickResult.OutlierIgnored && e.metrics != nil {
 			e.metrics.RecordOutlierFailOpen(stablePoolKey)
 		}
 
 		upstreamAddr := pickResult.Addr
 
+		var drainCtx context.Context
+		release := func() {}
 		if e.registry != nil {
-			e.registry.InflightStart(poolKey, upstreamAddr)
-			defer e.registry.InflightDone(poolKey, upstreamAddr)
+			drainCtx, release = e.registry.Track(poolKey, upstreamAddr)
+		}
+		ctx, stopCutoff := withDrainCutoff(ctx, drainCtx)
+		done := func() {
+			stopCutoff()
+			release()
 		}
 
+		attemptNumber := int(attemptCount.Add(1))
+		debugTrace(r.Context(), "upstream_pick", "pool", string(poolKey), "addr", upstreamAddr, "attempt", attemptNumber, "zone", pickResult.Zone, "outlier_ignored", pickResult.OutlierIgnored)
+		ctx, attemptSpan := obs.StartSpan(ctx, "upstream_attempt", obs.SpanKindClient)
+		attemptSpan.SetAttribute("proxy.attempt", attemptNumber)
+		attemptSpan.SetAttribute("server.address", upstreamAddr)
+		attemptSpan.SetAttribute("proxy.pool", string(poolKey))
 		roundtripStart := time.Now()
-		resp, err := roundTripUpstream(ctx, r, upstreamAddr, transport, body)
+		attemptBody := body
+		if replayBody != nil {
+			attemptBody = io.NopCloser(bytes.NewReader(replayBody))
+		}
+		resp, err := roundTripUpstream(ctx, r, scheme, upstreamAddr, transport, attemptBody)
+		finishAttemptSpan(attemptSpan, resp, err)
+		if resp != nil {
+			debugTrace(r.Context(), "upstream_attempt", "addr", upstreamAddr, "attempt", attemptNumber, "status", resp.StatusCode, "duration_ms", time.Since(roundtripStart).Milliseconds())
+		} else {
+			debugTrace(r.Context(), "upstream_attempt", "addr", upstreamAddr, "attempt", attemptNumber, "error", err, "duration_ms", time.Since(roundtripStart).Milliseconds())
+		}
+		if err == nil && resp != nil && resp.Body != nil {
+			resp.Body = &inflightReadCloser{inner: resp.Body, drainCtx: drainCtx, release: done}
+		} else {
+			done()
+		}
+		if err != nil && errors.Is(context.Cause(ctx), errDrainCutoff) {
+			err = errDrainCutoff
+		} else if err != nil && errors.Is(context.Cause(ctx), context.DeadlineExceeded) {
+			err = context.DeadlineExceeded
+		}
 		if upstreamAddr != "" {
 			requestLatency := time.Since(roundtripStart)
 			success := err == nil && resp != nil && resp.StatusCode < http.StatusInternalServerError
-			reportable := err == nil || (!errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded))
+			reportable := err == nil || (!errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) && !isRequestBodyRejected(err))
 			if reportable {
 				if e.outlierReg != nil {
 					e.outlierReg.RecordResult(stablePoolKey, upstreamAddr, success, requestLatency)
@@ -128,15 +182,25 @@ func (e *Engine) roundTripWithRetry(r *http.Request, poolKey pool.PoolKey, stabl
 				if e.breakerReg != nil && !errors.Is(err, errNoUpstream) {
 					e.breakerReg.Report(stablePoolKey, breakerCfg, success)
 				}
+				if pickResult.Zone != "" && e.metrics != nil {
+					e.metrics.RecordZoneRequest(string(poolKey), pickResult.Zone, success)
+				}
 			}
 		}
 		if e.metrics != nil {
-			e.metrics.ObserveUpstreamRoundTrip(string(poolKey), time.Since(roundtripStart))
+			e.metrics.ObserveUpstreamRoundTrip(string(poolKey), time.Since(roundtripStart), sampledTraceID(attemptSpan))
 		}
 		if err != nil {
 			if errors.Is(err, errNoUpstream) {
 				return nil, err, upstreamAddr
 			}
+			if isRequestBodyRejected(err) {
+				return nil, err, upstreamAddr
+			}
+			if errors.Is(err, errDrainCutoff) {
+				e.recordUpstreamError(poolKey, "drain_cutoff")
+				return nil, err, upstreamAddr
+			}
 			if isTimeoutError(err) || errors.Is(err, context.DeadlineExceeded) {
 				e.recordUpstreamError(poolKey, "timeout")
 				e.passiveFailure(poolKey, upstreamAddr)
@@ -150,9 +214,18 @@ func (e *Engine) roundTripWithRetry(r *http.Request, poolKey pool.PoolKey, stabl
 			e.recordUpstreamError(poolKey, "other")
 			return nil, err, upstreamAddr
 		}
+		e.passiveStatus(poolKey, upstreamAddr, resp.StatusCode)
 		return resp, nil, upstreamAddr
 	}
 
+	if allowHedge {
+		attempt = retry.Hedge(policy.Hedge, attempt, func(result string) {
+			if e.metrics != nil {
+				e.metrics.RecordHedge(routeID, result)
+			}
+		})
+	}
+
 	retryResult := retry.Execute(retry.Config{
 		Policy:        policy.Retry,
 		OuterContext:  r.Context(),
@@ -164,6 +237,8 @@ func (e *Engine) roundTripWithRetry(r *http.Request, poolKey pool.PoolKey, stabl
 			Client: clientBudget,
 		},
 		OnRetry: func(reason string) {
+			obs.SpanFromContext(r.Context()).AddEvent("retry", map[string]interface{}{"proxy.retry_reason": reason})
+			debugTrace(r.Context(), "retry", "reason", reason)
 			if e.metrics != nil {
 				e.metrics.RecordRetry(routeID, reason)
 			}
@@ -174,10 +249,12 @@ func (e *Engine) roundTripWithRetry(r *http.Request, poolKey pool.PoolKey, stabl
 	result.RetryReason = retryResult.RetryReason
 	result.RetryBudgetExhausted = retryResult.RetryBudgetExhausted
 	result.UpstreamAddr = retryResult.UpstreamAddr
+	pickMu.Lock()
 	result.SelectedHealthy = lastPick.SelectedHealthy
 	result.SelectedFailOpen = lastPick.SelectedFailOpen
 	result.OutlierIgnored = lastPick.OutlierIgnored
 	result.EndpointEjected = lastPick.EndpointEjected
+	pickMu.Unlock()
 
 	if retryResult.RetryBudgetExhausted && e.metrics != nil {
 		e.metrics.RecordRetryBudgetExhausted(routeID)
@@ -198,11 +275,82 @@ func (e *Engine) roundTripWithRetry(r *http.Request, poolKey pool.PoolKey, stabl
 	return retryResult, result
 }
 
+func bufferRetryBody(r *http.Request, limit int64) ([]byte, string) {
+	if limit <= 0 {
+		return nil, ""
+	}
+	if r.ContentLength > limit {
+		return nil, "body_too_large_to_retry"
+	}
+	buffered, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	if err != nil || int64(len(buffered)) > limit {
+		r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(buffered), r.Body))
+		if err != nil {
+			return nil, ""
+		}
+		return nil, "body_too_large_to_retry"
+	}
+	_ = r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(buffered))
+	return buffered, ""
+}
+
+type attemptedAddrs struct {
+	mu       sync.Mutex
+	attempts map[string]bool
+	inflight map[string]int
+}
+
+func (a *attemptedAddrs) exclude(includeAttempted bool) map[string]bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var exclude map[string]bool
+	for addr, count := range a.inflight {
+		if count > 0 || (includeAttempted && a.attempts[addr]) {
+			if exclude == nil {
+				exclude = make(map[string]bool)
+			}
+			exclude[addr] = true
+		}
+	}
+	return exclude
+}
+
+func (a *attemptedAddrs) start(addr string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.inflight == nil {
+		a.attempts = make(map[string]bool)
+		a.inflight = make(map[string]int)
+	}
+	a.attempts[addr] = true
+	a.inflight[addr]++
+}
+
+func (a *attemptedAddrs) finish(addr string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.inflight[addr]--
+}
+
 func writeProxyErrorForResult(w http.ResponseWriter, r *http.Request, requestID string, retryResult retry.Result) bool {
+	var multipartErr *multipartLimitError
+	if errors.As(retryResult.Err, &multipartErr) {
+		WriteProxyError(w, requestID, multipartErr.status, multipartErr.category, multipartErr.message)
+		return true
+	}
+	if isRequestBodyTooLarge(retryResult.Err) {
+		WriteProxyError(w, requestID, http.StatusRequestEntityTooLarge, "request_too_large", "request body too large")
+		return true
+	}
 	if errors.Is(retryResult.Err, errTransportUnavailable) {
 		WriteProxyError(w, requestID, http.StatusBadGateway, "bad_gateway", "upstream transport unavailable")
 		return true
 	}
+	if errors.Is(retryResult.Err, errDrainCutoff) {
+		WriteProxyError(w, requestID, http.StatusBadGateway, "upstream_drained", "upstream endpoint removed")
+		return true
+	}
 	if errors.Is(retryResult.Err, errNoUpstream) {
 		WriteProxyError(w, requestID, http.StatusBadGateway, "bad_gateway", "no upstream available")
 		return true
@@ -225,8 +373,42 @@ func writeProxyErrorForResult(w http.ResponseWriter, r *http.Request, requestID
 	return false
 }
 
+func withDrainCutoff(ctx context.Context, drainCtx context.Context) (context.Context, func()) {
+	if drainCtx == nil {
+		return ctx, func() {}
+	}
+	cutoffCtx, cancel := context.WithCancelCause(ctx)
+	stop := context.AfterFunc(drainCtx, func() {
+		cancel(errDrainCutoff)
+	})
+	return cutoffCtx, func() {
+		stop()
+		cancel(nil)
+	}
+}
+
+type inflightReadCloser struct {
+	inner    io.ReadCloser
+	drainCtx context.Context
+	release  func()
+}
+
+func (i *inflightReadCloser) Read(buffer []byte) (int, error) {
+	if i.drainCtx != nil && i.drainCtx.Err() != nil {
+		_ = i.inner.Close()
+		return 0, errDrainCutoff
+	}
+	return i.inner.Read(buffer)
+}
+
+func (i *inflightReadCloser) Close() error {
+	err := i.inner.Close()
+	i.release()
+	return err
+}
+
 func (e *Engine) RoundTripUpstream(ctx context.Context, req *http.Request, upstreamAddr string, poolKey pool.PoolKey) (*http.Response, error) {
-	transport, err := e.transportFor(poolKey)
+	transport, scheme, err := e.transportFor(poolKey)
 	if err != nil {
 		return nil, err
 	}
@@ -234,12 +416,12 @@ func (e *Engine) RoundTripUpstream(ctx context.Context, req *http.Request, upstr
 	if req.Body != nil && req.ContentLength == 0 {
 		body = http.NoBody
 	}
-	return roundTripUpstream(ctx, req, upstreamAddr, transport, body)
+	return roundTripUpstream(ctx, req, scheme, upstreamAddr, transport, body)
 }
 
-func roundTripUpstream(ctx context.Context, req *http.Request, upstreamAddr string, transport *http.Transport, body io.ReadCloser) (*http.Response, error) {
+func roundTripUpstream(ctx context.Context, req *http.Request, scheme string, upstreamAddr string, transport *http.Transport, body io.ReadCloser) (*http.Response, error) {
 	target := &url.URL{
-		Scheme:   "http",
+		Scheme:   scheme,
 		Host:     upstreamAddr,
 		Path:     req.URL.Path,
 		RawQuery: req.URL.RawQuery,
@@ -257,7 +439,11 @@ func roundTripUpstream(ctx context.Context, req *http.Request, upstreamAddr stri
 	outbound.Header = req.Header.Clone()
 	outbound.Host = upstreamAddr
 	setForwardedHeaders(outbound, req)
-	obs.InjectTraceHeaders(outbound, req.Context())
+	traceCtx := req.Context()
+	if obs.SpanFromContext(ctx) != nil {
+		traceCtx = ctx
+	}
+	obs.InjectTraceHeaders(outbound, traceCtx)
 
 	obs.MarkPhase(req.Context(), "upstream_roundtrip_start")
 	resp, err := transport.RoundTrip(outbound)
@@ -305,19 +491,30 @@ func copyHeaders(dst, src http.Header) {
 	}
 }
 
-func (e *Engine) transportFor(poolKey pool.PoolKey) (*http.Transport, error) {
+func (e *Engine) transportFor(poolKey pool.PoolKey) (*http.Transport, string, error) {
 	if e == nil || e.registry == nil {
-		return nil, errors.New("registry unavailable")
+		return nil, "", errors.New("registry unavailable")
 	}
 	transport := e.registry.Transport(poolKey)
 	if transport == nil {
-		return nil, errTransportUnavailable
+		return nil, "", errTransportUnavailable
 	}
-	return transport, nil
+	opts, _ := e.registry.TransportOptions(poolKey)
+	return transport, opts.Scheme(), nil
 }
 
 func isRequestTimeout(ctx context.Context) bool {
-	return errors.Is(ctx.Err(), context.DeadlineExceeded)
+	return errors.Is(context.Cause(ctx), context.DeadlineExceeded)
+}
+
+func isRequestBodyTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
+
+func isRequestBodyRejected(err error) bool {
+	var multipartErr *multipartLimitError
+	return isRequestBodyTooLarge(err) || errors.As(err, &multipartErr)
 }
 
 func isClientCanceled(ctx context.Context) bool {
@@ -347,6 +544,13 @@ func (e *Engine) passiveFailure(poolKey pool.PoolKey, addr string) {
 	e.registry.PassiveFailure(poolKey, addr)
 }
 
+func (e *Engine) passiveStatus(poolKey pool.PoolKey, addr string, code int) {
+	if e.registry == nil || addr == "" {
+		return
+	}
+	e.registry.PassiveStatus(poolKey, addr, code)
+}
+
 func (e *Engine) passiveSuccess(poolKey pool.PoolKey, addr string) {
 	if e.registry == nil {
 		return
@@ -5,7 +5,12 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"html"
 	"net/http"
+	"strconv"
+	"strings"
+
+	"modern_reverse_proxy/internal/policy"
 )
 
 const RequestIDHeader = "X-Request-Id"
@@ -28,6 +33,12 @@ func WriteProxyError(w http.ResponseWriter, requestID string, status int, catego
 		recorder.SetErrorCategory(category)
 	}
 	w.Header().Set(RequestIDHeader, requestID)
+	if pages, ok := w.(errorPageWriter); ok {
+		if page, ok := matchErrorPage(pages.ErrorPages(), status); ok {
+			writeErrorPage(w, page, requestID, status, category, message)
+			return
+		}
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	_ = json.NewEncoder(w).Encode(ProxyErrorBody{
@@ -38,6 +49,41 @@ func WriteProxyError(w http.ResponseWriter, requestID string, status int, catego
 	})
 }
 
+func matchErrorPage(pages []policy.ErrorPage, status int) (policy.ErrorPage, bool) {
+	for _, page := range pages {
+		if page.Statuses == nil || page.Statuses[status] {
+			return page, true
+		}
+	}
+	return policy.ErrorPage{}, false
+}
+
+func writeErrorPage(w http.ResponseWriter, page policy.ErrorPage, requestID string, status int, category string, message string) {
+	escape := jsonEscape
+	contentType := "application/json"
+	if page.Format == "html" {
+		escape = html.EscapeString
+		contentType = "text/html; charset=utf-8"
+	}
+	body := strings.NewReplacer(
+		"{{status}}", strconv.Itoa(status),
+		"{{request_id}}", escape(requestID),
+		"{{category}}", escape(category),
+		"{{message}}", escape(message),
+	).Replace(page.Body)
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(body))
+}
+
+func jsonEscape(value string) string {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(encoded[1 : len(encoded)-1])
+}
+
 func WriteOverload(w http.ResponseWriter, requestID string) {
 	w.Header().Set("Retry-After", overloadRetryAfterSeconds)
 	WriteProxyError(w, requestID, http.StatusServiceUnavailable, "overloaded", "overloaded")
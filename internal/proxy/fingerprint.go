@@ -0,0 +1,24 @@
+package proxy
+
+import (
+	"net/http"
+
+	"modern_reverse_proxy/internal/fingerprint"
+	"modern_reverse_proxy/internal/policy"
+)
+
+func enforceFingerprintPolicy(recorder *ResponseRecorder, requestID string, fingerprintPolicy policy.FingerprintPolicy, clientFingerprint fingerprint.Fingerprint) (string, bool) {
+	if clientFingerprint.Matches(fingerprintPolicy.Deny) {
+		WriteProxyError(recorder, requestID, http.StatusForbidden, "fingerprint_denied", "client fingerprint denied")
+		return "deny", true
+	}
+	if len(fingerprintPolicy.Allow) > 0 && !clientFingerprint.Matches(fingerprintPolicy.Allow) {
+		WriteProxyError(recorder, requestID, http.StatusForbidden, "fingerprint_denied", "client fingerprint not allowed")
+		return "not_allowed", true
+	}
+	if fingerprintPolicy.Limiter != nil && !fingerprintPolicy.Limiter.Allow(clientFingerprint.JA4) {
+		WriteProxyError(recorder, requestID, http.StatusTooManyRequests, "fingerprint_rate_limited", "client fingerprint rate limited")
+		return "rate_limited", true
+	}
+	return "", false
+}
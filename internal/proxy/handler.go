@@ -8,10 +8,13 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"modern_reverse_proxy/internal/breaker"
 	"modern_reverse_proxy/internal/cache"
+	"modern_reverse_proxy/internal/concurrency"
+	"modern_reverse_proxy/internal/fingerprint"
 	"modern_reverse_proxy/internal/limits"
 	"modern_reverse_proxy/internal/obs"
 	"modern_reverse_proxy/internal/outlier"
@@ -28,12 +31,16 @@ type Handler struct {
 	Registry         *registry.Registry
 	RetryRegistry    *registry.RetryRegistry
 	BreakerRegistry  *breaker.Registry
+	Concurrency      *concurrency.Registry
 	OutlierRegistry  *outlier.Registry
 	PluginRegistry   *plugin.Registry
 	Engine           *Engine
 	Metrics          *obs.Metrics
 	Cache            *cache.Cache
 	Inflight         *runtime.InflightTracker
+	SlowRoutes       *obs.SlowRouteTracker
+	Tap              *obs.Tap
+	Maintenance      MaintenanceSource
 	SnapshotObserver SnapshotObserver
 }
 
@@ -45,9 +52,18 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		defer h.Inflight.Dec()
 	}
 
-	requestID := r.Header.Get(RequestIDHeader)
-	if requestID == "" {
-		requestID = NewRequestID()
+	var requestIDPolicy policy.RequestIDPolicy
+	if h != nil && h.Store != nil {
+		if current := h.Store.Get(); current != nil {
+			requestIDPolicy = current.RequestID
+		}
+	}
+	inboundRequestID := r.Header.Get(RequestIDHeader)
+	requestID := resolveRequestID(r, requestIDPolicy, inboundRequestID)
+	originalRequestID := ""
+	if requestID != inboundRequestID {
+		originalRequestID = inboundRequestID
+		r.Header.Set(RequestIDHeader, requestID)
 	}
 	recorder.Header().Set(RequestIDHeader, requestID)
 	if strings.HasPrefix(r.URL.Path, "/admin") {
@@ -65,13 +81,18 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	redactQuery := false
 	routeID := "none"
+	routeLabels := map[string]string(nil)
 	poolKey := "none"
 	upstreamAddr := "none"
 	snapshotVersion := "none"
 	snapshotSource := "none"
+	snapshotProvenance := runtime.Provenance{}
 	bytesIn := int64(0)
 	retryCount := 0
 	retryLastReason := ""
+	retrySkipReason := ""
+	idempotencyStatus := ""
+	requestRule := ""
 	retryBudgetExhausted := false
 	cacheStatus := "bypass"
 	cacheMetricStatus := ""
@@ -81,16 +102,28 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	endpointEjected := false
 	mtlsRouteRequired := false
 	mtlsVerified := false
+	mtlsIdentity := ""
 	trafficVariant := traffic.VariantStable
 	cohortMode := "random"
 	cohortKeyPresent := false
 	overloadRejected := false
 	autoDrainActive := false
 	trafficPlan := (*traffic.Plan)(nil)
+	accessLogPolicy := policy.AccessLogPolicy{SampleRate: 1}
+	accessLogSink := io.Writer(nil)
+	slowLogThreshold := time.Duration(0)
+	slowLogSink := io.Writer(nil)
+	tapCapture := (*obs.TapCapture)(nil)
+	tapRequestHeaders := http.Header(nil)
 	pluginFilters := []string{}
 	pluginTracking := &pluginTracking{}
 	tlsEnabled := r.TLS != nil
+	clientFingerprint, _ := fingerprint.FromContext(r.Context())
 	canonRoute := ""
+	upstreamOverride := false
+	scriptVars := map[string]string(nil)
+	authSubject := ""
+	clientIP := ""
 	canonObserved := false
 	if r.ContentLength > 0 {
 		bytesIn = r.ContentLength
@@ -120,15 +153,16 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 		proxyError := errorCategory != "none"
 		if trafficPlan != nil && trafficPlan.Stats != nil {
-			trafficPlan.Stats.Record(trafficVariant, recorder.Status(), proxyError)
+			trafficPlan.Stats.Record(trafficVariant, recorder.Status(), proxyError, duration)
 		}
 
-		obs.LogAccess(obs.RequestContext{
+		requestContext := obs.RequestContext{
 			RequestID:            requestID,
 			Method:               r.Method,
 			Host:                 r.Host,
 			Path:                 logPath,
 			RouteID:              routeID,
+			RouteLabels:          routeLabels,
 			PoolKey:              poolKey,
 			UpstreamAddr:         upstreamAddr,
 			PluginFilters:        pluginFilters,
@@ -144,10 +178,17 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			ErrorCategory:        errorCategory,
 			RetryCount:           retryCount,
 			RetryLastReason:      retryLastReason,
+			RetrySkipReason:      retrySkipReason,
+			IdempotencyStatus:    idempotencyStatus,
+			RequestRule:          requestRule,
+			OriginalRequestID:    originalRequestID,
 			RetryBudgetExhausted: retryBudgetExhausted,
 			CacheStatus:          cacheStatus,
 			SnapshotVersion:      snapshotVersion,
 			SnapshotSource:       snapshotSource,
+			SnapshotLabel:        snapshotProvenance.Label,
+			SnapshotGitSHA:       snapshotProvenance.GitSHA,
+			SnapshotAuthor:       snapshotProvenance.Author,
 			TrafficVariant:       variantLabel,
 			CohortMode:           cohortMode,
 			CohortKeyPresent:     cohortKeyPresent,
@@ -162,14 +203,36 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			TLS:                  tlsEnabled,
 			MTLSRouteRequired:    mtlsRouteRequired,
 			MTLSVerified:         mtlsVerified,
-		})
+			MTLSIdentity:         mtlsIdentity,
+			TLSJA3:               clientFingerprint.JA3Hash,
+			TLSJA4:               clientFingerprint.JA4,
+			UpstreamOverride:     upstreamOverride,
+			ScriptVars:           scriptVars,
+			AuthSubject:          authSubject,
+			ClientIP:             clientIP,
+			Phases:               obs.PhaseTimingsFromContext(r.Context()),
+		}
+		if shouldLogAccess(accessLogPolicy, recorder.Status(), errorCategory, duration) {
+			obs.LogAccessTo(accessLogSink, requestContext, accessLogPolicy.Fields, accessLogPolicy.Rename)
+		}
+		slowRequest := slowLogThreshold > 0 && duration >= slowLogThreshold
+		if slowRequest {
+			obs.LogSlowRequest(slowLogSink, requestContext, slowLogThreshold)
+		}
+		if h != nil && h.SlowRoutes != nil && routeID != "none" {
+			h.SlowRoutes.Observe(routeID, duration, slowRequest, requestID, upstreamAddr)
+		}
+		tapCapture.Publish(requestContext, tapRequestHeaders, recorder.Header())
 
 		if h != nil && h.Metrics != nil {
 			if !canonObserved {
 				canonRoute, _ = h.Metrics.Canonicalize(routeID, poolKey)
 			}
-			h.Metrics.SetSnapshotInfo(snapshotVersion, snapshotSource)
-			h.Metrics.ObserveRequestCanonical(canonRoute, recorder.Status(), duration)
+			h.Metrics.SetSnapshotInfo(snapshotVersion, snapshotSource, snapshotProvenance.Label, snapshotProvenance.GitSHA, snapshotProvenance.Author)
+			if routeLabels != nil {
+				h.Metrics.SetRouteLabelsCanonical(canonRoute, routeLabels)
+			}
+			h.Metrics.ObserveRequestCanonical(canonRoute, recorder.Status(), duration, sampledTraceID(obs.SpanFromContext(ctx)))
 			if errorCategory != "none" {
 				h.Metrics.RecordProxyErrorCanonical(canonRoute, errorCategory)
 			}
@@ -181,6 +244,8 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 				h.Metrics.RecordOverloadRejectCanonical(canonRoute)
 			}
 		}
+		debugTrace(r.Context(), "request_complete", "status", recorder.Status(), "duration_ms", duration.Milliseconds(), "error_category", errorCategory, "upstream_addr", upstreamAddr, "retries", retryCount, "cache_status", cacheStatus)
+		finishRequestSpan(obs.SpanFromContext(ctx), r.Method, r.Host, logPath, routeID, poolKey, upstreamAddr, recorder.Status(), errorCategory, retryCount)
 	}()
 
 	if h == nil || h.Store == nil || h.Engine == nil || h.Registry == nil {
@@ -199,11 +264,34 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	defer h.Store.Release(snap)
 	snapshotVersion = snap.Version
 	snapshotSource = snap.Source
+	snapshotProvenance = snap.Provenance
+	if snap.FailSafe {
+		WriteProxyError(recorder, requestID, http.StatusServiceUnavailable, "not_ready", "proxy not ready")
+		return
+	}
+	if tracePolicy := snap.DebugTrace; tracePolicy.Enabled {
+		token := r.Header.Get(tracePolicy.Header)
+		r.Header.Del(tracePolicy.Header)
+		if traceCtx, traced := withDebugTrace(r.Context(), tracePolicy, token, requestID); traced {
+			r = r.WithContext(traceCtx)
+			debugTrace(r.Context(), "request_start", "method", r.Method, "host", r.Host, "path", r.URL.Path, "snapshot_version", snap.Version)
+		}
+	}
 	redactQuery = snap.Logging.RedactQuery
+	accessLogSink = snap.AccessLog
+	if snap.SlowLog.Enabled {
+		slowLogThreshold = snap.SlowLog.Threshold
+		slowLogSink = snap.SlowLog.Sink
+	}
 	if redactQuery {
 		logPath = r.URL.Path
 	}
 
+	if acmeManager := snap.TLSStore.ACME(); acmeManager.ServesChallenge(r) {
+		acmeManager.ServeChallenge(recorder, r)
+		return
+	}
+
 	if enforceRequestLimits(recorder, requestID, r, snap.Limits) {
 		return
 	}
@@ -211,12 +299,69 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.observeSnapshot(SnapshotPhaseRouteMatch, snap)
 	obs.MarkPhase(r.Context(), "route_match")
 
+	_, matchSpan := obs.StartSpan(r.Context(), "route_match", obs.SpanKindInternal)
 	route, ok := snap.Router.Match(r)
+	matchSpan.SetAttribute("proxy.route_matched", ok)
+	if ok {
+		matchSpan.SetAttribute("http.route", route.ID)
+	}
+	matchSpan.End()
+	obs.MarkPhase(r.Context(), "route_match_end")
+	debugTrace(r.Context(), "route_match", "matched", ok, "route", route.ID)
 	if !ok {
 		WriteProxyError(recorder, requestID, http.StatusNotFound, "no_route", "no route matched")
 		return
 	}
 	routeID = route.ID
+	routeLabels = route.Labels
+	if enforceBodyLimit(recorder, requestID, r, snap.Limits.MaxBodyBytes, route.Policy.RequestBody) {
+		return
+	}
+	if tapCapture = h.Tap.Sample(route.ID); tapCapture != nil {
+		tapRequestHeaders = r.Header.Clone()
+		r.Body = tapCapture.WrapRequestBody(r.Body)
+	}
+	recorder.SetErrorPages(route.Policy.ErrorPages)
+	if slowLogThreshold > 0 && route.Policy.SlowLogThreshold > 0 {
+		slowLogThreshold = route.Policy.SlowLogThreshold
+	}
+	accessLogPolicy = route.Policy.AccessLog
+	if accessLogPolicy.Sink != nil {
+		accessLogSink = accessLogPolicy.Sink
+	}
+	if h.Maintenance != nil {
+		if maintenance, disabled := h.Maintenance.RouteMaintenance(route.ID); disabled {
+			WriteMaintenance(recorder, requestID, maintenance)
+			return
+		}
+	}
+	applySecurityHeaders(recorder, r, route.Policy.SecurityHeaders)
+	var ruleRejected bool
+	requestRule, ruleRejected = h.enforceRequestRules(recorder, r, route, snap.RequestRules, requestID)
+	if ruleRejected {
+		return
+	}
+	if applyCORS(recorder, r, route.Policy.CORS, requestID) {
+		return
+	}
+	var output http.ResponseWriter = recorder
+	if shaper := newBandwidthWriter(recorder, r, route.Policy.Bandwidth); shaper != nil {
+		output = shaper
+		defer func() {
+			if waited := shaper.Waited(); waited > 0 && h.Metrics != nil {
+				h.Metrics.RecordEgressThrottleCanonical(canonRoute, waited)
+			}
+		}()
+	}
+	if compressor := newCompressWriter(output, r, route.Policy.Compression); compressor != nil {
+		output = compressor
+		defer func() {
+			_ = compressor.Close()
+			if algorithm, bytesIn, bytesOut, ok := compressor.Compressed(); ok && h.Metrics != nil {
+				h.Metrics.RecordCompressionCanonical(canonRoute, algorithm, bytesIn, bytesOut)
+			}
+		}()
+	}
 	if route.Policy.Plugins.Enabled && len(route.Policy.Plugins.Filters) > 0 {
 		pluginFilters = make([]string, 0, len(route.Policy.Plugins.Filters))
 		for _, filter := range route.Policy.Plugins.Filters {
@@ -242,7 +387,8 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 				rawCerts = append(rawCerts, cert.Raw)
 			}
 		}
-		if err := snap.TLSStore.VerifyClientCert(rawCerts, nil); err != nil {
+		chain, err := snap.TLSStore.VerifyClientChain(rawCerts)
+		if err != nil {
 			if h.Metrics != nil {
 				h.Metrics.RecordMTLSReject(route.ID)
 			}
@@ -250,6 +396,55 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		mtlsVerified = true
+		identity, rejected := h.authorizeMTLSIdentity(recorder, r, route, requestID)
+		mtlsIdentity = identity
+		if rejected {
+			return
+		}
+		if h.enforceRevocation(recorder, r, route, requestID, chain) {
+			return
+		}
+	}
+
+	if reason, rejected := enforceFingerprintPolicy(recorder, requestID, route.Policy.TLSFingerprint, clientFingerprint); rejected {
+		if h.Metrics != nil {
+			h.Metrics.RecordFingerprintReject(route.ID, reason)
+		}
+		return
+	}
+
+	clientIP, rejected := h.enforceAccessPolicy(recorder, r, route, requestID)
+	if rejected {
+		return
+	}
+
+	authSubject, rejected = h.enforceRouteAuth(recorder, r, route, requestID)
+	if rejected {
+		return
+	}
+
+	if reason, rejected := decompressRequest(recorder, requestID, r, route.Policy.RequestDecompression, route.Policy.RequestBody, snap.Limits); rejected {
+		if h.Metrics != nil {
+			h.Metrics.RecordDecompressionReject(route.ID, reason)
+		}
+		return
+	}
+	if multipartBody := limitMultipartRequest(r, route.Policy.RequestBody.Multipart, func(reason string) {
+		if h.Metrics != nil {
+			h.Metrics.RecordMultipartReject(route.ID, reason)
+		}
+	}); multipartBody != nil {
+		defer multipartBody.Close()
+	}
+
+	scriptResult, rejected := h.runRouteScript(recorder, r, route, requestID)
+	scriptPool := ""
+	if scriptResult != nil {
+		scriptVars = scriptResult.Vars
+		scriptPool = scriptResult.Pool
+	}
+	if rejected {
+		return
 	}
 
 	trafficPlan = route.TrafficPlan
@@ -258,7 +453,10 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if selectedPoolKey == "" {
 		selectedPoolKey = route.ID + "::" + route.PoolName
 	}
-	if trafficPlan != nil {
+	if scriptPool != "" {
+		selectedPoolName = scriptPool
+		selectedPoolKey = route.ID + "::" + scriptPool
+	} else if trafficPlan != nil {
 		variant, meta := trafficPlan.PickVariant(r)
 		trafficVariant = variant
 		cohortMode = meta.CohortMode
@@ -288,6 +486,7 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	poolKey = string(poolKeyValue)
+	debugTrace(r.Context(), "pool_select", "pool", poolKey, "variant", string(trafficVariant), "script_pool", scriptPool != "")
 
 	stablePoolKey := selectedPoolKey
 
@@ -301,14 +500,44 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		canonObserved = true
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), route.Policy.RequestTimeout)
+	ctx, cancel, stopRequestTimeout := withRequestTimeout(r.Context(), route.Policy.RequestTimeout, route.Policy.SSE.Enabled)
 	defer cancel()
 
 	r = r.WithContext(ctx)
-	if h.applyRequestPlugins(recorder, r, route, requestID, pluginTracking) {
+	if len(pluginFilters) > 0 {
+		obs.MarkPhase(r.Context(), "plugin_request")
+	}
+	pluginResponded := h.applyRequestPlugins(recorder, r, route, requestID, pluginTracking)
+	if len(pluginFilters) > 0 {
+		obs.MarkPhase(r.Context(), "plugin_request_end")
+	}
+	if pluginResponded {
 		return
 	}
 
+	if target, action := h.rematchOnSwap(r, snap, route, trafficVariant, start); action != "" {
+		if target != nil {
+			defer h.Store.Release(target.snapshot)
+			snap = target.snapshot
+			route = target.route
+			routeID = route.ID
+			routeLabels = route.Labels
+			snapshotVersion = snap.Version
+			snapshotSource = snap.Source
+			snapshotProvenance = snap.Provenance
+			poolKeyValue = target.poolKey
+			poolKey = string(poolKeyValue)
+			stablePoolKey = target.stableKey
+			poolConfig = target.poolConfig
+			if h.Metrics != nil {
+				canonRoute, _ = h.Metrics.Canonicalize(routeID, poolKey)
+			}
+		}
+		if h.Metrics != nil {
+			h.Metrics.RecordStaleSnapshotCanonical(canonRoute, action)
+		}
+	}
+
 	if h.BreakerRegistry != nil {
 		state, allowed, err := h.BreakerRegistry.Allow(stablePoolKey, poolConfig.Breaker)
 		breakerState = state.String()
@@ -328,32 +557,79 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if h.Concurrency != nil && poolConfig.Concurrency.Enabled {
+		release, allowed := h.Concurrency.Acquire(poolKey, poolConfig.Concurrency)
+		if !allowed {
+			if h.Metrics != nil {
+				h.Metrics.RecordConcurrencyDrop(poolKey)
+			}
+			WriteProxyError(recorder, requestID, http.StatusServiceUnavailable, "concurrency_limited", "concurrency limit reached")
+			return
+		}
+		acquired := time.Now()
+		defer func() {
+			outcome := concurrency.OutcomeSuccess
+			if upstreamAddr == "none" || upstreamAddr == "" {
+				outcome = concurrency.OutcomeIgnore
+			} else if recorder.Status() >= http.StatusInternalServerError || recorder.ErrorCategory() != "" {
+				outcome = concurrency.OutcomeFailure
+			}
+			release(time.Since(acquired), outcome)
+		}()
+	}
+
+	overrideAddr, rejected := resolveUpstreamOverride(recorder, requestID, r, route.Policy.DebugUpstream, h.Registry, poolKeyValue)
+	if rejected {
+		return
+	}
+	upstreamOverride = overrideAddr != ""
+
 	cachePolicy := route.Policy.Cache
 	cacheKey := ""
-	cacheEligible := isCacheEligible(r, cachePolicy, h.Cache)
+	cacheEligible := isCacheEligible(r, cachePolicy, h.Cache) && !upstreamOverride
+	var staleEntry *cache.Entry
 	if cacheEligible {
 		cacheKey = cache.BuildKey(r, cachePolicy)
 		if h.Cache != nil && h.Cache.Store != nil {
-			if entry, ok := h.Cache.Store.Get(cacheKey); ok {
-				cacheStatus = "hit"
-				cacheMetricStatus = "hit"
-				writeCachedResponse(recorder, entry, requestID, r.Method)
-				if h.Metrics != nil {
-					h.Metrics.RecordCacheRequestCanonical(canonRoute, cacheMetricStatus)
+			_, lookupSpan := obs.StartSpan(r.Context(), "cache_lookup", obs.SpanKindInternal)
+			entry, ok := h.Cache.Store.Get(cacheKey)
+			lookupSpan.SetAttribute("proxy.cache_entry_found", ok)
+			lookupSpan.End()
+			if ok {
+				if entry.Fresh(time.Now()) {
+					cacheStatus = "hit"
+					cacheMetricStatus = "hit"
+					writeCachedResponse(output, entry, requestID, r)
+					if h.Metrics != nil {
+						h.Metrics.RecordCacheRequestCanonical(canonRoute, cacheMetricStatus)
+					}
+					return
+				}
+				if cachePolicy.RespectCacheControl && cache.HasValidator(entry.Header) {
+					staleEntry = &entry
 				}
-				return
 			}
 		}
 	}
+	if !upstreamOverride {
+		h.mirrorRequest(r, route)
+	}
 	obs.MarkPhase(r.Context(), "upstream_pick")
-	picker := func() (pool.PickResult, bool) {
+	picker := func(exclude map[string]bool) (pool.PickResult, bool) {
 		h.observeSnapshot(SnapshotPhaseUpstreamPick, snap)
-		return h.Registry.Pick(poolKeyValue, func(addr string, now time.Time) bool {
+		if upstreamOverride {
+			return pool.PickResult{Addr: overrideAddr}, true
+		}
+		return h.Registry.PickExcluding(poolKeyValue, func(addr string, now time.Time) bool {
 			if h.OutlierRegistry == nil {
 				return false
 			}
 			return h.OutlierRegistry.IsEjected(stablePoolKey, addr, now)
-		})
+		}, exclude)
+	}
+	rangeRequested := r.Method == http.MethodGet && r.Header.Get("Range") != ""
+	if cacheEligible && rangeRequested && !cachePolicy.RangeCollapse {
+		cacheEligible = false
 	}
 	if cacheEligible {
 		cacheStatus = "miss"
@@ -363,7 +639,7 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			if completed && err == nil && ok {
 				cacheStatus = "coalesce_follower"
 				cacheMetricStatus = "miss"
-				writeCachedResponse(recorder, entry, requestID, r.Method)
+				writeCachedResponse(output, entry, requestID, r)
 				if h.Metrics != nil {
 					h.Metrics.RecordCacheRequestCanonical(canonRoute, cacheMetricStatus)
 				}
@@ -386,7 +662,29 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			}()
 		}
 
+		injectedValidators := false
+		if staleEntry != nil {
+			injectedValidators = addRevalidationHeaders(r, *staleEntry)
+		}
+		var collapsedRange, collapsedIfRange string
+		if rangeRequested {
+			collapsedRange = r.Header.Get("Range")
+			collapsedIfRange = r.Header.Get("If-Range")
+			r.Header.Del("Range")
+			r.Header.Del("If-Range")
+		}
 		retryResult, forwardResult := h.Engine.roundTripWithRetry(r, poolKeyValue, stablePoolKey, picker, route.Policy, route.ID, poolConfig.Breaker)
+		retrySkipReason = forwardResult.RetrySkipReason
+		if injectedValidators {
+			r.Header.Del("If-None-Match")
+			r.Header.Del("If-Modified-Since")
+		}
+		if rangeRequested {
+			r.Header.Set("Range", collapsedRange)
+			if collapsedIfRange != "" {
+				r.Header.Set("If-Range", collapsedIfRange)
+			}
+		}
 		if retryResult.Response == nil {
 			coalesceErr = retryResult.Err
 			if writeProxyErrorForResult(recorder, r, requestID, retryResult) {
@@ -409,14 +707,42 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		applyResponseStreamTimeout(retryResult.Response, snap.Limits.ResponseStreamTimeout)
+		applyResponseStreamTimeout(retryResult.Response, route.Policy, snap.Limits)
+
+		if injectedValidators && retryResult.Response.StatusCode == http.StatusNotModified {
+			_, _ = io.Copy(io.Discard, io.LimitReader(retryResult.Response.Body, 64*1024))
+			_ = retryResult.Response.Body.Close()
+			entry, storable := refreshCacheEntry(*staleEntry, retryResult.Response.Header, cachePolicy, time.Now().UTC())
+			cacheStatus = "revalidated"
+			cacheMetricStatus = "revalidated"
+			coalesceEntry = entry
+			coalesceResult = true
+			if h.Cache != nil && h.Cache.Store != nil {
+				if !storable {
+					h.Cache.Store.Delete(cacheKey)
+				} else if err := h.Cache.Store.Set(cacheKey, entry); err != nil && h.Metrics != nil {
+					h.Metrics.RecordCacheStoreFailCanonical(canonRoute)
+				}
+			}
+			writeCachedResponse(output, entry, requestID, r)
+			if h.Metrics != nil {
+				h.Metrics.RecordCacheRequestCanonical(canonRoute, cacheMetricStatus)
+			}
+			return
+		}
 
+		now := time.Now().UTC()
+		freshUntil, expiresAt, storable := cacheEntryTimes(retryResult.Response.StatusCode, retryResult.Response.Header, cachePolicy, now)
 		cacheable, contentLength := isCacheableResponse(retryResult.Response, cachePolicy)
-		if !cacheable {
+		if !cacheable || !storable {
 			cacheStatus = "not_cacheable"
 			cacheMetricStatus = "not_cacheable"
 			coalesceResult = false
-			WriteUpstreamResponse(recorder, retryResult.Response, requestID)
+			if isSSEResponse(retryResult.Response, route.Policy.SSE) {
+				h.writeEventStream(r.Context(), output, recorder, retryResult.Response, requestID, route.Policy.SSE, canonRoute, stopRequestTimeout)
+			} else {
+				WriteUpstreamResponse(output, retryResult.Response, requestID)
+			}
 			if h.Metrics != nil {
 				h.Metrics.RecordCacheRequestCanonical(canonRoute, cacheMetricStatus)
 			}
@@ -431,11 +757,12 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 
 		entry := cache.Entry{
-			Status:    retryResult.Response.StatusCode,
-			Header:    cloneHeader(retryResult.Response.Header),
-			Body:      body,
-			StoredAt:  time.Now().UTC(),
-			ExpiresAt: time.Now().UTC().Add(cachePolicy.TTL),
+			Status:     retryResult.Response.StatusCode,
+			Header:     cloneHeader(retryResult.Response.Header),
+			Body:       body,
+			StoredAt:   now,
+			ExpiresAt:  expiresAt,
+			FreshUntil: freshUntil,
 		}
 		coalesceEntry = entry
 		coalesceResult = true
@@ -451,14 +778,36 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
-		writeCachedResponse(recorder, entry, requestID, r.Method)
+		writeCachedResponse(output, entry, requestID, r)
 		if h.Metrics != nil {
 			h.Metrics.RecordCacheRequestCanonical(canonRoute, cacheMetricStatus)
 		}
 		return
 	}
 
+	idempotencyPolicy := route.Policy.Idempotency
+	var replayFlight *idempotencyFlight
+	if key := idempotencyKey(r, idempotencyPolicy); key != "" && idempotencyPolicy.ReplayTTL > 0 && !upstreamOverride {
+		scopedKey := idempotencyScope(r, authSubject, clientIP) + "\x00" + r.Method + "\x00" + r.URL.Path + "\x00" + key
+		entry, found, flight := h.Engine.replays.begin(route.ID, scopedKey, idempotencyPolicy, time.Now())
+		if found && entry.done {
+			idempotencyStatus = idempotencyReplayed
+			writeIdempotentReplay(output, entry, requestID)
+			return
+		}
+		if found {
+			idempotencyStatus = idempotencyConflict
+			WriteProxyError(recorder, requestID, http.StatusConflict, "idempotency_conflict", "a request with this idempotency key is in progress")
+			return
+		}
+		if flight != nil {
+			replayFlight = flight
+			defer flight.abort()
+		}
+	}
+
 	retryResult, forwardResult := h.Engine.roundTripWithRetry(r, poolKeyValue, stablePoolKey, picker, route.Policy, route.ID, poolConfig.Breaker)
+	retrySkipReason = forwardResult.RetrySkipReason
 	if retryResult.Response == nil {
 		if writeProxyErrorForResult(recorder, r, requestID, retryResult) {
 			return
@@ -474,11 +823,57 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	retryBudgetExhausted = forwardResult.RetryBudgetExhausted
 	outlierIgnored = forwardResult.OutlierIgnored
 	endpointEjected = forwardResult.EndpointEjected
+	retryResult.Response.Body = &phaseReadCloser{inner: retryResult.Response.Body, ctx: r.Context(), phase: "upstream_body_end"}
+	retryResult.Response.Body = tapCapture.WrapResponseBody(retryResult.Response.Body)
 	if h.applyResponsePlugins(recorder, r, retryResult.Response, route, requestID, pluginTracking) {
 		return
 	}
-	applyResponseStreamTimeout(retryResult.Response, snap.Limits.ResponseStreamTimeout)
-	WriteUpstreamResponse(recorder, retryResult.Response, requestID)
+	applyResponseStreamTimeout(retryResult.Response, route.Policy, snap.Limits)
+	if replayFlight != nil && retryResult.Response.StatusCode >= http.StatusOK && retryResult.Response.StatusCode < http.StatusMultipleChoices {
+		idempotencyStatus = idempotencyUncached
+		if bufferIdempotentResponse(retryResult.Response, idempotencyPolicy.MaxBodyBytes) {
+			replayFlight.complete(retryResult.Response)
+			idempotencyStatus = idempotencyStored
+		}
+	}
+	if route.Policy.ServerTiming {
+		retryResult.Response.Header.Add("Server-Timing", serverTimingValue(obs.PhaseTimingsFromContext(r.Context())))
+	}
+	obs.MarkPhase(r.Context(), "response_write")
+	if isSSEResponse(retryResult.Response, route.Policy.SSE) {
+		h.writeEventStream(r.Context(), output, recorder, retryResult.Response, requestID, route.Policy.SSE, canonRoute, stopRequestTimeout)
+	} else {
+		WriteUpstreamResponse(output, retryResult.Response, requestID)
+	}
+	obs.MarkPhase(r.Context(), "response_write_end")
+}
+
+func withRequestTimeout(parent context.Context, timeout time.Duration, stoppable bool) (context.Context, context.CancelFunc, func() bool) {
+	if !stoppable {
+		ctx, cancel := context.WithTimeout(parent, timeout)
+		return ctx, cancel, func() bool { return false }
+	}
+	ctx, cancel := context.WithCancelCause(parent)
+	timer := time.AfterFunc(timeout, func() {
+		cancel(context.DeadlineExceeded)
+	})
+	return ctx, func() {
+		timer.Stop()
+		cancel(context.Canceled)
+	}, timer.Stop
+}
+
+func (h *Handler) writeEventStream(ctx context.Context, w http.ResponseWriter, recorder *ResponseRecorder, resp *http.Response, requestID string, ssePolicy policy.SSEPolicy, canonRoute string, stopRequestTimeout func() bool) {
+	stopRequestTimeout()
+	if h.Metrics != nil {
+		h.Metrics.AddSSEActiveCanonical(canonRoute, 1)
+		defer h.Metrics.AddSSEActiveCanonical(canonRoute, -1)
+	}
+	writeEventStream(ctx, w, recorder, resp, requestID, ssePolicy, func() {
+		if h.Metrics != nil {
+			h.Metrics.RecordSSEHeartbeatCanonical(canonRoute)
+		}
+	})
 }
 
 func (h *Handler) observeSnapshot(phase string, snapshot *runtime.Snapshot) {
@@ -505,7 +900,17 @@ func enforceRequestLimits(recorder *ResponseRecorder, requestID string, request
 		WriteProxyError(recorder, requestID, http.StatusRequestHeaderFieldsTooLarge, "headers_too_large", "too many headers")
 		return true
 	}
-	if limitConfig.MaxBodyBytes == 0 {
+	return false
+}
+
+func enforceBodyLimit(recorder *ResponseRecorder, requestID string, request *http.Request, maxBodyBytes int64, bodyPolicy policy.RequestBodyPolicy) bool {
+	if request == nil {
+		return false
+	}
+	if bodyPolicy.MaxBodyBytes > 0 {
+		maxBodyBytes = bodyPolicy.MaxBodyBytes
+	}
+	if maxBodyBytes == 0 {
 		if request.Body != nil && request.Body != http.NoBody {
 			_ = request.Body.Close()
 		}
@@ -515,13 +920,19 @@ func enforceRequestLimits(recorder *ResponseRecorder, requestID string, request
 		}
 		return false
 	}
-	if limitConfig.MaxBodyBytes > 0 {
-		if request.ContentLength > limitConfig.MaxBodyBytes {
+	if maxBodyBytes > 0 {
+		if request.ContentLength > maxBodyBytes {
 			WriteProxyError(recorder, requestID, http.StatusRequestEntityTooLarge, "request_too_large", "request body too large")
 			return true
 		}
+		if bodyPolicy.Stream {
+			if request.Body != nil && request.Body != http.NoBody {
+				request.Body = http.MaxBytesReader(recorder, request.Body, maxBodyBytes)
+			}
+			return false
+		}
 		if request.ContentLength < 0 {
-			body, err := readBodyWithinLimit(request.Body, limitConfig.MaxBodyBytes)
+			body, err := readBodyWithinLimit(request.Body, maxBodyBytes)
 			if err != nil {
 				if errors.Is(err, errBodyTooLarge) {
 					WriteProxyError(recorder, requestID, http.StatusRequestEntityTooLarge, "request_too_large", "request body too large")
@@ -572,8 +983,83 @@ func (d *deadlineReadCloser) Close() error {
 	return d.inner.Close()
 }
 
-func applyResponseStreamTimeout(resp *http.Response, timeout time.Duration) {
-	if resp == nil || resp.Body == nil || timeout <= 0 {
+type idleReadCloser struct {
+	inner   io.ReadCloser
+	timeout time.Duration
+	timer   *time.Timer
+	expired atomic.Bool
+}
+
+func (d *idleReadCloser) Read(buffer []byte) (int, error) {
+	if d.timer == nil {
+		d.timer = time.AfterFunc(d.timeout, d.expire)
+	} else {
+		d.timer.Reset(d.timeout)
+	}
+	n, err := d.inner.Read(buffer)
+	d.timer.Stop()
+	if d.expired.Load() {
+		return n, context.DeadlineExceeded
+	}
+	return n, err
+}
+
+func (d *idleReadCloser) expire() {
+	d.expired.Store(true)
+	_ = d.inner.Close()
+}
+
+func (d *idleReadCloser) Close() error {
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	return d.inner.Close()
+}
+
+type phaseReadCloser struct {
+	inner  io.ReadCloser
+	ctx    context.Context
+	phase  string
+	marked bool
+}
+
+func (p *phaseReadCloser) Read(buffer []byte) (int, error) {
+	n, err := p.inner.Read(buffer)
+	if err == io.EOF && !p.marked {
+		p.marked = true
+		obs.MarkPhase(p.ctx, p.phase)
+	}
+	return n, err
+}
+
+func (p *phaseReadCloser) Close() error {
+	return p.inner.Close()
+}
+
+func serverTimingValue(phases obs.PhaseTimings) string {
+	format := func(name string, duration time.Duration) string {
+		return name + ";dur=" + strconv.FormatFloat(float64(duration.Microseconds())/1000, 'f', 3, 64)
+	}
+	parts := []string{format("route", phases.RouteMatch)}
+	if phases.PluginRequest > 0 {
+		parts = append(parts, format("plugin", phases.PluginRequest))
+	}
+	parts = append(parts, format("upstream", phases.UpstreamTTFB))
+	return strings.Join(parts, ", ")
+}
+
+func applyResponseStreamTimeout(resp *http.Response, routePolicy policy.Policy, limitConfig limits.Limits) {
+	if resp == nil || resp.Body == nil || isSSEResponse(resp, routePolicy.SSE) {
+		return
+	}
+	if routePolicy.IdleTimeout > 0 {
+		resp.Body = &idleReadCloser{inner: resp.Body, timeout: routePolicy.IdleTimeout}
+	}
+	timeout := limitConfig.ResponseStreamTimeout
+	if routePolicy.ResponseStreamTimeout > 0 {
+		timeout = routePolicy.ResponseStreamTimeout
+	}
+	if timeout <= 0 {
 		return
 	}
 	resp.Body = &deadlineReadCloser{inner: resp.Body, deadline: time.Now().Add(timeout)}
@@ -626,7 +1112,7 @@ func isCacheableResponse(resp *http.Response, cachePolicy policy.CachePolicy) (b
 	if resp == nil {
 		return false, 0
 	}
-	if resp.StatusCode != http.StatusOK {
+	if _, ok := cacheStatusTTL(cachePolicy, resp.StatusCode); !ok {
 		return false, 0
 	}
 	if hasNoStoreHeader(resp.Header) {
@@ -635,10 +1121,12 @@ func isCacheableResponse(resp *http.Response, cachePolicy policy.CachePolicy) (b
 	if hasChunkedEncoding(resp) {
 		return false, 0
 	}
-	contentType := strings.TrimSpace(resp.Header.Get("Content-Type"))
-	if strings.HasPrefix(strings.ToLower(contentType), "text/event-stream") {
+	if isEventStream(resp.Header) {
 		return false, 0
 	}
+	if resp.StatusCode == http.StatusNoContent {
+		return true, 0
+	}
 	contentLengthHeader := strings.TrimSpace(resp.Header.Get("Content-Length"))
 	if contentLengthHeader == "" {
 		return false, 0
@@ -656,6 +1144,14 @@ func isCacheableResponse(resp *http.Response, cachePolicy policy.CachePolicy) (b
 	return true, contentLength
 }
 
+func cacheStatusTTL(cachePolicy policy.CachePolicy, status int) (time.Duration, bool) {
+	if cachePolicy.StatusTTLs == nil {
+		return cachePolicy.TTL, status == http.StatusOK
+	}
+	ttl, ok := cachePolicy.StatusTTLs[status]
+	return ttl, ok
+}
+
 func hasChunkedEncoding(resp *http.Response) bool {
 	for _, encoding := range resp.TransferEncoding {
 		if strings.EqualFold(encoding, "chunked") {
@@ -694,12 +1190,97 @@ func cloneHeader(header http.Header) http.Header {
 	return result
 }
 
-func writeCachedResponse(w http.ResponseWriter, entry cache.Entry, requestID string, method string) {
+func writeCachedResponse(w http.ResponseWriter, entry cache.Entry, requestID string, r *http.Request) {
 	copyHeaders(w.Header(), entry.Header)
 	w.Header().Set(RequestIDHeader, requestID)
+	w.Header().Set("Age", strconv.FormatInt(int64(entry.Age(time.Now()).Seconds()), 10))
+	if etagMatches(r.Header.Get("If-None-Match"), entry.Header.Get("ETag")) {
+		w.Header().Del("Content-Length")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if entry.Status == http.StatusOK && w.Header().Get("Accept-Ranges") == "" {
+		w.Header().Set("Accept-Ranges", "bytes")
+	}
+	if rangeApplies(r, entry) && writeRangeResponse(w, entry, r.Header.Get("Range")) {
+		return
+	}
 	w.WriteHeader(entry.Status)
-	if method == http.MethodHead {
+	if r.Method == http.MethodHead {
 		return
 	}
 	_, _ = w.Write(entry.Body)
 }
+
+func cacheEntryTimes(status int, header http.Header, cachePolicy policy.CachePolicy, now time.Time) (time.Time, time.Time, bool) {
+	ttl, ok := cacheStatusTTL(cachePolicy, status)
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	if !cachePolicy.RespectCacheControl {
+		expiresAt := now.Add(ttl)
+		return expiresAt, expiresAt, true
+	}
+	lifetime, storable := cache.FreshnessLifetime(header, now, ttl)
+	if !storable {
+		return time.Time{}, time.Time{}, false
+	}
+	freshUntil := now.Add(lifetime)
+	expiresAt := freshUntil
+	if cache.HasValidator(header) {
+		expiresAt = expiresAt.Add(cachePolicy.RevalidateWindow)
+	}
+	if !expiresAt.After(now) {
+		return time.Time{}, time.Time{}, false
+	}
+	return freshUntil, expiresAt, true
+}
+
+func addRevalidationHeaders(r *http.Request, entry cache.Entry) bool {
+	if r.Header.Get("If-None-Match") != "" || r.Header.Get("If-Modified-Since") != "" {
+		return false
+	}
+	injected := false
+	if etag := entry.Header.Get("ETag"); etag != "" {
+		r.Header.Set("If-None-Match", etag)
+		injected = true
+	}
+	if lastModified := entry.Header.Get("Last-Modified"); lastModified != "" {
+		r.Header.Set("If-Modified-Since", lastModified)
+		injected = true
+	}
+	return injected
+}
+
+func refreshCacheEntry(entry cache.Entry, header http.Header, cachePolicy policy.CachePolicy, now time.Time) (cache.Entry, bool) {
+	merged := cloneHeader(entry.Header)
+	for key, values := range header {
+		if key == "Content-Length" || key == "Transfer-Encoding" || key == "Connection" {
+			continue
+		}
+		merged[key] = append([]string(nil), values...)
+	}
+	if len(header.Values("Age")) == 0 {
+		merged.Del("Age")
+	}
+	entry.Header = merged
+	entry.StoredAt = now
+	freshUntil, expiresAt, storable := cacheEntryTimes(entry.Status, merged, cachePolicy, now)
+	entry.FreshUntil = freshUntil
+	entry.ExpiresAt = expiresAt
+	return entry, storable
+}
+
+func etagMatches(ifNoneMatch string, etag string) bool {
+	if ifNoneMatch == "" || etag == "" {
+		return false
+	}
+	target := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+package proxy
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"modern_reverse_proxy/internal/policy"
+)
+
+const (
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	idempotencyStored   = "stored"
+	idempotencyReplayed = "replayed"
+	idempotencyConflict = "conflict"
+	idempotencyUncached = "not_stored"
+)
+
+type idempotencyStore struct {
+	mu     sync.Mutex
+	routes map[string]map[string]*idempotencyEntry
+}
+
+type idempotencyEntry struct {
+	done    bool
+	status  int
+	header  http.Header
+	body    []byte
+	expires time.Time
+}
+
+type idempotencyFlight struct {
+	store   *idempotencyStore
+	routeID string
+	key     string
+	policy  policy.IdempotencyPolicy
+}
+
+func idempotencyKey(r *http.Request, idempotencyPolicy policy.IdempotencyPolicy) string {
+	if !idempotencyPolicy.Enabled {
+		return ""
+	}
+	return r.Header.Get(idempotencyPolicy.Header)
+}
+
+func idempotencyScope(r *http.Request, authSubject string, clientIP string) string {
+	if authSubject != "" {
+		return "subject:" + authSubject
+	}
+	if clientIP != "" {
+		return "ip:" + clientIP
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+func (s *idempotencyStore) begin(routeID string, key string, idempotencyPolicy policy.IdempotencyPolicy, now time.Time) (idempotencyEntry, bool, *idempotencyFlight) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.routes == nil {
+		s.routes = make(map[string]map[string]*idempotencyEntry)
+	}
+	entries := s.routes[routeID]
+	if entries == nil {
+		entries = make(map[string]*idempotencyEntry)
+		s.routes[routeID] = entries
+	}
+	if entry := entries[key]; entry != nil {
+		if now.Before(entry.expires) {
+			return *entry, true, nil
+		}
+		delete(entries, key)
+	}
+	if len(entries) >= idempotencyPolicy.MaxEntries {
+		evictIdempotencyEntries(entries, now)
+	}
+	if len(entries) >= idempotencyPolicy.MaxEntries {
+		return idempotencyEntry{}, false, nil
+	}
+	entries[key] = &idempotencyEntry{expires: now.Add(idempotencyPolicy.ReplayTTL)}
+	return idempotencyEntry{}, false, &idempotencyFlight{store: s, routeID: routeID, key: key, policy: idempotencyPolicy}
+}
+
+func evictIdempotencyEntries(entries map[string]*idempotencyEntry, now time.Time) {
+	var oldestKey string
+	var oldest time.Time
+	for key, entry := range entries {
+		if !now.Before(entry.expires) {
+			delete(entries, key)
+			continue
+		}
+		if entry.done && (oldestKey == "" || entry.expires.Before(oldest)) {
+			oldestKey, oldest = key, entry.expires
+		}
+	}
+	if oldestKey != "" {
+		delete(entries, oldestKey)
+	}
+}
+
+func (f *idempotencyFlight) complete(resp *http.Response) {
+	f.store.mu.Lock()
+	defer f.store.mu.Unlock()
+	entry := f.store.routes[f.routeID][f.key]
+	if entry == nil || entry.done {
+		return
+	}
+	entry.done = true
+	entry.status = resp.StatusCode
+	entry.header = cloneHeader(resp.Header)
+	entry.expires = time.Now().Add(f.policy.ReplayTTL)
+	if body, ok := resp.Body.(*replayableBody); ok {
+		entry.body = body.data
+	}
+}
+
+func (f *idempotencyFlight) abort() {
+	f.store.mu.Lock()
+	defer f.store.mu.Unlock()
+	if entries := f.store.routes[f.routeID]; entries != nil {
+		if entry := entries[f.key]; entry != nil && !entry.done {
+			delete(entries, f.key)
+		}
+	}
+}
+
+type replayableBody struct {
+	*bytes.Reader
+	data []byte
+}
+
+func (b *replayableBody) Close() error {
+	return nil
+}
+
+func bufferIdempotentResponse(resp *http.Response, maxBytes int64) bool {
+	if resp.ContentLength > maxBytes {
+		return false
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil || int64(len(data)) > maxBytes {
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(data), resp.Body), resp.Body}
+		return false
+	}
+	_ = resp.Body.Close()
+	resp.Body = &replayableBody{Reader: bytes.NewReader(data), data: data}
+	return true
+}
+
+func writeIdempotentReplay(w http.ResponseWriter, entry idempotencyEntry, requestID string) {
+	copyHeaders(w.Header(), entry.header)
+	w.Header().Set(RequestIDHeader, requestID)
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(entry.status)
+	_, _ = w.Write(entry.body)
+}
@@ -0,0 +1,33 @@
+package proxy
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type Maintenance struct {
+	Status            int       `json:"status"`
+	Message           string    `json:"message"`
+	RetryAfterSeconds int       `json:"retry_after_seconds,omitempty"`
+	DisabledAt        time.Time `json:"disabled_at"`
+}
+
+type MaintenanceSource interface {
+	RouteMaintenance(routeID string) (Maintenance, bool)
+}
+
+func WriteMaintenance(w http.ResponseWriter, requestID string, maintenance Maintenance) {
+	status := maintenance.Status
+	if status == 0 {
+		status = http.StatusServiceUnavailable
+	}
+	message := maintenance.Message
+	if message == "" {
+		message = "route under maintenance"
+	}
+	if maintenance.RetryAfterSeconds > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(maintenance.RetryAfterSeconds))
+	}
+	WriteProxyError(w, requestID, status, "maintenance", message)
+}
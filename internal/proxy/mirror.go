@@ -0,0 +1,141 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"modern_reverse_proxy/internal/policy"
+	"modern_reverse_proxy/internal/pool"
+)
+
+type mirrorCounters struct {
+	mu       sync.Mutex
+	inflight map[string]*atomic.Int64
+}
+
+func (h *Handler) mirrorRequest(r *http.Request, route policy.Route) {
+	mirror := route.Policy.Mirror
+	if !mirror.Enabled || h.Engine == nil {
+		return
+	}
+	if mirror.Percent < 100 && rand.Float64()*100 >= mirror.Percent {
+		return
+	}
+	var body []byte
+	if r.Body != nil && r.Body != http.NoBody && r.ContentLength != 0 {
+		if r.ContentLength < 0 {
+			h.Engine.recordMirror(route.ID, mirror.Pool, "body_unknown_length")
+			return
+		}
+		if r.ContentLength > mirror.MaxBodyBytes {
+			h.Engine.recordMirror(route.ID, mirror.Pool, "body_too_large")
+			return
+		}
+		buffered, err := io.ReadAll(io.LimitReader(r.Body, mirror.MaxBodyBytes+1))
+		if err != nil || int64(len(buffered)) > mirror.MaxBodyBytes {
+			r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(buffered), r.Body))
+			result := "body_too_large"
+			if err != nil {
+				result = "body_error"
+			}
+			h.Engine.recordMirror(route.ID, mirror.Pool, result)
+			return
+		}
+		_ = r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(buffered))
+		body = buffered
+	}
+	h.Engine.Mirror(r, body, route.ID, mirror)
+}
+
+func (e *Engine) Mirror(r *http.Request, body []byte, routeID string, mirror policy.MirrorPolicy) {
+	counter := e.mirrorInflight(mirror.Pool)
+	if counter.Add(1) > int64(mirror.MaxInflight) {
+		counter.Add(-1)
+		e.recordMirror(routeID, mirror.Pool, "dropped")
+		return
+	}
+	if e.metrics != nil {
+		e.metrics.AddMirrorInflight(mirror.Pool, 1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mirror.Timeout)
+	shadow := r.Clone(ctx)
+	shadow.ContentLength = int64(len(body))
+	go func() {
+		defer func() {
+			cancel()
+			counter.Add(-1)
+			if e.metrics != nil {
+				e.metrics.AddMirrorInflight(mirror.Pool, -1)
+			}
+		}()
+		e.recordMirror(routeID, mirror.Pool, e.roundTripMirror(ctx, shadow, body, mirror))
+	}()
+}
+
+func (e *Engine) roundTripMirror(ctx context.Context, shadow *http.Request, body []byte, mirror policy.MirrorPolicy) string {
+	poolKey := pool.PoolKey(mirror.Pool)
+	transport, scheme, err := e.transportFor(poolKey)
+	if err != nil {
+		return "no_upstream"
+	}
+	pickResult, ok := e.registry.Pick(poolKey, nil)
+	if !ok || pickResult.Addr == "" {
+		return "no_upstream"
+	}
+	_, release := e.registry.Track(poolKey, pickResult.Addr)
+	defer release()
+
+	var requestBody io.ReadCloser = http.NoBody
+	if len(body) > 0 {
+		requestBody = io.NopCloser(bytes.NewReader(body))
+	}
+	resp, err := roundTripUpstream(ctx, shadow, scheme, pickResult.Addr, transport, requestBody)
+	if err != nil {
+		switch {
+		case isTimeoutError(err) || errors.Is(err, context.DeadlineExceeded):
+			return "timeout"
+		case isDialError(err):
+			return "connect_failed"
+		default:
+			return "error"
+		}
+	}
+	defer resp.Body.Close()
+	if mirror.IgnoreResponse {
+		return "sent"
+	}
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		return "error"
+	}
+	return fmt.Sprintf("%dxx", resp.StatusCode/100)
+}
+
+func (e *Engine) mirrorInflight(poolName string) *atomic.Int64 {
+	e.mirrors.mu.Lock()
+	defer e.mirrors.mu.Unlock()
+	if e.mirrors.inflight == nil {
+		e.mirrors.inflight = make(map[string]*atomic.Int64)
+	}
+	counter, ok := e.mirrors.inflight[poolName]
+	if !ok {
+		counter = &atomic.Int64{}
+		e.mirrors.inflight[poolName] = counter
+	}
+	return counter
+}
+
+func (e *Engine) recordMirror(routeID string, poolName string, result string) {
+	if e.metrics == nil {
+		return
+	}
+	e.metrics.RecordMirror(routeID, poolName, result)
+}
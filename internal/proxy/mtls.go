@@ -0,0 +1,126 @@
+package proxy
+
+import (
+	"crypto/x509"
+	"net/http"
+	"path"
+	"strings"
+
+	"modern_reverse_proxy/internal/policy"
+	"modern_reverse_proxy/internal/tlsstore"
+)
+
+func (h *Handler) authorizeMTLSIdentity(recorder *ResponseRecorder, r *http.Request, route policy.Route, requestID string) (string, bool) {
+	mtlsPolicy := route.Policy.MTLS
+	if mtlsPolicy.IdentityHeader != "" {
+		r.Header.Del(mtlsPolicy.IdentityHeader)
+	}
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return "", false
+	}
+	leaf := r.TLS.PeerCertificates[0]
+	spiffeID := certSPIFFEID(leaf)
+	identity := certIdentity(leaf, spiffeID)
+	restricted := len(mtlsPolicy.AllowedSANs) > 0 || len(mtlsPolicy.AllowedSPIFFEIDs) > 0
+	if restricted && !matchesSPIFFEID(mtlsPolicy.AllowedSPIFFEIDs, spiffeID) && !matchesSAN(mtlsPolicy.AllowedSANs, leaf) {
+		if h.Metrics != nil {
+			h.Metrics.RecordMTLSIdentity(route.ID, identity, "denied")
+		}
+		WriteProxyError(recorder, requestID, http.StatusForbidden, "mtls_identity_denied", "client identity not allowed")
+		return identity, true
+	}
+	if h.Metrics != nil {
+		h.Metrics.RecordMTLSIdentity(route.ID, identity, "allowed")
+	}
+	if mtlsPolicy.IdentityHeader != "" && identity != "" {
+		r.Header.Set(mtlsPolicy.IdentityHeader, identity)
+	}
+	return identity, false
+}
+
+func certSPIFFEID(cert *x509.Certificate) string {
+	for _, uri := range cert.URIs {
+		if strings.EqualFold(uri.Scheme, "spiffe") {
+			return uri.String()
+		}
+	}
+	return ""
+}
+
+func certIdentity(cert *x509.Certificate, spiffeID string) string {
+	switch {
+	case spiffeID != "":
+		return spiffeID
+	case len(cert.DNSNames) > 0:
+		return strings.ToLower(cert.DNSNames[0])
+	case len(cert.EmailAddresses) > 0:
+		return strings.ToLower(cert.EmailAddresses[0])
+	case len(cert.URIs) > 0:
+		return cert.URIs[0].String()
+	}
+	return cert.Subject.CommonName
+}
+
+func matchesSPIFFEID(patterns []string, spiffeID string) bool {
+	if spiffeID == "" {
+		return false
+	}
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, spiffeID); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesSAN(patterns []string, cert *x509.Certificate) bool {
+	if len(patterns) == 0 {
+		return false
+	}
+	sans := make([]string, 0, len(cert.DNSNames)+len(cert.EmailAddresses)+len(cert.URIs)+len(cert.IPAddresses))
+	sans = append(sans, cert.DNSNames...)
+	sans = append(sans, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		sans = append(sans, uri.String())
+	}
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	for _, san := range sans {
+		san = strings.ToLower(san)
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, san); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (h *Handler) enforceRevocation(recorder *ResponseRecorder, r *http.Request, route policy.Route, requestID string, chain []*x509.Certificate) bool {
+	checker := route.Policy.MTLS.Revocation
+	if checker == nil || len(chain) == 0 {
+		return false
+	}
+	var issuer *x509.Certificate
+	if len(chain) > 1 {
+		issuer = chain[1]
+	}
+	outcomes, allowed := checker.Check(r.Context(), chain[0], issuer)
+	if h.Metrics != nil {
+		for _, outcome := range outcomes {
+			h.Metrics.RecordRevocationCheck(route.ID, outcome.Method, outcome.Result)
+		}
+	}
+	if allowed {
+		return false
+	}
+	for _, outcome := range outcomes {
+		if outcome.Result == tlsstore.RevocationRevoked {
+			WriteProxyError(recorder, requestID, http.StatusForbidden, "mtls_revoked", "client certificate revoked")
+			return true
+		}
+	}
+	WriteProxyError(recorder, requestID, http.StatusForbidden, "mtls_revocation_unavailable", "client certificate revocation status unavailable")
+	return true
+}
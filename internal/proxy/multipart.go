@@ -0,0 +1,158 @@
+package proxy
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"sync"
+
+	"modern_reverse_proxy/internal/policy"
+)
+
+type multipartLimitError struct {
+	status   int
+	category string
+	message  string
+	reason   string
+}
+
+func (e *multipartLimitError) Error() string {
+	return e.message
+}
+
+var (
+	errMultipartPartTooLarge = &multipartLimitError{status: http.StatusRequestEntityTooLarge, category: "multipart_part_too_large", message: "multipart part too large", reason: "part_too_large"}
+	errMultipartTooManyParts = &multipartLimitError{status: http.StatusRequestEntityTooLarge, category: "multipart_too_many_parts", message: "too many multipart parts", reason: "too_many_parts"}
+	errMultipartContentType  = &multipartLimitError{status: http.StatusUnsupportedMediaType, category: "multipart_content_type", message: "multipart part content type not allowed", reason: "content_type"}
+	errMultipartMalformed    = &multipartLimitError{status: http.StatusBadRequest, category: "multipart_invalid", message: "malformed multipart body", reason: "malformed"}
+)
+
+type multipartLimitReader struct {
+	source   io.ReadCloser
+	boundary string
+	policy   policy.MultipartPolicy
+	onReject func(reason string)
+	reader   *io.PipeReader
+	writer   *io.PipeWriter
+	output   *multipart.Writer
+	start    sync.Once
+}
+
+func limitMultipartRequest(request *http.Request, multipartPolicy policy.MultipartPolicy, onReject func(reason string)) *multipartLimitReader {
+	if request == nil || !multipartPolicy.Enabled {
+		return nil
+	}
+	if request.Body == nil || request.Body == http.NoBody {
+		return nil
+	}
+	mediaType, params, err := mime.ParseMediaType(request.Header.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") || params["boundary"] == "" {
+		return nil
+	}
+	reader, writer := io.Pipe()
+	body := &multipartLimitReader{
+		source:   request.Body,
+		boundary: params["boundary"],
+		policy:   multipartPolicy,
+		onReject: onReject,
+		reader:   reader,
+		writer:   writer,
+		output:   multipart.NewWriter(writer),
+	}
+	params["boundary"] = body.output.Boundary()
+	request.Header.Set("Content-Type", mime.FormatMediaType(mediaType, params))
+	request.Header.Del("Content-Length")
+	request.ContentLength = -1
+	request.Body = body
+	return body
+}
+
+func (b *multipartLimitReader) Read(buffer []byte) (int, error) {
+	b.start.Do(func() {
+		go func() {
+			_ = b.writer.CloseWithError(b.rewrite())
+		}()
+	})
+	return b.reader.Read(buffer)
+}
+
+func (b *multipartLimitReader) Close() error {
+	_ = b.reader.Close()
+	return b.source.Close()
+}
+
+func (b *multipartLimitReader) rewrite() error {
+	parts := multipart.NewReader(b.source, b.boundary)
+	count := 0
+	for {
+		part, err := parts.NextRawPart()
+		if errors.Is(err, io.EOF) {
+			return b.output.Close()
+		}
+		if err != nil {
+			return b.readFailure(err)
+		}
+		count++
+		if b.policy.MaxParts > 0 && count > b.policy.MaxParts {
+			return b.reject(errMultipartTooManyParts)
+		}
+		if !b.allowed(part) {
+			if !b.policy.StripDisallowed {
+				return b.reject(errMultipartContentType)
+			}
+			b.onReject("stripped")
+			continue
+		}
+		destination, err := b.output.CreatePart(part.Header)
+		if err != nil {
+			return err
+		}
+		var source io.Reader = part
+		if b.policy.MaxPartBytes > 0 {
+			source = io.LimitReader(part, b.policy.MaxPartBytes+1)
+		}
+		written, err := io.Copy(destination, source)
+		if err != nil {
+			return b.readFailure(err)
+		}
+		if b.policy.MaxPartBytes > 0 && written > b.policy.MaxPartBytes {
+			return b.reject(errMultipartPartTooLarge)
+		}
+	}
+}
+
+func (b *multipartLimitReader) allowed(part *multipart.Part) bool {
+	if len(b.policy.AllowedContentTypes) == 0 || part.FileName() == "" {
+		return true
+	}
+	contentType := "application/octet-stream"
+	if value := part.Header.Get("Content-Type"); value != "" {
+		mediaType, _, err := mime.ParseMediaType(value)
+		if err != nil {
+			return false
+		}
+		contentType = mediaType
+	}
+	mainType, _, _ := strings.Cut(contentType, "/")
+	for _, allowed := range b.policy.AllowedContentTypes {
+		if allowed == contentType || allowed == mainType+"/*" {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *multipartLimitReader) readFailure(err error) error {
+	if isRequestBodyTooLarge(err) || errors.Is(err, io.ErrClosedPipe) {
+		return err
+	}
+	return b.reject(errMultipartMalformed)
+}
+
+func (b *multipartLimitReader) reject(err *multipartLimitError) error {
+	b.onReject(err.reason)
+	return err
+}
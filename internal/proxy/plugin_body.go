@@ -0,0 +1,149 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+
+	"modern_reverse_proxy/internal/plugin"
+	"modern_reverse_proxy/internal/plugin/proto"
+)
+
+type replayReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func replayBody(consumed []byte, body io.ReadCloser) io.ReadCloser {
+	if len(consumed) == 0 {
+		return body
+	}
+	return replayReadCloser{Reader: io.MultiReader(bytes.NewReader(consumed), body), Closer: body}
+}
+
+func (h *Handler) inspectRequestBody(recorder *ResponseRecorder, r *http.Request, routeID string, requestID string, filter plugin.Filter, client *plugin.Client, tracking *pluginTracking) bool {
+	if r.Body == nil || r.Body == http.NoBody {
+		return false
+	}
+	breaker, allowed := h.allowBodyInspection(filter, tracking, "request_body")
+	if !allowed {
+		if filter.FailureMode != plugin.FailureModeFailClose {
+			return false
+		}
+		WriteProxyError(recorder, requestID, http.StatusServiceUnavailable, "plugin_unavailable", "plugin unavailable")
+		return true
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), filter.BodyTimeout)
+	inspection, err := plugin.InspectBody(ctx, client, plugin.BodyChunkMeta{
+		RequestID: requestID,
+		RouteID:   routeID,
+		Phase:     pluginpb.BodyChunk_REQUEST,
+	}, r.Body, filter.MaxBodyBytes)
+	cancel()
+	r.Body = replayBody(inspection.Consumed, r.Body)
+	if err != nil {
+		if breaker != nil {
+			breaker.Report(false)
+		}
+		return h.handlePluginFailure(recorder, requestID, filter, tracking, "request_body", pluginErrorResult(err))
+	}
+	return h.applyBodyVerdict(recorder, r, requestID, filter, breaker, inspection.Verdict, tracking, "request_body")
+}
+
+func (h *Handler) inspectResponseBody(recorder *ResponseRecorder, r *http.Request, resp *http.Response, routeID string, requestID string, filter plugin.Filter, client *plugin.Client, tracking *pluginTracking) bool {
+	if resp.Body == nil || resp.Body == http.NoBody {
+		return false
+	}
+	breaker, allowed := h.allowBodyInspection(filter, tracking, "response_body")
+	if !allowed {
+		if filter.FailureMode != plugin.FailureModeFailClose {
+			return false
+		}
+		WriteProxyError(recorder, requestID, http.StatusServiceUnavailable, "plugin_unavailable", "plugin unavailable")
+		resp.Body.Close()
+		return true
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), filter.BodyTimeout)
+	inspection, err := plugin.InspectBody(ctx, client, plugin.BodyChunkMeta{
+		RequestID: requestID,
+		RouteID:   routeID,
+		Phase:     pluginpb.BodyChunk_RESPONSE,
+	}, resp.Body, filter.MaxBodyBytes)
+	cancel()
+	resp.Body = replayBody(inspection.Consumed, resp.Body)
+	handled := false
+	if err != nil {
+		if breaker != nil {
+			breaker.Report(false)
+		}
+		handled = h.handlePluginFailure(recorder, requestID, filter, tracking, "response_body", pluginErrorResult(err))
+	} else {
+		handled = h.applyBodyVerdict(recorder, r, requestID, filter, breaker, inspection.Verdict, tracking, "response_body")
+	}
+	if handled {
+		resp.Body.Close()
+	}
+	return handled
+}
+
+func (h *Handler) allowBodyInspection(filter plugin.Filter, tracking *pluginTracking, phase string) (*plugin.Breaker, bool) {
+	if h.PluginRegistry == nil {
+		return nil, true
+	}
+	breaker := h.PluginRegistry.GetBreaker(plugin.FilterKey(filter.Name, filter.Addr)+":"+phase, filter.Breaker)
+	if breaker == nil {
+		return nil, true
+	}
+	if _, allowed := breaker.Allow(); allowed {
+		return breaker, true
+	}
+	if h.Metrics != nil {
+		h.Metrics.RecordPluginCall(filter.Name, phase, "breaker_bypass")
+		h.Metrics.RecordPluginBypass(filter.Name, "breaker_open")
+	}
+	tracking.markBypass("breaker_open", filter.FailureMode)
+	if filter.FailureMode == plugin.FailureModeFailClose {
+		tracking.markFailureMode(filter.FailureMode)
+		if h.Metrics != nil {
+			h.Metrics.RecordPluginFailClosed(filter.Name)
+		}
+	}
+	return nil, false
+}
+
+func (h *Handler) applyBodyVerdict(recorder *ResponseRecorder, r *http.Request, requestID string, filter plugin.Filter, breaker *plugin.Breaker, verdict *pluginpb.BodyVerdict, tracking *pluginTracking, phase string) bool {
+	if verdict.GetAction() == pluginpb.BodyVerdict_CONTINUE {
+		if h.Metrics != nil {
+			h.Metrics.RecordPluginCall(filter.Name, phase, "success")
+		}
+		if breaker != nil {
+			breaker.Report(true)
+		}
+		return false
+	}
+	status := int(verdict.GetResponseStatus())
+	if verdict.GetAction() != pluginpb.BodyVerdict_RESPOND || status <= 0 {
+		if breaker != nil {
+			breaker.Report(false)
+		}
+		return h.handlePluginFailure(recorder, requestID, filter, tracking, phase, "error")
+	}
+	if h.Metrics != nil {
+		h.Metrics.RecordPluginCall(filter.Name, phase, "success")
+		h.Metrics.RecordPluginShortCircuit(filter.Name)
+	}
+	if breaker != nil {
+		breaker.Report(true)
+	}
+	if plugin.ApplyHeaderMutations(recorder.Header(), verdict.GetResponseHeaders()) {
+		tracking.markMutationDenied()
+	}
+	recorder.Header().Set(RequestIDHeader, requestID)
+	recorder.WriteHeader(status)
+	if r.Method != http.MethodHead {
+		_, _ = recorder.Write(verdict.GetResponseBody())
+	}
+	tracking.markShortCircuit()
+	return true
+}
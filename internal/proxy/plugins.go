@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"time"
 
 	"modern_reverse_proxy/internal/plugin"
 	"modern_reverse_proxy/internal/plugin/proto"
@@ -67,6 +68,13 @@ func (h *Handler) applyRequestPlugins(recorder *ResponseRecorder, r *http.Reques
 			return false
 		}
 
+		if filter.Type == plugin.FilterTypeWASM {
+			if h.applyWASMRequestFilter(recorder, r, route, requestID, filter, tracking) {
+				return true
+			}
+			continue
+		}
+
 		if h.PluginRegistry == nil {
 			if h.handlePluginFailure(recorder, requestID, filter, tracking, "request", "error") {
 				return true
@@ -108,6 +116,8 @@ func (h *Handler) applyRequestPlugins(recorder *ResponseRecorder, r *http.Reques
 		}
 
 		ctx, cancel := context.WithTimeout(r.Context(), filter.RequestTimeout)
+		ctx, pluginSpan := startPluginSpan(ctx, filter, "request")
+		callStart := time.Now()
 		resp, err := client.ApplyRequest(ctx, &pluginpb.ApplyRequestRequest{
 			RequestId:   requestID,
 			RouteId:     route.ID,
@@ -117,6 +127,8 @@ func (h *Handler) applyRequestPlugins(recorder *ResponseRecorder, r *http.Reques
 			Headers:     plugin.HeadersToMap(r.Header),
 			BodyPreview: nil,
 		})
+		finishPluginSpan(pluginSpan, err)
+		debugTrace(r.Context(), "plugin_call", "filter", filter.Name, "phase", "request", "duration_ms", time.Since(callStart).Milliseconds(), "action", resp.GetAction().String(), "error", err)
 		cancel()
 		if err != nil {
 			if breaker != nil {
@@ -150,6 +162,9 @@ func (h *Handler) applyRequestPlugins(recorder *ResponseRecorder, r *http.Reques
 			if plugin.ApplyHeaderMutations(r.Header, resp.GetMutatedHeaders()) {
 				tracking.markMutationDenied()
 			}
+			if filter.InspectBody && h.inspectRequestBody(recorder, r, route.ID, requestID, filter, client, tracking) {
+				return true
+			}
 			continue
 		case pluginpb.ApplyRequestResponse_RESPOND:
 			status := int(resp.GetResponseStatus())
@@ -206,6 +221,12 @@ func (h *Handler) applyResponsePlugins(recorder *ResponseRecorder, r *http.Reque
 		if r.Context().Err() != nil {
 			return false
 		}
+		if filter.Type == plugin.FilterTypeWASM {
+			if h.applyWASMResponseFilter(recorder, r, resp, route, requestID, filter, tracking) {
+				return true
+			}
+			continue
+		}
 		if h.PluginRegistry == nil {
 			if h.handlePluginFailure(recorder, requestID, filter, tracking, "response", "error") {
 				if resp.Body != nil {
@@ -255,12 +276,16 @@ func (h *Handler) applyResponsePlugins(recorder *ResponseRecorder, r *http.Reque
 		}
 
 		ctx, cancel := context.WithTimeout(r.Context(), filter.ResponseTimeout)
+		ctx, pluginSpan := startPluginSpan(ctx, filter, "response")
+		callStart := time.Now()
 		pluginResp, err := client.ApplyResponse(ctx, &pluginpb.ApplyResponseRequest{
 			RequestId:       requestID,
 			RouteId:         route.ID,
 			UpstreamStatus:  int32(resp.StatusCode),
 			UpstreamHeaders: plugin.HeadersToMap(resp.Header),
 		})
+		finishPluginSpan(pluginSpan, err)
+		debugTrace(r.Context(), "plugin_call", "filter", filter.Name, "phase", "response", "duration_ms", time.Since(callStart).Milliseconds(), "error", err)
 		cancel()
 		if err != nil {
 			if breaker != nil {
@@ -298,6 +323,9 @@ func (h *Handler) applyResponsePlugins(recorder *ResponseRecorder, r *http.Reque
 		if plugin.ApplyHeaderMutations(resp.Header, pluginResp.GetMutatedHeaders()) {
 			tracking.markMutationDenied()
 		}
+		if filter.InspectBody && h.inspectResponseBody(recorder, r, resp, route.ID, requestID, filter, client, tracking) {
+			return true
+		}
 	}
 	return false
 }
@@ -0,0 +1,183 @@
+package proxy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+
+	"modern_reverse_proxy/internal/cache"
+)
+
+const maxByteRanges = 16
+
+var (
+	errRangeNotSatisfiable = errors.New("range not satisfiable")
+	errRangeTooLarge       = errors.New("ranges exceed entity size")
+)
+
+type byteRange struct {
+	start  int64
+	length int64
+}
+
+func (b byteRange) contentRange(size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", b.start, b.start+b.length-1, size)
+}
+
+func parseByteRanges(header string, size int64) ([]byteRange, error) {
+	const prefix = "bytes="
+	if !strings.HasPrefix(header, prefix) {
+		return nil, errors.New("invalid range unit")
+	}
+	specs := strings.Split(header[len(prefix):], ",")
+	if len(specs) > maxByteRanges {
+		return nil, errors.New("too many ranges")
+	}
+	var ranges []byteRange
+	noOverlap := false
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		startText, endText, ok := strings.Cut(spec, "-")
+		if !ok {
+			return nil, errors.New("invalid range")
+		}
+		startText = strings.TrimSpace(startText)
+		endText = strings.TrimSpace(endText)
+		var r byteRange
+		if startText == "" {
+			suffix, err := strconv.ParseInt(endText, 10, 64)
+			if err != nil || suffix < 0 {
+				return nil, errors.New("invalid range")
+			}
+			if suffix > size {
+				suffix = size
+			}
+			if suffix == 0 {
+				noOverlap = true
+				continue
+			}
+			r = byteRange{start: size - suffix, length: suffix}
+		} else {
+			start, err := strconv.ParseInt(startText, 10, 64)
+			if err != nil || start < 0 {
+				return nil, errors.New("invalid range")
+			}
+			if start >= size {
+				noOverlap = true
+				continue
+			}
+			end := size - 1
+			if endText != "" {
+				end, err = strconv.ParseInt(endText, 10, 64)
+				if err != nil || end < start {
+					return nil, errors.New("invalid range")
+				}
+				if end >= size {
+					end = size - 1
+				}
+			}
+			r = byteRange{start: start, length: end - start + 1}
+		}
+		ranges = append(ranges, r)
+	}
+	if len(ranges) == 0 {
+		if noOverlap {
+			return nil, errRangeNotSatisfiable
+		}
+		return nil, errors.New("invalid range")
+	}
+	var total int64
+	for _, r := range ranges {
+		total += r.length
+	}
+	if total > size {
+		return nil, errRangeTooLarge
+	}
+	return coalesceRanges(ranges), nil
+}
+
+func coalesceRanges(ranges []byteRange) []byteRange {
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].start < ranges[j].start })
+	merged := ranges[:1]
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if r.start > last.start+last.length {
+			merged = append(merged, r)
+			continue
+		}
+		if end := r.start + r.length; end > last.start+last.length {
+			last.length = end - last.start
+		}
+	}
+	return merged
+}
+
+func rangeApplies(r *http.Request, entry cache.Entry) bool {
+	if r.Method != http.MethodGet || entry.Status != http.StatusOK || r.Header.Get("Range") == "" {
+		return false
+	}
+	ifRange := strings.TrimSpace(r.Header.Get("If-Range"))
+	if ifRange == "" {
+		return true
+	}
+	if strings.HasPrefix(ifRange, `"`) {
+		etag := entry.Header.Get("ETag")
+		return etag != "" && !strings.HasPrefix(etag, "W/") && etag == ifRange
+	}
+	return ifRange == entry.Header.Get("Last-Modified")
+}
+
+func writeRangeResponse(w http.ResponseWriter, entry cache.Entry, rangeHeader string) bool {
+	size := int64(len(entry.Body))
+	ranges, err := parseByteRanges(rangeHeader, size)
+	if errors.Is(err, errRangeNotSatisfiable) {
+		w.Header().Del("Content-Length")
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+		w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+		return true
+	}
+	if err != nil {
+		return false
+	}
+	if len(ranges) == 1 {
+		part := ranges[0]
+		w.Header().Set("Content-Range", part.contentRange(size))
+		w.Header().Set("Content-Length", strconv.FormatInt(part.length, 10))
+		w.WriteHeader(http.StatusPartialContent)
+		_, _ = w.Write(entry.Body[part.start : part.start+part.length])
+		return true
+	}
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	contentType := entry.Header.Get("Content-Type")
+	for _, part := range ranges {
+		partHeader := textproto.MIMEHeader{}
+		if contentType != "" {
+			partHeader.Set("Content-Type", contentType)
+		}
+		partHeader.Set("Content-Range", part.contentRange(size))
+		writer, err := parts.CreatePart(partHeader)
+		if err != nil {
+			return false
+		}
+		_, _ = writer.Write(entry.Body[part.start : part.start+part.length])
+	}
+	if err := parts.Close(); err != nil {
+		return false
+	}
+	w.Header().Set("Content-Type", "multipart/byteranges; boundary="+parts.Boundary())
+	w.Header().Set("Content-Length", strconv.Itoa(body.Len()))
+	w.WriteHeader(http.StatusPartialContent)
+	_, _ = w.Write(body.Bytes())
+	return true
+}
@@ -0,0 +1,41 @@
+package proxy
+
+import (
+	"net"
+	"net/http"
+
+	"modern_reverse_proxy/internal/policy"
+)
+
+const maxRequestIDLength = 128
+
+func resolveRequestID(r *http.Request, requestIDPolicy policy.RequestIDPolicy, inbound string) string {
+	if inbound == "" || !validRequestID(inbound) {
+		return NewRequestID()
+	}
+	switch requestIDPolicy.Mode {
+	case "regenerate":
+		return NewRequestID()
+	case "trusted":
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		if ip := net.ParseIP(host); ip == nil || !matchesAny(requestIDPolicy.TrustedCIDRs, ip) {
+			return NewRequestID()
+		}
+	}
+	return inbound
+}
+
+func validRequestID(requestID string) bool {
+	if len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] <= ' ' || requestID[i] >= 0x7f {
+			return false
+		}
+	}
+	return true
+}
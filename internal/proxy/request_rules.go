@@ -0,0 +1,68 @@
+package proxy
+
+import (
+	"net/http"
+	"time"
+
+	"modern_reverse_proxy/internal/policy"
+)
+
+func (h *Handler) enforceRequestRules(recorder *ResponseRecorder, r *http.Request, route policy.Route, globalRules []policy.RequestRule, requestID string) (string, bool) {
+	scopes := []struct {
+		name  string
+		rules []policy.RequestRule
+	}{
+		{"global", globalRules},
+		{"route", route.Policy.RequestRules},
+	}
+	for _, scope := range scopes {
+		for _, rule := range scope.rules {
+			if !requestRuleMatches(rule, r) {
+				continue
+			}
+			if h.Metrics != nil {
+				h.Metrics.RecordRequestRuleMatch(route.ID, scope.name, rule.Name, rule.Action)
+			}
+			if rule.Action == "allow" {
+				return rule.Name, false
+			}
+			if rule.Action == "tarpit" {
+				timer := time.NewTimer(rule.Tarpit)
+				select {
+				case <-timer.C:
+				case <-r.Context().Done():
+					timer.Stop()
+				}
+			}
+			WriteProxyError(recorder, requestID, rule.Status, "request_blocked", "request blocked")
+			return rule.Name, true
+		}
+	}
+	return "", false
+}
+
+func requestRuleMatches(rule policy.RequestRule, r *http.Request) bool {
+	if rule.Methods != nil && !rule.Methods[r.Method] {
+		return false
+	}
+	if rule.Path != nil && !rule.Path.MatchString(r.URL.Path) {
+		return false
+	}
+	for _, header := range rule.Headers {
+		values := r.Header.Values(header.Name)
+		if len(values) == 0 {
+			values = []string{""}
+		}
+		matched := false
+		for _, value := range values {
+			if header.Pattern.MatchString(value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
@@ -1,6 +1,10 @@
 package proxy
 
-import "net/http"
+import (
+	"net/http"
+
+	"modern_reverse_proxy/internal/policy"
+)
 
 type ResponseRecorder struct {
 	writer        http.ResponseWriter
@@ -8,12 +12,18 @@ type ResponseRecorder struct {
 	bytesWritten  int64
 	wroteHeader   bool
 	errorCategory string
+	errorPages    []policy.ErrorPage
+	headers       http.Header
 }
 
 type errorCategoryWriter interface {
 	SetErrorCategory(string)
 }
 
+type errorPageWriter interface {
+	ErrorPages() []policy.ErrorPage
+}
+
 func NewResponseRecorder(w http.ResponseWriter) *ResponseRecorder {
 	return &ResponseRecorder{writer: w, status: http.StatusOK}
 }
@@ -26,6 +36,9 @@ func (r *ResponseRecorder) WriteHeader(status int) {
 	if !r.wroteHeader {
 		r.status = status
 		r.wroteHeader = true
+		for key, values := range r.headers {
+			r.writer.Header()[key] = values
+		}
 	}
 	r.writer.WriteHeader(status)
 }
@@ -39,6 +52,10 @@ func (r *ResponseRecorder) Write(data []byte) (int, error) {
 	return n, err
 }
 
+func (r *ResponseRecorder) Unwrap() http.ResponseWriter {
+	return r.writer
+}
+
 func (r *ResponseRecorder) Status() int {
 	return r.status
 }
@@ -55,6 +72,23 @@ func (r *ResponseRecorder) ErrorCategory() string {
 	return r.errorCategory
 }
 
+func (r *ResponseRecorder) SetErrorPages(pages []policy.ErrorPage) {
+	r.errorPages = pages
+}
+
+func (r *ResponseRecorder) ErrorPages() []policy.ErrorPage {
+	return r.errorPages
+}
+
+func (r *ResponseRecorder) SetResponseHeaders(headers http.Header) {
+	if r.headers == nil {
+		r.headers = make(http.Header, len(headers))
+	}
+	for key, values := range headers {
+		r.headers[key] = values
+	}
+}
+
 func (r *ResponseRecorder) WroteHeader() bool {
 	return r.wroteHeader
 }
@@ -0,0 +1,64 @@
+package proxy
+
+import (
+	"errors"
+	"net/http"
+
+	"modern_reverse_proxy/internal/plugin"
+	"modern_reverse_proxy/internal/policy"
+	"modern_reverse_proxy/internal/script"
+)
+
+func (h *Handler) runRouteScript(recorder *ResponseRecorder, r *http.Request, route policy.Route, requestID string) (*script.Result, bool) {
+	scriptPolicy := route.Policy.Script
+	if scriptPolicy.Program == nil {
+		return nil, false
+	}
+	result, err := scriptPolicy.Program.Run(r.Context(), script.Request{
+		Method:   r.Method,
+		Host:     r.Host,
+		Path:     r.URL.Path,
+		Query:    r.URL.RawQuery,
+		RemoteIP: bandwidthClientKey(r),
+		Header:   r.Header,
+	})
+	if err != nil {
+		outcome := "error"
+		if errors.Is(err, script.ErrTimeout) {
+			outcome = "timeout"
+		}
+		if h.Metrics != nil {
+			h.Metrics.RecordScriptResult(route.ID, outcome)
+		}
+		if scriptPolicy.FailOpen {
+			return nil, false
+		}
+		WriteProxyError(recorder, requestID, http.StatusInternalServerError, "script_"+outcome, "route script failed")
+		return nil, true
+	}
+	if result.Denied {
+		if h.Metrics != nil {
+			h.Metrics.RecordScriptResult(route.ID, "deny")
+		}
+		status := result.Status
+		if status < 400 || status > 599 {
+			status = http.StatusForbidden
+		}
+		if result.Body == "" {
+			WriteProxyError(recorder, requestID, status, "script_denied", "request denied")
+			return result, true
+		}
+		recorder.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		recorder.Header().Set(RequestIDHeader, requestID)
+		recorder.WriteHeader(status)
+		if r.Method != http.MethodHead {
+			_, _ = recorder.Write([]byte(result.Body))
+		}
+		return result, true
+	}
+	if h.Metrics != nil {
+		h.Metrics.RecordScriptResult(route.ID, "allow")
+	}
+	plugin.ApplyHeaderMutations(r.Header, result.SetHeaders)
+	return result, false
+}
@@ -0,0 +1,16 @@
+package proxy
+
+import (
+	"net/http"
+
+	"modern_reverse_proxy/internal/policy"
+)
+
+func applySecurityHeaders(recorder *ResponseRecorder, r *http.Request, securityPolicy policy.SecurityHeadersPolicy) {
+	if len(securityPolicy.Headers) > 0 {
+		recorder.SetResponseHeaders(securityPolicy.Headers)
+	}
+	if securityPolicy.HSTS != "" && r.TLS != nil {
+		recorder.SetResponseHeaders(http.Header{"Strict-Transport-Security": {securityPolicy.HSTS}})
+	}
+}
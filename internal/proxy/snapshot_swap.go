@@ -0,0 +1,78 @@
+package proxy
+
+import (
+	"net/http"
+	"time"
+
+	"modern_reverse_proxy/internal/policy"
+	"modern_reverse_proxy/internal/pool"
+	"modern_reverse_proxy/internal/runtime"
+	"modern_reverse_proxy/internal/traffic"
+)
+
+const (
+	staleActionKept      = "kept"
+	staleActionRematched = "rematched"
+	staleActionRouteGone = "route_gone"
+)
+
+type swapTarget struct {
+	snapshot   *runtime.Snapshot
+	route      policy.Route
+	poolName   string
+	poolKey    pool.PoolKey
+	stableKey  string
+	poolConfig runtime.PoolConfig
+}
+
+func (h *Handler) rematchOnSwap(r *http.Request, snap *runtime.Snapshot, route policy.Route, variant traffic.Variant, arrived time.Time) (*swapTarget, string) {
+	current := h.Store.Get()
+	if current == nil || current == snap {
+		return nil, ""
+	}
+	swapPolicy := route.Policy.SnapshotSwap
+	if !swapPolicy.Rematch || time.Since(arrived) < swapPolicy.RematchAfter {
+		return nil, staleActionKept
+	}
+
+	next := h.Store.Acquire()
+	if next == nil || next.Router == nil || next.FailSafe {
+		h.Store.Release(next)
+		return nil, staleActionRouteGone
+	}
+	nextRoute, ok := next.Router.Match(r)
+	if !ok {
+		h.Store.Release(next)
+		return nil, staleActionRouteGone
+	}
+	poolName := nextRoute.PoolName
+	stableKey := nextRoute.StablePoolKey
+	if stableKey == "" {
+		stableKey = nextRoute.ID + "::" + nextRoute.PoolName
+	}
+	if variant == traffic.VariantCanary && nextRoute.CanaryPoolName != "" {
+		poolName = nextRoute.CanaryPoolName
+		stableKey = nextRoute.CanaryPoolKey
+		if stableKey == "" {
+			stableKey = nextRoute.ID + "::" + nextRoute.CanaryPoolName
+		}
+	}
+	poolKey, ok := next.Pools[poolName]
+	if !ok || poolKey == "" {
+		h.Store.Release(next)
+		return nil, staleActionRouteGone
+	}
+	poolConfig, ok := next.PoolConfigs[poolName]
+	if !ok {
+		h.Store.Release(next)
+		return nil, staleActionRouteGone
+	}
+	return &swapTarget{
+		snapshot:   next,
+		route:      nextRoute,
+		poolName:   poolName,
+		poolKey:    poolKey,
+		stableKey:  stableKey,
+		poolConfig: poolConfig,
+	}, staleActionRematched
+}
@@ -0,0 +1,127 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"modern_reverse_proxy/internal/policy"
+)
+
+const sseMaxPendingBytes = 64 * 1024
+
+var sseHeartbeat = []byte(":\n\n")
+
+func isEventStream(header http.Header) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(header.Get("Content-Type"))), "text/event-stream")
+}
+
+func isSSEResponse(resp *http.Response, ssePolicy policy.SSEPolicy) bool {
+	return ssePolicy.Enabled && resp != nil && resp.Body != nil && isEventStream(resp.Header)
+}
+
+func writeEventStream(ctx context.Context, w http.ResponseWriter, recorder *ResponseRecorder, resp *http.Response, requestID string, ssePolicy policy.SSEPolicy, onHeartbeat func()) {
+	defer resp.Body.Close()
+	controller := http.NewResponseController(recorder)
+	_ = controller.SetWriteDeadline(time.Time{})
+	copyHeaders(w.Header(), resp.Header)
+	w.Header().Del("Content-Length")
+	w.Header().Set(RequestIDHeader, requestID)
+	w.WriteHeader(resp.StatusCode)
+	if controller.Flush() != nil {
+		_, _ = io.Copy(w, resp.Body)
+		return
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	chunks := make(chan []byte)
+	go func() {
+		defer close(chunks)
+		buffer := make([]byte, 32*1024)
+		for {
+			n, err := resp.Body.Read(buffer)
+			if n > 0 {
+				select {
+				case chunks <- append([]byte(nil), buffer[:n]...):
+				case <-done:
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTimer(ssePolicy.HeartbeatInterval)
+	defer heartbeat.Stop()
+	var pending []byte
+	midEvent := false
+	for {
+		select {
+		case chunk, ok := <-chunks:
+			if !ok {
+				if len(pending) > 0 {
+					_, _ = w.Write(pending)
+					_ = controller.Flush()
+				}
+				return
+			}
+			pending = append(pending, chunk...)
+			if boundary := lastEventBoundary(pending); boundary > 0 {
+				if _, err := w.Write(pending[:boundary]); err != nil {
+					return
+				}
+				pending = append(pending[:0], pending[boundary:]...)
+				midEvent = false
+				if controller.Flush() != nil {
+					return
+				}
+			}
+			if len(pending) > sseMaxPendingBytes {
+				if _, err := w.Write(pending); err != nil {
+					return
+				}
+				pending = pending[:0]
+				midEvent = true
+				if controller.Flush() != nil {
+					return
+				}
+			}
+			if !heartbeat.Stop() {
+				select {
+				case <-heartbeat.C:
+				default:
+				}
+			}
+			heartbeat.Reset(ssePolicy.HeartbeatInterval)
+		case <-heartbeat.C:
+			if len(pending) == 0 && !midEvent {
+				if _, err := w.Write(sseHeartbeat); err != nil {
+					return
+				}
+				if controller.Flush() != nil {
+					return
+				}
+				onHeartbeat()
+			}
+			heartbeat.Reset(ssePolicy.HeartbeatInterval)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func lastEventBoundary(data []byte) int {
+	boundary := 0
+	for _, separator := range [][]byte{[]byte("\n\n"), []byte("\r\r"), []byte("\n\r\n")} {
+		if index := bytes.LastIndex(data, separator); index >= 0 && index+len(separator) > boundary {
+			boundary = index + len(separator)
+		}
+	}
+	return boundary
+}
@@ -0,0 +1,69 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/plugin"
+)
+
+func finishRequestSpan(span *obs.Span, method string, host string, path string, routeID string, poolKey string, upstreamAddr string, status int, errorCategory string, retryCount int) {
+	if span == nil {
+		return
+	}
+	span.SetAttribute("http.request.method", method)
+	span.SetAttribute("server.address", host)
+	span.SetAttribute("url.path", path)
+	span.SetAttribute("http.route", routeID)
+	span.SetAttribute("http.response.status_code", status)
+	span.SetAttribute("proxy.pool", poolKey)
+	span.SetAttribute("proxy.upstream", upstreamAddr)
+	span.SetAttribute("proxy.retry_count", retryCount)
+	if errorCategory != "none" {
+		span.SetAttribute("error.type", errorCategory)
+	}
+	if status >= http.StatusInternalServerError || errorCategory != "none" {
+		span.SetError(errorCategory)
+	} else {
+		span.SetOK()
+	}
+	span.End()
+}
+
+func finishAttemptSpan(span *obs.Span, resp *http.Response, err error) {
+	if span == nil {
+		return
+	}
+	if resp != nil {
+		span.SetAttribute("http.response.status_code", resp.StatusCode)
+	}
+	if err != nil {
+		span.SetError(err.Error())
+	} else if resp != nil && resp.StatusCode >= http.StatusInternalServerError {
+		span.SetError(http.StatusText(resp.StatusCode))
+	}
+	span.End()
+}
+
+func startPluginSpan(ctx context.Context, filter plugin.Filter, phase string) (context.Context, *obs.Span) {
+	ctx, span := obs.StartSpan(ctx, "plugin "+filter.Name, obs.SpanKindClient)
+	span.SetAttribute("proxy.plugin", filter.Name)
+	span.SetAttribute("proxy.plugin_phase", phase)
+	span.SetAttribute("proxy.plugin_type", string(filter.Type))
+	return ctx, span
+}
+
+func finishPluginSpan(span *obs.Span, err error) {
+	if err != nil {
+		span.SetError(err.Error())
+	}
+	span.End()
+}
+
+func sampledTraceID(span *obs.Span) string {
+	if !span.Sampled() {
+		return ""
+	}
+	return span.TraceID()
+}
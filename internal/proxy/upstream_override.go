@@ -0,0 +1,81 @@
+package proxy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"modern_reverse_proxy/internal/policy"
+	"modern_reverse_proxy/internal/pool"
+	"modern_reverse_proxy/internal/registry"
+)
+
+const debugUpstreamTokenSuffix = "-Token"
+
+func SignDebugUpstreamToken(secret []byte, addr string, expires time.Time) string {
+	expiry := strconv.FormatInt(expires.Unix(), 10)
+	return expiry + "." + debugUpstreamSignature(secret, addr, expiry)
+}
+
+func debugUpstreamSignature(secret []byte, addr string, expiry string) string {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write([]byte(addr + "|" + expiry))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func resolveUpstreamOverride(w http.ResponseWriter, requestID string, r *http.Request, debugPolicy policy.DebugUpstreamPolicy, reg *registry.Registry, poolKey pool.PoolKey) (string, bool) {
+	if !debugPolicy.Enabled {
+		return "", false
+	}
+	tokenHeader := debugPolicy.Header + debugUpstreamTokenSuffix
+	addr := strings.TrimSpace(r.Header.Get(debugPolicy.Header))
+	token := strings.TrimSpace(r.Header.Get(tokenHeader))
+	r.Header.Del(debugPolicy.Header)
+	r.Header.Del(tokenHeader)
+	if addr == "" {
+		return "", false
+	}
+	if !debugUpstreamAuthorized(r, debugPolicy, addr, token, time.Now()) {
+		WriteProxyError(w, requestID, http.StatusForbidden, "debug_upstream_forbidden", "upstream override not authorized")
+		return "", true
+	}
+	if reg == nil || !reg.HasEndpoint(poolKey, addr) {
+		WriteProxyError(w, requestID, http.StatusBadRequest, "debug_upstream_unknown", "upstream override endpoint not in pool")
+		return "", true
+	}
+	return addr, false
+}
+
+func debugUpstreamAuthorized(r *http.Request, debugPolicy policy.DebugUpstreamPolicy, addr string, token string, now time.Time) bool {
+	if len(debugPolicy.TrustedNets) > 0 {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		if ip := net.ParseIP(host); ip != nil {
+			for _, network := range debugPolicy.TrustedNets {
+				if network.Contains(ip) {
+					return true
+				}
+			}
+		}
+	}
+	if len(debugPolicy.TokenSecret) == 0 || token == "" {
+		return false
+	}
+	expiry, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	expiresAt, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil || now.Unix() > expiresAt {
+		return false
+	}
+	expected := debugUpstreamSignature(debugPolicy.TokenSecret, addr, expiry)
+	return hmac.Equal([]byte(signature), []byte(expected))
+}
@@ -0,0 +1,90 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+
+	"modern_reverse_proxy/internal/plugin"
+	"modern_reverse_proxy/internal/policy"
+)
+
+func (h *Handler) applyWASMRequestFilter(recorder *ResponseRecorder, r *http.Request, route policy.Route, requestID string, filter plugin.Filter, tracking *pluginTracking) bool {
+	ctx, cancel := context.WithTimeout(r.Context(), filter.RequestTimeout)
+	ctx, pluginSpan := startPluginSpan(ctx, filter, "request")
+	result, err := filter.WASM.ApplyRequest(ctx, plugin.WASMRequest{
+		RequestID: requestID,
+		RouteID:   route.ID,
+		Method:    r.Method,
+		Host:      r.Host,
+		Path:      r.URL.Path,
+		Headers:   plugin.HeadersToMap(r.Header),
+	})
+	finishPluginSpan(pluginSpan, err)
+	cancel()
+	if err != nil {
+		return h.handlePluginFailure(recorder, requestID, filter, tracking, "request", pluginErrorResult(err))
+	}
+
+	switch result.Action {
+	case "", "continue":
+		if h.Metrics != nil {
+			h.Metrics.RecordPluginCall(filter.Name, "request", "success")
+		}
+		if plugin.ApplyHeaderMutations(r.Header, result.MutatedHeaders) {
+			tracking.markMutationDenied()
+		}
+		return false
+	case "respond":
+		if result.ResponseStatus <= 0 {
+			return h.handlePluginFailure(recorder, requestID, filter, tracking, "request", "error")
+		}
+		if h.Metrics != nil {
+			h.Metrics.RecordPluginCall(filter.Name, "request", "success")
+			h.Metrics.RecordPluginShortCircuit(filter.Name)
+		}
+		if plugin.ApplyHeaderMutations(recorder.Header(), result.ResponseHeaders) {
+			tracking.markMutationDenied()
+		}
+		recorder.Header().Set(RequestIDHeader, requestID)
+		recorder.WriteHeader(result.ResponseStatus)
+		if r.Method != http.MethodHead {
+			_, _ = recorder.Write([]byte(result.ResponseBody))
+		}
+		tracking.markShortCircuit()
+		return true
+	default:
+		return h.handlePluginFailure(recorder, requestID, filter, tracking, "request", "error")
+	}
+}
+
+func (h *Handler) applyWASMResponseFilter(recorder *ResponseRecorder, r *http.Request, resp *http.Response, route policy.Route, requestID string, filter plugin.Filter, tracking *pluginTracking) bool {
+	if !filter.WASM.HandlesResponse() {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), filter.ResponseTimeout)
+	ctx, pluginSpan := startPluginSpan(ctx, filter, "response")
+	result, err := filter.WASM.ApplyResponse(ctx, plugin.WASMResponse{
+		RequestID: requestID,
+		RouteID:   route.ID,
+		Status:    resp.StatusCode,
+		Headers:   plugin.HeadersToMap(resp.Header),
+	})
+	finishPluginSpan(pluginSpan, err)
+	cancel()
+	if err != nil {
+		if h.handlePluginFailure(recorder, requestID, filter, tracking, "response", pluginErrorResult(err)) {
+			if resp.Body != nil {
+				resp.Body.Close()
+			}
+			return true
+		}
+		return false
+	}
+	if h.Metrics != nil {
+		h.Metrics.RecordPluginCall(filter.Name, "response", "success")
+	}
+	if plugin.ApplyHeaderMutations(resp.Header, result.MutatedHeaders) {
+		tracking.markMutationDenied()
+	}
+	return false
+}
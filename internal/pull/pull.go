@@ -5,15 +5,20 @@ import (
 	"crypto/ed25519"
 	"encoding/json"
 	"errors"
-	"log"
+	"fmt"
 	"math/rand"
 	"net/http"
+	"os"
+	"sync"
 	"time"
 
 	"modern_reverse_proxy/internal/bundle"
 	"modern_reverse_proxy/internal/obs"
 	"modern_reverse_proxy/internal/rollout"
 	"modern_reverse_proxy/internal/runtime"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
 type Config struct {
@@ -22,10 +27,15 @@ type Config struct {
 	Interval       time.Duration
 	Jitter         time.Duration
 	PublicKey      ed25519.PublicKey
+	Keyring        *bundle.Keyring
 	RolloutManager *rollout.Manager
 	Store          *runtime.Store
 	HTTPClient     *http.Client
 	Token          string
+	TokenFunc      func() string
+	StreamAddr     string
+	StreamDialOpts []grpc.DialOption
+	NodeID         string
 }
 
 type Puller struct {
@@ -33,11 +43,40 @@ type Puller struct {
 	baseURL   string
 	interval  time.Duration
 	jitter    time.Duration
-	publicKey ed25519.PublicKey
+	keyring   *bundle.Keyring
 	rollout   *rollout.Manager
 	store     *runtime.Store
 	client    *http.Client
 	token     string
+	tokenFunc func() string
+
+	streamAddr     string
+	streamDialOpts []grpc.DialOption
+	nodeID         string
+
+	mu          sync.Mutex
+	status      Status
+	etag        string
+	etagVersion string
+}
+
+type Status struct {
+	Enabled              bool      `json:"enabled"`
+	Mode                 string    `json:"mode"`
+	BaseURL              string    `json:"base_url,omitempty"`
+	StreamAddr           string    `json:"stream_addr,omitempty"`
+	StreamConnected      bool      `json:"stream_connected"`
+	LastAttemptAt        time.Time `json:"last_attempt_at"`
+	LastSuccessAt        time.Time `json:"last_success_at"`
+	LastResult           string    `json:"last_result,omitempty"`
+	LastError            string    `json:"last_error,omitempty"`
+	LastVersionSeen      string    `json:"last_version_seen,omitempty"`
+	LastAppliedVersion   string    `json:"last_applied_version,omitempty"`
+	ConsecutiveFailures  int       `json:"consecutive_failures"`
+	VerificationFailures int       `json:"verification_failures"`
+	LastVerifyError      string    `json:"last_verify_error,omitempty"`
+	KeyringVersion       int64     `json:"keyring_version"`
+	LastKeyringError     string    `json:"last_keyring_error,omitempty"`
 }
 
 func NewPuller(cfg Config) *Puller {
@@ -49,49 +88,90 @@ func NewPuller(cfg Config) *Puller {
 	if jitter < 0 {
 		jitter = 0
 	}
+	keyring := cfg.Keyring
+	if keyring == nil && len(cfg.PublicKey) == ed25519.PublicKeySize {
+		keyring = bundle.NewKeyring(cfg.PublicKey)
+	}
 	client := cfg.HTTPClient
 	if client == nil {
 		client = &http.Client{Timeout: 5 * time.Second}
 	}
+	dialOpts := cfg.StreamDialOpts
+	if len(dialOpts) == 0 {
+		dialOpts = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	}
+	nodeID := cfg.NodeID
+	if nodeID == "" {
+		nodeID, _ = os.Hostname()
+	}
+	mode := "pull"
+	if cfg.StreamAddr != "" {
+		mode = "stream"
+	}
 	return &Puller{
 		enabled:   cfg.Enabled,
 		baseURL:   cfg.BaseURL,
 		interval:  interval,
 		jitter:    jitter,
-		publicKey: cfg.PublicKey,
+		keyring:   keyring,
 		rollout:   cfg.RolloutManager,
 		store:     cfg.Store,
 		client:    client,
 		token:     cfg.Token,
+		tokenFunc: cfg.TokenFunc,
+
+		streamAddr:     cfg.StreamAddr,
+		streamDialOpts: dialOpts,
+		nodeID:         nodeID,
+		status: Status{
+			Enabled:    cfg.Enabled,
+			Mode:       mode,
+			BaseURL:    cfg.BaseURL,
+			StreamAddr: cfg.StreamAddr,
+		},
+	}
+}
+
+func (p *Puller) Status() Status {
+	if p == nil {
+		return Status{}
 	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	status := p.status
+	status.KeyringVersion = p.keyring.Version()
+	return status
 }
 
 func (p *Puller) Run(ctx context.Context) {
 	if p == nil || !p.enabled {
 		return
 	}
+	if p.streamAddr != "" {
+		p.runStream(ctx)
+		return
+	}
 	if p.baseURL == "" {
 		return
 	}
 	p.pullOnce(ctx)
+	for p.wait(ctx) {
+		p.pullOnce(ctx)
+	}
+}
 
-	ticker := time.NewTicker(p.interval)
-	defer ticker.Stop()
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			if p.jitter > 0 {
-				jitter := time.Duration(rand.Int63n(int64(p.jitter)))
-				select {
-				case <-ctx.Done():
-					return
-				case <-time.After(jitter):
-				}
-			}
-			p.pullOnce(ctx)
-		}
+func (p *Puller) wait(ctx context.Context) bool {
+	delay := p.interval
+	if p.jitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(p.jitter)))
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
 	}
 }
 
@@ -99,57 +179,189 @@ func (p *Puller) pullOnce(ctx context.Context) {
 	if p == nil {
 		return
 	}
+	p.refreshKeyring(ctx)
+	result, version, err := p.fetchAndApply(ctx)
+	if ctx.Err() != nil {
+		return
+	}
+	p.recordResult(result, version, err)
+}
+
+func (p *Puller) fetchAndApply(ctx context.Context) (string, string, error) {
 	request, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/bundles/latest", nil)
 	if err != nil {
-		return
+		return "request_error", "", err
 	}
-	if p.token != "" {
-		request.Header.Set("X-Distributor-Token", p.token)
+	if token := p.currentToken(); token != "" {
+		request.Header.Set("X-Distributor-Token", token)
+	}
+	current := p.currentVersion()
+	etag, etagVersion := p.cachedETag()
+	if etag != "" && etagVersion == current {
+		request.Header.Set("If-None-Match", etag)
 	}
 	resp, err := p.client.Do(request)
 	if err != nil {
-		return
+		return "fetch_error", "", err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return "not_modified", etagVersion, nil
+	}
 	if resp.StatusCode != http.StatusOK {
-		return
+		return "bad_status", "", fmt.Errorf("distributor returned status %d", resp.StatusCode)
 	}
 	var bundlePayload bundle.Bundle
 	if err := json.NewDecoder(resp.Body).Decode(&bundlePayload); err != nil {
-		return
+		return "decode_error", "", err
 	}
 	if bundlePayload.Meta.Version == "" {
-		return
+		return "decode_error", "", errors.New("bundle version missing")
 	}
-	current := ""
-	if p.store != nil {
-		if snap := p.store.Get(); snap != nil {
-			current = snap.Version
-		}
+	result, version, err := p.verifyAndApply(ctx, bundlePayload)
+	if result == "applied" || result == "unchanged" {
+		p.rememberETag(resp.Header.Get("ETag"), version)
 	}
-	if current == bundlePayload.Meta.Version {
-		return
+	return result, version, err
+}
+
+func (p *Puller) verifyAndApply(ctx context.Context, bundlePayload bundle.Bundle) (string, string, error) {
+	version := bundlePayload.Meta.Version
+	if p.currentVersion() == version {
+		return "unchanged", version, nil
 	}
 	metrics := obs.DefaultMetrics()
-	if err := bundle.VerifyBundle(bundlePayload, p.publicKey); err != nil {
-		result := "bad_sig"
-		if errors.Is(err, bundle.ErrBadHash) {
-			result = "bad_hash"
-		}
+	if err := p.keyring.Verify(bundlePayload); err != nil {
+		result := bundle.VerifyResult(err)
 		if metrics != nil {
 			metrics.RecordBundleVerify(result)
 		}
-		log.Printf("bundle_version=%s verify_result=%s", bundlePayload.Meta.Version, result)
-		return
+		logger.Warn("bundle_verify", "bundle_version", version, "verify_result", result)
+		return result, version, err
 	}
 	if metrics != nil {
 		metrics.RecordBundleVerify("ok")
 	}
-	log.Printf("bundle_version=%s verify_result=ok", bundlePayload.Meta.Version)
+	logger.Info("bundle_verify", "bundle_version", version, "verify_result", "ok")
 	if p.rollout == nil {
-		return
+		return "verified", version, nil
 	}
 	if _, err := p.rollout.ApplyBundle(ctx, bundlePayload, ""); err != nil {
-		log.Printf("bundle_version=%s rollout_result=error reason=%v", bundlePayload.Meta.Version, err)
+		logger.Warn("bundle_rollout", "bundle_version", version, "rollout_result", "error", "reason", err)
+		return "apply_error", version, err
+	}
+	return "applied", version, nil
+}
+
+func (p *Puller) refreshKeyring(ctx context.Context) {
+	if p.keyring.Len() == 0 || p.baseURL == "" {
+		return
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/keyring", nil)
+	if err != nil {
+		return
+	}
+	if token := p.currentToken(); token != "" {
+		request.Header.Set("X-Distributor-Token", token)
+	}
+	resp, err := p.client.Do(request)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return
+	}
+	var doc bundle.KeyringDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		p.recordKeyringError(err)
+		return
+	}
+	if doc.Version <= p.keyring.Version() {
+		return
+	}
+	if err := p.keyring.Rotate(doc); err != nil {
+		p.recordKeyringError(err)
+		logger.Warn("keyring_rotate", "keyring_version", doc.Version, "rotate_result", "error", "reason", err)
+		return
+	}
+	p.recordKeyringError(nil)
+	logger.Info("keyring_rotate", "keyring_version", doc.Version, "rotate_result", "ok", "keys", len(doc.Keys))
+}
+
+func (p *Puller) recordKeyringError(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err == nil {
+		p.status.LastKeyringError = ""
+		return
+	}
+	p.status.LastKeyringError = err.Error()
+}
+
+func (p *Puller) currentToken() string {
+	if p.tokenFunc != nil {
+		if token := p.tokenFunc(); token != "" {
+			return token
+		}
+	}
+	return p.token
+}
+
+func (p *Puller) currentVersion() string {
+	if p.store == nil {
+		return ""
+	}
+	if snap := p.store.Get(); snap != nil {
+		return snap.Version
+	}
+	return ""
+}
+
+func (p *Puller) cachedETag() (string, string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.etag, p.etagVersion
+}
+
+func (p *Puller) rememberETag(etag string, version string) {
+	p.mu.Lock()
+	p.etag = etag
+	p.etagVersion = version
+	p.mu.Unlock()
+}
+
+func (p *Puller) recordResult(result string, version string, err error) {
+	now := time.Now().UTC()
+	p.mu.Lock()
+	p.status.LastAttemptAt = now
+	p.status.LastResult = result
+	if version != "" {
+		p.status.LastVersionSeen = version
+	}
+	if err != nil {
+		p.status.LastError = err.Error()
+		p.status.ConsecutiveFailures++
+		if result == "bad_sig" || result == "bad_hash" || result == "unknown_key" {
+			p.status.VerificationFailures++
+			p.status.LastVerifyError = err.Error()
+		}
+	} else {
+		p.status.LastError = ""
+		p.status.LastSuccessAt = now
+		p.status.ConsecutiveFailures = 0
+		if result == "applied" {
+			p.status.LastAppliedVersion = version
+		}
+	}
+	failures := p.status.ConsecutiveFailures
+	lastSuccess := p.status.LastSuccessAt
+	p.mu.Unlock()
+
+	if err != nil {
+		logger.Warn("pull", "pull_result", result, "consecutive_failures", failures, "reason", err)
+	}
+	if metrics := obs.DefaultMetrics(); metrics != nil {
+		metrics.RecordPull(result, failures, lastSuccess)
 	}
 }
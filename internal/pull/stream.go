@@ -0,0 +1,103 @@
+package pull
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"modern_reverse_proxy/internal/bundle"
+	"modern_reverse_proxy/internal/distributor/proto"
+	"modern_reverse_proxy/internal/logging"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+var logger = logging.For("pull")
+
+const streamTokenMetadata = "x-distributor-token"
+
+func (p *Puller) runStream(ctx context.Context) {
+	for {
+		err := p.streamOnce(ctx)
+		p.setStreamConnected(false)
+		if ctx.Err() != nil {
+			return
+		}
+		fallback := "none"
+		if p.baseURL != "" {
+			fallback = "pull"
+		}
+		logger.Warn("pull_stream", "pull_stream_result", "error", "addr", p.streamAddr, "fallback", fallback, "reason", err)
+		p.recordResult("stream_error", "", err)
+		if p.baseURL != "" {
+			p.pullOnce(ctx)
+		}
+		if !p.wait(ctx) {
+			return
+		}
+	}
+}
+
+func (p *Puller) streamOnce(ctx context.Context) error {
+	conn, err := grpc.NewClient(p.streamAddr, p.streamDialOpts...)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if token := p.currentToken(); token != "" {
+		streamCtx = metadata.AppendToOutgoingContext(streamCtx, streamTokenMetadata, token)
+	}
+	stream, err := distributorpb.NewBundleStreamClient(conn).StreamBundles(streamCtx)
+	if err != nil {
+		return err
+	}
+	if err := stream.Send(&distributorpb.StreamRequest{NodeId: p.nodeID, VersionInfo: p.currentVersion()}); err != nil {
+		return err
+	}
+	p.setStreamConnected(true)
+	logger.Info("pull_stream", "pull_stream_result", "connected", "addr", p.streamAddr, "node_id", p.nodeID)
+
+	for {
+		update, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		p.refreshKeyring(ctx)
+		previous := p.currentVersion()
+		result, version, applyErr := p.applyUpdate(ctx, update)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		p.recordResult(result, version, applyErr)
+
+		ack := &distributorpb.StreamRequest{NodeId: p.nodeID, VersionInfo: version, ResponseNonce: update.GetNonce()}
+		if applyErr != nil {
+			ack.VersionInfo = previous
+			ack.ErrorDetail = applyErr.Error()
+		}
+		if err := stream.Send(ack); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *Puller) applyUpdate(ctx context.Context, update *distributorpb.BundleUpdate) (string, string, error) {
+	var bundlePayload bundle.Bundle
+	if err := json.Unmarshal(update.GetBundleJson(), &bundlePayload); err != nil {
+		return "decode_error", update.GetVersion(), err
+	}
+	if bundlePayload.Meta.Version == "" {
+		return "decode_error", update.GetVersion(), errors.New("bundle version missing")
+	}
+	return p.verifyAndApply(ctx, bundlePayload)
+}
+
+func (p *Puller) setStreamConnected(connected bool) {
+	p.mu.Lock()
+	p.status.StreamConnected = connected
+	p.mu.Unlock()
+}
@@ -7,10 +7,13 @@ import (
 	"time"
 
 	"modern_reverse_proxy/internal/health"
+	"modern_reverse_proxy/internal/logging"
 	"modern_reverse_proxy/internal/pool"
 	"modern_reverse_proxy/internal/transport"
 )
 
+var logger = logging.For("registry")
+
 const (
 	defaultReapInterval = 50 * time.Millisecond
 	defaultDrainTimeout = 200 * time.Millisecond
@@ -19,12 +22,18 @@ const (
 type Registry struct {
 	mu           sync.RWMutex
 	pools        map[pool.PoolKey]*pool.PoolRuntime
+	fingerprints map[pool.PoolKey]string
 	transports   *transport.Registry
 	reapInterval time.Duration
 	drainTimeout time.Duration
+	observerMu   sync.RWMutex
+	onCutoff     DrainCutoffObserver
+	onWarmup     WarmupObserver
 	stopCh       chan struct{}
 }
 
+type DrainCutoffObserver func(poolKey string, inflight int64)
+
 func NewRegistry(reapInterval time.Duration, drainTimeout time.Duration) *Registry {
 	if reapInterval <= 0 {
 		reapInterval = defaultReapInterval
@@ -35,6 +44,7 @@ func NewRegistry(reapInterval time.Duration, drainTimeout time.Duration) *Regist
 
 	reg := &Registry{
 		pools:        make(map[pool.PoolKey]*pool.PoolRuntime),
+		fingerprints: make(map[pool.PoolKey]string),
 		transports:   transport.NewRegistry(0, 0),
 		reapInterval: reapInterval,
 		drainTimeout: drainTimeout,
@@ -44,30 +54,65 @@ func NewRegistry(reapInterval time.Duration, drainTimeout time.Duration) *Regist
 	return reg
 }
 
-func (r *Registry) Reconcile(key pool.PoolKey, endpoints []string, cfg health.Config, transportOpts transport.Options) {
+func (r *Registry) Reconcile(key pool.PoolKey, endpoints []string, cfg health.Config, transportOpts transport.Options, drain pool.DrainConfig, slowStart pool.SlowStartConfig, locality pool.LocalityConfig) {
+	if drain.Timeout <= 0 {
+		drain.Timeout = r.drainTimeout
+	}
+
 	r.mu.Lock()
 	poolRuntime := r.pools[key]
 	if poolRuntime == nil {
-		poolRuntime = pool.NewPoolRuntime(key, cfg, r.drainTimeout)
+		poolRuntime = pool.NewPoolRuntime(key, cfg, drain, slowStart, locality)
 		r.pools[key] = poolRuntime
 	}
+	delete(r.fingerprints, key)
 	r.mu.Unlock()
 
-	endpointRemoved := poolRuntime.Reconcile(endpoints, cfg, r.drainTimeout)
+	endpointRemoved := poolRuntime.Reconcile(endpoints, cfg, drain, slowStart, locality)
 	if r.transports != nil {
-		r.transports.Reconcile(string(key), endpoints, transportOpts)
+		upstream := r.transports.Reconcile(string(key), endpoints, transportOpts)
 		if endpointRemoved {
 			r.transports.CloseIdleConnections(string(key))
 		}
+		if transportOpts.WarmupConnections > 0 && upstream != nil {
+			go r.warmup(key, endpoints, upstream, transportOpts)
+		}
+	}
+}
+
+func (r *Registry) PoolFingerprint(key pool.PoolKey) string {
+	if r == nil {
+		return ""
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if _, ok := r.pools[key]; !ok {
+		return ""
+	}
+	return r.fingerprints[key]
+}
+
+func (r *Registry) SetPoolFingerprint(key pool.PoolKey, fingerprint string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.pools[key]; ok {
+		r.fingerprints[key] = fingerprint
 	}
 }
 
 func (r *Registry) Pick(key pool.PoolKey, outlierEjected func(addr string, now time.Time) bool) (pool.PickResult, bool) {
+	return r.PickExcluding(key, outlierEjected, nil)
+}
+
+func (r *Registry) PickExcluding(key pool.PoolKey, outlierEjected func(addr string, now time.Time) bool, exclude map[string]bool) (pool.PickResult, bool) {
 	poolRuntime := r.getPool(key)
 	if poolRuntime == nil {
 		return pool.PickResult{}, false
 	}
-	return poolRuntime.Pick(outlierEjected), true
+	return poolRuntime.PickExcluding(outlierEjected, exclude), true
 }
 
 func (r *Registry) InflightStart(key pool.PoolKey, addr string) {
@@ -82,18 +127,108 @@ func (r *Registry) InflightDone(key pool.PoolKey, addr string) {
 	}
 }
 
+func (r *Registry) Track(key pool.PoolKey, addr string) (context.Context, func()) {
+	endpoint := r.endpoint(key, addr)
+	if endpoint == nil {
+		return nil, func() {}
+	}
+	endpoint.InflightInc()
+	var once sync.Once
+	return endpoint.DrainContext(), func() {
+		once.Do(endpoint.InflightDec)
+	}
+}
+
+func (r *Registry) SetDrainCutoffObserver(observer DrainCutoffObserver) {
+	if r == nil {
+		return
+	}
+	r.observerMu.Lock()
+	r.onCutoff = observer
+	r.observerMu.Unlock()
+}
+
 func (r *Registry) PassiveFailure(key pool.PoolKey, addr string) {
 	if endpoint := r.endpoint(key, addr); endpoint != nil {
 		endpoint.RecordPassiveFailure()
 	}
 }
 
+func (r *Registry) PassiveStatus(key pool.PoolKey, addr string, code int) {
+	if endpoint := r.endpoint(key, addr); endpoint != nil {
+		endpoint.RecordPassiveStatus(code)
+	}
+}
+
 func (r *Registry) PassiveSuccess(key pool.PoolKey, addr string) {
 	if endpoint := r.endpoint(key, addr); endpoint != nil {
 		endpoint.RecordPassiveSuccess()
 	}
 }
 
+func (r *Registry) PoolKeys() []pool.PoolKey {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	keys := make([]pool.PoolKey, 0, len(r.pools))
+	for key := range r.pools {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (r *Registry) Endpoints(key pool.PoolKey) ([]string, bool) {
+	poolRuntime := r.getPool(key)
+	if poolRuntime == nil {
+		return nil, false
+	}
+	return poolRuntime.Endpoints(), true
+}
+
+func (r *Registry) TransportOptions(key pool.PoolKey) (transport.Options, bool) {
+	if r == nil || r.transports == nil {
+		return transport.Options{}, false
+	}
+	return r.transports.Options(string(key))
+}
+
+func (r *Registry) PoolStatus(key pool.PoolKey) ([]pool.EndpointStatus, bool) {
+	poolRuntime := r.getPool(key)
+	if poolRuntime == nil {
+		return nil, false
+	}
+	return poolRuntime.Status(time.Now()), true
+}
+
+func (r *Registry) DrainEndpoint(key pool.PoolKey, addr string) bool {
+	endpoint := r.endpoint(key, addr)
+	if endpoint == nil {
+		return false
+	}
+	endpoint.ManualDrain()
+	return true
+}
+
+func (r *Registry) EjectEndpoint(key pool.PoolKey, addr string) bool {
+	endpoint := r.endpoint(key, addr)
+	if endpoint == nil {
+		return false
+	}
+	endpoint.ManualEject()
+	return true
+}
+
+func (r *Registry) RestoreEndpoint(key pool.PoolKey, addr string) bool {
+	endpoint := r.endpoint(key, addr)
+	if endpoint == nil {
+		return false
+	}
+	endpoint.ManualRestore()
+	return true
+}
+
 func (r *Registry) HasEndpoint(key pool.PoolKey, addr string) bool {
 	return r.endpoint(key, addr) != nil
 }
@@ -192,6 +327,7 @@ func (r *Registry) PrunePools(desired map[pool.PoolKey]struct{}) {
 		removed = append(removed, poolRuntime)
 		removedKeys = append(removedKeys, key)
 		delete(r.pools, key)
+		delete(r.fingerprints, key)
 	}
 	r.mu.Unlock()
 
@@ -229,14 +365,24 @@ func (r *Registry) reapLoop() {
 
 func (r *Registry) reapOnce() {
 	r.mu.RLock()
-	pools := make([]*pool.PoolRuntime, 0, len(r.pools))
-	for _, poolRuntime := range r.pools {
-		pools = append(pools, poolRuntime)
+	pools := make(map[pool.PoolKey]*pool.PoolRuntime, len(r.pools))
+	for key, poolRuntime := range r.pools {
+		pools[key] = poolRuntime
 	}
 	r.mu.RUnlock()
 
+	r.observerMu.RLock()
+	observer := r.onCutoff
+	r.observerMu.RUnlock()
+
 	now := time.Now()
-	for _, poolRuntime := range pools {
-		poolRuntime.Reap(now)
+	for key, poolRuntime := range pools {
+		_, cutoffs := poolRuntime.Reap(now)
+		for _, cutoff := range cutoffs {
+			logger.Warn("endpoint_drain_cutoff", "pool", key, "addr", cutoff.Addr, "inflight", cutoff.Inflight)
+			if observer != nil {
+				observer(string(key), cutoff.Inflight)
+			}
+		}
 	}
 }
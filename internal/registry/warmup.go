@@ -0,0 +1,81 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"modern_reverse_proxy/internal/pool"
+	"modern_reverse_proxy/internal/transport"
+)
+
+const (
+	WarmupSuccess = "success"
+	WarmupFailure = "failure"
+
+	maxWarmupDrainBytes = 64 * 1024
+)
+
+type WarmupObserver func(poolKey string, result string)
+
+func (r *Registry) SetWarmupObserver(observer WarmupObserver) {
+	if r == nil {
+		return
+	}
+	r.observerMu.Lock()
+	r.onWarmup = observer
+	r.observerMu.Unlock()
+}
+
+func (r *Registry) warmup(key pool.PoolKey, endpoints []string, upstream *http.Transport, opts transport.Options) {
+	r.observerMu.RLock()
+	observer := r.onWarmup
+	r.observerMu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.WarmupTimeout)
+	defer cancel()
+	go func() {
+		select {
+		case <-r.stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for _, addr := range endpoints {
+		for i := 0; i < opts.WarmupConnections; i++ {
+			wg.Add(1)
+			go func(addr string) {
+				defer wg.Done()
+				result := WarmupSuccess
+				if err := warmupConn(ctx, upstream, opts.Scheme(), addr, opts.WarmupPath); err != nil {
+					result = WarmupFailure
+					logger.Warn("upstream_warmup", "upstream_warmup_result", "error", "pool", key, "addr", addr, "reason", err)
+				}
+				if observer != nil {
+					observer(string(key), result)
+				}
+			}(addr)
+		}
+	}
+	wg.Wait()
+}
+
+func warmupConn(ctx context.Context, upstream *http.Transport, scheme string, addr string, path string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, scheme+"://"+addr+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := upstream.RoundTrip(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if _, err := io.Copy(io.Discard, io.LimitReader(resp.Body, maxWarmupDrainBytes)); err != nil {
+		return fmt.Errorf("drain response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package retry
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"modern_reverse_proxy/internal/policy"
+)
+
+const (
+	HedgeFired = "fired"
+	HedgeWon   = "won"
+)
+
+type hedgeOutcome struct {
+	resp  *http.Response
+	err   error
+	addr  string
+	index int
+}
+
+func Hedge(hedgePolicy policy.HedgePolicy, attempt AttemptFunc, onHedge func(result string)) AttemptFunc {
+	if attempt == nil || !hedgePolicy.Enabled || hedgePolicy.MaxHedges <= 0 || hedgePolicy.Delay <= 0 {
+		return attempt
+	}
+	return func(ctx context.Context) (*http.Response, error, string) {
+		results := make(chan hedgeOutcome, hedgePolicy.MaxHedges+1)
+		cancels := make([]context.CancelFunc, 0, hedgePolicy.MaxHedges+1)
+		launch := func() {
+			attemptCtx, cancel := context.WithCancel(ctx)
+			index := len(cancels)
+			cancels = append(cancels, cancel)
+			go func() {
+				resp, err, addr := attempt(attemptCtx)
+				results <- hedgeOutcome{resp: resp, err: err, addr: addr, index: index}
+			}()
+		}
+
+		launch()
+		timer := time.NewTimer(hedgePolicy.Delay)
+		defer timer.Stop()
+
+		inflight := 1
+		var last hedgeOutcome
+		for inflight > 0 {
+			select {
+			case <-timer.C:
+				if len(cancels) > hedgePolicy.MaxHedges {
+					continue
+				}
+				launch()
+				inflight++
+				if onHedge != nil {
+					onHedge(HedgeFired)
+				}
+				timer.Reset(hedgePolicy.Delay)
+			case outcome := <-results:
+				inflight--
+				if outcome.err != nil || outcome.resp == nil {
+					cancels[outcome.index]()
+					last = outcome
+					continue
+				}
+				for index, cancel := range cancels {
+					if index != outcome.index {
+						cancel()
+					}
+				}
+				go discardLosers(results, inflight)
+				if outcome.index > 0 && onHedge != nil {
+					onHedge(HedgeWon)
+				}
+				if outcome.resp.Body == nil {
+					cancels[outcome.index]()
+				} else {
+					outcome.resp.Body = &cancelOnClose{inner: outcome.resp.Body, cancel: cancels[outcome.index]}
+				}
+				return outcome.resp, nil, outcome.addr
+			}
+		}
+		return last.resp, last.err, last.addr
+	}
+}
+
+func discardLosers(results <-chan hedgeOutcome, remaining int) {
+	for i := 0; i < remaining; i++ {
+		outcome := <-results
+		if outcome.resp != nil && outcome.resp.Body != nil {
+			outcome.resp.Body.Close()
+		}
+	}
+}
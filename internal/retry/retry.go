@@ -3,8 +3,11 @@ package retry
 import (
 	"context"
 	"io"
+	"math"
 	"math/rand"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"modern_reverse_proxy/internal/policy"
@@ -77,15 +80,29 @@ func Execute(cfg Config, attempt AttemptFunc) Result {
 			return result
 		}
 
-		attemptCtx, cancel := context.WithTimeout(outerCtx, perTry)
+		attemptCtx, cancelCause := context.WithCancelCause(outerCtx)
+		cancel := func() { cancelCause(nil) }
+		headerTimer := time.AfterFunc(perTry, func() { cancelCause(context.DeadlineExceeded) })
 		resp, err, upstreamAddr := attempt(attemptCtx)
-		cancel()
+		if !headerTimer.Stop() && err == nil && resp != nil {
+			drainResponse(resp)
+			resp, err = nil, context.DeadlineExceeded
+		}
+		if err != nil || resp == nil || resp.Body == nil {
+			cancel()
+		} else {
+			resp.Body = &cancelOnClose{inner: resp.Body, cancel: cancel}
+		}
 		result.UpstreamAddr = upstreamAddr
 
 		if err == nil {
 			reason, retryable := ClassifyStatus(resp.StatusCode, policyConfig)
 			if retryable && attempts < maxAttempts && cfg.AllowRetry {
 				result.RetryReason = reason
+				backoff, jitter := statusBackoff(resp, policyConfig, time.Now())
+				if hasDeadline && time.Until(deadline) <= backoff {
+					return resultWithResponse(result, resp)
+				}
 				if !consumeBudgets(&result, cfg) {
 					return resultWithResponse(result, resp)
 				}
@@ -94,7 +111,7 @@ func Execute(cfg Config, attempt AttemptFunc) Result {
 					cfg.OnRetry(reason)
 				}
 				drainResponse(resp)
-				if !sleepWithBackoff(outerCtx, policyConfig.Backoff, policyConfig.BackoffJitter) {
+				if !sleepWithBackoff(outerCtx, backoff, jitter) {
 					result.Err = outerCtx.Err()
 					return result
 				}
@@ -139,6 +156,52 @@ func Execute(cfg Config, attempt AttemptFunc) Result {
 	return result
 }
 
+func statusBackoff(resp *http.Response, retryPolicy policy.RetryPolicy, now time.Time) (time.Duration, time.Duration) {
+	if retryPolicy.RetryAfter {
+		if delay, ok := RetryAfter(resp, now, retryPolicy.RetryAfterCap); ok {
+			return delay, 0
+		}
+	}
+	if backoff, ok := retryPolicy.StatusBackoff[resp.StatusCode]; ok {
+		return backoff, retryPolicy.BackoffJitter
+	}
+	return retryPolicy.Backoff, retryPolicy.BackoffJitter
+}
+
+func RetryAfter(resp *http.Response, now time.Time, limit time.Duration) (time.Duration, bool) {
+	if limit <= 0 {
+		limit = time.Duration(math.MaxInt64)
+	}
+	if resp == nil {
+		return 0, false
+	}
+	value := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		if seconds > int64(limit/time.Second) {
+			return limit, true
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	at, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	delay := at.Sub(now)
+	if delay <= 0 {
+		return 0, true
+	}
+	if delay > limit {
+		return limit, true
+	}
+	return delay, true
+}
+
 func computePerTryTimeout(perTry time.Duration, remainingOuter time.Duration, remainingBudget time.Duration) time.Duration {
 	if remainingOuter <= 0 || remainingBudget <= 0 {
 		return 0
@@ -204,6 +267,21 @@ func sleepWithBackoff(ctx context.Context, backoff time.Duration, jitter time.Du
 	}
 }
 
+type cancelOnClose struct {
+	inner  io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Read(buffer []byte) (int, error) {
+	return c.inner.Read(buffer)
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.inner.Close()
+	c.cancel()
+	return err
+}
+
 func resultWithResponse(result Result, resp *http.Response) Result {
 	result.Response = resp
 	return result
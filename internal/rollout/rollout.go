@@ -4,16 +4,18 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"log"
 	"time"
 
 	"modern_reverse_proxy/internal/apply"
 	"modern_reverse_proxy/internal/bundle"
 	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/logging"
 	"modern_reverse_proxy/internal/obs"
 	"modern_reverse_proxy/internal/runtime"
 )
 
+var logger = logging.For("rollout")
+
 type Config struct {
 	ApplyManager     *apply.Manager
 	Store            *runtime.Store
@@ -62,12 +64,13 @@ func (m *Manager) ApplyBundle(ctx context.Context, bundlePayload bundle.Bundle,
 		source = sourceOverride
 	}
 	version := bundlePayload.Meta.Version
+	provenance := runtime.Provenance{Label: bundlePayload.Meta.Label, GitSHA: bundlePayload.Meta.GitSHA, Author: bundlePayload.Meta.Author}
 	previous := (*runtime.Snapshot)(nil)
 	if m.store != nil {
 		previous = m.store.Get()
 	}
 
-	result, err := m.apply.ApplyResolvedVersion(ctx, configBytes, source, version, apply.ModeValidate)
+	result, err := m.apply.ApplyResolvedProvenance(ctx, configBytes, source, version, provenance, apply.ModeValidate)
 	if err != nil {
 		m.recordStage("validate", "error", version, err)
 		return nil, err
@@ -79,7 +82,7 @@ func (m *Manager) ApplyBundle(ctx context.Context, bundlePayload bundle.Bundle,
 		m.recordStage("locked", "error", version, err)
 		return nil, err
 	}
-	result, err = m.apply.ApplyResolvedVersion(ctx, lockedBytes, source, version, apply.ModeApply)
+	result, err = m.apply.ApplyResolvedProvenance(ctx, lockedBytes, source, version, provenance, apply.ModeApply)
 	if err != nil {
 		m.recordStage("locked", "error", version, err)
 		return nil, err
@@ -101,7 +104,7 @@ func (m *Manager) ApplyBundle(ctx context.Context, bundlePayload bundle.Bundle,
 		return nil, err
 	}
 
-	result, err = m.apply.ApplyResolvedVersion(ctx, configBytes, source, version, apply.ModeApply)
+	result, err = m.apply.ApplyResolvedProvenance(ctx, configBytes, source, version, provenance, apply.ModeApply)
 	if err != nil {
 		m.recordStage("full", "error", version, err)
 		m.rollback(previous, version)
@@ -116,10 +119,10 @@ func (m *Manager) recordStage(stage string, result string, version string, err e
 		m.metrics.RecordRolloutStage(stage, result)
 	}
 	if err != nil {
-		log.Printf("bundle_version=%s rollout_stage=%s rollout_result=%s reason=%v", version, stage, result, err)
+		logger.Warn("bundle_rollout", "bundle_version", version, "rollout_stage", stage, "rollout_result", result, "reason", err)
 		return
 	}
-	log.Printf("bundle_version=%s rollout_stage=%s rollout_result=%s", version, stage, result)
+	logger.Info("bundle_rollout", "bundle_version", version, "rollout_stage", stage, "rollout_result", result)
 }
 
 func (m *Manager) rollback(previous *runtime.Snapshot, targetVersion string) {
@@ -127,13 +130,13 @@ func (m *Manager) rollback(previous *runtime.Snapshot, targetVersion string) {
 		return
 	}
 	if err := m.store.Swap(previous); err != nil {
-		log.Printf("rollback_from_version=%s rollback_result=error reason=%v", targetVersion, err)
+		logger.Warn("rollback", "rollback_from_version", targetVersion, "rollback_result", "error", "reason", err)
 		return
 	}
 	if m.metrics != nil {
 		m.metrics.RecordRollback("success")
 	}
-	log.Printf("rollback_from_version=%s rollback_to_version=%s", targetVersion, previous.Version)
+	logger.Info("rollback", "rollback_from_version", targetVersion, "rollback_to_version", previous.Version)
 }
 
 func lockConfigBytes(raw []byte) ([]byte, error) {
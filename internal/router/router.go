@@ -1,6 +1,7 @@
 package router
 
 import (
+	"context"
 	"net"
 	"net/http"
 	"strings"
@@ -8,6 +9,21 @@ import (
 	"modern_reverse_proxy/internal/policy"
 )
 
+const DefaultListener = "default"
+
+type listenerKey struct{}
+
+func WithListener(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, listenerKey{}, name)
+}
+
+func ListenerFromContext(ctx context.Context) string {
+	if name, ok := ctx.Value(listenerKey{}).(string); ok && name != "" {
+		return name
+	}
+	return DefaultListener
+}
+
 type Router struct {
 	routes []policy.Route
 }
@@ -25,7 +41,11 @@ func (r *Router) Match(req *http.Request) (policy.Route, bool) {
 		host = h
 	}
 
+	listener := ListenerFromContext(req.Context())
 	for _, route := range r.routes {
+		if route.Listeners != nil && !route.Listeners[listener] {
+			continue
+		}
 		if route.Host == host && strings.HasPrefix(req.URL.Path, route.PathPrefix) {
 			if route.Methods != nil && !route.Methods[req.Method] {
 				return policy.Route{}, false
@@ -36,3 +56,22 @@ func (r *Router) Match(req *http.Request) (policy.Route, bool) {
 
 	return policy.Route{}, false
 }
+
+func (r *Router) Routes() []policy.Route {
+	if r == nil {
+		return nil
+	}
+	return append([]policy.Route(nil), r.routes...)
+}
+
+func (r *Router) Route(id string) (policy.Route, bool) {
+	if r == nil {
+		return policy.Route{}, false
+	}
+	for _, route := range r.routes {
+		if route.ID == id {
+			return route, true
+		}
+	}
+	return policy.Route{}, false
+}
@@ -0,0 +1,100 @@
+package runtime
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"modern_reverse_proxy/internal/obs"
+)
+
+const (
+	defaultCertReloadInterval = 10 * time.Second
+	certExpiryWarnInterval    = 24 * time.Hour
+)
+
+type CertReloadObserver func(trigger string, result string)
+
+type CertWatcher struct {
+	store    *Store
+	interval time.Duration
+	observe  CertReloadObserver
+
+	warnMu sync.Mutex
+	warned map[string]time.Time
+}
+
+func NewCertWatcher(store *Store, interval time.Duration, observe CertReloadObserver) *CertWatcher {
+	if interval <= 0 {
+		interval = defaultCertReloadInterval
+	}
+	return &CertWatcher{store: store, interval: interval, observe: observe, warned: make(map[string]time.Time)}
+}
+
+func (w *CertWatcher) Run(ctx context.Context, signals <-chan struct{}) {
+	w.CheckExpiry()
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.Reload("watch", false)
+		case <-signals:
+			w.Reload("signal", true)
+		}
+	}
+}
+
+func (w *CertWatcher) Reload(trigger string, force bool) string {
+	snap := w.store.Get()
+	if snap == nil || snap.TLSStore == nil {
+		return "skipped"
+	}
+	changed, err := snap.TLSStore.Reload(force)
+	result := "unchanged"
+	switch {
+	case err != nil:
+		result = "error"
+		logger.Warn("tls_cert_reload", "trigger", trigger, "result", "error", "version", snap.Version, "err", err)
+	case changed:
+		result = "reloaded"
+		logger.Info("tls_cert_reload", "trigger", trigger, "result", "reloaded", "version", snap.Version)
+	}
+	if w.observe != nil && (result != "unchanged" || force) {
+		w.observe(trigger, result)
+	}
+	w.CheckExpiry()
+	return result
+}
+
+func (w *CertWatcher) CheckExpiry() int {
+	snap := w.store.Get()
+	if snap == nil || snap.TLSStore == nil {
+		return 0
+	}
+	w.warnMu.Lock()
+	defer w.warnMu.Unlock()
+
+	now := time.Now()
+	expiries := make(map[string]time.Time)
+	expiring := 0
+	for _, cert := range snap.TLSStore.Certificates() {
+		expiries[cert.ServerName] = cert.NotAfter
+		remaining := cert.NotAfter.Sub(now)
+		if remaining > snap.TLSExpiryWarning {
+			continue
+		}
+		expiring++
+		if last, ok := w.warned[cert.Fingerprint]; ok && now.Sub(last) < certExpiryWarnInterval {
+			continue
+		}
+		w.warned[cert.Fingerprint] = now
+		logger.Warn("tls_cert_expiring", "server_name", cert.ServerName, "not_after", cert.NotAfter.UTC(), "days_remaining", int(remaining.Hours()/24), "cert_file", cert.CertFile, "fingerprint", cert.Fingerprint)
+	}
+	metrics := obs.DefaultMetrics()
+	metrics.SetCertificateExpiry(expiries)
+	metrics.SetCertificatesExpiring(expiring)
+	return expiring
+}
@@ -0,0 +1,40 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+)
+
+const defaultAltSvcMaxAge = 24 * time.Hour
+
+type HTTP3Config struct {
+	Enabled      bool
+	Addr         string
+	AltSvc       bool
+	AltSvcMaxAge time.Duration
+}
+
+func HTTP3FromConfig(cfg config.TLSConfig) (HTTP3Config, error) {
+	if !cfg.HTTP3.Enabled {
+		return HTTP3Config{}, nil
+	}
+	if !cfg.Enabled {
+		return HTTP3Config{}, errors.New("http3 requires tls to be enabled")
+	}
+	if cfg.HTTP3.AltSvcMaxAgeMS < 0 {
+		return HTTP3Config{}, fmt.Errorf("http3 alt_svc_max_age_ms must be non-negative")
+	}
+	maxAge := defaultAltSvcMaxAge
+	if cfg.HTTP3.AltSvcMaxAgeMS > 0 {
+		maxAge = time.Duration(cfg.HTTP3.AltSvcMaxAgeMS) * time.Millisecond
+	}
+	return HTTP3Config{
+		Enabled:      true,
+		Addr:         cfg.HTTP3.Addr,
+		AltSvc:       boolOrDefault(cfg.HTTP3.AltSvc, true),
+		AltSvcMaxAge: maxAge,
+	}, nil
+}
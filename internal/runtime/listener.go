@@ -0,0 +1,111 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/limits"
+	"modern_reverse_proxy/internal/router"
+)
+
+type Listener struct {
+	Name   string
+	Addr   string
+	TLS    bool
+	Limits limits.Limits
+}
+
+func listenersFromConfig(cfg *config.Config, defaultLimits limits.Limits) (map[string]Listener, error) {
+	listeners := make(map[string]Listener, len(cfg.Listeners))
+	addrs := make(map[string]string, len(cfg.Listeners))
+	for _, listenerCfg := range cfg.Listeners {
+		name := listenerCfg.Name
+		if name == "" {
+			return nil, errors.New("listener name is required")
+		}
+		if name == router.DefaultListener || strings.ContainsAny(name, ". \t") {
+			return nil, fmt.Errorf("listener name %q is invalid", name)
+		}
+		if _, ok := listeners[name]; ok {
+			return nil, fmt.Errorf("listener name %q is not unique", name)
+		}
+		if listenerCfg.Addr == "" {
+			return nil, fmt.Errorf("listener %q addr is required", name)
+		}
+		if other, ok := addrs[listenerCfg.Addr]; ok {
+			return nil, fmt.Errorf("listener %q addr duplicates listener %q", name, other)
+		}
+		addrs[listenerCfg.Addr] = name
+
+		listener := Listener{Name: name, Addr: listenerCfg.Addr, Limits: defaultLimits}
+		switch listenerCfg.Protocol {
+		case "", "http":
+		case "https":
+			if !cfg.TLS.Enabled {
+				return nil, fmt.Errorf("listener %q protocol https requires tls enabled", name)
+			}
+			listener.TLS = true
+		default:
+			return nil, fmt.Errorf("listener %q protocol %q is invalid", name, listenerCfg.Protocol)
+		}
+		if listenerCfg.Limits != nil {
+			limitConfig, err := limits.FromConfig(*listenerCfg.Limits)
+			if err != nil {
+				return nil, fmt.Errorf("listener %q %v", name, err)
+			}
+			listener.Limits = limitConfig
+		}
+		if err := applyListenerLimits(&listener.Limits, listenerCfg); err != nil {
+			return nil, fmt.Errorf("listener %q %v", name, err)
+		}
+		listeners[name] = listener
+	}
+	return listeners, nil
+}
+
+func applyListenerLimits(limitConfig *limits.Limits, listenerCfg config.Listener) error {
+	if listenerCfg.MaxConcurrentRequests != nil {
+		limitConfig.MaxConcurrentRequests = *listenerCfg.MaxConcurrentRequests
+	}
+	if listenerCfg.MaxQueuedRequests != nil {
+		limitConfig.MaxQueuedRequests = *listenerCfg.MaxQueuedRequests
+	}
+	if listenerCfg.QueueTimeoutMS > 0 {
+		limitConfig.QueueTimeout = time.Duration(listenerCfg.QueueTimeoutMS) * time.Millisecond
+	} else if listenerCfg.QueueTimeoutMS < 0 {
+		return errors.New("queue_timeout_ms must be positive")
+	}
+	if listenerCfg.MaxConnections != nil {
+		limitConfig.MaxConnections = *listenerCfg.MaxConnections
+	}
+	if limitConfig.MaxConcurrentRequests < 0 {
+		return errors.New("max_concurrent_requests must be non-negative")
+	}
+	if limitConfig.MaxQueuedRequests < 0 {
+		return errors.New("max_queued_requests must be non-negative")
+	}
+	if limitConfig.MaxQueuedRequests > 0 && limitConfig.MaxConcurrentRequests == 0 {
+		return errors.New("max_queued_requests requires max_concurrent_requests")
+	}
+	if limitConfig.MaxConnections < 0 {
+		return errors.New("max_connections must be non-negative")
+	}
+	return nil
+}
+
+func routeListeners(route config.Route, listeners map[string]Listener) (map[string]bool, error) {
+	if len(route.Listeners) == 0 {
+		return nil, nil
+	}
+	attached := make(map[string]bool, len(route.Listeners))
+	for _, name := range route.Listeners {
+		if _, ok := listeners[name]; !ok && name != router.DefaultListener {
+			return nil, fmt.Errorf("route %q references missing listener %q", route.ID, name)
+		}
+		attached[name] = true
+	}
+	return attached, nil
+}
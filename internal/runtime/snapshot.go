@@ -1,52 +1,115 @@
 package runtime
 
 import (
+	"crypto/sha256"
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"time"
 
+	"golang.org/x/net/http/httpguts"
+
+	"modern_reverse_proxy/internal/bandwidth"
 	"modern_reverse_proxy/internal/breaker"
+	"modern_reverse_proxy/internal/concurrency"
 	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/fingerprint"
 	"modern_reverse_proxy/internal/health"
+	"modern_reverse_proxy/internal/keyauth"
 	"modern_reverse_proxy/internal/limits"
 	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/oidc"
 	"modern_reverse_proxy/internal/outlier"
 	"modern_reverse_proxy/internal/plugin"
 	"modern_reverse_proxy/internal/policy"
 	"modern_reverse_proxy/internal/pool"
 	"modern_reverse_proxy/internal/registry"
 	"modern_reverse_proxy/internal/router"
+	"modern_reverse_proxy/internal/script"
+	"modern_reverse_proxy/internal/secrets"
 	"modern_reverse_proxy/internal/tlsstore"
 	"modern_reverse_proxy/internal/traffic"
 	"modern_reverse_proxy/internal/transport"
 )
 
 type Snapshot struct {
-	ID          uint64
-	Router      *router.Router
-	Pools       map[string]pool.PoolKey
-	PoolConfigs map[string]PoolConfig
-	TLSEnabled  bool
-	TLSStore    *tlsstore.Store
-	TLSConfig   *tls.Config
-	TLSAddr     string
-	Version     string
-	CreatedAt   time.Time
-	Source      string
-	RouteCount  int
-	Limits      limits.Limits
-	Logging     config.LoggingConfig
-	refCount    atomic.Int64
-	retiredAt   atomic.Int64
+	ID               uint64
+	Router           *router.Router
+	Pools            map[string]pool.PoolKey
+	PoolConfigs      map[string]PoolConfig
+	TLSEnabled       bool
+	TLSStore         *tlsstore.Store
+	TLSConfig        *tls.Config
+	TLSAddr          string
+	TLSExpiryWarning time.Duration
+	TLSTicketKeys    *tlsstore.TicketKeyRotator
+	Streams          map[string]policy.Stream
+	Listeners        map[string]Listener
+	Version          string
+	CreatedAt        time.Time
+	Source           string
+	RouteCount       int
+	Limits           limits.Limits
+	RequestRules     []policy.RequestRule
+	RequestID        policy.RequestIDPolicy
+	DebugTrace       policy.DebugTracePolicy
+	SlowLog          policy.SlowLogPolicy
+	Logging          config.LoggingConfig
+	AccessLog        io.Writer
+	Config           *config.Config
+	FailSafe         bool
+	Provenance       Provenance
+	Reuse            BuildReuse
+	routeHashes      map[string]string
+	refCount         atomic.Int64
+	retiredAt        atomic.Int64
+}
+
+type Provenance struct {
+	Label  string `json:"label,omitempty"`
+	GitSHA string `json:"git_sha,omitempty"`
+	Author string `json:"author,omitempty"`
+}
+
+func (p Provenance) Merge(override Provenance) Provenance {
+	if override.Label != "" {
+		p.Label = override.Label
+	}
+	if override.GitSHA != "" {
+		p.GitSHA = override.GitSHA
+	}
+	if override.Author != "" {
+		p.Author = override.Author
+	}
+	return p
+}
+
+type BuildReuse struct {
+	RoutesReused    int `json:"routes_reused"`
+	RoutesCompiled  int `json:"routes_compiled"`
+	PoolsReused     int `json:"pools_reused"`
+	PoolsReconciled int `json:"pools_reconciled"`
 }
 
 type PoolConfig struct {
-	Breaker breaker.Config
-	Outlier outlier.Config
+	Breaker     breaker.Config
+	Outlier     outlier.Config
+	Concurrency concurrency.Config
 }
 
 const (
@@ -62,6 +125,7 @@ const (
 	defaultMaxEject                      = 5 * time.Minute
 	defaultRetryMaxAttempts              = 1
 	defaultRetryClientLRUSize            = 10000
+	defaultRetryAfterCap                 = 10 * time.Second
 	defaultCacheMaxObjectBytes           = int64(1024 * 1024)
 	defaultCacheMinObjectBytes           = int64(1024)
 	defaultCacheMaxObjectBytesLimit      = int64(50 * 1024 * 1024)
@@ -71,6 +135,13 @@ const (
 	defaultBreakerEvalWindow             = 10 * time.Second
 	defaultBreakerOpenDuration           = 2 * time.Second
 	defaultBreakerHalfOpenMaxProbes      = 5
+	defaultConcurrencyInitialLimit       = 20
+	defaultConcurrencyMinLimit           = 1
+	defaultConcurrencyMaxLimit           = 1000
+	defaultConcurrencyWindow             = time.Second
+	defaultConcurrencyTolerance          = 2.0
+	defaultConcurrencySmoothing          = 0.2
+	defaultConcurrencyBackoffRatio       = 0.9
 	defaultOutlierConsecutiveFailures    = 5
 	defaultOutlierErrorRateThreshold     = 50
 	defaultOutlierErrorRateWindow        = 30 * time.Second
@@ -84,7 +155,13 @@ const (
 	defaultOutlierLatencyMultiplier      = 3
 	defaultOutlierLatencyConsecutive     = 3
 	defaultCacheCoalesceTimeout          = 5 * time.Second
+	defaultCacheRevalidateWindow         = 10 * time.Minute
 	defaultTLSAddr                       = "127.0.0.1:8443"
+	defaultTLSExpiryWarningDays          = 14
+	defaultTicketRotationInterval        = time.Hour
+	defaultTicketRetainedKeys            = 2
+	minTicketRotationInterval            = time.Second
+	minTicketSeedBytes                   = 16
 	defaultTrafficStableWeight           = 100
 	defaultPluginRequestTimeout          = 50 * time.Millisecond
 	defaultPluginResponseTimeout         = 50 * time.Millisecond
@@ -92,17 +169,81 @@ const (
 	maxPluginFilters                     = 200
 	defaultPluginBreakerConsecutiveFails = 5
 	defaultPluginBreakerHalfOpenProbes   = 3
+	defaultPluginMaxBodyBytes            = 64 * 1024
+	maxPluginMaxBodyBytes                = 4 * 1024 * 1024
+	maxRetryBufferBodyBytes              = 16 * 1024 * 1024
+	maxErrorPageBytes                    = 64 * 1024
+	defaultRequestRuleTarpit             = 5 * time.Second
+	requestIDModeAccept                  = "accept"
+	requestIDModeRegenerate              = "regenerate"
+	requestIDModeTrusted                 = "trusted"
+	maxRequestRuleTarpit                 = time.Minute
+	defaultPluginBodyTimeout             = 250 * time.Millisecond
 	defaultPoolMaxIdlePerHost            = 256
 	defaultPoolIdleConnTimeout           = 90 * time.Second
+	defaultPoolMaxDrainBudget            = 30 * time.Second
+	defaultPoolWarmupTimeout             = 2 * time.Second
+	defaultSlowStartMinWeightPercent     = 10
+	defaultUnhealthyAfterStatusFailures  = 5
+	defaultMinLocalHealthyPercent        = 70
+	defaultDNSMinTTL                     = 5 * time.Second
+	defaultDNSMaxTTL                     = 5 * time.Minute
+	defaultDNSNegativeTTL                = 5 * time.Second
+	defaultDNSTimeout                    = time.Second
+	defaultDebugUpstreamHeader           = "X-Debug-Upstream"
+	defaultDebugTraceHeader              = "X-Debug-Trace"
+	defaultSlowLogThreshold              = time.Second
+	defaultScriptTimeout                 = 10 * time.Millisecond
+	maxScriptTimeout                     = time.Second
+	authModeOIDC                         = "oidc"
+	authModeAPIKey                       = "api_key"
+	authModeHMAC                         = "hmac"
+	maxHMACBodyBytes                     = 16 << 20
+	defaultMTLSIdentityHeader            = "X-Client-Identity"
+	spiffeScheme                         = "spiffe://"
+	streamModeTCP                        = "tcp"
+	defaultMirrorTimeout                 = 5 * time.Second
+	defaultMirrorMaxBodyBytes            = 1 << 20
+	defaultMirrorMaxInflight             = 100
+	defaultIdempotencyHeader             = "Idempotency-Key"
+	defaultIdempotencyMaxEntries         = 10000
+	defaultIdempotencyMaxBodyBytes       = 1 << 20
+	maxIdempotencyReplayTTL              = 24 * time.Hour
+	defaultHedgeMaxHedges                = 1
+	defaultDrainMaxCohorts               = 10000
+	defaultStreamConnectTimeout          = 5 * time.Second
+	defaultStreamIdleTimeout             = 5 * time.Minute
+	defaultCompressionMinSize            = int64(1024)
+	defaultDecompressionMaxRatio         = 100
+	defaultSSEHeartbeatInterval          = 15 * time.Second
+	maxRouteLabels                       = 16
+	maxRouteLabelKeyLen                  = 64
+	maxRouteLabelValueLen                = 128
+	maxProvenanceLength                  = 128
 )
 
 var (
-	defaultRetryStatuses = []int{502, 503, 504}
-	defaultRetryErrors   = []string{"dial", "timeout"}
-	snapshotIDCounter    atomic.Uint64
+	defaultRetryStatuses           = []int{502, 503, 504}
+	defaultRetryErrors             = []string{"dial", "timeout"}
+	defaultCompressionAlgorithms   = []string{"gzip"}
+	defaultCompressionContentTypes = []string{"text/", "application/json", "application/javascript", "application/xml", "image/svg+xml"}
+	supportedCompression           = map[string]bool{"gzip": true, "deflate": true, "br": true}
+	defaultRampSteps               = []int{1, 5, 25, 50, 100}
+	snapshotIDCounter              atomic.Uint64
 )
 
 func BuildSnapshot(cfg *config.Config, reg *registry.Registry, breakerReg *breaker.Registry, outlierReg *outlier.Registry, trafficReg *traffic.Registry) (*Snapshot, error) {
+	return buildSnapshot(cfg, nil, reg, breakerReg, outlierReg, trafficReg)
+}
+
+func BuildSnapshotDelta(cfg *config.Config, previous *Snapshot, reg *registry.Registry, breakerReg *breaker.Registry, outlierReg *outlier.Registry, trafficReg *traffic.Registry) (*Snapshot, error) {
+	if previous != nil && previous.FailSafe {
+		previous = nil
+	}
+	return buildSnapshot(cfg, previous, reg, breakerReg, outlierReg, trafficReg)
+}
+
+func buildSnapshot(cfg *config.Config, previous *Snapshot, reg *registry.Registry, breakerReg *breaker.Registry, outlierReg *outlier.Registry, trafficReg *traffic.Registry) (*Snapshot, error) {
 	_ = breakerReg
 	success := false
 	defer func() {
@@ -128,13 +269,37 @@ func BuildSnapshot(cfg *config.Config, reg *registry.Registry, breakerReg *break
 	if err != nil {
 		return nil, err
 	}
+	listeners, err := listenersFromConfig(cfg, limitConfig)
+	if err != nil {
+		return nil, err
+	}
+	globalRules, err := requestRulesFromConfig("request_rules", cfg.RequestRules)
+	if err != nil {
+		return nil, err
+	}
+	requestIDPolicy, err := requestIDPolicyFromConfig(cfg.RequestID)
+	if err != nil {
+		return nil, err
+	}
+	debugTracePolicy, err := debugTracePolicyFromConfig(cfg.DebugTrace)
+	if err != nil {
+		return nil, err
+	}
+	slowLogPolicy, err := slowLogPolicyFromConfig(cfg.SlowLog)
+	if err != nil {
+		return nil, err
+	}
 	if trafficReg == nil {
 		trafficReg = traffic.NewRegistry(0, 0)
 	}
 
+	var reuse BuildReuse
 	pools := make(map[string]pool.PoolKey, len(cfg.Pools))
 	poolConfigs := make(map[string]PoolConfig, len(cfg.Pools))
 	desiredPools := make(map[pool.PoolKey]struct{}, len(cfg.Pools))
+	poolFingerprints := make(map[pool.PoolKey]string, len(cfg.Pools))
+	reconciledPools := make(map[string]bool, len(cfg.Pools))
+	streamOnly := streamOnlyPools(cfg)
 	for name, poolCfg := range cfg.Pools {
 		if len(poolCfg.Endpoints) == 0 {
 			return nil, fmt.Errorf("pool %q has no endpoints", name)
@@ -142,14 +307,55 @@ func BuildSnapshot(cfg *config.Config, reg *registry.Registry, breakerReg *break
 		poolKey := pool.PoolKey(name)
 		pools[name] = poolKey
 
+		healthType := poolCfg.Health.Type
+		if healthType == "" {
+			healthType = health.CheckHTTP
+			if streamOnly[name] {
+				healthType = health.CheckTCP
+			}
+		}
+		if healthType != health.CheckHTTP && healthType != health.CheckTCP && healthType != health.CheckGRPC {
+			return nil, fmt.Errorf("pool %q health type %q is invalid", name, poolCfg.Health.Type)
+		}
+		expectedStatuses, err := health.ParseStatusRanges(poolCfg.Health.ExpectedStatuses)
+		if err != nil {
+			return nil, fmt.Errorf("pool %q health expected_statuses: %w", name, err)
+		}
+		passiveStatuses, err := health.ParseStatusRanges(poolCfg.Health.PassiveFailureStatuses)
+		if err != nil {
+			return nil, fmt.Errorf("pool %q health passive_failure_statuses: %w", name, err)
+		}
+		if poolCfg.Health.UnhealthyAfterStatusFailures < 0 {
+			return nil, fmt.Errorf("pool %q health unhealthy_after_status_failures must be >= 0", name)
+		}
+		if err := validateHealthPorts(name, poolCfg); err != nil {
+			return nil, err
+		}
+		healthMethod := strings.ToUpper(strings.TrimSpace(poolCfg.Health.Method))
+		if strings.ContainsAny(healthMethod, " \t/") {
+			return nil, fmt.Errorf("pool %q health method %q is invalid", name, poolCfg.Health.Method)
+		}
 		healthCfg := health.Config{
-			Path:                   stringOrDefault(poolCfg.Health.Path, defaultHealthPath),
-			Interval:               durationOrDefault(poolCfg.Health.IntervalMS, defaultHealthInterval),
-			Timeout:                durationOrDefault(poolCfg.Health.TimeoutMS, defaultHealthTimeout),
-			UnhealthyAfterFailures: intOrDefault(poolCfg.Health.UnhealthyAfterFailures, defaultUnhealthyAfterFailures),
-			HealthyAfterSuccesses:  intOrDefault(poolCfg.Health.HealthyAfterSuccesses, defaultHealthyAfterSuccesses),
-			BaseEjectDuration:      durationOrDefault(poolCfg.Health.BaseEjectMS, defaultBaseEject),
-			MaxEjectDuration:       durationOrDefault(poolCfg.Health.MaxEjectMS, defaultMaxEject),
+			Type:                         healthType,
+			Path:                         stringOrDefault(poolCfg.Health.Path, defaultHealthPath),
+			Interval:                     durationOrDefault(poolCfg.Health.IntervalMS, defaultHealthInterval),
+			Timeout:                      durationOrDefault(poolCfg.Health.TimeoutMS, defaultHealthTimeout),
+			UnhealthyAfterFailures:       intOrDefault(poolCfg.Health.UnhealthyAfterFailures, defaultUnhealthyAfterFailures),
+			HealthyAfterSuccesses:        intOrDefault(poolCfg.Health.HealthyAfterSuccesses, defaultHealthyAfterSuccesses),
+			BaseEjectDuration:            durationOrDefault(poolCfg.Health.BaseEjectMS, defaultBaseEject),
+			MaxEjectDuration:             durationOrDefault(poolCfg.Health.MaxEjectMS, defaultMaxEject),
+			Method:                       healthMethod,
+			Headers:                      poolCfg.Health.Headers,
+			ExpectedStatuses:             expectedStatuses,
+			BodyContains:                 poolCfg.Health.BodyContains,
+			TLS:                          poolCfg.Health.TLS,
+			TLSServerName:                poolCfg.Health.TLSServerName,
+			TLSInsecureSkipVerify:        poolCfg.Health.TLSInsecureSkipVerify,
+			Port:                         poolCfg.Health.Port,
+			EndpointPorts:                poolCfg.Health.EndpointPorts,
+			GRPCService:                  poolCfg.Health.GRPCService,
+			PassiveFailureStatuses:       passiveStatuses,
+			UnhealthyAfterStatusFailures: intOrDefault(poolCfg.Health.UnhealthyAfterStatusFailures, defaultUnhealthyAfterStatusFailures),
 		}
 
 		transportOpts := transport.Options{
@@ -157,7 +363,69 @@ func BuildSnapshot(cfg *config.Config, reg *registry.Registry, breakerReg *break
 			MaxConnsPerHost:     nonNegative(poolCfg.Transport.MaxConnsPerHost),
 			IdleConnTimeout:     durationOrDefault(poolCfg.Transport.IdleConnTimeoutMS, defaultPoolIdleConnTimeout),
 		}
-		reg.Reconcile(poolKey, poolCfg.Endpoints, healthCfg, transportOpts)
+		if warmup := poolCfg.Transport.Warmup; warmup.Connections != 0 || warmup.TimeoutMS != 0 {
+			if warmup.Connections < 0 || warmup.TimeoutMS < 0 {
+				return nil, fmt.Errorf("pool %q transport warmup connections and timeout_ms must be non-negative", name)
+			}
+			if warmup.Connections > transportOpts.MaxIdleConnsPerHost {
+				return nil, fmt.Errorf("pool %q transport warmup connections must be <= max_idle_per_host", name)
+			}
+			if warmup.Connections > 0 && streamOnly[name] {
+				return nil, fmt.Errorf("pool %q transport warmup requires an HTTP pool", name)
+			}
+			transportOpts.WarmupConnections = warmup.Connections
+			transportOpts.WarmupPath = stringOrDefault(warmup.Path, healthCfg.Path)
+			transportOpts.WarmupTimeout = durationOrDefault(warmup.TimeoutMS, defaultPoolWarmupTimeout)
+		}
+		dnsOpts, err := dnsOptionsFromPool(name, poolCfg.Transport.DNS)
+		if err != nil {
+			return nil, err
+		}
+		transportOpts.DNS = dnsOpts
+		tlsOpts, err := upstreamTLSFromPool(name, poolCfg.Transport.TLS)
+		if err != nil {
+			return nil, err
+		}
+		transportOpts.TLS = tlsOpts
+		proxyOpts, err := outboundProxyFromPool(name, poolCfg.Transport.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		transportOpts.Proxy = proxyOpts
+		if poolCfg.Drain.TimeoutMS < 0 || poolCfg.Drain.MaxBudgetMS < 0 {
+			return nil, fmt.Errorf("pool %q drain timeout_ms and max_budget_ms must be non-negative", name)
+		}
+		if poolCfg.Drain.MaxBudgetMS > 0 && poolCfg.Drain.MaxBudgetMS < poolCfg.Drain.TimeoutMS {
+			return nil, fmt.Errorf("pool %q drain max_budget_ms must be >= timeout_ms", name)
+		}
+		concurrencyCfg, err := concurrencyConfigFromPool(name, poolCfg.AdaptiveConcurrency)
+		if err != nil {
+			return nil, err
+		}
+		drainCfg := pool.DrainConfig{
+			Timeout:   durationOrZero(poolCfg.Drain.TimeoutMS),
+			MaxBudget: durationOrDefault(poolCfg.Drain.MaxBudgetMS, defaultPoolMaxDrainBudget),
+		}
+		if poolCfg.SlowStart.WindowMS < 0 || poolCfg.SlowStart.MinWeightPercent < 0 || poolCfg.SlowStart.MinWeightPercent > 100 {
+			return nil, fmt.Errorf("pool %q slow_start window_ms must be >= 0 and min_weight_percent between 0 and 100", name)
+		}
+		slowStartCfg := pool.SlowStartConfig{
+			Window:           durationOrZero(poolCfg.SlowStart.WindowMS),
+			MinWeightPercent: intOrDefault(poolCfg.SlowStart.MinWeightPercent, defaultSlowStartMinWeightPercent),
+		}
+		localityCfg, err := localityFromPool(name, poolCfg)
+		if err != nil {
+			return nil, err
+		}
+		fingerprint := poolFingerprint(poolCfg, healthCfg, transportOpts, drainCfg)
+		if previous != nil && fingerprint != "" && reg.PoolFingerprint(poolKey) == fingerprint {
+			reuse.PoolsReused++
+		} else {
+			reg.Reconcile(poolKey, poolCfg.Endpoints, healthCfg, transportOpts, drainCfg, slowStartCfg, localityCfg)
+			poolFingerprints[poolKey] = fingerprint
+			reconciledPools[name] = true
+			reuse.PoolsReconciled++
+		}
 		desiredPools[poolKey] = struct{}{}
 
 		poolConfigs[name] = PoolConfig{
@@ -184,11 +452,20 @@ func BuildSnapshot(cfg *config.Config, reg *registry.Registry, breakerReg *break
 				LatencyMinSamples:           intOrDefault(poolCfg.Outlier.LatencyMinSamples, defaultOutlierLatencyMinSamples),
 				LatencyMultiplier:           intOrDefault(poolCfg.Outlier.LatencyMultiplier, defaultOutlierLatencyMultiplier),
 				LatencyConsecutiveIntervals: intOrDefault(poolCfg.Outlier.LatencyConsecutiveIntervals, defaultOutlierLatencyConsecutive),
+				Shared:                      poolCfg.Outlier.Shared,
 			},
+			Concurrency: concurrencyCfg,
 		}
 	}
 	reg.PrunePools(desiredPools)
 
+	previousRoutes := make(map[string]policy.Route)
+	if previous != nil {
+		for _, route := range previous.Router.Routes() {
+			previousRoutes[route.ID] = route
+		}
+	}
+	routeHashes := make(map[string]string, len(cfg.Routes))
 	seenIDs := make(map[string]struct{}, len(cfg.Routes))
 	filterNames := make(map[string]struct{})
 	routes := make([]policy.Route, 0, len(cfg.Routes))
@@ -209,6 +486,11 @@ func BuildSnapshot(cfg *config.Config, reg *registry.Registry, breakerReg *break
 			return nil, fmt.Errorf("route %q references missing pool %q", route.ID, route.Pool)
 		}
 
+		labels, err := sanitizeRouteLabels(route.ID, route.Labels)
+		if err != nil {
+			return nil, err
+		}
+
 		methods := make(map[string]bool)
 		for _, method := range route.Methods {
 			if method == "" {
@@ -219,6 +501,10 @@ func BuildSnapshot(cfg *config.Config, reg *registry.Registry, breakerReg *break
 		if len(methods) == 0 {
 			methods = nil
 		}
+		attachedListeners, err := routeListeners(route, listeners)
+		if err != nil {
+			return nil, err
+		}
 
 		if route.Policy.RequireMTLS && route.Policy.MTLSClientCA != "" && route.Policy.MTLSClientCA != "default" {
 			return nil, fmt.Errorf("route %q mtls_client_ca must be default", route.ID)
@@ -227,47 +513,31 @@ func BuildSnapshot(cfg *config.Config, reg *registry.Registry, breakerReg *break
 			requiresMTLS = true
 		}
 
-		policyRuntime := policy.Policy{
-			RequestTimeout:                durationOrDefault(route.Policy.RequestTimeoutMS, defaultRequestTimeout),
-			UpstreamDialTimeout:           durationOrDefault(route.Policy.UpstreamDialTimeoutMS, defaultUpstreamDialTimeout),
-			UpstreamResponseHeaderTimeout: durationOrDefault(route.Policy.UpstreamResponseHeaderTimeoutMS, defaultUpstreamResponseHeaderTimeout),
-			Retry: policy.RetryPolicy{
-				Enabled:          route.Policy.Retry.Enabled,
-				MaxAttempts:      intOrDefault(route.Policy.Retry.MaxAttempts, defaultRetryMaxAttempts),
-				PerTryTimeout:    durationOrZero(route.Policy.Retry.PerTryTimeoutMS),
-				TotalRetryBudget: durationOrZero(route.Policy.Retry.TotalRetryBudgetMS),
-				RetryOnStatus:    retryStatusMap(route.Policy.Retry.RetryOnStatus),
-				RetryOnErrors:    retryErrorMap(route.Policy.Retry.RetryOnErrors),
-				Backoff:          durationOrZero(route.Policy.Retry.BackoffMS),
-				BackoffJitter:    durationOrZero(route.Policy.Retry.BackoffJitterMS),
-			},
-			RetryBudget: policy.RetryBudgetPolicy{
-				Enabled:            route.Policy.RetryBudget.Enabled,
-				PercentOfSuccesses: nonNegative(route.Policy.RetryBudget.PercentOfSuccesses),
-				Burst:              nonNegative(route.Policy.RetryBudget.Burst),
-			},
-			ClientRetryCap: policy.ClientRetryCapPolicy{
-				Enabled:            route.Policy.ClientRetryCap.Enabled,
-				Key:                route.Policy.ClientRetryCap.Key,
-				PercentOfSuccesses: nonNegative(route.Policy.ClientRetryCap.PercentOfSuccesses),
-				Burst:              nonNegative(route.Policy.ClientRetryCap.Burst),
-				LRUSize:            intOrDefault(route.Policy.ClientRetryCap.LRUSize, defaultRetryClientLRUSize),
-			},
-			RequireMTLS:  route.Policy.RequireMTLS,
-			MTLSClientCA: route.Policy.MTLSClientCA,
-		}
-
-		pluginPolicy, err := pluginPolicyFromConfig(route.ID, route.Policy.Plugins, filterNames)
-		if err != nil {
-			return nil, err
+		var policyRuntime policy.Policy
+		routeHash := routeFingerprint(route)
+		routeHashes[route.ID] = routeHash
+		previousRoute, reusable := previousRoutes[route.ID]
+		reusedRoute := reusable && routeHash != "" && previous.routeHashes[route.ID] == routeHash
+		if reusedRoute {
+			policyRuntime = previousRoute.Policy
+			if err := registerFilterNames(route.Policy.Plugins, filterNames); err != nil {
+				return nil, err
+			}
+			if err := validateRoutePoolReferences(route, pools); err != nil {
+				return nil, err
+			}
+			reuse.RoutesReused++
+		} else {
+			policyRuntime, err = routePolicyFromConfig(route, pools, filterNames)
+			if err != nil {
+				return nil, err
+			}
+			reuse.RoutesCompiled++
 		}
-		policyRuntime.Plugins = pluginPolicy
 
-		cachePolicy, err := cachePolicyFromConfig(route.ID, route.Policy.Cache)
-		if err != nil {
+		if err := validateRouteTimeouts(route.ID, policyRuntime, route.Policy.RequestTimeoutMS > 0, limitConfig, listeners, attachedListeners); err != nil {
 			return nil, err
 		}
-		policyRuntime.Cache = cachePolicy
 
 		trafficCfg, stablePoolName, canaryPoolName, err := trafficConfigFromRoute(route.ID, route.Policy.Traffic)
 		if err != nil {
@@ -294,7 +564,7 @@ func BuildSnapshot(cfg *config.Config, reg *registry.Registry, breakerReg *break
 			if err != nil {
 				return nil, err
 			}
-			if outlierReg != nil {
+			if outlierReg != nil && (!reusedRoute || reconciledPools[stablePoolName] || reconciledPools[canaryPoolName]) {
 				poolCfg := cfg.Pools[stablePoolName]
 				outlierCfg := poolConfigs[stablePoolName].Outlier
 				outlierReg.Reconcile(stablePoolKey, poolCfg.Endpoints, outlierCfg)
@@ -307,7 +577,7 @@ func BuildSnapshot(cfg *config.Config, reg *registry.Registry, breakerReg *break
 		} else {
 			stablePoolName = route.Pool
 			stablePoolKey = fmt.Sprintf("%s::%s", route.ID, route.Pool)
-			if outlierReg != nil {
+			if outlierReg != nil && (!reusedRoute || reconciledPools[route.Pool]) {
 				poolCfg := cfg.Pools[route.Pool]
 				outlierCfg := poolConfigs[route.Pool].Outlier
 				outlierReg.Reconcile(stablePoolKey, poolCfg.Endpoints, outlierCfg)
@@ -319,12 +589,14 @@ func BuildSnapshot(cfg *config.Config, reg *registry.Registry, breakerReg *break
 			Host:           route.Host,
 			PathPrefix:     route.PathPrefix,
 			Methods:        methods,
+			Listeners:      attachedListeners,
 			PoolName:       stablePoolName,
 			CanaryPoolName: canaryPoolName,
 			StablePoolKey:  stablePoolKey,
 			CanaryPoolKey:  canaryPoolKey,
 			TrafficPlan:    trafficPlan,
 			Policy:         policyRuntime,
+			Labels:         labels,
 		})
 	}
 
@@ -336,8 +608,29 @@ func BuildSnapshot(cfg *config.Config, reg *registry.Registry, breakerReg *break
 	var tlsStore *tlsstore.Store
 	var tlsConfig *tls.Config
 	tlsAddr := ""
+	var tlsExpiryWarning time.Duration
+	var tlsTicketKeys *tlsstore.TicketKeyRotator
 	if cfg.TLS.Enabled {
-		if len(cfg.TLS.Certs) == 0 {
+		var acmeManager *tlsstore.ACMEManager
+		if cfg.TLS.ACME.Enabled {
+			if cfg.TLS.ACME.RenewBeforeMS < 0 {
+				return nil, errors.New("tls acme renew_before_ms must be >= 0")
+			}
+			var err error
+			acmeManager, err = tlsstore.ACMEManagerFor(tlsstore.ACMESpec{
+				Hostnames:    cfg.TLS.ACME.Hostnames,
+				Email:        strings.TrimSpace(cfg.TLS.ACME.Email),
+				DirectoryURL: strings.TrimSpace(cfg.TLS.ACME.DirectoryURL),
+				CacheDir:     strings.TrimSpace(cfg.TLS.ACME.CacheDir),
+				HTTP01:       boolOrDefault(cfg.TLS.ACME.HTTP01, true),
+				RenewBefore:  durationOrZero(cfg.TLS.ACME.RenewBeforeMS),
+				CARootFile:   strings.TrimSpace(cfg.TLS.ACME.CARootFile),
+			})
+			if err != nil {
+				return nil, fmt.Errorf("tls %v", err)
+			}
+		}
+		if len(cfg.TLS.Certs) == 0 && acmeManager == nil {
 			return nil, errors.New("tls enabled but no certs configured")
 		}
 		if requiresMTLS && cfg.TLS.ClientCAFile == "" {
@@ -352,10 +645,18 @@ func BuildSnapshot(cfg *config.Config, reg *registry.Registry, breakerReg *break
 			})
 		}
 		var err error
-		tlsStore, err = tlsstore.LoadStore(certSpecs, cfg.TLS.ClientCAFile)
+		tlsStore, err = tlsstore.LoadStoreWithACME(certSpecs, cfg.TLS.ClientCAFile, acmeManager)
 		if err != nil {
 			return nil, err
 		}
+		if cfg.TLS.OCSPStapling {
+			tlsStore.SetOCSPStapler(tlsstore.SharedOCSPStapler(func(result string) {
+				obs.DefaultMetrics().RecordOCSPStaple(result)
+			}))
+		}
+		tlsStore.SetSelectionObserver(func(certificate string, missReason string) {
+			obs.DefaultMetrics().RecordCertificateSelection(certificate, missReason)
+		})
 		minVersion, err := parseTLSMinVersion(cfg.TLS.MinVersion)
 		if err != nil {
 			return nil, err
@@ -370,6 +671,9 @@ func BuildSnapshot(cfg *config.Config, reg *registry.Registry, breakerReg *break
 			ClientAuth:     tls.RequestClientCert,
 			NextProtos:     []string{"h2", "http/1.1"},
 		}
+		if acmeManager != nil {
+			tlsConfig.NextProtos = append(tlsConfig.NextProtos, tlsstore.ACMEALPNProto)
+		}
 		if len(cipherSuites) > 0 {
 			tlsConfig.CipherSuites = cipherSuites
 		}
@@ -377,31 +681,410 @@ func BuildSnapshot(cfg *config.Config, reg *registry.Registry, breakerReg *break
 		if tlsAddr == "" {
 			tlsAddr = defaultTLSAddr
 		}
+		if cfg.TLS.ExpiryWarningDays < 0 {
+			return nil, errors.New("tls expiry_warning_days must be non-negative")
+		}
+		expiryWarningDays := cfg.TLS.ExpiryWarningDays
+		if expiryWarningDays == 0 {
+			expiryWarningDays = defaultTLSExpiryWarningDays
+		}
+		tlsExpiryWarning = time.Duration(expiryWarningDays) * 24 * time.Hour
+		tlsTicketKeys, err = ticketKeysFromConfig(cfg.TLS.SessionTickets)
+		if err != nil {
+			return nil, err
+		}
 	}
 	if requiresMTLS && !cfg.TLS.Enabled {
 		return nil, errors.New("mtls required but tls disabled")
 	}
 
+	streams, err := streamsFromConfig(cfg, poolConfigs, outlierReg)
+	if err != nil {
+		return nil, err
+	}
+
+	provenance, err := ProvenanceFromConfig(cfg.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	accessLogSink, err := accessLogSinkFromConfig("logging access_log", cfg.Logging.AccessLog)
+	if err != nil {
+		return nil, err
+	}
+
 	snapshot := &Snapshot{
-		ID:          nextSnapshotID(),
-		Router:      compiled,
-		Pools:       pools,
-		PoolConfigs: poolConfigs,
-		TLSEnabled:  cfg.TLS.Enabled,
-		TLSStore:    tlsStore,
-		TLSConfig:   tlsConfig,
-		TLSAddr:     tlsAddr,
-		Version:     fmt.Sprintf("v-%d", time.Now().UnixNano()),
-		CreatedAt:   time.Now().UTC(),
-		Source:      "file",
-		RouteCount:  len(routes),
-		Limits:      limitConfig,
-		Logging:     cfg.Logging,
+		ID:               nextSnapshotID(),
+		Router:           compiled,
+		Pools:            pools,
+		PoolConfigs:      poolConfigs,
+		TLSEnabled:       cfg.TLS.Enabled,
+		TLSStore:         tlsStore,
+		TLSConfig:        tlsConfig,
+		TLSAddr:          tlsAddr,
+		TLSExpiryWarning: tlsExpiryWarning,
+		TLSTicketKeys:    tlsTicketKeys,
+		Streams:          streams,
+		Listeners:        listeners,
+		Version:          fmt.Sprintf("v-%d", time.Now().UnixNano()),
+		CreatedAt:        time.Now().UTC(),
+		Source:           "file",
+		RouteCount:       len(routes),
+		Limits:           limitConfig,
+		RequestRules:     globalRules,
+		RequestID:        requestIDPolicy,
+		DebugTrace:       debugTracePolicy,
+		SlowLog:          slowLogPolicy,
+		Logging:          cfg.Logging,
+		AccessLog:        accessLogSink,
+		Config:           cfg,
+		Provenance:       provenance,
+		Reuse:            reuse,
+		routeHashes:      routeHashes,
+	}
+	for key, fingerprint := range poolFingerprints {
+		reg.SetPoolFingerprint(key, fingerprint)
 	}
 	success = true
 	return snapshot, nil
 }
 
+func routePolicyFromConfig(route config.Route, pools map[string]pool.PoolKey, filterNames map[string]struct{}) (policy.Policy, error) {
+	statusBackoff, err := retryStatusBackoff(route.ID, route.Policy.Retry.StatusBackoffMS)
+	if err != nil {
+		return policy.Policy{}, err
+	}
+	if route.Policy.Retry.RetryAfterCapMS < 0 {
+		return policy.Policy{}, fmt.Errorf("route %q retry retry_after_cap_ms must be >= 0", route.ID)
+	}
+	if route.Policy.RequestTimeoutMS < 0 || route.Policy.IdleTimeoutMS < 0 || route.Policy.ResponseStreamTimeoutMS < 0 || route.Policy.Retry.PerTryTimeoutMS < 0 {
+		return policy.Policy{}, fmt.Errorf("route %q timeouts must be >= 0", route.ID)
+	}
+	if route.Policy.Retry.BufferBodyBytes < 0 || route.Policy.Retry.BufferBodyBytes > maxRetryBufferBodyBytes {
+		return policy.Policy{}, fmt.Errorf("route %q retry buffer_body_bytes must be between 0 and %d", route.ID, maxRetryBufferBodyBytes)
+	}
+
+	policyRuntime := policy.Policy{
+		RequestTimeout:                durationOrDefault(route.Policy.RequestTimeoutMS, defaultRequestTimeout),
+		UpstreamDialTimeout:           durationOrDefault(route.Policy.UpstreamDialTimeoutMS, defaultUpstreamDialTimeout),
+		UpstreamResponseHeaderTimeout: durationOrDefault(route.Policy.UpstreamResponseHeaderTimeoutMS, defaultUpstreamResponseHeaderTimeout),
+		IdleTimeout:                   durationOrZero(route.Policy.IdleTimeoutMS),
+		ResponseStreamTimeout:         durationOrZero(route.Policy.ResponseStreamTimeoutMS),
+		Retry: policy.RetryPolicy{
+			Enabled:          route.Policy.Retry.Enabled,
+			MaxAttempts:      intOrDefault(route.Policy.Retry.MaxAttempts, defaultRetryMaxAttempts),
+			PerTryTimeout:    durationOrZero(route.Policy.Retry.PerTryTimeoutMS),
+			TotalRetryBudget: durationOrZero(route.Policy.Retry.TotalRetryBudgetMS),
+			RetryOnStatus:    retryStatusMap(route.Policy.Retry.RetryOnStatus),
+			RetryOnErrors:    retryErrorMap(route.Policy.Retry.RetryOnErrors),
+			Backoff:          durationOrZero(route.Policy.Retry.BackoffMS),
+			BackoffJitter:    durationOrZero(route.Policy.Retry.BackoffJitterMS),
+			StatusBackoff:    statusBackoff,
+			RetryAfter:       route.Policy.Retry.RespectRetryAfter,
+			RetryAfterCap:    durationOrDefault(route.Policy.Retry.RetryAfterCapMS, defaultRetryAfterCap),
+			ExcludeAttempted: route.Policy.Retry.ExcludeAttempted,
+			BufferBodyBytes:  route.Policy.Retry.BufferBodyBytes,
+			NonIdempotent:    route.Policy.Retry.NonIdempotent,
+		},
+		RetryBudget: policy.RetryBudgetPolicy{
+			Enabled:            route.Policy.RetryBudget.Enabled,
+			PercentOfSuccesses: nonNegative(route.Policy.RetryBudget.PercentOfSuccesses),
+			Burst:              nonNegative(route.Policy.RetryBudget.Burst),
+		},
+		ClientRetryCap: policy.ClientRetryCapPolicy{
+			Enabled:            route.Policy.ClientRetryCap.Enabled,
+			Key:                route.Policy.ClientRetryCap.Key,
+			PercentOfSuccesses: nonNegative(route.Policy.ClientRetryCap.PercentOfSuccesses),
+			Burst:              nonNegative(route.Policy.ClientRetryCap.Burst),
+			LRUSize:            intOrDefault(route.Policy.ClientRetryCap.LRUSize, defaultRetryClientLRUSize),
+		},
+		RequireMTLS:  route.Policy.RequireMTLS,
+		MTLSClientCA: route.Policy.MTLSClientCA,
+		RequestDecompression: policy.DecompressionPolicy{
+			Enabled:  route.Policy.RequestDecompression.Enabled,
+			MaxRatio: intOrDefault(route.Policy.RequestDecompression.MaxRatio, defaultDecompressionMaxRatio),
+		},
+	}
+
+	pluginPolicy, err := pluginPolicyFromConfig(route.ID, route.Policy.Plugins, filterNames)
+	if err != nil {
+		return policy.Policy{}, err
+	}
+	policyRuntime.Plugins = pluginPolicy
+
+	cachePolicy, err := cachePolicyFromConfig(route.ID, route.Policy.Cache)
+	if err != nil {
+		return policy.Policy{}, err
+	}
+	policyRuntime.Cache = cachePolicy
+
+	compressionPolicy, err := compressionPolicyFromConfig(route.ID, route.Policy.Compression)
+	if err != nil {
+		return policy.Policy{}, err
+	}
+	policyRuntime.Compression = compressionPolicy
+
+	fingerprintPolicy, err := fingerprintPolicyFromConfig(route.ID, route.Policy.TLSFingerprint)
+	if err != nil {
+		return policy.Policy{}, err
+	}
+	policyRuntime.TLSFingerprint = fingerprintPolicy
+
+	requestBodyPolicy, err := requestBodyPolicyFromConfig(route.ID, route.Policy.RequestBody)
+	if err != nil {
+		return policy.Policy{}, err
+	}
+	policyRuntime.RequestBody = requestBodyPolicy
+
+	ssePolicy, err := ssePolicyFromConfig(route.ID, route.Policy.SSE)
+	if err != nil {
+		return policy.Policy{}, err
+	}
+	policyRuntime.SSE = ssePolicy
+
+	bandwidthPolicy, err := bandwidthPolicyFromConfig(route.ID, route.Policy.Bandwidth)
+	if err != nil {
+		return policy.Policy{}, err
+	}
+	policyRuntime.Bandwidth = bandwidthPolicy
+
+	debugUpstreamPolicy, err := debugUpstreamPolicyFromConfig(route.ID, route.Policy.DebugUpstream)
+	if err != nil {
+		return policy.Policy{}, err
+	}
+	policyRuntime.DebugUpstream = debugUpstreamPolicy
+
+	scriptPolicy, err := scriptPolicyFromConfig(route.ID, route.Policy.Script)
+	if err != nil {
+		return policy.Policy{}, err
+	}
+	policyRuntime.Script = scriptPolicy
+
+	mtlsPolicy, err := mtlsPolicyFromConfig(route.ID, route.Policy)
+	if err != nil {
+		return policy.Policy{}, err
+	}
+	policyRuntime.MTLS = mtlsPolicy
+
+	mirrorPolicy, err := mirrorPolicyFromConfig(route.ID, route.Policy.Mirror, pools)
+	if err != nil {
+		return policy.Policy{}, err
+	}
+	policyRuntime.Mirror = mirrorPolicy
+
+	hedgePolicy, err := hedgePolicyFromConfig(route.ID, route.Policy.Hedge)
+	if err != nil {
+		return policy.Policy{}, err
+	}
+	policyRuntime.Hedge = hedgePolicy
+
+	idempotencyPolicy, err := idempotencyPolicyFromConfig(route.ID, route.Policy.Idempotency)
+	if err != nil {
+		return policy.Policy{}, err
+	}
+	policyRuntime.Idempotency = idempotencyPolicy
+
+	errorPages, err := errorPagesFromConfig(route.ID, route.Policy.ErrorPages)
+	if err != nil {
+		return policy.Policy{}, err
+	}
+	policyRuntime.ErrorPages = errorPages
+
+	corsPolicy, err := corsPolicyFromConfig(route.ID, route.Policy.CORS)
+	if err != nil {
+		return policy.Policy{}, err
+	}
+	policyRuntime.CORS = corsPolicy
+
+	requestRules, err := requestRulesFromConfig(fmt.Sprintf("route %q request_rules", route.ID), route.Policy.RequestRules)
+	if err != nil {
+		return policy.Policy{}, err
+	}
+	policyRuntime.RequestRules = requestRules
+
+	securityHeadersPolicy, err := securityHeadersPolicyFromConfig(route.ID, route.Policy.SecurityHeaders)
+	if err != nil {
+		return policy.Policy{}, err
+	}
+	policyRuntime.SecurityHeaders = securityHeadersPolicy
+	policyRuntime.ServerTiming = route.Policy.ServerTiming
+	if route.Policy.SlowLogThresholdMS < 0 {
+		return policy.Policy{}, fmt.Errorf("route %q slow_log_threshold_ms must be >= 0", route.ID)
+	}
+	policyRuntime.SlowLogThreshold = durationOrZero(route.Policy.SlowLogThresholdMS)
+
+	accessLogPolicy, err := accessLogPolicyFromConfig(route.ID, route.Policy.AccessLog)
+	if err != nil {
+		return policy.Policy{}, err
+	}
+	policyRuntime.AccessLog = accessLogPolicy
+
+	accessPolicy, err := accessPolicyFromConfig(route.ID, route.Policy.Access)
+	if err != nil {
+		return policy.Policy{}, err
+	}
+	policyRuntime.Access = accessPolicy
+
+	authPolicy, err := authPolicyFromConfig(route.ID, route.Policy)
+	if err != nil {
+		return policy.Policy{}, err
+	}
+	policyRuntime.Auth = authPolicy
+
+	if route.Policy.SnapshotSwap.RematchAfterMS < 0 {
+		return policy.Policy{}, fmt.Errorf("route %q snapshot_swap rematch_after_ms must be >= 0", route.ID)
+	}
+	policyRuntime.SnapshotSwap = policy.SnapshotSwapPolicy{
+		Rematch:      route.Policy.SnapshotSwap.Rematch,
+		RematchAfter: durationOrZero(route.Policy.SnapshotSwap.RematchAfterMS),
+	}
+	return policyRuntime, nil
+}
+
+func registerFilterNames(pluginCfg config.PluginConfig, filterNames map[string]struct{}) error {
+	for _, filter := range pluginCfg.Filters {
+		filterNames[strings.TrimSpace(filter.Name)] = struct{}{}
+		if len(filterNames) > maxPluginFilters {
+			return fmt.Errorf("plugin filter count exceeds %d", maxPluginFilters)
+		}
+	}
+	return nil
+}
+
+func routeFingerprint(route config.Route) string {
+	inputs := make(map[string]string)
+	collectRouteInputs(reflect.ValueOf(route.Policy), inputs)
+	raw, err := json.Marshal(struct {
+		Route  config.Route      `json:"route"`
+		Inputs map[string]string `json:"inputs"`
+	}{route, inputs})
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+func collectRouteInputs(value reflect.Value, inputs map[string]string) {
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !value.IsNil() {
+			collectRouteInputs(value.Elem(), inputs)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			collectRouteInputs(value.Index(i), inputs)
+		}
+	case reflect.Map:
+		iter := value.MapRange()
+		for iter.Next() {
+			collectRouteInputs(iter.Value(), inputs)
+		}
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			field := value.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			fieldValue := value.Field(i)
+			if fieldValue.Kind() != reflect.String || fieldValue.String() == "" {
+				collectRouteInputs(fieldValue, inputs)
+				continue
+			}
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			switch {
+			case name == "file" || name == "module" || strings.HasSuffix(name, "_file"):
+				path := fieldValue.String()
+				data, err := os.ReadFile(path)
+				if err != nil {
+					inputs["file:"+path] = "error:" + err.Error()
+					continue
+				}
+				sum := sha256.Sum256(data)
+				inputs["file:"+path] = hex.EncodeToString(sum[:])
+			case strings.HasSuffix(name, "_env"):
+				env := fieldValue.String()
+				sum := sha256.Sum256([]byte(secrets.LookupEnv(env)))
+				inputs["env:"+env] = hex.EncodeToString(sum[:])
+			}
+		}
+	}
+}
+
+func validateRoutePoolReferences(route config.Route, pools map[string]pool.PoolKey) error {
+	if mirrorPool := route.Policy.Mirror.Pool; mirrorPool != "" {
+		if _, ok := pools[mirrorPool]; !ok {
+			return fmt.Errorf("route %q mirror references missing pool %q", route.ID, mirrorPool)
+		}
+	}
+	return nil
+}
+
+func poolFingerprint(poolCfg config.Pool, healthCfg health.Config, transportOpts transport.Options, drainCfg pool.DrainConfig) string {
+	raw, err := json.Marshal(struct {
+		Config    config.Pool       `json:"config"`
+		Health    health.Config     `json:"health"`
+		Transport transport.Options `json:"transport"`
+		Drain     pool.DrainConfig  `json:"drain"`
+	}{poolCfg, healthCfg, transportOpts, drainCfg})
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+func ProvenanceFromConfig(metadata config.MetadataConfig) (Provenance, error) {
+	provenance := Provenance{
+		Label:  strings.TrimSpace(metadata.VersionLabel),
+		GitSHA: strings.TrimSpace(metadata.GitSHA),
+		Author: strings.TrimSpace(metadata.Author),
+	}
+	if err := ValidateProvenance(provenance); err != nil {
+		return Provenance{}, err
+	}
+	return provenance, nil
+}
+
+func ValidateProvenance(provenance Provenance) error {
+	fields := []struct {
+		name  string
+		value string
+	}{
+		{"version_label", provenance.Label},
+		{"git_sha", provenance.GitSHA},
+		{"author", provenance.Author},
+	}
+	for _, field := range fields {
+		if len(field.value) > maxProvenanceLength || !isASCII(field.value) {
+			return fmt.Errorf("metadata %s must be ASCII and at most %d characters", field.name, maxProvenanceLength)
+		}
+	}
+	if provenance.GitSHA != "" && !isHexString(provenance.GitSHA) {
+		return fmt.Errorf("metadata git_sha must be hex")
+	}
+	return nil
+}
+
+func isHexString(value string) bool {
+	for _, r := range value {
+		if !(r >= '0' && r <= '9') && !(r >= 'a' && r <= 'f') && !(r >= 'A' && r <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+func FailSafeSnapshot(reg *registry.Registry, trafficReg *traffic.Registry) (*Snapshot, error) {
+	snapshot, err := BuildSnapshot(&config.Config{}, reg, nil, nil, trafficReg)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Version = "failsafe"
+	snapshot.Source = "failsafe"
+	snapshot.FailSafe = true
+	return snapshot, nil
+}
+
 func nextSnapshotID() uint64 {
 	return snapshotIDCounter.Add(1)
 }
@@ -455,6 +1138,13 @@ func cachePolicyFromConfig(routeID string, cacheCfg config.CacheConfig) (policy.
 	if cacheCfg.Enabled && ttl <= 0 {
 		return policy.CachePolicy{}, fmt.Errorf("route %q cache ttl_ms must be > 0", routeID)
 	}
+	if cacheCfg.RevalidateWindowMS < 0 {
+		return policy.CachePolicy{}, fmt.Errorf("route %q cache revalidate_window_ms must be >= 0", routeID)
+	}
+	statusTTLs, err := cacheStatusTTLs(routeID, cacheCfg.CacheableStatuses, ttl)
+	if err != nil {
+		return policy.CachePolicy{}, err
+	}
 
 	return policy.CachePolicy{
 		Enabled:             cacheCfg.Enabled,
@@ -465,12 +1155,1301 @@ func cachePolicyFromConfig(routeID string, cacheCfg config.CacheConfig) (policy.
 		CoalesceEnabled:     coalesceEnabled,
 		CoalesceTimeout:     coalesceTimeout,
 		OnlyIfContentLength: onlyIfContentLength,
+		RespectCacheControl: boolOrDefault(cacheCfg.RespectCacheControl, true),
+		RevalidateWindow:    durationOrDefault(cacheCfg.RevalidateWindowMS, defaultCacheRevalidateWindow),
+		StatusTTLs:          statusTTLs,
+		RangeCollapse:       cacheCfg.RangeCollapse,
 	}, nil
 }
 
-func pluginPolicyFromConfig(routeID string, pluginCfg config.PluginConfig, filterNames map[string]struct{}) (plugin.Policy, error) {
-	filters := make([]plugin.Filter, 0, len(pluginCfg.Filters))
-	if pluginCfg.Enabled && len(pluginCfg.Filters) == 0 {
+var cacheableStatusCodes = map[int]bool{
+	http.StatusOK:                   true,
+	http.StatusNonAuthoritativeInfo: true,
+	http.StatusNoContent:            true,
+	http.StatusMultipleChoices:      true,
+	http.StatusMovedPermanently:     true,
+	http.StatusFound:                true,
+	http.StatusTemporaryRedirect:    true,
+	http.StatusPermanentRedirect:    true,
+	http.StatusNotFound:             true,
+	http.StatusMethodNotAllowed:     true,
+	http.StatusGone:                 true,
+	http.StatusRequestURITooLong:    true,
+	http.StatusNotImplemented:       true,
+}
+
+func cacheStatusTTLs(routeID string, statuses []config.CacheStatusConfig, ttl time.Duration) (map[int]time.Duration, error) {
+	if len(statuses) == 0 {
+		return map[int]time.Duration{http.StatusOK: ttl}, nil
+	}
+	ttls := make(map[int]time.Duration, len(statuses))
+	for _, status := range statuses {
+		if !cacheableStatusCodes[status.Status] {
+			return nil, fmt.Errorf("route %q cache status %d is not cacheable", routeID, status.Status)
+		}
+		if _, ok := ttls[status.Status]; ok {
+			return nil, fmt.Errorf("route %q cache status %d is duplicated", routeID, status.Status)
+		}
+		if status.TTLMS < 0 {
+			return nil, fmt.Errorf("route %q cache status %d ttl_ms must be >= 0", routeID, status.Status)
+		}
+		ttls[status.Status] = durationOrDefault(status.TTLMS, ttl)
+	}
+	return ttls, nil
+}
+
+func fingerprintPolicyFromConfig(routeID string, fingerprintCfg config.FingerprintConfig) (policy.FingerprintPolicy, error) {
+	if fingerprintCfg.RateLimitRPS < 0 || fingerprintCfg.RateLimitBurst < 0 || fingerprintCfg.RateLimitKeys < 0 {
+		return policy.FingerprintPolicy{}, fmt.Errorf("route %q tls_fingerprint rate limits must be non-negative", routeID)
+	}
+	allow, err := fingerprintSet(routeID, fingerprintCfg.Allow)
+	if err != nil {
+		return policy.FingerprintPolicy{}, err
+	}
+	deny, err := fingerprintSet(routeID, fingerprintCfg.Deny)
+	if err != nil {
+		return policy.FingerprintPolicy{}, err
+	}
+	return policy.FingerprintPolicy{
+		Allow:   allow,
+		Deny:    deny,
+		Limiter: fingerprint.NewLimiter(fingerprintCfg.RateLimitRPS, fingerprintCfg.RateLimitBurst, fingerprintCfg.RateLimitKeys),
+	}, nil
+}
+
+func fingerprintSet(routeID string, values []string) (map[string]bool, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			return nil, fmt.Errorf("route %q tls_fingerprint entry is empty", routeID)
+		}
+		set[value] = true
+	}
+	return set, nil
+}
+
+func requestBodyPolicyFromConfig(routeID string, bodyCfg config.RequestBodyConfig) (policy.RequestBodyPolicy, error) {
+	if bodyCfg.MaxBodyBytes < 0 {
+		return policy.RequestBodyPolicy{}, fmt.Errorf("route %q request_body max_body_bytes must be non-negative", routeID)
+	}
+	bodyPolicy := policy.RequestBodyPolicy{MaxBodyBytes: bodyCfg.MaxBodyBytes}
+	switch strings.ToLower(strings.TrimSpace(bodyCfg.Mode)) {
+	case "", "buffer":
+	case "stream":
+		bodyPolicy.Stream = true
+	default:
+		return policy.RequestBodyPolicy{}, fmt.Errorf("route %q request_body mode must be buffer or stream", routeID)
+	}
+	multipartPolicy, err := multipartPolicyFromConfig(routeID, bodyCfg.Multipart)
+	if err != nil {
+		return policy.RequestBodyPolicy{}, err
+	}
+	bodyPolicy.Multipart = multipartPolicy
+	return bodyPolicy, nil
+}
+
+func multipartPolicyFromConfig(routeID string, multipartCfg config.MultipartConfig) (policy.MultipartPolicy, error) {
+	if !multipartCfg.Enabled {
+		return policy.MultipartPolicy{}, nil
+	}
+	if multipartCfg.MaxPartBytes < 0 || multipartCfg.MaxParts < 0 {
+		return policy.MultipartPolicy{}, fmt.Errorf("route %q request_body multipart limits must be non-negative", routeID)
+	}
+	if multipartCfg.StripDisallowed && len(multipartCfg.AllowedContentTypes) == 0 {
+		return policy.MultipartPolicy{}, fmt.Errorf("route %q request_body multipart strip_disallowed requires allowed_content_types", routeID)
+	}
+	allowed := make([]string, 0, len(multipartCfg.AllowedContentTypes))
+	for _, contentType := range multipartCfg.AllowedContentTypes {
+		contentType = strings.ToLower(strings.TrimSpace(contentType))
+		mainType, subType, ok := strings.Cut(contentType, "/")
+		if !ok || mainType == "" || subType == "" || strings.ContainsAny(contentType, " ;") || mainType == "*" || (strings.Contains(subType, "*") && subType != "*") {
+			return policy.MultipartPolicy{}, fmt.Errorf("route %q request_body multipart content type %q is invalid", routeID, contentType)
+		}
+		allowed = append(allowed, contentType)
+	}
+	return policy.MultipartPolicy{
+		Enabled:             true,
+		MaxPartBytes:        multipartCfg.MaxPartBytes,
+		MaxParts:            multipartCfg.MaxParts,
+		AllowedContentTypes: allowed,
+		StripDisallowed:     multipartCfg.StripDisallowed,
+	}, nil
+}
+
+func ssePolicyFromConfig(routeID string, sseCfg config.SSEConfig) (policy.SSEPolicy, error) {
+	if !sseCfg.Enabled {
+		return policy.SSEPolicy{}, nil
+	}
+	if sseCfg.HeartbeatIntervalMS < 0 {
+		return policy.SSEPolicy{}, fmt.Errorf("route %q sse heartbeat_interval_ms must be non-negative", routeID)
+	}
+	return policy.SSEPolicy{
+		Enabled:           true,
+		HeartbeatInterval: durationOrDefault(sseCfg.HeartbeatIntervalMS, defaultSSEHeartbeatInterval),
+	}, nil
+}
+
+func bandwidthPolicyFromConfig(routeID string, bandwidthCfg config.BandwidthConfig) (policy.BandwidthPolicy, error) {
+	if !bandwidthCfg.Enabled {
+		return policy.BandwidthPolicy{}, nil
+	}
+	if bandwidthCfg.RouteBytesPerSec < 0 || bandwidthCfg.RouteBurstBytes < 0 || bandwidthCfg.ClientBytesPerSec < 0 || bandwidthCfg.ClientBurstBytes < 0 || bandwidthCfg.MaxClients < 0 {
+		return policy.BandwidthPolicy{}, fmt.Errorf("route %q bandwidth limits must be non-negative", routeID)
+	}
+	if bandwidthCfg.RouteBytesPerSec == 0 && bandwidthCfg.ClientBytesPerSec == 0 {
+		return policy.BandwidthPolicy{}, fmt.Errorf("route %q bandwidth requires route_bytes_per_sec or client_bytes_per_sec", routeID)
+	}
+	return policy.BandwidthPolicy{
+		Route:   bandwidth.NewBucket(bandwidthCfg.RouteBytesPerSec, bandwidthCfg.RouteBurstBytes),
+		Clients: bandwidth.NewLimiter(bandwidthCfg.ClientBytesPerSec, bandwidthCfg.ClientBurstBytes, bandwidthCfg.MaxClients),
+	}, nil
+}
+
+func debugUpstreamPolicyFromConfig(routeID string, debugCfg config.DebugUpstreamConfig) (policy.DebugUpstreamPolicy, error) {
+	if !debugCfg.Enabled {
+		return policy.DebugUpstreamPolicy{}, nil
+	}
+	header := http.CanonicalHeaderKey(stringOrDefault(strings.TrimSpace(debugCfg.Header), defaultDebugUpstreamHeader))
+	trustedNets := make([]*net.IPNet, 0, len(debugCfg.TrustedCIDRs))
+	for _, cidr := range debugCfg.TrustedCIDRs {
+		_, network, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return policy.DebugUpstreamPolicy{}, fmt.Errorf("route %q debug_upstream trusted_cidrs entry %q is invalid", routeID, cidr)
+		}
+		trustedNets = append(trustedNets, network)
+	}
+	var secret []byte
+	if env := strings.TrimSpace(debugCfg.TokenEnv); env != "" {
+		value := strings.TrimSpace(secrets.LookupEnv(env))
+		if value == "" {
+			return policy.DebugUpstreamPolicy{}, fmt.Errorf("route %q debug_upstream token missing in %s", routeID, env)
+		}
+		secret = []byte(value)
+	}
+	if len(trustedNets) == 0 && len(secret) == 0 {
+		return policy.DebugUpstreamPolicy{}, fmt.Errorf("route %q debug_upstream requires trusted_cidrs or token_env", routeID)
+	}
+	return policy.DebugUpstreamPolicy{
+		Enabled:     true,
+		Header:      header,
+		TrustedNets: trustedNets,
+		TokenSecret: secret,
+	}, nil
+}
+
+func compressionPolicyFromConfig(routeID string, compressionCfg config.CompressionConfig) (policy.CompressionPolicy, error) {
+	if !compressionCfg.Enabled {
+		return policy.CompressionPolicy{}, nil
+	}
+	if compressionCfg.MinSizeBytes < 0 {
+		return policy.CompressionPolicy{}, fmt.Errorf("route %q compression min_size_bytes must be >= 0", routeID)
+	}
+	minSize := int64(compressionCfg.MinSizeBytes)
+	if minSize == 0 {
+		minSize = defaultCompressionMinSize
+	}
+
+	algorithms := make([]string, 0, len(compressionCfg.Algorithms))
+	for _, algorithm := range compressionCfg.Algorithms {
+		normalized := strings.ToLower(strings.TrimSpace(algorithm))
+		if normalized == "" {
+			continue
+		}
+		if !supportedCompression[normalized] {
+			return policy.CompressionPolicy{}, fmt.Errorf("route %q compression algorithm %q unsupported, must be gzip, deflate or br", routeID, algorithm)
+		}
+		algorithms = append(algorithms, normalized)
+	}
+	if len(algorithms) == 0 {
+		algorithms = append(algorithms, defaultCompressionAlgorithms...)
+	}
+
+	contentTypes := make([]string, 0, len(compressionCfg.ContentTypes))
+	for _, contentType := range compressionCfg.ContentTypes {
+		normalized := strings.ToLower(strings.TrimSpace(contentType))
+		if normalized == "" {
+			continue
+		}
+		contentTypes = append(contentTypes, normalized)
+	}
+	if len(contentTypes) == 0 {
+		contentTypes = append(contentTypes, defaultCompressionContentTypes...)
+	}
+
+	return policy.CompressionPolicy{
+		Enabled:      true,
+		Algorithms:   algorithms,
+		MinSize:      minSize,
+		ContentTypes: contentTypes,
+	}, nil
+}
+
+func errorPagesFromConfig(routeID string, pagesCfg []config.ErrorPageConfig) ([]policy.ErrorPage, error) {
+	if len(pagesCfg) == 0 {
+		return nil, nil
+	}
+	pages := make([]policy.ErrorPage, 0, len(pagesCfg))
+	for i, pageCfg := range pagesCfg {
+		format := stringOrDefault(pageCfg.Format, "json")
+		if format != "json" && format != "html" {
+			return nil, fmt.Errorf("route %q error_pages[%d] format must be json or html", routeID, i)
+		}
+		body := pageCfg.Body
+		if pageCfg.File != "" {
+			if body != "" {
+				return nil, fmt.Errorf("route %q error_pages[%d] must set only one of body or file", routeID, i)
+			}
+			data, err := os.ReadFile(pageCfg.File)
+			if err != nil {
+				return nil, fmt.Errorf("route %q error_pages[%d] file: %v", routeID, i, err)
+			}
+			body = string(data)
+		}
+		if body == "" {
+			return nil, fmt.Errorf("route %q error_pages[%d] requires body or file", routeID, i)
+		}
+		if len(body) > maxErrorPageBytes {
+			return nil, fmt.Errorf("route %q error_pages[%d] body must be <= %d bytes", routeID, i, maxErrorPageBytes)
+		}
+		var statuses map[int]bool
+		if len(pageCfg.Status) > 0 {
+			statuses = make(map[int]bool, len(pageCfg.Status))
+			for _, status := range pageCfg.Status {
+				if status < 400 || status > 599 {
+					return nil, fmt.Errorf("route %q error_pages[%d] status %d must be between 400 and 599", routeID, i, status)
+				}
+				statuses[status] = true
+			}
+		}
+		pages = append(pages, policy.ErrorPage{Statuses: statuses, Format: format, Body: body})
+	}
+	return pages, nil
+}
+
+func scriptPolicyFromConfig(routeID string, scriptCfg config.ScriptConfig) (policy.ScriptPolicy, error) {
+	if !scriptCfg.Enabled {
+		return policy.ScriptPolicy{}, nil
+	}
+	source := scriptCfg.Lua
+	name := routeID
+	if scriptCfg.File != "" {
+		if source != "" {
+			return policy.ScriptPolicy{}, fmt.Errorf("route %q script must set only one of lua or file", routeID)
+		}
+		data, err := os.ReadFile(scriptCfg.File)
+		if err != nil {
+			return policy.ScriptPolicy{}, fmt.Errorf("route %q script file: %v", routeID, err)
+		}
+		source = string(data)
+		name = scriptCfg.File
+	}
+	if strings.TrimSpace(source) == "" {
+		return policy.ScriptPolicy{}, fmt.Errorf("route %q script requires lua or file", routeID)
+	}
+	timeout := durationOrDefault(scriptCfg.TimeoutMS, defaultScriptTimeout)
+	if timeout > maxScriptTimeout {
+		return policy.ScriptPolicy{}, fmt.Errorf("route %q script timeout_ms must be <= %d", routeID, maxScriptTimeout.Milliseconds())
+	}
+	if scriptCfg.MaxSteps < 0 {
+		return policy.ScriptPolicy{}, fmt.Errorf("route %q script max_steps must be >= 0", routeID)
+	}
+	failureMode, err := parseFailureMode(stringOrDefault(scriptCfg.FailureMode, string(plugin.FailureModeFailClose)))
+	if err != nil {
+		return policy.ScriptPolicy{}, fmt.Errorf("route %q script %v", routeID, err)
+	}
+	program, err := script.Compile(name, source, timeout, scriptCfg.MaxSteps)
+	if err != nil {
+		return policy.ScriptPolicy{}, fmt.Errorf("route %q script compile: %v", routeID, err)
+	}
+	return policy.ScriptPolicy{
+		Program:  program,
+		FailOpen: failureMode == plugin.FailureModeFailOpen,
+	}, nil
+}
+
+func streamOnlyPools(cfg *config.Config) map[string]bool {
+	streamOnly := make(map[string]bool)
+	for _, stream := range cfg.Streams {
+		streamOnly[stream.Pool] = true
+		for _, sniRoute := range stream.SNIRoutes {
+			streamOnly[sniRoute.Pool] = true
+		}
+	}
+	for _, route := range cfg.Routes {
+		delete(streamOnly, route.Pool)
+		delete(streamOnly, route.Policy.Traffic.StablePool)
+		delete(streamOnly, route.Policy.Traffic.CanaryPool)
+	}
+	return streamOnly
+}
+
+func streamsFromConfig(cfg *config.Config, poolConfigs map[string]PoolConfig, outlierReg *outlier.Registry) (map[string]policy.Stream, error) {
+	streams := make(map[string]policy.Stream, len(cfg.Streams))
+	addrs := make(map[string]string, len(cfg.Streams))
+	for _, streamCfg := range cfg.Streams {
+		if streamCfg.ID == "" {
+			return nil, errors.New("stream id is required")
+		}
+		if _, ok := streams[streamCfg.ID]; ok {
+			return nil, fmt.Errorf("stream id %q is not unique", streamCfg.ID)
+		}
+		if streamCfg.Mode != streamModeTCP {
+			return nil, fmt.Errorf("stream %q mode must be tcp", streamCfg.ID)
+		}
+		if streamCfg.Addr == "" {
+			return nil, fmt.Errorf("stream %q addr is required", streamCfg.ID)
+		}
+		if other, ok := addrs[streamCfg.Addr]; ok {
+			return nil, fmt.Errorf("stream %q addr duplicates stream %q", streamCfg.ID, other)
+		}
+		addrs[streamCfg.Addr] = streamCfg.ID
+		if streamCfg.ConnectTimeoutMS < 0 || streamCfg.IdleTimeoutMS < 0 {
+			return nil, fmt.Errorf("stream %q timeouts must be >= 0", streamCfg.ID)
+		}
+		if streamCfg.Pool == "" && len(streamCfg.SNIRoutes) == 0 {
+			return nil, fmt.Errorf("stream %q requires pool or sni_routes", streamCfg.ID)
+		}
+
+		target := func(poolName string) (policy.StreamTarget, error) {
+			poolCfg, ok := cfg.Pools[poolName]
+			if !ok {
+				return policy.StreamTarget{}, fmt.Errorf("stream %q references missing pool %q", streamCfg.ID, poolName)
+			}
+			outlierKey := fmt.Sprintf("stream:%s::%s", streamCfg.ID, poolName)
+			if outlierReg != nil {
+				outlierReg.Reconcile(outlierKey, poolCfg.Endpoints, poolConfigs[poolName].Outlier)
+			}
+			return policy.StreamTarget{PoolName: poolName, OutlierKey: outlierKey}, nil
+		}
+
+		stream := policy.Stream{
+			ID:             streamCfg.ID,
+			Addr:           streamCfg.Addr,
+			ConnectTimeout: durationOrDefault(streamCfg.ConnectTimeoutMS, defaultStreamConnectTimeout),
+			IdleTimeout:    durationOrDefault(streamCfg.IdleTimeoutMS, defaultStreamIdleTimeout),
+		}
+		if streamCfg.Pool != "" {
+			defaultTarget, err := target(streamCfg.Pool)
+			if err != nil {
+				return nil, err
+			}
+			stream.Default = defaultTarget
+		}
+		seenNames := make(map[string]struct{})
+		for _, sniRoute := range streamCfg.SNIRoutes {
+			if len(sniRoute.ServerNames) == 0 {
+				return nil, fmt.Errorf("stream %q sni route requires server_names", streamCfg.ID)
+			}
+			sniTarget, err := target(sniRoute.Pool)
+			if err != nil {
+				return nil, err
+			}
+			for _, serverName := range sniRoute.ServerNames {
+				serverName = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(serverName), "."))
+				if serverName == "" || strings.Contains(serverName[1:], "*") || (strings.HasPrefix(serverName, "*") && !strings.HasPrefix(serverName, "*.")) {
+					return nil, fmt.Errorf("stream %q sni server name %q is invalid", streamCfg.ID, serverName)
+				}
+				if _, ok := seenNames[serverName]; ok {
+					return nil, fmt.Errorf("stream %q sni server name %q is duplicated", streamCfg.ID, serverName)
+				}
+				seenNames[serverName] = struct{}{}
+				stream.SNIRoutes = append(stream.SNIRoutes, policy.StreamSNIRoute{ServerName: serverName, Target: sniTarget})
+			}
+		}
+		streams[streamCfg.ID] = stream
+	}
+	return streams, nil
+}
+
+func mtlsPolicyFromConfig(routeID string, routeCfg config.RoutePolicy) (policy.MTLSPolicy, error) {
+	mtlsCfg := routeCfg.MTLS
+	restricted := len(mtlsCfg.AllowedSANs) > 0 || len(mtlsCfg.AllowedSPIFFEIDs) > 0
+	revocationSet := mtlsCfg.Revocation != config.RevocationConfig{}
+	if (restricted || revocationSet || mtlsCfg.IdentityHeader != "") && !routeCfg.RequireMTLS {
+		return policy.MTLSPolicy{}, fmt.Errorf("route %q mtls requires require_mtls", routeID)
+	}
+	if !routeCfg.RequireMTLS {
+		return policy.MTLSPolicy{}, nil
+	}
+	sans := make([]string, 0, len(mtlsCfg.AllowedSANs))
+	for _, pattern := range mtlsCfg.AllowedSANs {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			return policy.MTLSPolicy{}, fmt.Errorf("route %q mtls allowed_sans pattern %q is invalid", routeID, pattern)
+		}
+		sans = append(sans, pattern)
+	}
+	spiffeIDs := make([]string, 0, len(mtlsCfg.AllowedSPIFFEIDs))
+	for _, pattern := range mtlsCfg.AllowedSPIFFEIDs {
+		pattern = strings.TrimSpace(pattern)
+		if _, err := path.Match(pattern, ""); err != nil || !strings.HasPrefix(pattern, spiffeScheme) || len(pattern) == len(spiffeScheme) {
+			return policy.MTLSPolicy{}, fmt.Errorf("route %q mtls allowed_spiffe_ids pattern %q is invalid", routeID, pattern)
+		}
+		spiffeIDs = append(spiffeIDs, pattern)
+	}
+	revocation, err := revocationCheckerFromConfig(routeID, mtlsCfg.Revocation)
+	if err != nil {
+		return policy.MTLSPolicy{}, err
+	}
+	return policy.MTLSPolicy{
+		AllowedSANs:      sans,
+		AllowedSPIFFEIDs: spiffeIDs,
+		IdentityHeader:   http.CanonicalHeaderKey(stringOrDefault(mtlsCfg.IdentityHeader, defaultMTLSIdentityHeader)),
+		Revocation:       revocation,
+	}, nil
+}
+
+func revocationCheckerFromConfig(routeID string, revocationCfg config.RevocationConfig) (*tlsstore.RevocationChecker, error) {
+	crlFile := strings.TrimSpace(revocationCfg.CRLFile)
+	if !revocationCfg.OCSP && crlFile == "" {
+		if revocationCfg.SoftFail || revocationCfg.TimeoutMS != 0 || revocationCfg.CacheTTLMS != 0 {
+			return nil, fmt.Errorf("route %q mtls revocation requires ocsp or crl_file", routeID)
+		}
+		return nil, nil
+	}
+	if revocationCfg.TimeoutMS < 0 || revocationCfg.CacheTTLMS < 0 {
+		return nil, fmt.Errorf("route %q mtls revocation timeouts must be >= 0", routeID)
+	}
+	checker, err := tlsstore.NewRevocationChecker(tlsstore.RevocationSpec{
+		OCSP:     revocationCfg.OCSP,
+		CRLFile:  crlFile,
+		SoftFail: revocationCfg.SoftFail,
+		Timeout:  durationOrZero(revocationCfg.TimeoutMS),
+		CacheTTL: durationOrZero(revocationCfg.CacheTTLMS),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("route %q mtls revocation %v", routeID, err)
+	}
+	return checker, nil
+}
+
+func corsPolicyFromConfig(routeID string, corsCfg config.CORSConfig) (policy.CORSPolicy, error) {
+	if !corsCfg.Enabled {
+		return policy.CORSPolicy{}, nil
+	}
+	if len(corsCfg.AllowedOrigins) == 0 {
+		return policy.CORSPolicy{}, fmt.Errorf("route %q cors allowed_origins is required", routeID)
+	}
+	if corsCfg.MaxAgeSeconds < 0 {
+		return policy.CORSPolicy{}, fmt.Errorf("route %q cors max_age_seconds must be >= 0", routeID)
+	}
+	corsPolicy := policy.CORSPolicy{
+		Enabled:          true,
+		AllowedMethods:   make(map[string]bool),
+		AllowedHeaders:   make(map[string]bool),
+		AllowCredentials: corsCfg.AllowCredentials,
+	}
+	for _, origin := range corsCfg.AllowedOrigins {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+		if origin == "*" {
+			corsPolicy.AllowAllOrigins = true
+			continue
+		}
+		if _, err := path.Match(origin, ""); err != nil || !strings.Contains(origin, "://") {
+			return policy.CORSPolicy{}, fmt.Errorf("route %q cors allowed_origins %q is invalid", routeID, origin)
+		}
+		corsPolicy.AllowedOrigins = append(corsPolicy.AllowedOrigins, origin)
+	}
+	if corsPolicy.AllowAllOrigins && corsCfg.AllowCredentials {
+		return policy.CORSPolicy{}, fmt.Errorf("route %q cors allow_credentials cannot be used with allowed_origins *", routeID)
+	}
+
+	methods := corsCfg.AllowedMethods
+	if len(methods) == 0 {
+		methods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+	}
+	methodNames := make([]string, 0, len(methods))
+	for _, method := range methods {
+		method = strings.ToUpper(strings.TrimSpace(method))
+		if !httpguts.ValidHeaderFieldName(method) {
+			return policy.CORSPolicy{}, fmt.Errorf("route %q cors allowed_methods %q is invalid", routeID, method)
+		}
+		if !corsPolicy.AllowedMethods[method] {
+			corsPolicy.AllowedMethods[method] = true
+			methodNames = append(methodNames, method)
+		}
+	}
+	corsPolicy.AllowMethods = strings.Join(methodNames, ", ")
+
+	headerNames := make([]string, 0, len(corsCfg.AllowedHeaders))
+	for _, header := range corsCfg.AllowedHeaders {
+		header = strings.TrimSpace(header)
+		if header == "*" {
+			corsPolicy.AllowAllHeaders = true
+			continue
+		}
+		if !httpguts.ValidHeaderFieldName(header) {
+			return policy.CORSPolicy{}, fmt.Errorf("route %q cors allowed_headers %q is invalid", routeID, header)
+		}
+		corsPolicy.AllowedHeaders[strings.ToLower(header)] = true
+		headerNames = append(headerNames, http.CanonicalHeaderKey(header))
+	}
+	corsPolicy.AllowHeaders = strings.Join(headerNames, ", ")
+
+	exposed := make([]string, 0, len(corsCfg.ExposedHeaders))
+	for _, header := range corsCfg.ExposedHeaders {
+		header = strings.TrimSpace(header)
+		if !httpguts.ValidHeaderFieldName(header) {
+			return policy.CORSPolicy{}, fmt.Errorf("route %q cors exposed_headers %q is invalid", routeID, header)
+		}
+		exposed = append(exposed, http.CanonicalHeaderKey(header))
+	}
+	corsPolicy.ExposeHeaders = strings.Join(exposed, ", ")
+	if corsCfg.MaxAgeSeconds > 0 {
+		corsPolicy.MaxAge = strconv.Itoa(corsCfg.MaxAgeSeconds)
+	}
+	return corsPolicy, nil
+}
+
+func requestIDPolicyFromConfig(requestIDCfg config.RequestIDConfig) (policy.RequestIDPolicy, error) {
+	mode := stringOrDefault(requestIDCfg.Mode, requestIDModeAccept)
+	switch mode {
+	case requestIDModeAccept, requestIDModeRegenerate:
+		if len(requestIDCfg.TrustedCIDRs) > 0 {
+			return policy.RequestIDPolicy{}, fmt.Errorf("request_id trusted_cidrs requires mode trusted")
+		}
+		return policy.RequestIDPolicy{Mode: mode}, nil
+	case requestIDModeTrusted:
+	default:
+		return policy.RequestIDPolicy{}, fmt.Errorf("request_id mode must be accept, regenerate, or trusted")
+	}
+	if len(requestIDCfg.TrustedCIDRs) == 0 {
+		return policy.RequestIDPolicy{}, fmt.Errorf("request_id mode trusted requires trusted_cidrs")
+	}
+	trusted := make([]*net.IPNet, 0, len(requestIDCfg.TrustedCIDRs))
+	for _, entry := range requestIDCfg.TrustedCIDRs {
+		network, err := parseIPNet(entry)
+		if err != nil {
+			return policy.RequestIDPolicy{}, fmt.Errorf("request_id trusted_cidrs entry %q is invalid", entry)
+		}
+		trusted = append(trusted, network)
+	}
+	return policy.RequestIDPolicy{Mode: mode, TrustedCIDRs: trusted}, nil
+}
+
+func slowLogPolicyFromConfig(slowCfg config.SlowLogConfig) (policy.SlowLogPolicy, error) {
+	if !slowCfg.Enabled {
+		return policy.SlowLogPolicy{}, nil
+	}
+	if slowCfg.ThresholdMS < 0 {
+		return policy.SlowLogPolicy{}, fmt.Errorf("slow_log threshold_ms must be >= 0")
+	}
+	sink, err := accessLogSinkFromConfig("slow_log sink", slowCfg.Sink)
+	if err != nil {
+		return policy.SlowLogPolicy{}, err
+	}
+	return policy.SlowLogPolicy{
+		Enabled:   true,
+		Threshold: durationOrDefault(slowCfg.ThresholdMS, defaultSlowLogThreshold),
+		Sink:      sink,
+	}, nil
+}
+
+func debugTracePolicyFromConfig(traceCfg config.DebugTraceConfig) (policy.DebugTracePolicy, error) {
+	if !traceCfg.Enabled {
+		return policy.DebugTracePolicy{}, nil
+	}
+	header := strings.TrimSpace(traceCfg.Header)
+	if header != "" && !httpguts.ValidHeaderFieldName(header) {
+		return policy.DebugTracePolicy{}, fmt.Errorf("debug_trace header %q is invalid", header)
+	}
+	env := strings.TrimSpace(traceCfg.TokenEnv)
+	if env == "" {
+		return policy.DebugTracePolicy{}, fmt.Errorf("debug_trace requires token_env")
+	}
+	secret := strings.TrimSpace(secrets.LookupEnv(env))
+	if secret == "" {
+		return policy.DebugTracePolicy{}, fmt.Errorf("debug_trace token missing in %s", env)
+	}
+	return policy.DebugTracePolicy{
+		Enabled:     true,
+		Header:      http.CanonicalHeaderKey(stringOrDefault(header, defaultDebugTraceHeader)),
+		TokenSecret: []byte(secret),
+	}, nil
+}
+
+func requestRulesFromConfig(scope string, rulesCfg []config.RequestRuleConfig) ([]policy.RequestRule, error) {
+	if len(rulesCfg) == 0 {
+		return nil, nil
+	}
+	rules := make([]policy.RequestRule, 0, len(rulesCfg))
+	names := make(map[string]bool, len(rulesCfg))
+	for _, ruleCfg := range rulesCfg {
+		if ruleCfg.Name == "" {
+			return nil, fmt.Errorf("%s name is required", scope)
+		}
+		if names[ruleCfg.Name] {
+			return nil, fmt.Errorf("%s name %q is not unique", scope, ruleCfg.Name)
+		}
+		names[ruleCfg.Name] = true
+		rule := policy.RequestRule{Name: ruleCfg.Name, Action: ruleCfg.Action}
+		switch ruleCfg.Action {
+		case "block", "allow":
+		case "tarpit":
+			rule.Tarpit = durationOrDefault(ruleCfg.TarpitMS, defaultRequestRuleTarpit)
+			if ruleCfg.TarpitMS < 0 || rule.Tarpit > maxRequestRuleTarpit {
+				return nil, fmt.Errorf("%s %q tarpit_ms must be between 0 and %d", scope, ruleCfg.Name, maxRequestRuleTarpit.Milliseconds())
+			}
+		default:
+			return nil, fmt.Errorf("%s %q action must be block, allow, or tarpit", scope, ruleCfg.Name)
+		}
+		rule.Status = intOrDefault(ruleCfg.Status, http.StatusForbidden)
+		if ruleCfg.Status < 0 || rule.Status < 400 || rule.Status > 599 {
+			return nil, fmt.Errorf("%s %q status must be between 400 and 599", scope, ruleCfg.Name)
+		}
+		if len(ruleCfg.Methods) > 0 {
+			rule.Methods = make(map[string]bool, len(ruleCfg.Methods))
+			for _, method := range ruleCfg.Methods {
+				rule.Methods[strings.ToUpper(method)] = true
+			}
+		}
+		if ruleCfg.PathRegex != "" {
+			pattern, err := regexp.Compile(ruleCfg.PathRegex)
+			if err != nil {
+				return nil, fmt.Errorf("%s %q path_regex: %v", scope, ruleCfg.Name, err)
+			}
+			rule.Path = pattern
+		}
+		headerNames := make([]string, 0, len(ruleCfg.Headers))
+		for name := range ruleCfg.Headers {
+			headerNames = append(headerNames, name)
+		}
+		sort.Strings(headerNames)
+		for _, name := range headerNames {
+			if !httpguts.ValidHeaderFieldName(name) {
+				return nil, fmt.Errorf("%s %q header %q is invalid", scope, ruleCfg.Name, name)
+			}
+			pattern, err := regexp.Compile(ruleCfg.Headers[name])
+			if err != nil {
+				return nil, fmt.Errorf("%s %q header %q: %v", scope, ruleCfg.Name, name, err)
+			}
+			rule.Headers = append(rule.Headers, policy.HeaderMatcher{Name: http.CanonicalHeaderKey(name), Pattern: pattern})
+		}
+		if rule.Methods == nil && rule.Path == nil && len(rule.Headers) == 0 {
+			return nil, fmt.Errorf("%s %q requires methods, path_regex, or headers", scope, ruleCfg.Name)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+var securityHeaderProfiles = map[string]map[string]string{
+	"strict": {
+		"Strict-Transport-Security":  "max-age=63072000; includeSubDomains",
+		"X-Content-Type-Options":     "nosniff",
+		"X-Frame-Options":            "DENY",
+		"Referrer-Policy":            "no-referrer",
+		"Content-Security-Policy":    "default-src 'self'; frame-ancestors 'none'",
+		"Cross-Origin-Opener-Policy": "same-origin",
+		"Permissions-Policy":         "camera=(), microphone=(), geolocation=()",
+	},
+	"relaxed": {
+		"Strict-Transport-Security": "max-age=31536000",
+		"X-Content-Type-Options":    "nosniff",
+		"X-Frame-Options":           "SAMEORIGIN",
+		"Referrer-Policy":           "strict-origin-when-cross-origin",
+	},
+	"off": {},
+}
+
+func securityHeadersPolicyFromConfig(routeID string, headersCfg config.SecurityHeadersConfig) (policy.SecurityHeadersPolicy, error) {
+	profile, ok := securityHeaderProfiles[stringOrDefault(headersCfg.Profile, "off")]
+	if !ok {
+		return policy.SecurityHeadersPolicy{}, fmt.Errorf("route %q security_headers profile must be strict, relaxed, or off", routeID)
+	}
+	values := make(map[string]string, len(profile)+len(headersCfg.Headers))
+	for name, value := range profile {
+		values[name] = value
+	}
+	for name, value := range headersCfg.Headers {
+		if !httpguts.ValidHeaderFieldName(name) {
+			return policy.SecurityHeadersPolicy{}, fmt.Errorf("route %q security_headers header %q is invalid", routeID, name)
+		}
+		if !httpguts.ValidHeaderFieldValue(value) {
+			return policy.SecurityHeadersPolicy{}, fmt.Errorf("route %q security_headers header %q value is invalid", routeID, name)
+		}
+		values[http.CanonicalHeaderKey(name)] = value
+	}
+
+	var securityPolicy policy.SecurityHeadersPolicy
+	for name, value := range values {
+		if value == "" {
+			continue
+		}
+		if name == "Strict-Transport-Security" {
+			securityPolicy.HSTS = value
+			continue
+		}
+		if securityPolicy.Headers == nil {
+			securityPolicy.Headers = make(http.Header)
+		}
+		securityPolicy.Headers.Set(name, value)
+	}
+	return securityPolicy, nil
+}
+
+func idempotencyPolicyFromConfig(routeID string, idempotencyCfg config.IdempotencyConfig) (policy.IdempotencyPolicy, error) {
+	if !idempotencyCfg.Enabled {
+		return policy.IdempotencyPolicy{}, nil
+	}
+	if idempotencyCfg.ReplayTTLMS < 0 || idempotencyCfg.MaxEntries < 0 || idempotencyCfg.MaxBodyBytes < 0 {
+		return policy.IdempotencyPolicy{}, fmt.Errorf("route %q idempotency limits must be >= 0", routeID)
+	}
+	if time.Duration(idempotencyCfg.ReplayTTLMS)*time.Millisecond > maxIdempotencyReplayTTL {
+		return policy.IdempotencyPolicy{}, fmt.Errorf("route %q idempotency replay_ttl_ms must be <= %d", routeID, maxIdempotencyReplayTTL.Milliseconds())
+	}
+	header := strings.TrimSpace(idempotencyCfg.Header)
+	if header == "" {
+		header = defaultIdempotencyHeader
+	}
+	if !httpguts.ValidHeaderFieldName(header) {
+		return policy.IdempotencyPolicy{}, fmt.Errorf("route %q idempotency header %q is invalid", routeID, header)
+	}
+	maxBodyBytes := idempotencyCfg.MaxBodyBytes
+	if maxBodyBytes == 0 {
+		maxBodyBytes = defaultIdempotencyMaxBodyBytes
+	}
+	return policy.IdempotencyPolicy{
+		Enabled:      true,
+		Header:       header,
+		ReplayTTL:    durationOrZero(idempotencyCfg.ReplayTTLMS),
+		MaxEntries:   intOrDefault(idempotencyCfg.MaxEntries, defaultIdempotencyMaxEntries),
+		MaxBodyBytes: maxBodyBytes,
+	}, nil
+}
+
+func mirrorPolicyFromConfig(routeID string, mirrorCfg config.MirrorConfig, pools map[string]pool.PoolKey) (policy.MirrorPolicy, error) {
+	if mirrorCfg.Pool == "" {
+		if mirrorCfg.Percent != 0 {
+			return policy.MirrorPolicy{}, fmt.Errorf("route %q mirror pool is required", routeID)
+		}
+		return policy.MirrorPolicy{}, nil
+	}
+	if _, ok := pools[mirrorCfg.Pool]; !ok {
+		return policy.MirrorPolicy{}, fmt.Errorf("route %q mirror references missing pool %q", routeID, mirrorCfg.Pool)
+	}
+	if mirrorCfg.Percent <= 0 || mirrorCfg.Percent > 100 {
+		return policy.MirrorPolicy{}, fmt.Errorf("route %q mirror percent must be in (0, 100]", routeID)
+	}
+	if mirrorCfg.TimeoutMS < 0 || mirrorCfg.MaxBodyBytes < 0 || mirrorCfg.MaxInflight < 0 {
+		return policy.MirrorPolicy{}, fmt.Errorf("route %q mirror limits must be >= 0", routeID)
+	}
+	maxBodyBytes := mirrorCfg.MaxBodyBytes
+	if maxBodyBytes == 0 {
+		maxBodyBytes = defaultMirrorMaxBodyBytes
+	}
+	return policy.MirrorPolicy{
+		Enabled:        true,
+		Pool:           mirrorCfg.Pool,
+		Percent:        mirrorCfg.Percent,
+		IgnoreResponse: boolOrDefault(mirrorCfg.IgnoreResponse, true),
+		Timeout:        durationOrDefault(mirrorCfg.TimeoutMS, defaultMirrorTimeout),
+		MaxBodyBytes:   maxBodyBytes,
+		MaxInflight:    intOrDefault(mirrorCfg.MaxInflight, defaultMirrorMaxInflight),
+	}, nil
+}
+
+func localityFromPool(poolName string, poolCfg config.Pool) (pool.LocalityConfig, error) {
+	locality := poolCfg.Locality
+	if locality.MinLocalHealthyPercent < 0 || locality.MinLocalHealthyPercent > 100 {
+		return pool.LocalityConfig{}, fmt.Errorf("pool %q locality min_local_healthy_percent must be between 0 and 100", poolName)
+	}
+	endpoints := make(map[string]bool, len(poolCfg.Endpoints))
+	for _, endpoint := range poolCfg.Endpoints {
+		endpoints[endpoint] = true
+	}
+	zones := make(map[string]string, len(locality.EndpointZones))
+	for addr, zone := range locality.EndpointZones {
+		if !endpoints[addr] {
+			return pool.LocalityConfig{}, fmt.Errorf("pool %q locality endpoint_zones references unknown endpoint %q", poolName, addr)
+		}
+		zone = strings.TrimSpace(zone)
+		if zone == "" {
+			return pool.LocalityConfig{}, fmt.Errorf("pool %q locality endpoint_zones %q has an empty zone", poolName, addr)
+		}
+		zones[addr] = zone
+	}
+	localZone := strings.TrimSpace(locality.LocalZone)
+	if localZone != "" && len(zones) == 0 {
+		return pool.LocalityConfig{}, fmt.Errorf("pool %q locality local_zone requires endpoint_zones", poolName)
+	}
+	return pool.LocalityConfig{
+		LocalZone:              localZone,
+		EndpointZones:          zones,
+		MinLocalHealthyPercent: intOrDefault(locality.MinLocalHealthyPercent, defaultMinLocalHealthyPercent),
+	}, nil
+}
+
+func validateHealthPorts(poolName string, poolCfg config.Pool) error {
+	if poolCfg.Health.Port < 0 || poolCfg.Health.Port > 65535 {
+		return fmt.Errorf("pool %q health port %d is invalid", poolName, poolCfg.Health.Port)
+	}
+	endpoints := make(map[string]bool, len(poolCfg.Endpoints))
+	for _, endpoint := range poolCfg.Endpoints {
+		endpoints[endpoint] = true
+	}
+	for addr, port := range poolCfg.Health.EndpointPorts {
+		if !endpoints[addr] {
+			return fmt.Errorf("pool %q health endpoint_ports references unknown endpoint %q", poolName, addr)
+		}
+		if port <= 0 || port > 65535 {
+			return fmt.Errorf("pool %q health endpoint_ports %q port %d is invalid", poolName, addr, port)
+		}
+	}
+	return nil
+}
+
+func dnsOptionsFromPool(poolName string, cfg config.DNSConfig) (transport.DNSOptions, error) {
+	if !cfg.Enabled {
+		return transport.DNSOptions{}, nil
+	}
+	if cfg.MinTTLMS < 0 || cfg.MaxTTLMS < 0 || cfg.NegativeTTLMS < 0 || cfg.TimeoutMS < 0 {
+		return transport.DNSOptions{}, fmt.Errorf("pool %q transport dns ttl and timeout values must be >= 0", poolName)
+	}
+	resolver := strings.TrimSpace(cfg.Resolver)
+	if resolver != "" {
+		if _, _, err := net.SplitHostPort(resolver); err != nil {
+			resolver = net.JoinHostPort(resolver, "53")
+		}
+		host, port, err := net.SplitHostPort(resolver)
+		if err != nil || net.ParseIP(host) == nil || port == "" {
+			return transport.DNSOptions{}, fmt.Errorf("pool %q transport dns resolver %q must be an ip or ip:port", poolName, cfg.Resolver)
+		}
+	}
+	result := transport.DNSOptions{
+		Enabled:     true,
+		Resolver:    resolver,
+		MinTTL:      durationOrDefault(cfg.MinTTLMS, defaultDNSMinTTL),
+		MaxTTL:      durationOrDefault(cfg.MaxTTLMS, defaultDNSMaxTTL),
+		NegativeTTL: durationOrDefault(cfg.NegativeTTLMS, defaultDNSNegativeTTL),
+		Timeout:     durationOrDefault(cfg.TimeoutMS, defaultDNSTimeout),
+	}
+	if result.MinTTL > result.MaxTTL {
+		return transport.DNSOptions{}, fmt.Errorf("pool %q transport dns min_ttl_ms must be <= max_ttl_ms", poolName)
+	}
+	return result, nil
+}
+
+func upstreamTLSFromPool(poolName string, cfg config.UpstreamTLSConfig) (transport.TLSOptions, error) {
+	if !cfg.Enabled {
+		if cfg.ServerName != "" || len(cfg.ALPN) > 0 || cfg.CAFile != "" || cfg.InsecureSkipVerify {
+			return transport.TLSOptions{}, fmt.Errorf("pool %q transport tls settings require enabled", poolName)
+		}
+		return transport.TLSOptions{}, nil
+	}
+	serverName := strings.TrimSpace(cfg.ServerName)
+	if strings.ContainsAny(serverName, " :/") {
+		return transport.TLSOptions{}, fmt.Errorf("pool %q transport tls server_name %q must be a host name", poolName, cfg.ServerName)
+	}
+	seen := make(map[string]bool, len(cfg.ALPN))
+	for _, proto := range cfg.ALPN {
+		if proto == "" || strings.ContainsAny(proto, ", ") || seen[proto] {
+			return transport.TLSOptions{}, fmt.Errorf("pool %q transport tls alpn %q must be a unique, non-empty protocol id", poolName, proto)
+		}
+		seen[proto] = true
+	}
+	if cfg.InsecureSkipVerify && cfg.CAFile != "" {
+		return transport.TLSOptions{}, fmt.Errorf("pool %q transport tls ca_file cannot be combined with insecure_skip_verify", poolName)
+	}
+	result := transport.TLSOptions{
+		Enabled:            true,
+		ServerName:         serverName,
+		ALPN:               strings.Join(cfg.ALPN, ","),
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+	if cfg.CAFile != "" {
+		data, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return transport.TLSOptions{}, fmt.Errorf("pool %q transport tls ca_file: %w", poolName, err)
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM(data) {
+			return transport.TLSOptions{}, fmt.Errorf("pool %q transport tls ca_file %q contains no certificates", poolName, cfg.CAFile)
+		}
+		result.CAPEM = string(data)
+	}
+	return result, nil
+}
+
+func outboundProxyFromPool(poolName string, cfg config.OutboundProxyConfig) (transport.ProxyOptions, error) {
+	rawURL := strings.TrimSpace(cfg.URL)
+	if rawURL == "" {
+		if cfg.UsernameEnv != "" || cfg.PasswordEnv != "" || len(cfg.NoProxy) > 0 {
+			return transport.ProxyOptions{}, fmt.Errorf("pool %q transport proxy settings require url", poolName)
+		}
+		return transport.ProxyOptions{}, nil
+	}
+	proxyURL, err := url.Parse(rawURL)
+	if err != nil || proxyURL.Hostname() == "" {
+		return transport.ProxyOptions{}, fmt.Errorf("pool %q transport proxy url %q is invalid", poolName, cfg.URL)
+	}
+	defaultPort := ""
+	switch proxyURL.Scheme {
+	case "http":
+		defaultPort = "80"
+	case "https":
+		defaultPort = "443"
+	case "socks5", "socks5h":
+		defaultPort = "1080"
+	default:
+		return transport.ProxyOptions{}, fmt.Errorf("pool %q transport proxy url scheme must be http, https, socks5 or socks5h", poolName)
+	}
+	if proxyURL.User != nil {
+		return transport.ProxyOptions{}, fmt.Errorf("pool %q transport proxy credentials must use username_env and password_env", poolName)
+	}
+	if (proxyURL.Path != "" && proxyURL.Path != "/") || proxyURL.RawQuery != "" {
+		return transport.ProxyOptions{}, fmt.Errorf("pool %q transport proxy url must not have a path or query", poolName)
+	}
+	if proxyURL.Port() == "" {
+		proxyURL.Host = net.JoinHostPort(proxyURL.Hostname(), defaultPort)
+	}
+	result := transport.ProxyOptions{URL: proxyURL.Scheme + "://" + proxyURL.Host}
+	if env := strings.TrimSpace(cfg.UsernameEnv); env != "" {
+		result.Username = secrets.LookupEnv(env)
+		if result.Username == "" {
+			return transport.ProxyOptions{}, fmt.Errorf("pool %q transport proxy username missing in %s", poolName, env)
+		}
+	}
+	if env := strings.TrimSpace(cfg.PasswordEnv); env != "" {
+		if result.Username == "" {
+			return transport.ProxyOptions{}, fmt.Errorf("pool %q transport proxy password_env requires username_env", poolName)
+		}
+		result.Password = secrets.LookupEnv(env)
+		if result.Password == "" {
+			return transport.ProxyOptions{}, fmt.Errorf("pool %q transport proxy password missing in %s", poolName, env)
+		}
+	}
+	noProxy := make([]string, 0, len(cfg.NoProxy))
+	for _, entry := range cfg.NoProxy {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" || strings.Contains(entry, ",") {
+			return transport.ProxyOptions{}, fmt.Errorf("pool %q transport proxy no_proxy entry %q is invalid", poolName, entry)
+		}
+		if strings.Contains(entry, "/") {
+			if _, _, err := net.ParseCIDR(entry); err != nil {
+				return transport.ProxyOptions{}, fmt.Errorf("pool %q transport proxy no_proxy entry %q is not a valid CIDR", poolName, entry)
+			}
+		}
+		noProxy = append(noProxy, entry)
+	}
+	result.NoProxy = strings.Join(noProxy, ",")
+	return result, nil
+}
+
+func concurrencyConfigFromPool(poolName string, cfg config.AdaptiveConcurrencyConfig) (concurrency.Config, error) {
+	if !cfg.Enabled {
+		return concurrency.Config{}, nil
+	}
+	algorithm := stringOrDefault(strings.ToLower(strings.TrimSpace(cfg.Algorithm)), concurrency.AlgorithmGradient)
+	if algorithm != concurrency.AlgorithmGradient && algorithm != concurrency.AlgorithmAIMD {
+		return concurrency.Config{}, fmt.Errorf("pool %q adaptive_concurrency algorithm %q is invalid", poolName, cfg.Algorithm)
+	}
+	if cfg.InitialLimit < 0 || cfg.MinLimit < 0 || cfg.MaxLimit < 0 || cfg.WindowMS < 0 || cfg.LatencyThresholdMS < 0 {
+		return concurrency.Config{}, fmt.Errorf("pool %q adaptive_concurrency limits must be >= 0", poolName)
+	}
+	if cfg.Tolerance < 0 || cfg.Smoothing < 0 || cfg.Smoothing > 1 || cfg.BackoffRatio < 0 || cfg.BackoffRatio >= 1 {
+		return concurrency.Config{}, fmt.Errorf("pool %q adaptive_concurrency tolerance, smoothing or backoff_ratio out of range", poolName)
+	}
+	result := concurrency.Config{
+		Enabled:          true,
+		Algorithm:        algorithm,
+		InitialLimit:     intOrDefault(cfg.InitialLimit, defaultConcurrencyInitialLimit),
+		MinLimit:         intOrDefault(cfg.MinLimit, defaultConcurrencyMinLimit),
+		MaxLimit:         intOrDefault(cfg.MaxLimit, defaultConcurrencyMaxLimit),
+		Window:           durationOrDefault(cfg.WindowMS, defaultConcurrencyWindow),
+		Tolerance:        floatOrDefault(cfg.Tolerance, defaultConcurrencyTolerance),
+		Smoothing:        floatOrDefault(cfg.Smoothing, defaultConcurrencySmoothing),
+		BackoffRatio:     floatOrDefault(cfg.BackoffRatio, defaultConcurrencyBackoffRatio),
+		LatencyThreshold: durationOrZero(cfg.LatencyThresholdMS),
+	}
+	if result.MinLimit > result.MaxLimit || result.InitialLimit < result.MinLimit || result.InitialLimit > result.MaxLimit {
+		return concurrency.Config{}, fmt.Errorf("pool %q adaptive_concurrency requires min_limit <= initial_limit <= max_limit", poolName)
+	}
+	return result, nil
+}
+
+func hedgePolicyFromConfig(routeID string, hedgeCfg config.HedgeConfig) (policy.HedgePolicy, error) {
+	if hedgeCfg.DelayMS == 0 && hedgeCfg.MaxHedges == 0 {
+		return policy.HedgePolicy{}, nil
+	}
+	if hedgeCfg.DelayMS <= 0 {
+		return policy.HedgePolicy{}, fmt.Errorf("route %q hedge delay_ms must be > 0", routeID)
+	}
+	if hedgeCfg.MaxHedges < 0 {
+		return policy.HedgePolicy{}, fmt.Errorf("route %q hedge max_hedges must be >= 0", routeID)
+	}
+	return policy.HedgePolicy{
+		Enabled:   true,
+		Delay:     durationOrZero(hedgeCfg.DelayMS),
+		MaxHedges: intOrDefault(hedgeCfg.MaxHedges, defaultHedgeMaxHedges),
+	}, nil
+}
+
+func accessLogPolicyFromConfig(routeID string, logCfg config.AccessLogConfig) (policy.AccessLogPolicy, error) {
+	sampleRate := 1.0
+	if logCfg.SampleRate != nil {
+		sampleRate = *logCfg.SampleRate
+	}
+	if sampleRate < 0 || sampleRate > 1 {
+		return policy.AccessLogPolicy{}, fmt.Errorf("route %q access_log sample_rate must be between 0 and 1", routeID)
+	}
+	if logCfg.SlowMS < 0 {
+		return policy.AccessLogPolicy{}, fmt.Errorf("route %q access_log slow_ms must be >= 0", routeID)
+	}
+	known := make(map[string]struct{})
+	for _, name := range obs.AccessLogFieldNames() {
+		known[name] = struct{}{}
+	}
+	fields := make([]string, 0, len(logCfg.Fields))
+	for _, field := range logCfg.Fields {
+		field = strings.TrimSpace(field)
+		if _, ok := known[field]; !ok {
+			return policy.AccessLogPolicy{}, fmt.Errorf("route %q access_log field %q is unknown", routeID, field)
+		}
+		fields = append(fields, field)
+	}
+	var rename map[string]string
+	if len(logCfg.Rename) > 0 {
+		rename = make(map[string]string, len(logCfg.Rename))
+		targets := make(map[string]string, len(logCfg.Rename))
+		for from, to := range logCfg.Rename {
+			if _, ok := known[from]; !ok {
+				return policy.AccessLogPolicy{}, fmt.Errorf("route %q access_log rename field %q is unknown", routeID, from)
+			}
+			to = strings.TrimSpace(to)
+			if to == "" {
+				return policy.AccessLogPolicy{}, fmt.Errorf("route %q access_log rename of %q must not be empty", routeID, from)
+			}
+			if previous, ok := targets[to]; ok {
+				return policy.AccessLogPolicy{}, fmt.Errorf("route %q access_log rename maps %q and %q to %q", routeID, previous, from, to)
+			}
+			targets[to] = from
+			rename[from] = to
+		}
+		for to, from := range targets {
+			if _, ok := known[to]; ok {
+				if _, renamed := rename[to]; !renamed {
+					return policy.AccessLogPolicy{}, fmt.Errorf("route %q access_log rename of %q collides with field %q", routeID, from, to)
+				}
+			}
+		}
+	}
+	if len(fields) == 0 {
+		fields = nil
+	}
+	sink, err := accessLogSinkFromConfig(fmt.Sprintf("route %q access_log sink", routeID), logCfg.Sink)
+	if err != nil {
+		return policy.AccessLogPolicy{}, err
+	}
+	return policy.AccessLogPolicy{
+		Disabled:      logCfg.Disabled,
+		SampleRate:    sampleRate,
+		ErrorsOnly:    logCfg.ErrorsOnly,
+		SlowThreshold: durationOrZero(logCfg.SlowMS),
+		Fields:        fields,
+		Rename:        rename,
+		Sink:          sink,
+	}, nil
+}
+
+func accessLogSinkFromConfig(scope string, sinkCfg *config.AccessLogSinkConfig) (io.Writer, error) {
+	if sinkCfg == nil {
+		return nil, nil
+	}
+	sinkType := strings.ToLower(strings.TrimSpace(sinkCfg.Type))
+	if sinkType == "" {
+		return nil, fmt.Errorf("%s type is required", scope)
+	}
+	if sinkCfg.MaxSizeMB < 0 || sinkCfg.MaxBackups < 0 || sinkCfg.BufferSize < 0 || sinkCfg.BatchSize < 0 || sinkCfg.FlushMS < 0 || sinkCfg.TimeoutMS < 0 {
+		return nil, fmt.Errorf("%s sizes and durations must be >= 0", scope)
+	}
+	if sinkType == obs.SinkHTTP || sinkType == obs.SinkKafka {
+		parsed, err := url.Parse(sinkCfg.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, fmt.Errorf("%s url must be an absolute http(s) url", scope)
+		}
+	}
+	sink, err := obs.AccessLogSinkFor(obs.AccessLogSinkSpec{
+		Type:          sinkType,
+		Path:          sinkCfg.Path,
+		MaxSizeBytes:  int64(sinkCfg.MaxSizeMB) << 20,
+		MaxBackups:    sinkCfg.MaxBackups,
+		Network:       sinkCfg.Network,
+		Address:       sinkCfg.Address,
+		Tag:           sinkCfg.Tag,
+		URL:           sinkCfg.URL,
+		Topic:         sinkCfg.Topic,
+		Headers:       sinkCfg.Headers,
+		BufferSize:    sinkCfg.BufferSize,
+		BatchSize:     sinkCfg.BatchSize,
+		FlushInterval: durationOrZero(sinkCfg.FlushMS),
+		Timeout:       durationOrZero(sinkCfg.TimeoutMS),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", scope, err)
+	}
+	return sink, nil
+}
+
+func accessPolicyFromConfig(routeID string, accessCfg config.AccessConfig) (policy.AccessPolicy, error) {
+	allow, err := parseAccessNets(routeID, "allow_cidrs", accessCfg.AllowCIDRs)
+	if err != nil {
+		return policy.AccessPolicy{}, err
+	}
+	deny, err := parseAccessNets(routeID, "deny_cidrs", accessCfg.DenyCIDRs)
+	if err != nil {
+		return policy.AccessPolicy{}, err
+	}
+	trusted, err := parseAccessNets(routeID, "trusted_proxies", accessCfg.TrustedProxies)
+	if err != nil {
+		return policy.AccessPolicy{}, err
+	}
+	return policy.AccessPolicy{
+		Enabled:        len(allow) > 0 || len(deny) > 0,
+		Allow:          allow,
+		Deny:           deny,
+		TrustedProxies: trusted,
+	}, nil
+}
+
+func parseAccessNets(routeID string, field string, entries []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		network, err := parseIPNet(entry)
+		if err != nil {
+			return nil, fmt.Errorf("route %q access %s entry %q is invalid", routeID, field, strings.TrimSpace(entry))
+		}
+		nets = append(nets, network)
+	}
+	return nets, nil
+}
+
+func parseIPNet(entry string) (*net.IPNet, error) {
+	entry = strings.TrimSpace(entry)
+	if ip := net.ParseIP(entry); ip != nil {
+		bits := 128
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 32
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, network, err := net.ParseCIDR(entry)
+	if err != nil {
+		return nil, err
+	}
+	return network, nil
+}
+
+func authPolicyFromConfig(routeID string, routePolicy config.RoutePolicy) (policy.AuthPolicy, error) {
+	switch strings.TrimSpace(routePolicy.Auth) {
+	case "":
+		return policy.AuthPolicy{}, nil
+	case authModeOIDC:
+	case authModeAPIKey:
+		return apiKeyAuthPolicyFromConfig(routeID, routePolicy.APIKey)
+	case authModeHMAC:
+		return hmacAuthPolicyFromConfig(routeID, routePolicy.HMAC)
+	default:
+		return policy.AuthPolicy{}, fmt.Errorf("route %q auth %q is not supported", routeID, routePolicy.Auth)
+	}
+	oidcCfg := routePolicy.OIDC
+	if oidcCfg.SessionTTLMS < 0 {
+		return policy.AuthPolicy{}, fmt.Errorf("route %q oidc session_ttl_ms must be >= 0", routeID)
+	}
+	var clientSecret string
+	if env := strings.TrimSpace(oidcCfg.ClientSecretEnv); env != "" {
+		clientSecret = strings.TrimSpace(secrets.LookupEnv(env))
+		if clientSecret == "" {
+			return policy.AuthPolicy{}, fmt.Errorf("route %q oidc client secret missing in %s", routeID, env)
+		}
+	}
+	env := strings.TrimSpace(oidcCfg.CookieSecretEnv)
+	if env == "" {
+		return policy.AuthPolicy{}, fmt.Errorf("route %q oidc requires cookie_secret_env", routeID)
+	}
+	cookieSecret := strings.TrimSpace(secrets.LookupEnv(env))
+	if cookieSecret == "" {
+		return policy.AuthPolicy{}, fmt.Errorf("route %q oidc cookie secret missing in %s", routeID, env)
+	}
+	relyingParty, err := oidc.NewRelyingParty(oidc.Config{
+		Issuer:       strings.TrimSpace(oidcCfg.Issuer),
+		ClientID:     strings.TrimSpace(oidcCfg.ClientID),
+		ClientSecret: clientSecret,
+		RedirectURL:  strings.TrimSpace(oidcCfg.RedirectURL),
+		CallbackPath: strings.TrimSpace(oidcCfg.CallbackPath),
+		LogoutPath:   strings.TrimSpace(oidcCfg.LogoutPath),
+		Scopes:       oidcCfg.Scopes,
+		CookieName:   strings.TrimSpace(oidcCfg.CookieName),
+		CookieSecret: []byte(cookieSecret),
+		CookieSecure: oidcCfg.CookieSecure,
+		SessionTTL:   durationOrDefault(oidcCfg.SessionTTLMS, oidc.DefaultSessionTTL),
+	})
+	if err != nil {
+		return policy.AuthPolicy{}, fmt.Errorf("route %q oidc %v", routeID, err)
+	}
+	return policy.AuthPolicy{Mode: authModeOIDC, OIDC: relyingParty}, nil
+}
+
+func apiKeyAuthPolicyFromConfig(routeID string, apiKeyCfg config.APIKeyConfig) (policy.AuthPolicy, error) {
+	keys, err := keyauth.NewKeySet(authKeySpecs(apiKeyCfg.Keys), strings.TrimSpace(apiKeyCfg.KeysFile))
+	if err != nil {
+		return policy.AuthPolicy{}, fmt.Errorf("route %q api_key %v", routeID, err)
+	}
+	return policy.AuthPolicy{
+		Mode: authModeAPIKey,
+		APIKey: &keyauth.APIKeyVerifier{
+			Header: http.CanonicalHeaderKey(stringOrDefault(strings.TrimSpace(apiKeyCfg.Header), keyauth.DefaultAPIKeyHeader)),
+			Keys:   keys,
+		},
+	}, nil
+}
+
+func hmacAuthPolicyFromConfig(routeID string, hmacCfg config.HMACConfig) (policy.AuthPolicy, error) {
+	if hmacCfg.MaxSkewMS < 0 {
+		return policy.AuthPolicy{}, fmt.Errorf("route %q hmac max_skew_ms must be >= 0", routeID)
+	}
+	if hmacCfg.MaxBodyBytes < 0 || hmacCfg.MaxBodyBytes > maxHMACBodyBytes {
+		return policy.AuthPolicy{}, fmt.Errorf("route %q hmac max_body_bytes must be between 0 and %d", routeID, maxHMACBodyBytes)
+	}
+	keys, err := keyauth.NewKeySet(authKeySpecs(hmacCfg.Keys), strings.TrimSpace(hmacCfg.KeysFile))
+	if err != nil {
+		return policy.AuthPolicy{}, fmt.Errorf("route %q hmac %v", routeID, err)
+	}
+	maxBodyBytes := hmacCfg.MaxBodyBytes
+	if maxBodyBytes == 0 {
+		maxBodyBytes = keyauth.DefaultHMACMaxBodySize
+	}
+	return policy.AuthPolicy{
+		Mode: authModeHMAC,
+		HMAC: &keyauth.HMACVerifier{
+			Header:       http.CanonicalHeaderKey(stringOrDefault(strings.TrimSpace(hmacCfg.Header), keyauth.DefaultHMACHeader)),
+			MaxSkew:      durationOrDefault(hmacCfg.MaxSkewMS, keyauth.DefaultHMACMaxSkew),
+			MaxBodyBytes: maxBodyBytes,
+			Keys:         keys,
+		},
+	}, nil
+}
+
+func authKeySpecs(keys []config.AuthKeyConfig) []keyauth.KeySpec {
+	specs := make([]keyauth.KeySpec, 0, len(keys))
+	for _, key := range keys {
+		specs = append(specs, keyauth.KeySpec{
+			ID:             key.ID,
+			Key:            key.Key,
+			KeyEnv:         key.KeyEnv,
+			RateLimitRPS:   key.RateLimitRPS,
+			RateLimitBurst: key.RateLimitBurst,
+		})
+	}
+	return specs
+}
+
+func pluginPolicyFromConfig(routeID string, pluginCfg config.PluginConfig, filterNames map[string]struct{}) (plugin.Policy, error) {
+	filters := make([]plugin.Filter, 0, len(pluginCfg.Filters))
+	if pluginCfg.Enabled && len(pluginCfg.Filters) == 0 {
 		return plugin.Policy{}, fmt.Errorf("route %q plugins enabled but no filters", routeID)
 	}
 
@@ -479,12 +2458,33 @@ func pluginPolicyFromConfig(routeID string, pluginCfg config.PluginConfig, filte
 		if name == "" {
 			return plugin.Policy{}, fmt.Errorf("route %q plugin filter name required", routeID)
 		}
+		filterType := stringOrDefault(strings.TrimSpace(filter.Type), plugin.FilterTypeGRPC)
 		addr := strings.TrimSpace(filter.Addr)
-		if addr == "" {
-			return plugin.Policy{}, fmt.Errorf("route %q plugin filter %q addr required", routeID, name)
-		}
-		if _, _, err := net.SplitHostPort(addr); err != nil {
-			return plugin.Policy{}, fmt.Errorf("route %q plugin filter %q addr must be host:port", routeID, name)
+		var wasmModule *plugin.WASMModule
+		switch filterType {
+		case plugin.FilterTypeGRPC:
+			if addr == "" {
+				return plugin.Policy{}, fmt.Errorf("route %q plugin filter %q addr required", routeID, name)
+			}
+			if _, _, err := net.SplitHostPort(addr); err != nil {
+				return plugin.Policy{}, fmt.Errorf("route %q plugin filter %q addr must be host:port", routeID, name)
+			}
+		case plugin.FilterTypeWASM:
+			modulePath := strings.TrimSpace(filter.Module)
+			if modulePath == "" {
+				return plugin.Policy{}, fmt.Errorf("route %q plugin filter %q module required", routeID, name)
+			}
+			if filter.InspectBody {
+				return plugin.Policy{}, fmt.Errorf("route %q plugin filter %q inspect_body is not supported for wasm filters", routeID, name)
+			}
+			module, err := plugin.LoadWASMModule(modulePath, filter.MemoryPages)
+			if err != nil {
+				return plugin.Policy{}, fmt.Errorf("route %q plugin filter %q module: %v", routeID, name, err)
+			}
+			wasmModule = module
+			addr = "wasm:" + module.Hash
+		default:
+			return plugin.Policy{}, fmt.Errorf("route %q plugin filter %q type must be grpc or wasm", routeID, name)
 		}
 		if filterNames != nil {
 			filterNames[name] = struct{}{}
@@ -507,6 +2507,18 @@ func pluginPolicyFromConfig(routeID string, pluginCfg config.PluginConfig, filte
 			return plugin.Policy{}, fmt.Errorf("route %q plugin filter %q %v", routeID, name, err)
 		}
 
+		maxBodyBytes := filter.MaxBodyBytes
+		if maxBodyBytes == 0 {
+			maxBodyBytes = defaultPluginMaxBodyBytes
+		}
+		if maxBodyBytes < 0 || maxBodyBytes > maxPluginMaxBodyBytes {
+			return plugin.Policy{}, fmt.Errorf("route %q plugin filter %q max_body_bytes must be between 1 and %d", routeID, name, maxPluginMaxBodyBytes)
+		}
+		bodyTimeout := durationOrDefault(filter.BodyTimeoutMS, defaultPluginBodyTimeout)
+		if bodyTimeout <= 0 {
+			return plugin.Policy{}, fmt.Errorf("route %q plugin filter %q body_timeout_ms must be > 0", routeID, name)
+		}
+
 		breakerEnabled := boolOrDefault(filter.Breaker.Enabled, true)
 		breaker := plugin.BreakerConfig{
 			Enabled:             breakerEnabled,
@@ -517,11 +2529,16 @@ func pluginPolicyFromConfig(routeID string, pluginCfg config.PluginConfig, filte
 
 		filters = append(filters, plugin.Filter{
 			Name:            name,
+			Type:            filterType,
 			Addr:            addr,
+			WASM:            wasmModule,
 			RequestTimeout:  requestTimeout,
 			ResponseTimeout: responseTimeout,
 			FailureMode:     failureMode,
 			Breaker:         breaker,
+			InspectBody:     filter.InspectBody,
+			MaxBodyBytes:    maxBodyBytes,
+			BodyTimeout:     bodyTimeout,
 		})
 	}
 
@@ -569,9 +2586,28 @@ func trafficConfigFromRoute(routeID string, cfg config.TrafficConfig) (traffic.C
 	if stableWeight == 0 && canaryWeight == 0 {
 		return traffic.Config{}, "", "", fmt.Errorf("route %q traffic weights cannot both be zero", routeID)
 	}
+	ramp, err := rampConfigFromTraffic(routeID, cfg, canaryPool)
+	if err != nil {
+		return traffic.Config{}, "", "", err
+	}
 	if cfg.Cohort.Enabled && strings.TrimSpace(cfg.Cohort.Key) == "" {
 		return traffic.Config{}, "", "", fmt.Errorf("route %q traffic cohort key missing", routeID)
 	}
+	force := traffic.ForceConfig{
+		Header: strings.TrimSpace(cfg.Force.Header),
+		Cookie: strings.TrimSpace(cfg.Force.Cookie),
+	}
+	if force.Cookie != "" {
+		env := strings.TrimSpace(cfg.Force.CookieSecretEnv)
+		if env == "" {
+			return traffic.Config{}, "", "", fmt.Errorf("route %q traffic force cookie requires cookie_secret_env", routeID)
+		}
+		secret := strings.TrimSpace(secrets.LookupEnv(env))
+		if secret == "" {
+			return traffic.Config{}, "", "", fmt.Errorf("route %q traffic force cookie secret missing in %s", routeID, env)
+		}
+		force.CookieSecret = []byte(secret)
+	}
 	if cfg.Overload.Enabled {
 		if cfg.Overload.MaxInflight <= 0 {
 			return traffic.Config{}, "", "", fmt.Errorf("route %q traffic overload max_inflight must be > 0", routeID)
@@ -597,6 +2633,22 @@ func trafficConfigFromRoute(routeID string, cfg config.TrafficConfig) (traffic.C
 			return traffic.Config{}, "", "", fmt.Errorf("route %q traffic autodrain cooloff_ms must be > 0", routeID)
 		}
 	}
+	drainMode := stringOrDefault(strings.ToLower(strings.TrimSpace(cfg.AutoDrain.Mode)), traffic.DrainModeImmediate)
+	switch drainMode {
+	case traffic.DrainModeImmediate:
+	case traffic.DrainModeGraceful:
+		if !cfg.Cohort.Enabled {
+			return traffic.Config{}, "", "", fmt.Errorf("route %q traffic autodrain graceful mode requires cohort", routeID)
+		}
+		if cfg.AutoDrain.GracePeriodMS <= 0 {
+			return traffic.Config{}, "", "", fmt.Errorf("route %q traffic autodrain grace_period_ms must be > 0", routeID)
+		}
+		if cfg.AutoDrain.MaxCohorts < 0 {
+			return traffic.Config{}, "", "", fmt.Errorf("route %q traffic autodrain max_cohorts must be >= 0", routeID)
+		}
+	default:
+		return traffic.Config{}, "", "", fmt.Errorf("route %q traffic autodrain mode must be immediate or graceful", routeID)
+	}
 
 	return traffic.Config{
 		Enabled:      cfg.Enabled,
@@ -606,6 +2658,7 @@ func trafficConfigFromRoute(routeID string, cfg config.TrafficConfig) (traffic.C
 			Enabled: cfg.Cohort.Enabled,
 			Key:     strings.TrimSpace(cfg.Cohort.Key),
 		},
+		Force: force,
 		Overload: traffic.OverloadConfig{
 			Enabled:      cfg.Overload.Enabled,
 			MaxInflight:  cfg.Overload.MaxInflight,
@@ -618,10 +2671,52 @@ func trafficConfigFromRoute(routeID string, cfg config.TrafficConfig) (traffic.C
 			MinRequests:         cfg.AutoDrain.MinRequests,
 			ErrorRateMultiplier: cfg.AutoDrain.ErrorRateMultiplier,
 			Cooloff:             durationOrZero(cfg.AutoDrain.CooloffMS),
+			Mode:                drainMode,
+			GracePeriod:         durationOrZero(cfg.AutoDrain.GracePeriodMS),
+			MaxCohorts:          intOrDefault(cfg.AutoDrain.MaxCohorts, defaultDrainMaxCohorts),
 		},
+		Ramp: ramp,
 	}, stablePool, canaryPool, nil
 }
 
+func rampConfigFromTraffic(routeID string, cfg config.TrafficConfig, canaryPool string) (traffic.RampConfig, error) {
+	rampCfg := cfg.Ramp
+	if !rampCfg.Enabled {
+		return traffic.RampConfig{}, nil
+	}
+	if canaryPool == "" {
+		return traffic.RampConfig{}, fmt.Errorf("route %q traffic ramp requires canary_pool", routeID)
+	}
+	steps := rampCfg.Steps
+	if len(steps) == 0 {
+		steps = defaultRampSteps
+	}
+	for i, step := range steps {
+		if step < 0 || step > 100 || (i > 0 && step <= steps[i-1]) {
+			return traffic.RampConfig{}, fmt.Errorf("route %q traffic ramp steps must increase within 0-100", routeID)
+		}
+	}
+	if rampCfg.StepIntervalMS <= 0 {
+		return traffic.RampConfig{}, fmt.Errorf("route %q traffic ramp step_interval_ms must be > 0", routeID)
+	}
+	if rampCfg.MinRequests < 0 || rampCfg.MaxErrorRate < 0 || rampCfg.MaxErrorRate > 1 || rampCfg.MaxLatencyMS < 0 {
+		return traffic.RampConfig{}, fmt.Errorf("route %q traffic ramp thresholds are invalid", routeID)
+	}
+	onBreach := stringOrDefault(strings.ToLower(strings.TrimSpace(rampCfg.OnBreach)), traffic.RampBreachPause)
+	if onBreach != traffic.RampBreachPause && onBreach != traffic.RampBreachRevert {
+		return traffic.RampConfig{}, fmt.Errorf("route %q traffic ramp on_breach must be pause or revert", routeID)
+	}
+	return traffic.RampConfig{
+		Enabled:      true,
+		Steps:        append([]int(nil), steps...),
+		StepInterval: durationOrZero(rampCfg.StepIntervalMS),
+		MinRequests:  rampCfg.MinRequests,
+		MaxErrorRate: rampCfg.MaxErrorRate,
+		MaxLatency:   durationOrZero(rampCfg.MaxLatencyMS),
+		OnBreach:     onBreach,
+	}, nil
+}
+
 func stringOrDefault(value string, fallback string) string {
 	if value == "" {
 		return fallback
@@ -629,6 +2724,13 @@ func stringOrDefault(value string, fallback string) string {
 	return value
 }
 
+func floatOrDefault(value float64, fallback float64) float64 {
+	if value <= 0 {
+		return fallback
+	}
+	return value
+}
+
 func sanitizeVaryHeaders(routeID string, values []string) ([]string, error) {
 	if len(values) == 0 {
 		return nil, nil
@@ -650,6 +2752,41 @@ func sanitizeVaryHeaders(routeID string, values []string) ([]string, error) {
 	return result, nil
 }
 
+func sanitizeRouteLabels(routeID string, labels map[string]string) (map[string]string, error) {
+	if len(labels) == 0 {
+		return nil, nil
+	}
+	if len(labels) > maxRouteLabels {
+		return nil, fmt.Errorf("route %q has more than %d labels", routeID, maxRouteLabels)
+	}
+	result := make(map[string]string, len(labels))
+	for key, value := range labels {
+		if !isLabelKey(key) || len(key) > maxRouteLabelKeyLen {
+			return nil, fmt.Errorf("route %q label %q invalid key", routeID, key)
+		}
+		if len(value) > maxRouteLabelValueLen || !isASCII(value) {
+			return nil, fmt.Errorf("route %q label %q invalid value", routeID, key)
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+func isLabelKey(value string) bool {
+	if value == "" {
+		return false
+	}
+	for i, r := range value {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 func boolOrDefault(value *bool, fallback bool) bool {
 	if value == nil {
 		return fallback
@@ -680,6 +2817,23 @@ func retryStatusMap(values []int) map[int]bool {
 	return result
 }
 
+func retryStatusBackoff(routeID string, values map[int]int) (map[int]time.Duration, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	result := make(map[int]time.Duration, len(values))
+	for status, backoffMS := range values {
+		if status < 100 || status > 599 {
+			return nil, fmt.Errorf("route %q retry status_backoff_ms has invalid status %d", routeID, status)
+		}
+		if backoffMS < 0 {
+			return nil, fmt.Errorf("route %q retry status_backoff_ms for %d must be >= 0", routeID, status)
+		}
+		result[status] = durationOrZero(backoffMS)
+	}
+	return result, nil
+}
+
 func retryErrorMap(values []string) map[string]bool {
 	if len(values) == 0 {
 		values = defaultRetryErrors
@@ -694,6 +2848,40 @@ func retryErrorMap(values []string) map[string]bool {
 	return result
 }
 
+func ticketKeysFromConfig(ticketCfg config.SessionTicketsConfig) (*tlsstore.TicketKeyRotator, error) {
+	if !ticketCfg.Enabled {
+		return nil, nil
+	}
+	if ticketCfg.RotationIntervalMS < 0 {
+		return nil, errors.New("tls session_tickets rotation_interval_ms must be non-negative")
+	}
+	interval := durationOrDefault(ticketCfg.RotationIntervalMS, defaultTicketRotationInterval)
+	if interval < minTicketRotationInterval {
+		return nil, fmt.Errorf("tls session_tickets rotation_interval_ms must be at least %d", minTicketRotationInterval.Milliseconds())
+	}
+	retained := ticketCfg.RetainedKeys
+	switch {
+	case retained == 0:
+		retained = defaultTicketRetainedKeys
+	case retained == -1:
+		retained = 0
+	case retained < 0:
+		return nil, errors.New("tls session_tickets retained_keys must be positive or -1")
+	}
+	var seed []byte
+	if env := strings.TrimSpace(ticketCfg.SeedEnv); env != "" {
+		value := strings.TrimSpace(secrets.LookupEnv(env))
+		if value == "" {
+			return nil, fmt.Errorf("tls session_tickets seed missing in %s", env)
+		}
+		if len(value) < minTicketSeedBytes {
+			return nil, fmt.Errorf("tls session_tickets seed in %s must be at least %d bytes", env, minTicketSeedBytes)
+		}
+		seed = []byte(value)
+	}
+	return tlsstore.NewTicketKeyRotator(tlsstore.TicketKeySpec{Seed: seed, Interval: interval, Retained: retained}), nil
+}
+
 func parseTLSMinVersion(value string) (uint16, error) {
 	trimmed := strings.TrimSpace(value)
 	if trimmed == "" || trimmed == "1.2" {
@@ -0,0 +1,229 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"modern_reverse_proxy/internal/breaker"
+	"modern_reverse_proxy/internal/config"
+	"modern_reverse_proxy/internal/logging"
+	"modern_reverse_proxy/internal/outlier"
+)
+
+var logger = logging.For("runtime")
+
+const (
+	stateFileVersion         = 1
+	defaultStateSaveInterval = 30 * time.Second
+	defaultStateMaxAge       = 10 * time.Minute
+)
+
+type StatePersistenceConfig struct {
+	Path     string
+	Interval time.Duration
+	MaxAge   time.Duration
+}
+
+type StatePersister struct {
+	config   StatePersistenceConfig
+	breakers *breaker.Registry
+	outliers *outlier.Registry
+}
+
+type stateFile struct {
+	Version  int                `json:"version"`
+	SavedAt  time.Time          `json:"saved_at"`
+	Breakers []persistedBreaker `json:"breakers"`
+	Outliers []persistedOutlier `json:"outliers"`
+}
+
+type persistedBreaker struct {
+	Key       string    `json:"key"`
+	State     string    `json:"state"`
+	OpenUntil time.Time `json:"open_until,omitempty"`
+}
+
+type persistedOutlier struct {
+	Pool       string    `json:"pool"`
+	Addr       string    `json:"addr"`
+	EjectUntil time.Time `json:"eject_until"`
+	EjectCount int       `json:"eject_count"`
+}
+
+type StateRestoreResult struct {
+	Breakers int
+	Outliers int
+	Stale    bool
+}
+
+func StatePersistenceFromConfig(cfg config.StatePersistenceConfig) (StatePersistenceConfig, error) {
+	persistence := StatePersistenceConfig{
+		Path:     cfg.Path,
+		Interval: defaultStateSaveInterval,
+		MaxAge:   defaultStateMaxAge,
+	}
+	if cfg.IntervalMS > 0 {
+		persistence.Interval = time.Duration(cfg.IntervalMS) * time.Millisecond
+	} else if cfg.IntervalMS < 0 {
+		return StatePersistenceConfig{}, fmt.Errorf("interval_ms must be non-negative")
+	}
+	if cfg.MaxAgeMS > 0 {
+		persistence.MaxAge = time.Duration(cfg.MaxAgeMS) * time.Millisecond
+	} else if cfg.MaxAgeMS < 0 {
+		return StatePersistenceConfig{}, fmt.Errorf("max_age_ms must be non-negative")
+	}
+	if cfg.Path == "" && (cfg.IntervalMS != 0 || cfg.MaxAgeMS != 0) {
+		return StatePersistenceConfig{}, fmt.Errorf("path is required")
+	}
+	return persistence, nil
+}
+
+func NewStatePersister(cfg StatePersistenceConfig, breakers *breaker.Registry, outliers *outlier.Registry) *StatePersister {
+	if cfg.Path == "" {
+		return nil
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultStateSaveInterval
+	}
+	if cfg.MaxAge <= 0 {
+		cfg.MaxAge = defaultStateMaxAge
+	}
+	return &StatePersister{config: cfg, breakers: breakers, outliers: outliers}
+}
+
+func (p *StatePersister) Save() error {
+	if p == nil {
+		return nil
+	}
+	now := time.Now()
+	file := stateFile{Version: stateFileVersion, SavedAt: now}
+	for key, status := range p.breakers.Statuses() {
+		if status.State == breaker.StateClosed {
+			continue
+		}
+		file.Breakers = append(file.Breakers, persistedBreaker{Key: key, State: status.State.String(), OpenUntil: status.OpenUntil})
+	}
+	for poolKey, endpoints := range p.outliers.Ejections(now) {
+		for addr, status := range endpoints {
+			file.Outliers = append(file.Outliers, persistedOutlier{Pool: poolKey, Addr: addr, EjectUntil: status.EjectUntil, EjectCount: status.EjectCount})
+		}
+	}
+	sort.Slice(file.Breakers, func(i, j int) bool { return file.Breakers[i].Key < file.Breakers[j].Key })
+	sort.Slice(file.Outliers, func(i, j int) bool {
+		if file.Outliers[i].Pool != file.Outliers[j].Pool {
+			return file.Outliers[i].Pool < file.Outliers[j].Pool
+		}
+		return file.Outliers[i].Addr < file.Outliers[j].Addr
+	})
+
+	data, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(p.config.Path)
+	tmp, err := os.CreateTemp(dir, ".state-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpName)
+		return err
+	}
+	if err := os.Rename(tmpName, p.config.Path); err != nil {
+		_ = os.Remove(tmpName)
+		return err
+	}
+	return nil
+}
+
+func (p *StatePersister) Restore() (StateRestoreResult, error) {
+	var result StateRestoreResult
+	if p == nil {
+		return result, nil
+	}
+	data, err := os.ReadFile(p.config.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return result, nil
+	}
+	if err != nil {
+		return result, err
+	}
+	var file stateFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return result, fmt.Errorf("decode state file: %w", err)
+	}
+	if file.Version != stateFileVersion {
+		return result, fmt.Errorf("unsupported state file version %d", file.Version)
+	}
+	now := time.Now()
+	if now.Sub(file.SavedAt) > p.config.MaxAge {
+		result.Stale = true
+		return result, nil
+	}
+
+	for _, saved := range file.Breakers {
+		var state breaker.State
+		switch saved.State {
+		case breaker.StateOpen.String():
+			state = breaker.StateOpen
+		case breaker.StateHalfOpen.String():
+			state = breaker.StateHalfOpen
+		default:
+			continue
+		}
+		p.breakers.Restore(saved.Key, breaker.Status{State: state, OpenUntil: saved.OpenUntil})
+		result.Breakers++
+	}
+	for _, saved := range file.Outliers {
+		if !saved.EjectUntil.After(now) {
+			continue
+		}
+		if p.outliers.Restore(saved.Pool, saved.Addr, outlier.EndpointStatus{Ejected: true, EjectUntil: saved.EjectUntil, EjectCount: saved.EjectCount}) {
+			result.Outliers++
+		}
+	}
+	return result, nil
+}
+
+func (p *StatePersister) Run(ctx context.Context) {
+	if p == nil {
+		return
+	}
+	ticker := time.NewTicker(p.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := p.Save(); err != nil {
+				logger.Warn("state_save", "state_save_result", "error", "trigger", "interval", "reason", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (p *StatePersister) Stop(ctx context.Context) error {
+	_ = ctx
+	if p == nil {
+		return nil
+	}
+	if err := p.Save(); err != nil {
+		logger.Warn("state_save", "state_save_result", "error", "trigger", "shutdown", "reason", err)
+		return err
+	}
+	logger.Info("state_save", "state_save_result", "success", "trigger", "shutdown")
+	return nil
+}
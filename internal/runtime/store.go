@@ -4,6 +4,8 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"modern_reverse_proxy/internal/plugin"
 )
 
 const defaultMaxRetiredSnapshots = 10
@@ -13,6 +15,7 @@ type Store struct {
 	mu         sync.Mutex
 	retired    []*Snapshot
 	maxRetired int
+	onSwap     []func(*Snapshot)
 }
 
 type RetiredSnapshotInfo struct {
@@ -23,6 +26,7 @@ type RetiredSnapshotInfo struct {
 
 func NewStore(initial *Snapshot) *Store {
 	store := &Store{maxRetired: defaultMaxRetiredSnapshots}
+	plugin.RetainWASMModules(wasmModules(initial))
 	store.current.Store(initial)
 	return store
 }
@@ -39,12 +43,17 @@ func (s *Store) Get() *Snapshot {
 }
 
 func (s *Store) Acquire() *Snapshot {
-	snapshot := s.Get()
-	if snapshot == nil {
-		return nil
+	for {
+		snapshot := s.Get()
+		if snapshot == nil {
+			return nil
+		}
+		snapshot.IncRef()
+		if s.Get() == snapshot {
+			return snapshot
+		}
+		s.Release(snapshot)
 	}
-	snapshot.IncRef()
-	return snapshot
 }
 
 func (s *Store) Release(snapshot *Snapshot) {
@@ -68,18 +77,32 @@ func (s *Store) Swap(next *Snapshot) error {
 		previous = value.(*Snapshot)
 	}
 
+	plugin.RetainWASMModules(wasmModules(next))
 	s.mu.Lock()
 	if previous != nil {
 		previous.MarkRetired(time.Now())
 		s.retired = append(s.retired, previous)
 	}
 	s.current.Store(next)
+	hooks := s.onSwap
 	s.mu.Unlock()
 
 	s.Reap()
+	for _, hook := range hooks {
+		hook(next)
+	}
 	return nil
 }
 
+func (s *Store) OnSwap(hook func(*Snapshot)) {
+	if s == nil || hook == nil {
+		return
+	}
+	s.mu.Lock()
+	s.onSwap = append(s.onSwap[:len(s.onSwap):len(s.onSwap)], hook)
+	s.mu.Unlock()
+}
+
 func (s *Store) RetiredCount() int {
 	if s == nil {
 		return 0
@@ -123,16 +146,34 @@ func (s *Store) Reap() {
 		return
 	}
 	retained := s.retired[:0]
+	var removed []*Snapshot
 	for _, snapshot := range s.retired {
-		if snapshot == nil {
-			continue
-		}
-		if snapshot.RefCount() != 0 {
+		if snapshot != nil && snapshot.RefCount() != 0 {
 			retained = append(retained, snapshot)
+			continue
 		}
+		removed = append(removed, snapshot)
 	}
 	s.retired = retained
 	s.mu.Unlock()
+	for _, snapshot := range removed {
+		plugin.ReleaseWASMModules(wasmModules(snapshot))
+	}
+}
+
+func wasmModules(snapshot *Snapshot) map[*plugin.WASMModule]struct{} {
+	modules := make(map[*plugin.WASMModule]struct{})
+	if snapshot == nil || snapshot.Router == nil {
+		return modules
+	}
+	for _, route := range snapshot.Router.Routes() {
+		for _, filter := range route.Policy.Plugins.Filters {
+			if filter.WASM != nil {
+				modules[filter.WASM] = struct{}{}
+			}
+		}
+	}
+	return modules
 }
 
 func (s *Store) SetMaxRetired(limit int) {
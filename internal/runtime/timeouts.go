@@ -0,0 +1,44 @@
+package runtime
+
+import (
+	"fmt"
+	"sort"
+
+	"modern_reverse_proxy/internal/limits"
+	"modern_reverse_proxy/internal/policy"
+	"modern_reverse_proxy/internal/router"
+)
+
+func validateRouteTimeouts(routeID string, routePolicy policy.Policy, explicitRequestTimeout bool, defaultLimits limits.Limits, listeners map[string]Listener, attached map[string]bool) error {
+	requestTimeout := routePolicy.RequestTimeout
+	if routePolicy.Retry.Enabled && routePolicy.Retry.PerTryTimeout > 0 && routePolicy.Retry.PerTryTimeout >= requestTimeout {
+		return fmt.Errorf("route %q retry per_try_timeout_ms (%s) must be less than request_timeout_ms (%s)", routeID, routePolicy.Retry.PerTryTimeout, requestTimeout)
+	}
+	if routePolicy.IdleTimeout > 0 && routePolicy.ResponseStreamTimeout > 0 && routePolicy.IdleTimeout > routePolicy.ResponseStreamTimeout {
+		return fmt.Errorf("route %q idle_timeout_ms (%s) must not exceed response_stream_timeout_ms (%s)", routeID, routePolicy.IdleTimeout, routePolicy.ResponseStreamTimeout)
+	}
+	if !explicitRequestTimeout {
+		return nil
+	}
+
+	names := make([]string, 0, len(listeners)+1)
+	if attached == nil || attached[router.DefaultListener] {
+		names = append(names, router.DefaultListener)
+	}
+	for name := range listeners {
+		if attached == nil || attached[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		writeTimeout := defaultLimits.WriteTimeout
+		if listener, ok := listeners[name]; ok {
+			writeTimeout = listener.Limits.WriteTimeout
+		}
+		if writeTimeout > 0 && requestTimeout >= writeTimeout {
+			return fmt.Errorf("route %q request_timeout_ms (%s) must be less than listener %q write_timeout_ms (%s)", routeID, requestTimeout, name, writeTimeout)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,325 @@
+package script
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
+)
+
+const (
+	callStackSize   = 64
+	registrySize    = 1024
+	registryMaxSize = 64 * 1024
+	maxVars         = 32
+	defaultMaxSteps = 10000000
+	maxStringBytes  = 64 << 10
+	maxFormatWidth  = 99
+	sizeCheckSteps  = 16
+	denySentinel    = "__proxy_deny__"
+)
+
+var (
+	ErrTimeout     = errors.New("script timeout")
+	ErrStepLimit   = errors.New("script step limit exceeded")
+	ErrMemoryLimit = errors.New("script string size limit exceeded")
+)
+
+type Program struct {
+	proto    *lua.FunctionProto
+	timeout  time.Duration
+	maxSteps int
+}
+
+type Request struct {
+	Method   string
+	Host     string
+	Path     string
+	Query    string
+	RemoteIP string
+	Header   http.Header
+}
+
+type Result struct {
+	Denied     bool
+	Status     int
+	Body       string
+	SetHeaders map[string]string
+	Vars       map[string]string
+	Pool       string
+}
+
+func Compile(name string, source string, timeout time.Duration, maxSteps int) (*Program, error) {
+	chunk, err := parse.Parse(strings.NewReader(source), name)
+	if err != nil {
+		return nil, err
+	}
+	proto, err := lua.Compile(chunk, name)
+	if err != nil {
+		return nil, err
+	}
+	if maxSteps <= 0 {
+		maxSteps = defaultMaxSteps
+	}
+	return &Program{proto: proto, timeout: timeout, maxSteps: maxSteps}, nil
+}
+
+func (p *Program) Run(ctx context.Context, req Request) (*Result, error) {
+	state := lua.NewState(lua.Options{
+		SkipOpenLibs:        true,
+		CallStackSize:       callStackSize,
+		RegistrySize:        registrySize,
+		RegistryMaxSize:     registryMaxSize,
+		IncludeGoStackTrace: false,
+	})
+	defer state.Close()
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.StringLibName, lua.OpenString},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		state.Push(state.NewFunction(lib.open))
+		state.Push(lua.LString(lib.name))
+		state.Call(1, 0)
+	}
+	limitLibs(state)
+	for _, unsafe := range []string{"dofile", "loadfile", "load", "loadstring", "require", "module", "collectgarbage"} {
+		state.SetGlobal(unsafe, lua.LNil)
+	}
+
+	result := &Result{}
+	state.SetGlobal("request", requestTable(state, req))
+	state.SetGlobal("deny", state.NewFunction(func(L *lua.LState) int {
+		result.Denied = true
+		result.Status = L.OptInt(1, http.StatusForbidden)
+		result.Body = L.OptString(2, "")
+		L.RaiseError("%s", denySentinel)
+		return 0
+	}))
+	state.SetGlobal("set_header", state.NewFunction(func(L *lua.LState) int {
+		if result.SetHeaders == nil {
+			result.SetHeaders = make(map[string]string)
+		}
+		result.SetHeaders[L.CheckString(1)] = L.CheckString(2)
+		return 0
+	}))
+	state.SetGlobal("set_var", state.NewFunction(func(L *lua.LState) int {
+		if result.Vars == nil {
+			result.Vars = make(map[string]string)
+		}
+		name := L.CheckString(1)
+		if _, ok := result.Vars[name]; !ok && len(result.Vars) >= maxVars {
+			L.RaiseError("too many variables")
+		}
+		result.Vars[name] = L.CheckString(2)
+		return 0
+	}))
+	state.SetGlobal("use_pool", state.NewFunction(func(L *lua.LState) int {
+		result.Pool = L.CheckString(1)
+		return 0
+	}))
+
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+	budget := &budgetContext{Context: ctx, state: state, maxSteps: p.maxSteps, done: make(chan struct{})}
+	state.SetContext(budget)
+
+	state.Push(state.NewFunctionFromProto(p.proto))
+	if err := state.PCall(0, lua.MultRet, nil); err != nil {
+		if result.Denied && strings.Contains(err.Error(), denySentinel) {
+			return result, nil
+		}
+		if budget.err != nil {
+			return nil, budget.err
+		}
+		if ctx.Err() != nil {
+			return nil, ErrTimeout
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+type budgetContext struct {
+	context.Context
+	state    *lua.LState
+	steps    int
+	maxSteps int
+	err      error
+	done     chan struct{}
+}
+
+func (c *budgetContext) Done() <-chan struct{} {
+	if c.err != nil {
+		return c.done
+	}
+	c.steps++
+	if c.steps > c.maxSteps {
+		c.err = ErrStepLimit
+	} else if c.steps%sizeCheckSteps == 0 && oversizedRegister(c.state) {
+		c.err = ErrMemoryLimit
+	}
+	if c.err != nil {
+		close(c.done)
+		return c.done
+	}
+	return c.Context.Done()
+}
+
+func (c *budgetContext) Err() error {
+	if c.err != nil {
+		return c.err
+	}
+	return c.Context.Err()
+}
+
+func oversizedRegister(state *lua.LState) bool {
+	for i := state.GetTop(); i > 0; i-- {
+		if value, ok := state.Get(i).(lua.LString); ok && len(value) > maxStringBytes {
+			return true
+		}
+	}
+	return false
+}
+
+func limitLibs(state *lua.LState) {
+	strlib := state.GetGlobal(lua.StringLibName).(*lua.LTable)
+	rep := strlib.RawGetString("rep").(*lua.LFunction)
+	strlib.RawSetString("rep", state.NewFunction(func(L *lua.LState) int {
+		str := L.CheckString(1)
+		if count := L.CheckInt(2); count > 0 && len(str) > 0 {
+			if count > maxStringBytes/len(str) {
+				L.RaiseError("%s", ErrMemoryLimit.Error())
+			}
+			checkStringSize(L, count*len(str))
+		}
+		return callOriginal(L, rep)
+	}))
+	format := strlib.RawGetString("format").(*lua.LFunction)
+	strlib.RawSetString("format", state.NewFunction(func(L *lua.LState) int {
+		size, err := formatSize(L.CheckString(1))
+		if err != nil {
+			L.RaiseError("%s", err.Error())
+		}
+		for i := 2; i <= L.GetTop(); i++ {
+			size += len(lua.LVAsString(L.Get(i))) + 32
+		}
+		checkStringSize(L, size)
+		return callOriginal(L, format)
+	}))
+	gsub := strlib.RawGetString("gsub").(*lua.LFunction)
+	strlib.RawSetString("gsub", state.NewFunction(func(L *lua.LState) int {
+		str := L.CheckString(1)
+		switch repl := L.Get(3).(type) {
+		case lua.LString:
+			expansion := len(repl) + strings.Count(string(repl), "%")*len(str)
+			checkStringSize(L, len(str)+(len(str)+1)*expansion)
+		case *lua.LTable, *lua.LFunction:
+			size := len(str)
+			L.Replace(3, L.NewFunction(func(L *lua.LState) int {
+				var value lua.LValue
+				if table, ok := repl.(*lua.LTable); ok {
+					value = L.GetTable(table, L.Get(1))
+				} else {
+					L.Insert(repl, 1)
+					L.Call(L.GetTop()-1, 1)
+					value = L.Get(-1)
+				}
+				size += len(lua.LVAsString(value))
+				checkStringSize(L, size)
+				L.Push(value)
+				return 1
+			}))
+		}
+		return callOriginal(L, gsub)
+	}))
+	tablib := state.GetGlobal(lua.TabLibName).(*lua.LTable)
+	concat := tablib.RawGetString("concat").(*lua.LFunction)
+	tablib.RawSetString("concat", state.NewFunction(func(L *lua.LState) int {
+		table := L.CheckTable(1)
+		sep := L.OptString(2, "")
+		size := 0
+		for i, last := L.OptInt(3, 1), L.OptInt(4, table.Len()); i <= last; i++ {
+			value := table.RawGetInt(i)
+			if value == lua.LNil {
+				break
+			}
+			size += len(lua.LVAsString(value)) + len(sep)
+			checkStringSize(L, size)
+		}
+		return callOriginal(L, concat)
+	}))
+}
+
+func formatSize(format string) (int, error) {
+	size := len(format)
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		i++
+		for i < len(format) && strings.IndexByte("-+ #0", format[i]) >= 0 {
+			i++
+		}
+		for _, part := range []bool{true, false} {
+			if !part {
+				if i >= len(format) || format[i] != '.' {
+					break
+				}
+				i++
+			}
+			width := 0
+			for i < len(format) && format[i] >= '0' && format[i] <= '9' {
+				width = width*10 + int(format[i]-'0')
+				if width > maxFormatWidth {
+					return 0, errors.New("invalid format (width or precision too long)")
+				}
+				i++
+			}
+			size += width
+		}
+	}
+	return size, nil
+}
+
+func checkStringSize(L *lua.LState, size int) {
+	if size > maxStringBytes {
+		L.RaiseError("%s", ErrMemoryLimit.Error())
+	}
+}
+
+func callOriginal(L *lua.LState, fn *lua.LFunction) int {
+	top := L.GetTop()
+	L.Insert(fn, 1)
+	L.Call(top, lua.MultRet)
+	return L.GetTop()
+}
+
+func requestTable(state *lua.LState, req Request) *lua.LTable {
+	table := state.NewTable()
+	table.RawSetString("method", lua.LString(req.Method))
+	table.RawSetString("host", lua.LString(req.Host))
+	table.RawSetString("path", lua.LString(req.Path))
+	table.RawSetString("query", lua.LString(req.Query))
+	table.RawSetString("remote_ip", lua.LString(req.RemoteIP))
+	headers := state.NewTable()
+	for key, values := range req.Header {
+		if len(values) == 0 {
+			continue
+		}
+		headers.RawSetString(strings.ToLower(key), lua.LString(strings.Join(values, ",")))
+	}
+	table.RawSetString("headers", headers)
+	return table
+}
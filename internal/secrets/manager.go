@@ -0,0 +1,185 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"modern_reverse_proxy/internal/logging"
+)
+
+var logger = logging.For("secrets")
+
+const (
+	DefaultRefresh = time.Minute
+	Redacted       = "[REDACTED]"
+	minRedactLen   = 4
+)
+
+type Config struct {
+	Source  Source
+	Refresh time.Duration
+}
+
+type Manager struct {
+	source  Source
+	refresh time.Duration
+
+	mu       sync.RWMutex
+	values   map[string]string
+	redacted map[string]struct{}
+	patterns []string
+}
+
+func NewManager(cfg Config) *Manager {
+	refresh := cfg.Refresh
+	if refresh <= 0 {
+		refresh = DefaultRefresh
+	}
+	return &Manager{
+		source:   cfg.Source,
+		refresh:  refresh,
+		values:   make(map[string]string),
+		redacted: make(map[string]struct{}),
+	}
+}
+
+func (m *Manager) Resolve(ctx context.Context, value string) (string, error) {
+	name, ok := RefName(value)
+	if !ok {
+		if IsRef(value) {
+			return "", errors.New("secret reference is missing a name")
+		}
+		return value, nil
+	}
+	if m == nil || m.source == nil {
+		return "", fmt.Errorf("secret %q: no secrets source configured", name)
+	}
+	if cached, ok := m.Lookup(name); ok {
+		return cached, nil
+	}
+	fetched, err := m.source.Fetch(ctx, name)
+	if err != nil {
+		return "", fmt.Errorf("secret %q from %s: %w", name, m.source.Name(), err)
+	}
+	m.store(name, fetched)
+	return fetched, nil
+}
+
+func (m *Manager) Lookup(name string) (string, bool) {
+	if m == nil {
+		return "", false
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	value, ok := m.values[name]
+	return value, ok
+}
+
+func (m *Manager) Func(ref string) func() string {
+	return func() string {
+		value, err := m.Resolve(context.Background(), ref)
+		if err != nil {
+			return ""
+		}
+		return value
+	}
+}
+
+func (m *Manager) Refresh(ctx context.Context) {
+	if m == nil || m.source == nil {
+		return
+	}
+	m.mu.RLock()
+	names := make([]string, 0, len(m.values))
+	for name := range m.values {
+		names = append(names, name)
+	}
+	m.mu.RUnlock()
+	sort.Strings(names)
+
+	for _, name := range names {
+		value, err := m.source.Fetch(ctx, name)
+		if err != nil {
+			logger.Warn("secrets_refresh", "name", name, "source", m.source.Name(), "result", "error", "reason", err)
+			continue
+		}
+		if previous, _ := m.Lookup(name); previous != value {
+			m.store(name, value)
+			logger.Info("secrets_refresh", "name", name, "source", m.source.Name(), "result", "rotated")
+		}
+	}
+}
+
+func (m *Manager) Run(ctx context.Context) {
+	if m == nil {
+		return
+	}
+	ticker := time.NewTicker(m.refresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Refresh(ctx)
+		}
+	}
+}
+
+func (m *Manager) Redact(text string) string {
+	if m == nil {
+		return text
+	}
+	m.mu.RLock()
+	patterns := m.patterns
+	m.mu.RUnlock()
+	for _, value := range patterns {
+		text = strings.ReplaceAll(text, value, Redacted)
+	}
+	return text
+}
+
+func (m *Manager) store(name string, value string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.values[name] = value
+	if _, ok := m.redacted[value]; ok || len(value) < minRedactLen {
+		return
+	}
+	m.redacted[value] = struct{}{}
+	if encoded, err := json.Marshal(value); err == nil {
+		m.redacted[string(encoded[1:len(encoded)-1])] = struct{}{}
+	}
+	patterns := make([]string, 0, len(m.redacted))
+	for pattern := range m.redacted {
+		patterns = append(patterns, pattern)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		return len(patterns[i]) > len(patterns[j])
+	})
+	m.patterns = patterns
+}
+
+type redactingWriter struct {
+	writer  io.Writer
+	manager *Manager
+}
+
+func NewRedactingWriter(w io.Writer, m *Manager) io.Writer {
+	return &redactingWriter{writer: w, manager: m}
+}
+
+func (r *redactingWriter) Write(p []byte) (int, error) {
+	redacted := r.manager.Redact(string(p))
+	if _, err := io.WriteString(r.writer, redacted); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
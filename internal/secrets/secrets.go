@@ -0,0 +1,59 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+const RefPrefix = "secret://"
+
+var ErrNotFound = errors.New("secret not found")
+
+type Source interface {
+	Name() string
+	Fetch(ctx context.Context, name string) (string, error)
+}
+
+func IsRef(value string) bool {
+	return strings.HasPrefix(strings.TrimSpace(value), RefPrefix)
+}
+
+func RefName(value string) (string, bool) {
+	trimmed := strings.TrimSpace(value)
+	if !strings.HasPrefix(trimmed, RefPrefix) {
+		return "", false
+	}
+	name := strings.TrimPrefix(trimmed, RefPrefix)
+	if name == "" {
+		return "", false
+	}
+	return name, true
+}
+
+var defaultManager atomic.Pointer[Manager]
+
+func SetDefault(m *Manager) {
+	defaultManager.Store(m)
+}
+
+func Default() *Manager {
+	return defaultManager.Load()
+}
+
+func LookupEnv(env string) string {
+	if IsRef(env) {
+		m := Default()
+		if m == nil {
+			return ""
+		}
+		value, err := m.Resolve(context.Background(), env)
+		if err != nil {
+			return ""
+		}
+		return value
+	}
+	return os.Getenv(env)
+}
@@ -0,0 +1,119 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type EnvSource struct {
+	Prefix string
+}
+
+func (s *EnvSource) Name() string {
+	return "env"
+}
+
+func (s *EnvSource) Fetch(ctx context.Context, name string) (string, error) {
+	_ = ctx
+	key := strings.ToUpper(strings.NewReplacer("-", "_", ".", "_", "/", "_").Replace(name))
+	value, ok := os.LookupEnv(s.Prefix + key)
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+type FileSource struct {
+	Dir string
+}
+
+func (s *FileSource) Name() string {
+	return "file"
+}
+
+func (s *FileSource) Fetch(ctx context.Context, name string) (string, error) {
+	_ = ctx
+	data, err := readSecretFile(s.Dir, name)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+type VaultSource struct {
+	Addr   string
+	Token  string
+	Mount  string
+	Client *http.Client
+}
+
+func (s *VaultSource) Name() string {
+	return "vault"
+}
+
+func (s *VaultSource) Fetch(ctx context.Context, name string) (string, error) {
+	path, key, _ := strings.Cut(name, "#")
+	if key == "" {
+		key = "value"
+	}
+	mount := strings.Trim(s.Mount, "/")
+	if mount == "" {
+		mount = "secret"
+	}
+	endpoint := strings.TrimRight(s.Addr, "/") + "/v1/" + mount + "/data/" + strings.TrimLeft(path, "/")
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("X-Vault-Token", s.Token)
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	resp, err := client.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return "", ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return "", fmt.Errorf("vault status %d for %s", resp.StatusCode, url.PathEscape(path))
+	}
+	var payload struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&payload); err != nil {
+		return "", err
+	}
+	value, ok := payload.Data.Data[key].(string)
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+func readSecretFile(dir string, name string) ([]byte, error) {
+	clean := filepath.Clean("/" + name)
+	if clean == "/" {
+		return nil, ErrNotFound
+	}
+	data, err := os.ReadFile(filepath.Join(dir, clean))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
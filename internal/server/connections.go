@@ -0,0 +1,208 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"modern_reverse_proxy/internal/limits"
+	"modern_reverse_proxy/internal/obs"
+)
+
+type connRequestsKey struct{}
+
+type connTracker struct {
+	listener string
+	mu       sync.Mutex
+	states   map[net.Conn]http.ConnState
+	active   int64
+}
+
+func configureConnections(server *http.Server, listener string, limitConfig limits.Limits) {
+	tracker := &connTracker{listener: listener, states: make(map[net.Conn]http.ConnState)}
+	server.ConnState = tracker.track
+	switch {
+	case limitConfig.KeepAliveTimeout < 0:
+		server.SetKeepAlivesEnabled(false)
+	case limitConfig.KeepAliveTimeout > 0:
+		server.IdleTimeout = limitConfig.KeepAliveTimeout
+	}
+	if limitConfig.MaxRequestsPerConn <= 0 {
+		return
+	}
+	maxRequests := int64(limitConfig.MaxRequestsPerConn)
+	next := server.Handler
+	server.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if served, ok := r.Context().Value(connRequestsKey{}).(*atomic.Int64); ok && served.Add(1) >= maxRequests {
+			w.Header().Set("Connection", "close")
+		}
+		next.ServeHTTP(w, r)
+	})
+	connContext := server.ConnContext
+	server.ConnContext = func(ctx context.Context, conn net.Conn) context.Context {
+		if connContext != nil {
+			ctx = connContext(ctx, conn)
+		}
+		return context.WithValue(ctx, connRequestsKey{}, new(atomic.Int64))
+	}
+}
+
+func (t *connTracker) track(conn net.Conn, state http.ConnState) {
+	t.mu.Lock()
+	previous, known := t.states[conn]
+	switch state {
+	case http.StateNew:
+		t.states[conn] = state
+	case http.StateActive, http.StateIdle:
+		if !known {
+			t.mu.Unlock()
+			return
+		}
+		t.states[conn] = state
+	default:
+		if !known {
+			t.mu.Unlock()
+			return
+		}
+		delete(t.states, conn)
+	}
+	if known && previous == http.StateActive && state != http.StateActive {
+		t.active--
+	}
+	if state == http.StateActive && previous != http.StateActive {
+		t.active++
+	}
+	open, active := int64(len(t.states)), t.active
+	t.mu.Unlock()
+
+	if metrics := obs.DefaultMetrics(); metrics != nil {
+		if state == http.StateNew {
+			metrics.RecordListenerAccepted(t.listener)
+		}
+		metrics.SetListenerConnections(t.listener, open)
+		metrics.SetListenerActiveConnections(t.listener, active)
+	}
+}
+
+type handshakeListener struct {
+	net.Listener
+	listener string
+	config   *tls.Config
+	timeout  time.Duration
+	conns    chan net.Conn
+	failed   chan struct{}
+	done     chan struct{}
+	err      error
+	once     sync.Once
+}
+
+func newHandshakeListener(listener string, ln net.Listener, config *tls.Config, limitConfig limits.Limits) net.Listener {
+	timeout := limitConfig.ReadHeaderTimeout
+	for _, candidate := range []time.Duration{limitConfig.ReadTimeout, limitConfig.WriteTimeout} {
+		if candidate > 0 && (timeout <= 0 || candidate < timeout) {
+			timeout = candidate
+		}
+	}
+	l := &handshakeListener{
+		Listener: ln,
+		listener: listener,
+		config:   config,
+		timeout:  timeout,
+		conns:    make(chan net.Conn),
+		failed:   make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go l.acceptLoop()
+	return l
+}
+
+func (l *handshakeListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.failed:
+		return nil, l.err
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *handshakeListener) Close() error {
+	l.once.Do(func() {
+		close(l.done)
+	})
+	return l.Listener.Close()
+}
+
+func (l *handshakeListener) acceptLoop() {
+	var backoff time.Duration
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				l.err = err
+				close(l.failed)
+				return
+			}
+			backoff = min(max(2*backoff, 5*time.Millisecond), time.Second)
+			logger.Warn("accept_error", "listener", l.listener, "reason", err, "retry_in", backoff)
+			select {
+			case <-time.After(backoff):
+				continue
+			case <-l.done:
+				return
+			}
+		}
+		backoff = 0
+		go l.handshake(conn)
+	}
+}
+
+func (l *handshakeListener) handshake(conn net.Conn) {
+	tlsConn := tls.Server(conn, l.config)
+	if l.timeout > 0 {
+		_ = conn.SetDeadline(time.Now().Add(l.timeout))
+	}
+	start := time.Now()
+	err := tlsConn.Handshake()
+	metrics := obs.DefaultMetrics()
+	if err != nil {
+		_ = tlsConn.Close()
+		if metrics != nil {
+			metrics.RecordTLSHandshakeError(l.listener, handshakeErrorReason(err))
+		}
+		logger.Debug("tls_handshake_error", "listener", l.listener, "remote_addr", conn.RemoteAddr().String(), "reason", err)
+		return
+	}
+	_ = conn.SetDeadline(time.Time{})
+	if metrics != nil {
+		state := tlsConn.ConnectionState()
+		metrics.ObserveTLSHandshake(l.listener, tls.VersionName(state.Version), tls.CipherSuiteName(state.CipherSuite), state.DidResume, time.Since(start))
+	}
+	select {
+	case l.conns <- tlsConn:
+	case <-l.done:
+		_ = tlsConn.Close()
+	}
+}
+
+func handshakeErrorReason(err error) string {
+	var recordErr tls.RecordHeaderError
+	switch {
+	case errors.Is(err, os.ErrDeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, io.EOF), errors.Is(err, net.ErrClosed):
+		return "client_closed"
+	case errors.As(err, &recordErr):
+		return "not_tls"
+	default:
+		return "handshake_failed"
+	}
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/quic-go/quic-go/http3"
+
+	"modern_reverse_proxy/internal/handoff"
+	"modern_reverse_proxy/internal/limits"
+	"modern_reverse_proxy/internal/logging"
+)
+
+var logger = logging.For("server")
+
+func startHTTP3(handler http.Handler, tlsCfg *tls.Config, name string, addr string, limitConfig limits.Limits) (*http3.Server, net.PacketConn, error) {
+	conn, err := handoff.ListenPacket(name, addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	h3Server := &http3.Server{
+		Handler:        limitHandler("h3", handler, limitConfig),
+		TLSConfig:      http3.ConfigureTLSConfig(tlsCfg),
+		MaxHeaderBytes: limitConfig.MaxHeaderBytes,
+		IdleTimeout:    limitConfig.IdleTimeout,
+	}
+	go func() {
+		if err := h3Server.Serve(conn); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("http3_server_error", "reason", err)
+		}
+	}()
+	return h3Server, conn, nil
+}
+
+func altSvcHandler(handler http.Handler, conn net.PacketConn, maxAge time.Duration) http.Handler {
+	udpAddr, ok := conn.LocalAddr().(*net.UDPAddr)
+	if !ok {
+		return handler
+	}
+	value := fmt.Sprintf(`h3=":%d"; ma=%d`, udpAddr.Port, int64(maxAge/time.Second))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Alt-Svc", value)
+		handler.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"modern_reverse_proxy/internal/limits"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/proxy"
+)
+
+type concurrencyLimiter struct {
+	listener     string
+	next         http.Handler
+	slots        chan struct{}
+	queue        chan struct{}
+	queueTimeout time.Duration
+	inflight     atomic.Int64
+	queued       atomic.Int64
+}
+
+func limitHandler(listener string, handler http.Handler, limitConfig limits.Limits) http.Handler {
+	if limitConfig.MaxConcurrentRequests <= 0 {
+		return handler
+	}
+	limiter := &concurrencyLimiter{
+		listener:     listener,
+		next:         handler,
+		slots:        make(chan struct{}, limitConfig.MaxConcurrentRequests),
+		queueTimeout: limitConfig.QueueTimeout,
+	}
+	if limitConfig.MaxQueuedRequests > 0 {
+		limiter.queue = make(chan struct{}, limitConfig.MaxQueuedRequests)
+	}
+	return limiter
+}
+
+func (l *concurrencyLimiter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if reason, ok := l.acquire(r); !ok {
+		if metrics := obs.DefaultMetrics(); metrics != nil {
+			metrics.RecordListenerShed(l.listener, reason)
+		}
+		requestID := r.Header.Get(proxy.RequestIDHeader)
+		if requestID == "" {
+			requestID = proxy.NewRequestID()
+		}
+		proxy.WriteOverload(w, requestID)
+		return
+	}
+	defer l.release()
+	l.next.ServeHTTP(w, r)
+}
+
+func (l *concurrencyLimiter) acquire(r *http.Request) (string, bool) {
+	select {
+	case l.slots <- struct{}{}:
+		l.recordInflight(1)
+		return "", true
+	default:
+	}
+	if l.queue == nil {
+		return "concurrency", false
+	}
+	select {
+	case l.queue <- struct{}{}:
+	default:
+		return "queue_full", false
+	}
+	l.recordQueued(1)
+	defer func() {
+		<-l.queue
+		l.recordQueued(-1)
+	}()
+
+	timer := time.NewTimer(l.queueTimeout)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		l.recordInflight(1)
+		return "", true
+	case <-timer.C:
+		return "queue_timeout", false
+	case <-r.Context().Done():
+		return "client_gone", false
+	}
+}
+
+func (l *concurrencyLimiter) release() {
+	<-l.slots
+	l.recordInflight(-1)
+}
+
+func (l *concurrencyLimiter) recordInflight(delta int64) {
+	value := l.inflight.Add(delta)
+	if metrics := obs.DefaultMetrics(); metrics != nil {
+		metrics.SetListenerInflight(l.listener, value)
+	}
+}
+
+func (l *concurrencyLimiter) recordQueued(delta int64) {
+	value := l.queued.Add(delta)
+	if metrics := obs.DefaultMetrics(); metrics != nil {
+		metrics.SetListenerQueued(l.listener, value)
+	}
+}
+
+type connLimitListener struct {
+	net.Listener
+	listener string
+	max      int64
+	open     atomic.Int64
+}
+
+func limitListener(listener string, ln net.Listener, limitConfig limits.Limits) net.Listener {
+	if limitConfig.MaxConnections <= 0 {
+		return ln
+	}
+	return &connLimitListener{
+		Listener: ln,
+		listener: listener,
+		max:      int64(limitConfig.MaxConnections),
+	}
+}
+
+func (l *connLimitListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		if l.open.Add(1) > l.max {
+			l.open.Add(-1)
+			_ = conn.Close()
+			if metrics := obs.DefaultMetrics(); metrics != nil {
+				metrics.RecordListenerShed(l.listener, "connections")
+			}
+			continue
+		}
+		return &limitedConn{Conn: conn, release: func() {
+			l.open.Add(-1)
+		}}, nil
+	}
+}
+
+type limitedConn struct {
+	net.Conn
+	once    sync.Once
+	release func()
+}
+
+func (c *limitedConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.release)
+	return err
+}
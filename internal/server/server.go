@@ -3,26 +3,36 @@ package server
 import (
 	"context"
 	"crypto/tls"
+	"encoding/json"
 	"errors"
-	"log"
+	"fmt"
 	"net"
 	"net/http"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/quic-go/quic-go/http3"
+
+	"modern_reverse_proxy/internal/fingerprint"
 	"modern_reverse_proxy/internal/limits"
+	"modern_reverse_proxy/internal/router"
 	"modern_reverse_proxy/internal/runtime"
 )
 
 type Server struct {
-	HTTPAddr string
-	TLSAddr  string
+	HTTPAddr      string
+	TLSAddr       string
+	HTTP3Addr     string
+	ListenerAddrs map[string]string
 
 	httpServer   *http.Server
 	tlsServer    *http.Server
 	httpLn       net.Listener
 	tlsLn        net.Listener
+	h3Server     *http3.Server
+	h3Conn       net.PacketConn
+	named        []*namedServer
 	limits       limits.Limits
 	shutdown     runtime.ShutdownConfig
 	inflight     *runtime.InflightTracker
@@ -48,6 +58,14 @@ type Options struct {
 	Inflight  *runtime.InflightTracker
 	Stoppers  []Stopper
 	CloseIdle []func()
+	HTTP3     runtime.HTTP3Config
+	Name      string
+	Listeners []runtime.Listener
+}
+
+type namedServer struct {
+	server *http.Server
+	ln     net.Listener
 }
 
 func BaseTLSConfig(store *runtime.Store) *tls.Config {
@@ -55,14 +73,32 @@ func BaseTLSConfig(store *runtime.Store) *tls.Config {
 		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
 			snap := store.Get()
 			if snap == nil || !snap.TLSEnabled || snap.TLSConfig == nil {
-				log.Printf("tls config missing for incoming connection")
+				logger.Warn("tls_config_missing")
 				return nil, errors.New("tls config missing")
 			}
+			snap.TLSTicketKeys.Apply(snap.TLSConfig)
 			return snap.TLSConfig, nil
 		},
 	}
 }
 
+func ReadinessHandler(store *runtime.Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var snap *runtime.Snapshot
+		if store != nil {
+			snap = store.Get()
+		}
+		if snap == nil || snap.FailSafe {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"ready": false})
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"ready": true, "version": snap.Version})
+	})
+}
+
 func StartServers(handler http.Handler, tlsCfg *tls.Config, httpAddr string, tlsAddr string, options Options) (*Server, error) {
 	if handler == nil {
 		return nil, errors.New("handler is nil")
@@ -78,22 +114,25 @@ func StartServers(handler http.Handler, tlsCfg *tls.Config, httpAddr string, tls
 	var tlsSrv *http.Server
 	var httpLn net.Listener
 	var tlsLn net.Listener
+	var h3Server *http3.Server
+	var h3Conn net.PacketConn
 
 	if httpAddr != "" {
-		ln, err := net.Listen("tcp", httpAddr)
+		ln, err := listen(listenerName(options.Name, "http"), httpAddr, limitConfig.Socket)
 		if err != nil {
 			return nil, err
 		}
 		httpLn = ln
 		httpSrv = &http.Server{
-			Handler:           handler,
+			Handler:           limitHandler("http", handler, limitConfig),
 			MaxHeaderBytes:    limitConfig.MaxHeaderBytes,
 			ReadHeaderTimeout: limitConfig.ReadHeaderTimeout,
 			ReadTimeout:       limitConfig.ReadTimeout,
 			WriteTimeout:      limitConfig.WriteTimeout,
 			IdleTimeout:       limitConfig.IdleTimeout,
 		}
-		go serve(httpSrv, httpLn)
+		configureConnections(httpSrv, "http", limitConfig)
+		go serve(httpSrv, limitListener("http", httpLn, limitConfig))
 	}
 
 	if tlsAddr != "" {
@@ -103,7 +142,7 @@ func StartServers(handler http.Handler, tlsCfg *tls.Config, httpAddr string, tls
 			}
 			return nil, errors.New("tls config is required")
 		}
-		ln, err := net.Listen("tcp", tlsAddr)
+		ln, err := listen(listenerName(options.Name, "tls"), tlsAddr, limitConfig.Socket)
 		if err != nil {
 			if httpLn != nil {
 				_ = httpLn.Close()
@@ -111,34 +150,109 @@ func StartServers(handler http.Handler, tlsCfg *tls.Config, httpAddr string, tls
 			return nil, err
 		}
 		tlsLn = ln
+		tlsHandler := handler
+		if options.HTTP3.Enabled {
+			h3Addr := options.HTTP3.Addr
+			if h3Addr == "" {
+				h3Addr = tlsLn.Addr().String()
+			}
+			h3Server, h3Conn, err = startHTTP3(handler, tlsCfg, listenerName(options.Name, "h3"), h3Addr, limitConfig)
+			if err != nil {
+				_ = tlsLn.Close()
+				if httpLn != nil {
+					_ = httpLn.Close()
+				}
+				return nil, err
+			}
+			if options.HTTP3.AltSvc {
+				tlsHandler = altSvcHandler(handler, h3Conn, options.HTTP3.AltSvcMaxAge)
+			}
+		}
 		tlsSrv = &http.Server{
-			Handler:           handler,
+			Handler:           limitHandler("tls", tlsHandler, limitConfig),
+			ConnContext:       fingerprint.ConnContext,
 			MaxHeaderBytes:    limitConfig.MaxHeaderBytes,
 			ReadHeaderTimeout: limitConfig.ReadHeaderTimeout,
 			ReadTimeout:       limitConfig.ReadTimeout,
 			WriteTimeout:      limitConfig.WriteTimeout,
 			IdleTimeout:       limitConfig.IdleTimeout,
 		}
-		go serve(tlsSrv, tls.NewListener(tlsLn, tlsCfg))
+		configureConnections(tlsSrv, "tls", limitConfig)
+		go serve(tlsSrv, newHandshakeListener("tls", fingerprint.NewListener(limitListener("tls", tlsLn, limitConfig)), tlsCfg, limitConfig))
 	}
 
-	if httpLn == nil && tlsLn == nil {
+	if httpLn == nil && tlsLn == nil && len(options.Listeners) == 0 {
 		return nil, errors.New("no listeners configured")
 	}
+	if options.HTTP3.Enabled && tlsLn == nil {
+		if httpLn != nil {
+			_ = httpLn.Close()
+		}
+		return nil, errors.New("http3 requires a tls listener")
+	}
 
-	return &Server{
-		HTTPAddr:   addrString(httpLn),
-		TLSAddr:    addrString(tlsLn),
-		httpServer: httpSrv,
-		tlsServer:  tlsSrv,
-		httpLn:     httpLn,
-		tlsLn:      tlsLn,
-		limits:     limitConfig,
-		shutdown:   shutdownConfig,
-		inflight:   options.Inflight,
-		stoppers:   options.Stoppers,
-		closeIdle:  options.CloseIdle,
-	}, nil
+	srv := &Server{
+		HTTPAddr:      addrString(httpLn),
+		TLSAddr:       addrString(tlsLn),
+		HTTP3Addr:     packetAddrString(h3Conn),
+		ListenerAddrs: make(map[string]string, len(options.Listeners)),
+		httpServer:    httpSrv,
+		tlsServer:     tlsSrv,
+		httpLn:        httpLn,
+		tlsLn:         tlsLn,
+		h3Server:      h3Server,
+		h3Conn:        h3Conn,
+		limits:        limitConfig,
+		shutdown:      shutdownConfig,
+		inflight:      options.Inflight,
+		stoppers:      options.Stoppers,
+		closeIdle:     options.CloseIdle,
+	}
+	for _, spec := range options.Listeners {
+		named, err := startNamed(handler, tlsCfg, listenerName(options.Name, "listener."+spec.Name), spec)
+		if err != nil {
+			srv.closeListeners()
+			srv.closeServers()
+			return nil, fmt.Errorf("listener %s: %w", spec.Name, err)
+		}
+		srv.named = append(srv.named, named)
+		srv.ListenerAddrs[spec.Name] = addrString(named.ln)
+	}
+	return srv, nil
+}
+
+func startNamed(handler http.Handler, tlsCfg *tls.Config, handoffName string, spec runtime.Listener) (*namedServer, error) {
+	if spec.TLS && tlsCfg == nil {
+		return nil, errors.New("tls config is required")
+	}
+	limitConfig := spec.Limits
+	if limitConfig.MaxHeaderBytes == 0 {
+		limitConfig = limits.Default()
+	}
+	ln, err := listen(handoffName, spec.Addr, limitConfig.Socket)
+	if err != nil {
+		return nil, err
+	}
+	name := spec.Name
+	httpSrv := &http.Server{
+		Handler: limitHandler(name, handler, limitConfig),
+		BaseContext: func(net.Listener) context.Context {
+			return router.WithListener(context.Background(), name)
+		},
+		MaxHeaderBytes:    limitConfig.MaxHeaderBytes,
+		ReadHeaderTimeout: limitConfig.ReadHeaderTimeout,
+		ReadTimeout:       limitConfig.ReadTimeout,
+		WriteTimeout:      limitConfig.WriteTimeout,
+		IdleTimeout:       limitConfig.IdleTimeout,
+	}
+	served := limitListener(name, ln, limitConfig)
+	if spec.TLS {
+		httpSrv.ConnContext = fingerprint.ConnContext
+		served = newHandshakeListener(name, fingerprint.NewListener(served), tlsCfg, limitConfig)
+	}
+	configureConnections(httpSrv, name, limitConfig)
+	go serve(httpSrv, served)
+	return &namedServer{server: httpSrv, ln: ln}, nil
 }
 
 func serve(server *http.Server, ln net.Listener) {
@@ -146,7 +260,7 @@ func serve(server *http.Server, ln net.Listener) {
 		return
 	}
 	if err := server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
-		log.Printf("server error: %v", err)
+		logger.Error("server_error", "reason", err)
 	}
 }
 
@@ -157,6 +271,20 @@ func addrString(ln net.Listener) string {
 	return ln.Addr().String()
 }
 
+func packetAddrString(conn net.PacketConn) string {
+	if conn == nil {
+		return ""
+	}
+	return conn.LocalAddr().String()
+}
+
+func listenerName(prefix string, kind string) string {
+	if prefix == "" {
+		return kind
+	}
+	return prefix + "." + kind
+}
+
 func (s *Server) Close() error {
 	if s == nil {
 		return nil
@@ -212,6 +340,15 @@ func (s *Server) shutdownSequence() error {
 			firstErr = err
 		}
 	}
+	for _, named := range s.named {
+		if err := named.server.Shutdown(gracefulCtx); err != nil && !errors.Is(err, http.ErrServerClosed) && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if s.h3Server != nil {
+		_ = s.h3Server.Close()
+		_ = s.h3Conn.Close()
+	}
 	if gracefulCtx.Err() == nil {
 		return firstErr
 	}
@@ -233,6 +370,14 @@ func (s *Server) closeListeners() {
 	if s.tlsLn != nil {
 		_ = s.tlsLn.Close()
 	}
+	for _, named := range s.named {
+		_ = named.ln.Close()
+	}
+	if s.h3Server != nil {
+		go func() {
+			_ = s.h3Server.Shutdown(context.Background())
+		}()
+	}
 }
 
 func (s *Server) closeServers() {
@@ -242,6 +387,13 @@ func (s *Server) closeServers() {
 	if s.tlsServer != nil {
 		_ = s.tlsServer.Close()
 	}
+	for _, named := range s.named {
+		_ = named.server.Close()
+	}
+	if s.h3Server != nil {
+		_ = s.h3Server.Close()
+		_ = s.h3Conn.Close()
+	}
 }
 
 func RequireBearerToken(handler http.Handler, requireToken bool, token string) http.Handler {
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"modern_reverse_proxy/internal/handoff"
+	"modern_reverse_proxy/internal/limits"
+)
+
+func listen(name string, addr string, socket limits.Socket) (net.Listener, error) {
+	ln, err := handoff.ListenWith(name, addr, &net.ListenConfig{Control: socketControl(socket)})
+	if err != nil {
+		return nil, err
+	}
+	if socket.Backlog > 0 {
+		if err := setBacklog(ln, socket.Backlog); err != nil {
+			_ = ln.Close()
+			return nil, err
+		}
+	}
+	if socket.KeepAlive == 0 && socket.NoDelay {
+		return ln, nil
+	}
+	return &tunedListener{Listener: ln, keepAlive: socket.KeepAlive, noDelay: socket.NoDelay}, nil
+}
+
+func socketControl(socket limits.Socket) func(network string, address string, conn syscall.RawConn) error {
+	if !socket.ReusePort && socket.DeferAccept <= 0 {
+		return nil
+	}
+	return func(network string, address string, conn syscall.RawConn) error {
+		var sockErr error
+		err := conn.Control(func(fd uintptr) {
+			if socket.ReusePort {
+				if err := unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1); err != nil {
+					sockErr = fmt.Errorf("reuse_port: %w", err)
+					return
+				}
+			}
+			if socket.DeferAccept > 0 {
+				sockErr = setDeferAccept(int(fd), socket.DeferAccept)
+			}
+		})
+		if err != nil {
+			return err
+		}
+		return sockErr
+	}
+}
+
+func setBacklog(ln net.Listener, backlog int) error {
+	conn, ok := ln.(syscall.Conn)
+	if !ok {
+		return nil
+	}
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var listenErr error
+	if err := raw.Control(func(fd uintptr) {
+		listenErr = unix.Listen(int(fd), backlog)
+	}); err != nil {
+		return err
+	}
+	if listenErr != nil {
+		return fmt.Errorf("backlog: %w", listenErr)
+	}
+	return nil
+}
+
+type tunedListener struct {
+	net.Listener
+	keepAlive time.Duration
+	noDelay   bool
+}
+
+func (l *tunedListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		if l.keepAlive < 0 {
+			_ = tcpConn.SetKeepAlive(false)
+		} else if l.keepAlive > 0 {
+			_ = tcpConn.SetKeepAlive(true)
+			_ = tcpConn.SetKeepAlivePeriod(l.keepAlive)
+		}
+		if !l.noDelay {
+			_ = tcpConn.SetNoDelay(false)
+		}
+	}
+	return conn, nil
+}
@@ -0,0 +1,16 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func setDeferAccept(fd int, timeout time.Duration) error {
+	seconds := int((timeout + time.Second - 1) / time.Second)
+	if err := unix.SetsockoptInt(fd, unix.IPPROTO_TCP, unix.TCP_DEFER_ACCEPT, seconds); err != nil {
+		return fmt.Errorf("defer_accept: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package server
+
+import (
+	"errors"
+	"time"
+)
+
+func setDeferAccept(fd int, timeout time.Duration) error {
+	return errors.New("defer_accept is only supported on linux")
+}
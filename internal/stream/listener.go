@@ -0,0 +1,303 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"modern_reverse_proxy/internal/fingerprint"
+	"modern_reverse_proxy/internal/handoff"
+	"modern_reverse_proxy/internal/logging"
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/outlier"
+	"modern_reverse_proxy/internal/policy"
+	"modern_reverse_proxy/internal/pool"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+)
+
+var logger = logging.For("stream")
+
+const (
+	helloTimeout     = 10 * time.Second
+	maxDialAttempts  = 2
+	copyBufferSize   = 32 * 1024
+	resultProxied    = "proxied"
+	resultNoRoute    = "no_route"
+	resultNoEndpoint = "no_endpoint"
+	resultDialError  = "dial_error"
+	resultSNIError   = "sni_error"
+)
+
+var errNoEndpoint = errors.New("no endpoint available")
+
+type Config struct {
+	ID              string
+	Addr            string
+	Store           *runtime.Store
+	Registry        *registry.Registry
+	OutlierRegistry *outlier.Registry
+	Metrics         *obs.Metrics
+}
+
+type Listener struct {
+	cfg      Config
+	ln       net.Listener
+	mu       sync.Mutex
+	conns    map[net.Conn]struct{}
+	wg       sync.WaitGroup
+	closed   chan struct{}
+	stopOnce sync.Once
+}
+
+func Listen(cfg Config) (*Listener, error) {
+	ln, err := handoff.Listen("stream."+cfg.ID, cfg.Addr)
+	if err != nil {
+		return nil, err
+	}
+	l := &Listener{
+		cfg:    cfg,
+		ln:     ln,
+		conns:  make(map[net.Conn]struct{}),
+		closed: make(chan struct{}),
+	}
+	go l.serve()
+	return l, nil
+}
+
+func (l *Listener) Addr() string {
+	return l.ln.Addr().String()
+}
+
+func (l *Listener) Stop(ctx context.Context) error {
+	l.closeListener()
+	done := make(chan struct{})
+	go func() {
+		l.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+	l.mu.Lock()
+	for conn := range l.conns {
+		_ = conn.Close()
+	}
+	l.mu.Unlock()
+	<-done
+	return ctx.Err()
+}
+
+func (l *Listener) closeListener() {
+	l.stopOnce.Do(func() {
+		close(l.closed)
+		_ = l.ln.Close()
+	})
+}
+
+func (l *Listener) serve() {
+	var backoff time.Duration
+	for {
+		conn, err := l.ln.Accept()
+		if err != nil {
+			select {
+			case <-l.closed:
+				return
+			default:
+			}
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			backoff = min(max(2*backoff, 5*time.Millisecond), time.Second)
+			logger.Warn("stream_accept_error", "listener", l.cfg.ID, "err", err, "retry_in", backoff)
+			select {
+			case <-time.After(backoff):
+				continue
+			case <-l.closed:
+				return
+			}
+		}
+		backoff = 0
+		l.track(conn, true)
+		l.wg.Add(1)
+		go func() {
+			defer l.wg.Done()
+			defer l.track(conn, false)
+			l.handle(conn)
+		}()
+	}
+}
+
+func (l *Listener) track(conn net.Conn, open bool) {
+	l.mu.Lock()
+	if open {
+		l.conns[conn] = struct{}{}
+	} else {
+		delete(l.conns, conn)
+	}
+	l.mu.Unlock()
+	if open {
+		l.cfg.Metrics.AddStreamActive(l.cfg.ID, 1)
+	} else {
+		l.cfg.Metrics.AddStreamActive(l.cfg.ID, -1)
+	}
+}
+
+func (l *Listener) handle(conn net.Conn) {
+	defer conn.Close()
+
+	var streamPolicy policy.Stream
+	ok := false
+	if snap := l.cfg.Store.Get(); snap != nil {
+		streamPolicy, ok = snap.Streams[l.cfg.ID]
+	}
+	if !ok {
+		l.cfg.Metrics.RecordStreamConnection(l.cfg.ID, "", resultNoRoute)
+		return
+	}
+
+	var prefix []byte
+	serverName := ""
+	if len(streamPolicy.SNIRoutes) > 0 {
+		_ = conn.SetReadDeadline(time.Now().Add(helloTimeout))
+		var err error
+		serverName, prefix, err = fingerprint.PeekServerName(conn)
+		_ = conn.SetReadDeadline(time.Time{})
+		if err != nil && !errors.Is(err, fingerprint.ErrNotTLS) {
+			l.cfg.Metrics.RecordStreamConnection(l.cfg.ID, "", resultSNIError)
+			logger.Info("stream_rejected", "listener", l.cfg.ID, "client", conn.RemoteAddr().String(), "result", resultSNIError, "err", err)
+			return
+		}
+	}
+
+	target, ok := streamPolicy.Resolve(serverName)
+	if !ok {
+		l.cfg.Metrics.RecordStreamConnection(l.cfg.ID, "", resultNoRoute)
+		logger.Info("stream_rejected", "listener", l.cfg.ID, "client", conn.RemoteAddr().String(), "sni", serverName, "result", resultNoRoute)
+		return
+	}
+
+	upstream, addr, err := l.dial(streamPolicy, target)
+	if err != nil {
+		result := resultDialError
+		if errors.Is(err, errNoEndpoint) {
+			result = resultNoEndpoint
+		}
+		l.cfg.Metrics.RecordStreamConnection(l.cfg.ID, target.PoolName, result)
+		logger.Info("stream_rejected", "listener", l.cfg.ID, "client", conn.RemoteAddr().String(), "sni", serverName, "pool", target.PoolName, "result", result, "err", err)
+		return
+	}
+	defer upstream.Close()
+	l.cfg.Metrics.RecordStreamConnection(l.cfg.ID, target.PoolName, resultProxied)
+
+	poolKey := pool.PoolKey(target.PoolName)
+	drainCtx, done := l.cfg.Registry.Track(poolKey, addr)
+	defer done()
+	if drainCtx != nil {
+		stop := context.AfterFunc(drainCtx, func() {
+			_ = conn.Close()
+			_ = upstream.Close()
+		})
+		defer stop()
+	}
+
+	if len(prefix) > 0 {
+		if _, err := upstream.Write(prefix); err != nil {
+			return
+		}
+		l.cfg.Metrics.RecordStreamBytes(l.cfg.ID, "upstream", int64(len(prefix)))
+	}
+
+	var lastActive atomic.Int64
+	lastActive.Store(time.Now().UnixNano())
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		n := relay(upstream, conn, streamPolicy.IdleTimeout, &lastActive)
+		l.cfg.Metrics.RecordStreamBytes(l.cfg.ID, "upstream", n)
+	}()
+	go func() {
+		defer wg.Done()
+		n := relay(conn, upstream, streamPolicy.IdleTimeout, &lastActive)
+		l.cfg.Metrics.RecordStreamBytes(l.cfg.ID, "downstream", n)
+	}()
+	wg.Wait()
+}
+
+func (l *Listener) dial(streamPolicy policy.Stream, target policy.StreamTarget) (net.Conn, string, error) {
+	poolKey := pool.PoolKey(target.PoolName)
+	ejected := func(addr string, now time.Time) bool {
+		return l.cfg.OutlierRegistry.IsEjected(target.OutlierKey, addr, now)
+	}
+	var lastErr error = errNoEndpoint
+	for attempt := 0; attempt < maxDialAttempts; attempt++ {
+		pick, ok := l.cfg.Registry.Pick(poolKey, ejected)
+		if !ok || pick.Addr == "" {
+			return nil, "", lastErr
+		}
+		start := time.Now()
+		upstream, err := net.DialTimeout("tcp", pick.Addr, streamPolicy.ConnectTimeout)
+		if err == nil {
+			l.cfg.Registry.PassiveSuccess(poolKey, pick.Addr)
+			l.cfg.OutlierRegistry.RecordResult(target.OutlierKey, pick.Addr, true, time.Since(start))
+			return upstream, pick.Addr, nil
+		}
+		l.cfg.Registry.PassiveFailure(poolKey, pick.Addr)
+		l.cfg.OutlierRegistry.RecordResult(target.OutlierKey, pick.Addr, false, 0)
+		lastErr = err
+	}
+	return nil, "", lastErr
+}
+
+func relay(dst net.Conn, src net.Conn, idleTimeout time.Duration, lastActive *atomic.Int64) int64 {
+	buffer := make([]byte, copyBufferSize)
+	var total int64
+	for {
+		if idleTimeout > 0 {
+			_ = src.SetReadDeadline(time.Now().Add(idleTimeout))
+		}
+		n, readErr := src.Read(buffer)
+		if n > 0 {
+			lastActive.Store(time.Now().UnixNano())
+			if idleTimeout > 0 {
+				_ = dst.SetWriteDeadline(time.Now().Add(idleTimeout))
+			}
+			written, writeErr := dst.Write(buffer[:n])
+			total += int64(written)
+			if writeErr != nil {
+				_ = src.Close()
+				return total
+			}
+		}
+		if readErr != nil {
+			var netErr net.Error
+			if errors.Is(readErr, io.EOF) {
+				closeWrite(dst)
+			} else if errors.As(readErr, &netErr) && netErr.Timeout() {
+				if time.Since(time.Unix(0, lastActive.Load())) < idleTimeout {
+					continue
+				}
+				_ = src.Close()
+				_ = dst.Close()
+			} else {
+				_ = dst.Close()
+			}
+			return total
+		}
+	}
+}
+
+func closeWrite(conn net.Conn) {
+	if halfCloser, ok := conn.(interface{ CloseWrite() error }); ok {
+		_ = halfCloser.CloseWrite()
+		return
+	}
+	_ = conn.Close()
+}
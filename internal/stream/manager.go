@@ -0,0 +1,116 @@
+package stream
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"modern_reverse_proxy/internal/obs"
+	"modern_reverse_proxy/internal/outlier"
+	"modern_reverse_proxy/internal/registry"
+	"modern_reverse_proxy/internal/runtime"
+)
+
+const retireTimeout = 30 * time.Second
+
+type ManagerConfig struct {
+	Store           *runtime.Store
+	Registry        *registry.Registry
+	OutlierRegistry *outlier.Registry
+	Metrics         *obs.Metrics
+}
+
+type Manager struct {
+	cfg       ManagerConfig
+	mu        sync.Mutex
+	listeners map[string]*Listener
+	stopped   bool
+}
+
+func NewManager(cfg ManagerConfig) *Manager {
+	return &Manager{cfg: cfg, listeners: make(map[string]*Listener)}
+}
+
+func (m *Manager) Reconcile(snap *runtime.Snapshot) error {
+	if m == nil || snap == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stopped {
+		return nil
+	}
+	for id, listener := range m.listeners {
+		if streamPolicy, ok := snap.Streams[id]; ok && streamPolicy.Addr == listener.cfg.Addr {
+			continue
+		}
+		delete(m.listeners, id)
+		logger.Info("stream_listener_removed", "stream", id, "addr", listener.Addr())
+		listener.closeListener()
+		go func(listener *Listener) {
+			ctx, cancel := context.WithTimeout(context.Background(), retireTimeout)
+			defer cancel()
+			_ = listener.Stop(ctx)
+		}(listener)
+	}
+	ids := make([]string, 0, len(snap.Streams))
+	for id := range snap.Streams {
+		if _, ok := m.listeners[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	var firstErr error
+	for _, id := range ids {
+		listener, err := Listen(Config{
+			ID:              id,
+			Addr:            snap.Streams[id].Addr,
+			Store:           m.cfg.Store,
+			Registry:        m.cfg.Registry,
+			OutlierRegistry: m.cfg.OutlierRegistry,
+			Metrics:         m.cfg.Metrics,
+		})
+		if err != nil {
+			logger.Warn("stream_listen_error", "stream", id, "addr", snap.Streams[id].Addr, "err", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		logger.Info("listening", "scheme", "tcp", "addr", listener.Addr(), "stream", id)
+		m.listeners[id] = listener
+	}
+	return firstErr
+}
+
+func (m *Manager) Addr(id string) (string, bool) {
+	if m == nil {
+		return "", false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	listener, ok := m.listeners[id]
+	if !ok {
+		return "", false
+	}
+	return listener.Addr(), true
+}
+
+func (m *Manager) Stop(ctx context.Context) error {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	m.stopped = true
+	listeners := m.listeners
+	m.listeners = make(map[string]*Listener)
+	m.mu.Unlock()
+	var firstErr error
+	for _, listener := range listeners {
+		if err := listener.Stop(ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
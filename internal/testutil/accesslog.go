@@ -0,0 +1,56 @@
+package testutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"modern_reverse_proxy/internal/obs"
+)
+
+type AccessLogCapture struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func CaptureAccessLogs(t *testing.T) *AccessLogCapture {
+	t.Helper()
+	capture := &AccessLogCapture{}
+	obs.SetAccessLogOutput(capture)
+	t.Cleanup(func() {
+		obs.SetAccessLogOutput(nil)
+	})
+	return capture
+}
+
+func (c *AccessLogCapture) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.buf.Write(p)
+}
+
+func (c *AccessLogCapture) Entries(t *testing.T) []map[string]interface{} {
+	t.Helper()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(c.buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("decode access log %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func (c *AccessLogCapture) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.buf.Reset()
+}
@@ -8,6 +8,7 @@ import (
 	"crypto/x509/pkix"
 	"encoding/pem"
 	"math/big"
+	"net/url"
 	"os"
 	"path/filepath"
 	"testing"
@@ -89,6 +90,33 @@ func WriteClientCert(t *testing.T, commonName string, ca CA) CertFiles {
 	}
 }
 
+func WriteSVID(t *testing.T, spiffeID string, dnsNames []string, ca CA) CertFiles {
+	t.Helper()
+	id, err := url.Parse(spiffeID)
+	if err != nil {
+		t.Fatalf("parse spiffe id: %v", err)
+	}
+	key := generateKey(t)
+	template := baseTemplate("", dnsNames, false)
+	template.URIs = []*url.URL{id}
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+
+	der := createCertificate(t, template, ca.Cert, &key.PublicKey, ca.Key)
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse svid: %v", err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := marshalKey(t, key)
+
+	return CertFiles{
+		Cert:     cert,
+		CertFile: writeTempFile(t, "svid.pem", certPEM),
+		KeyFile:  writeTempFile(t, "svid.key", keyPEM),
+	}
+}
+
 func WriteServerCert(t *testing.T, serverName string, ca CA) CertFiles {
 	t.Helper()
 	key := generateKey(t)
@@ -164,3 +192,25 @@ func baseTemplate(commonName string, dnsNames []string, isCA bool) *x509.Certifi
 		BasicConstraintsValid: true,
 	}
 }
+
+func WriteACMECacheCert(t *testing.T, cacheDir string, serverName string) *x509.Certificate {
+	t.Helper()
+	key := generateKey(t)
+	template := baseTemplate(serverName, []string{serverName}, true)
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	template.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign
+
+	der := createCertificate(t, template, template, &key.PublicKey, key)
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse cert: %v", err)
+	}
+	data := append(marshalKey(t, key), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	if err := os.MkdirAll(cacheDir, 0o700); err != nil {
+		t.Fatalf("create acme cache dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(cacheDir, serverName), data, 0o600); err != nil {
+		t.Fatalf("write acme cache entry: %v", err)
+	}
+	return cert
+}
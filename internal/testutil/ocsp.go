@@ -0,0 +1,147 @@
+package testutil
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+type OCSPResponder struct {
+	URL string
+
+	ca       CA
+	server   *httptest.Server
+	mu       sync.Mutex
+	statuses map[string]int
+	requests atomic.Int64
+}
+
+func StartOCSPResponder(t *testing.T, ca CA) *OCSPResponder {
+	t.Helper()
+	responder := &OCSPResponder{ca: ca, statuses: make(map[string]int)}
+	responder.server = httptest.NewServer(http.HandlerFunc(responder.respond))
+	responder.URL = responder.server.URL
+	return responder
+}
+
+func (o *OCSPResponder) SetStatus(serial *big.Int, status int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.statuses[serial.String()] = status
+}
+
+func (o *OCSPResponder) Requests() int64 {
+	return o.requests.Load()
+}
+
+func (o *OCSPResponder) Close() {
+	o.server.Close()
+}
+
+func (o *OCSPResponder) respond(w http.ResponseWriter, r *http.Request) {
+	o.requests.Add(1)
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "read request", http.StatusBadRequest)
+		return
+	}
+	request, err := ocsp.ParseRequest(body)
+	if err != nil {
+		http.Error(w, "parse request", http.StatusBadRequest)
+		return
+	}
+	o.mu.Lock()
+	status, ok := o.statuses[request.SerialNumber.String()]
+	o.mu.Unlock()
+	if !ok {
+		status = ocsp.Good
+	}
+	now := time.Now()
+	template := ocsp.Response{
+		Status:       status,
+		SerialNumber: request.SerialNumber,
+		ThisUpdate:   now.Add(-time.Minute),
+		NextUpdate:   now.Add(time.Hour),
+	}
+	if status == ocsp.Revoked {
+		template.RevokedAt = now.Add(-time.Minute)
+	}
+	response, err := ocsp.CreateResponse(o.ca.Cert, o.ca.Cert, template, o.ca.Key)
+	if err != nil {
+		http.Error(w, "create response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/ocsp-response")
+	_, _ = w.Write(response)
+}
+
+func WriteClientCertWithOCSP(t *testing.T, commonName string, ca CA, ocspURL string) CertFiles {
+	t.Helper()
+	key := generateKey(t)
+	template := baseTemplate(commonName, nil, false)
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.OCSPServer = []string{ocspURL}
+
+	der := createCertificate(t, template, ca.Cert, &key.PublicKey, ca.Key)
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse client cert: %v", err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	return CertFiles{
+		Cert:     cert,
+		CertFile: writeTempFile(t, "client.pem", certPEM),
+		KeyFile:  writeTempFile(t, "client.key", marshalKey(t, key)),
+	}
+}
+
+func WriteServerCertChain(t *testing.T, serverName string, ca CA, ocspURL string) CertFiles {
+	t.Helper()
+	key := generateKey(t)
+	template := baseTemplate(serverName, []string{serverName}, false)
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.OCSPServer = []string{ocspURL}
+
+	der := createCertificate(t, template, ca.Cert, &key.PublicKey, ca.Key)
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse server cert: %v", err)
+	}
+	chainPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	chainPEM = append(chainPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Cert.Raw})...)
+	return CertFiles{
+		Cert:     cert,
+		CertFile: writeTempFile(t, "server-chain.pem", chainPEM),
+		KeyFile:  writeTempFile(t, "server.key", marshalKey(t, key)),
+	}
+}
+
+func WriteCRL(t *testing.T, ca CA, revoked ...*big.Int) string {
+	t.Helper()
+	entries := make([]x509.RevocationListEntry, 0, len(revoked))
+	for _, serial := range revoked {
+		entries = append(entries, x509.RevocationListEntry{SerialNumber: serial, RevocationTime: time.Now().Add(-time.Minute)})
+	}
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:                    big.NewInt(time.Now().UnixNano()),
+		ThisUpdate:                time.Now().Add(-time.Minute),
+		NextUpdate:                time.Now().Add(time.Hour),
+		RevokedCertificateEntries: entries,
+	}, ca.Cert, ca.Key)
+	if err != nil {
+		t.Fatalf("create crl: %v", err)
+	}
+	return writeTempFile(t, "ca.crl", pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der}))
+}
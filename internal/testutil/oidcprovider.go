@@ -0,0 +1,194 @@
+package testutil
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type OIDCProvider struct {
+	URL      string
+	ClientID string
+	Subject  string
+	Email    string
+
+	key       *rsa.PrivateKey
+	server    *httptest.Server
+	mu        sync.Mutex
+	codes     map[string]oidcGrant
+	refreshes atomic.Int64
+}
+
+type oidcGrant struct {
+	nonce       string
+	challenge   string
+	redirectURI string
+}
+
+func StartOIDCProvider(t *testing.T, clientID string) *OIDCProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate oidc key: %v", err)
+	}
+	provider := &OIDCProvider{
+		ClientID: clientID,
+		Subject:  "user-123",
+		Email:    "user@example.com",
+		key:      key,
+		codes:    make(map[string]oidcGrant),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", provider.discovery)
+	mux.HandleFunc("/jwks", provider.jwks)
+	mux.HandleFunc("/authorize", provider.authorize)
+	mux.HandleFunc("/token", provider.token)
+	provider.server = httptest.NewServer(mux)
+	provider.URL = provider.server.URL
+	return provider
+}
+
+func (p *OIDCProvider) Close() {
+	p.server.Close()
+}
+
+func (p *OIDCProvider) Refreshes() int64 {
+	return p.refreshes.Load()
+}
+
+func (p *OIDCProvider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeOIDCJSON(w, map[string]string{
+		"issuer":                 p.URL,
+		"authorization_endpoint": p.URL + "/authorize",
+		"token_endpoint":         p.URL + "/token",
+		"jwks_uri":               p.URL + "/jwks",
+	})
+}
+
+func (p *OIDCProvider) jwks(w http.ResponseWriter, r *http.Request) {
+	writeOIDCJSON(w, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test-key",
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+		}},
+	})
+}
+
+func (p *OIDCProvider) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client_id") != p.ClientID || query.Get("response_type") != "code" || query.Get("code_challenge_method") != "S256" {
+		http.Error(w, "bad authorize request", http.StatusBadRequest)
+		return
+	}
+	code := fmt.Sprintf("code-%d", time.Now().UnixNano())
+	p.mu.Lock()
+	p.codes[code] = oidcGrant{
+		nonce:       query.Get("nonce"),
+		challenge:   query.Get("code_challenge"),
+		redirectURI: query.Get("redirect_uri"),
+	}
+	p.mu.Unlock()
+	target, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil {
+		http.Error(w, "bad redirect_uri", http.StatusBadRequest)
+		return
+	}
+	values := target.Query()
+	values.Set("code", code)
+	values.Set("state", query.Get("state"))
+	target.RawQuery = values.Encode()
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
+
+func (p *OIDCProvider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("client_id") != p.ClientID {
+		http.Error(w, "invalid_client", http.StatusUnauthorized)
+		return
+	}
+	nonce := ""
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		p.mu.Lock()
+		grant, ok := p.codes[r.PostForm.Get("code")]
+		delete(p.codes, r.PostForm.Get("code"))
+		p.mu.Unlock()
+		verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if !ok || grant.redirectURI != r.PostForm.Get("redirect_uri") || base64.RawURLEncoding.EncodeToString(verifier[:]) != grant.challenge {
+			http.Error(w, "invalid_grant", http.StatusBadRequest)
+			return
+		}
+		nonce = grant.nonce
+	case "refresh_token":
+		if r.PostForm.Get("refresh_token") != "refresh-"+p.Subject {
+			http.Error(w, "invalid_grant", http.StatusBadRequest)
+			return
+		}
+		p.refreshes.Add(1)
+	default:
+		http.Error(w, "unsupported_grant_type", http.StatusBadRequest)
+		return
+	}
+	idToken, err := p.signIDToken(nonce)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeOIDCJSON(w, map[string]interface{}{
+		"access_token":  "access-" + p.Subject,
+		"id_token":      idToken,
+		"refresh_token": "refresh-" + p.Subject,
+		"token_type":    "Bearer",
+		"expires_in":    3600,
+	})
+}
+
+func (p *OIDCProvider) signIDToken(nonce string) (string, error) {
+	now := time.Now()
+	claims := map[string]interface{}{
+		"iss":   p.URL,
+		"sub":   p.Subject,
+		"aud":   p.ClientID,
+		"email": p.Email,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "kid": "test-key", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func writeOIDCJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(value)
+}
@@ -13,6 +13,7 @@ import (
 type PluginHandlers struct {
 	ApplyRequest  func(context.Context, *pluginpb.ApplyRequestRequest) (*pluginpb.ApplyRequestResponse, error)
 	ApplyResponse func(context.Context, *pluginpb.ApplyResponseRequest) (*pluginpb.ApplyResponseResponse, error)
+	InspectBody   func(pluginpb.FilterService_InspectBodyServer) error
 }
 
 func StartPluginServer(t *testing.T, handlers PluginHandlers) (string, func()) {
@@ -59,3 +60,10 @@ func (p *pluginServer) ApplyRequest(ctx context.Context, req *pluginpb.ApplyRequ
 func (p *pluginServer) ApplyResponse(ctx context.Context, req *pluginpb.ApplyResponseRequest) (*pluginpb.ApplyResponseResponse, error) {
 	return p.handlers.ApplyResponse(ctx, req)
 }
+
+func (p *pluginServer) InspectBody(stream pluginpb.FilterService_InspectBodyServer) error {
+	if p.handlers.InspectBody == nil {
+		return p.UnimplementedFilterServiceServer.InspectBody(stream)
+	}
+	return p.handlers.InspectBody(stream)
+}
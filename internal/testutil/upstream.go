@@ -1,6 +1,10 @@
 package testutil
 
 import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -17,3 +21,32 @@ func StartUpstream(t *testing.T, handler http.Handler) (string, func()) {
 	server := httptest.NewServer(handler)
 	return server.Listener.Addr().String(), server.Close
 }
+
+func StartTCPUpstream(t *testing.T, tlsConfig *tls.Config, banner string) (string, func()) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen tcp upstream: %v", err)
+	}
+	if tlsConfig != nil {
+		ln = tls.NewListener(ln, tlsConfig)
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				scanner := bufio.NewScanner(conn)
+				for scanner.Scan() {
+					if _, err := fmt.Fprintf(conn, "%s:%s\n", banner, scanner.Text()); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String(), func() { _ = ln.Close() }
+}
@@ -0,0 +1,130 @@
+package testutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	wasmInputOffset    = 16384
+	wasmRequestOffset  = 4096
+	wasmResponseOffset = 8192
+)
+
+type WASMFilterSpec struct {
+	RequestOutput  string
+	ResponseOutput string
+	Spin           bool
+}
+
+func WriteWASMFilter(t *testing.T, spec WASMFilterSpec) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "filter.wasm")
+	if err := os.WriteFile(path, BuildWASMFilter(spec), 0o600); err != nil {
+		t.Fatalf("write wasm filter: %v", err)
+	}
+	return path
+}
+
+func BuildWASMFilter(spec WASMFilterSpec) []byte {
+	module := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+
+	types := vec(2,
+		[]byte{0x60, 0x01, 0x7f, 0x01, 0x7f},
+		[]byte{0x60, 0x02, 0x7f, 0x7f, 0x01, 0x7e},
+	)
+	module = append(module, section(1, types)...)
+	module = append(module, section(3, vec(3, []byte{0x00}, []byte{0x01}, []byte{0x01}))...)
+	module = append(module, section(5, vec(1, []byte{0x00, 0x01}))...)
+	module = append(module, section(7, vec(4,
+		exportEntry("memory", 0x02, 0),
+		exportEntry("alloc", 0x00, 0),
+		exportEntry("on_request", 0x00, 1),
+		exportEntry("on_response", 0x00, 2),
+	))...)
+
+	alloc := append([]byte{0x00, 0x41}, sleb(wasmInputOffset)...)
+	alloc = append(alloc, 0x0b)
+	module = append(module, section(10, vec(3,
+		sized(alloc),
+		sized(outputBody(wasmRequestOffset, len(spec.RequestOutput), spec.Spin)),
+		sized(outputBody(wasmResponseOffset, len(spec.ResponseOutput), spec.Spin)),
+	))...)
+
+	module = append(module, section(11, vec(2,
+		dataSegment(wasmRequestOffset, spec.RequestOutput),
+		dataSegment(wasmResponseOffset, spec.ResponseOutput),
+	))...)
+	return module
+}
+
+func outputBody(offset int, size int, spin bool) []byte {
+	body := []byte{0x00}
+	if spin {
+		body = append(body, 0x03, 0x40, 0x0c, 0x00, 0x0b)
+	}
+	body = append(body, 0x42)
+	body = append(body, sleb(int64(offset)<<32|int64(size))...)
+	return append(body, 0x0b)
+}
+
+func dataSegment(offset int, data string) []byte {
+	segment := append([]byte{0x00, 0x41}, sleb(int64(offset))...)
+	segment = append(segment, 0x0b)
+	segment = append(segment, uleb(uint64(len(data)))...)
+	return append(segment, data...)
+}
+
+func exportEntry(name string, kind byte, index int) []byte {
+	entry := append(uleb(uint64(len(name))), name...)
+	entry = append(entry, kind)
+	return append(entry, uleb(uint64(index))...)
+}
+
+func section(id byte, content []byte) []byte {
+	return append([]byte{id}, sized(content)...)
+}
+
+func sized(content []byte) []byte {
+	return append(uleb(uint64(len(content))), content...)
+}
+
+func vec(count int, items ...[]byte) []byte {
+	out := uleb(uint64(count))
+	for _, item := range items {
+		out = append(out, item...)
+	}
+	return out
+}
+
+func uleb(value uint64) []byte {
+	var out []byte
+	for {
+		b := byte(value & 0x7f)
+		value >>= 7
+		if value != 0 {
+			b |= 0x80
+		}
+		out = append(out, b)
+		if value == 0 {
+			return out
+		}
+	}
+}
+
+func sleb(value int64) []byte {
+	var out []byte
+	for {
+		b := byte(value & 0x7f)
+		value >>= 7
+		done := (value == 0 && b&0x40 == 0) || (value == -1 && b&0x40 != 0)
+		if !done {
+			b |= 0x80
+		}
+		out = append(out, b)
+		if done {
+			return out
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package tlsstore
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+const (
+	DefaultACMEDirectory  = autocert.DefaultACMEDirectory
+	ACMEALPNProto         = acme.ALPNProto
+	acmeChallengePrefix   = "/.well-known/acme-challenge/"
+	acmeDirectoryTimeout  = 30 * time.Second
+	defaultACMERenewAhead = 30 * 24 * time.Hour
+)
+
+type ACMESpec struct {
+	Hostnames    []string
+	Email        string
+	DirectoryURL string
+	CacheDir     string
+	HTTP01       bool
+	RenewBefore  time.Duration
+	CARootFile   string
+}
+
+type ACMEManager struct {
+	manager *autocert.Manager
+	handler atomic.Pointer[http.Handler]
+	hosts   atomic.Pointer[map[string]struct{}]
+	http01  atomic.Bool
+}
+
+var acmeManagers = struct {
+	mu       sync.Mutex
+	managers map[string]*ACMEManager
+}{managers: make(map[string]*ACMEManager)}
+
+func ACMEManagerFor(spec ACMESpec) (*ACMEManager, error) {
+	if spec.CacheDir == "" {
+		return nil, errors.New("acme cache_dir is required")
+	}
+	if len(spec.Hostnames) == 0 {
+		return nil, errors.New("acme hostnames are required")
+	}
+	hosts := make(map[string]struct{}, len(spec.Hostnames))
+	for _, host := range spec.Hostnames {
+		host = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(host), "."))
+		if host == "" || strings.Contains(host, "*") || !strings.Contains(host, ".") {
+			return nil, fmt.Errorf("acme hostname %q is invalid", host)
+		}
+		hosts[host] = struct{}{}
+	}
+	directory := spec.DirectoryURL
+	if directory == "" {
+		directory = DefaultACMEDirectory
+	}
+	renewBefore := spec.RenewBefore
+	if renewBefore <= 0 {
+		renewBefore = defaultACMERenewAhead
+	}
+	key := strings.Join([]string{directory, spec.CacheDir, spec.Email, spec.CARootFile, renewBefore.String()}, "|")
+
+	acmeManagers.mu.Lock()
+	defer acmeManagers.mu.Unlock()
+	if existing, ok := acmeManagers.managers[key]; ok {
+		existing.hosts.Store(&hosts)
+		existing.enableHTTP01(spec.HTTP01)
+		return existing, nil
+	}
+
+	if err := os.MkdirAll(spec.CacheDir, 0o700); err != nil {
+		return nil, fmt.Errorf("acme cache_dir: %w", err)
+	}
+	httpClient := &http.Client{Timeout: acmeDirectoryTimeout}
+	if spec.CARootFile != "" {
+		data, err := os.ReadFile(spec.CARootFile)
+		if err != nil {
+			return nil, fmt.Errorf("acme ca_root_file: %w", err)
+		}
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(data) {
+			return nil, errors.New("acme ca_root_file: no certificates found")
+		}
+		httpClient.Transport = &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12},
+		}
+	}
+
+	m := &ACMEManager{}
+	m.hosts.Store(&hosts)
+	m.manager = &autocert.Manager{
+		Prompt:      autocert.AcceptTOS,
+		Cache:       autocert.DirCache(spec.CacheDir),
+		HostPolicy:  m.hostPolicy,
+		RenewBefore: renewBefore,
+		Email:       spec.Email,
+		Client: &acme.Client{
+			DirectoryURL: directory,
+			HTTPClient:   httpClient,
+		},
+	}
+	m.enableHTTP01(spec.HTTP01)
+	acmeManagers.managers[key] = m
+	return m, nil
+}
+
+func (m *ACMEManager) enableHTTP01(enabled bool) {
+	if enabled && m.handler.Load() == nil {
+		handler := m.manager.HTTPHandler(http.NotFoundHandler())
+		m.handler.Store(&handler)
+	}
+	m.http01.Store(enabled)
+}
+
+func (m *ACMEManager) Manages(host string) bool {
+	if m == nil {
+		return false
+	}
+	_, ok := (*m.hosts.Load())[strings.ToLower(strings.TrimSuffix(host, "."))]
+	return ok
+}
+
+func (m *ACMEManager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return m.manager.GetCertificate(hello)
+}
+
+func (m *ACMEManager) ServesChallenge(r *http.Request) bool {
+	return m != nil && m.http01.Load() && r.TLS == nil && strings.HasPrefix(r.URL.Path, acmeChallengePrefix)
+}
+
+func (m *ACMEManager) ServeChallenge(w http.ResponseWriter, r *http.Request) {
+	handler := m.handler.Load()
+	if handler == nil {
+		http.NotFound(w, r)
+		return
+	}
+	(*handler).ServeHTTP(w, r)
+}
+
+func (m *ACMEManager) hostPolicy(_ context.Context, host string) error {
+	if !m.Manages(host) {
+		return fmt.Errorf("acme: host %q not configured", host)
+	}
+	return nil
+}
+
+func isACMEChallengeHello(hello *tls.ClientHelloInfo) bool {
+	if hello == nil {
+		return false
+	}
+	for _, proto := range hello.SupportedProtos {
+		if proto == acme.ALPNProto {
+			return true
+		}
+	}
+	return false
+}
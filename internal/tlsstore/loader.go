@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 )
 
 type CertSpec struct {
@@ -16,12 +17,27 @@ type CertSpec struct {
 }
 
 func LoadStore(certs []CertSpec, clientCAFile string) (*Store, error) {
-	if len(certs) == 0 {
+	return LoadStoreWithACME(certs, clientCAFile, nil)
+}
+
+func LoadStoreWithACME(certs []CertSpec, clientCAFile string, acme *ACMEManager) (*Store, error) {
+	if len(certs) == 0 && acme == nil {
 		return nil, errors.New("no certificates configured")
 	}
+	stamps := fileStamps(certs, clientCAFile)
+	state, err := loadState(certs, clientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	store := &Store{specs: certs, clientCAFile: clientCAFile, stamps: stamps, acme: acme}
+	store.state.Store(state)
+	return store, nil
+}
 
+func loadState(certs []CertSpec, clientCAFile string) (*certState, error) {
 	loaded := make([]tls.Certificate, len(certs))
 	certMap := make(map[string]*tls.Certificate, len(certs))
+	files := make(map[string]string, len(certs))
 	for i, spec := range certs {
 		if spec.ServerName == "" {
 			return nil, errors.New("certificate server name is required")
@@ -33,9 +49,17 @@ func LoadStore(certs []CertSpec, clientCAFile string) (*Store, error) {
 		if err != nil {
 			return nil, fmt.Errorf("load cert for %s: %w", spec.ServerName, err)
 		}
+		if cert.Leaf == nil {
+			leaf, err := x509.ParseCertificate(cert.Certificate[0])
+			if err != nil {
+				return nil, fmt.Errorf("parse cert for %s: %w", spec.ServerName, err)
+			}
+			cert.Leaf = leaf
+		}
 		loaded[i] = cert
 		name := strings.ToLower(spec.ServerName)
 		certMap[name] = &loaded[i]
+		files[name] = spec.CertFile
 	}
 
 	var clientCA *x509.CertPool
@@ -51,5 +75,30 @@ func LoadStore(certs []CertSpec, clientCAFile string) (*Store, error) {
 		clientCA = pool
 	}
 
-	return NewStore(certMap, &loaded[0], clientCA), nil
+	var defaultCert *tls.Certificate
+	var defaultName string
+	if len(loaded) > 0 {
+		defaultCert = &loaded[0]
+		defaultName = strings.ToLower(certs[0].ServerName)
+	}
+	return &certState{certs: certMap, defaultCert: defaultCert, defaultName: defaultName, files: files, clientCA: clientCA}, nil
+}
+
+func fileStamps(certs []CertSpec, clientCAFile string) map[string]time.Time {
+	stamps := make(map[string]time.Time, 2*len(certs)+1)
+	paths := make([]string, 0, 2*len(certs)+1)
+	for _, spec := range certs {
+		paths = append(paths, spec.CertFile, spec.KeyFile)
+	}
+	if clientCAFile != "" {
+		paths = append(paths, clientCAFile)
+	}
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			stamps[path] = info.ModTime()
+		} else {
+			stamps[path] = time.Time{}
+		}
+	}
+	return stamps
 }
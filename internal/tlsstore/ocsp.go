@@ -0,0 +1,208 @@
+package tlsstore
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"modern_reverse_proxy/internal/logging"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+var logger = logging.For("tlsstore")
+
+const (
+	defaultOCSPTimeout     = 5 * time.Second
+	maxOCSPResponseBytes   = 64 << 10
+	ocspRetryInterval      = time.Minute
+	ocspNoResponderRecheck = time.Hour
+)
+
+var ErrNoOCSPResponder = errors.New("certificate has no ocsp responder")
+
+type OCSPObserver func(result string)
+
+type OCSPStapler struct {
+	client  *http.Client
+	observe OCSPObserver
+
+	mu      sync.Mutex
+	entries map[[32]byte]*stapleEntry
+}
+
+type stapleEntry struct {
+	mu        sync.Mutex
+	staple    []byte
+	expiresAt time.Time
+	refreshAt time.Time
+	fetching  bool
+}
+
+var sharedStapler = struct {
+	once    sync.Once
+	stapler *OCSPStapler
+}{}
+
+func SharedOCSPStapler(observe OCSPObserver) *OCSPStapler {
+	sharedStapler.once.Do(func() {
+		sharedStapler.stapler = NewOCSPStapler(defaultOCSPTimeout, observe)
+	})
+	return sharedStapler.stapler
+}
+
+func NewOCSPStapler(timeout time.Duration, observe OCSPObserver) *OCSPStapler {
+	if timeout <= 0 {
+		timeout = defaultOCSPTimeout
+	}
+	return &OCSPStapler{
+		client:  &http.Client{Timeout: timeout},
+		observe: observe,
+		entries: make(map[[32]byte]*stapleEntry),
+	}
+}
+
+func (s *OCSPStapler) Staple(cert *tls.Certificate) *tls.Certificate {
+	if s == nil || cert == nil || len(cert.Certificate) < 2 {
+		return cert
+	}
+	entry := s.entry(cert)
+	now := time.Now()
+	entry.mu.Lock()
+	if !entry.fetching && !now.Before(entry.refreshAt) {
+		entry.fetching = true
+		go s.refresh(entry, cert)
+	}
+	staple := entry.staple
+	if !entry.expiresAt.IsZero() && now.After(entry.expiresAt) {
+		staple = nil
+	}
+	entry.mu.Unlock()
+	if staple == nil {
+		return cert
+	}
+	stapled := *cert
+	stapled.OCSPStaple = staple
+	return &stapled
+}
+
+func (s *OCSPStapler) entry(cert *tls.Certificate) *stapleEntry {
+	key := sha256.Sum256(cert.Certificate[0])
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[key]
+	if !ok {
+		entry = &stapleEntry{}
+		s.entries[key] = entry
+	}
+	return entry
+}
+
+func (s *OCSPStapler) refresh(entry *stapleEntry, cert *tls.Certificate) {
+	result := "good"
+	staple, expiresAt, refreshAt, err := s.fetch(cert)
+	now := time.Now()
+	switch {
+	case errors.Is(err, ErrNoOCSPResponder):
+		result = "no_responder"
+		refreshAt = now.Add(ocspNoResponderRecheck)
+	case err != nil:
+		result = "error"
+		refreshAt = now.Add(ocspRetryInterval)
+		logger.Warn("ocsp_staple", "ocsp_staple_result", "error", "err", err)
+	case staple == nil:
+		result = "not_good"
+	}
+
+	entry.mu.Lock()
+	if err == nil {
+		entry.staple = staple
+		entry.expiresAt = expiresAt
+	}
+	entry.refreshAt = refreshAt
+	entry.fetching = false
+	entry.mu.Unlock()
+	if s.observe != nil {
+		s.observe(result)
+	}
+}
+
+func (s *OCSPStapler) fetch(cert *tls.Certificate) ([]byte, time.Time, time.Time, error) {
+	leaf := cert.Leaf
+	if leaf == nil {
+		parsed, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			return nil, time.Time{}, time.Time{}, err
+		}
+		leaf = parsed
+	}
+	issuer, err := x509.ParseCertificate(cert.Certificate[1])
+	if err != nil {
+		return nil, time.Time{}, time.Time{}, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.client.Timeout)
+	defer cancel()
+	response, raw, err := QueryOCSP(ctx, s.client, leaf, issuer)
+	if err != nil {
+		return nil, time.Time{}, time.Time{}, err
+	}
+	refreshAt := ocspRefreshTime(response)
+	if response.Status != ocsp.Good {
+		return nil, time.Time{}, refreshAt, nil
+	}
+	return raw, response.NextUpdate, refreshAt, nil
+}
+
+func QueryOCSP(ctx context.Context, client *http.Client, leaf *x509.Certificate, issuer *x509.Certificate) (*ocsp.Response, []byte, error) {
+	if len(leaf.OCSPServer) == 0 {
+		return nil, nil, ErrNoOCSPResponder
+	}
+	request, err := ocsp.CreateRequest(leaf, issuer, &ocsp.RequestOptions{Hash: crypto.SHA1})
+	if err != nil {
+		return nil, nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, leaf.OCSPServer[0], bytes.NewReader(request))
+	if err != nil {
+		return nil, nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/ocsp-request")
+	httpReq.Header.Set("Accept", "application/ocsp-response")
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("ocsp responder status %d", resp.StatusCode)
+	}
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxOCSPResponseBytes))
+	if err != nil {
+		return nil, nil, err
+	}
+	response, err := ocsp.ParseResponseForCert(raw, leaf, issuer)
+	if err != nil {
+		return nil, nil, err
+	}
+	return response, raw, nil
+}
+
+func ocspRefreshTime(response *ocsp.Response) time.Time {
+	now := time.Now()
+	if response.NextUpdate.IsZero() {
+		return now.Add(ocspNoResponderRecheck)
+	}
+	refreshAt := response.ThisUpdate.Add(response.NextUpdate.Sub(response.ThisUpdate) / 2)
+	if refreshAt.Before(now.Add(ocspRetryInterval)) {
+		refreshAt = now.Add(ocspRetryInterval)
+	}
+	return refreshAt
+}
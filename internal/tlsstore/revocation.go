@@ -0,0 +1,235 @@
+package tlsstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+const (
+	RevocationGood    = "good"
+	RevocationRevoked = "revoked"
+	RevocationUnknown = "unknown"
+	RevocationError   = "error"
+
+	crlCheckInterval         = time.Second
+	defaultOCSPCacheTTL      = 5 * time.Minute
+	defaultRevocationTimeout = 2 * time.Second
+	maxOCSPCacheEntries      = 10000
+)
+
+type RevocationSpec struct {
+	OCSP     bool
+	CRLFile  string
+	SoftFail bool
+	Timeout  time.Duration
+	CacheTTL time.Duration
+}
+
+type RevocationOutcome struct {
+	Method string
+	Result string
+}
+
+type RevocationChecker struct {
+	ocsp     bool
+	softFail bool
+	timeout  time.Duration
+	cacheTTL time.Duration
+	client   *http.Client
+	crl      *crlSource
+
+	mu    sync.Mutex
+	cache map[[32]byte]ocspCacheEntry
+}
+
+type ocspCacheEntry struct {
+	result  string
+	expires time.Time
+}
+
+type crlSource struct {
+	path string
+
+	mu        sync.Mutex
+	list      *x509.RevocationList
+	revoked   map[string]struct{}
+	verified  map[string]bool
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+func NewRevocationChecker(spec RevocationSpec) (*RevocationChecker, error) {
+	if !spec.OCSP && spec.CRLFile == "" {
+		return nil, errors.New("revocation requires ocsp or crl_file")
+	}
+	timeout := spec.Timeout
+	if timeout <= 0 {
+		timeout = defaultRevocationTimeout
+	}
+	cacheTTL := spec.CacheTTL
+	if cacheTTL <= 0 {
+		cacheTTL = defaultOCSPCacheTTL
+	}
+	checker := &RevocationChecker{
+		ocsp:     spec.OCSP,
+		softFail: spec.SoftFail,
+		timeout:  timeout,
+		cacheTTL: cacheTTL,
+		client:   &http.Client{Timeout: timeout},
+		cache:    make(map[[32]byte]ocspCacheEntry),
+	}
+	if spec.CRLFile != "" {
+		source := &crlSource{path: spec.CRLFile}
+		if err := source.load(); err != nil {
+			return nil, err
+		}
+		checker.crl = source
+	}
+	return checker, nil
+}
+
+func (c *RevocationChecker) Check(ctx context.Context, leaf *x509.Certificate, issuer *x509.Certificate) ([]RevocationOutcome, bool) {
+	outcomes := make([]RevocationOutcome, 0, 2)
+	if c.crl != nil {
+		outcomes = append(outcomes, RevocationOutcome{Method: "crl", Result: c.crl.check(leaf, issuer)})
+	}
+	if c.ocsp && (len(outcomes) == 0 || outcomes[0].Result != RevocationRevoked) {
+		outcomes = append(outcomes, RevocationOutcome{Method: "ocsp", Result: c.checkOCSP(ctx, leaf, issuer)})
+	}
+	allowed := true
+	for _, outcome := range outcomes {
+		switch outcome.Result {
+		case RevocationRevoked:
+			allowed = false
+		case RevocationUnknown, RevocationError:
+			if !c.softFail {
+				allowed = false
+			}
+		}
+	}
+	return outcomes, allowed
+}
+
+func (c *RevocationChecker) checkOCSP(ctx context.Context, leaf *x509.Certificate, issuer *x509.Certificate) string {
+	if issuer == nil {
+		return RevocationUnknown
+	}
+	key := sha256.Sum256(leaf.Raw)
+	now := time.Now()
+	c.mu.Lock()
+	if entry, ok := c.cache[key]; ok && now.Before(entry.expires) {
+		c.mu.Unlock()
+		return entry.result
+	}
+	c.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	response, _, err := QueryOCSP(ctx, c.client, leaf, issuer)
+	if err != nil {
+		if errors.Is(err, ErrNoOCSPResponder) {
+			return RevocationUnknown
+		}
+		logger.Warn("ocsp_check", "ocsp_check_result", "error", "serial", leaf.SerialNumber.String(), "err", err)
+		return RevocationError
+	}
+	result := RevocationUnknown
+	switch response.Status {
+	case ocsp.Good:
+		result = RevocationGood
+	case ocsp.Revoked:
+		result = RevocationRevoked
+	}
+	expires := now.Add(c.cacheTTL)
+	if !response.NextUpdate.IsZero() && response.NextUpdate.Before(expires) {
+		expires = response.NextUpdate
+	}
+	c.mu.Lock()
+	if len(c.cache) >= maxOCSPCacheEntries {
+		for evict := range c.cache {
+			delete(c.cache, evict)
+			break
+		}
+	}
+	c.cache[key] = ocspCacheEntry{result: result, expires: expires}
+	c.mu.Unlock()
+	return result
+}
+
+func (s *crlSource) check(leaf *x509.Certificate, issuer *x509.Certificate) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if now.Sub(s.checkedAt) >= crlCheckInterval {
+		s.checkedAt = now
+		if info, err := os.Stat(s.path); err == nil && !info.ModTime().Equal(s.modTime) {
+			if err := s.loadLocked(); err != nil {
+				logger.Warn("crl_reload", "crl_reload_result", "error", "path", s.path, "err", err)
+			}
+		}
+	}
+	if issuer == nil || !bytes.Equal(s.list.RawIssuer, issuer.RawSubject) {
+		return RevocationUnknown
+	}
+	issuerKey := string(issuer.Raw)
+	verified, ok := s.verified[issuerKey]
+	if !ok {
+		verified = s.list.CheckSignatureFrom(issuer) == nil
+		s.verified[issuerKey] = verified
+	}
+	if !verified {
+		return RevocationError
+	}
+	if _, revoked := s.revoked[leaf.SerialNumber.String()]; revoked {
+		return RevocationRevoked
+	}
+	if !s.list.NextUpdate.IsZero() && now.After(s.list.NextUpdate) {
+		return RevocationUnknown
+	}
+	return RevocationGood
+}
+
+func (s *crlSource) load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checkedAt = time.Now()
+	return s.loadLocked()
+}
+
+func (s *crlSource) loadLocked() error {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return fmt.Errorf("crl_file: %w", err)
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("crl_file: %w", err)
+	}
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+	list, err := x509.ParseRevocationList(data)
+	if err != nil {
+		return fmt.Errorf("crl_file: %w", err)
+	}
+	revoked := make(map[string]struct{}, len(list.RevokedCertificateEntries))
+	for _, entry := range list.RevokedCertificateEntries {
+		revoked[entry.SerialNumber.String()] = struct{}{}
+	}
+	s.list = list
+	s.revoked = revoked
+	s.verified = make(map[string]bool)
+	s.modTime = info.ModTime()
+	return nil
+}
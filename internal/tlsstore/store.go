@@ -1,50 +1,187 @@
 package tlsstore
 
 import (
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/hex"
 	"errors"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
+type SelectionObserver func(certificate string, missReason string)
+
+type CertificateInfo struct {
+	ServerName  string
+	DNSNames    []string
+	NotBefore   time.Time
+	NotAfter    time.Time
+	CertFile    string
+	Fingerprint string
+}
+
 type Store struct {
+	state   atomic.Pointer[certState]
+	acme    *ACMEManager
+	stapler *OCSPStapler
+	observe SelectionObserver
+
+	reloadMu     sync.Mutex
+	specs        []CertSpec
+	clientCAFile string
+	stamps       map[string]time.Time
+}
+
+type certState struct {
 	certs       map[string]*tls.Certificate
 	defaultCert *tls.Certificate
+	defaultName string
+	files       map[string]string
 	clientCA    *x509.CertPool
 }
 
 func NewStore(certs map[string]*tls.Certificate, defaultCert *tls.Certificate, clientCA *x509.CertPool) *Store {
-	return &Store{certs: certs, defaultCert: defaultCert, clientCA: clientCA}
+	store := &Store{}
+	store.state.Store(&certState{certs: certs, defaultCert: defaultCert, clientCA: clientCA})
+	return store
+}
+
+func (s *Store) ACME() *ACMEManager {
+	if s == nil {
+		return nil
+	}
+	return s.acme
+}
+
+func (s *Store) SetOCSPStapler(stapler *OCSPStapler) {
+	s.stapler = stapler
+	s.primeStaples()
+}
+
+func (s *Store) SetSelectionObserver(observe SelectionObserver) {
+	s.observe = observe
+}
+
+func (s *Store) Certificates() []CertificateInfo {
+	if s == nil {
+		return nil
+	}
+	state := s.state.Load()
+	infos := make([]CertificateInfo, 0, len(state.certs))
+	for name, cert := range state.certs {
+		leaf := cert.Leaf
+		if leaf == nil && len(cert.Certificate) > 0 {
+			leaf, _ = x509.ParseCertificate(cert.Certificate[0])
+		}
+		if leaf == nil {
+			continue
+		}
+		fingerprint := sha256.Sum256(leaf.Raw)
+		infos = append(infos, CertificateInfo{
+			ServerName:  name,
+			DNSNames:    leaf.DNSNames,
+			NotBefore:   leaf.NotBefore,
+			NotAfter:    leaf.NotAfter,
+			CertFile:    state.files[name],
+			Fingerprint: hex.EncodeToString(fingerprint[:]),
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ServerName < infos[j].ServerName
+	})
+	return infos
+}
+
+func (s *Store) primeStaples() {
+	if s.stapler == nil {
+		return
+	}
+	for _, cert := range s.state.Load().certs {
+		s.stapler.Staple(cert)
+	}
+}
+
+func (s *Store) Reload(force bool) (bool, error) {
+	if s == nil || (len(s.specs) == 0 && s.clientCAFile == "") {
+		return false, nil
+	}
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	stamps := fileStamps(s.specs, s.clientCAFile)
+	if !force && sameStamps(stamps, s.stamps) {
+		return false, nil
+	}
+	state, err := loadState(s.specs, s.clientCAFile)
+	if err != nil {
+		return false, err
+	}
+	s.state.Store(state)
+	s.stamps = stamps
+	s.primeStaples()
+	return true, nil
 }
 
 func (s *Store) GetCertificate(chi *tls.ClientHelloInfo) (*tls.Certificate, error) {
 	if s == nil {
 		return nil, errors.New("tls store is nil")
 	}
+	if s.acme != nil && isACMEChallengeHello(chi) {
+		return s.acme.GetCertificate(chi)
+	}
+	state := s.state.Load()
+	missReason := "no_sni"
 	if chi != nil && chi.ServerName != "" {
-		if cert, ok := s.certs[strings.ToLower(chi.ServerName)]; ok {
-			return cert, nil
+		name := strings.ToLower(chi.ServerName)
+		if cert, ok := state.certs[name]; ok {
+			s.recordSelection(name, "")
+			return s.stapler.Staple(cert), nil
+		}
+		if s.acme.Manages(chi.ServerName) {
+			s.recordSelection("acme", "")
+			return s.acme.GetCertificate(chi)
 		}
+		missReason = "unknown_sni"
 	}
-	if s.defaultCert == nil {
+	s.recordSelection(state.defaultName, missReason)
+	if state.defaultCert == nil {
 		return nil, errors.New("default certificate missing")
 	}
-	return s.defaultCert, nil
+	return s.stapler.Staple(state.defaultCert), nil
+}
+
+func (s *Store) recordSelection(certificate string, missReason string) {
+	if s.observe != nil {
+		s.observe(certificate, missReason)
+	}
 }
 
 func (s *Store) VerifyClientCert(rawCerts [][]byte, _ [][]*x509.Certificate) error {
-	if s == nil || s.clientCA == nil {
-		return errors.New("client CA pool missing")
+	_, err := s.VerifyClientChain(rawCerts)
+	return err
+}
+
+func (s *Store) VerifyClientChain(rawCerts [][]byte) ([]*x509.Certificate, error) {
+	if s == nil {
+		return nil, errors.New("client CA pool missing")
+	}
+	clientCA := s.state.Load().clientCA
+	if clientCA == nil {
+		return nil, errors.New("client CA pool missing")
 	}
 	if len(rawCerts) == 0 {
-		return errors.New("client certificate missing")
+		return nil, errors.New("client certificate missing")
 	}
 
 	certs := make([]*x509.Certificate, len(rawCerts))
 	for i, raw := range rawCerts {
 		cert, err := x509.ParseCertificate(raw)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		certs[i] = cert
 	}
@@ -54,10 +191,25 @@ func (s *Store) VerifyClientCert(rawCerts [][]byte, _ [][]*x509.Certificate) err
 		intermediates.AddCert(cert)
 	}
 
-	_, err := certs[0].Verify(x509.VerifyOptions{
-		Roots:         s.clientCA,
+	chains, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         clientCA,
 		Intermediates: intermediates,
 		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
 	})
-	return err
+	if err != nil {
+		return nil, err
+	}
+	return chains[0], nil
+}
+
+func sameStamps(a map[string]time.Time, b map[string]time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, stamp := range a {
+		if prior, ok := b[path]; !ok || !prior.Equal(stamp) {
+			return false
+		}
+	}
+	return true
 }
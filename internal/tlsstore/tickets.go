@@ -0,0 +1,78 @@
+package tlsstore
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/binary"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var localTicketSeed = struct {
+	once sync.Once
+	seed []byte
+}{}
+
+type TicketKeySpec struct {
+	Seed     []byte
+	Interval time.Duration
+	Retained int
+}
+
+type TicketKeyRotator struct {
+	spec    TicketKeySpec
+	mu      sync.Mutex
+	applied atomic.Int64
+}
+
+func NewTicketKeyRotator(spec TicketKeySpec) *TicketKeyRotator {
+	if len(spec.Seed) == 0 {
+		localTicketSeed.once.Do(func() {
+			localTicketSeed.seed = make([]byte, 32)
+			_, _ = rand.Read(localTicketSeed.seed)
+		})
+		spec.Seed = localTicketSeed.seed
+	}
+	rotator := &TicketKeyRotator{spec: spec}
+	rotator.applied.Store(-1)
+	return rotator
+}
+
+func (r *TicketKeyRotator) Apply(config *tls.Config) {
+	if r == nil || config == nil {
+		return
+	}
+	epoch := time.Now().UnixNano() / int64(r.spec.Interval)
+	if r.applied.Load() == epoch {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.applied.Load() == epoch {
+		return
+	}
+	config.SetSessionTicketKeys(r.keys(epoch))
+	r.applied.Store(epoch)
+	logger.Debug("tls_ticket_keys_rotated", "epoch", epoch)
+}
+
+func (r *TicketKeyRotator) keys(epoch int64) [][32]byte {
+	keys := make([][32]byte, 0, r.spec.Retained+2)
+	keys = append(keys, r.key(epoch), r.key(epoch+1))
+	for i := 1; i <= r.spec.Retained; i++ {
+		keys = append(keys, r.key(epoch-int64(i)))
+	}
+	return keys
+}
+
+func (r *TicketKeyRotator) key(epoch int64) [32]byte {
+	mac := hmac.New(sha256.New, r.spec.Seed)
+	mac.Write([]byte("session-ticket|"))
+	_ = binary.Write(mac, binary.BigEndian, epoch)
+	var key [32]byte
+	copy(key[:], mac.Sum(nil))
+	return key
+}
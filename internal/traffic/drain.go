@@ -0,0 +1,77 @@
+package traffic
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	DrainModeImmediate = "immediate"
+	DrainModeGraceful  = "graceful"
+)
+
+type DrainObserver func(routeID string, remaining int)
+
+type cohortTracker struct {
+	mu         sync.Mutex
+	maxCohorts int
+	canary     map[string]struct{}
+	draining   map[string]struct{}
+	graceUntil time.Time
+}
+
+func newCohortTracker(maxCohorts int) *cohortTracker {
+	return &cohortTracker{
+		maxCohorts: maxCohorts,
+		canary:     make(map[string]struct{}),
+		draining:   make(map[string]struct{}),
+	}
+}
+
+func (c *cohortTracker) remember(key string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.canary[key]; ok || len(c.canary) >= c.maxCohorts {
+		return
+	}
+	c.canary[key] = struct{}{}
+}
+
+func (c *cohortTracker) begin(now time.Time, grace time.Duration) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.draining = c.canary
+	c.canary = make(map[string]struct{})
+	c.graceUntil = now.Add(grace)
+}
+
+func (c *cohortTracker) retains(key string, now time.Time) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !now.Before(c.graceUntil) {
+		return false
+	}
+	_, ok := c.draining[key]
+	return ok
+}
+
+func (c *cohortTracker) remaining(now time.Time) int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !now.Before(c.graceUntil) && len(c.draining) > 0 {
+		c.draining = make(map[string]struct{})
+	}
+	return len(c.draining)
+}
@@ -0,0 +1,67 @@
+package traffic
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+)
+
+const (
+	ForceAlways = "always"
+	ForceNever  = "never"
+)
+
+type ForceConfig struct {
+	Header       string
+	Cookie       string
+	CookieSecret []byte
+}
+
+type ForceMatcher struct {
+	header       string
+	cookie       string
+	cookieSecret []byte
+}
+
+func NewForceMatcher(cfg ForceConfig) *ForceMatcher {
+	if cfg.Header == "" && cfg.Cookie == "" {
+		return nil
+	}
+	return &ForceMatcher{header: cfg.Header, cookie: cfg.Cookie, cookieSecret: cfg.CookieSecret}
+}
+
+func (f *ForceMatcher) Match(r *http.Request) (Variant, bool) {
+	if f == nil || r == nil {
+		return "", false
+	}
+	if f.header != "" {
+		switch strings.ToLower(strings.TrimSpace(r.Header.Get(f.header))) {
+		case ForceAlways:
+			return VariantCanary, true
+		case ForceNever:
+			return VariantStable, true
+		}
+	}
+	if f.cookie != "" {
+		if cookie, err := r.Cookie(f.cookie); err == nil {
+			for _, variant := range []Variant{VariantCanary, VariantStable} {
+				if hmac.Equal([]byte(cookie.Value), []byte(SignForceCookie(f.cookieSecret, variant))) {
+					return variant, true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+func SignForceCookie(secret []byte, variant Variant) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("force:" + string(variant)))
+	return string(variant) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func forceSame(a ForceConfig, b ForceConfig) bool {
+	return a.Header == b.Header && a.Cookie == b.Cookie && hmac.Equal(a.CookieSecret, b.CookieSecret)
+}
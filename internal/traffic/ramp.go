@@ -0,0 +1,165 @@
+package traffic
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"modern_reverse_proxy/internal/logging"
+)
+
+var logger = logging.For("traffic")
+
+const (
+	RampBreachPause  = "pause"
+	RampBreachRevert = "revert"
+
+	RampStateRamping  = "ramping"
+	RampStatePaused   = "paused"
+	RampStateReverted = "reverted"
+	RampStateComplete = "complete"
+)
+
+type RampConfig struct {
+	Enabled      bool
+	Steps        []int
+	StepInterval time.Duration
+	MinRequests  int
+	MaxErrorRate float64
+	MaxLatency   time.Duration
+	OnBreach     string
+}
+
+type RampObserver func(routeID string, weight int, state string)
+
+type Ramp struct {
+	routeID   string
+	stats     *Stats
+	config    RampConfig
+	observer  RampObserver
+	mu        sync.Mutex
+	step      int
+	stepStart time.Time
+	state     string
+	stopCh    chan struct{}
+}
+
+func NewRamp(routeID string, stats *Stats, cfg RampConfig, observer RampObserver) *Ramp {
+	if stats == nil || len(cfg.Steps) == 0 {
+		return nil
+	}
+	ramp := &Ramp{
+		routeID:   routeID,
+		stats:     stats,
+		config:    cfg,
+		observer:  observer,
+		stepStart: time.Now(),
+		state:     RampStateRamping,
+		stopCh:    make(chan struct{}),
+	}
+	if len(cfg.Steps) == 1 {
+		ramp.state = RampStateComplete
+	}
+	ramp.notify()
+	go ramp.loop()
+	return ramp
+}
+
+func (r *Ramp) Weight() int {
+	if r == nil {
+		return 0
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.state == RampStateReverted {
+		return 0
+	}
+	return r.config.Steps[r.step]
+}
+
+func (r *Ramp) State() string {
+	if r == nil {
+		return ""
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state
+}
+
+func (r *Ramp) Stop() {
+	if r == nil {
+		return
+	}
+	select {
+	case <-r.stopCh:
+		return
+	default:
+		close(r.stopCh)
+	}
+}
+
+func (r *Ramp) loop() {
+	interval := r.stats.bucketDuration
+	if interval <= 0 || interval > r.config.StepInterval {
+		interval = r.config.StepInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.evaluate(time.Now())
+		case <-r.stopCh:
+			return
+		}
+	}
+}
+
+func (r *Ramp) evaluate(now time.Time) {
+	r.mu.Lock()
+	if r.state != RampStateRamping {
+		r.mu.Unlock()
+		return
+	}
+	if r.breached(now) {
+		r.state = RampStatePaused
+		if r.config.OnBreach == RampBreachRevert {
+			r.state = RampStateReverted
+		}
+	} else if now.Sub(r.stepStart) >= r.config.StepInterval {
+		r.step++
+		r.stepStart = now
+		if r.step == len(r.config.Steps)-1 {
+			r.state = RampStateComplete
+		}
+	} else {
+		r.mu.Unlock()
+		return
+	}
+	r.mu.Unlock()
+	r.notify()
+}
+
+func (r *Ramp) breached(now time.Time) bool {
+	totals := r.stats.WindowTotals(now)
+	if totals.CanaryReq == 0 || totals.CanaryReq < int64(r.config.MinRequests) {
+		return false
+	}
+	if r.config.MaxErrorRate > 0 && float64(totals.CanaryErr)/float64(totals.CanaryReq) > r.config.MaxErrorRate {
+		return true
+	}
+	return r.config.MaxLatency > 0 && totals.CanaryLatency/time.Duration(totals.CanaryReq) > r.config.MaxLatency
+}
+
+func (r *Ramp) notify() {
+	weight, state := r.Weight(), r.State()
+	logger.Info("traffic_ramp", "route", r.routeID, "weight", weight, "state", state)
+	if r.observer != nil {
+		r.observer(r.routeID, weight, state)
+	}
+}
+
+func rampSame(a RampConfig, b RampConfig) bool {
+	return a.Enabled == b.Enabled && slices.Equal(a.Steps, b.Steps) && a.StepInterval == b.StepInterval && a.MinRequests == b.MinRequests && a.MaxErrorRate == b.MaxErrorRate && a.MaxLatency == b.MaxLatency && a.OnBreach == b.OnBreach
+}
@@ -17,18 +17,22 @@ type Stats struct {
 }
 
 type bucket struct {
-	start     time.Time
-	stableReq int64
-	stableErr int64
-	canaryReq int64
-	canaryErr int64
+	start         time.Time
+	stableReq     int64
+	stableErr     int64
+	stableLatency time.Duration
+	canaryReq     int64
+	canaryErr     int64
+	canaryLatency time.Duration
 }
 
 type WindowTotals struct {
-	StableReq int64
-	StableErr int64
-	CanaryReq int64
-	CanaryErr int64
+	StableReq     int64
+	StableErr     int64
+	StableLatency time.Duration
+	CanaryReq     int64
+	CanaryErr     int64
+	CanaryLatency time.Duration
 }
 
 func NewStats(window time.Duration) *Stats {
@@ -50,7 +54,7 @@ func NewStats(window time.Duration) *Stats {
 	}
 }
 
-func (s *Stats) Record(variant Variant, status int, proxyError bool) {
+func (s *Stats) Record(variant Variant, status int, proxyError bool, latency time.Duration) {
 	if s == nil {
 		return
 	}
@@ -63,11 +67,13 @@ func (s *Stats) Record(variant Variant, status int, proxyError bool) {
 	switch variant {
 	case VariantCanary:
 		b.canaryReq++
+		b.canaryLatency += latency
 		if isError {
 			b.canaryErr++
 		}
 	default:
 		b.stableReq++
+		b.stableLatency += latency
 		if isError {
 			b.stableErr++
 		}
@@ -93,8 +99,10 @@ func (s *Stats) WindowTotals(now time.Time) WindowTotals {
 		}
 		totals.StableReq += b.stableReq
 		totals.StableErr += b.stableErr
+		totals.StableLatency += b.stableLatency
 		totals.CanaryReq += b.canaryReq
 		totals.CanaryErr += b.canaryErr
+		totals.CanaryLatency += b.canaryLatency
 	}
 	s.mu.Unlock()
 	return totals
@@ -132,28 +140,52 @@ type AutoDrainConfig struct {
 	MinRequests         int
 	ErrorRateMultiplier float64
 	Cooloff             time.Duration
+	Mode                string
+	GracePeriod         time.Duration
+	MaxCohorts          int
 }
 
 type AutoDrain struct {
-	stats        *Stats
-	config       AutoDrainConfig
-	drainedUntil atomic.Int64
-	stopCh       chan struct{}
+	stats         *Stats
+	config        AutoDrainConfig
+	drainedUntil  atomic.Int64
+	cohorts       *cohortTracker
+	observe       func(remaining int)
+	lastRemaining int
+	stopCh        chan struct{}
 }
 
-func NewAutoDrain(stats *Stats, cfg AutoDrainConfig) *AutoDrain {
+func NewAutoDrain(stats *Stats, cfg AutoDrainConfig, observe func(remaining int)) *AutoDrain {
 	if stats == nil {
 		return nil
 	}
 	controller := &AutoDrain{
-		stats:  stats,
-		config: cfg,
-		stopCh: make(chan struct{}),
+		stats:   stats,
+		config:  cfg,
+		observe: observe,
+		stopCh:  make(chan struct{}),
+	}
+	if cfg.Mode == DrainModeGraceful {
+		controller.cohorts = newCohortTracker(cfg.MaxCohorts)
 	}
 	go controller.loop()
 	return controller
 }
 
+func (a *AutoDrain) Remember(cohortKey string) {
+	if a == nil {
+		return
+	}
+	a.cohorts.remember(cohortKey)
+}
+
+func (a *AutoDrain) Retains(cohortKey string) bool {
+	if a == nil {
+		return false
+	}
+	return a.cohorts.retains(cohortKey, time.Now())
+}
+
 func (a *AutoDrain) Active() bool {
 	if a == nil {
 		return false
@@ -185,7 +217,9 @@ func (a *AutoDrain) loop() {
 	for {
 		select {
 		case <-ticker.C:
-			a.evaluate(time.Now())
+			now := time.Now()
+			a.evaluate(now)
+			a.report(now)
 		case <-a.stopCh:
 			return
 		}
@@ -217,10 +251,24 @@ func (a *AutoDrain) evaluate(now time.Time) {
 	}
 }
 
+func (a *AutoDrain) report(now time.Time) {
+	if a.cohorts == nil || a.observe == nil {
+		return
+	}
+	remaining := a.cohorts.remaining(now)
+	if remaining != a.lastRemaining {
+		a.lastRemaining = remaining
+		a.observe(remaining)
+	}
+}
+
 func (a *AutoDrain) drainUntil(now time.Time) {
 	if a.config.Cooloff <= 0 {
 		return
 	}
+	if !a.Active() {
+		a.cohorts.begin(now, a.config.GracePeriod)
+	}
 	until := now.Add(a.config.Cooloff).UnixNano()
 	for {
 		current := a.drainedUntil.Load()
@@ -13,8 +13,10 @@ type Config struct {
 	StableWeight int
 	CanaryWeight int
 	Cohort       CohortConfig
+	Force        ForceConfig
 	Overload     OverloadConfig
 	AutoDrain    AutoDrainConfig
+	Ramp         RampConfig
 }
 
 type CohortConfig struct {
@@ -32,9 +34,11 @@ type OverloadConfig struct {
 type Plan struct {
 	Split     Split
 	Cohort    *CohortExtractor
+	Force     *ForceMatcher
 	Overload  *OverloadLimiter
 	Stats     *Stats
 	AutoDrain *AutoDrain
+	Ramp      *Ramp
 }
 
 type PickMeta struct {
@@ -48,35 +52,62 @@ func (p *Plan) PickVariant(r *http.Request) (Variant, PickMeta) {
 	if p == nil {
 		return VariantStable, meta
 	}
-	split := p.Split
-	if p.AutoDrain != nil && p.AutoDrain.Active() {
-		meta.AutoDrainActive = true
-		split = Split{StableWeight: p.Split.StableWeight, CanaryWeight: 0}
+	if variant, ok := p.Force.Match(r); ok {
+		meta.CohortMode = "forced"
+		return variant, meta
 	}
+	split, drainActive := p.EffectiveSplit()
+	meta.AutoDrainActive = drainActive
 	if p.Cohort != nil {
 		key, ok := p.Cohort.Extract(r)
 		meta.CohortKeyPresent = ok
 		if ok {
 			meta.CohortMode = "sticky"
-			return split.ChooseDeterministic(key), meta
+			if meta.AutoDrainActive && p.AutoDrain.Retains(key) {
+				meta.CohortMode = "draining"
+				return VariantCanary, meta
+			}
+			variant := split.ChooseDeterministic(key)
+			if variant == VariantCanary {
+				p.AutoDrain.Remember(key)
+			}
+			return variant, meta
 		}
 	}
 	return split.ChooseRandom(), meta
 }
 
+func (p *Plan) EffectiveSplit() (Split, bool) {
+	if p == nil {
+		return Split{StableWeight: 100}, false
+	}
+	split := p.Split
+	if p.Ramp != nil {
+		weight := p.Ramp.Weight()
+		split = Split{StableWeight: 100 - weight, CanaryWeight: weight}
+	}
+	if p.AutoDrain != nil && p.AutoDrain.Active() {
+		return Split{StableWeight: split.StableWeight, CanaryWeight: 0}, true
+	}
+	return split, false
+}
+
 func (p *Plan) Stop() {
-	if p == nil || p.AutoDrain == nil {
+	if p == nil {
 		return
 	}
 	p.AutoDrain.Stop()
+	p.Ramp.Stop()
 }
 
 type Registry struct {
-	mu           sync.Mutex
-	routes       map[string]*entry
-	reapInterval time.Duration
-	ttl          time.Duration
-	stopCh       chan struct{}
+	mu            sync.Mutex
+	routes        map[string]*entry
+	reapInterval  time.Duration
+	ttl           time.Duration
+	stopCh        chan struct{}
+	rampObserver  RampObserver
+	drainObserver DrainObserver
 }
 
 type entry struct {
@@ -112,7 +143,7 @@ func (r *Registry) Plan(routeID string, cfg Config) (*Plan, error) {
 		return nil, errors.New("route id is empty")
 	}
 	if r == nil {
-		return buildPlan(cfg)
+		return buildPlan(routeID, cfg, nil, nil)
 	}
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -125,7 +156,7 @@ func (r *Registry) Plan(routeID string, cfg Config) (*Plan, error) {
 	if current != nil && current.plan != nil {
 		current.plan.Stop()
 	}
-	plan, err := buildPlan(cfg)
+	plan, err := buildPlan(routeID, cfg, r.rampObserver, r.drainObserver)
 	if err != nil {
 		return nil, err
 	}
@@ -133,6 +164,24 @@ func (r *Registry) Plan(routeID string, cfg Config) (*Plan, error) {
 	return plan, nil
 }
 
+func (r *Registry) SetRampObserver(observer RampObserver) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.rampObserver = observer
+	r.mu.Unlock()
+}
+
+func (r *Registry) SetDrainObserver(observer DrainObserver) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.drainObserver = observer
+	r.mu.Unlock()
+}
+
 func (r *Registry) Close() {
 	if r == nil {
 		return
@@ -191,7 +240,7 @@ func (r *Registry) reapOnce() {
 	r.mu.Unlock()
 }
 
-func buildPlan(cfg Config) (*Plan, error) {
+func buildPlan(routeID string, cfg Config, rampObserver RampObserver, drainObserver DrainObserver) (*Plan, error) {
 	if !cfg.Enabled {
 		return nil, nil
 	}
@@ -203,12 +252,22 @@ func buildPlan(cfg Config) (*Plan, error) {
 		}
 		plan.Cohort = extractor
 	}
+	plan.Force = NewForceMatcher(cfg.Force)
 	if cfg.Overload.Enabled {
 		plan.Overload = NewOverloadLimiter(cfg.Overload.MaxInflight, cfg.Overload.MaxQueue, cfg.Overload.QueueTimeout)
 	}
 	plan.Stats = NewStats(cfg.AutoDrain.Window)
 	if cfg.AutoDrain.Enabled {
-		plan.AutoDrain = NewAutoDrain(plan.Stats, cfg.AutoDrain)
+		var observe func(remaining int)
+		if drainObserver != nil {
+			observe = func(remaining int) {
+				drainObserver(routeID, remaining)
+			}
+		}
+		plan.AutoDrain = NewAutoDrain(plan.Stats, cfg.AutoDrain, observe)
+	}
+	if cfg.Ramp.Enabled {
+		plan.Ramp = NewRamp(routeID, plan.Stats, cfg.Ramp, rampObserver)
 	}
 	return plan, nil
 }
@@ -218,8 +277,10 @@ func sameConfig(a Config, b Config) bool {
 		a.StableWeight == b.StableWeight &&
 		a.CanaryWeight == b.CanaryWeight &&
 		cohortSame(a.Cohort, b.Cohort) &&
+		forceSame(a.Force, b.Force) &&
 		overloadSame(a.Overload, b.Overload) &&
-		autoDrainSame(a.AutoDrain, b.AutoDrain)
+		autoDrainSame(a.AutoDrain, b.AutoDrain) &&
+		rampSame(a.Ramp, b.Ramp)
 }
 
 func cohortSame(a CohortConfig, b CohortConfig) bool {
@@ -231,5 +292,6 @@ func overloadSame(a OverloadConfig, b OverloadConfig) bool {
 }
 
 func autoDrainSame(a AutoDrainConfig, b AutoDrainConfig) bool {
-	return a.Enabled == b.Enabled && a.Window == b.Window && a.MinRequests == b.MinRequests && a.ErrorRateMultiplier == b.ErrorRateMultiplier && a.Cooloff == b.Cooloff
+	return a.Enabled == b.Enabled && a.Window == b.Window && a.MinRequests == b.MinRequests && a.ErrorRateMultiplier == b.ErrorRateMultiplier && a.Cooloff == b.Cooloff &&
+		a.Mode == b.Mode && a.GracePeriod == b.GracePeriod && a.MaxCohorts == b.MaxCohorts
 }
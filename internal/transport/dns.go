@@ -0,0 +1,300 @@
+package transport
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"modern_reverse_proxy/internal/obs"
+)
+
+const (
+	DNSCacheHit         = "hit"
+	DNSCacheMiss        = "miss"
+	DNSCacheNegativeHit = "negative_hit"
+	DNSCacheStale       = "stale"
+
+	maxDNSMessageBytes = 4096
+)
+
+var errNoAddresses = errors.New("no addresses")
+
+type DNSOptions struct {
+	Enabled     bool
+	Resolver    string
+	MinTTL      time.Duration
+	MaxTTL      time.Duration
+	NegativeTTL time.Duration
+	Timeout     time.Duration
+}
+
+type dnsDialer struct {
+	pool    string
+	opts    DNSOptions
+	dialer  *net.Dialer
+	mu      sync.Mutex
+	entries map[string]*dnsEntry
+}
+
+type dnsEntry struct {
+	ips     []net.IP
+	err     error
+	expires time.Time
+	done    chan struct{}
+}
+
+func newDNSDialer(pool string, opts DNSOptions, dialTimeout time.Duration) *dnsDialer {
+	return &dnsDialer{
+		pool:    pool,
+		opts:    opts,
+		dialer:  &net.Dialer{Timeout: dialTimeout},
+		entries: make(map[string]*dnsEntry),
+	}
+}
+
+func (d *dnsDialer) DialContext(ctx context.Context, network string, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || net.ParseIP(host) != nil {
+		return d.dialer.DialContext(ctx, network, addr)
+	}
+	ips, err := d.lookup(ctx, host)
+	if err != nil {
+		return nil, &net.OpError{Op: "dial", Net: network, Err: &net.DNSError{Err: err.Error(), Name: host}}
+	}
+	var firstErr error
+	for _, ip := range ips {
+		conn, err := d.dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, firstErr
+}
+
+func (d *dnsDialer) lookup(ctx context.Context, host string) ([]net.IP, error) {
+	host = strings.ToLower(host)
+	now := time.Now()
+
+	d.mu.Lock()
+	entry := d.entries[host]
+	if entry != nil && entry.done == nil && now.Before(entry.expires) {
+		d.mu.Unlock()
+		if entry.err != nil {
+			recordDNSCache(d.pool, DNSCacheNegativeHit)
+			return nil, entry.err
+		}
+		recordDNSCache(d.pool, DNSCacheHit)
+		return entry.ips, nil
+	}
+	if entry != nil && entry.done != nil {
+		done := entry.done
+		d.mu.Unlock()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		recordDNSCache(d.pool, DNSCacheHit)
+		return entry.ips, entry.err
+	}
+	var stale []net.IP
+	if entry != nil {
+		stale = entry.ips
+	}
+	pending := &dnsEntry{done: make(chan struct{})}
+	d.entries[host] = pending
+	d.mu.Unlock()
+
+	recordDNSCache(d.pool, DNSCacheMiss)
+	lookupCtx, cancel := context.WithTimeout(context.Background(), d.opts.Timeout)
+	start := time.Now()
+	ips, ttl, err := d.resolve(lookupCtx, host)
+	cancel()
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	if metrics := obs.DefaultMetrics(); metrics != nil {
+		metrics.ObserveDNSResolution(d.pool, result, time.Since(start))
+	}
+
+	d.mu.Lock()
+	switch {
+	case err == nil:
+		pending.ips, pending.expires = ips, time.Now().Add(d.clampTTL(ttl))
+	case len(stale) > 0:
+		pending.ips, pending.expires = stale, time.Now().Add(d.opts.NegativeTTL)
+		recordDNSCache(d.pool, DNSCacheStale)
+	default:
+		pending.err, pending.expires = err, time.Now().Add(d.opts.NegativeTTL)
+	}
+	done := pending.done
+	pending.done = nil
+	d.mu.Unlock()
+	close(done)
+	return pending.ips, pending.err
+}
+
+func (d *dnsDialer) clampTTL(ttl time.Duration) time.Duration {
+	if ttl < d.opts.MinTTL {
+		return d.opts.MinTTL
+	}
+	if ttl > d.opts.MaxTTL {
+		return d.opts.MaxTTL
+	}
+	return ttl
+}
+
+func (d *dnsDialer) resolve(ctx context.Context, host string) ([]net.IP, time.Duration, error) {
+	if d.opts.Resolver == "" {
+		addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, 0, err
+		}
+		ips := make([]net.IP, 0, len(addrs))
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+		return ips, 0, nil
+	}
+
+	var ips []net.IP
+	var ttl uint32
+	for _, qtype := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+		answers, answerTTL, err := d.query(ctx, host, qtype)
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(answers) > 0 && (len(ips) == 0 || answerTTL < ttl) {
+			ttl = answerTTL
+		}
+		ips = append(ips, answers...)
+	}
+	if len(ips) == 0 {
+		return nil, 0, errNoAddresses
+	}
+	return ips, time.Duration(ttl) * time.Second, nil
+}
+
+func (d *dnsDialer) query(ctx context.Context, host string, qtype dnsmessage.Type) ([]net.IP, uint32, error) {
+	name, err := dnsmessage.NewName(strings.TrimSuffix(host, ".") + ".")
+	if err != nil {
+		return nil, 0, err
+	}
+	id := uint16(rand.Uint32())
+	packed, err := (&dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: name, Type: qtype, Class: dnsmessage.ClassINET}},
+	}).Pack()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp, err := d.exchange(ctx, "udp", id, packed)
+	if err == nil && resp.Truncated {
+		resp, err = d.exchange(ctx, "tcp", id, packed)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	switch resp.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeNameError:
+		return nil, 0, errors.New("no such host")
+	default:
+		return nil, 0, fmt.Errorf("resolver returned %s", resp.RCode)
+	}
+
+	var ips []net.IP
+	var ttl uint32
+	for _, answer := range resp.Answers {
+		var ip net.IP
+		switch body := answer.Body.(type) {
+		case *dnsmessage.AResource:
+			ip = net.IP(body.A[:])
+		case *dnsmessage.AAAAResource:
+			ip = net.IP(body.AAAA[:])
+		default:
+			continue
+		}
+		if len(ips) == 0 || answer.Header.TTL < ttl {
+			ttl = answer.Header.TTL
+		}
+		ips = append(ips, ip)
+	}
+	return ips, ttl, nil
+}
+
+func (d *dnsDialer) exchange(ctx context.Context, network string, id uint16, packed []byte) (*dnsmessage.Message, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, d.opts.Resolver)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if network == "tcp" {
+		framed := make([]byte, 2+len(packed))
+		binary.BigEndian.PutUint16(framed, uint16(len(packed)))
+		copy(framed[2:], packed)
+		if _, err := conn.Write(framed); err != nil {
+			return nil, err
+		}
+		var length [2]byte
+		if _, err := io.ReadFull(conn, length[:]); err != nil {
+			return nil, err
+		}
+		buf := make([]byte, binary.BigEndian.Uint16(length[:]))
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return nil, err
+		}
+		var resp dnsmessage.Message
+		if err := resp.Unpack(buf); err != nil {
+			return nil, err
+		}
+		if resp.ID != id {
+			return nil, errors.New("resolver response id mismatch")
+		}
+		return &resp, nil
+	}
+
+	if _, err := conn.Write(packed); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, maxDNSMessageBytes)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		var resp dnsmessage.Message
+		if err := resp.Unpack(buf[:n]); err != nil || resp.ID != id || !resp.Response {
+			continue
+		}
+		return &resp, nil
+	}
+}
+
+func recordDNSCache(pool string, result string) {
+	if metrics := obs.DefaultMetrics(); metrics != nil {
+		metrics.RecordDNSCache(pool, result)
+	}
+}
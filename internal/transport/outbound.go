@@ -0,0 +1,152 @@
+package transport
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+type ProxyOptions struct {
+	URL      string
+	Username string
+	Password string
+	NoProxy  string
+}
+
+type dialContextFunc func(ctx context.Context, network string, addr string) (net.Conn, error)
+
+type outboundDialer struct {
+	proxyURL *url.URL
+	username string
+	password string
+	noProxy  []string
+	dialer   *net.Dialer
+	direct   dialContextFunc
+}
+
+func newOutboundDialer(opts ProxyOptions, dialTimeout time.Duration, direct dialContextFunc) (*outboundDialer, error) {
+	proxyURL, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, err
+	}
+	d := &outboundDialer{
+		proxyURL: proxyURL,
+		username: opts.Username,
+		password: opts.Password,
+		dialer:   &net.Dialer{Timeout: dialTimeout},
+		direct:   direct,
+	}
+	if opts.NoProxy != "" {
+		d.noProxy = strings.Split(opts.NoProxy, ",")
+	}
+	return d, nil
+}
+
+func (d *outboundDialer) DialContext(ctx context.Context, network string, addr string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if matchNoProxy(d.noProxy, host) {
+		return d.direct(ctx, network, addr)
+	}
+	switch d.proxyURL.Scheme {
+	case "socks5", "socks5h":
+		var auth *proxy.Auth
+		if d.username != "" {
+			auth = &proxy.Auth{User: d.username, Password: d.password}
+		}
+		socks, err := proxy.SOCKS5("tcp", d.proxyURL.Host, auth, d.dialer)
+		if err != nil {
+			return nil, err
+		}
+		return socks.(proxy.ContextDialer).DialContext(ctx, network, addr)
+	default:
+		return d.dialConnect(ctx, addr)
+	}
+}
+
+func (d *outboundDialer) dialConnect(ctx context.Context, addr string) (net.Conn, error) {
+	conn, err := d.dialer.DialContext(ctx, "tcp", d.proxyURL.Host)
+	if err != nil {
+		return nil, fmt.Errorf("dial outbound proxy: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	if d.proxyURL.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: d.proxyURL.Hostname(), MinVersion: tls.VersionTLS12})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("outbound proxy tls: %w", err)
+		}
+		conn = tlsConn
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if d.username != "" {
+		credentials := base64.StdEncoding.EncodeToString([]byte(d.username + ":" + d.password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("outbound proxy connect: %w", err)
+	}
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("outbound proxy connect: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("outbound proxy connect to %s: %s", addr, resp.Status)
+	}
+	if reader.Buffered() > 0 {
+		conn.Close()
+		return nil, fmt.Errorf("outbound proxy connect to %s: unexpected data after response", addr)
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+func matchNoProxy(patterns []string, host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	ip := net.ParseIP(host)
+	for _, pattern := range patterns {
+		switch {
+		case pattern == "*":
+			return true
+		case strings.Contains(pattern, "/"):
+			if _, network, err := net.ParseCIDR(pattern); err == nil && ip != nil && network.Contains(ip) {
+				return true
+			}
+		case strings.HasPrefix(pattern, "*."):
+			if strings.HasSuffix(host, pattern[1:]) {
+				return true
+			}
+		case strings.HasPrefix(pattern, "."):
+			if strings.HasSuffix(host, pattern) || host == pattern[1:] {
+				return true
+			}
+		case host == pattern:
+			return true
+		}
+	}
+	return false
+}
@@ -1,12 +1,15 @@
 package transport
 
 import (
-	"log"
 	"net/http"
 	"sync"
 	"time"
+
+	"modern_reverse_proxy/internal/logging"
 )
 
+var logger = logging.For("transport")
+
 const (
 	defaultTransportTTL          = 10 * time.Minute
 	defaultTransportReapInterval = time.Minute
@@ -69,7 +72,7 @@ func (r *Registry) Get(poolKey string) *http.Transport {
 			lastUsed:       time.Now(),
 			lastReconciled: time.Now(),
 		}
-		log.Printf("transport registry missing pool %s; created default transport", poolKey)
+		logger.Warn("transport_default_created", "pool", poolKey)
 		return transport
 	}
 
@@ -91,7 +94,7 @@ func (r *Registry) Reconcile(poolKey string, _ []string, opts Options) *http.Tra
 	r.mu.Lock()
 	current := r.transports[poolKey]
 	if current == nil {
-		transport := NewTransport(opts)
+		transport := newPoolTransport(poolKey, opts)
 		r.transports[poolKey] = &transportEntry{
 			transport:      transport,
 			opts:           opts,
@@ -104,7 +107,7 @@ func (r *Registry) Reconcile(poolKey string, _ []string, opts Options) *http.Tra
 
 	if !optionsEqual(current.opts, opts) {
 		old = current.transport
-		current.transport = NewTransport(opts)
+		current.transport = newPoolTransport(poolKey, opts)
 		current.opts = opts
 	}
 	current.lastReconciled = time.Now()
@@ -119,6 +122,19 @@ func (r *Registry) Reconcile(poolKey string, _ []string, opts Options) *http.Tra
 	return transport
 }
 
+func (r *Registry) Options(poolKey string) (Options, bool) {
+	if r == nil || poolKey == "" {
+		return Options{}, false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current := r.transports[poolKey]
+	if current == nil || current.draining {
+		return Options{}, false
+	}
+	return current.opts, true
+}
+
 func (r *Registry) Remove(poolKey string) {
 	if r == nil || poolKey == "" {
 		return
@@ -290,6 +306,12 @@ func mergeOptions(defaults Options, override Options) Options {
 	if override.MaxConnsPerHost >= 0 {
 		defaults.MaxConnsPerHost = override.MaxConnsPerHost
 	}
+	defaults.WarmupConnections = override.WarmupConnections
+	defaults.WarmupPath = override.WarmupPath
+	defaults.WarmupTimeout = override.WarmupTimeout
+	defaults.DNS = override.DNS
+	defaults.TLS = override.TLS
+	defaults.Proxy = override.Proxy
 	return defaults
 }
 
@@ -301,5 +323,8 @@ func optionsEqual(a Options, b Options) bool {
 		a.IdleConnTimeout == b.IdleConnTimeout &&
 		a.MaxIdleConns == b.MaxIdleConns &&
 		a.MaxIdleConnsPerHost == b.MaxIdleConnsPerHost &&
-		a.MaxConnsPerHost == b.MaxConnsPerHost
+		a.MaxConnsPerHost == b.MaxConnsPerHost &&
+		a.DNS == b.DNS &&
+		a.TLS == b.TLS &&
+		a.Proxy == b.Proxy
 }
@@ -1,8 +1,11 @@
 package transport
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"net"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -25,6 +28,27 @@ type Options struct {
 	MaxIdleConns          int
 	MaxIdleConnsPerHost   int
 	MaxConnsPerHost       int
+	WarmupConnections     int
+	WarmupPath            string
+	WarmupTimeout         time.Duration
+	DNS                   DNSOptions
+	TLS                   TLSOptions
+	Proxy                 ProxyOptions
+}
+
+type TLSOptions struct {
+	Enabled            bool
+	ServerName         string
+	ALPN               string
+	CAPEM              string
+	InsecureSkipVerify bool
+}
+
+func (o Options) Scheme() string {
+	if o.TLS.Enabled {
+		return "https"
+	}
+	return "http"
 }
 
 func DefaultOptions() Options {
@@ -43,7 +67,7 @@ func NewTransport(opts Options) *http.Transport {
 	opts = normalizeOptions(opts)
 
 	dialer := &net.Dialer{Timeout: opts.DialTimeout}
-	return &http.Transport{
+	transport := &http.Transport{
 		Proxy:                 http.ProxyFromEnvironment,
 		DialContext:           dialer.DialContext,
 		TLSHandshakeTimeout:   opts.TLSHandshakeTimeout,
@@ -55,6 +79,56 @@ func NewTransport(opts Options) *http.Transport {
 		MaxConnsPerHost:       opts.MaxConnsPerHost,
 		ForceAttemptHTTP2:     true,
 	}
+	if opts.TLS.Enabled {
+		transport.TLSClientConfig = upstreamTLSConfig(opts.TLS)
+		if protos := transport.TLSClientConfig.NextProtos; len(protos) > 0 && !containsString(protos, "h2") {
+			transport.ForceAttemptHTTP2 = false
+		}
+	}
+	return transport
+}
+
+func upstreamTLSConfig(opts TLSOptions) *tls.Config {
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         opts.ServerName,
+		InsecureSkipVerify: opts.InsecureSkipVerify,
+	}
+	if opts.ALPN != "" {
+		cfg.NextProtos = strings.Split(opts.ALPN, ",")
+	}
+	if opts.CAPEM != "" {
+		roots := x509.NewCertPool()
+		roots.AppendCertsFromPEM([]byte(opts.CAPEM))
+		cfg.RootCAs = roots
+	}
+	return cfg
+}
+
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
+
+func newPoolTransport(poolKey string, opts Options) *http.Transport {
+	transport := NewTransport(opts)
+	if opts.DNS.Enabled {
+		transport.DialContext = newDNSDialer(poolKey, opts.DNS, normalizeOptions(opts).DialTimeout).DialContext
+	}
+	if opts.Proxy.URL != "" {
+		outbound, err := newOutboundDialer(opts.Proxy, normalizeOptions(opts).DialTimeout, transport.DialContext)
+		if err != nil {
+			logger.Warn("outbound_proxy_invalid", "pool", poolKey, "error", err)
+			return transport
+		}
+		transport.Proxy = nil
+		transport.DialContext = outbound.DialContext
+	}
+	return transport
 }
 
 func normalizeOptions(opts Options) Options {
@@ -0,0 +1,230 @@
+package adminclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	requestIDHeader      = "X-Request-Id"
+	maxErrorBodyBytes    = 64 << 10
+	defaultClientTimeout = 30 * time.Second
+)
+
+type Config struct {
+	BaseURL    string
+	Token      string
+	TLSConfig  *tls.Config
+	HTTPClient *http.Client
+}
+
+type Client struct {
+	baseURL *url.URL
+	token   string
+	http    *http.Client
+}
+
+type APIError struct {
+	StatusCode int
+	Message    string
+	RequestID  string
+}
+
+func (e *APIError) Error() string {
+	if e.RequestID != "" {
+		return fmt.Sprintf("admin api: %d %s (request %s)", e.StatusCode, e.Message, e.RequestID)
+	}
+	return fmt.Sprintf("admin api: %d %s", e.StatusCode, e.Message)
+}
+
+type ValidateResult struct {
+	OK       bool            `json:"ok"`
+	Warnings []string        `json:"warnings,omitempty"`
+	Impact   json.RawMessage `json:"impact,omitempty"`
+}
+
+type ApplyResult struct {
+	Applied  bool     `json:"applied"`
+	Version  string   `json:"version"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+type BundleMeta struct {
+	Version   string `json:"version"`
+	CreatedAt string `json:"created_at"`
+	Source    string `json:"source"`
+	Notes     string `json:"notes,omitempty"`
+	Label     string `json:"label,omitempty"`
+	GitSHA    string `json:"git_sha,omitempty"`
+	Author    string `json:"author,omitempty"`
+	KeyID     string `json:"key_id,omitempty"`
+}
+
+type Bundle struct {
+	Meta           BundleMeta `json:"meta"`
+	ConfigBytesB64 string     `json:"config_bytes_b64"`
+	ConfigSHA256   string     `json:"config_sha256"`
+	SignatureB64   string     `json:"signature_b64"`
+}
+
+type RollbackResult struct {
+	RolledBack bool   `json:"rolled_back"`
+	Version    string `json:"version"`
+}
+
+type Snapshot struct {
+	Version        string                     `json:"version"`
+	CreatedAt      time.Time                  `json:"created_at"`
+	Source         string                     `json:"source"`
+	RouteCount     int                        `json:"route_count"`
+	PoolCount      int                        `json:"pool_count"`
+	Provenance     json.RawMessage            `json:"provenance,omitempty"`
+	DisabledRoutes map[string]json.RawMessage `json:"disabled_routes,omitempty"`
+}
+
+type Health struct {
+	Version string       `json:"version"`
+	Pools   []PoolHealth `json:"pools"`
+}
+
+type PoolHealth struct {
+	Name      string           `json:"name"`
+	Healthy   int              `json:"healthy"`
+	Total     int              `json:"total"`
+	Endpoints []EndpointHealth `json:"endpoints"`
+}
+
+type EndpointHealth struct {
+	Addr                       string          `json:"addr"`
+	State                      string          `json:"state"`
+	Ejected                    bool            `json:"ejected"`
+	EjectUntil                 *time.Time      `json:"eject_until,omitempty"`
+	Inflight                   int64           `json:"inflight"`
+	ConsecutiveActiveFailures  int             `json:"consecutive_active_failures"`
+	ConsecutivePassiveFailures int             `json:"consecutive_passive_failures"`
+	ConsecutiveStatusFailures  int             `json:"consecutive_status_failures"`
+	Override                   string          `json:"override,omitempty"`
+	Outlier                    json.RawMessage `json:"outlier,omitempty"`
+}
+
+func New(cfg Config) (*Client, error) {
+	base, err := url.Parse(strings.TrimRight(strings.TrimSpace(cfg.BaseURL), "/"))
+	if err != nil {
+		return nil, fmt.Errorf("admin base url: %w", err)
+	}
+	if base.Scheme != "http" && base.Scheme != "https" || base.Host == "" {
+		return nil, errors.New("admin base url must be an absolute http or https url")
+	}
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = cfg.TLSConfig
+		httpClient = &http.Client{Timeout: defaultClientTimeout, Transport: transport}
+	}
+	return &Client{baseURL: base, token: cfg.Token, http: httpClient}, nil
+}
+
+func (c *Client) Validate(ctx context.Context, config []byte) (*ValidateResult, error) {
+	var result ValidateResult
+	if err := c.do(ctx, http.MethodPost, "/admin/validate", config, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) Apply(ctx context.Context, config []byte) (*ApplyResult, error) {
+	var result ApplyResult
+	if err := c.do(ctx, http.MethodPost, "/admin/config", config, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) ApplyBundle(ctx context.Context, bundle Bundle) (*ApplyResult, error) {
+	body, err := json.Marshal(bundle)
+	if err != nil {
+		return nil, err
+	}
+	var result ApplyResult
+	if err := c.do(ctx, http.MethodPost, "/admin/bundle", body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) Rollback(ctx context.Context, version string) (*RollbackResult, error) {
+	body, err := json.Marshal(map[string]string{"version": version})
+	if err != nil {
+		return nil, err
+	}
+	var result RollbackResult
+	if err := c.do(ctx, http.MethodPost, "/admin/rollback", body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) Snapshot(ctx context.Context) (*Snapshot, error) {
+	var result Snapshot
+	if err := c.do(ctx, http.MethodGet, "/admin/snapshot", nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) Health(ctx context.Context) (*Health, error) {
+	var result Health
+	if err := c.do(ctx, http.MethodGet, "/admin/health", nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) do(ctx context.Context, method string, path string, body []byte, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL.String()+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &APIError{StatusCode: resp.StatusCode, RequestID: resp.Header.Get(requestIDHeader)}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+		var payload struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &payload) == nil && payload.Error != "" {
+			apiErr.Message = payload.Error
+		} else {
+			apiErr.Message = strings.TrimSpace(string(data))
+		}
+		return apiErr
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode %s response: %w", path, err)
+	}
+	return nil
+}